@@ -0,0 +1,94 @@
+package btree
+
+// ==================================
+// Arena: 노드를 덩어리로 할당하기
+// ==================================
+// 노드를 하나씩 new 하면 노드 구조체, keys 배열, children 배열이 힙 여기저기에 흩어진다.
+// 키가 수백만 개인 트리를 한꺼번에 적재하거나 다시 만들 때는 arena 를 켜서
+// 노드 arenaChunk 개와 그 키 / 값 / 자식 배열을 큰 슬라이스 네 개에서 잘라 쓴다.
+// - 이웃한 노드가 메모리에서도 이웃하므로 포인터를 따라갈 때 캐시 미스가 준다.
+// - GC 가 추적할 객체 수가 노드 수가 아니라 덩어리 수에 비례한다.
+// - 노드를 하나씩 돌려주지 않는다. Reset 으로 트리를 비우면 덩어리째 버린다.
+// 덩어리 안의 노드 하나라도 살아 있으면 덩어리 전체가 남으므로, 지우기가 잦은 트리에는 맞지 않는다.
+
+// arena 가 한 번에 만드는 노드 수
+const arenaChunk = 1024
+
+// Arena 는 최소 차수 t 인 노드를 덩어리에서 잘라 준다. nil 이면 노드를 하나씩 힙에 만든다.
+type Arena struct {
+	t        int
+	nodes    []BTreeNode  // 현재 덩어리에서 아직 안 쓴 노드
+	keys     []int        // 노드마다 2t-1 칸씩
+	values   [][]byte     // 노드마다 2t-1 칸씩
+	children []*BTreeNode // 내부 노드마다 2t 칸씩
+	chunks   int
+}
+
+func newArena(t int) *Arena {
+	return &Arena{t: t}
+}
+
+// newNode 는 newNode(t, isLeaf) 와 같은 노드를 덩어리에서 잘라 만든다.
+// keys / values / children 은 용량을 정확히 2t-1 / 2t-1 / 2t 로 잘라 두므로 append 가 옆 노드의 칸을 덮지 않는다.
+func (a *Arena) newNode(t int, isLeaf bool) *BTreeNode {
+	if a == nil {
+		return newNode(t, isLeaf)
+	}
+	maxKeys := 2*a.t - 1
+	if len(a.nodes) == 0 {
+		a.nodes = make([]BTreeNode, arenaChunk)
+		a.chunks++
+	}
+	x := &a.nodes[0]
+	a.nodes = a.nodes[1:]
+	x.isLeaf = isLeaf
+
+	if len(a.keys) < maxKeys {
+		a.keys = make([]int, arenaChunk*maxKeys)
+	}
+	x.keys = a.keys[:0:maxKeys]
+	a.keys = a.keys[maxKeys:]
+
+	if len(a.values) < maxKeys {
+		a.values = make([][]byte, arenaChunk*maxKeys)
+	}
+	x.values = a.values[:0:maxKeys]
+	a.values = a.values[maxKeys:]
+
+	if !isLeaf {
+		if len(a.children) < maxKeys+1 {
+			// 내부 노드는 리프보다 훨씬 적으므로 덩어리를 작게 잡는다.
+			a.children = make([]*BTreeNode, arenaChunk/8*(maxKeys+1))
+		}
+		x.children = a.children[: 0 : maxKeys+1]
+		a.children = a.children[maxKeys+1:]
+	}
+	return x
+}
+
+// SetArena 는 켜면 이후에 만드는 노드를 arena 에서 잘라 쓴다. 대량 적재나 재구성 전에 켠다.
+// 끄면 이후 노드는 다시 하나씩 만든다. 이미 arena 에서 만든 노드는 그대로 쓴다.
+func (b *BTree) SetArena(on bool) {
+	switch {
+	case on && b.arena == nil:
+		b.arena = newArena(b.t)
+	case !on:
+		b.arena = nil
+	}
+}
+
+// ArenaChunks 는 지금 쓰고 있는 arena 가 만든 노드 덩어리 수. arena 를 쓰지 않으면 0.
+func (b *BTree) ArenaChunks() int {
+	if b.arena == nil {
+		return 0
+	}
+	return b.arena.chunks
+}
+
+// Reset 은 트리를 비운다. arena 를 쓰고 있었다면 새 arena 로 바꿔서 이전 노드를 덩어리째 버린다.
+func (b *BTree) Reset() {
+	b.root = nil
+	if b.arena != nil {
+		b.arena = newArena(b.t)
+	}
+}
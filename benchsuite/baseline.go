@@ -0,0 +1,179 @@
+package benchsuite
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// ==================================
+// baseline 비교 (CI 용)
+// ==================================
+// -save-baseline 으로 지금 결과를 JSON 파일에 남기고, 다음 실행에서 -baseline 으로 그 파일과 비교한다.
+// 비교하는 값은 두 가지다.
+//
+//	ns/op       -max-slowdown 보다 많이 느려지면 (ops/sec 가 그만큼 줄면) 회귀. -io-only 면 보여 주기만 한다.
+//	I/O 지표    ReportMetric 단위가 "/op" 나 "-amp" 로 끝나는 값 (reads/op, syncs/op, write-amp ...).
+//	            -max-io-increase 보다 많이 늘거나 결과에서 빠지면 회귀
+//
+// 하나라도 회귀면 Main 이 에러를 돌려주므로 `btree bench` 가 0 이 아닌 코드로 끝난다.
+// baseline 에 없는 벤치마크는 "new" 로 보여 주기만 한다. 거꾸로 baseline 에 있는데 돌지 않은 벤치마크나 지표는 회귀로 센다.
+// 이름이 바뀌었거나 지워진 벤치마크가 조용히 비교에서 빠지지 않게 하려는 것이다. 그럴 때는 baseline 을 다시 만든다.
+//
+// 횟수를 고정한 -benchtime (Nx) 에서 I/O 지표는 기계와 상관없이 매번 같지만 시간은 그렇지 않다.
+// 그래서 저장소의 baseline (testdata/bench/baseline.json) 과 `make check` 는 -io-only 로 I/O 지표만 본다.
+// 시간 비교는 `make bench-time-baseline` / `make bench-time-check` 로 따로 한다. 같은 기계에서 만든 baseline 과
+// 충분히 긴 -benchtime 으로만 의미가 있으므로 그 baseline 은 커밋하지 않는다.
+
+// Result 는 벤치마크 하나의 결과. baseline 파일에 이 모양으로 저장한다.
+type Result struct {
+	Name        string             `json:"name"`
+	N           int                `json:"n"`
+	NsPerOp     float64            `json:"nsPerOp"`
+	AllocsPerOp int64              `json:"allocsPerOp"`
+	BytesPerOp  int64              `json:"bytesPerOp"`
+	Metrics     map[string]float64 `json:"metrics,omitempty"`
+}
+
+// Baseline 은 -save-baseline 이 쓰고 -baseline 이 읽는 파일
+type Baseline struct {
+	Benchtime  string   `json:"benchtime"`
+	Benchmarks []Result `json:"benchmarks"`
+}
+
+// Thresholds 는 회귀로 보는 증가 비율. 0.25 면 baseline 보다 25% 넘게 늘어야 회귀다.
+// IOOnly 면 ns/op 는 비교하지 않는다 (MaxSlowdown 을 보지 않는다).
+type Thresholds struct {
+	MaxSlowdown   float64
+	MaxIOIncrease float64
+	IOOnly        bool
+}
+
+func newResult(name string, r testing.BenchmarkResult) Result {
+	res := Result{
+		Name:        name,
+		N:           r.N,
+		NsPerOp:     float64(r.T.Nanoseconds()) / float64(r.N),
+		AllocsPerOp: r.AllocsPerOp(),
+		BytesPerOp:  r.AllocedBytesPerOp(),
+	}
+	if len(r.Extra) > 0 {
+		res.Metrics = make(map[string]float64, len(r.Extra))
+		for unit, v := range r.Extra {
+			res.Metrics[unit] = v
+		}
+	}
+	return res
+}
+
+// isIOMetric 는 baseline 과 비교하는 (작을수록 좋은) 지표인지
+func isIOMetric(unit string) bool {
+	return strings.HasSuffix(unit, "/op") || strings.HasSuffix(unit, "-amp")
+}
+
+// LoadBaseline 은 baseline 파일을 읽는다.
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var base Baseline
+	if err := json.Unmarshal(data, &base); err != nil {
+		return nil, fmt.Errorf("baseline %s: %w", path, err)
+	}
+	return &base, nil
+}
+
+// SaveBaseline 은 base 를 path 에 JSON 으로 쓴다.
+func SaveBaseline(path string, base *Baseline) error {
+	data, err := json.MarshalIndent(base, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Compare 는 results 를 base 와 비교해서 w 에 한 줄씩 쓰고, 회귀했거나 results 에 빠진 벤치마크 수를 돌려준다.
+// baseline 에 있는 I/O 지표가 결과에 없으면 그 벤치마크는 회귀다.
+func Compare(w io.Writer, base *Baseline, results []Result, th Thresholds) int {
+	old := make(map[string]Result, len(base.Benchmarks))
+	for _, r := range base.Benchmarks {
+		old[r.Name] = r
+	}
+
+	regressions := 0
+	ran := make(map[string]bool, len(results))
+	for _, r := range results {
+		ran[r.Name] = true
+		b, ok := old[r.Name]
+		if !ok {
+			fmt.Fprintf(w, "%s: new (not in baseline)\n", r.Name)
+			continue
+		}
+		var problems []string
+		if !th.IOOnly && exceeds(b.NsPerOp, r.NsPerOp, th.MaxSlowdown) {
+			problems = append(problems, fmt.Sprintf("ns/op %.1f -> %.1f (%s, ops/sec %.0f -> %.0f)",
+				b.NsPerOp, r.NsPerOp, change(b.NsPerOp, r.NsPerOp), opsPerSec(b.NsPerOp), opsPerSec(r.NsPerOp)))
+		}
+		units := make([]string, 0, len(b.Metrics))
+		for unit := range b.Metrics {
+			if isIOMetric(unit) {
+				units = append(units, unit)
+			}
+		}
+		sort.Strings(units)
+		for _, unit := range units {
+			now, ok := r.Metrics[unit]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s missing (baseline %.3f)", unit, b.Metrics[unit]))
+				continue
+			}
+			if exceeds(b.Metrics[unit], now, th.MaxIOIncrease) {
+				problems = append(problems, fmt.Sprintf("%s %.3f -> %.3f (%s)", unit, b.Metrics[unit], now, change(b.Metrics[unit], now)))
+			}
+		}
+
+		if len(problems) == 0 {
+			fmt.Fprintf(w, "%s: ok (ns/op %s)\n", r.Name, change(b.NsPerOp, r.NsPerOp))
+			continue
+		}
+		regressions++
+		fmt.Fprintf(w, "%s: REGRESSION\n", r.Name)
+		for _, p := range problems {
+			fmt.Fprintf(w, "  %s\n", p)
+		}
+	}
+	for _, b := range base.Benchmarks {
+		if !ran[b.Name] {
+			regressions++
+			fmt.Fprintf(w, "%s: MISSING (in baseline but not run)\n", b.Name)
+		}
+	}
+	return regressions
+}
+
+// exceeds 는 now 가 base 보다 limit 비율을 넘게 늘었는지. base 가 0 이면 조금이라도 늘면 넘은 것이다.
+func exceeds(base, now, limit float64) bool {
+	return now > base*(1+limit) && now > base
+}
+
+func change(base, now float64) string {
+	if base == 0 {
+		if now == 0 {
+			return "+0.0%"
+		}
+		return "new non-zero"
+	}
+	return fmt.Sprintf("%+.1f%%", (now-base)/base*100)
+}
+
+func opsPerSec(nsPerOp float64) float64 {
+	if nsPerOp == 0 {
+		return 0
+	}
+	return 1e9 / nsPerOp
+}
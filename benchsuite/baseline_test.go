@@ -0,0 +1,79 @@
+package benchsuite
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	base := &Baseline{Benchtime: "10x", Benchmarks: []Result{
+		{Name: "Steady", NsPerOp: 100, Metrics: map[string]float64{"reads/op": 2}},
+		{Name: "Slower", NsPerOp: 100},
+		{Name: "MoreIO", NsPerOp: 100, Metrics: map[string]float64{"syncs/op": 1}},
+		{Name: "Gone", NsPerOp: 100},
+	}}
+	results := []Result{
+		{Name: "Steady", NsPerOp: 110, Metrics: map[string]float64{"reads/op": 2}},
+		{Name: "Slower", NsPerOp: 200},
+		{Name: "MoreIO", NsPerOp: 100, Metrics: map[string]float64{"syncs/op": 2}},
+		{Name: "Added", NsPerOp: 100},
+	}
+	th := Thresholds{MaxSlowdown: 0.25, MaxIOIncrease: 0.1}
+
+	// Slower, MoreIO 는 회귀, Gone 은 baseline 에만 있으므로 실패, Added 는 새 벤치마크라 통과
+	if n := Compare(io.Discard, base, results, th); n != 3 {
+		t.Fatalf("Compare = %d, want 3 (Slower, MoreIO and the missing Gone)", n)
+	}
+	if n := Compare(io.Discard, base, results[:1], th); n != 3 {
+		t.Fatalf("Compare with only Steady = %d, want 3 missing", n)
+	}
+	if n := Compare(io.Discard, base, append(results, Result{Name: "Gone", NsPerOp: 100}), th); n != 2 {
+		t.Fatalf("Compare with Gone back = %d, want 2", n)
+	}
+}
+
+// baseline 에 있는데 결과에 없는 벤치마크와 I/O 지표는 둘 다 회귀다.
+func TestCompareMissing(t *testing.T) {
+	base := &Baseline{Benchtime: "10x", Benchmarks: []Result{
+		{Name: "Kept", NsPerOp: 100, Metrics: map[string]float64{"reads/op": 2, "write-amp": 3}},
+		{Name: "Dropped", NsPerOp: 100},
+	}}
+	th := Thresholds{MaxSlowdown: 0.25, MaxIOIncrease: 0.1}
+
+	full := []Result{
+		{Name: "Kept", NsPerOp: 100, Metrics: map[string]float64{"reads/op": 2, "write-amp": 3}},
+		{Name: "Dropped", NsPerOp: 100},
+	}
+	if n := Compare(io.Discard, base, full, th); n != 0 {
+		t.Fatalf("Compare with every benchmark and metric = %d, want 0", n)
+	}
+
+	var out strings.Builder
+	noBench := []Result{full[0]}
+	if n := Compare(&out, base, noBench, th); n != 1 || !strings.Contains(out.String(), "Dropped: MISSING") {
+		t.Fatalf("Compare without Dropped = %d, output:\n%s", n, out.String())
+	}
+
+	out.Reset()
+	noMetric := []Result{{Name: "Kept", NsPerOp: 100, Metrics: map[string]float64{"reads/op": 2}}, full[1]}
+	if n := Compare(&out, base, noMetric, th); n != 1 || !strings.Contains(out.String(), "write-amp missing") {
+		t.Fatalf("Compare without write-amp = %d, output:\n%s", n, out.String())
+	}
+}
+
+// IOOnly 면 ns/op 가 아무리 늘어도 통과하고, I/O 지표는 그대로 본다.
+func TestCompareIOOnly(t *testing.T) {
+	base := &Baseline{Benchtime: "10x", Benchmarks: []Result{
+		{Name: "Slower", NsPerOp: 100},
+		{Name: "MoreIO", NsPerOp: 100, Metrics: map[string]float64{"syncs/op": 1}},
+	}}
+	results := []Result{
+		{Name: "Slower", NsPerOp: 1000},
+		{Name: "MoreIO", NsPerOp: 100, Metrics: map[string]float64{"syncs/op": 2}},
+	}
+	th := Thresholds{MaxSlowdown: 0.25, MaxIOIncrease: 0.1, IOOnly: true}
+	if n := Compare(io.Discard, base, results, th); n != 1 {
+		t.Fatalf("Compare = %d, want 1 (MoreIO only)", n)
+	}
+}
@@ -0,0 +1,8 @@
+package benchsuite
+
+import "testing"
+
+// BenchmarkAll 은 `btree bench` 와 같은 목록을 `go test -bench` 로 돌리는 하나뿐인 진입점이다. 하위 벤치마크 이름으로 고른다.
+//
+//	go test -bench All/Pager ./benchsuite
+func BenchmarkAll(b *testing.B) { Run(b, All()) }
@@ -0,0 +1,152 @@
+// Package benchsuite 는 저장소의 벤치마크 모음과, 그 벤치마크를 일반 바이너리에서 돌리는 작은 러너다.
+// 라이브러리 패키지가 testing 을 들이지 않도록 벤치마크 본문은 이 패키지에 모으고 공개 API 만 쓴다.
+// 같은 목록을 두 곳에서 돌린다. 이 패키지의 BenchmarkAll (bench_test.go) 은 Run 으로 `go test -bench` 에 내놓고,
+// `btree bench` 는 Main 으로 testing.Benchmark 를 불러 결과를 `go test -bench` 와 같은 모양으로 출력한다.
+package benchsuite
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"slices"
+	"testing"
+)
+
+// Benchmark 는 이름과 testing.B 함수 한 쌍. 이름은 go test 처럼 "Benchmark" 접두사 없이 적는다.
+type Benchmark struct {
+	Name string
+	F    func(b *testing.B)
+}
+
+// All 은 `btree bench` 가 돌리는 전체 목록
+func All() []Benchmark {
+	var all []Benchmark
+	for _, list := range [][]Benchmark{BTree, Pager, OffsetList, PagedList, LSM, ExtHash, Log} {
+		all = append(all, list...)
+	}
+	return all
+}
+
+// Run 은 benches 를 b 의 하위 벤치마크로 돌린다. BenchmarkAll 이 부른다.
+//
+//	go test -bench All/PagerWrite ./benchsuite
+func Run(b *testing.B, benches []Benchmark) {
+	for _, bm := range benches {
+		b.Run(bm.Name, bm.F)
+	}
+}
+
+// Options 는 Register 가 FlagSet 에 건 플래그의 값
+type Options struct {
+	Run          string // 이름이 맞는 벤치마크만 (기본: 전부)
+	Benchtime    string // 벤치마크 하나를 돌릴 시간 또는 횟수 (d 또는 Nx)
+	List         bool   // 실행하지 않고 이름만 출력
+	Baseline     string // 결과를 비교할 baseline 파일 (baseline.go)
+	SaveBaseline string // 결과를 저장할 baseline 파일
+	Thresholds   Thresholds
+}
+
+// Register 는 fs 에 벤치마크 러너의 플래그를 등록한다. 도움말(-h)도 fs 의 Usage 가 그대로 보여 준다.
+func Register(fs *flag.FlagSet) *Options {
+	o := &Options{}
+	fs.StringVar(&o.Run, "run", "", "run only benchmarks whose name matches this regexp")
+	fs.StringVar(&o.Benchtime, "benchtime", "1s", "run each benchmark for duration d or N times if of the form Nx")
+	fs.BoolVar(&o.List, "list", false, "list benchmark names and exit")
+	fs.StringVar(&o.Baseline, "baseline", "", "compare the results with this baseline file and fail on regressions")
+	fs.StringVar(&o.SaveBaseline, "save-baseline", "", "write the results to this baseline file")
+	fs.Float64Var(&o.Thresholds.MaxSlowdown, "max-slowdown", 0.25, "fail if ns/op grows by more than this fraction of the baseline")
+	fs.Float64Var(&o.Thresholds.MaxIOIncrease, "max-io-increase", 0.1, "fail if an I/O metric (*/op, *-amp) grows by more than this fraction of the baseline")
+	fs.BoolVar(&o.Thresholds.IOOnly, "io-only", false, "compare only the I/O metrics with the baseline; ns/op is shown but never fails")
+	return o
+}
+
+// Main 은 fs.Parse 뒤에 부른다. benches 중 -run 에 맞는 것만 차례로 돌려 go test -bench 와 같은 형식으로 w 에 쓴다.
+// -baseline 이 있으면 baseline 중 -run 에 맞는 것과 비교하고, 회귀하거나 빠진 벤치마크가 있으면 에러다.
+func Main(w io.Writer, o *Options, benches []Benchmark) error {
+	th := o.Thresholds
+	if th.MaxSlowdown < 0 || th.MaxIOIncrease < 0 {
+		return errors.New("-max-slowdown and -max-io-increase must not be negative")
+	}
+
+	filter, err := regexp.Compile(o.Run)
+	if err != nil {
+		return fmt.Errorf("invalid -run: %w", err)
+	}
+
+	// testing.Benchmark 는 -test.benchtime 플래그 값을 읽으므로 여기서 등록하고 채워 둔다.
+	testing.Init()
+	if err := flag.Set("test.benchtime", o.Benchtime); err != nil {
+		return fmt.Errorf("invalid -benchtime: %w", err)
+	}
+
+	selected := make([]Benchmark, 0, len(benches))
+	width := 0
+	for _, bm := range benches {
+		if filter.MatchString(bm.Name) {
+			selected = append(selected, bm)
+			width = max(width, len("Benchmark"+bm.Name))
+		}
+	}
+	if len(selected) == 0 {
+		return fmt.Errorf("no benchmark matches %q", o.Run)
+	}
+
+	// 다 돌린 뒤에 비교하려고 먼저 읽어 둔다. 파일이 없으면 벤치마크를 돌리기 전에 멈춘다.
+	// -run 으로 고르지 않은 벤치마크는 baseline 에 있어도 빠진 것으로 치지 않는다.
+	var base *Baseline
+	if o.Baseline != "" && !o.List {
+		if base, err = LoadBaseline(o.Baseline); err != nil {
+			return err
+		}
+		// op 당 I/O 지표는 N 에 따라 달라지므로 baseline 과 같은 -benchtime 으로만 비교한다.
+		if base.Benchtime != o.Benchtime {
+			return fmt.Errorf("baseline %s was recorded with -benchtime %s, not %s", o.Baseline, base.Benchtime, o.Benchtime)
+		}
+		base.Benchmarks = slices.DeleteFunc(base.Benchmarks, func(r Result) bool { return !filter.MatchString(r.Name) })
+	}
+
+	results := make([]Result, 0, len(selected))
+	// 하나 끝날 때마다 바로 출력하므로 이름 폭을 미리 맞춰 둔다.
+	for _, bm := range selected {
+		if o.List {
+			fmt.Fprintf(w, "Benchmark%s\n", bm.Name)
+			continue
+		}
+		slog.Debug("benchmark start", "name", bm.Name)
+		r := testing.Benchmark(bm.F)
+		if r.N == 0 {
+			// b.Fatal 등으로 실패하면 N 이 0 으로 돌아온다.
+			slog.Error("benchmark failed", "name", bm.Name)
+			return fmt.Errorf("benchmark %s failed", bm.Name)
+		}
+		// 결과 줄은 go test -bench 모양 그대로 w 에 쓰고, 같은 값을 로그로도 남겨 -log-format json 으로 모을 수 있게 한다.
+		slog.Debug("benchmark done", "name", bm.Name, "n", r.N, "nsPerOp", r.NsPerOp(),
+			"bytesPerOp", r.AllocedBytesPerOp(), "allocsPerOp", r.AllocsPerOp(), "elapsed", r.T)
+		fmt.Fprintf(w, "%-*s\t%s\t%s\n", width, "Benchmark"+bm.Name, r.String(), r.MemString())
+		results = append(results, newResult(bm.Name, r))
+	}
+	if o.List {
+		return nil
+	}
+
+	if o.SaveBaseline != "" {
+		if err := SaveBaseline(o.SaveBaseline, &Baseline{Benchtime: o.Benchtime, Benchmarks: results}); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "wrote baseline %s (%d benchmarks)\n", o.SaveBaseline, len(results))
+	}
+	if base != nil {
+		slowdown := fmt.Sprintf("max slowdown %.0f%%", th.MaxSlowdown*100)
+		if th.IOOnly {
+			slowdown = "ns/op not compared"
+		}
+		fmt.Fprintf(w, "\ncompared with %s (%s, max I/O increase %.0f%%):\n", o.Baseline, slowdown, th.MaxIOIncrease*100)
+		if n := Compare(w, base, results, th); n > 0 {
+			return fmt.Errorf("%d benchmark(s) regressed or missing against %s", n, o.Baseline)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package benchsuite
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/tmdgusya/btree"
+	"github.com/tmdgusya/btree/workload"
+)
+
+// ==================================
+// 메모리 B-Tree 벤치마크 (`btree bench -run BTree`)
+// ==================================
+// 삭제와 전체 순회는 아직 구현되어 있지 않아서 Insert / Search 만 잰다.
+
+// 벤치마크에 쓰는 최소 차수. 교육용 UI 의 기본값과 같다.
+const btreeDegree = 3
+
+// 디스크 페이지처럼 노드 하나에 키가 많은 경우. 리프 삽입 때 키를 미는 비용이 잘 보인다.
+const btreeWideDegree = 64
+
+// FindChildIndex 마이크로 벤치마크의 노드 차수. 꽉 찬 노드에 키가 2t-1 = 511 개 있다.
+const btreeNodeDegree = 256
+
+// 미리 채워 두는 키 개수 (Search 용)
+const btreePreload = 100000
+
+func btreeKeys(n int, seed int64) []uint32 {
+	gen, err := workload.New(workload.Config{Distribution: workload.Uniform, KeySpace: btreePreload, Seed: seed})
+	if err != nil {
+		panic(err)
+	}
+	return gen.Keys(n)
+}
+
+func newTree(b *testing.B, t int) *btree.BTree {
+	tree, err := btree.New(t)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return tree
+}
+
+func benchmarkBTreeInsertSequential(b *testing.B) {
+	tree := newTree(b, btreeDegree)
+	for i := 0; i < b.N; i++ {
+		tree.Insert(i, nil)
+	}
+}
+
+func benchmarkBTreeInsertRandom(degree int) func(b *testing.B) {
+	return func(b *testing.B) {
+		keys := btreeKeys(b.N, 1)
+		tree := newTree(b, degree)
+		b.ResetTimer()
+		for _, k := range keys {
+			tree.Insert(int(k), nil)
+		}
+	}
+}
+
+func benchmarkBTreeSearch(b *testing.B) {
+	tree := newTree(b, btreeDegree)
+	for i := 0; i < btreePreload; i++ {
+		tree.Insert(i, nil)
+	}
+	keys := btreeKeys(b.N, 2)
+	b.ResetTimer()
+	for _, k := range keys {
+		if _, ok := tree.Search(int(k)); !ok {
+			b.Fatalf("key %d not found", k)
+		}
+	}
+}
+
+// 키 btreePreload 개로 트리를 새로 만든다. op 는 트리 하나.
+// 만든 트리를 살려 둔 채 GC 를 한 번 돌려서, 힙에 남은 노드를 훑는 시간을 gc-us/op 로 같이 보고한다.
+func benchmarkBTreeBulkLoad(arena bool) func(b *testing.B) {
+	return func(b *testing.B) {
+		keys := btreeKeys(btreePreload, 4)
+		var gc time.Duration
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			tree := newTree(b, btreeDegree)
+			tree.SetArena(arena)
+			for _, k := range keys {
+				tree.Insert(int(k), nil)
+			}
+			b.StopTimer()
+			start := time.Now()
+			runtime.GC()
+			gc += time.Since(start)
+			runtime.KeepAlive(tree)
+			b.StartTimer()
+		}
+		b.ReportMetric(float64(gc.Microseconds())/float64(b.N), "gc-us/op")
+	}
+}
+
+// 꽉 찬 노드 하나에서 자식 위치만 찾는다. 이진 탐색은 키 수의 log, 선형 탐색은 키 수에 비례한다.
+// 차수 btreeNodeDegree 인 트리에 키 2t-1 개를 넣으면 아직 분할 전이라 루트 하나가 꽉 찬 노드다.
+func benchmarkBTreeFindChild(find func(x *btree.BTreeNode, k int) int) func(b *testing.B) {
+	return func(b *testing.B) {
+		tree := newTree(b, btreeNodeDegree)
+		const n = 2*btreeNodeDegree - 1
+		for i := 0; i < n; i++ {
+			tree.Insert(2*i, nil)
+		}
+		x := tree.Root()
+		keys := btreeKeys(b.N, 3)
+		b.ResetTimer()
+		for _, k := range keys {
+			find(x, int(k)%(2*n))
+		}
+	}
+}
+
+// 키가 많은 노드로 이루어진 트리에서 SearchTrace 의 비교 횟수를 op 당 comparisons 로 같이 보고한다.
+func benchmarkBTreeSearchWide(linear bool) func(b *testing.B) {
+	return func(b *testing.B) {
+		tree := newTree(b, btreeWideDegree)
+		tree.SetLinearSearch(linear)
+		for i := 0; i < btreePreload; i++ {
+			tree.Insert(i, nil)
+		}
+		keys := btreeKeys(b.N, 2)
+		compares := 0
+		b.ResetTimer()
+		for _, k := range keys {
+			tr := tree.SearchTrace(int(k))
+			if !tr.Found {
+				b.Fatalf("key %d not found", k)
+			}
+			compares += tr.Comparisons()
+		}
+		b.ReportMetric(float64(compares)/float64(b.N), "comparisons/op")
+	}
+}
+
+// BTree 는 메모리 B-Tree 벤치마크 목록
+var BTree = []Benchmark{
+	{Name: "BTreeInsertSequential", F: benchmarkBTreeInsertSequential},
+	{Name: "BTreeInsertRandom", F: benchmarkBTreeInsertRandom(btreeDegree)},
+	{Name: "BTreeInsertRandomWide", F: benchmarkBTreeInsertRandom(btreeWideDegree)},
+	{Name: "BTreeSearch", F: benchmarkBTreeSearch},
+	{Name: "BTreeBulkLoad", F: benchmarkBTreeBulkLoad(false)},
+	{Name: "BTreeBulkLoadArena", F: benchmarkBTreeBulkLoad(true)},
+	{Name: "BTreeFindChildBinary", F: benchmarkBTreeFindChild((*btree.BTreeNode).FindChildIndex)},
+	{Name: "BTreeFindChildLinear", F: benchmarkBTreeFindChild((*btree.BTreeNode).FindChildIndexLinear)},
+	{Name: "BTreeSearchWideBinary", F: benchmarkBTreeSearchWide(false)},
+	{Name: "BTreeSearchWideLinear", F: benchmarkBTreeSearchWide(true)},
+}
@@ -0,0 +1,81 @@
+package benchsuite
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/tmdgusya/btree/chapter03/exthash"
+)
+
+// ==================================
+// Extendible hashing 벤치마크 (`btree bench -run ExtHash`)
+// ==================================
+// 메모리 B-Tree 벤치마크(BTreeInsertSequential / BTreeInsertRandom / BTreeSearch)와 같은 키 스트림을 쓴다.
+// 디스크 인덱스라서 연산당 페이지 읽기 수를 같이 보여 준다.
+
+func openBenchIndex(b *testing.B) *exthash.Index {
+	b.Helper()
+	idx, err := exthash.Open(filepath.Join(b.TempDir(), "bench.hash"), 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { idx.Close() })
+	return idx
+}
+
+func reportIndexReads(b *testing.B, idx *exthash.Index, before int64) {
+	b.ReportMetric(float64(idx.Stats().IO.Reads-before)/float64(b.N), "reads/op")
+}
+
+func benchmarkExtHashInsertSequential(b *testing.B) {
+	idx := openBenchIndex(b)
+	before := idx.Stats().IO.Reads
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := idx.Put(uint64(i), uint64(i)); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.StopTimer()
+	reportIndexReads(b, idx, before)
+}
+
+func benchmarkExtHashInsertRandom(b *testing.B) {
+	idx := openBenchIndex(b)
+	keys := btreeKeys(b.N, 1)
+	before := idx.Stats().IO.Reads
+	b.ResetTimer()
+	for _, k := range keys {
+		if err := idx.Put(uint64(k), uint64(k)); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.StopTimer()
+	reportIndexReads(b, idx, before)
+}
+
+func benchmarkExtHashSearch(b *testing.B) {
+	idx := openBenchIndex(b)
+	for i := 0; i < btreePreload; i++ {
+		if err := idx.Put(uint64(i), uint64(i)); err != nil {
+			b.Fatal(err)
+		}
+	}
+	keys := btreeKeys(b.N, 2)
+	before := idx.Stats().IO.Reads
+	b.ResetTimer()
+	for _, k := range keys {
+		if _, ok, err := idx.Get(uint64(k)); err != nil || !ok {
+			b.Fatalf("get %d: ok=%v err=%v", k, ok, err)
+		}
+	}
+	b.StopTimer()
+	reportIndexReads(b, idx, before)
+}
+
+// ExtHash 는 extendible hashing 벤치마크 목록
+var ExtHash = []Benchmark{
+	{Name: "ExtHashInsertSequential", F: benchmarkExtHashInsertSequential},
+	{Name: "ExtHashInsertRandom", F: benchmarkExtHashInsertRandom},
+	{Name: "ExtHashSearch", F: benchmarkExtHashSearch},
+}
@@ -0,0 +1,80 @@
+package benchsuite
+
+import (
+	"testing"
+
+	"github.com/tmdgusya/btree/chapter04/logstore"
+)
+
+// ==================================
+// 로그 벤치마크 (`btree bench -run Log`)
+// ==================================
+// 레코드 크기는 작은 WAL 기록 정도로 잡는다. fsync 를 레코드마다 하는지, 묶어서 하는지에 따라
+// 같은 순차 쓰기가 얼마나 달라지는지 본다.
+
+const logRecordSize = 128
+
+// 묶어서 Sync 할 때 한 번에 묶는 레코드 수
+const logGroupSize = 100
+
+func openBenchLog(b *testing.B) *logstore.Log {
+	b.Helper()
+	log, err := logstore.Open(b.TempDir(), logstore.Options{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { log.Close() })
+	return log
+}
+
+// syncEvery 가 0 이면 Sync 하지 않는다.
+func benchmarkLogAppend(syncEvery int) func(b *testing.B) {
+	return func(b *testing.B) {
+		log := openBenchLog(b)
+		data := make([]byte, logRecordSize)
+		b.SetBytes(logRecordSize)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := log.Append(data); err != nil {
+				b.Fatal(err)
+			}
+			if syncEvery > 0 && (i+1)%syncEvery == 0 {
+				if err := log.Sync(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	}
+}
+
+// op 하나는 레코드 하나를 재생하는 것
+func benchmarkLogReadAll(b *testing.B) {
+	log := openBenchLog(b)
+	data := make([]byte, logRecordSize)
+	for i := 0; i < b.N; i++ {
+		if _, err := log.Append(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.SetBytes(logRecordSize)
+	b.ResetTimer()
+	n := 0
+	err := log.ReadAll(func(pos logstore.Position, data []byte) error {
+		n++
+		return nil
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	if n != b.N {
+		b.Fatalf("replayed %d records, want %d", n, b.N)
+	}
+}
+
+// Log 는 로그 벤치마크 목록
+var Log = []Benchmark{
+	{Name: "LogAppend", F: benchmarkLogAppend(0)},
+	{Name: "LogAppendSync", F: benchmarkLogAppend(1)},
+	{Name: "LogAppendGrouped", F: benchmarkLogAppend(logGroupSize)},
+	{Name: "LogReadAll", F: benchmarkLogReadAll},
+}
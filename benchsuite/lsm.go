@@ -0,0 +1,192 @@
+package benchsuite
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/tmdgusya/btree/chapter03/lsm"
+	"github.com/tmdgusya/btree/workload"
+)
+
+// ==================================
+// LSM 벤치마크 (`btree bench -run LSM`)
+// ==================================
+// 작은 memtable 로 flush / 컴팩션 비용이 Put 에 섞여 들어가게 한다.
+// 끝나면 증폭 비율을 ReportMetric 으로 같이 보여 준다.
+
+const lsmKeySpace = 100000
+
+var lsmOptions = lsm.Options{MemtableSize: 64 << 10, TableSize: 256 << 10, LevelBase: 1 << 20}
+
+var lsmValue = make([]byte, 100)
+
+func openBenchDB(b *testing.B) *lsm.DB {
+	b.Helper()
+	db, err := lsm.Open(b.TempDir(), lsmOptions)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { db.Close() })
+	return db
+}
+
+func lsmKeys(b *testing.B, dist workload.Distribution, keySpace int, seed int64) []uint32 {
+	gen, err := workload.New(workload.Config{Distribution: dist, KeySpace: keySpace, Seed: seed})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return gen.Keys(b.N)
+}
+
+func lsmKey(k uint32) []byte {
+	return binary.BigEndian.AppendUint32(nil, k)
+}
+
+func benchmarkLSMPut(dist workload.Distribution) func(b *testing.B) {
+	return func(b *testing.B) {
+		db := openBenchDB(b)
+		keys := lsmKeys(b, dist, lsmKeySpace, 1)
+		b.SetBytes(int64(4 + len(lsmValue)))
+		b.ResetTimer()
+		for _, k := range keys {
+			if err := db.Put(lsmKey(k), lsmValue); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.StopTimer()
+		b.ReportMetric(db.Stats().WriteAmplification(), "write-amp")
+	}
+}
+
+func benchmarkLSMGet(b *testing.B) {
+	db := openBenchDB(b)
+	for k := uint32(0); k < lsmKeySpace; k++ {
+		if err := db.Put(lsmKey(k), lsmValue); err != nil {
+			b.Fatal(err)
+		}
+	}
+	if err := db.Flush(); err != nil {
+		b.Fatal(err)
+	}
+	keys := lsmKeys(b, workload.Uniform, lsmKeySpace, 2)
+	before := db.Stats()
+	b.ResetTimer()
+	for _, k := range keys {
+		if _, ok, err := db.Get(lsmKey(k)); err != nil || !ok {
+			b.Fatalf("get %d: ok=%v err=%v", k, ok, err)
+		}
+	}
+	b.StopTimer()
+	after := db.Stats()
+	b.ReportMetric(float64(after.Get.Reads-before.Get.Reads)/float64(b.N), "reads/op")
+}
+
+// ==================================
+// 값 크기 sweep (`btree bench -run LSM.*Value`)
+// ==================================
+// 같은 Put / Get 을 값 크기 8 B ~ 64 KB 로 바꿔 가며 잰다. 키 수는 살아 있는 데이터가 lsmValueBudget 쯤이 되게 줄인다.
+// SSTable 에는 overflow 페이지가 없어서 값은 항상 data block 안에 통째로 들어간다.
+// 작은 값은 BlockSize(4 KB) 블록 하나에 entry 가 수백 개라 Get 한 번이 값보다 훨씬 많은 바이트를 읽고,
+// BlockSize 를 넘는 값은 블록 하나에 entry 하나가 되어 블록이 값 크기만큼 커진다.
+// keys/block 과 read-bytes/value 로 그 사이의 페이지 채움 trade-off 를 본다.
+// memtable 이 64 KB 라서 64 KB 값은 Put 마다 flush 가 일어난다.
+
+// 값 크기별로 살아 있는 데이터의 양
+const lsmValueBudget = 16 << 20
+
+var lsmValueSizes = []int{8, 64, 512, 4 << 10, 64 << 10}
+
+// lsmValueKeySpace 는 값이 size 바이트일 때 쓰는 키 범위
+func lsmValueKeySpace(size int) int {
+	return min(lsmKeySpace, lsmValueBudget/size)
+}
+
+// reportBlockFill 은 살아 있는 테이블의 data block 하나에 든 평균 entry 수를 보고한다.
+func reportBlockFill(b *testing.B, s lsm.Stats) {
+	var entries int64
+	var blocks int
+	for _, l := range s.Levels {
+		entries += l.Entries
+		blocks += l.Blocks
+	}
+	if blocks > 0 {
+		b.ReportMetric(float64(entries)/float64(blocks), "keys/block")
+	}
+}
+
+func benchmarkLSMPutValueSize(size int) func(b *testing.B) {
+	return func(b *testing.B) {
+		db := openBenchDB(b)
+		keys := lsmKeys(b, workload.Uniform, lsmValueKeySpace(size), 1)
+		value := make([]byte, size)
+		b.SetBytes(int64(4 + size))
+		b.ResetTimer()
+		for _, k := range keys {
+			if err := db.Put(lsmKey(k), value); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.StopTimer()
+		s := db.Stats()
+		b.ReportMetric(s.WriteAmplification(), "write-amp")
+		reportBlockFill(b, s)
+	}
+}
+
+func benchmarkLSMGetValueSize(size int) func(b *testing.B) {
+	return func(b *testing.B) {
+		db := openBenchDB(b)
+		value := make([]byte, size)
+		for k := 0; k < lsmValueKeySpace(size); k++ {
+			if err := db.Put(lsmKey(uint32(k)), value); err != nil {
+				b.Fatal(err)
+			}
+		}
+		if err := db.Flush(); err != nil {
+			b.Fatal(err)
+		}
+		keys := lsmKeys(b, workload.Uniform, lsmValueKeySpace(size), 2)
+		before := db.Stats()
+		b.SetBytes(int64(size))
+		b.ResetTimer()
+		for _, k := range keys {
+			if _, ok, err := db.Get(lsmKey(k)); err != nil || !ok {
+				b.Fatalf("get %d: ok=%v err=%v", k, ok, err)
+			}
+		}
+		b.StopTimer()
+		after := db.Stats()
+		read := float64(after.Get.BytesRead - before.Get.BytesRead)
+		b.ReportMetric(read/float64(b.N)/float64(size), "read-bytes/value")
+		b.ReportMetric(float64(after.DiskBytes())/float64(after.UserBytes), "space-amp")
+		reportBlockFill(b, after)
+	}
+}
+
+// formatValueSize 는 벤치마크 이름에 붙이는 크기 ("8B", "4KB")
+func formatValueSize(size int) string {
+	if size >= 1<<10 && size%(1<<10) == 0 {
+		return fmt.Sprintf("%dKB", size>>10)
+	}
+	return fmt.Sprintf("%dB", size)
+}
+
+// lsmValueSizeBenchmarks 는 lsmValueSizes 마다 LSMPutValue<크기> / LSMGetValue<크기> 를 만든다.
+func lsmValueSizeBenchmarks() []Benchmark {
+	var list []Benchmark
+	for _, size := range lsmValueSizes {
+		list = append(list,
+			Benchmark{Name: "LSMPutValue" + formatValueSize(size), F: benchmarkLSMPutValueSize(size)},
+			Benchmark{Name: "LSMGetValue" + formatValueSize(size), F: benchmarkLSMGetValueSize(size)},
+		)
+	}
+	return list
+}
+
+// LSM 은 LSM 벤치마크 목록
+var LSM = append([]Benchmark{
+	{Name: "LSMPutSequential", F: benchmarkLSMPut(workload.Sequential)},
+	{Name: "LSMPutUniform", F: benchmarkLSMPut(workload.Uniform)},
+	{Name: "LSMGet", F: benchmarkLSMGet},
+}, lsmValueSizeBenchmarks()...)
@@ -0,0 +1,113 @@
+package benchsuite
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/tmdgusya/btree/chapter02/linkedlist"
+	"github.com/tmdgusya/btree/workload"
+)
+
+// ==================================
+// Offset 리스트 벤치마크 (`btree bench -run Offset`)
+// ==================================
+// 검색 / 삭제는 체인을 따라가므로 비용이 리스트 길이에 비례한다. 길이를 listSize 로 고정해서 잰다.
+// Paged 리스트 벤치마크(pagedlist.go)와 같은 길이, 같은 값 스트림을 쓴다.
+
+const listSize = 1000
+
+// 벤치마크마다 임시 디렉터리에 새 파일을 만든다.
+func openOffsetList(b *testing.B, store *linkedlist.OffsetStore, size int) *linkedlist.Handle {
+	b.Helper()
+	handle, err := store.Open(filepath.Join(b.TempDir(), "bench.db"), true)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { store.Close(handle) })
+	for i := 0; i < size; i++ {
+		if err := store.AppendTail(handle, uint32(i)); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return handle
+}
+
+func listTargets(b *testing.B, seed int64) []uint32 {
+	gen, err := workload.New(workload.Config{Distribution: workload.Uniform, KeySpace: listSize, Seed: seed})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return gen.Keys(b.N)
+}
+
+func benchmarkOffsetAppendTail(b *testing.B) {
+	store := &linkedlist.OffsetStore{}
+	handle := openOffsetList(b, store, 0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := store.AppendTail(handle, uint32(i)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchmarkOffsetWhere(b *testing.B) {
+	store := &linkedlist.OffsetStore{}
+	handle := openOffsetList(b, store, listSize)
+	targets := listTargets(b, 1)
+	b.ResetTimer()
+	for _, target := range targets {
+		off, err := store.Where(handle, target)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if off == linkedlist.NullOffset {
+			b.Fatalf("value %d not found", target)
+		}
+	}
+}
+
+func benchmarkOffsetTraverse(b *testing.B) {
+	store := &linkedlist.OffsetStore{}
+	handle := openOffsetList(b, store, listSize)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		values, err := store.TraverseValues(handle)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(values) != listSize {
+			b.Fatalf("traverse returned %d values, expected %d", len(values), listSize)
+		}
+	}
+}
+
+// 지운 값은 타이머를 멈춘 채 다시 붙여서 리스트 길이를 유지한다.
+func benchmarkOffsetDelete(b *testing.B) {
+	store := &linkedlist.OffsetStore{}
+	handle := openOffsetList(b, store, listSize)
+	targets := listTargets(b, 2)
+	b.ResetTimer()
+	for _, target := range targets {
+		ok, err := store.DeleteFirstByValue(handle, target)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if !ok {
+			b.Fatalf("value %d not found", target)
+		}
+		b.StopTimer()
+		if err := store.AppendTail(handle, target); err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+	}
+}
+
+// OffsetList 는 Offset 리스트 벤치마크 목록
+var OffsetList = []Benchmark{
+	{Name: "OffsetAppendTail", F: benchmarkOffsetAppendTail},
+	{Name: "OffsetWhere", F: benchmarkOffsetWhere},
+	{Name: "OffsetTraverse", F: benchmarkOffsetTraverse},
+	{Name: "OffsetDelete", F: benchmarkOffsetDelete},
+}
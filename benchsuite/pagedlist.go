@@ -0,0 +1,184 @@
+package benchsuite
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	pagedlist "github.com/tmdgusya/btree/chapter02/paged_linked_list"
+	"github.com/tmdgusya/btree/iometrics"
+)
+
+// ==================================
+// Paged 리스트 벤치마크 (`btree bench -run Paged`)
+// ==================================
+// 검색 / 삭제는 체인을 따라가므로 비용이 리스트 길이에 비례한다. 길이를 listSize 로 고정해서 잰다.
+
+// 물리 순회 벤치마크의 리스트 길이. 4 KB 페이지로 약 3000 페이지 (12 MB) 라서 페이지를 나눠 읽는 효과가 보인다.
+const largeListSize = 1 << 20
+
+// AppendValues 한 번에 넣는 값 수 (`btree load` 의 기본 배치 크기와 같다)
+const listBatchSize = 1000
+
+// 벤치마크마다 임시 디렉터리에 새 파일을 만든다.
+func openPagedList(b *testing.B, store *pagedlist.PagedStore, size int) *pagedlist.Handle {
+	b.Helper()
+	handle, err := store.Open(filepath.Join(b.TempDir(), "bench.llst"), true)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { store.Close(handle) })
+	for i := 0; i < size; i++ {
+		if err := store.AppendTail(handle, uint32(i)); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return handle
+}
+
+// 쓰기 횟수를 세는 빈 리스트. append 벤치마크가 값 하나당 write 수를 같이 보고한다.
+func openCountedList(b *testing.B, store *pagedlist.PagedStore) (*pagedlist.Handle, *iometrics.CountingFile) {
+	b.Helper()
+	raw, err := os.Create(filepath.Join(b.TempDir(), "bench.llst"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	cf := iometrics.NewCountingFile(raw)
+	handle, err := store.OpenFile(cf)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { store.Close(handle) })
+	return handle, cf
+}
+
+func benchmarkPagedAppendTail(b *testing.B) {
+	store := &pagedlist.PagedStore{}
+	handle, cf := openCountedList(b, store)
+	start := cf.Metrics().Writes
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := store.AppendTail(handle, uint32(i)); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(cf.Metrics().Writes-start)/float64(b.N), "writes/op")
+}
+
+// 값 listBatchSize 개씩 AppendValues 로 넣는다. op 는 값 하나.
+func benchmarkPagedAppendValues(b *testing.B) {
+	store := &pagedlist.PagedStore{}
+	handle, cf := openCountedList(b, store)
+	start := cf.Metrics().Writes
+	batch := make([]uint32, listBatchSize)
+	b.ResetTimer()
+	for i := 0; i < b.N; i += len(batch) {
+		vals := batch[:min(len(batch), b.N-i)]
+		for j := range vals {
+			vals[j] = uint32(i + j)
+		}
+		if err := store.AppendValues(handle, vals); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(cf.Metrics().Writes-start)/float64(b.N), "writes/op")
+}
+
+func benchmarkPagedWhere(b *testing.B) {
+	store := &pagedlist.PagedStore{}
+	handle := openPagedList(b, store, listSize)
+	targets := listTargets(b, 1)
+	b.ResetTimer()
+	for _, target := range targets {
+		loc, err := store.Where(handle, target)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if loc == nil {
+			b.Fatalf("value %d not found", target)
+		}
+	}
+}
+
+func benchmarkPagedTraverse(b *testing.B) {
+	store := &pagedlist.PagedStore{}
+	handle := openPagedList(b, store, listSize)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		values, err := store.TraverseValues(handle)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(values) != listSize {
+			b.Fatalf("traverse returned %d values, expected %d", len(values), listSize)
+		}
+	}
+}
+
+// 큰 리스트를 페이지 순서대로 읽는다. MB/s 는 파일에서 읽은 페이지 바이트 기준.
+func benchmarkPagedPhysical(traverse func(*pagedlist.PagedStore, *pagedlist.Handle) ([]uint32, error)) func(b *testing.B) {
+	return func(b *testing.B) {
+		store := &pagedlist.PagedStore{}
+		handle := openPagedList(b, store, 0)
+		values := make([]uint32, largeListSize)
+		for i := range values {
+			values[i] = uint32(i)
+		}
+		if err := store.AppendValues(handle, values); err != nil {
+			b.Fatal(err)
+		}
+		h := handle.Header.(*pagedlist.Header)
+		b.SetBytes(int64(h.PageCount) * int64(h.PageSize))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			got, err := traverse(store, handle)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if len(got) != largeListSize {
+				b.Fatalf("traverse returned %d values, expected %d", len(got), largeListSize)
+			}
+		}
+	}
+}
+
+func benchmarkPagedPhysicalParallel(workers int) func(b *testing.B) {
+	return benchmarkPagedPhysical(func(s *pagedlist.PagedStore, h *pagedlist.Handle) ([]uint32, error) {
+		return s.TraverseValuesPhysicalParallel(h, workers)
+	})
+}
+
+// 지운 값은 타이머를 멈춘 채 다시 붙여서 리스트 길이를 유지한다.
+func benchmarkPagedDelete(b *testing.B) {
+	store := &pagedlist.PagedStore{}
+	handle := openPagedList(b, store, listSize)
+	targets := listTargets(b, 2)
+	b.ResetTimer()
+	for _, target := range targets {
+		ok, err := store.DeleteFirstByValue(handle, target)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if !ok {
+			b.Fatalf("value %d not found", target)
+		}
+		b.StopTimer()
+		if err := store.AppendTail(handle, target); err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+	}
+}
+
+// PagedList 는 Paged 리스트 벤치마크 목록
+var PagedList = []Benchmark{
+	{Name: "PagedAppendTail", F: benchmarkPagedAppendTail},
+	{Name: "PagedAppendValues", F: benchmarkPagedAppendValues},
+	{Name: "PagedWhere", F: benchmarkPagedWhere},
+	{Name: "PagedTraverse", F: benchmarkPagedTraverse},
+	{Name: "PagedDelete", F: benchmarkPagedDelete},
+	{Name: "PagedPhysical", F: benchmarkPagedPhysical((*pagedlist.PagedStore).TraverseValuesPhysical)},
+	{Name: "PagedPhysicalParallel1", F: benchmarkPagedPhysicalParallel(1)},
+	{Name: "PagedPhysicalParallel4", F: benchmarkPagedPhysicalParallel(4)},
+	{Name: "PagedPhysicalParallel8", F: benchmarkPagedPhysicalParallel(8)},
+}
@@ -0,0 +1,156 @@
+package benchsuite
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/tmdgusya/btree/chapter01/page"
+	"github.com/tmdgusya/btree/workload"
+)
+
+// ==================================
+// Pager 벤치마크 (`btree bench -run Pager`)
+// ==================================
+// 평문 / 암호화 / double-write 설정별로 페이지 한 장 쓰기와 읽기를 잰다.
+// BufferPool 벤치마크는 같은 쓰기 스트림을 write-through / write-back 으로 흘려서 I/O 를 비교한다.
+
+// 미리 써 두는 페이지 수. 읽기는 이 범위에서 무작위로 고른다.
+const pagerPages = 256
+
+var pagerKey = []byte("0123456789abcdef")
+
+func openBenchPager(b *testing.B, opts page.PagerOptions) *page.Pager {
+	b.Helper()
+	pager, err := page.OpenPager(filepath.Join(b.TempDir(), "bench.db"), opts)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { pager.Close() })
+	return pager
+}
+
+// fillPages 는 1..pagerPages 페이지를 data 로 채운다. Pager 는 구멍을 허용하지 않으므로
+// 아무 페이지에나 쓰기 전에 자리를 잡아 둔다.
+func fillPages(b *testing.B, pager *page.Pager, data []byte) {
+	b.Helper()
+	for id := 1; id <= pagerPages; id++ {
+		if err := pager.WritePage(&page.Page{Id: id, Data: data}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func pagerPageIDs(b *testing.B) []uint32 {
+	gen, err := workload.New(workload.Config{Distribution: workload.Uniform, KeySpace: pagerPages, Seed: 1})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return gen.Keys(b.N)
+}
+
+func benchmarkPagerWrite(opts page.PagerOptions) func(b *testing.B) {
+	return func(b *testing.B) {
+		pager := openBenchPager(b, opts)
+		fillPages(b, pager, nil)
+		ids := pagerPageIDs(b)
+		data := make([]byte, pager.PageSize())
+		b.SetBytes(int64(len(data)))
+		b.ResetTimer()
+		for _, id := range ids {
+			if err := pager.WritePage(&page.Page{Id: int(id) + 1, Data: data}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func benchmarkPagerRead(opts page.PagerOptions) func(b *testing.B) {
+	return func(b *testing.B) {
+		pager := openBenchPager(b, opts)
+		data := make([]byte, pager.PageSize())
+		fillPages(b, pager, data)
+		ids := pagerPageIDs(b)
+		b.SetBytes(int64(len(data)))
+		b.ResetTimer()
+		for _, id := range ids {
+			if _, err := pager.ReadPage(int64(id) + 1); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// 버퍼 풀이 들고 있는 페이지 수와 Commit 간격(쓰기 수)
+const (
+	poolCapacity    = 64
+	poolCommitEvery = 16
+)
+
+// 앞쪽 페이지에 쓰기가 몰리는 (Zipfian) 스트림을 poolCommitEvery 번마다 Commit 하며 쓴다.
+// Close 의 Checkpoint 까지 포함해서 데이터 파일과 WAL 의 쓰기 / fsync 횟수를 op 당으로 보고한다.
+func benchmarkBufferPool(policy page.FlushPolicy) func(b *testing.B) {
+	return func(b *testing.B) {
+		path := filepath.Join(b.TempDir(), "bench.db")
+		// 자리를 잡는 I/O 는 버퍼 풀의 측정에 들어가지 않게 따로 연 Pager 로 한다.
+		pager, err := page.OpenPager(path, page.PagerOptions{})
+		if err != nil {
+			b.Fatal(err)
+		}
+		fillPages(b, pager, nil)
+		if err := pager.Sync(); err != nil {
+			b.Fatal(err)
+		}
+		if err := pager.Close(); err != nil {
+			b.Fatal(err)
+		}
+
+		pool, err := page.OpenBufferPool(path, page.PagerOptions{}, page.BufferPoolOptions{
+			Capacity: poolCapacity,
+			Policy:   policy,
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+		gen, err := workload.New(workload.Config{Distribution: workload.Zipfian, KeySpace: pagerPages, Seed: 1})
+		if err != nil {
+			b.Fatal(err)
+		}
+		ids := gen.Keys(b.N)
+		data := make([]byte, pool.PageSize())
+		b.SetBytes(int64(pool.PageSize()))
+		b.ResetTimer()
+		for i, id := range ids {
+			if err := pool.WritePage(&page.Page{Id: int(id) + 1, Data: data}); err != nil {
+				b.Fatal(err)
+			}
+			if (i+1)%poolCommitEvery == 0 {
+				if err := pool.Commit(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+		if err := pool.Close(); err != nil {
+			b.Fatal(err)
+		}
+		b.StopTimer()
+
+		m, wal := pool.Metrics(), pool.WALMetrics()
+		n := float64(b.N)
+		b.ReportMetric(float64(m.Writes)/n, "data-writes/op")
+		b.ReportMetric(float64(wal.Writes)/n, "wal-writes/op")
+		b.ReportMetric(float64(m.Syncs+wal.Syncs)/n, "syncs/op")
+	}
+}
+
+// Pager 는 Pager / BufferPool 벤치마크 목록.
+// double-write 쓰기는 페이지마다 fsync 두 번이라 디스크 속도에 좌우된다.
+var Pager = []Benchmark{
+	{Name: "PagerWrite", F: benchmarkPagerWrite(page.PagerOptions{})},
+	{Name: "PagerRead", F: benchmarkPagerRead(page.PagerOptions{})},
+	{Name: "PagerWriteEncrypted", F: benchmarkPagerWrite(page.PagerOptions{Key: pagerKey})},
+	{Name: "PagerReadEncrypted", F: benchmarkPagerRead(page.PagerOptions{Key: pagerKey})},
+	{Name: "PagerWriteDoubleWrite", F: benchmarkPagerWrite(page.PagerOptions{DoubleWrite: true})},
+	{Name: "PagerReadDoubleWrite", F: benchmarkPagerRead(page.PagerOptions{DoubleWrite: true})},
+	{Name: "BufferPoolWriteThrough", F: benchmarkBufferPool(page.WriteThrough)},
+	{Name: "BufferPoolWriteBack", F: benchmarkBufferPool(page.WriteBack)},
+}
@@ -0,0 +1,97 @@
+// Package bloom 은 "이 키는 확실히 없다" 를 디스크를 읽지 않고 알려 주는 Bloom filter 다.
+// 키마다 k 개의 비트를 켜 두고, 찾을 때 k 개가 모두 켜져 있을 때만 "있을 수도 있다" 고 답한다.
+// 없는 키를 있다고 잘못 답할 확률(false positive)은 키당 비트 수로 정한다. 있는 키를 없다고 답하는 일은 없다.
+//
+// 해시는 파일에 저장해도 실행마다 같도록 FNV-1a 64 비트를 쓰고, 두 반쪽으로 k 개를 만든다 (double hashing).
+package bloom
+
+import (
+	"errors"
+	"hash/fnv"
+	"math"
+)
+
+var ErrCorrupt = errors.New("bloom: corrupt filter")
+
+// DefaultBitsPerKey 는 false positive 약 1% 가 되는 값
+const DefaultBitsPerKey = 10
+
+// Filter 는 비트 배열과 해시 함수 개수 k
+type Filter struct {
+	bits []byte
+	k    int
+}
+
+// New 는 n 개의 키를 키당 bitsPerKey 비트로 담을 필터를 만든다.
+func New(n, bitsPerKey int) *Filter {
+	if bitsPerKey < 1 {
+		bitsPerKey = DefaultBitsPerKey
+	}
+	// 키가 아주 적을 때 false positive 가 치솟지 않도록 최소 64 비트
+	nbits := max(n*bitsPerKey, 64)
+	return &Filter{bits: make([]byte, (nbits+7)/8), k: hashCount(bitsPerKey)}
+}
+
+// hashCount 는 false positive 를 가장 낮추는 k = bitsPerKey * ln 2 (1~30 으로 자른다)
+func hashCount(bitsPerKey int) int {
+	return min(max(int(math.Round(float64(bitsPerKey)*math.Ln2)), 1), 30)
+}
+
+// EstimatedFalsePositiveRate 는 키당 bitsPerKey 비트일 때의 이론값 (1 - e^(-k/b))^k
+func EstimatedFalsePositiveRate(bitsPerKey int) float64 {
+	k := float64(hashCount(bitsPerKey))
+	return math.Pow(1-math.Exp(-k/float64(bitsPerKey)), k)
+}
+
+// Hash 는 필터가 쓰는 키 해시. 같은 키를 여러 필터에 물어볼 때 한 번만 계산하도록 공개한다.
+func Hash(key []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(key)
+	return h.Sum64()
+}
+
+func (f *Filter) Add(key []byte) { f.AddHash(Hash(key)) }
+
+func (f *Filter) AddHash(h uint64) {
+	nbits := uint64(len(f.bits)) * 8
+	h1, h2 := h&0xffffffff, h>>32|1 // h2 는 홀수여야 비트가 한쪽으로 몰리지 않는다
+	for i := 0; i < f.k; i++ {
+		pos := (h1 + uint64(i)*h2) % nbits
+		f.bits[pos/8] |= 1 << (pos % 8)
+	}
+}
+
+// MayContain 이 false 면 key 는 확실히 없다. true 면 있을 수도 있다.
+func (f *Filter) MayContain(key []byte) bool { return f.MayContainHash(Hash(key)) }
+
+func (f *Filter) MayContainHash(h uint64) bool {
+	nbits := uint64(len(f.bits)) * 8
+	h1, h2 := h&0xffffffff, h>>32|1
+	for i := 0; i < f.k; i++ {
+		pos := (h1 + uint64(i)*h2) % nbits
+		if f.bits[pos/8]&(1<<(pos%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Encode 는 필터를 [비트 배열][k(1)] 로 직렬화한다.
+func (f *Filter) Encode() []byte {
+	out := make([]byte, len(f.bits)+1)
+	copy(out, f.bits)
+	out[len(f.bits)] = byte(f.k)
+	return out
+}
+
+// Decode 는 Encode 의 결과를 읽는다. data 를 복사하지 않고 그대로 쓴다.
+func Decode(data []byte) (*Filter, error) {
+	if len(data) < 2 {
+		return nil, ErrCorrupt
+	}
+	k := int(data[len(data)-1])
+	if k < 1 || k > 30 {
+		return nil, ErrCorrupt
+	}
+	return &Filter{bits: data[:len(data)-1], k: k}, nil
+}
@@ -0,0 +1,395 @@
+// Package btree 는 교육용 메모리 B-Tree 와, 그 트리를 단계별로 그려 보여 주는 웹 서버다.
+// 디스크 위의 구조는 chapter01 / chapter02 패키지에 있고, 실행 파일은 cmd/btree 하나로 모았다.
+package btree
+
+import (
+	"errors"
+	"slices"
+)
+
+// BTreeNode 의 values[i] 는 keys[i] 의 값이다. 키를 옮기는 곳(분할, borrow, merge ...)은 값도 같이 옮긴다.
+type BTreeNode struct {
+	keys     []int
+	values   [][]byte
+	children []*BTreeNode
+	isLeaf   bool
+}
+
+type BTree struct {
+	root   *BTreeNode
+	t      int
+	linear bool   // 노드 안에서 키를 앞에서부터 하나씩 비교한다 (SetLinearSearch)
+	arena  *Arena // nil 이 아니면 새 노드를 여기서 잘라 쓴다 (SetArena)
+}
+
+// ErrInvalidDegree 는 최소 차수 t 가 2 보다 작을 때
+var ErrInvalidDegree = errors.New("minimum degree t must be at least 2")
+
+// New 는 최소 차수 t 인 빈 트리를 만든다.
+func New(t int) (*BTree, error) {
+	if t < 2 {
+		return nil, ErrInvalidDegree
+	}
+	return &BTree{t: t}, nil
+}
+
+// SetLinearSearch 는 노드 안에서 키를 찾는 방법을 고른다. 기본은 이진 탐색이고,
+// true 면 튜토리얼의 처음 버전처럼 앞에서부터 하나씩 비교한다. 트레이스의 비교 횟수로 두 방법을 견줘 볼 수 있다.
+func (b *BTree) SetLinearSearch(linear bool) {
+	b.linear = linear
+}
+
+// FindChildIndex 는 k 가 내려갈 자식 번호, 즉 k 보다 큰 첫 키의 위치를 이진 탐색으로 찾는다.
+func (x *BTreeNode) FindChildIndex(k int) int {
+	return x.findChildIndex(k, false, nil, "")
+}
+
+// FindChildIndexLinear 는 FindChildIndex 와 같은 위치를 키를 앞에서부터 하나씩 비교해서 찾는다.
+func (x *BTreeNode) FindChildIndexLinear(k int) int {
+	return x.findChildIndex(k, true, nil, "")
+}
+
+func (x *BTreeNode) findChildIndex(k int, linear bool, tr *Trace, path string) int {
+	if linear {
+		lastIndex := len(x.keys)
+		for i := 0; i < len(x.keys); i++ {
+			tr.compare(path, x, i, k)
+			if k < x.keys[i] {
+				return i
+			}
+		}
+		return lastIndex
+	}
+
+	// keys[:lo] 는 모두 k 이하, keys[hi:] 는 모두 k 보다 크다.
+	lo, hi := 0, len(x.keys)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		tr.compare(path, x, mid, k)
+		if k < x.keys[mid] {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return lo
+}
+
+// lowerBound 는 k 이상인 첫 키의 위치. 검색은 같은 키를 만나면 멈춰야 하므로 FindChildIndex 대신 이것을 쓴다.
+func (x *BTreeNode) lowerBound(k int, linear bool, tr *Trace, path string) int {
+	if linear {
+		i := 0
+		for i < len(x.keys) {
+			tr.compare(path, x, i, k)
+			if k <= x.keys[i] {
+				break
+			}
+			i++
+		}
+		return i
+	}
+
+	lo, hi := 0, len(x.keys)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		tr.compare(path, x, mid, k)
+		if k <= x.keys[mid] {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return lo
+}
+
+func (x *BTreeNode) Search(k int) (*BTreeNode, int) {
+	for i := 0; i < len(x.keys); i++ {
+		if x.keys[i] == k {
+			return x, i
+		}
+	}
+
+	if x.isLeaf {
+		return nil, -1
+	}
+
+	i := x.FindChildIndex(k)
+
+	return x.children[i].Search(k)
+}
+
+func (x *BTreeNode) SplitChild(i int, t int) {
+	x.splitChild(i, t, nil, nil, "")
+}
+
+// newNode 는 keys / values / children 을 꽉 찬 노드 크기(2t-1 / 2t-1 / 2t)의 용량으로 만든다.
+// 삽입과 분할이 새 슬라이스를 할당하지 않고 남는 용량 안에서 제자리로 밀고 자를 수 있다.
+func newNode(t int, isLeaf bool) *BTreeNode {
+	x := &BTreeNode{keys: make([]int, 0, 2*t-1), values: make([][]byte, 0, 2*t-1), isLeaf: isLeaf}
+	if !isLeaf {
+		x.children = make([]*BTreeNode, 0, 2*t)
+	}
+	return x
+}
+
+func (x *BTreeNode) splitChild(i int, t int, a *Arena, tr *Trace, path string) {
+	y := x.children[i]
+	median := t - 1
+	z := a.newNode(t, y.isLeaf)
+
+	// y 는 앞쪽 t-1 개만 남기고 같은 배열을 그대로 쓴다. 뒤쪽 t-1 개는 z 로 복사한다.
+	midKey, midValue := y.keys[median], y.values[median]
+	z.keys = append(z.keys, y.keys[median+1:]...)
+	y.keys = y.keys[:median]
+	z.values = append(z.values, y.values[median+1:]...)
+	clear(y.values[median:])
+	y.values = y.values[:median]
+
+	if !y.isLeaf {
+		z.children = append(z.children, y.children[median+1:]...)
+		clear(y.children[median+1:])
+		y.children = y.children[:median+1]
+	}
+
+	x.keys = slices.Insert(x.keys, i, midKey)
+	x.values = slices.Insert(x.values, i, midValue)
+	x.children = slices.Insert(x.children, i+1, z)
+
+	tr.add(TraceEvent{Kind: TraceSplit, Node: path, Index: i, Key: midKey})
+}
+
+func (x *BTreeNode) InsertNonFull(k int, value []byte, t int) {
+	x.insertNonFull(k, value, t, false, false, nil, nil, "", 0)
+}
+
+// cow 면 내려가는 자식을 고치기 전에 복사해서 바꿔 끼운다 (InsertCopy). x 는 이미 복사본이어야 한다.
+// path 는 x 의 이름, index 는 x 가 부모의 몇 번째 자식인지 (트레이스용).
+func (x *BTreeNode) insertNonFull(k int, value []byte, t int, linear, cow bool, a *Arena, tr *Trace, path string, index int) {
+	tr.visit(path, x)
+	if x.isLeaf {
+		// 꽉 차지 않은 노드만 내려오므로 용량 안에서 한 칸 늘리고 뒤에서부터 민다.
+		x.keys = append(x.keys, 0)
+		x.values = append(x.values, nil)
+
+		i := len(x.keys) - 2
+		for ; i >= 0; i-- {
+			tr.compare(path, x, i, k)
+			if k < x.keys[i] {
+				x.keys[i+1] = x.keys[i]
+				x.values[i+1] = x.values[i]
+			} else {
+				break
+			}
+		}
+		x.keys[i+1] = k
+		x.values[i+1] = value
+		tr.add(TraceEvent{Kind: TraceInsert, Node: path, Index: i + 1, Key: k})
+		tr.step(index, i+1, DirInsert)
+	} else {
+		idx := x.findChildIndex(k, linear, tr, path)
+		if cow {
+			x.children[idx] = x.children[idx].clone(t)
+		}
+
+		if len(x.children[idx].keys) == 2*t-1 {
+			x.splitChild(idx, t, a, tr, path)
+
+			tr.compare(path, x, idx, k)
+			if x.keys[idx] < k {
+				idx++
+			}
+		}
+
+		tr.step(index, idx, DirDown)
+		x.children[idx].insertNonFull(k, value, t, linear, cow, a, tr, tr.child(path, idx), idx)
+	}
+}
+
+// Insert 는 k 와 그 값을 넣는다. 이미 있는 키면 트리 모양은 그대로 두고 그 키의 값만 value 로 바꾸고 true 를 돌려준다.
+// value 는 nil 이어도 되고, 트리가 복사하지 않고 그대로 들고 있으므로 넣은 뒤에는 고치지 않는다.
+func (b *BTree) Insert(k int, value []byte) bool {
+	return b.insert(k, value, nil, false)
+}
+
+// InsertTrace 는 Insert 를 하면서 방문한 노드, 비교, 분할을 기록해서 돌려준다. Found 는 값만 바꿨는지다.
+func (b *BTree) InsertTrace(k int, value []byte) *Trace {
+	tr := &Trace{Op: "insert", Key: k}
+	tr.Found = b.insert(k, value, tr, false)
+	return tr
+}
+
+func (b *BTree) insert(k int, value []byte, tr *Trace, cow bool) bool {
+	if b.lookupForInsert(k, tr) {
+		b.root.setValue(k, value, b.t, cow, tr, "root")
+		return true
+	}
+
+	if b.root == nil {
+		b.root = b.arena.newNode(b.t, true)
+		b.root.keys = append(b.root.keys, k)
+		b.root.values = append(b.root.values, value)
+		tr.add(TraceEvent{Kind: TraceNewRoot, Node: "root", Keys: []int{k}})
+		tr.step(0, 0, DirInsert)
+		return false
+	}
+
+	if len(b.root.keys) == 2*b.t-1 {
+		node := b.arena.newNode(b.t, false)
+		node.children = append(node.children, b.root)
+		node.splitChild(0, b.t, b.arena, tr, "root")
+		b.root = node
+		tr.add(TraceEvent{Kind: TraceNewRoot, Node: "root", Keys: append([]int(nil), node.keys...)})
+	}
+
+	b.root.insertNonFull(k, value, b.t, b.linear, cow, b.arena, tr, "root", 0)
+	return false
+}
+
+// lookupForInsert 는 k 가 이미 있는지 본다. 있는 키를 넣느라 내려가며 분할해서 트리 모양만 바뀌지 않도록 먼저 찾는다.
+// 있으면 이 검색의 기록이 곧 값을 바꾼 삽입의 기록이고, 없으면 기록을 비우고 실제 삽입을 기록한다.
+func (b *BTree) lookupForInsert(k int, tr *Trace) bool {
+	if b.root == nil {
+		return false
+	}
+	if x, _ := searchWithTrace(b.root, "root", 0, k, b.linear, tr); x != nil {
+		return true
+	}
+	if tr != nil {
+		tr.Path, tr.Events = tr.Path[:0], tr.Events[:0]
+	}
+	return false
+}
+
+// setValue 는 있는 것을 확인한 k 의 값을 value 로 바꾼다. 같은 키가 여럿이면 검색이 찾는 것, 즉 루트에서 가장 가까운 것이다.
+// cow 면 x 는 이미 복사본이고, 키가 있는 노드까지 내려가는 자식을 복사해서 바꿔 끼운다.
+func (x *BTreeNode) setValue(k int, value []byte, t int, cow bool, tr *Trace, path string) {
+	for {
+		i := x.lowerBound(k, false, nil, "")
+		if i < len(x.keys) && x.keys[i] == k {
+			x.values[i] = value
+			tr.add(TraceEvent{Kind: TraceUpdate, Node: path, Index: i, Key: k})
+			return
+		}
+		if cow {
+			x.children[i] = x.children[i].clone(t)
+		}
+		x, path = x.children[i], tr.child(path, i)
+	}
+}
+
+// Search 는 k 의 값을 찾는다. 같은 키가 여럿이면 루트에서 가장 가까운 것 하나의 값이다.
+// 값 없이 넣은 키는 nil, true 이므로 있는지는 두 번째 반환값으로 본다.
+func (b *BTree) Search(k int) ([]byte, bool) {
+	x := b.root
+	for x != nil {
+		i := x.lowerBound(k, b.linear, nil, "")
+		if i < len(x.keys) && x.keys[i] == k {
+			return x.values[i], true
+		}
+		if x.isLeaf {
+			break
+		}
+		x = x.children[i]
+	}
+	return nil, false
+}
+
+// SearchPath 는 k 를 찾으며 내려간 경로와 찾았는지를 돌려준다. 노드 이름은 Path.Labels 로 만든다.
+func (b *BTree) SearchPath(k int) (Path, bool) {
+	if b.root == nil {
+		return nil, false
+	}
+	tr := b.SearchTrace(k)
+	return tr.Path, tr.Found
+}
+
+// SearchTrace 는 k 를 찾으며 방문한 노드와 비교를 기록해서 돌려준다.
+func (b *BTree) SearchTrace(k int) *Trace {
+	tr, _ := b.searchValueTrace(k)
+	return tr
+}
+
+// searchValueTrace 는 SearchTrace 이면서 찾은 키의 값도 돌려준다. 값은 기록의 마지막 노드에서 꺼내므로 트리를 다시 내려가지 않는다.
+func (b *BTree) searchValueTrace(k int) (*Trace, []byte) {
+	tr := &Trace{Op: "search", Key: k}
+	if b.root == nil {
+		return tr, nil
+	}
+	x, i := searchWithTrace(b.root, "root", 0, k, b.linear, tr)
+	if x == nil {
+		return tr, nil
+	}
+	tr.Found = true
+	return tr, x.values[i]
+}
+
+// searchWithTrace 는 k 가 있는 노드와 그 안의 위치를 돌려준다. 없으면 nil 이다.
+func searchWithTrace(node *BTreeNode, label string, index, k int, linear bool, tr *Trace) (*BTreeNode, int) {
+	tr.visit(label, node)
+
+	i := node.lowerBound(k, linear, tr, label)
+	if i < len(node.keys) && node.keys[i] == k {
+		tr.step(index, i, DirFound)
+		return node, i
+	}
+
+	if node.isLeaf || i >= len(node.children) {
+		tr.step(index, i, DirMiss)
+		return nil, 0
+	}
+
+	tr.step(index, i, DirDown)
+	return searchWithTrace(node.children[i], tr.child(label, i), i, k, linear, tr)
+}
+
+// Root 는 루트 노드. 빈 트리면 nil 이다.
+func (b *BTree) Root() *BTreeNode {
+	return b.root
+}
+
+// Height 는 루트에서 잎까지의 노드 수. 빈 트리는 0, 루트 하나뿐이면 1. 모든 잎이 같은 깊이이므로 맨 왼쪽 길만 본다.
+func (b *BTree) Height() int {
+	if b.root == nil {
+		return 0
+	}
+	h := 1
+	for x := b.root; !x.isLeaf; x = x.children[0] {
+		h++
+	}
+	return h
+}
+
+// Range 는 lo <= k <= hi 인 키를 오름차순으로 fn 에 넘긴다. fn 이 false 를 돌려주면 멈춘다.
+// 범위 밖의 서브트리는 내려가지 않으므로 찾는 키 수 + 높이 정도의 노드만 본다.
+func (b *BTree) Range(lo, hi int, fn func(k int) bool) {
+	b.RangeValues(lo, hi, func(k int, _ []byte) bool { return fn(k) })
+}
+
+// RangeValues 는 Range 와 같고 키마다 값도 같이 넘긴다.
+func (b *BTree) RangeValues(lo, hi int, fn func(k int, value []byte) bool) {
+	if b.root == nil || lo > hi {
+		return
+	}
+	b.root.rangeKeys(lo, hi, fn)
+}
+
+func (x *BTreeNode) rangeKeys(lo, hi int, fn func(k int, value []byte) bool) bool {
+	for i, k := range x.keys {
+		// children[i] 에는 keys[i] 보다 작은 (분할 때문에 같은 값도) 키가 있다.
+		if !x.isLeaf && lo <= k {
+			if !x.children[i].rangeKeys(lo, hi, fn) {
+				return false
+			}
+		}
+		if k > hi {
+			return false
+		}
+		if k >= lo && !fn(k, x.values[i]) {
+			return false
+		}
+	}
+	if !x.isLeaf {
+		return x.children[len(x.keys)].rangeKeys(lo, hi, fn)
+	}
+	return true
+}
@@ -0,0 +1,180 @@
+package btree
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// 이미 있는 키를 다시 넣으면 키 수와 트리 모양은 그대로고 값만 바뀐다.
+// 키가 잎에도 내부 노드에도 있도록 여러 높이의 트리를 만들어 모든 키를 한 번씩 다시 넣는다.
+func TestInsertReplacesValue(t *testing.T) {
+	const n = 200
+	tree, err := New(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k := 0; k < n; k++ {
+		if tree.Insert(k, []byte("old")) {
+			t.Fatalf("insert %d into a tree without it reported a replace", k)
+		}
+	}
+	height := tree.Height()
+
+	for k := 0; k < n; k++ {
+		if !tree.Insert(k, []byte(fmt.Sprint("new", k))) {
+			t.Fatalf("insert %d again did not report a replace", k)
+		}
+	}
+	keys, err := tree.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys != n || tree.Height() != height {
+		t.Fatalf("tree has %d keys and height %d, want %d keys and height %d", keys, tree.Height(), n, height)
+	}
+	for k := 0; k < n; k++ {
+		if v, ok := tree.Search(k); !ok || string(v) != fmt.Sprint("new", k) {
+			t.Fatalf("search %d = %q, %v", k, v, ok)
+		}
+	}
+}
+
+// copy-on-write 로 값을 바꾸면 새 트리만 바뀌고 이전 트리는 이전 값을 그대로 읽는다.
+func TestInsertCopyReplacesValue(t *testing.T) {
+	const n = 50
+	tree, err := New(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k := 0; k < n; k++ {
+		tree, _ = tree.InsertCopy(k, []byte("old"))
+	}
+
+	for k := 0; k < n; k++ {
+		next, tr := tree.InsertCopyTrace(k, []byte("new"))
+		if !tr.Found {
+			t.Fatalf("insert %d again: trace does not report a replace", k)
+		}
+		if v, _ := tree.Search(k); string(v) != "old" {
+			t.Fatalf("key %d in the old tree = %q, want old", k, v)
+		}
+		if v, _ := next.Search(k); string(v) != "new" {
+			t.Fatalf("key %d in the new tree = %q, want new", k, v)
+		}
+		if keys, err := next.Check(); err != nil || keys != n {
+			t.Fatalf("new tree: %d keys, err %v", keys, err)
+		}
+		tree = next
+	}
+}
+
+// 검색 트레이스와 함께 꺼낸 값은 Search 의 값과 같다.
+func TestSearchValueTrace(t *testing.T) {
+	tree, err := New(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k := 0; k < 100; k += 2 {
+		tree.Insert(k, []byte(fmt.Sprint(k)))
+	}
+	for k := -1; k < 101; k++ {
+		tr, value := tree.searchValueTrace(k)
+		want, ok := tree.Search(k)
+		if tr.Found != ok || string(value) != string(want) {
+			t.Fatalf("key %d: trace found %v value %q, Search %v %q", k, tr.Found, value, ok, want)
+		}
+	}
+}
+
+// 무작위로 넣고 지우면서 매 단계 map 과 비교하고 Check 로 불변식을 본다.
+// 키 범위를 좁게 잡아서 없는 키 삭제, 빌려 오기(borrow), 합치기(merge), 높이 줄이기가 모두 자주 일어나게 한다.
+func TestRandomInsertDelete(t *testing.T) {
+	for _, degree := range []int{2, 3} {
+		t.Run(fmt.Sprint("t=", degree), func(t *testing.T) {
+			rng := rand.New(rand.NewSource(int64(degree)))
+			tree, err := New(degree)
+			if err != nil {
+				t.Fatal(err)
+			}
+			model := map[int]string{}
+			for step := 0; step < 5000; step++ {
+				k := rng.Intn(200)
+				if rng.Intn(2) == 0 {
+					v := fmt.Sprint(k, "@", step)
+					_, existed := model[k]
+					if replaced := tree.Insert(k, []byte(v)); replaced != existed {
+						t.Fatalf("step %d: insert %d replaced=%v, key existed=%v", step, k, replaced, existed)
+					}
+					model[k] = v
+				} else {
+					_, existed := model[k]
+					if deleted := tree.Delete(k); deleted != existed {
+						t.Fatalf("step %d: delete %d = %v, key existed=%v", step, k, deleted, existed)
+					}
+					delete(model, k)
+				}
+
+				keys, err := tree.Check()
+				if err != nil {
+					t.Fatalf("step %d: %v", step, err)
+				}
+				if keys != len(model) {
+					t.Fatalf("step %d: tree has %d keys, model %d", step, keys, len(model))
+				}
+				if v, ok := tree.Search(k); ok != (model[k] != "") || string(v) != model[k] {
+					t.Fatalf("step %d: search %d = %q, %v; want %q", step, k, v, ok, model[k])
+				}
+			}
+			for k := 0; k < 200; k++ {
+				if v, ok := tree.Search(k); ok != (model[k] != "") || string(v) != model[k] {
+					t.Fatalf("search %d = %q, %v; want %q", k, v, ok, model[k])
+				}
+			}
+		})
+	}
+}
+
+// DeleteCopy 는 새 트리에서만 지우고, 이전 트리의 키와 값은 그대로 남는다. 없는 키는 false 이고 같은 트리를 돌려준다.
+func TestDeleteCopyKeepsOldTree(t *testing.T) {
+	const n = 100
+	tree, err := New(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k := 0; k < n; k++ {
+		tree.Insert(k, []byte(fmt.Sprint(k)))
+	}
+
+	old := tree
+	for k := 0; k < n; k += 3 {
+		next, ok := tree.DeleteCopy(k)
+		if !ok {
+			t.Fatalf("DeleteCopy(%d) = false", k)
+		}
+		if _, found := next.Search(k); found {
+			t.Fatalf("key %d still in the new tree", k)
+		}
+		if keys, err := next.Check(); err != nil || keys != n-k/3-1 {
+			t.Fatalf("new tree after deleting %d: %d keys, err %v", k, keys, err)
+		}
+		tree = next
+	}
+
+	if keys, err := old.Check(); err != nil || keys != n {
+		t.Fatalf("old tree: %d keys, err %v", keys, err)
+	}
+	for k := 0; k < n; k++ {
+		if v, ok := old.Search(k); !ok || string(v) != fmt.Sprint(k) {
+			t.Fatalf("old tree search %d = %q, %v", k, v, ok)
+		}
+	}
+
+	same, ok := tree.DeleteCopy(n + 1)
+	if ok || same != tree {
+		t.Fatalf("DeleteCopy of a missing key = %v, same tree %v", ok, same == tree)
+	}
+	if tree.Delete(n + 1) {
+		t.Fatal("Delete of a missing key = true")
+	}
+}
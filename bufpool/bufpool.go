@@ -0,0 +1,74 @@
+// Package bufpool 은 페이지 크기의 바이트 버퍼를 sync.Pool 로 재사용한다.
+// Pager 와 리스트 저장소는 읽고 쓸 때마다 4 KB 페이지 버퍼를 잠깐 쓰고 버리는데,
+// 벤치마크처럼 같은 연산을 수십만 번 돌리면 그 할당이 GC 부담의 대부분이 된다.
+// 슬롯이나 페이지 헤더처럼 크기가 상수인 작은 버퍼는 여기서 빌리지 말고 `var buf [SLOT_SIZE]byte` 처럼 스택 배열을 쓴다.
+// File 인터페이스로 넘기면 배열이 힙으로 옮겨지지만, 십여 바이트짜리 할당은 풀을 오가는 것과 비용이 비슷하고 빌리고 돌려줄 일이 없다.
+//
+// 크기별로 2 의 거듭제곱 등급(8 B ~ 2 MB)을 두고, 요청한 크기를 담을 수 있는 가장 작은 등급에서 빌려준다.
+// 빌린 버퍼는 함수 밖으로 내보내지 말고 (돌려줄 Page.Data 등) 다 쓰면 Put 으로 돌려준다.
+//
+//	bp := bufpool.Get(n)
+//	defer bufpool.Put(bp)
+//	buf := *bp
+package bufpool
+
+import (
+	"math/bits"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	minShift = 3  // 8 B
+	maxShift = 21 // 2 MB: 가장 큰 페이지(1 MB)에 암호화 / 꼬리가 붙어도 들어간다
+)
+
+var pools [maxShift - minShift + 1]sync.Pool
+
+// 새로 할당한 횟수와 풀에서 꺼낸 횟수. 벤치마크에서 재사용률을 보려는 용도.
+var allocs, reuses atomic.Int64
+
+// class 는 n 바이트를 담는 등급 번호. 너무 크면 -1.
+func class(n int) int {
+	if n <= 1<<minShift {
+		return 0
+	}
+	shift := bits.Len(uint(n - 1))
+	if shift > maxShift {
+		return -1
+	}
+	return shift - minShift
+}
+
+// Get 은 길이 n 인 버퍼를 빌려준다. 이전에 쓰던 내용이 남아 있을 수 있으므로 0 이 필요하면 clear 한다.
+// 등급보다 큰 n 은 풀을 거치지 않고 새로 할당한다 (Put 해도 버린다).
+func Get(n int) *[]byte {
+	c := class(n)
+	if c < 0 {
+		allocs.Add(1)
+		b := make([]byte, n)
+		return &b
+	}
+	if bp, ok := pools[c].Get().(*[]byte); ok {
+		reuses.Add(1)
+		*bp = (*bp)[:n]
+		return bp
+	}
+	allocs.Add(1)
+	b := make([]byte, n, 1<<(c+minShift))
+	return &b
+}
+
+// Put 은 Get 으로 빌린 버퍼를 돌려준다. 돌려준 뒤에는 그 버퍼를 읽거나 쓰면 안 된다.
+func Put(bp *[]byte) {
+	c := class(cap(*bp))
+	if c < 0 || cap(*bp) != 1<<(c+minShift) {
+		return
+	}
+	pools[c].Put(bp)
+}
+
+// Stats 는 지금까지 새로 할당한 버퍼 수와 풀에서 재사용한 버퍼 수
+func Stats() (allocated, reused int64) {
+	return allocs.Load(), reuses.Load()
+}
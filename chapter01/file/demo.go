@@ -0,0 +1,65 @@
+package file
+
+import (
+	"fmt"
+	"os"
+)
+
+// Demo 는 정수 24 개와 덜 찬 꼬리 하나를 test.txt 에 쓰고 PageManager 로 페이지를 다시 읽어 출력한다.
+// 튜토리얼 데모라서 실패하면 panic 한다.
+func Demo() {
+	f, err := os.OpenFile("test.txt", os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+
+	pageManager, err := NewPageManager(f, PAGE_SIZE)
+	if err != nil {
+		panic(err)
+	}
+
+	arr := make([]uint32, INT_LENGTH)
+
+	for i := 0; i < INT_LENGTH; i++ {
+		arr[i] = uint32(i)
+	}
+
+	_, err = f.Write(IntSliceToBytes(arr))
+	if err != nil {
+		panic(err)
+	}
+
+	// 페이지 크기의 배수가 아닌 만큼 더 써서 마지막 페이지를 덜 채운다.
+	count, err := pageManager.PageCount()
+	if err != nil {
+		panic(err)
+	}
+	if err := pageManager.WritePage(count, IntSliceToBytes([]uint32{100, 101})); err != nil {
+		panic(err)
+	}
+
+	if err := pageManager.ReadAll(); err != nil {
+		panic(err)
+	}
+
+	page, err := pageManager.ReadAt(0)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("%v\n", BytesToIntSlice(page))
+
+	count, err = pageManager.PageCount()
+	if err != nil {
+		panic(err)
+	}
+	last, err := pageManager.ReadAt(count - 1)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("pages=%d (page size %d), last page %d bytes: %v\n", count, pageManager.PageSize(), len(last), BytesToIntSlice(last))
+
+	if _, err := pageManager.ReadAt(count); err != nil {
+		fmt.Println("read past the end:", err)
+	}
+}
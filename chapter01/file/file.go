@@ -0,0 +1,171 @@
+// Package file 은 chapter01 의 첫 예제: 정수 배열을 파일에 쓰고 고정 크기 페이지 단위로 다시 읽는다.
+package file
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/tmdgusya/btree/codec"
+)
+
+const PAGE_SIZE = 16 // Byte, 페이지 크기를 주지 않았을 때
+const INT_LENGTH = 24
+const BYTE_LENGTH = INT_LENGTH * 4
+
+var (
+	ErrInvalidPageSize = errors.New("file: invalid page size")
+	ErrPageOutOfRange  = errors.New("file: page out of range")
+	ErrPageTooLarge    = errors.New("file: data is larger than a page")
+)
+
+type Page struct {
+	Id   int32
+	Data []byte // 마지막 페이지가 덜 찼으면 파일에 있는 만큼만 담긴다
+}
+
+// PageManager 는 파일을 pageSize 바이트씩 잘라서 페이지로 다룬다.
+// 파일 크기가 pageSize 의 배수가 아니면 마지막 페이지는 덜 찬 채로 읽힌다.
+// ReadAll 로 읽어 둔 페이지는 pages 에 들고 있고, WritePage 가 쓴 페이지는 그 자리도 고친다.
+type PageManager struct {
+	f        *os.File
+	pageSize int
+	pages    []*Page
+}
+
+// NewPageManager 는 f 위에 페이지 관리자를 만든다. pageSize 가 0 이면 PAGE_SIZE.
+func NewPageManager(f *os.File, pageSize int) (*PageManager, error) {
+	if pageSize == 0 {
+		pageSize = PAGE_SIZE
+	}
+	if pageSize < 0 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidPageSize, pageSize)
+	}
+	return &PageManager{f: f, pageSize: pageSize}, nil
+}
+
+// PageSize 는 페이지 하나의 바이트 수
+func (p *PageManager) PageSize() int {
+	return p.pageSize
+}
+
+// PageCount 는 파일 크기를 페이지 크기로 나눈 값 (덜 찬 마지막 페이지도 하나로 센다)
+func (p *PageManager) PageCount() (int32, error) {
+	info, err := p.f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return int32((info.Size() + int64(p.pageSize) - 1) / int64(p.pageSize)), nil
+}
+
+// ReadAt 은 id 페이지의 데이터. ReadAll 로 읽어 둔 페이지가 있으면 그것을, 없으면 파일에서 읽는다.
+func (p *PageManager) ReadAt(id int32) ([]byte, error) {
+	if id >= 0 && int(id) < len(p.pages) {
+		return p.pages[id].Data, nil
+	}
+	pg, err := p.ReadPage(id)
+	if err != nil {
+		return nil, err
+	}
+	return pg.Data, nil
+}
+
+// ReadPage 는 id 페이지를 파일에서 읽는다. 파일 끝에 걸친 페이지는 남은 바이트만 담는다.
+func (p *PageManager) ReadPage(id int32) (*Page, error) {
+	count, err := p.PageCount()
+	if err != nil {
+		return nil, err
+	}
+	if id < 0 || id >= count {
+		return nil, fmt.Errorf("%w: page %d (page count %d)", ErrPageOutOfRange, id, count)
+	}
+
+	buf := make([]byte, p.pageSize)
+	n, err := p.f.ReadAt(buf, int64(id)*int64(p.pageSize))
+	if err != nil && !(errors.Is(err, io.EOF) && n > 0) {
+		return nil, fmt.Errorf("file: read page %d: %w", id, err)
+	}
+	return &Page{Id: id, Data: buf[:n]}, nil
+}
+
+// ReadAll 은 파일의 모든 페이지를 읽어서 들고 있는다.
+func (p *PageManager) ReadAll() error {
+	count, err := p.PageCount()
+	if err != nil {
+		return err
+	}
+	pages := make([]*Page, count)
+	for i := int32(0); i < count; i++ {
+		if pages[i], err = p.ReadPage(i); err != nil {
+			return err
+		}
+	}
+	p.pages = pages
+	return nil
+}
+
+// WritePage 는 data 를 id 페이지 자리에 쓴다.
+// - id 는 지금 있는 페이지이거나 바로 다음 페이지여야 한다. 그 뒤에 쓰면 사이에 구멍이 생기므로 막는다.
+// - data 가 페이지보다 짧으면 마지막 페이지일 때는 그만큼만 써서 (파일도 거기서 끝난다) 덜 찬 페이지로 두고,
+// 중간 페이지일 때는 뒤를 0 으로 채워서 이전 내용이 남지 않게 한다.
+func (p *PageManager) WritePage(id int32, data []byte) error {
+	if len(data) > p.pageSize {
+		return fmt.Errorf("%w: %d bytes (page size %d)", ErrPageTooLarge, len(data), p.pageSize)
+	}
+	count, err := p.PageCount()
+	if err != nil {
+		return err
+	}
+	if id < 0 || id > count {
+		return fmt.Errorf("%w: page %d (page count %d)", ErrPageOutOfRange, id, count)
+	}
+
+	buf := data
+	if id < count-1 && len(data) < p.pageSize {
+		buf = make([]byte, p.pageSize)
+		copy(buf, data)
+	}
+	off := int64(id) * int64(p.pageSize)
+	if _, err := p.f.WriteAt(buf, off); err != nil {
+		return fmt.Errorf("file: write page %d: %w", id, err)
+	}
+	if id == count-1 && len(buf) < p.pageSize {
+		// 마지막 페이지를 더 짧게 고쳐 썼으면 예전 꼬리를 잘라 낸다.
+		if err := p.f.Truncate(off + int64(len(buf))); err != nil {
+			return fmt.Errorf("file: write page %d: %w", id, err)
+		}
+	}
+
+	// 들고 있는 페이지도 파일과 같게 맞춘다. 덜 찬 마지막 페이지 뒤에 새 페이지를 쓰면 그 사이는 0 으로 채워진다.
+	if id == count && id > 0 && int(id) <= len(p.pages) {
+		prev := p.pages[id-1]
+		prev.Data = append(prev.Data, make([]byte, p.pageSize-len(prev.Data))...)
+	}
+	pg := &Page{Id: id, Data: append([]byte(nil), buf...)}
+	switch {
+	case int(id) < len(p.pages):
+		p.pages[id] = pg
+	case int(id) == len(p.pages) && p.pages != nil:
+		p.pages = append(p.pages, pg)
+	}
+	return nil
+}
+
+func IntSliceToBytes(nums []uint32) []byte {
+	buf := make([]byte, 4*len(nums))
+	w := codec.NewWriter(buf)
+	for _, n := range nums {
+		w.Uint32(n)
+	}
+	return buf
+}
+
+func BytesToIntSlice(buf []byte) []int {
+	out := make([]int, len(buf)/4)
+	r := codec.NewReader(buf)
+	for i := range out {
+		out[i] = int(r.Uint32())
+	}
+	return out
+}
@@ -0,0 +1,574 @@
+package page
+
+import (
+	"bytes"
+	"cmp"
+	"container/list"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/tmdgusya/btree/bufpool"
+	"github.com/tmdgusya/btree/chapter04/logstore"
+	"github.com/tmdgusya/btree/codec"
+	"github.com/tmdgusya/btree/dberr"
+	"github.com/tmdgusya/btree/iometrics"
+)
+
+// ==================================
+// BufferPool: write-through / write-back
+// ==================================
+// Pager 위에 페이지 캐시를 얹는다. 읽기는 캐시에서 먼저 찾고, 고친 페이지를 언제 데이터 파일에 내릴지는 FlushPolicy 로 고른다.
+// - WriteThrough: WritePage 마다 데이터 파일 제자리에 바로 쓴다. Commit 은 데이터 파일을 fsync 한다.
+//   흩어진 페이지를 고치면 흩어진 쓰기가 그대로 디스크로 간다.
+// - WriteBack: WritePage 는 페이지 이미지를 WAL(path + ".wal" 디렉터리의 logstore 로그) 끝에 덧붙이고 캐시의 페이지를 dirty 로 표시만 한다.
+//   Commit 은 WAL 만 fsync 한다 (순차 쓰기 + fsync 한 번). dirty 페이지는 LRU 에서 밀려날 때나
+//   Checkpoint / Close 때 데이터 파일에 쓴다. 같은 페이지를 여러 번 고쳐도 데이터 파일에는 한 번만 쓴다.
+// 죽은 뒤 다시 열면 WAL 에 남은 페이지 이미지를 차례로 데이터 파일에 다시 써서(redo) 마지막 Commit 까지 되살린다.
+// 페이지마다 마지막 변경의 LSN 을 찍어 두므로 (lsn.go) 이미 데이터 파일에 들어간 기록은 재생에서 건너뛴다.
+// 두 정책의 차이는 Metrics(데이터 파일)와 WALMetrics 의 Writes, Syncs, SeekDistance 로 비교한다.
+
+// FlushPolicy 는 버퍼 풀이 고친 페이지를 언제 데이터 파일에 쓸지 정한다.
+type FlushPolicy int
+
+const (
+	// WriteThrough: WritePage 가 바로 데이터 파일에 쓴다.
+	WriteThrough FlushPolicy = iota
+	// WriteBack: WritePage 는 WAL 에만 쓰고, 데이터 파일에는 나중에 모아서 쓴다.
+	WriteBack
+)
+
+var flushPolicyNames = map[FlushPolicy]string{
+	WriteThrough: "write-through",
+	WriteBack:    "write-back",
+}
+
+func (p FlushPolicy) String() string {
+	if name, ok := flushPolicyNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("FlushPolicy(%d)", int(p))
+}
+
+// ParseFlushPolicy 는 플래그 값("write-through", "write-back")을 FlushPolicy 로 바꾼다.
+func ParseFlushPolicy(s string) (FlushPolicy, error) {
+	for p, name := range flushPolicyNames {
+		if name == s {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown flush policy %q", s)
+}
+
+// WAL 디렉터리 이름은 데이터 파일 이름 뒤에 붙인다.
+const walSuffix = ".wal"
+
+// WAL 은 logstore 로그이고, 레코드 하나가 기록 하나다. 길이와 CRC 는 logstore 가 붙이고 잘린 꼬리도 logstore 가 잘라 낸다.
+// 기록 종류(1) 뒤에
+// - walPageRecord: PageID(8) + 페이지 이미지(pageSize, 끝에 그 변경의 LSN 이 찍혀 있다)
+// - walReserveRecord: 예약한 LSN 의 끝(8) (lsn.go)
+const (
+	walPageRecord    byte = 'P'
+	walReserveRecord byte = 'R'
+)
+
+// 두 기록에 공통인 앞부분: 종류(1) + PageID 또는 예약 끝(8)
+const walRecordHeaderSize = 1 + 8
+
+// Capacity 를 주지 않았을 때 캐시할 페이지 수 (config 의 cache-size 기본값과 같다)
+const defaultPoolCapacity = 64
+
+// WAL 에는 평문 페이지가 그대로 들어가므로 암호화된 파일에는 write-back 을 허용하지 않는다.
+// (write-through 도 WAL 을 열지만 LSN 예약만 적는다.)
+var ErrWALEncrypted = errors.New("pager: write-back WAL does not support encrypted files")
+
+// Capacity 는 캐시에 들고 있을 페이지 수. 0 이면 defaultPoolCapacity.
+// PinDebug 를 켜면 Pin 한 위치를 기록해 두고, PinThreshold(0 이면 defaultPinThreshold)보다 오래 잡혀 있던 페이지와
+// Close 때까지 Unpin 하지 않은 페이지를 OnPinReport 로 알린다. OnPinReport 가 nil 이면 slog.Warn 으로 찍는다.
+// WALSegmentSize 는 WAL 세그먼트 하나의 크기 한도 (0 이면 logstore.DefaultSegmentSize).
+// WALDir 은 WAL 디렉터리. 비어 있으면 데이터 파일 이름 뒤에 ".wal" 을 붙인다.
+type BufferPoolOptions struct {
+	Capacity     int
+	Policy       FlushPolicy
+	PinDebug     bool
+	PinThreshold time.Duration
+	OnPinReport  func(PinReport)
+
+	WALSegmentSize int64
+	WALDir         string
+}
+
+// 캐시 한 칸. data 는 항상 Pager 의 pageSize 바이트이고 끝 pageLSNSize 바이트에 LSN 이 있다.
+// walEnd 는 이 페이지의 마지막 WAL 기록의 순번(walSeq). 그 기록까지 fsync 됐으면 데이터 파일에 써도 된다.
+type frame struct {
+	id     int64
+	data   []byte
+	dirty  bool
+	walEnd int64
+
+	// Pin 상태. pins 가 0 보다 크면 내보내지 않는다. pinnedAt / caller 는 0 에서 1 이 될 때 기록한다.
+	pins     int
+	pinnedAt time.Time
+	caller   string // PinDebug 일 때만
+}
+
+// BufferPool 은 Pager 의 페이지를 LRU 로 캐시하고 FlushPolicy 에 따라 쓰기를 내린다.
+type BufferPool struct {
+	pager    *Pager
+	policy   FlushPolicy
+	capacity int
+	frames   map[int64]*list.Element // Value 는 *frame
+	lru      *list.List              // 앞쪽이 최근에 쓴 페이지
+
+	wal       *logstore.Log // 쓰기 가능하게 열었을 때만
+	walSeq    int64         // 지금까지 WAL 에 덧붙인 기록 수
+	walSynced int64         // 여기까지의 기록은 fsync 됐다
+
+	pages int64 // 할당된 페이지 수. WriteBack 이면 아직 데이터 파일에 없는 새 페이지도 센다.
+
+	lsn      int64 // 마지막으로 나눠 준 LSN
+	reserved int64 // WAL 에 적어 둔, 나눠 줘도 되는 LSN 의 끝
+
+	replayed int // 열 때 WAL 에서 되살린 페이지 수
+	skipped  int // 열 때 데이터 파일에 이미 들어가 있어서 건너뛴 WAL 기록 수
+
+	pinDebug     bool
+	pinThreshold time.Duration
+	onPinReport  func(PinReport)
+}
+
+// OpenBufferPool 은 path 의 페이지 파일을 pagerOpts 로 열고 그 위에 버퍼 풀을 만든다.
+// WAL(기본은 path + ".wal")에 이전에 write-back 으로 쓰다 남은 기록이 있으면 정책과 상관없이 먼저 재생한다.
+// 페이지마다 끝 pageLSNSize 바이트를 LSN 에 쓰므로 PageSize 는 Pager 보다 그만큼 작다.
+func OpenBufferPool(path string, pagerOpts PagerOptions, opts BufferPoolOptions) (*BufferPool, error) {
+	if opts.Capacity == 0 {
+		opts.Capacity = defaultPoolCapacity
+	}
+	if opts.Capacity < 1 {
+		return nil, fmt.Errorf("pager: buffer pool capacity %d must be at least 1", opts.Capacity)
+	}
+	if _, ok := flushPolicyNames[opts.Policy]; !ok {
+		return nil, fmt.Errorf("pager: unknown flush policy %v", opts.Policy)
+	}
+	if opts.Policy == WriteBack && pagerOpts.Key != nil {
+		return nil, ErrWALEncrypted
+	}
+
+	pager, err := OpenPager(path, pagerOpts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.PinThreshold == 0 {
+		opts.PinThreshold = defaultPinThreshold
+	}
+	if opts.OnPinReport == nil {
+		opts.OnPinReport = logPinReport
+	}
+	bp := &BufferPool{
+		pager:        pager,
+		policy:       opts.Policy,
+		capacity:     opts.Capacity,
+		frames:       make(map[int64]*list.Element),
+		lru:          list.New(),
+		pinDebug:     opts.PinDebug,
+		pinThreshold: opts.PinThreshold,
+		onPinReport:  opts.OnPinReport,
+	}
+	if opts.WALDir == "" {
+		opts.WALDir = path + walSuffix
+	}
+	if err := bp.openWAL(opts.WALDir, opts.WALSegmentSize); err != nil {
+		pager.Close()
+		return nil, err
+	}
+	bp.pages = pager.PageCount()
+	return bp, nil
+}
+
+// openWAL 은 WAL 을 열고 남은 기록을 재생한다.
+// 읽기 전용이면 재생할 수 없으므로 페이지 기록이 남아 있을 때 에러를 낸다.
+func (bp *BufferPool) openWAL(dir string, segmentSize int64) error {
+	if bp.pager.readOnly {
+		wal, err := logstore.Open(dir, logstore.Options{ReadOnly: true})
+		if err != nil {
+			return err
+		}
+		pending := 0
+		err = readWAL(wal, bp.pager.pageSize, func(id int64, img []byte) error {
+			pending++
+			return nil
+		}, func(int64) {})
+		if err != nil {
+			return err
+		}
+		if pending > 0 {
+			return fmt.Errorf("%w: %s has %d page records to replay", ErrReadOnly, dir, pending)
+		}
+		return nil
+	}
+
+	wal, err := logstore.Open(dir, logstore.Options{SegmentSize: segmentSize})
+	if err != nil {
+		return err
+	}
+	bp.wal = wal
+	if err := bp.replay(); err != nil {
+		bp.wal.Close()
+		bp.wal = nil
+		return err
+	}
+	return nil
+}
+
+// replay 는 WAL 의 기록을 마지막 checkpoint 부터 읽어 데이터 파일에 다시 쓴다.
+// 데이터 파일의 페이지 LSN 이 기록의 LSN 보다 작지 않으면 이미 들어간 변경이므로 건너뛴다.
+// 다 쓰면 데이터 파일을 fsync 하고 WAL 을 비운다.
+func (bp *BufferPool) replay() error {
+	records := 0
+	err := readWAL(bp.wal, bp.pager.pageSize, func(id int64, img []byte) error {
+		records++
+		lsn := pageLSN(img)
+		bp.lsn = max(bp.lsn, lsn)
+		applied, err := bp.diskLSN(id)
+		if err != nil {
+			return err
+		}
+		if applied >= lsn {
+			bp.skipped++
+			return nil
+		}
+		if err := bp.writeToPager(id, img); err != nil {
+			return err
+		}
+		bp.replayed++
+		return nil
+	}, func(reserved int64) {
+		records++
+		bp.reserved = max(bp.reserved, reserved)
+	})
+	if err != nil {
+		return err
+	}
+	if records == 0 {
+		// 새 파일이거나 WAL 을 잃어버린 파일. 페이지에 찍힌 LSN 보다 큰 데서 시작해야 하므로 한 번 훑는다.
+		if bp.lsn, err = maxPageLSN(bp.pager); err != nil {
+			return err
+		}
+	}
+	// 예약한 번호 중 어디까지 썼는지는 모르므로 예약의 끝에서 이어 간다.
+	bp.lsn = max(bp.lsn, bp.reserved)
+	bp.reserved = bp.lsn
+	if bp.replayed > 0 {
+		if err := bp.pager.Sync(); err != nil {
+			return err
+		}
+	}
+	return bp.resetWAL()
+}
+
+// resetWAL 은 LSN 예약을 다시 적고 fsync 한 뒤, 그 예약 기록을 WAL 의 checkpoint 로 삼는다.
+// 그 앞의 기록은 다시 재생하지 않고, 그 앞에서 끝난 세그먼트는 logstore 가 지운다.
+// 예약이 디스크에 닿은 뒤에 checkpoint 를 옮기므로 그 사이에 죽어도 LSN 이 뒤로 가지 않는다.
+func (bp *BufferPool) resetWAL() error {
+	pos, err := bp.writeReservation()
+	if err != nil {
+		return err
+	}
+	return bp.wal.Checkpoint(pos)
+}
+
+// Policy 는 열 때 고른 FlushPolicy
+func (bp *BufferPool) Policy() FlushPolicy {
+	return bp.policy
+}
+
+// PageSize 는 페이지 하나에 담을 수 있는 데이터 크기. Pager 의 PageSize 에서 LSN 자리를 뺀 값이다.
+func (bp *BufferPool) PageSize() int {
+	return bp.pager.pageSize - pageLSNSize
+}
+
+// FilePageSize 는 데이터 파일에 기록된 페이지 크기 (Pager 의 PageSize). 파일을 다시 열 때 넘길 값이다.
+func (bp *BufferPool) FilePageSize() int {
+	return bp.pager.PageSize()
+}
+
+// Replayed 는 열 때 WAL 에서 되살린 페이지 수
+func (bp *BufferPool) Replayed() int {
+	return bp.replayed
+}
+
+// Skipped 는 열 때 데이터 파일의 페이지 LSN 을 보고 건너뛴 WAL 기록 수
+func (bp *BufferPool) Skipped() int {
+	return bp.skipped
+}
+
+// PageCount 는 할당된 페이지 수. 아직 데이터 파일에 내려가지 않은 새 페이지도 센다.
+func (bp *BufferPool) PageCount() int64 {
+	return bp.pages
+}
+
+// Dirty 는 아직 데이터 파일에 쓰지 않은 캐시 페이지 수. WriteThrough 면 항상 0.
+func (bp *BufferPool) Dirty() int {
+	n := 0
+	for e := bp.lru.Front(); e != nil; e = e.Next() {
+		if e.Value.(*frame).dirty {
+			n++
+		}
+	}
+	return n
+}
+
+// Metrics 는 데이터 파일의 I/O 와 캐시 적중 / 실패. WAL 의 I/O 는 WALMetrics 로 따로 본다.
+func (bp *BufferPool) Metrics() iometrics.IOMetrics {
+	return bp.pager.Metrics()
+}
+
+// WALMetrics 는 WAL 파일의 I/O. WAL 을 쓰지 않으면 (WriteThrough, 읽기 전용) 0 이다.
+func (bp *BufferPool) WALMetrics() iometrics.IOMetrics {
+	if bp.wal == nil {
+		return iometrics.IOMetrics{}
+	}
+	return bp.wal.Metrics()
+}
+
+// ReadPage 는 캐시에 있으면 복사본을 돌려주고, 없으면 Pager 에서 읽어 캐시에 넣는다.
+func (bp *BufferPool) ReadPage(id int64) (*Page, error) {
+	if id == 0 {
+		return nil, ErrReservedPage
+	}
+	fr, err := bp.fetch(id)
+	if err != nil {
+		return nil, err
+	}
+	return &Page{Id: int(id), Data: bytes.Clone(fr.data[:bp.PageSize()])}, nil
+}
+
+// fetch 는 id 페이지가 올라 있는 캐시 칸. 없으면 Pager 에서 읽어 올린다.
+func (bp *BufferPool) fetch(id int64) (*frame, error) {
+	if e, ok := bp.frames[id]; ok {
+		bp.pager.f.CacheHit()
+		bp.lru.MoveToFront(e)
+		return e.Value.(*frame), nil
+	}
+
+	bp.pager.f.CacheMiss()
+	pg, err := bp.pager.ReadPage(id)
+	if err != nil {
+		return nil, err
+	}
+	fr, err := bp.lookup(id)
+	if err != nil {
+		return nil, err
+	}
+	copy(fr.data, pg.Data)
+	return fr, nil
+}
+
+// WritePage 는 pg 를 캐시에 넣고 새 LSN 을 찍어 정책에 따라 데이터 파일이나 WAL 에 쓴다.
+// PageSize 보다 짧은 Data 는 뒤를 0 으로 채운 한 페이지로 다룬다.
+// Pager 처럼 할당된 페이지나 바로 다음 페이지(PageCount+1)에만 쓸 수 있다.
+// 쓰기가 실패하면 캐시는 이전 내용 그대로 둔다.
+func (bp *BufferPool) WritePage(pg *Page) error {
+	if bp.pager.readOnly {
+		return ErrReadOnly
+	}
+	if pg.Id == 0 {
+		return ErrReservedPage
+	}
+	if len(pg.Data) > bp.PageSize() {
+		return fmt.Errorf("pager: page %d data is %d bytes, page size is %d", pg.Id, len(pg.Data), bp.PageSize())
+	}
+	id := int64(pg.Id)
+	if id < 0 || id > bp.pages+1 {
+		return &dberr.PageError{Page: id, Err: fmt.Errorf("%w (page count %d)", ErrPageOutOfRange, bp.pages)}
+	}
+
+	ip := bufpool.Get(bp.pager.pageSize)
+	defer bufpool.Put(ip)
+	img := *ip
+	copy(img, pg.Data)
+	clear(img[len(pg.Data):])
+
+	if err := bp.persist(id, img); err != nil {
+		return err
+	}
+	fr, err := bp.lookup(id)
+	if err != nil {
+		return err
+	}
+	copy(fr.data, img)
+	bp.markWritten(fr)
+	bp.pages = max(bp.pages, id)
+	return nil
+}
+
+// persist 는 바뀐 페이지 이미지에 새 LSN 을 찍고 정책에 따라 데이터 파일(WriteThrough)이나 WAL(WriteBack)에 쓴다.
+func (bp *BufferPool) persist(id int64, img []byte) error {
+	lsn, err := bp.nextLSN()
+	if err != nil {
+		return err
+	}
+	putPageLSN(img, lsn)
+	if bp.policy == WriteBack {
+		return bp.appendWAL(id, img)
+	}
+	return bp.writeToPager(id, img)
+}
+
+// writeToPager 는 img 를 데이터 파일의 id 자리에 쓴다. Pager 는 구멍을 허용하지 않는데 캐시는 페이지를
+// 할당한 순서와 다르게 내려 쓸 수 있으므로, 사이의 아직 내려가지 않은 페이지 자리를 0 으로 먼저 잡는다.
+// 그 페이지들은 WAL 이나 캐시에 진짜 내용이 있어서 나중에 덮어쓴다.
+func (bp *BufferPool) writeToPager(id int64, img []byte) error {
+	if err := bp.pager.grow(id - 1); err != nil {
+		return err
+	}
+	return bp.pager.WritePage(&Page{Id: int(id), Data: img})
+}
+
+// markWritten 은 persist 가 끝난 캐시 칸의 상태를 맞춘다. WriteBack 이면 데이터 파일에 아직 없으므로 dirty.
+func (bp *BufferPool) markWritten(fr *frame) {
+	fr.dirty = bp.policy == WriteBack
+	fr.walEnd = bp.walSeq
+}
+
+// appendWAL 은 페이지 이미지 하나를 WAL 끝에 덧붙인다. fsync 는 Commit 이나 writeBack 이 한다.
+func (bp *BufferPool) appendWAL(id int64, img []byte) error {
+	rp := bufpool.Get(walRecordHeaderSize + len(img))
+	defer bufpool.Put(rp)
+	rec := *rp
+	w := codec.NewWriter(rec)
+	w.Uint8(walPageRecord)
+	w.Int64(id)
+	w.Bytes(img)
+	if _, err := bp.wal.Append(rec); err != nil {
+		return err
+	}
+	bp.walSeq++
+	return nil
+}
+
+func (bp *BufferPool) syncWAL() error {
+	if bp.walSynced == bp.walSeq {
+		return nil
+	}
+	if err := bp.wal.Sync(); err != nil {
+		return err
+	}
+	bp.walSynced = bp.walSeq
+	return nil
+}
+
+// lookup 은 id 페이지의 캐시 칸을 돌려준다. 없으면 새로 만들고, 꽉 찼으면 pin 되지 않은 페이지 중
+// 가장 오래 안 쓴 것을 내보내고 그 칸을 쓴다. 모두 pin 되어 있으면 ErrPoolExhausted.
+func (bp *BufferPool) lookup(id int64) (*frame, error) {
+	if e, ok := bp.frames[id]; ok {
+		bp.lru.MoveToFront(e)
+		return e.Value.(*frame), nil
+	}
+
+	var fr *frame
+	if bp.lru.Len() >= bp.capacity {
+		victim := bp.lru.Back()
+		for victim != nil && victim.Value.(*frame).pins > 0 {
+			victim = victim.Prev()
+		}
+		if victim == nil {
+			return nil, fmt.Errorf("%w: %d pages", ErrPoolExhausted, bp.capacity)
+		}
+		fr = victim.Value.(*frame)
+		if fr.dirty {
+			if err := bp.writeBack(fr); err != nil {
+				return nil, err
+			}
+		}
+		bp.lru.Remove(victim)
+		delete(bp.frames, fr.id)
+		fr.id = id
+	} else {
+		fr = &frame{id: id, data: make([]byte, bp.pager.pageSize)}
+	}
+	bp.frames[id] = bp.lru.PushFront(fr)
+	return fr, nil
+}
+
+// writeBack 은 dirty 페이지를 데이터 파일 제자리에 쓴다.
+// 이 페이지의 WAL 기록이 디스크에 닿기 전에 데이터 파일을 먼저 고치면, 제자리 쓰기가 찢어졌을 때 되살릴 사본이 없다.
+// 그래서 그 기록이 아직 fsync 되지 않았으면 WAL 부터 fsync 한다 (write-ahead 규칙).
+// 마지막 Commit 전에 고친 페이지라면 이미 fsync 됐으므로 바로 쓴다.
+func (bp *BufferPool) writeBack(fr *frame) error {
+	if fr.walEnd > bp.walSynced {
+		if err := bp.syncWAL(); err != nil {
+			return err
+		}
+	}
+	if err := bp.writeToPager(fr.id, fr.data); err != nil {
+		return err
+	}
+	fr.dirty = false
+	return nil
+}
+
+// Commit 은 지금까지의 WritePage 가 디스크에 닿게 한다.
+// WriteThrough 는 데이터 파일을, WriteBack 은 WAL 만 fsync 한다.
+func (bp *BufferPool) Commit() error {
+	if bp.policy == WriteBack {
+		return bp.syncWAL()
+	}
+	return bp.pager.Sync()
+}
+
+// Checkpoint 는 dirty 페이지를 모두 데이터 파일에 쓰고 fsync 한 뒤 WAL 을 비운다.
+// WAL 은 Checkpoint 전까지 계속 자라므로 오래 열어 두면 틈틈이 부른다. WriteThrough 면 Commit 과 같다.
+func (bp *BufferPool) Checkpoint() error {
+	if bp.policy == WriteThrough || bp.wal == nil {
+		return bp.pager.Sync()
+	}
+	// 페이지 번호 순서로 쓰면 데이터 파일을 한 방향으로 훑으므로 이동 거리가 준다.
+	// pin 된 페이지는 Unpin 전에 제자리에서 고치고 있을 수 있어서 (WAL 에 없는 내용) 쓰지 않는다.
+	// 그런 dirty 페이지가 남으면 그 WAL 기록이 아직 필요하므로 WAL 을 비우지 않는다.
+	var dirty []*frame
+	pinnedDirty := false
+	for e := bp.lru.Front(); e != nil; e = e.Next() {
+		fr := e.Value.(*frame)
+		switch {
+		case fr.dirty && fr.pins > 0:
+			pinnedDirty = true
+		case fr.dirty:
+			dirty = append(dirty, fr)
+		}
+	}
+	slices.SortFunc(dirty, func(a, b *frame) int { return cmp.Compare(a.id, b.id) })
+	for _, fr := range dirty {
+		if err := bp.writeBack(fr); err != nil {
+			return err
+		}
+	}
+	if err := bp.pager.Sync(); err != nil {
+		return err
+	}
+	if pinnedDirty {
+		return nil
+	}
+	return bp.resetWAL()
+}
+
+// Close 는 Checkpoint 를 하고 WAL 과 데이터 파일을 닫는다.
+// 아직 Unpin 하지 않은 페이지가 있으면 그래도 닫고 ErrPinLeak 을 돌려준다 (PinDebug 면 페이지마다 알린다).
+func (bp *BufferPool) Close() error {
+	err := bp.checkLeaks()
+	if !bp.pager.readOnly {
+		if cerr := bp.Checkpoint(); err == nil {
+			err = cerr
+		}
+	}
+	if bp.wal != nil {
+		if cerr := bp.wal.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if cerr := bp.pager.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
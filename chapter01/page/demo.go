@@ -0,0 +1,130 @@
+package page
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"github.com/tmdgusya/btree/workload"
+)
+
+// Demo 는 test.db 에 페이지 하나를 쓰고 다시 읽어서 내용과 I/O 를 출력한다.
+// PAGER_KEY(hex) 와 PAGER_DOUBLE_WRITE=1 로 암호화 / double-write 모드를 고를 수 있다.
+// 이어서 같은 쓰기를 BufferPool 의 write-through / write-back 으로 흘려 I/O 를 비교한다.
+// 튜토리얼 데모라서 실패하면 panic 한다.
+func Demo() {
+	arr := make([]int, defaultPageSize/4)
+	for i := 0; i < defaultPageSize/4; i++ {
+		arr[i] = i
+	}
+
+	// PAGER_KEY(hex) 가 주어지면 암호화된 파일로 연다.
+	var key []byte
+	if hexKey := os.Getenv("PAGER_KEY"); hexKey != "" {
+		var err error
+		key, err = hex.DecodeString(hexKey)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	// PAGER_DOUBLE_WRITE=1 이면 새 파일을 double-write 모드로 만든다.
+	opts := PagerOptions{Key: key, DoubleWrite: os.Getenv("PAGER_DOUBLE_WRITE") == "1"}
+
+	pager, err := OpenPager("test.db", opts)
+	if err != nil {
+		panic(err)
+	}
+	defer pager.Close()
+
+	if id, ok := pager.RecoveredPage(); ok {
+		fmt.Printf("Recovered page %d from the double-write buffer\n", id)
+	}
+
+	bytes := IntSliceToBytes(arr)
+	page := &Page{
+		Id:   1,
+		Data: bytes,
+	}
+
+	err = pager.WritePage(page)
+
+	if err != nil {
+		panic("Error!")
+	}
+
+	page, err = pager.ReadPage(int64(page.Id))
+
+	if err != nil {
+		panic(err)
+	}
+
+	ints := BytesToIntSlice(page.Data)
+	fmt.Printf("Data length: %d bytes\n", len(page.Data))
+	fmt.Printf("First 10 integers: %v\n", ints[:10])
+	fmt.Printf("Last 10 integers: %v\n", ints[len(ints)-10:])
+
+	m := pager.Metrics()
+	fmt.Printf("I/O: reads=%d writes=%d syncs=%d bytesRead=%d bytesWritten=%d\n",
+		m.Reads, m.Writes, m.Syncs, m.BytesRead, m.BytesWritten)
+
+	flushDemo()
+}
+
+// flushDemo 는 앞쪽 페이지에 몰리는 쓰기 500 번을 10 번마다 Commit 하며 두 정책으로 flush.db 에 쓴다.
+// write-through 는 쓰기마다 데이터 파일을 건드리고, write-back 은 WAL 에 순차로 쓰고 데이터 파일에는 모아서 쓴다.
+func flushDemo() {
+	const writes, commitEvery = 500, 10
+	gen, err := workload.New(workload.Config{Distribution: workload.Zipfian, KeySpace: 32, Seed: 1})
+	if err != nil {
+		panic(err)
+	}
+	ids := gen.Keys(writes)
+
+	fmt.Printf("\nFlush policies (%d writes over 32 pages, commit every %d, cache 8 pages):\n", writes, commitEvery)
+	for _, policy := range []FlushPolicy{WriteThrough, WriteBack} {
+		os.Remove("flush.db")
+		if err := preallocatePages("flush.db", 32); err != nil {
+			panic(err)
+		}
+		pool, err := OpenBufferPool("flush.db", PagerOptions{}, BufferPoolOptions{Capacity: 8, Policy: policy})
+		if err != nil {
+			panic(err)
+		}
+		data := make([]byte, pool.PageSize())
+		for i, id := range ids {
+			if err := pool.WritePage(&Page{Id: int(id) + 1, Data: data}); err != nil {
+				panic(err)
+			}
+			if (i+1)%commitEvery == 0 {
+				if err := pool.Commit(); err != nil {
+					panic(err)
+				}
+			}
+		}
+		if err := pool.Close(); err != nil {
+			panic(err)
+		}
+		m, wal := pool.Metrics(), pool.WALMetrics()
+		fmt.Printf("  %-13s data: writes=%d syncs=%d seek=%d  wal: writes=%d syncs=%d bytes=%d\n",
+			policy, m.Writes, m.Syncs, m.SeekDistance, wal.Writes, wal.Syncs, wal.BytesWritten)
+	}
+}
+
+// preallocatePages 는 path 에 빈 페이지 n 개를 할당해 둔다. Pager 는 구멍을 허용하지 않으므로
+// 아무 페이지에나 쓰는 데모는 먼저 자리를 잡는다. 이 I/O 는 버퍼 풀의 측정에 들어가지 않는다.
+func preallocatePages(path string, n int64) error {
+	pager, err := OpenPager(path, PagerOptions{})
+	if err != nil {
+		return err
+	}
+	if err := pager.grow(n); err != nil {
+		pager.Close()
+		return err
+	}
+	if err := pager.Sync(); err != nil {
+		pager.Close()
+		return err
+	}
+	return pager.Close()
+}
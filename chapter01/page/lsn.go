@@ -0,0 +1,208 @@
+package page
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/tmdgusya/btree/chapter04/logstore"
+	"github.com/tmdgusya/btree/codec"
+	"github.com/tmdgusya/btree/dberr"
+)
+
+// ==================================
+// 페이지 LSN
+// ==================================
+// 버퍼 풀은 페이지를 바꿀 때마다 1 씩 커지는 LSN(log sequence number)을 하나 받아 페이지 끝 8 바이트에 찍는다.
+// WAL 기록은 페이지 이미지를 통째로 담으므로 기록의 LSN 도 이미지 끝에 들어 있다.
+// - 재생: 데이터 파일의 페이지 LSN 이 기록의 LSN 보다 작지 않으면 그 변경은 이미 들어간 것이므로 건너뛴다.
+//   write-back 에서 내보낸 페이지가 많을수록 재생이 쓰기를 덜 한다.
+// - 백업 검증: 데이터 파일과 WAL 을 따로 복사하면 두 사본의 시점이 어긋날 수 있다.
+//   WAL 이 아는 가장 큰 LSN 보다 큰 LSN 이 찍힌 페이지가 있으면 데이터 파일 쪽이 더 나중 사본이다 (CheckLSNs).
+//
+// LSN 은 Checkpoint 로 WAL 을 비운 뒤에도 이어져야 하므로 WAL 에 "여기까지 나눠 줄 수 있다"는 예약 기록을 적어 둔다.
+// 매번 적지 않도록 lsnReserve 개씩 미리 예약하고, 다시 열면 예약의 끝에서 이어 간다 (사이의 번호는 건너뛴다).
+// WAL 을 비울 때는 새 세그먼트의 첫 기록으로 예약을 다시 적는다.
+
+// 페이지 끝에 찍는 LSN 크기
+const pageLSNSize = 8
+
+// 예약 기록 하나로 예약하는 LSN 수
+const lsnReserve = 1024
+
+var ErrWALRecord = dberr.New(dberr.ErrCorruptPage, "pager: invalid wal record")
+
+// pageLSN 은 페이지 이미지(pageSize 바이트) 끝에 찍힌 LSN
+func pageLSN(img []byte) int64 {
+	r := codec.NewReader(img[len(img)-pageLSNSize:])
+	return r.Int64()
+}
+
+func putPageLSN(img []byte, lsn int64) {
+	w := codec.NewWriter(img[len(img)-pageLSNSize:])
+	w.Int64(lsn)
+}
+
+// LSN 은 마지막으로 나눠 준 LSN
+func (bp *BufferPool) LSN() int64 {
+	return bp.lsn
+}
+
+// PageLSN 은 id 페이지에 마지막 변경이 찍어 둔 LSN. 버퍼 풀로 쓴 적 없는 페이지는 0 이다.
+func (bp *BufferPool) PageLSN(id int64) (int64, error) {
+	if id == 0 {
+		return 0, ErrReservedPage
+	}
+	fr, err := bp.fetch(id)
+	if err != nil {
+		return 0, err
+	}
+	return pageLSN(fr.data), nil
+}
+
+// nextLSN 은 새 LSN 을 하나 나눠 준다. 예약을 다 썼으면 WAL 에 다음 예약을 적고 fsync 한다.
+// 예약이 디스크에 닿은 뒤에만 그 범위의 LSN 이 페이지에 찍히므로, 다시 열었을 때 LSN 이 뒤로 가지 않는다.
+func (bp *BufferPool) nextLSN() (int64, error) {
+	if bp.lsn == bp.reserved {
+		bp.reserved += lsnReserve
+		if _, err := bp.writeReservation(); err != nil {
+			bp.reserved -= lsnReserve
+			return 0, err
+		}
+	}
+	bp.lsn++
+	return bp.lsn, nil
+}
+
+// writeReservation 은 예약 기록을 덧붙이고 fsync 한 뒤 그 자리를 돌려준다. 앞서 덧붙인 페이지 기록도 함께 디스크에 닿는다.
+func (bp *BufferPool) writeReservation() (logstore.Position, error) {
+	rec := make([]byte, walRecordHeaderSize)
+	w := codec.NewWriter(rec)
+	w.Uint8(walReserveRecord)
+	w.Int64(bp.reserved)
+	pos, err := bp.wal.Append(rec)
+	if err != nil {
+		return logstore.Position{}, err
+	}
+	bp.walSeq++
+	return pos, bp.syncWAL()
+}
+
+// readWAL 은 WAL 의 기록을 차례로 종류에 맞는 함수에 넘긴다.
+// img 는 다음 기록을 읽을 때 덮이므로 onPage 밖으로 내보내지 않는다.
+func readWAL(wal *logstore.Log, pageSize int, onPage func(id int64, img []byte) error, onReserve func(reserved int64)) error {
+	return wal.ReadAll(func(pos logstore.Position, rec []byte) error {
+		if len(rec) < walRecordHeaderSize {
+			return fmt.Errorf("%w: %d bytes at %s", ErrWALRecord, len(rec), pos)
+		}
+		r := codec.NewReader(rec)
+		kind, n := r.Uint8(), r.Int64()
+		switch {
+		case kind == walPageRecord && len(rec) == walRecordHeaderSize+pageSize:
+			if n <= 0 {
+				return fmt.Errorf("%w: page %d at %s", ErrWALRecord, n, pos)
+			}
+			return onPage(n, rec[walRecordHeaderSize:])
+		case kind == walReserveRecord && len(rec) == walRecordHeaderSize:
+			onReserve(n)
+			return nil
+		}
+		return fmt.Errorf("%w: %d bytes at %s", ErrWALRecord, len(rec), pos)
+	})
+}
+
+// diskLSN 은 데이터 파일에 있는 id 페이지의 LSN. 아직 없는 페이지나 깨진 페이지는 0 으로 보고 다시 쓰게 한다.
+func (bp *BufferPool) diskLSN(id int64) (int64, error) {
+	pg, err := bp.pager.ReadPage(id)
+	switch {
+	case errors.Is(err, ErrPageOutOfRange), errors.Is(err, io.EOF), errors.Is(err, dberr.ErrCorruptPage):
+		return 0, nil
+	case err != nil:
+		return 0, err
+	}
+	return pageLSN(pg.Data), nil
+}
+
+// maxPageLSN 은 데이터 파일 전체에서 가장 큰 페이지 LSN. 읽을 수 없는 페이지는 건너뛴다.
+func maxPageLSN(p *Pager) (int64, error) {
+	var maxLSN int64
+	err := scanPageLSNs(p, func(id, lsn int64, err error) error {
+		maxLSN = max(maxLSN, lsn)
+		return nil
+	})
+	return maxLSN, err
+}
+
+// scanPageLSNs 는 1 번부터 마지막 페이지까지 페이지 LSN 을 fn 에 넘긴다. 페이지가 깨졌거나 파일 끝에서 잘렸으면 err 에 담아 넘긴다.
+func scanPageLSNs(p *Pager, fn func(id, lsn int64, err error) error) error {
+	for id := int64(1); id <= p.PageCount(); id++ {
+		var lsn int64
+		pg, err := p.ReadPage(id)
+		switch {
+		case err == nil:
+			lsn = pageLSN(pg.Data)
+		case !errors.Is(err, dberr.ErrCorruptPage) && !errors.Is(err, io.EOF):
+			return err
+		}
+		if err := fn(id, lsn, err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LSNCheck 는 CheckLSNs 의 결과
+type LSNCheck struct {
+	Pages      int64
+	MaxPageLSN int64
+	HasWAL     bool
+	WALLSN     int64   // WAL 의 예약 끝과 남은 기록 LSN 중 큰 값. 어떤 페이지 LSN 도 이보다 크면 안 된다.
+	Ahead      []int64 // WALLSN 보다 큰 LSN 이 찍힌 페이지
+	Corrupt    []int64 // 읽을 수 없는 페이지
+}
+
+// Consistent 는 데이터 파일과 WAL 사본이 같은 시점으로 보이고 깨진 페이지가 없는지
+func (c LSNCheck) Consistent() bool {
+	return len(c.Ahead) == 0 && len(c.Corrupt) == 0
+}
+
+// CheckLSNs 는 path 의 페이지 파일과 WAL 을 읽기만 해서 페이지 LSN 이 WAL 과 맞는지 본다.
+// 백업(데이터 파일과 .wal 디렉터리를 복사한 것)을 복원하기 전에 부른다. WAL 이 없으면 Ahead 는 보지 않는다.
+func CheckLSNs(path string, opts PagerOptions) (LSNCheck, error) {
+	opts.ReadOnly = true
+	pager, err := OpenPager(path, opts)
+	if err != nil {
+		return LSNCheck{}, err
+	}
+	defer pager.Close()
+
+	var c LSNCheck
+	wal, err := logstore.Open(path+walSuffix, logstore.Options{ReadOnly: true})
+	if err != nil {
+		return LSNCheck{}, err
+	}
+	c.HasWAL = len(wal.Segments()) > 0
+	err = readWAL(wal, pager.pageSize, func(id int64, img []byte) error {
+		c.WALLSN = max(c.WALLSN, pageLSN(img))
+		return nil
+	}, func(reserved int64) {
+		c.WALLSN = max(c.WALLSN, reserved)
+	})
+	if err != nil {
+		return LSNCheck{}, err
+	}
+
+	err = scanPageLSNs(pager, func(id, lsn int64, err error) error {
+		c.Pages++
+		if err != nil {
+			c.Corrupt = append(c.Corrupt, id)
+			return nil
+		}
+		c.MaxPageLSN = max(c.MaxPageLSN, lsn)
+		if c.HasWAL && lsn > c.WALLSN {
+			c.Ahead = append(c.Ahead, id)
+		}
+		return nil
+	})
+	return c, err
+}
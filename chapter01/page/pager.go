@@ -0,0 +1,597 @@
+// Package page 는 chapter01 의 Pager: 슈퍼블록, 체크섬, 선택적 암호화 / double-write 를 갖춘 페이지 파일.
+package page
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/tmdgusya/btree/bufpool"
+	"github.com/tmdgusya/btree/codec"
+	"github.com/tmdgusya/btree/dberr"
+	"github.com/tmdgusya/btree/iometrics"
+)
+
+// 기본 페이지 크기. 실제 크기는 슈퍼블록에 기록되고 열 때 검증한다.
+const defaultPageSize = 4096
+
+// 허용하는 페이지 크기 범위 (2의 거듭제곱)
+const minPageSize = 512
+const maxPageSize = 1 << 20
+
+// 슈퍼블록(0번 페이지) 식별자와 플래그
+var superMagic = [4]byte{'P', 'G', 'R', '1'}
+
+const superFlagEncrypted uint16 = 1
+const superFlagDoubleWrite uint16 = 2
+
+// double-write 모드에서 디스크상 페이지마다 붙는 꼬리: PageID(8) + CRC32(4)
+// CRC 는 페이지 이미지와 PageID 를 함께 덮어서, 찢어진 쓰기와 엉뚱한 자리에 쓰인 페이지를 모두 잡는다.
+const pageTrailerSize = 8 + 4
+
+// double-write 영역은 슈퍼블록 바로 다음 자리(1번 슬롯)를 쓴다.
+const doubleWriteSlot = 1
+
+// 슈퍼블록 레이아웃
+// Magic(4) + Version(2) + Flags(2) + PageSize(4) + KeyCheck(nonce 12 + sealed 16 + tag 16) + PageCount(8)
+// PageCount 는 version 2 부터 있다. version 1 파일은 파일 크기로 세고, 처음 페이지를 늘릴 때 version 2 로 올린다.
+const superHeaderSize = 4 + 2 + 2 + 4
+const superPageCountOffset = superHeaderSize + 12 + 16 + 16
+const superVersion = 2
+
+// 키 검증용 평문. 올바른 키로만 복호화할 수 있다.
+var keyCheckPlain = []byte("btree-pager-key!")
+
+var (
+	ErrInvalidSuperblock = errors.New("pager: invalid superblock")
+	ErrWrongKey          = errors.New("pager: encryption key mismatch")
+	ErrKeyRequired       = errors.New("pager: file is encrypted but no key was given")
+	ErrNotEncrypted      = errors.New("pager: key given but file is not encrypted")
+	ErrReservedPage      = errors.New("pager: page 0 is reserved for the superblock")
+	ErrPageOutOfRange    = errors.New("pager: page out of range")
+	ErrInvalidPageSize   = errors.New("pager: invalid page size")
+	ErrChecksum          = dberr.New(dberr.ErrCorruptPage, "pager: page checksum mismatch")
+	ErrDecrypt           = dberr.New(dberr.ErrCorruptPage, "pager: page decryption failed")
+	ErrReadOnly          = dberr.New(dberr.ErrReadOnly, "pager: opened read-only")
+)
+
+type Page struct {
+	Id   int
+	Data []byte
+}
+
+// Pager 는 고정 크기 페이지 단위로 파일을 읽고 쓴다.
+// - 0번 페이지는 슈퍼블록으로 예약된다.
+// - aead 가 설정되어 있으면 페이지마다 AES-GCM 으로 암호화해서 기록한다.
+// - 암호화된 경우 디스크상 한 페이지 = nonce + 암호문(pageSize) + tag 이다.
+// - doubleWrite 가 켜져 있으면 페이지 뒤에 PageID + CRC32 꼬리가 붙고, 페이지 ID i 는 i+1 번 슬롯에 놓인다.
+// - 파일은 CountingFile 로 감싸서 ReadAt/WriteAt/Sync 횟수와 바이트 수를 센다.
+// - 할당된 페이지는 1 ~ pageCount 이고 슈퍼블록에 기록한다. 읽기는 그 안에서만, 쓰기는 그 안이나 바로 다음 페이지에만 된다.
+type Pager struct {
+	f           *iometrics.CountingFile
+	pageSize    int
+	aead        cipher.AEAD // nil 이면 평문 그대로 기록
+	doubleWrite bool
+	readOnly    bool
+	pageCount   int64
+	v1          bool // 슈퍼블록에 페이지 수가 없는 version 1 파일. 처음 페이지 수를 쓸 때 버전도 올린다.
+	writeHook   WriteHook
+
+	recovered int // 열 때 double-write 영역에서 복구한 페이지 ID (없으면 0)
+}
+
+// PageSize 는 새 파일에 쓸 페이지 크기. 0 이면 새 파일은 defaultPageSize, 기존 파일은 기록된 값을 쓴다.
+// Key 가 nil 이면 평문 파일, 16/24/32 바이트면 AES-128/192/256 으로 암호화된 파일로 다룬다.
+// DoubleWrite 는 새 파일을 만들 때만 의미가 있다. 기존 파일은 슈퍼블록에 기록된 설정을 따른다.
+// ReadOnly 면 파일을 읽기 전용으로 열고 (없으면 만들지 않는다) WritePage 는 ErrReadOnly 를 돌려준다.
+// WriteHook 은 WritePage 가 디스크에 나눠 쓰는 사이사이에 불린다 (writehook.go). 검사 도구용이고 보통은 nil.
+type PagerOptions struct {
+	PageSize    int
+	Key         []byte
+	DoubleWrite bool
+	ReadOnly    bool
+	WriteHook   WriteHook
+}
+
+func validatePageSize(pageSize int) error {
+	if pageSize < minPageSize || pageSize > maxPageSize || pageSize&(pageSize-1) != 0 {
+		return fmt.Errorf("%w: %d (must be a power of two between %d and %d)", ErrInvalidPageSize, pageSize, minPageSize, maxPageSize)
+	}
+	return nil
+}
+
+// OpenPager 는 path 의 파일을 열고 슈퍼블록을 검증한다.
+// 새 파일이면 슈퍼블록을 기록하고, 기존 파일이면 페이지 크기, 암호화 여부, 키가 맞는지 확인한다.
+// double-write 파일이면 마지막 쓰기가 중간에 끊겼는지 보고 scratch 영역에서 복구한다.
+func OpenPager(path string, opts PagerOptions) (*Pager, error) {
+	pageSize, key := opts.PageSize, opts.Key
+	if pageSize != 0 {
+		if err := validatePageSize(pageSize); err != nil {
+			return nil, err
+		}
+	}
+
+	flags := os.O_RDWR | os.O_CREATE
+	if opts.ReadOnly {
+		flags = os.O_RDONLY
+	}
+	raw, err := os.OpenFile(path, flags, 0666)
+	if err != nil {
+		return nil, err
+	}
+	f := iometrics.NewCountingFile(raw)
+
+	p := &Pager{f: f, pageSize: pageSize, readOnly: opts.ReadOnly, writeHook: opts.WriteHook}
+	if key != nil {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		p.aead, err = cipher.NewGCM(block)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if size == 0 && p.readOnly {
+		err = fmt.Errorf("%w: file is empty", ErrReadOnly)
+	} else if size == 0 {
+		if p.pageSize == 0 {
+			p.pageSize = defaultPageSize
+		}
+		p.doubleWrite = opts.DoubleWrite
+		err = p.writeSuperblock()
+	} else {
+		err = p.verifySuperblock()
+		if err == nil && p.doubleWrite {
+			err = p.recoverDoubleWrite()
+		}
+		if err == nil {
+			err = p.reconcilePageCount()
+		}
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (p *Pager) Close() error {
+	return p.f.Close()
+}
+
+// Sync 는 데이터 파일을 fsync 한다. double-write 모드는 WritePage 가 이미 fsync 하므로 보통 할 일이 없다.
+func (p *Pager) Sync() error {
+	return p.f.Sync()
+}
+
+// Metrics 는 열린 뒤 지금까지의 I/O 통계 (슈퍼블록 검증과 복구 포함)
+func (p *Pager) Metrics() iometrics.IOMetrics {
+	return p.f.Metrics()
+}
+
+// PageSize 는 페이지 하나에 담을 수 있는 데이터 크기 (암호화 / double-write 꼬리 제외)
+func (p *Pager) PageSize() int {
+	return p.pageSize
+}
+
+// 디스크에서 한 페이지가 차지하는 크기
+func (p *Pager) physicalPageSize() int64 {
+	size := int64(p.pageSize)
+	if p.aead != nil {
+		size += int64(p.aead.NonceSize() + p.aead.Overhead())
+	}
+	if p.doubleWrite {
+		size += pageTrailerSize
+	}
+	return size
+}
+
+// 페이지 ID 가 놓이는 파일 오프셋. double-write 모드에서는 scratch 슬롯만큼 한 칸씩 밀린다.
+func (p *Pager) pageOffset(id int64) int64 {
+	slot := id
+	if p.doubleWrite {
+		slot++
+	}
+	return slot * p.physicalPageSize()
+}
+
+// PageCount 는 할당된 페이지 수. 페이지 ID 는 1 부터 PageCount 까지다.
+func (p *Pager) PageCount() int64 {
+	return p.pageCount
+}
+
+// filePages 는 파일에 온전히 자리가 있는 마지막 페이지 ID. 슈퍼블록과 double-write 영역은 세지 않는다.
+func (p *Pager) filePages() (int64, error) {
+	size, err := p.f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+	return max(size-p.pageOffset(1), 0) / p.physicalPageSize(), nil
+}
+
+// reconcilePageCount 는 슈퍼블록의 페이지 수를 파일 크기와 맞춘다.
+// 페이지를 쓴 뒤 페이지 수를 고치기 전에 죽었으면 파일에는 있는데 세지 않은 페이지가 남으므로 큰 쪽을 믿는다.
+// version 1 파일은 슈퍼블록에 페이지 수가 없으므로 파일 크기만 본다.
+func (p *Pager) reconcilePageCount() error {
+	n, err := p.filePages()
+	if err != nil {
+		return err
+	}
+	p.pageCount = max(p.pageCount, n)
+	return nil
+}
+
+// checkPageID 는 id 가 할당된 페이지인지 본다. grow 면 바로 다음 페이지(새로 할당할 자리)도 받는다.
+func (p *Pager) checkPageID(id int64, grow bool) error {
+	if id == 0 {
+		return ErrReservedPage
+	}
+	limit := p.pageCount
+	if grow {
+		limit++
+	}
+	if id < 0 || id > limit {
+		return &dberr.PageError{Page: id, Err: fmt.Errorf("%w (page count %d)", ErrPageOutOfRange, p.pageCount)}
+	}
+	return nil
+}
+
+// writePageCount 는 슈퍼블록의 페이지 수만 고쳐 쓴다. fsync 는 다음 Sync 나 double-write 쓰기가 한다.
+func (p *Pager) writePageCount() error {
+	var buf [8]byte
+	if p.v1 {
+		w := codec.NewWriter(buf[:2])
+		w.Uint16(superVersion)
+		if _, err := p.f.WriteAt(buf[:2], 4); err != nil {
+			return err
+		}
+		p.v1 = false
+	}
+	w := codec.NewWriter(buf[:])
+	w.Int64(p.pageCount)
+	_, err := p.f.WriteAt(buf[:], superPageCountOffset)
+	return err
+}
+
+// 열려 있을 때 double-write 영역에서 복구한 페이지 ID. 복구가 없었으면 false.
+func (p *Pager) RecoveredPage() (int, bool) {
+	return p.recovered, p.recovered != 0
+}
+
+func (p *Pager) writeSuperblock() error {
+	var flags uint16
+	if p.aead != nil {
+		flags |= superFlagEncrypted
+	}
+	if p.doubleWrite {
+		flags |= superFlagDoubleWrite
+	}
+
+	buf := make([]byte, p.physicalPageSize())
+	w := codec.NewWriter(buf)
+	w.Bytes(superMagic[:])
+	w.Uint16(superVersion)
+	w.Uint16(flags)
+	w.Uint32(uint32(p.pageSize))
+	w.Zero(superPageCountOffset - superHeaderSize) // KeyCheck 는 아래에서 채운다
+	w.Int64(p.pageCount)
+
+	if p.aead != nil {
+		nonce := buf[superHeaderSize : superHeaderSize+p.aead.NonceSize()]
+		if _, err := rand.Read(nonce); err != nil {
+			return err
+		}
+		p.aead.Seal(buf[superHeaderSize+len(nonce):superHeaderSize+len(nonce)], nonce, keyCheckPlain, superMagic[:])
+	}
+
+	_, err := p.f.WriteAt(buf, 0)
+	return err
+}
+
+func (p *Pager) verifySuperblock() error {
+	buf := make([]byte, superHeaderSize)
+	if _, err := p.f.ReadAt(buf, 0); err != nil {
+		return err
+	}
+	r := codec.NewReader(buf)
+	var magic [4]byte
+	r.Bytes(magic[:])
+	version, flags := r.Uint16(), r.Uint16()
+	pageSize := int(r.Uint32())
+	if magic != superMagic {
+		return fmt.Errorf("%w: %w", ErrInvalidSuperblock, dberr.ErrInvalidMagic)
+	}
+	if err := validatePageSize(pageSize); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSuperblock, err)
+	}
+	if p.pageSize != 0 && p.pageSize != pageSize {
+		return fmt.Errorf("%w: file uses %d, requested %d", ErrInvalidPageSize, pageSize, p.pageSize)
+	}
+	p.pageSize = pageSize
+
+	p.doubleWrite = flags&superFlagDoubleWrite != 0
+
+	p.v1 = version < 2
+	if !p.v1 {
+		var count [8]byte
+		if _, err := p.f.ReadAt(count[:], superPageCountOffset); err != nil {
+			return err
+		}
+		r := codec.NewReader(count[:])
+		p.pageCount = r.Int64()
+	}
+
+	encrypted := flags&superFlagEncrypted != 0
+	switch {
+	case encrypted && p.aead == nil:
+		return ErrKeyRequired
+	case !encrypted && p.aead != nil:
+		return ErrNotEncrypted
+	case !encrypted:
+		return nil
+	}
+
+	check := make([]byte, p.aead.NonceSize()+len(keyCheckPlain)+p.aead.Overhead())
+	if _, err := p.f.ReadAt(check, superHeaderSize); err != nil {
+		return err
+	}
+	nonce, sealed := check[:p.aead.NonceSize()], check[p.aead.NonceSize():]
+	plain, err := p.aead.Open(nil, nonce, sealed, superMagic[:])
+	if err != nil || !bytes.Equal(plain, keyCheckPlain) {
+		return ErrWrongKey
+	}
+	return nil
+}
+
+// 페이지 ID 를 AAD 로 넣어서 암호문 페이지를 다른 위치로 옮겨 붙이는 것을 막는다.
+func pageAAD(id int64) []byte {
+	aad := make([]byte, 8)
+	w := codec.NewWriter(aad)
+	w.Int64(id)
+	return aad
+}
+
+// WritePage 는 pg 를 제자리에 쓴다. pg.Id 가 PageCount+1 이면 새 페이지를 할당하고 슈퍼블록의 페이지 수를 늘린다.
+// 그보다 뒤의 ID 는 사이에 구멍이 생기므로 ErrPageOutOfRange 다.
+func (p *Pager) WritePage(pg *Page) error {
+	if p.readOnly {
+		return ErrReadOnly
+	}
+	id := int64(pg.Id)
+	if err := p.checkPageID(id, true); err != nil {
+		return err
+	}
+	if len(pg.Data) > p.pageSize {
+		return fmt.Errorf("pager: page %d data is %d bytes, page size is %d", pg.Id, len(pg.Data), p.pageSize)
+	}
+	p.hook(id, WriteStarted)
+	if err := p.writePage(id, pg.Data); err != nil {
+		return err
+	}
+	p.hook(id, WritePageDone)
+	if id > p.pageCount {
+		p.pageCount = id
+		if err := p.writePageCount(); err != nil {
+			return err
+		}
+	}
+	p.hook(id, WriteFinished)
+	return nil
+}
+
+// grow 는 페이지 수가 n 이 될 때까지 0 으로 채운 페이지를 할당한다.
+// 캐시가 페이지를 할당한 순서와 다르게 내려 쓸 때 사이의 페이지 자리를 먼저 잡는 데 쓴다.
+func (p *Pager) grow(n int64) error {
+	for p.pageCount < n {
+		if err := p.WritePage(&Page{Id: int(p.pageCount + 1)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writePage 는 data 를 id 자리의 디스크 이미지로 만들어 쓴다.
+func (p *Pager) writePage(id int64, data []byte) error {
+	offset := p.pageOffset(id)
+
+	if p.aead == nil && !p.doubleWrite {
+		if len(data) < p.pageSize && id > p.pageCount {
+			// 새 페이지는 끝까지 써야 파일에 온전한 자리가 생긴다.
+			pp := bufpool.Get(p.pageSize)
+			defer bufpool.Put(pp)
+			plain := *pp
+			copy(plain, data)
+			clear(plain[len(data):])
+			data = plain
+		}
+		_, err := p.f.WriteAt(data, offset)
+		return err
+	}
+
+	// 평문 / 디스크 이미지는 이 함수 안에서만 쓰므로 bufpool 에서 빌린다.
+	pp := bufpool.Get(p.pageSize)
+	defer bufpool.Put(pp)
+	plain := *pp
+	copy(plain, data)
+	clear(plain[len(data):])
+
+	bp := bufpool.Get(int(p.physicalPageSize()))
+	defer bufpool.Put(bp)
+	buf := (*bp)[:0]
+	if p.aead == nil {
+		buf = append(buf, plain...)
+	} else {
+		buf = buf[:p.aead.NonceSize()]
+		if _, err := rand.Read(buf); err != nil {
+			return err
+		}
+		buf = p.aead.Seal(buf, buf, plain, pageAAD(id))
+	}
+
+	if !p.doubleWrite {
+		_, err := p.f.WriteAt(buf, offset)
+		return err
+	}
+
+	// 1) scratch 영역에 먼저 쓰고 fsync
+	// 2) 제자리에 쓰고 fsync. 다음 쓰기가 scratch 를 덮기 전에 이 페이지가 디스크에 있어야 한다.
+	// 제자리 쓰기 도중에 죽으면 다음 OpenPager 가 scratch 의 온전한 사본으로 되돌린다.
+	buf = appendPageTrailer(buf, id)
+	if _, err := p.f.WriteAt(buf, doubleWriteSlot*p.physicalPageSize()); err != nil {
+		return err
+	}
+	if err := p.f.Sync(); err != nil {
+		return err
+	}
+	p.hook(id, WriteScratchDone)
+	if _, err := p.f.WriteAt(buf, offset); err != nil {
+		return err
+	}
+	return p.f.Sync()
+}
+
+// ReadPage 는 id 페이지를 읽는다. 할당되지 않은 페이지는 ErrPageOutOfRange 다.
+func (p *Pager) ReadPage(id int64) (*Page, error) {
+	if err := p.checkPageID(id, false); err != nil {
+		return nil, err
+	}
+	offset := p.pageOffset(id)
+	// 평문 파일이면 읽은 버퍼가 그대로 Page.Data 가 된다. 암호화 파일은 복호화한 새 슬라이스를 돌려주므로
+	// 디스크 이미지는 bufpool 에서 빌려 읽고 돌려준다.
+	var buf []byte
+	if p.aead != nil {
+		bp := bufpool.Get(int(p.physicalPageSize()))
+		defer bufpool.Put(bp)
+		buf = *bp
+	} else {
+		buf = make([]byte, p.physicalPageSize())
+	}
+	_, err := p.f.ReadAt(buf, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.doubleWrite {
+		stored, ok := checkPageTrailer(buf)
+		if !ok || stored != id {
+			return nil, &dberr.PageError{Page: id, Err: ErrChecksum}
+		}
+		buf = buf[:len(buf)-pageTrailerSize]
+	}
+
+	if p.aead != nil {
+		nonce, sealed := buf[:p.aead.NonceSize()], buf[p.aead.NonceSize():]
+		buf, err = p.aead.Open(nil, nonce, sealed, pageAAD(id))
+		if err != nil {
+			return nil, &dberr.PageError{Page: id, Err: fmt.Errorf("%w: %w", ErrDecrypt, err)}
+		}
+	}
+
+	return &Page{
+		Id:   int(id),
+		Data: buf,
+	}, nil
+}
+
+// 페이지 이미지 뒤에 PageID + CRC32(이미지 + PageID) 를 붙인다.
+func appendPageTrailer(image []byte, id int64) []byte {
+	n := len(image)
+	image = slices.Grow(image, pageTrailerSize)[:n+pageTrailerSize]
+	w := codec.NewWriter(image[n:])
+	w.Int64(id)
+	w.Uint32(crc32.ChecksumIEEE(image[:n+8]))
+	return image
+}
+
+// 꼬리의 CRC 가 맞으면 기록된 PageID 를 돌려준다.
+func checkPageTrailer(rec []byte) (int64, bool) {
+	if len(rec) < pageTrailerSize {
+		return 0, false
+	}
+	// CRC 는 꼬리의 PageID 까지 덮는다.
+	r := codec.NewReader(rec[len(rec)-pageTrailerSize:])
+	id := r.Int64()
+	if crc32.ChecksumIEEE(rec[:len(rec)-4]) != r.Uint32() {
+		return 0, false
+	}
+	return id, true
+}
+
+// scratch 영역의 사본이 온전하고 제자리 페이지와 다르면, 제자리 쓰기가 끊긴 것이므로 사본으로 덮어쓴다.
+// scratch 자체가 깨져 있으면 제자리 쓰기는 시작도 안 한 것이므로 그대로 둔다.
+func (p *Pager) recoverDoubleWrite() error {
+	sp := bufpool.Get(int(p.physicalPageSize()))
+	defer bufpool.Put(sp)
+	scratch := *sp
+	n, err := p.f.ReadAt(scratch, doubleWriteSlot*p.physicalPageSize())
+	if err == io.EOF && n == 0 {
+		return nil // 아직 한 번도 쓰지 않은 파일
+	}
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	id, ok := checkPageTrailer(scratch[:n])
+	if !ok || n != len(scratch) || id <= 0 {
+		return nil
+	}
+
+	cp := bufpool.Get(int(p.physicalPageSize()))
+	defer bufpool.Put(cp)
+	current := *cp
+	clear(current) // 파일 끝에 걸려 덜 읽힌 부분은 0 으로 비교한다
+	if _, err := p.f.ReadAt(current, p.pageOffset(id)); err != nil && err != io.EOF {
+		return err
+	}
+	if bytes.Equal(current, scratch) {
+		return nil
+	}
+	if p.readOnly {
+		return fmt.Errorf("%w: page %d needs double-write recovery", ErrReadOnly, id)
+	}
+
+	if _, err := p.f.WriteAt(scratch, p.pageOffset(id)); err != nil {
+		return err
+	}
+	if err := p.f.Sync(); err != nil {
+		return err
+	}
+	p.recovered = int(id)
+	return nil
+}
+
+func IntSliceToBytes(nums []int) []byte {
+	buf := make([]byte, 4*len(nums))
+	w := codec.NewWriter(buf)
+	for _, n := range nums {
+		w.Uint32(uint32(n))
+	}
+	return buf
+}
+
+func BytesToIntSlice(buf []byte) []int {
+	out := make([]int, len(buf)/4)
+	r := codec.NewReader(buf)
+	for i := range out {
+		out[i] = int(r.Uint32())
+	}
+	return out
+}
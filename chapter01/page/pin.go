@@ -0,0 +1,158 @@
+package page
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"slices"
+	"time"
+)
+
+// ==================================
+// Pin / Unpin
+// ==================================
+// ReadPage / WritePage 는 페이지를 복사해서 주고받는다. 위 계층(트리 노드 등)이 페이지를 제자리에서 읽고 고치려면
+// Pin 으로 캐시 칸을 직접 빌리고, 다 쓰면 Unpin 으로 돌려준다. pin 된 페이지는 캐시에서 밀려나지 않는다.
+// Unpin 을 빠뜨리면 그 칸은 다시는 내보낼 수 없어서 쓸 수 있는 캐시가 점점 줄고, 결국 ErrPoolExhausted 가 난다.
+// PinDebug 는 그런 실수를 찾는 모드다. Pin 한 위치를 기록해 두었다가
+// - 모든 pin 이 풀릴 때 PinThreshold 보다 오래 잡혀 있었으면 알리고
+// - CheckPins 를 부르면 지금 PinThreshold 를 넘긴 페이지를 알리고
+// - Close 때 남아 있는 pin 을 새어 나간 것으로 알린다.
+
+// PinDebug 에서 오래 잡혀 있다고 볼 기본 시간
+const defaultPinThreshold = time.Second
+
+var (
+	ErrNotPinned     = errors.New("pager: page is not pinned")
+	ErrPoolExhausted = errors.New("pager: every buffer pool page is pinned")
+	ErrPinLeak       = errors.New("pager: pages still pinned at close")
+)
+
+// PinReport 는 PinDebug 가 알리는 한 건
+type PinReport struct {
+	Page   int64
+	Pins   int           // 알릴 때 남아 있던 pin 수 (모두 풀릴 때 알리면 0)
+	Held   time.Duration // 처음 Pin 한 뒤 지난 시간
+	Caller string        // 처음 Pin 을 부른 위치 (file:line)
+	Leaked bool          // Close 때까지 Unpin 하지 않았다
+}
+
+func (r PinReport) String() string {
+	what := "held"
+	if r.Leaked {
+		what = "leaked"
+	}
+	return fmt.Sprintf("page %d %s for %s (pins=%d, pinned at %s)", r.Page, what, r.Held.Round(time.Millisecond), r.Pins, r.Caller)
+}
+
+// OnPinReport 를 주지 않았을 때 쓰는 기본 보고
+func logPinReport(r PinReport) {
+	msg := "page pinned too long"
+	if r.Leaked {
+		msg = "page pin leaked"
+	}
+	slog.Warn(msg, "page", r.Page, "pins", r.Pins, "held", r.Held, "caller", r.Caller)
+}
+
+// Pin 은 id 페이지를 캐시에 올리고 (없으면 읽어 온다) 그 캐시 칸을 그대로 가리키는 Page 를 돌려준다.
+// Data 는 Unpin 전까지만 쓰고, 고쳤으면 Unpin(id, true) 로 알린다. 같은 페이지를 여러 번 Pin 하면 그만큼 Unpin 해야 한다.
+func (bp *BufferPool) Pin(id int64) (*Page, error) {
+	if id == 0 {
+		return nil, ErrReservedPage
+	}
+	fr, err := bp.fetch(id)
+	if err != nil {
+		return nil, err
+	}
+	if fr.pins == 0 {
+		fr.pinnedAt = time.Now()
+		fr.caller = ""
+		if bp.pinDebug {
+			fr.caller = callerOf(2)
+		}
+	}
+	fr.pins++
+	return &Page{Id: int(id), Data: fr.data[:bp.PageSize()]}, nil
+}
+
+// Unpin 은 id 페이지의 pin 하나를 푼다. dirty 면 Pin 동안 고친 내용에 새 LSN 을 찍어 WritePage 처럼 정책에 따라 쓴다.
+// pin 되지 않은 페이지면 ErrNotPinned. 쓰기가 실패해도 pin 은 풀린다.
+func (bp *BufferPool) Unpin(id int64, dirty bool) error {
+	e, ok := bp.frames[id]
+	if !ok || e.Value.(*frame).pins == 0 {
+		return fmt.Errorf("%w: page %d", ErrNotPinned, id)
+	}
+	fr := e.Value.(*frame)
+	fr.pins--
+	if fr.pins == 0 && bp.pinDebug {
+		if held := time.Since(fr.pinnedAt); held > bp.pinThreshold {
+			bp.onPinReport(PinReport{Page: id, Held: held, Caller: fr.caller})
+		}
+	}
+
+	if !dirty {
+		return nil
+	}
+	if bp.pager.readOnly {
+		return ErrReadOnly
+	}
+	if err := bp.persist(id, fr.data); err != nil {
+		return err
+	}
+	bp.markWritten(fr)
+	return nil
+}
+
+// CheckPins 는 지금 PinThreshold 보다 오래 잡혀 있는 페이지를 페이지 번호 순으로 돌려준다.
+// PinDebug 면 OnPinReport 로도 알린다. 오래 도는 작업 중간에 불러서 Unpin 을 빠뜨린 곳을 찾는다.
+func (bp *BufferPool) CheckPins() []PinReport {
+	reports := bp.pinned(bp.pinThreshold, false)
+	if bp.pinDebug {
+		for _, r := range reports {
+			bp.onPinReport(r)
+		}
+	}
+	return reports
+}
+
+// checkLeaks 는 Close 때 남은 pin 을 확인한다.
+func (bp *BufferPool) checkLeaks() error {
+	leaks := bp.pinned(0, true)
+	if len(leaks) == 0 {
+		return nil
+	}
+	if bp.pinDebug {
+		for _, r := range leaks {
+			bp.onPinReport(r)
+		}
+	}
+	return fmt.Errorf("%w: %d pages", ErrPinLeak, len(leaks))
+}
+
+// pinned 는 minHeld 보다 오래 pin 되어 있는 페이지
+func (bp *BufferPool) pinned(minHeld time.Duration, leaked bool) []PinReport {
+	now := time.Now()
+	var out []PinReport
+	for e := bp.lru.Front(); e != nil; e = e.Next() {
+		fr := e.Value.(*frame)
+		if fr.pins == 0 {
+			continue
+		}
+		if held := now.Sub(fr.pinnedAt); held >= minHeld {
+			out = append(out, PinReport{Page: fr.id, Pins: fr.pins, Held: held, Caller: fr.caller, Leaked: leaked})
+		}
+	}
+	slices.SortFunc(out, func(a, b PinReport) int { return cmp.Compare(a.Page, b.Page) })
+	return out
+}
+
+// callerOf 는 skip 단계 위 호출자의 file:line
+func callerOf(skip int) string {
+	_, file, line, ok := runtime.Caller(skip)
+	if !ok {
+		return "?"
+	}
+	return fmt.Sprintf("%s:%d", file, line)
+}
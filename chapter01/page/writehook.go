@@ -0,0 +1,51 @@
+package page
+
+import "fmt"
+
+// ==================================
+// 쓰기 hook
+// ==================================
+// WritePage 한 번은 디스크에 여러 번 나눠 쓴다 (double-write 면 scratch -> 제자리, 새 페이지면 슈퍼블록의 페이지 수까지).
+// PagerOptions.WriteHook 을 주면 그 사이사이에 쓰는 goroutine 에서 hook 을 동기로 부른다.
+// hook 이 돌아올 때까지 쓰기는 멈춰 있으므로, 검사 코드는 hook 안에서 (또는 hook 이 기다리는 동안 다른 goroutine 에서)
+// 따로 연 읽기 전용 Pager 로 같은 파일을 읽어 보고, 쓰는 도중의 어느 시점에서든 예전 이미지나 새 이미지 중 하나만
+// 보이는지 (찢어진 페이지가 보이지 않는지) 확인할 수 있다. 순서를 스케줄러에 맡기지 않으므로 같은 입력이면 매번 같은 곳에서 멈춘다.
+// - hook 은 Pager 를 다시 부르면 안 된다. Pager 는 잠금이 없다.
+// - BufferPool 은 받은 PagerOptions 를 그대로 쓰므로 Checkpoint / 내보내기로 데이터 파일에 쓸 때도 페이지마다 불린다.
+
+// WriteStep 은 WritePage 한 번 안에서 WriteHook 이 불리는 자리
+type WriteStep int
+
+const (
+	// WriteStarted 는 디스크에 아무것도 쓰기 전
+	WriteStarted WriteStep = iota
+	// WriteScratchDone 은 double-write 파일에서 scratch 에 쓰고 fsync 한 뒤, 제자리에 쓰기 전. 다른 파일에서는 불리지 않는다.
+	WriteScratchDone
+	// WritePageDone 은 제자리에 쓴 뒤. 새 페이지면 슈퍼블록의 페이지 수는 아직 예전 값이다.
+	WritePageDone
+	// WriteFinished 는 WritePage 가 돌아가기 직전 (새 페이지면 페이지 수까지 기록한 뒤)
+	WriteFinished
+)
+
+var writeStepNames = map[WriteStep]string{
+	WriteStarted:     "started",
+	WriteScratchDone: "scratch-done",
+	WritePageDone:    "page-done",
+	WriteFinished:    "finished",
+}
+
+func (s WriteStep) String() string {
+	if name, ok := writeStepNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("WriteStep(%d)", int(s))
+}
+
+// WriteHook 은 id 페이지를 쓰는 도중 step 자리에 왔을 때 불린다.
+type WriteHook func(id int64, step WriteStep)
+
+func (p *Pager) hook(id int64, step WriteStep) {
+	if p.writeHook != nil {
+		p.writeHook(id, step)
+	}
+}
@@ -0,0 +1,152 @@
+package compare
+
+import (
+	"errors"
+	"io"
+	"os"
+	"unsafe"
+)
+
+// O_DIRECT 로 연 파일은 오프셋, 길이, 메모리 주소가 모두 블록 크기에 정렬되어야 한다.
+// 리스트 포맷은 12/16 바이트 단위로 읽고 쓰기 때문에, alignedFile 이 중간에서
+// 블록 단위 read-modify-write 로 바꿔준다.
+const directIOAlign = 4096
+
+// alignedFile 은 O_DIRECT 파일을 io.ReadWriteSeeker 처럼 쓸 수 있게 해주는 래퍼
+// - pos: 현재 논리 오프셋
+// - size: 논리 파일 크기. 블록 단위로 쓰면 실제 파일은 블록 경계까지 늘어나므로 따로 관리하고 Close 때 잘라낸다.
+// - buf: 정렬된 임시 버퍼
+type alignedFile struct {
+	f    *os.File
+	pos  int64
+	size int64
+	buf  []byte
+}
+
+func openAligned(path string, flags int, perm os.FileMode) (*alignedFile, error) {
+	if !directIOSupported {
+		return nil, errors.New("direct I/O is not supported on this platform")
+	}
+
+	f, err := os.OpenFile(path, flags|directIOFlag, perm)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &alignedFile{f: f, size: info.Size()}, nil
+}
+
+// 주소가 directIOAlign 에 맞도록 여유분을 더 잡은 뒤 잘라서 쓴다.
+func alignedBuffer(n int) []byte {
+	raw := make([]byte, n+directIOAlign)
+	shift := int(uintptr(unsafe.Pointer(&raw[0])) & (directIOAlign - 1))
+	if shift != 0 {
+		shift = directIOAlign - shift
+	}
+	return raw[shift : shift+n]
+}
+
+// [off, off+n) 을 덮는 블록 범위를 정렬된 버퍼로 읽는다. 파일 끝 너머는 0 으로 채운다.
+func (af *alignedFile) readBlocks(off int64, n int) (start int64, block []byte, err error) {
+	start = off &^ (directIOAlign - 1)
+	end := (off + int64(n) + directIOAlign - 1) &^ (directIOAlign - 1)
+
+	if len(af.buf) < int(end-start) {
+		af.buf = alignedBuffer(int(end - start))
+	}
+	block = af.buf[:end-start]
+
+	read, err := af.f.ReadAt(block, start)
+	if err != nil && err != io.EOF {
+		return 0, nil, err
+	}
+	clear(block[read:])
+	return start, block, nil
+}
+
+func (af *alignedFile) Read(p []byte) (int, error) {
+	n, err := af.ReadAt(p, af.pos)
+	af.pos += int64(n)
+	return n, err
+}
+
+func (af *alignedFile) Write(p []byte) (int, error) {
+	n, err := af.WriteAt(p, af.pos)
+	af.pos += int64(n)
+	return n, err
+}
+
+// ReadAt/WriteAt 은 pos 를 움직이지 않는다. 논리 크기를 넘는 부분은 읽지 않는다.
+func (af *alignedFile) ReadAt(p []byte, off int64) (int, error) {
+	if off >= af.size {
+		return 0, io.EOF
+	}
+	n := len(p)
+	if rest := af.size - off; int64(n) > rest {
+		n = int(rest)
+	}
+
+	start, block, err := af.readBlocks(off, n)
+	if err != nil {
+		return 0, err
+	}
+	copy(p[:n], block[off-start:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (af *alignedFile) WriteAt(p []byte, off int64) (int, error) {
+	start, block, err := af.readBlocks(off, len(p))
+	if err != nil {
+		return 0, err
+	}
+	copy(block[off-start:], p)
+	if _, err := af.f.WriteAt(block, start); err != nil {
+		return 0, err
+	}
+
+	if end := off + int64(len(p)); end > af.size {
+		af.size = end
+	}
+	return len(p), nil
+}
+
+func (af *alignedFile) Seek(offset int64, whence int) (int64, error) {
+	var next int64
+	switch whence {
+	case io.SeekStart:
+		next = offset
+	case io.SeekCurrent:
+		next = af.pos + offset
+	case io.SeekEnd:
+		next = af.size + offset
+	default:
+		return 0, errors.New("alignedFile.Seek: invalid whence")
+	}
+	if next < 0 {
+		return 0, errors.New("alignedFile.Seek: negative position")
+	}
+	af.pos = next
+	return next, nil
+}
+
+// 블록 단위 쓰기로 늘어난 꼬리를 논리 크기에 맞게 잘라낸 뒤 닫는다.
+func (af *alignedFile) Close() error {
+	if err := af.f.Truncate(af.size); err != nil {
+		af.f.Close()
+		return err
+	}
+	return af.f.Close()
+}
+
+func (af *alignedFile) Sync() error {
+	return af.f.Sync()
+}
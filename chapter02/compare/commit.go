@@ -0,0 +1,66 @@
+package compare
+
+import (
+	"time"
+
+	"github.com/tmdgusya/btree/iometrics"
+)
+
+// groupCommit 은 여러 논리 연산의 헤더 기록과 fsync 를 한 번으로 묶는다.
+// - 연산이 끝날 때마다 done 을 호출하고, every 개가 쌓이거나 interval 이 지나면 그때 헤더를 쓴다.
+// - every 와 interval 이 모두 0 이면 매 연산마다 커밋한다 (묶지 않는 원래 방식).
+// - sync 가 켜져 있으면 커밋마다 fsync 까지 한다. 디스크에 닿는 횟수는 IOMetrics.Syncs 로 확인한다.
+// 커밋 전에 죽으면 마지막 커밋 이후의 연산은 헤더에 반영되지 않는다. 묶는 만큼 잃을 수 있는 양도 커진다.
+type groupCommit struct {
+	every    int
+	interval time.Duration
+	sync     bool
+
+	pending int
+	last    time.Time
+}
+
+func (g *groupCommit) due() bool {
+	if g.every <= 0 && g.interval <= 0 {
+		return true
+	}
+	if g.every > 0 && g.pending >= g.every {
+		return true
+	}
+	return g.interval > 0 && time.Since(g.last) >= g.interval
+}
+
+// 연산 하나가 끝났음을 알린다. 커밋할 때가 되었으면 write 로 헤더를 기록한다.
+func (g *groupCommit) done(cf *iometrics.CountingFile, write func() error) error {
+	if g.pending == 0 && g.last.IsZero() {
+		g.last = time.Now()
+	}
+	g.pending++
+	if !g.due() {
+		return nil
+	}
+	return g.commit(cf, write)
+}
+
+// 아직 커밋되지 않은 연산이 있으면 지금 커밋한다.
+func (g *groupCommit) flush(cf *iometrics.CountingFile, write func() error) error {
+	if g.pending == 0 {
+		return nil
+	}
+	return g.commit(cf, write)
+}
+
+func (g *groupCommit) commit(cf *iometrics.CountingFile, write func() error) error {
+	defer cf.Section("commit")()
+	if err := write(); err != nil {
+		return err
+	}
+	if g.sync {
+		if err := cf.Sync(); err != nil {
+			return err
+		}
+	}
+	g.pending = 0
+	g.last = time.Now()
+	return nil
+}
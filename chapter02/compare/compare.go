@@ -0,0 +1,1266 @@
+// Package compare 는 offset / paged 두 연결 리스트의 I/O 를 같은 워크로드로 재서 비교하는 하네스.
+package compare
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tmdgusya/btree/codec"
+	"github.com/tmdgusya/btree/dberr"
+	"github.com/tmdgusya/btree/iometrics"
+	"github.com/tmdgusya/btree/workload"
+)
+
+// ==================================
+// 공통: 포맷 정의
+// ==================================
+
+var Magic = [4]byte{'L', 'L', 'S', 'T'}
+
+const PAGE_SIZE = 4096
+const PAGE_HEADER_SIZE = 2
+
+// Node on disk:
+// Value    uint32 (4)
+// NextPage uint32 (4)
+// NextSlot uint16 (2)
+// Tomb     uint8  (1)
+// _pad     uint8  (1)
+const SLOT_SIZE = 12
+
+const HEADER_SIZE = 32 // Magic(4) + Version(2) + PageSize(2) + PageCount(4) + HeadPage(4) + HeadSlot(2) + TailPage(4) + TailSlot(2) + Size(8)
+
+// 페이지 크기는 헤더의 PageSize 를 따른다. PAGE_SIZE 는 기본값.
+func slotsPerPage(pageSize uint16) int {
+	return (int(pageSize) - PAGE_HEADER_SIZE) / SLOT_SIZE
+}
+
+const NullPage uint32 = ^uint32(0) // 0xFFFFFFFF
+const NullSlot uint16 = ^uint16(0) // 0xFFFF
+
+type Header struct {
+	Magic     [4]byte
+	Version   uint16
+	PageSize  uint16
+	PageCount uint32
+	HeadPage  uint32
+	HeadSlot  uint16
+	TailPage  uint32
+	TailSlot  uint16
+	Size      uint64
+}
+
+type PageHeader struct {
+	Used uint16
+}
+
+type Node struct {
+	Value    uint32
+	NextPage uint32
+	NextSlot uint16
+	Tomb     uint8
+	_pad     uint8
+}
+
+// ==================================
+// 헤더 / 페이지 / 슬롯 유틸
+// ==================================
+
+func pageOffset(pageSize uint16, pageID uint32) int64 {
+	return int64(HEADER_SIZE) + int64(pageID)*int64(pageSize)
+}
+
+func writeHeader(cf *iometrics.CountingFile, h *Header) error {
+	if _, err := cf.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	buf := make([]byte, HEADER_SIZE)
+	w := codec.NewWriter(buf)
+	w.Bytes(h.Magic[:])
+	w.Uint16(h.Version)
+	w.Uint16(h.PageSize)
+	w.Uint32(h.PageCount)
+	w.Uint32(h.HeadPage)
+	w.Uint16(h.HeadSlot)
+	w.Uint32(h.TailPage)
+	w.Uint16(h.TailSlot)
+	w.Uint64(h.Size)
+
+	_, err := cf.Write(buf)
+	return err
+}
+
+func readHeader(cf *iometrics.CountingFile, h *Header) error {
+	if _, err := cf.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	buf := make([]byte, HEADER_SIZE)
+	if _, err := io.ReadFull(cf, buf); err != nil {
+		return err
+	}
+	r := codec.NewReader(buf)
+	r.Bytes(h.Magic[:])
+	h.Version = r.Uint16()
+	h.PageSize = r.Uint16()
+	h.PageCount = r.Uint32()
+	h.HeadPage = r.Uint32()
+	h.HeadSlot = r.Uint16()
+	h.TailPage = r.Uint32()
+	h.TailSlot = r.Uint16()
+	h.Size = r.Uint64()
+	return nil
+}
+
+func initEmptyPage(cf *iometrics.CountingFile, pageSize uint16, pageID uint32) error {
+	offset := pageOffset(pageSize, pageID)
+	if _, err := cf.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	buf := make([]byte, pageSize) // zeroed page
+	_, err := cf.Write(buf)
+	return err
+}
+
+func readPageHeader(cf *iometrics.CountingFile, pageSize uint16, pageID uint32) (PageHeader, error) {
+	offset := pageOffset(pageSize, pageID)
+	if _, err := cf.Seek(offset, io.SeekStart); err != nil {
+		return PageHeader{}, err
+	}
+	buf := make([]byte, PAGE_HEADER_SIZE)
+	if _, err := io.ReadFull(cf, buf); err != nil {
+		return PageHeader{}, err
+	}
+	r := codec.NewReader(buf)
+	return PageHeader{Used: r.Uint16()}, nil
+}
+
+func writePageHeader(cf *iometrics.CountingFile, pageSize uint16, pageID uint32, ph PageHeader) error {
+	offset := pageOffset(pageSize, pageID)
+	if _, err := cf.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	buf := make([]byte, PAGE_HEADER_SIZE)
+	w := codec.NewWriter(buf)
+	w.Uint16(ph.Used)
+	_, err := cf.Write(buf)
+	return err
+}
+
+func writeSlot(cf *iometrics.CountingFile, pageSize uint16, pageID uint32, slotID uint16, node Node) error {
+	offset := pageOffset(pageSize, pageID) + PAGE_HEADER_SIZE + int64(SLOT_SIZE)*int64(slotID)
+	if _, err := cf.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	buf := make([]byte, SLOT_SIZE)
+	putSlot(buf, node)
+
+	_, err := cf.Write(buf)
+	return err
+}
+
+func putSlot(buf []byte, node Node) {
+	w := codec.NewWriter(buf)
+	w.Uint32(node.Value)
+	w.Uint32(node.NextPage)
+	w.Uint16(node.NextSlot)
+	w.Uint8(node.Tomb)
+	w.Uint8(node._pad)
+}
+
+func parseSlot(buf []byte) Node {
+	r := codec.NewReader(buf)
+	return Node{
+		Value:    r.Uint32(),
+		NextPage: r.Uint32(),
+		NextSlot: r.Uint16(),
+		Tomb:     r.Uint8(),
+		_pad:     r.Uint8(),
+	}
+}
+
+// naive: 슬롯 하나마다 Seek+Read
+func readSlotNaive(cf *iometrics.CountingFile, pageSize uint16, pageID uint32, slotID uint16) (Node, error) {
+	offset := pageOffset(pageSize, pageID) + PAGE_HEADER_SIZE + int64(SLOT_SIZE)*int64(slotID)
+	if _, err := cf.Seek(offset, io.SeekStart); err != nil {
+		return Node{}, err
+	}
+
+	buf := make([]byte, SLOT_SIZE)
+	if _, err := io.ReadFull(cf, buf); err != nil {
+		return Node{}, err
+	}
+
+	return parseSlot(buf), nil
+}
+
+// ==================================
+// PageBuffer: 페이지 단위 로딩 + 슬롯 메모리 파싱
+// ==================================
+
+type PageBuffer struct {
+	pageID uint32
+	data   []byte
+	valid  bool
+}
+
+func (pb *PageBuffer) loadPage(cf *iometrics.CountingFile, pageSize uint16, pageID uint32) error {
+	offset := pageOffset(pageSize, pageID)
+	if _, err := cf.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	if len(pb.data) != int(pageSize) {
+		pb.data = make([]byte, pageSize)
+	}
+	if _, err := io.ReadFull(cf, pb.data); err != nil {
+		return err
+	}
+
+	pb.pageID = pageID
+	pb.valid = true
+	return nil
+}
+
+func readSlotWithBuffer(cf *iometrics.CountingFile, pb *PageBuffer, pageSize uint16, pageID uint32, slotID uint16) (Node, error) {
+	// 필요할 때만 페이지 전체 읽기. 버퍼에서 바로 꺼내면 hit, 페이지를 새로 읽으면 miss 로 센다.
+	if !pb.valid || pb.pageID != pageID {
+		cf.CacheMiss()
+		if err := pb.loadPage(cf, pageSize, pageID); err != nil {
+			return Node{}, err
+		}
+	} else {
+		cf.CacheHit()
+	}
+
+	start := PAGE_HEADER_SIZE + int64(SLOT_SIZE)*int64(slotID)
+	return parseSlot(pb.data[start : start+SLOT_SIZE]), nil
+}
+
+// ==================================
+// 슬롯 할당 / AppendTail 로 리스트 구성
+// ==================================
+
+func allocateSlot(cf *iometrics.CountingFile, h *Header) (pageID uint32, slotIndex uint16, err error) {
+	if h.PageCount == 0 {
+		pageID = 0
+		if err = initEmptyPage(cf, h.PageSize, pageID); err != nil {
+			return
+		}
+		h.PageCount = 1
+	} else {
+		pageID = h.PageCount - 1
+	}
+
+	ph, err := readPageHeader(cf, h.PageSize, pageID)
+	if err != nil {
+		return
+	}
+
+	if int(ph.Used) >= slotsPerPage(h.PageSize) {
+		pageID = h.PageCount
+		if err = initEmptyPage(cf, h.PageSize, pageID); err != nil {
+			return
+		}
+		h.PageCount++
+		ph.Used = 0
+	}
+
+	slotIndex = ph.Used
+	ph.Used++
+	if err = writePageHeader(cf, h.PageSize, pageID, ph); err != nil {
+		return
+	}
+	return
+}
+
+// 마지막으로 기록한 tail 노드의 복사본. 있으면 tail 의 Next 를 고칠 때 다시 읽지 않는다.
+type pagedTailCache struct {
+	valid bool
+	page  uint32
+	slot  uint16
+	node  Node
+}
+
+// AppendTail only (append-only 리스트)
+// 논리 순서 = 물리 삽입 순서
+// tail 이 nil 이면 매번 tail 노드를 디스크에서 읽는다 (read-modify-write).
+// 바뀐 헤더는 메모리에만 반영하고, 기록은 호출한 쪽이 writeHeader 로 한다 (groupCommit 참고).
+func pagedAppendTail(cf *iometrics.CountingFile, h *Header, tail *pagedTailCache, value uint32) error {
+	endAllocate := cf.Section("allocate")
+	pageID, slotIndex, err := allocateSlot(cf, h)
+	endAllocate()
+	if err != nil {
+		return err
+	}
+
+	newNode := Node{
+		Value:    value,
+		NextPage: NullPage,
+		NextSlot: NullSlot,
+		Tomb:     0,
+		_pad:     0,
+	}
+
+	if err := writeSlot(cf, h.PageSize, pageID, slotIndex, newNode); err != nil {
+		return err
+	}
+
+	if h.HeadPage == NullPage {
+		h.HeadPage = pageID
+		h.HeadSlot = slotIndex
+		h.TailPage = pageID
+		h.TailSlot = slotIndex
+		h.Size++
+		if tail != nil {
+			*tail = pagedTailCache{valid: true, page: pageID, slot: slotIndex, node: newNode}
+		}
+		return nil
+	}
+
+	// 기존 tail 노드의 Next 를 새 슬롯으로 연결
+	var tailNode Node
+	if tail != nil && tail.valid && tail.page == h.TailPage && tail.slot == h.TailSlot {
+		tailNode = tail.node
+	} else {
+		tailNode, err = readSlotNaive(cf, h.PageSize, h.TailPage, h.TailSlot)
+		if err != nil {
+			return err
+		}
+	}
+	tailNode.NextPage = pageID
+	tailNode.NextSlot = slotIndex
+	if err := writeSlot(cf, h.PageSize, h.TailPage, h.TailSlot, tailNode); err != nil {
+		return err
+	}
+
+	h.TailPage = pageID
+	h.TailSlot = slotIndex
+	h.Size++
+	if tail != nil {
+		*tail = pagedTailCache{valid: true, page: pageID, slot: slotIndex, node: newNode}
+	}
+	return nil
+}
+
+// InsertAt: 체인의 pos 번째 노드 앞에 넣는다 (0 이면 head, Size 면 tail = pagedAppendTail).
+// 슬롯은 AppendTail 과 똑같이 마지막 페이지에서 할당하므로 물리 순서는 삽입 순서이고 논리 순서만 달라진다.
+// head 에 넣을 때는 새 슬롯과 헤더만 쓴다. 연달아 넣은 노드가 같은 페이지 안에서 거꾸로 이어지므로
+// PageBuffer 로 순회하면 여전히 페이지당 한 번 읽는다.
+// 중간에 넣을 때는 head 부터 pos-1 번 따라가서 앞 노드를 찾고 그 Next 를 고친다. 따라가는 동안은 PageBuffer 를 쓴다.
+func pagedInsertAt(cf *iometrics.CountingFile, h *Header, tail *pagedTailCache, pos int, value uint32) error {
+	if pos >= int(h.Size) {
+		return pagedAppendTail(cf, h, tail, value)
+	}
+
+	// 앞 노드를 먼저 찾는다. 새 슬롯을 할당하면서 페이지를 고쳐 쓰기 전에 버퍼로 읽어야 버퍼가 낡지 않는다.
+	var prevPage uint32
+	var prevSlot uint16
+	var prev Node
+	if pos > 0 {
+		endWalk := cf.Section("walk")
+		var pb PageBuffer
+		page, slot := h.HeadPage, h.HeadSlot
+		for i := 0; ; i++ {
+			node, err := readSlotWithBuffer(cf, &pb, h.PageSize, page, slot)
+			if err != nil {
+				endWalk()
+				return err
+			}
+			if i == pos-1 {
+				prevPage, prevSlot, prev = page, slot, node
+				break
+			}
+			page, slot = node.NextPage, node.NextSlot
+		}
+		endWalk()
+	}
+
+	endAllocate := cf.Section("allocate")
+	pageID, slotIndex, err := allocateSlot(cf, h)
+	endAllocate()
+	if err != nil {
+		return err
+	}
+
+	newNode := Node{Value: value, NextPage: h.HeadPage, NextSlot: h.HeadSlot}
+	if pos > 0 {
+		newNode.NextPage, newNode.NextSlot = prev.NextPage, prev.NextSlot
+	}
+	if err := writeSlot(cf, h.PageSize, pageID, slotIndex, newNode); err != nil {
+		return err
+	}
+
+	if pos == 0 {
+		h.HeadPage = pageID
+		h.HeadSlot = slotIndex
+	} else {
+		prev.NextPage = pageID
+		prev.NextSlot = slotIndex
+		if err := writeSlot(cf, h.PageSize, prevPage, prevSlot, prev); err != nil {
+			return err
+		}
+	}
+	// 앞 노드는 tail 이 아니므로 (pos < Size) tail 캐시는 그대로 맞다.
+	h.Size++
+	return nil
+}
+
+// ==================================
+// Traverse: naive vs buffered
+// ==================================
+
+func traverseNaive(cf *iometrics.CountingFile, h *Header) ([]uint32, error) {
+	values := make([]uint32, 0, int(h.Size))
+	page := h.HeadPage
+	slot := h.HeadSlot
+
+	for page != NullPage && slot != NullSlot {
+		node, err := readSlotNaive(cf, h.PageSize, page, slot)
+		if err != nil {
+			return nil, err
+		}
+		if node.Tomb == 0 {
+			values = append(values, node.Value)
+		}
+		page = node.NextPage
+		slot = node.NextSlot
+	}
+	return values, nil
+}
+
+func traverseBuffered(cf *iometrics.CountingFile, h *Header) ([]uint32, error) {
+	values := make([]uint32, 0, int(h.Size))
+	page := h.HeadPage
+	slot := h.HeadSlot
+
+	var pb PageBuffer
+
+	for page != NullPage && slot != NullSlot {
+		node, err := readSlotWithBuffer(cf, &pb, h.PageSize, page, slot)
+		if err != nil {
+			return nil, err
+		}
+		if node.Tomb == 0 {
+			values = append(values, node.Value)
+		}
+		page = node.NextPage
+		slot = node.NextSlot
+	}
+	return values, nil
+}
+
+func pagedWhere(cf *iometrics.CountingFile, h *Header, target uint32) (*Location, error) {
+	page := h.HeadPage
+	slot := h.HeadSlot
+
+	var pb PageBuffer
+
+	for page != NullPage && slot != NullSlot {
+		node, err := readSlotWithBuffer(cf, &pb, h.PageSize, page, slot)
+		if err != nil {
+			return nil, err
+		}
+		if node.Tomb == 0 && node.Value == target {
+			return &Location{Page: page, Slot: slot, Offset: NullOffset}, nil
+		}
+		page = node.NextPage
+		slot = node.NextSlot
+	}
+	return nil, nil
+}
+
+// ==================================
+// Offset 기반 리스트 (chapter02/linkedlist 포맷)
+// ==================================
+
+const NullOffset int64 = -1
+
+// Magic(4) + Version(2) + PageSize(2) + HeadOffset(8) + TailOffset(8) + Size(8)
+const OFFSET_HEADER_SIZE = 32
+
+// Value(4) + Next(8) + Tomb(1) + pad(3)
+const OFFSET_NODE_SIZE = 16
+
+type OffsetHeader struct {
+	Magic      [4]byte
+	Version    uint16
+	PageSize   uint16
+	HeadOffset int64
+	TailOffset int64
+	Size       int64
+}
+
+type OffsetNode struct {
+	Value uint32
+	Next  int64
+	Tomb  uint8
+}
+
+func offsetWriteHeader(cf *iometrics.CountingFile, h *OffsetHeader) error {
+	if _, err := cf.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	buf := make([]byte, OFFSET_HEADER_SIZE)
+	w := codec.NewWriter(buf)
+	w.Bytes(h.Magic[:])
+	w.Uint16(h.Version)
+	w.Uint16(h.PageSize)
+	w.Int64(h.HeadOffset)
+	w.Int64(h.TailOffset)
+	w.Int64(h.Size)
+
+	_, err := cf.Write(buf)
+	return err
+}
+
+func offsetReadHeader(cf *iometrics.CountingFile, h *OffsetHeader) error {
+	if _, err := cf.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	buf := make([]byte, OFFSET_HEADER_SIZE)
+	if _, err := io.ReadFull(cf, buf); err != nil {
+		return err
+	}
+	r := codec.NewReader(buf)
+	r.Bytes(h.Magic[:])
+	h.Version = r.Uint16()
+	h.PageSize = r.Uint16()
+	h.HeadOffset = r.Int64()
+	h.TailOffset = r.Int64()
+	h.Size = r.Int64()
+	return nil
+}
+
+func offsetWriteNode(cf *iometrics.CountingFile, off int64, n OffsetNode) error {
+	if _, err := cf.Seek(off, io.SeekStart); err != nil {
+		return err
+	}
+
+	buf := make([]byte, OFFSET_NODE_SIZE)
+	w := codec.NewWriter(buf)
+	w.Uint32(n.Value)
+	w.Int64(n.Next)
+	w.Uint8(n.Tomb)
+
+	_, err := cf.Write(buf)
+	return err
+}
+
+func offsetReadNode(cf *iometrics.CountingFile, off int64) (OffsetNode, error) {
+	if _, err := cf.Seek(off, io.SeekStart); err != nil {
+		return OffsetNode{}, err
+	}
+
+	buf := make([]byte, OFFSET_NODE_SIZE)
+	if _, err := io.ReadFull(cf, buf); err != nil {
+		return OffsetNode{}, err
+	}
+
+	r := codec.NewReader(buf)
+	return OffsetNode{
+		Value: r.Uint32(),
+		Next:  r.Int64(),
+		Tomb:  r.Uint8(),
+	}, nil
+}
+
+type offsetTailCache struct {
+	valid bool
+	off   int64
+	node  OffsetNode
+}
+
+// 파일 끝에 노드를 붙이고, 기존 tail 노드의 Next 를 갱신한다.
+// tail 이 nil 이면 매번 tail 노드를 디스크에서 읽는다 (read-modify-write).
+// 헤더 기록은 pagedAppendTail 과 마찬가지로 호출한 쪽에서 한다.
+func offsetAppendTail(cf *iometrics.CountingFile, h *OffsetHeader, tail *offsetTailCache, value uint32) error {
+	newOff, err := cf.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	newNode := OffsetNode{Value: value, Next: NullOffset}
+	if err := offsetWriteNode(cf, newOff, newNode); err != nil {
+		return err
+	}
+
+	if h.HeadOffset == NullOffset {
+		h.HeadOffset = newOff
+		h.TailOffset = newOff
+		h.Size++
+		if tail != nil {
+			*tail = offsetTailCache{valid: true, off: newOff, node: newNode}
+		}
+		return nil
+	}
+
+	var tailNode OffsetNode
+	if tail != nil && tail.valid && tail.off == h.TailOffset {
+		tailNode = tail.node
+	} else {
+		tailNode, err = offsetReadNode(cf, h.TailOffset)
+		if err != nil {
+			return err
+		}
+	}
+	tailNode.Next = newOff
+	if err := offsetWriteNode(cf, h.TailOffset, tailNode); err != nil {
+		return err
+	}
+
+	h.TailOffset = newOff
+	h.Size++
+	if tail != nil {
+		*tail = offsetTailCache{valid: true, off: newOff, node: newNode}
+	}
+	return nil
+}
+
+// offsetInsertAt 은 pagedInsertAt 의 offset 리스트 판. 새 노드는 언제나 파일 끝에 붙으므로
+// head 나 중간에 넣을수록 논리 순서가 파일 순서에서 멀어지고, 순회와 검색이 파일을 앞뒤로 오간다.
+func offsetInsertAt(cf *iometrics.CountingFile, h *OffsetHeader, tail *offsetTailCache, pos int, value uint32) error {
+	if pos >= int(h.Size) {
+		return offsetAppendTail(cf, h, tail, value)
+	}
+
+	var prevOff int64 = NullOffset
+	var prev OffsetNode
+	if pos > 0 {
+		endWalk := cf.Section("walk")
+		off := h.HeadOffset
+		for i := 0; ; i++ {
+			node, err := offsetReadNode(cf, off)
+			if err != nil {
+				endWalk()
+				return err
+			}
+			if i == pos-1 {
+				prevOff, prev = off, node
+				break
+			}
+			off = node.Next
+		}
+		endWalk()
+	}
+
+	newOff, err := cf.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	newNode := OffsetNode{Value: value, Next: h.HeadOffset}
+	if pos > 0 {
+		newNode.Next = prev.Next
+	}
+	if err := offsetWriteNode(cf, newOff, newNode); err != nil {
+		return err
+	}
+
+	if pos == 0 {
+		h.HeadOffset = newOff
+	} else {
+		prev.Next = newOff
+		if err := offsetWriteNode(cf, prevOff, prev); err != nil {
+			return err
+		}
+	}
+	h.Size++
+	return nil
+}
+
+func offsetTraverse(cf *iometrics.CountingFile, h *OffsetHeader) ([]uint32, error) {
+	values := make([]uint32, 0, h.Size)
+	for off := h.HeadOffset; off != NullOffset; {
+		node, err := offsetReadNode(cf, off)
+		if err != nil {
+			return nil, err
+		}
+		if node.Tomb == 0 {
+			values = append(values, node.Value)
+		}
+		off = node.Next
+	}
+	return values, nil
+}
+
+func offsetWhere(cf *iometrics.CountingFile, h *OffsetHeader, target uint32) (int64, error) {
+	for off := h.HeadOffset; off != NullOffset; {
+		node, err := offsetReadNode(cf, off)
+		if err != nil {
+			return NullOffset, err
+		}
+		if node.Tomb == 0 && node.Value == target {
+			return off, nil
+		}
+		off = node.Next
+	}
+	return NullOffset, nil
+}
+
+// ==================================
+// bench: 모든 저장소를 같은 조건으로 돌려서 표로 비교
+// ==================================
+
+// 벤치마크 대상 저장소. 어떤 LinkedListStore 든 이름과 파일 경로만 붙이면 같은 조건으로 돌릴 수 있다.
+type benchStore struct {
+	name  string
+	path  string
+	store LinkedListStore
+}
+
+type benchResult struct {
+	store   string
+	phase   string
+	ops     int
+	io      iometrics.IOMetrics
+	elapsed time.Duration
+	touches []int64 // touchBlock 바이트 블록별 읽기 / 쓰기 횟수. touchBlock 이 0 이면 nil
+}
+
+// 벤치마크 파일 기본 경로. 각 저장소 실행이 끝나면 지운다.
+const defaultOffsetBenchPath = "bench_offset.db"
+const defaultPagedBenchPath = "bench_paged.llst"
+
+func benchStores(opts StoreOptions, offsetPath, pagedPath string) []benchStore {
+	return []benchStore{
+		{name: "offset", path: offsetPath, store: &OffsetStore{StoreOptions: opts}},
+		{name: "paged", path: pagedPath, store: &PagedStore{StoreOptions: opts}},
+	}
+}
+
+type benchConfig struct {
+	n       int
+	values  int // 값 범위 [0, values). n 보다 작으면 같은 값이 여러 번 들어간다.
+	lookups int
+	pattern string
+	dist    workload.Distribution
+	seed    int64
+	mix     insertMix // build 단계에서 넣을 위치 (mix.go)
+
+	// 0 이 아니면 단계마다 이 크기의 블록별로 몇 번 건드렸는지 센다 (HTML 히트맵용)
+	touchBlock int64
+
+	// 비어 있지 않으면 저장소마다 <tracePrefix>.<store>.trace 에 모든 I/O 를 기록한다.
+	tracePrefix string
+}
+
+// access pattern
+// - scan: 전체 순회 1회
+// - lookup: dist 분포에서 뽑은 값 lookups 개 검색 (순차 체인을 따라가므로 위치에 비례하는 비용)
+// build 는 항상 0..values-1 을 순서대로 (모자라면 처음부터 다시) 넣는다. 넣는 위치는 cfg.mix 를 따르고 기본은 tail 이다.
+// lookup 키는 workload 로 만들기 때문에 같은 seed 면 저장소마다 같은 키를 찾는다.
+// build 단계의 시간과 I/O 에는 group commit 으로 미뤄둔 마지막 헤더 기록(Flush)까지 포함한다.
+// 두 번째 반환값은 저장소가 Section 으로 나눠 기록한 논리 연산별 I/O 이다.
+func runBench(t benchStore, cfg benchConfig) (_ []benchResult, _ []iometrics.NamedMetrics, err error) {
+	n, lookups, pattern := cfg.n, cfg.lookups, cfg.pattern
+
+	_ = os.Remove(t.path)
+	handle, err := t.store.Open(t.path, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.Remove(t.path)
+	defer t.store.Close(handle)
+	cf := handle.File
+
+	if cfg.tracePrefix != "" {
+		traceFile, err := os.Create(cfg.tracePrefix + "." + t.name + ".trace")
+		if err != nil {
+			return nil, nil, err
+		}
+		defer traceFile.Close()
+		stop := cf.Trace(traceFile)
+		defer func() {
+			if stopErr := stop(); stopErr != nil && err == nil {
+				err = stopErr
+			}
+		}()
+	}
+
+	results := make([]benchResult, 0, 3)
+	measure := func(phase string, ops int, fn func() error) error {
+		var touches []int64
+		if cfg.touchBlock > 0 {
+			defer cf.Observe(func(a iometrics.Access) {
+				touches = recordTouch(touches, cfg.touchBlock, a)
+			})()
+		}
+
+		before := cf.Metrics()
+		start := time.Now()
+		if err := fn(); err != nil {
+			return err
+		}
+		results = append(results, benchResult{
+			store:   t.name,
+			phase:   phase,
+			ops:     ops,
+			io:      cf.Metrics().Diff(before),
+			elapsed: time.Since(start),
+			touches: touches,
+		})
+		return nil
+	}
+
+	build, err := workload.New(workload.Config{Distribution: workload.Sequential, KeySpace: cfg.values})
+	if err != nil {
+		return nil, nil, err
+	}
+	positions := newPositionPicker(cfg.mix, cfg.seed)
+	err = measure("build", n, func() error {
+		for i, value := range build.Keys(n) {
+			var err error
+			if pos := positions.next(i); pos < i {
+				err = t.store.InsertAt(handle, pos, value)
+			} else {
+				err = t.store.AppendTail(handle, value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return t.store.Flush(handle)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if pattern == "scan" || pattern == "all" {
+		err = measure("scan", 1, func() error {
+			values, err := t.store.TraverseValues(handle)
+			if err == nil && len(values) != n {
+				err = fmt.Errorf("%s: scan returned %d values, expected %d", t.name, len(values), n)
+			}
+			return err
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if pattern == "lookup" || pattern == "all" {
+		gen, err := workload.New(workload.Config{Distribution: cfg.dist, KeySpace: cfg.values, Seed: cfg.seed})
+		if err != nil {
+			return nil, nil, err
+		}
+		targets := gen.Keys(lookups)
+		err = measure("lookup", lookups, func() error {
+			for _, target := range targets {
+				loc, err := t.store.Where(handle, target)
+				if err != nil {
+					return err
+				}
+				if loc == nil {
+					return fmt.Errorf("%s: value %d: %w", t.name, target, dberr.ErrKeyNotFound)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return results, cf.Sections(), nil
+}
+
+// sizes 가 true 면 결과마다 읽기 / 쓰기 크기 분포를 표 아래에 덧붙인다.
+func printBenchTable(w io.Writer, results []benchResult, sizes bool) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "store\tphase\tops\treads\twrites\tseeks\tsyncs\tbytes read\tbytes written\tlogical reads\thit ratio\tseek distance\tavg distance\telapsed\t")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%s\t%s\t%d\t%.0f\t%s\t\n",
+			r.store, r.phase, r.ops, r.io.Reads, r.io.Writes, r.io.Seeks, r.io.Syncs,
+			r.io.BytesRead, r.io.BytesWritten, logicalReads(r.io), hitRatio(r.io),
+			r.io.SeekDistance, r.io.AvgSeekDistance(), r.elapsed.Round(time.Microsecond))
+	}
+	tw.Flush()
+
+	// 저장소 전체(모든 단계 합)의 이동 거리. 두 레이아웃의 차이가 한 줄로 드러난다.
+	fmt.Fprintln(w)
+	totals := make(map[string]iometrics.IOMetrics)
+	var order []string
+	for _, r := range results {
+		t, ok := totals[r.store]
+		if !ok {
+			order = append(order, r.store)
+		}
+		t.Reads += r.io.Reads
+		t.Writes += r.io.Writes
+		t.SeekDistance += r.io.SeekDistance
+		totals[r.store] = t
+	}
+	for _, store := range order {
+		t := totals[store]
+		fmt.Fprintf(w, "%s: seek distance total=%s avg=%.0f B per read/write\n",
+			store, formatSize(t.SeekDistance), t.AvgSeekDistance())
+	}
+
+	if sizes {
+		fmt.Fprintln(w)
+		for _, r := range results {
+			fmt.Fprintf(w, "%s/%s read sizes : %s\n", r.store, r.phase, r.io.ReadSizes)
+			fmt.Fprintf(w, "%s/%s write sizes: %s\n", r.store, r.phase, r.io.WriteSizes)
+		}
+	}
+}
+
+// 바이트 수를 읽기 쉬운 단위로 (예: 1.5 MB)
+func formatSize(n int64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+	v := float64(n)
+	i := 0
+	for v >= 1024 && i < len(units)-1 {
+		v /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%d B", n)
+	}
+	return fmt.Sprintf("%.1f %s", v, units[i])
+}
+
+// 버퍼를 거치지 않은 단계는 logical / physical 구분이 없으므로 "-" 로 적는다.
+func logicalReads(m iometrics.IOMetrics) string {
+	if m.LogicalReads() == 0 {
+		return "-"
+	}
+	return fmt.Sprint(m.LogicalReads())
+}
+
+func hitRatio(m iometrics.IOMetrics) string {
+	if m.LogicalReads() == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", m.HitRatio()*100)
+}
+
+// 저장소 하나에서 Section 으로 나눈 논리 연산 하나의 I/O
+type benchSection struct {
+	store string
+	iometrics.NamedMetrics
+}
+
+// 저장소 / 논리 연산별 I/O 를 표로 출력한다. 연산이 중첩되면 가장 안쪽 연산에만 센다.
+func printSectionTable(w io.Writer, sections []benchSection) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "store\tsection\treads\twrites\tseeks\tsyncs\tbytes read\tbytes written\tlogical reads\thit ratio\tseek distance\tavg distance\t")
+	for _, s := range sections {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%d\t%d\t%d\t%s\t%s\t%d\t%.0f\t\n",
+			s.store, s.Name, s.IO.Reads, s.IO.Writes, s.IO.Seeks, s.IO.Syncs, s.IO.BytesRead, s.IO.BytesWritten,
+			logicalReads(s.IO), hitRatio(s.IO), s.IO.SeekDistance, s.IO.AvgSeekDistance())
+	}
+	tw.Flush()
+}
+
+// 결과마다 read / write / seek 한 번에 걸린 시간의 p50 / p95 / p99 를 표로 출력한다.
+func printLatencyTable(w io.Writer, results []benchResult) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "store\tphase\top\tcount\tp50\tp95\tp99\t")
+	for _, r := range results {
+		ops := []struct {
+			name string
+			h    iometrics.LatencyHistogram
+		}{
+			{"read", r.io.ReadLatency},
+			{"write", r.io.WriteLatency},
+			{"seek", r.io.SeekLatency},
+		}
+		for _, op := range ops {
+			if op.h.Count == 0 {
+				continue
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\t%s\t\n",
+				r.store, r.phase, op.name, op.h.Count, op.h.Quantile(0.50), op.h.Quantile(0.95), op.h.Quantile(0.99))
+		}
+	}
+	tw.Flush()
+}
+
+// Bench 는 `btree compare bench` 의 본체.
+// 사용법: btree compare bench [-n N] [-values R] [-mix tail|head|random|head=H,tail=T,random=R] [-lookups K] [-pattern scan|lookup|all] [-dist uniform|zipfian|sequential] [-store offset|paged] [-page-size B] [-seed S] [-direct] [-tail-cache=false] [-group N] [-group-interval D] [-sync] [-sizes] [-latency] [-sections] [-format table|csv|json|html] [-offset-file P] [-paged-file P] [-trace PREFIX]
+func Bench(args []string) error {
+	fs := flag.NewFlagSet("compare bench", flag.ContinueOnError)
+	n := fs.Int("n", 10000, "number of values appended to each store")
+	values := fs.Int("values", 0, "append values from [0, R) in order, wrapping around; 0 means R = n (all distinct)")
+	mix := fs.String("mix", "tail", "where build inserts go: tail, head, random, or weights like head=1,tail=2,random=1")
+	lookups := fs.Int("lookups", 100, "number of random lookups for the lookup pattern")
+	pattern := fs.String("pattern", "all", "access pattern after build: scan, lookup or all")
+	store := fs.String("store", "", "run only this store (offset or paged); empty runs all")
+	dist := fs.String("dist", "uniform", "key distribution for the lookup pattern: uniform, zipfian or sequential")
+	seed := fs.Int64("seed", 1, "random seed for the lookup pattern (0 = pick one at random and print it to stderr)")
+	pageSize := fs.Uint("page-size", PAGE_SIZE, "page size in bytes recorded in the file header (power of two, 512..32768)")
+	tailCache := fs.Bool("tail-cache", true, "keep the last appended node in memory instead of re-reading the tail on every append")
+	group := fs.Int("group", 1, "commit the header once every N appends (0 with -group-interval groups by time only)")
+	groupInterval := fs.Duration("group-interval", 0, "also commit when this much time has passed since the last commit")
+	sync := fs.Bool("sync", false, "fsync after every header commit")
+	showSections := fs.Bool("sections", false, "break down I/O by logical operation (append, allocate, commit, traverse, lookup)")
+	format := fs.String("format", "table", "output format: table, csv, json or html (self-contained report with charts)")
+	latency := fs.Bool("latency", false, "print p50/p95/p99 latency of each read/write/seek call after the table")
+	sizes := fs.Bool("sizes", false, "print per-call read/write size histograms after the table")
+	direct := fs.Bool("direct", false, "open data files with O_DIRECT (bypass the page cache) using aligned buffers")
+	offsetPath := fs.String("offset-file", defaultOffsetBenchPath, "scratch file for the offset store (removed afterwards)")
+	pagedPath := fs.String("paged-file", defaultPagedBenchPath, "scratch file for the paged store (removed afterwards)")
+	trace := fs.String("trace", "", "write every I/O call to PREFIX.<store>.trace (render with 'compare timeline')")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	switch *pattern {
+	case "scan", "lookup", "all":
+	default:
+		return fmt.Errorf("unknown pattern %q", *pattern)
+	}
+	lookupDist, err := workload.ParseDistribution(*dist)
+	if err != nil {
+		return err
+	}
+	insertMix, err := parseInsertMix(*mix)
+	if err != nil {
+		return err
+	}
+	switch *format {
+	case "table", "csv", "json", "html":
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	if *n <= 0 {
+		return fmt.Errorf("-n must be positive")
+	}
+	if *values < 0 {
+		return fmt.Errorf("-values must not be negative")
+	}
+	if *values == 0 {
+		*values = *n
+	}
+	if *offsetPath == *pagedPath {
+		return fmt.Errorf("-offset-file and -paged-file must differ")
+	}
+	if *pageSize < 512 || *pageSize > 32768 || *pageSize&(*pageSize-1) != 0 {
+		return fmt.Errorf("-page-size must be a power of two between 512 and 32768")
+	}
+
+	if *direct && !directIOSupported {
+		return fmt.Errorf("-direct is not supported on this platform")
+	}
+
+	if *group < 0 {
+		return fmt.Errorf("-group must not be negative")
+	}
+	// 표 / CSV / JSON / HTML 어느 출력이든 그대로 두도록, 고른 씨앗은 stderr 로 알려 준다.
+	if *seed == 0 {
+		*seed = workload.RandomSeed()
+		fmt.Fprintf(os.Stderr, "compare bench: seed %d\n", *seed)
+	}
+
+	cfg := benchConfig{n: *n, values: *values, lookups: *lookups, pattern: *pattern, dist: lookupDist, seed: *seed, mix: insertMix}
+	cfg.tracePrefix = *trace
+	if *format == "html" {
+		// 히트맵 블록은 페이지 크기와 맞춰서, paged 리스트는 한 칸이 대략 한 페이지가 되게 한다.
+		cfg.touchBlock = int64(*pageSize)
+	}
+	opts := StoreOptions{
+		PageSize:      uint16(*pageSize),
+		Direct:        *direct,
+		TailCache:     *tailCache,
+		GroupEvery:    *group,
+		GroupInterval: *groupInterval,
+		Sync:          *sync,
+	}
+
+	results := make([]benchResult, 0)
+	sections := make([]benchSection, 0)
+	ran := 0
+	for _, t := range benchStores(opts, *offsetPath, *pagedPath) {
+		if *store != "" && *store != t.name {
+			continue
+		}
+		r, sec, err := runBench(t, cfg)
+		if err != nil {
+			return err
+		}
+		results = append(results, r...)
+		for _, m := range sec {
+			sections = append(sections, benchSection{store: t.name, NamedMetrics: m})
+		}
+		ran++
+	}
+	if ran == 0 {
+		return fmt.Errorf("unknown store %q", *store)
+	}
+
+	switch *format {
+	case "csv":
+		return writeBenchCSV(os.Stdout, results)
+	case "json":
+		return writeBenchJSON(os.Stdout, results)
+	case "html":
+		return writeBenchHTML(os.Stdout, results, cfg.touchBlock)
+	}
+
+	if insertMix != tailOnly {
+		fmt.Printf("build insert mix: %s\n\n", insertMix)
+	}
+	printBenchTable(os.Stdout, results, *sizes)
+	if *latency {
+		fmt.Println()
+		printLatencyTable(os.Stdout, results)
+	}
+	if *showSections {
+		fmt.Println()
+		printSectionTable(os.Stdout, sections)
+	}
+	return nil
+}
+
+// ==================================
+// main: 리스트 하나 만들고 두 방식 비교
+// ==================================
+
+// Demo 는 `btree compare demo` 의 본체.
+// 사용법: btree compare demo [-n N] [-file P] [-page-size B]
+// 리스트 하나를 만든 뒤 naive / buffered 순회의 I/O 를 비교한다.
+func Demo(args []string) error {
+	fs := flag.NewFlagSet("compare demo", flag.ContinueOnError)
+	n := fs.Int("n", 100000, "number of values appended before the traversals")
+	path := fs.String("file", "paged_buffer_compare.llst", "list file to build (recreated on every run)")
+	pageSize := fs.Uint("page-size", PAGE_SIZE, "page size in bytes recorded in the file header (power of two, 512..32768)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *n <= 0 {
+		return fmt.Errorf("-n must be positive")
+	}
+	if *pageSize < 512 || *pageSize > 32768 || *pageSize&(*pageSize-1) != 0 {
+		return fmt.Errorf("-page-size must be a power of two between 512 and 32768")
+	}
+
+	// 깨끗하게 시작
+	_ = os.Remove(*path)
+
+	raw, err := os.OpenFile(*path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	cf := iometrics.NewCountingFile(raw)
+	defer cf.Close()
+
+	h := &Header{
+		Magic:     Magic,
+		Version:   2,
+		PageSize:  uint16(*pageSize),
+		PageCount: 0,
+		HeadPage:  NullPage,
+		HeadSlot:  NullSlot,
+		TailPage:  NullPage,
+		TailSlot:  NullSlot,
+		Size:      0,
+	}
+
+	if err := writeHeader(cf, h); err != nil {
+		return err
+	}
+
+	// 리스트 구성: append-only
+	var tail pagedTailCache
+	for i := 0; i < *n; i++ {
+		if err := pagedAppendTail(cf, h, &tail, uint32(i)); err != nil {
+			return err
+		}
+		if err := writeHeader(cf, h); err != nil {
+			return err
+		}
+	}
+
+	// 헤더를 다시 읽어서 상태 확인
+	if err := readHeader(cf, h); err != nil {
+		return err
+	}
+	fmt.Printf("List built: Size=%d, PageCount=%d\n", h.Size, h.PageCount)
+
+	// ---------------------------
+	// 1) naive Traverse
+	// ---------------------------
+	baseMetrics := cf.Metrics()
+	valsNaive, err := traverseNaive(cf, h)
+	if err != nil {
+		return err
+	}
+	afterNaive := cf.Metrics()
+	naiveDelta := afterNaive.Diff(baseMetrics)
+
+	fmt.Println("Naive traverse length:", len(valsNaive))
+	fmt.Printf("Naive I/O: Reads=%d, Writes=%d, Seeks=%d, BytesRead=%d\n",
+		naiveDelta.Reads, naiveDelta.Writes, naiveDelta.Seeks, naiveDelta.BytesRead)
+
+	// ---------------------------
+	// 2) buffered Traverse
+	// ---------------------------
+	baseMetrics2 := cf.Metrics()
+	valsBuf, err := traverseBuffered(cf, h)
+	if err != nil {
+		return err
+	}
+	afterBuf := cf.Metrics()
+	bufDelta := afterBuf.Diff(baseMetrics2)
+
+	fmt.Println("Buffered traverse length:", len(valsBuf))
+	fmt.Printf("Buffered I/O: Reads=%d, Writes=%d, Seeks=%d, BytesRead=%d\n",
+		bufDelta.Reads, bufDelta.Writes, bufDelta.Seeks, bufDelta.BytesRead)
+
+	// I want to print out the diff
+	fmt.Printf("Buffered I/O Diff: Reads=%d, Writes=%d, Seeks=%d\n",
+		bufDelta.Reads-naiveDelta.Reads,
+		bufDelta.Writes-naiveDelta.Writes,
+		bufDelta.Seeks-naiveDelta.Seeks)
+	return nil
+}
+
+// Timeline 은 `btree compare timeline` 의 본체.
+// 사용법: btree compare timeline [-o out.svg] <trace>
+// bench -trace 로 남긴 트레이스를 접근 패턴 그림(SVG)으로 그린다.
+func Timeline(args []string) error {
+	fs := flag.NewFlagSet("compare timeline", flag.ContinueOnError)
+	out := fs.String("o", "", "output SVG file (default: <trace>.svg)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: compare timeline [-o out.svg] <trace>")
+	}
+	path := fs.Arg(0)
+	if *out == "" {
+		*out = path + ".svg"
+	}
+
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	entries, err := iometrics.ReadTrace(in)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := iometrics.RenderTimeline(f, entries, filepath.Base(path)); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("%d calls -> %s\n", len(entries), *out)
+	return nil
+}
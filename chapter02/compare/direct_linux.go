@@ -0,0 +1,10 @@
+//go:build linux
+
+package compare
+
+import "syscall"
+
+// O_DIRECT: 페이지 캐시를 거치지 않고 디스크와 직접 주고받는다.
+const directIOFlag = syscall.O_DIRECT
+
+const directIOSupported = true
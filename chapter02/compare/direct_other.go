@@ -0,0 +1,8 @@
+//go:build !linux
+
+package compare
+
+// O_DIRECT 를 지원하지 않는 플랫폼에서는 -direct 옵션을 거부한다.
+const directIOFlag = 0
+
+const directIOSupported = false
@@ -0,0 +1,56 @@
+package compare
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/tmdgusya/btree/iometrics"
+)
+
+// ==================================
+// bench 결과를 기계가 읽을 수 있는 형식으로 내보내기 (CSV / JSON)
+// ==================================
+
+// bench 결과 전체를 CSV 로 쓴다. 앞쪽 열은 store, phase, ops, elapsed_ns 이고 나머지는 iometrics.IOMetrics 와 같다.
+func writeBenchCSV(w io.Writer, results []benchResult) error {
+	cw := csv.NewWriter(w)
+	header := append([]string{"store", "phase", "ops", "elapsed_ns"}, iometrics.CSVHeader()...)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, r := range results {
+		record := []string{r.store, r.phase, strconv.Itoa(r.ops), strconv.FormatInt(int64(r.elapsed), 10)}
+		if err := cw.Write(append(record, r.io.CSVRecord()...)); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+type benchResultJSON struct {
+	Store     string              `json:"store"`
+	Phase     string              `json:"phase"`
+	Ops       int                 `json:"ops"`
+	ElapsedNs int64               `json:"elapsedNs"`
+	IO        iometrics.IOMetrics `json:"io"`
+}
+
+func writeBenchJSON(w io.Writer, results []benchResult) error {
+	out := make([]benchResultJSON, 0, len(results))
+	for _, r := range results {
+		out = append(out, benchResultJSON{
+			Store:     r.store,
+			Phase:     r.phase,
+			Ops:       r.ops,
+			ElapsedNs: int64(r.elapsed / time.Nanosecond),
+			IO:        r.io,
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
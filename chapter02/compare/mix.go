@@ -0,0 +1,93 @@
+package compare
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// ==================================
+// build 단계의 삽입 위치 섞기
+// ==================================
+// 기본 build 는 tail 에만 붙여서 두 리스트 모두 논리 순서 = 파일 순서가 된다.
+// -mix 로 head / 무작위 위치 삽입을 섞으면 offset 리스트는 노드가 파일 끝에만 붙으므로 체인이 파일을 앞뒤로 오가고,
+// paged 리스트는 순회가 페이지 단위로 읽으므로 같은 페이지 안에서 오가는 것은 읽기 한 번으로 끝난다.
+// 무작위 위치는 앞 노드를 찾으려고 head 부터 따라가므로 build 비용이 n 의 제곱에 비례한다. n 을 줄여서 쓴다.
+
+// insertMix 는 head / tail / random 위치에 넣을 비율. 합이 1 이다.
+type insertMix struct {
+	head, tail, random float64
+}
+
+var tailOnly = insertMix{tail: 1}
+
+// parseInsertMix 는 -mix 값을 읽는다. "tail", "head", "random" 은 그 위치에만 넣고,
+// "head=1,tail=2,random=1" 처럼 쓰면 가중치대로 섞는다. 빠진 위치는 0 이다.
+func parseInsertMix(s string) (insertMix, error) {
+	switch s {
+	case "tail":
+		return tailOnly, nil
+	case "head":
+		return insertMix{head: 1}, nil
+	case "random":
+		return insertMix{random: 1}, nil
+	}
+
+	var m insertMix
+	for _, part := range strings.Split(s, ",") {
+		name, weight, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return insertMix{}, fmt.Errorf("insert mix %q: want tail, head, random or head=H,tail=T,random=R", s)
+		}
+		w, err := strconv.ParseFloat(weight, 64)
+		if err != nil || w < 0 {
+			return insertMix{}, fmt.Errorf("insert mix %q: bad weight %q for %s", s, weight, name)
+		}
+		switch name {
+		case "head":
+			m.head = w
+		case "tail":
+			m.tail = w
+		case "random":
+			m.random = w
+		default:
+			return insertMix{}, fmt.Errorf("insert mix %q: unknown position %q (want head, tail or random)", s, name)
+		}
+	}
+	sum := m.head + m.tail + m.random
+	if sum == 0 {
+		return insertMix{}, fmt.Errorf("insert mix %q: weights sum to zero", s)
+	}
+	return insertMix{head: m.head / sum, tail: m.tail / sum, random: m.random / sum}, nil
+}
+
+func (m insertMix) String() string {
+	return fmt.Sprintf("head %.0f%% / tail %.0f%% / random %.0f%%", m.head*100, m.tail*100, m.random*100)
+}
+
+// positionPicker 는 삽입마다 넣을 위치를 고른다. 같은 seed 면 저장소마다 같은 위치 순서가 나온다.
+type positionPicker struct {
+	mix insertMix
+	rng *rand.Rand
+}
+
+func newPositionPicker(mix insertMix, seed int64) *positionPicker {
+	return &positionPicker{mix: mix, rng: rand.New(rand.NewSource(seed))}
+}
+
+// next 는 노드가 size 개인 리스트에 넣을 위치. 0 이면 head, size 면 tail 이다.
+func (p *positionPicker) next(size int) int {
+	if p.mix == tailOnly {
+		return size
+	}
+	r := p.rng.Float64()
+	switch {
+	case r < p.mix.head:
+		return 0
+	case r < p.mix.head+p.mix.tail:
+		return size
+	default:
+		return p.rng.Intn(size + 1)
+	}
+}
@@ -0,0 +1,290 @@
+package compare
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/tmdgusya/btree/iometrics"
+)
+
+// ==================================
+// bench 결과를 HTML 보고서로 내보내기
+// ==================================
+// 외부 스크립트나 CSS 없이 SVG 를 직접 그려 넣어서, 파일 하나를 튜토리얼 페이지에 그대로 붙일 수 있게 한다.
+// - 막대 그래프: 단계(store/phase)별 reads / writes / seeks / seek distance
+// - 히트맵: 단계별로 파일의 어느 블록을 몇 번 건드렸는지 (왼쪽이 파일 앞쪽)
+
+// 히트맵 한 줄에 그리는 최대 칸 수. 블록이 더 많으면 이웃한 블록을 한 칸에 합친다.
+const heatmapMaxColumns = 128
+
+// 블록 하나 = touchBlock 바이트. [off, off+n) 이 걸치는 블록마다 1 씩 올린다.
+// Seek / Sync 는 데이터를 옮기지 않으므로 세지 않는다.
+func recordTouch(touches []int64, block int64, a iometrics.Access) []int64 {
+	if a.Op != iometrics.OpRead && a.Op != iometrics.OpWrite || a.Len == 0 {
+		return touches
+	}
+	first := a.Offset / block
+	last := (a.Offset + int64(a.Len) - 1) / block
+	for int64(len(touches)) <= last {
+		touches = append(touches, 0)
+	}
+	for b := first; b <= last; b++ {
+		touches[b]++
+	}
+	return touches
+}
+
+type reportBar struct {
+	Label  string
+	Value  int64
+	Y      int // 막대 윗변
+	TextY  int // 글자 기준선
+	Width  float64
+	ValueX float64 // 값 글자를 막대 오른쪽에 붙인다
+}
+
+type reportChart struct {
+	Title  string
+	Height int
+	Bars   []reportBar
+}
+
+type reportRow struct {
+	Store   string
+	Phase   string
+	Ops     int
+	IO      iometrics.IOMetrics
+	Elapsed string
+}
+
+type heatmapCell struct {
+	X, Y    int
+	Color   string
+	Tooltip string
+}
+
+type heatmapRow struct {
+	Label string
+	Y     int
+}
+
+type reportHeatmap struct {
+	Store         string
+	Width, Height int
+	SVGWidth      int // Width + 왼쪽 라벨 칸
+	Rows          []heatmapRow
+	Cells         []heatmapCell
+	BlocksPerCell int
+	Blocks        int
+}
+
+type reportData struct {
+	Generated  string
+	TouchBlock int64
+	Rows       []reportRow
+	Charts     []reportChart
+	Heatmaps   []reportHeatmap
+}
+
+// 막대 그래프 배치 (px): 왼쪽 라벨 칸, 막대 최대 폭, 한 줄 높이
+const reportLabelWidth = 140
+const reportBarWidth = 480
+const reportBarStep = 22
+
+func buildCharts(results []benchResult) []reportChart {
+	metrics := []struct {
+		title string
+		value func(m iometrics.IOMetrics) int64
+	}{
+		{"reads", func(m iometrics.IOMetrics) int64 { return m.Reads }},
+		{"writes", func(m iometrics.IOMetrics) int64 { return m.Writes }},
+		{"seeks", func(m iometrics.IOMetrics) int64 { return m.Seeks }},
+		{"seek distance (bytes)", func(m iometrics.IOMetrics) int64 { return m.SeekDistance }},
+	}
+
+	charts := make([]reportChart, 0, len(metrics))
+	for _, metric := range metrics {
+		var max int64
+		for _, r := range results {
+			if v := metric.value(r.io); v > max {
+				max = v
+			}
+		}
+		chart := reportChart{Title: metric.title, Height: len(results)*reportBarStep + 4}
+		for i, r := range results {
+			v := metric.value(r.io)
+			width := 0.0
+			if max > 0 {
+				width = float64(v) / float64(max) * reportBarWidth
+			}
+			chart.Bars = append(chart.Bars, reportBar{
+				Label:  r.store + "/" + r.phase,
+				Value:  v,
+				Y:      i*reportBarStep + 2,
+				TextY:  i*reportBarStep + 15,
+				Width:  width,
+				ValueX: reportLabelWidth + width + 6,
+			})
+		}
+		charts = append(charts, chart)
+	}
+	return charts
+}
+
+// 셀 크기 (px)
+const heatmapCellW = 6
+const heatmapCellH = 18
+
+// 저장소마다 히트맵 하나. 줄 = 단계, 칸 = 블록 묶음. 색은 log 스케일로 칠해서 한두 번 건드린 블록도 보이게 한다.
+func buildHeatmaps(results []benchResult, block int64) []reportHeatmap {
+	var maps []reportHeatmap
+	byStore := make(map[string][]benchResult)
+	var order []string
+	for _, r := range results {
+		if _, ok := byStore[r.store]; !ok {
+			order = append(order, r.store)
+		}
+		byStore[r.store] = append(byStore[r.store], r)
+	}
+
+	for _, store := range order {
+		rows := byStore[store]
+		blocks := 0
+		for _, r := range rows {
+			blocks = max(blocks, len(r.touches))
+		}
+		if blocks == 0 {
+			continue
+		}
+		per := (blocks + heatmapMaxColumns - 1) / heatmapMaxColumns
+		columns := (blocks + per - 1) / per
+
+		hm := reportHeatmap{
+			Store:         store,
+			Width:         columns * heatmapCellW,
+			SVGWidth:      columns*heatmapCellW + heatmapLabelWidth,
+			Height:        len(rows) * heatmapCellH,
+			BlocksPerCell: per,
+			Blocks:        blocks,
+		}
+
+		grid := make([][]int64, len(rows))
+		var peak int64
+		for y, r := range rows {
+			grid[y] = make([]int64, columns)
+			for b, count := range r.touches {
+				grid[y][b/per] += count
+			}
+			for _, count := range grid[y] {
+				peak = max(peak, count)
+			}
+		}
+
+		for y, r := range rows {
+			hm.Rows = append(hm.Rows, heatmapRow{Label: r.phase, Y: y*heatmapCellH + heatmapCellH/2 + 4})
+			for x, count := range grid[y] {
+				if count == 0 {
+					continue
+				}
+				first := int64(x*per) * block
+				last := int64(min((x+1)*per, blocks)) * block
+				hm.Cells = append(hm.Cells, heatmapCell{
+					X:       x * heatmapCellW,
+					Y:       y * heatmapCellH,
+					Color:   heatColor(count, peak),
+					Tooltip: fmt.Sprintf("%s bytes %d-%d: %d touches", r.phase, first, last, count),
+				})
+			}
+		}
+		maps = append(maps, hm)
+	}
+	return maps
+}
+
+// 흰색(적음) -> 진한 빨강(많음)
+func heatColor(count, peak int64) string {
+	t := 1.0
+	if peak > 1 {
+		t = math.Log1p(float64(count)) / math.Log1p(float64(peak))
+	}
+	light := 95 - int(t*60)
+	return fmt.Sprintf("hsl(8, 85%%, %d%%)", light)
+}
+
+// 히트맵 왼쪽 단계 이름 칸 (px)
+const heatmapLabelWidth = 60
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"upper": strings.ToUpper,
+}).Parse(`<!DOCTYPE html>
+<html lang="ko">
+<head>
+<meta charset="utf-8">
+<title>Linked list I/O comparison</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: right; }
+th:first-child, td:first-child, th:nth-child(2), td:nth-child(2) { text-align: left; }
+svg text { font-size: 12px; }
+.bar { fill: #4a7fb5; }
+</style>
+</head>
+<body>
+<h1>Linked list I/O comparison</h1>
+<p>generated {{.Generated}}</p>
+
+<table>
+<tr><th>store</th><th>phase</th><th>ops</th><th>reads</th><th>writes</th><th>seeks</th><th>syncs</th><th>bytes read</th><th>bytes written</th><th>elapsed</th></tr>
+{{range .Rows}}<tr><td>{{.Store}}</td><td>{{.Phase}}</td><td>{{.Ops}}</td><td>{{.IO.Reads}}</td><td>{{.IO.Writes}}</td><td>{{.IO.Seeks}}</td><td>{{.IO.Syncs}}</td><td>{{.IO.BytesRead}}</td><td>{{.IO.BytesWritten}}</td><td>{{.Elapsed}}</td></tr>
+{{end}}</table>
+
+{{range .Charts}}
+<h2>{{upper .Title}}</h2>
+<svg width="` + fmt.Sprint(reportLabelWidth+reportBarWidth+80) + `" height="{{.Height}}" role="img" aria-label="{{.Title}} per phase">
+{{range .Bars}}<text x="0" y="{{.TextY}}">{{.Label}}</text>
+<rect class="bar" x="` + fmt.Sprint(reportLabelWidth) + `" y="{{.Y}}" width="{{printf "%.1f" .Width}}" height="16"><title>{{.Label}}: {{.Value}}</title></rect>
+<text x="{{printf "%.1f" .ValueX}}" y="{{.TextY}}">{{.Value}}</text>
+{{end}}</svg>
+{{end}}
+
+{{if .Heatmaps}}<h2>Page touches</h2>
+<p>한 칸 = 파일의 {{.TouchBlock}} 바이트 블록 묶음, 왼쪽이 파일 앞쪽. 색이 진할수록 많이 읽고 쓴 곳 (log 스케일).</p>
+{{range .Heatmaps}}
+<h3>{{.Store}} ({{.Blocks}} blocks, {{.BlocksPerCell}} per cell)</h3>
+<svg width="{{.SVGWidth}}" height="{{.Height}}" role="img" aria-label="{{.Store}} page touch heatmap">
+{{range .Rows}}<text x="0" y="{{.Y}}">{{.Label}}</text>
+{{end}}<g transform="translate(` + fmt.Sprint(heatmapLabelWidth) + `,0)">
+<rect x="0" y="0" width="{{.Width}}" height="{{.Height}}" fill="#fafafa" stroke="#ddd"></rect>
+{{range .Cells}}<rect x="{{.X}}" y="{{.Y}}" width="` + fmt.Sprint(heatmapCellW) + `" height="` + fmt.Sprint(heatmapCellH-2) + `" fill="{{.Color}}"><title>{{.Tooltip}}</title></rect>
+{{end}}</g>
+</svg>
+{{end}}{{end}}
+</body>
+</html>
+`))
+
+// writeBenchHTML 은 결과 표, 막대 그래프, (touches 를 모았다면) 페이지 히트맵을 담은 HTML 한 장을 쓴다.
+func writeBenchHTML(w io.Writer, results []benchResult, touchBlock int64) error {
+	rows := make([]reportRow, 0, len(results))
+	for _, r := range results {
+		rows = append(rows, reportRow{
+			Store:   r.store,
+			Phase:   r.phase,
+			Ops:     r.ops,
+			IO:      r.io,
+			Elapsed: r.elapsed.Round(time.Microsecond).String(),
+		})
+	}
+	return reportTemplate.Execute(w, reportData{
+		Generated:  time.Now().Format(time.RFC3339),
+		TouchBlock: touchBlock,
+		Rows:       rows,
+		Charts:     buildCharts(results),
+		Heatmaps:   buildHeatmaps(results, touchBlock),
+	})
+}
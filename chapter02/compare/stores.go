@@ -0,0 +1,274 @@
+package compare
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/tmdgusya/btree/iometrics"
+)
+
+// LinkedListStore 는 paged_linked_list 의 LinkedListStore 중 비교 도구가 쓰는 부분을 같은 모양으로 옮긴 것
+// 챕터마다 독립된 main 패키지라 import 할 수 없어서 여기에도 둔다.
+// 하네스는 이 인터페이스만 보고 돌기 때문에 새 저장소는 구현체 하나만 추가하면 된다.
+// - InsertAt: 체인의 pos 번째 노드 앞에 넣는다. 0 이면 head, 지금 크기 이상이면 AppendTail 과 같다.
+// - Flush: group commit 으로 미뤄둔 헤더 기록을 지금 한다. Close 도 내부에서 호출한다.
+type LinkedListStore interface {
+	Open(path string, truncate bool) (*Handle, error)
+	AppendTail(h *Handle, value uint32) error
+	InsertAt(h *Handle, pos int, value uint32) error
+	TraverseValues(h *Handle) ([]uint32, error)
+	Where(h *Handle, target uint32) (*Location, error)
+	Flush(h *Handle) error
+	Close(h *Handle) error
+}
+
+type HeaderRecord interface {
+	headerVersion() uint16
+}
+
+func (h *Header) headerVersion() uint16 {
+	return h.Version
+}
+
+func (h *OffsetHeader) headerVersion() uint16 {
+	return h.Version
+}
+
+// Handle 은 열린 파일 하나와 그 헤더. File 이 iometrics.CountingFile 이라서 하네스가 I/O 횟수를 바로 읽을 수 있다.
+type Handle struct {
+	File   *iometrics.CountingFile
+	Header HeaderRecord
+
+	commit     *groupCommit
+	pagedTail  *pagedTailCache  // PagedStore 전용, TailCache 가 꺼져 있으면 nil
+	offsetTail *offsetTailCache // OffsetStore 전용, TailCache 가 꺼져 있으면 nil
+}
+
+// Location 은 값이 놓인 자리. paged 리스트는 (Page, Slot), offset 리스트는 Offset 을 쓴다.
+type Location struct {
+	Page   uint32
+	Slot   uint16
+	Offset int64
+}
+
+// 두 저장소가 공통으로 받는 설정
+// - PageSize: 새 파일 헤더에 기록할 페이지 크기 (0 이면 PAGE_SIZE)
+// - Direct: O_DIRECT 로 열어서 페이지 캐시를 우회
+// - TailCache: 마지막으로 쓴 tail 노드를 기억해서 append 때 다시 읽지 않음
+// - GroupEvery / GroupInterval / Sync: groupCommit 설정
+type StoreOptions struct {
+	PageSize      uint16
+	Direct        bool
+	TailCache     bool
+	GroupEvery    int
+	GroupInterval time.Duration
+	Sync          bool
+}
+
+func (o StoreOptions) pageSize() uint16 {
+	if o.PageSize == 0 {
+		return PAGE_SIZE
+	}
+	return o.PageSize
+}
+
+// 파일을 열고 iometrics.CountingFile 로 감싼다. 새 파일(또는 truncate) 이면 fresh 가 true.
+func (o StoreOptions) open(path string, truncate bool) (cf *iometrics.CountingFile, fresh bool, err error) {
+	flags := os.O_RDWR | os.O_CREATE
+	if truncate {
+		flags |= os.O_TRUNC
+	}
+
+	var raw iometrics.File
+	if o.Direct {
+		raw, err = openAligned(path, flags, 0644)
+	} else {
+		raw, err = os.OpenFile(path, flags, 0644)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	cf = iometrics.NewCountingFile(raw)
+	size, err := cf.Seek(0, io.SeekEnd)
+	if err != nil {
+		cf.Close()
+		return nil, false, err
+	}
+	return cf, size == 0, nil
+}
+
+func (o StoreOptions) newHandle(cf *iometrics.CountingFile, header HeaderRecord) *Handle {
+	return &Handle{
+		File:   cf,
+		Header: header,
+		commit: &groupCommit{every: o.GroupEvery, interval: o.GroupInterval, sync: o.Sync},
+	}
+}
+
+// ==================================
+// PagedStore: paged_linked_list 포맷
+// ==================================
+
+type PagedStore struct {
+	StoreOptions
+}
+
+func (s *PagedStore) Open(path string, truncate bool) (*Handle, error) {
+	cf, fresh, err := s.open(path, truncate)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &Header{}
+	if fresh {
+		*h = Header{
+			Magic:    Magic,
+			Version:  2,
+			PageSize: s.pageSize(),
+			HeadPage: NullPage,
+			HeadSlot: NullSlot,
+			TailPage: NullPage,
+			TailSlot: NullSlot,
+		}
+		err = writeHeader(cf, h)
+	} else {
+		err = readHeader(cf, h)
+	}
+	if err != nil {
+		cf.Close()
+		return nil, err
+	}
+
+	handle := s.newHandle(cf, h)
+	if s.TailCache {
+		handle.pagedTail = &pagedTailCache{}
+	}
+	return handle, nil
+}
+
+func (s *PagedStore) AppendTail(handle *Handle, value uint32) error {
+	defer handle.File.Section("append")()
+	h := handle.Header.(*Header)
+	if err := pagedAppendTail(handle.File, h, handle.pagedTail, value); err != nil {
+		return err
+	}
+	return handle.commit.done(handle.File, func() error { return writeHeader(handle.File, h) })
+}
+
+func (s *PagedStore) InsertAt(handle *Handle, pos int, value uint32) error {
+	defer handle.File.Section("insert")()
+	h := handle.Header.(*Header)
+	if err := pagedInsertAt(handle.File, h, handle.pagedTail, pos, value); err != nil {
+		return err
+	}
+	return handle.commit.done(handle.File, func() error { return writeHeader(handle.File, h) })
+}
+
+func (s *PagedStore) TraverseValues(handle *Handle) ([]uint32, error) {
+	defer handle.File.Section("traverse")()
+	return traverseBuffered(handle.File, handle.Header.(*Header))
+}
+
+func (s *PagedStore) Where(handle *Handle, target uint32) (*Location, error) {
+	defer handle.File.Section("lookup")()
+	return pagedWhere(handle.File, handle.Header.(*Header), target)
+}
+
+func (s *PagedStore) Flush(handle *Handle) error {
+	h := handle.Header.(*Header)
+	return handle.commit.flush(handle.File, func() error { return writeHeader(handle.File, h) })
+}
+
+func (s *PagedStore) Close(handle *Handle) error {
+	if err := s.Flush(handle); err != nil {
+		handle.File.Close()
+		return err
+	}
+	return handle.File.Close()
+}
+
+// ==================================
+// OffsetStore: linkedlist 포맷 (version 1)
+// ==================================
+
+type OffsetStore struct {
+	StoreOptions
+}
+
+func (s *OffsetStore) Open(path string, truncate bool) (*Handle, error) {
+	cf, fresh, err := s.open(path, truncate)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &OffsetHeader{}
+	if fresh {
+		*h = OffsetHeader{
+			Magic:      Magic,
+			Version:    1,
+			PageSize:   s.pageSize(),
+			HeadOffset: NullOffset,
+			TailOffset: NullOffset,
+		}
+		err = offsetWriteHeader(cf, h)
+	} else {
+		err = offsetReadHeader(cf, h)
+	}
+	if err != nil {
+		cf.Close()
+		return nil, err
+	}
+
+	handle := s.newHandle(cf, h)
+	if s.TailCache {
+		handle.offsetTail = &offsetTailCache{}
+	}
+	return handle, nil
+}
+
+func (s *OffsetStore) AppendTail(handle *Handle, value uint32) error {
+	defer handle.File.Section("append")()
+	h := handle.Header.(*OffsetHeader)
+	if err := offsetAppendTail(handle.File, h, handle.offsetTail, value); err != nil {
+		return err
+	}
+	return handle.commit.done(handle.File, func() error { return offsetWriteHeader(handle.File, h) })
+}
+
+func (s *OffsetStore) InsertAt(handle *Handle, pos int, value uint32) error {
+	defer handle.File.Section("insert")()
+	h := handle.Header.(*OffsetHeader)
+	if err := offsetInsertAt(handle.File, h, handle.offsetTail, pos, value); err != nil {
+		return err
+	}
+	return handle.commit.done(handle.File, func() error { return offsetWriteHeader(handle.File, h) })
+}
+
+func (s *OffsetStore) TraverseValues(handle *Handle) ([]uint32, error) {
+	defer handle.File.Section("traverse")()
+	return offsetTraverse(handle.File, handle.Header.(*OffsetHeader))
+}
+
+func (s *OffsetStore) Where(handle *Handle, target uint32) (*Location, error) {
+	defer handle.File.Section("lookup")()
+	off, err := offsetWhere(handle.File, handle.Header.(*OffsetHeader), target)
+	if err != nil || off == NullOffset {
+		return nil, err
+	}
+	return &Location{Page: NullPage, Slot: NullSlot, Offset: off}, nil
+}
+
+func (s *OffsetStore) Flush(handle *Handle) error {
+	h := handle.Header.(*OffsetHeader)
+	return handle.commit.flush(handle.File, func() error { return offsetWriteHeader(handle.File, h) })
+}
+
+func (s *OffsetStore) Close(handle *Handle) error {
+	if err := s.Flush(handle); err != nil {
+		handle.File.Close()
+		return err
+	}
+	return handle.File.Close()
+}
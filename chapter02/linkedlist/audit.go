@@ -0,0 +1,152 @@
+package linkedlist
+
+import (
+	"fmt"
+
+	"github.com/tmdgusya/btree/dberr"
+)
+
+// ==================================
+// 크기 감사 (audit)
+// ==================================
+// OffsetStore.Audit 를 켜면 변경 연산(AppendTail, PrependHead, DeleteFirstByValue)이 끝날 때마다
+// 헤더의 Size 를 핸들이 따로 센 값과 맞춰 보고, 어긋나면 ErrSizeDrift 로 그 연산을 바로 실패시킨다.
+// 프리 리스트 재사용처럼 헤더를 고치는 연산을 새로 붙일 때 실수를 그 자리에서 잡으려는 것이라 기본은 꺼져 있다.
+// - 처음 바꿀 때 체인과 프리 리스트를 한 번 따라가서 live / free 를 센다. 그 뒤로는 연산의 결과만 보고 더하고 뺀다.
+// - 삽입: live+1. 연산 전에 프리 리스트가 비어 있지 않았으면 거기서 꺼냈어야 하므로 free-1, 아니면 파일이 노드 하나만큼 는다.
+// - 삭제: live-1. 프리 리스트가 있는 파일이면 free+1, version 1 파일이면 어디에도 걸리지 않은 tombstone(orphan)+1.
+// - 파일의 노드 수 (데이터 영역 / 노드 크기) 는 늘 live + free + orphan 이어야 한다.
+
+var ErrSizeDrift = dberr.New(dberr.ErrCorruptPage, "size drift")
+
+// auditState 는 핸들마다 따로 센 값. ready 가 false 면 다음 변경 때 파일을 따라가서 다시 센다 (Load 뒤 등).
+type auditState struct {
+	ready  bool
+	live   int64
+	free   int64
+	orphan int64
+}
+
+// audit 은 변경 연산 하나의 감사. beginAudit 가 연산 전 상태를 잡고, 연산이 끝나면 end 가 맞춰 본다.
+type audit struct {
+	handle  *Handle
+	hadFree bool
+}
+
+// beginAudit 은 변경 연산 전에 부른다. 처음이면 live / free 를 세고, 그 값이 이미 헤더와 다르면 연산을 하지 않고 실패한다.
+func (s *OffsetStore) beginAudit(handle *Handle) (*audit, error) {
+	h, err := ensureOffsetHeader(handle)
+	if err != nil {
+		return nil, err
+	}
+	a := &audit{handle: handle, hadFree: h.FreeHead != NullOffset}
+	if handle.audit.ready {
+		return a, nil
+	}
+	nodes, err := nodeCount(handle.File, h)
+	if err != nil {
+		return nil, err
+	}
+	if handle.audit, err = countAudit(handle.File, h, nodes); err != nil {
+		return nil, err
+	}
+	if err := a.check("open"); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// end 는 변경 연산이 err 로 끝난 뒤에 부른다. added 는 넣었으면 1, 지웠으면 -1, 바뀐 게 없으면 0.
+// 실패한 연산은 어디까지 썼는지 모르므로 다음에 다시 센다.
+func (a *audit) end(op string, added int, err error) error {
+	if err != nil {
+		a.handle.audit.ready = false
+		return err
+	}
+	h, err := ensureOffsetHeader(a.handle)
+	if err != nil {
+		return err
+	}
+	st := &a.handle.audit
+	switch {
+	case added > 0:
+		st.live++
+		if a.hadFree {
+			st.free--
+		}
+	case added < 0:
+		st.live--
+		if h.hasFreeList() {
+			st.free++
+		} else {
+			st.orphan++
+		}
+	}
+	return a.check(op)
+}
+
+func (a *audit) check(op string) error {
+	h, err := ensureOffsetHeader(a.handle)
+	if err != nil {
+		return err
+	}
+	nodes, err := nodeCount(a.handle.File, h)
+	if err != nil {
+		return err
+	}
+	if err := a.handle.audit.verify(h, nodes, op); err != nil {
+		a.handle.audit.ready = false
+		return err
+	}
+	return nil
+}
+
+func (st auditState) verify(h *Header, nodes int64, op string) error {
+	if h.Size != st.live {
+		return fmt.Errorf("%w after %s: header size %d, counted %d", ErrSizeDrift, op, h.Size, st.live)
+	}
+	if want := st.live + st.free + st.orphan; nodes != want {
+		return fmt.Errorf("%w after %s: file holds %d nodes, counted %d live + %d free + %d orphan",
+			ErrSizeDrift, op, nodes, st.live, st.free, st.orphan)
+	}
+	return nil
+}
+
+// nodeCount 는 파일 크기로 센 노드 수
+func nodeCount(f File, h *Header) (int64, error) {
+	size, err := fileSize(f)
+	if err != nil {
+		return 0, err
+	}
+	return max(size-h.dataStart(), 0) / nodeOnDiskSize, nil
+}
+
+// countAudit 은 체인과 프리 리스트를 따라가서 live / free 를 센다. 나머지 노드는 orphan 으로 둔다.
+// 손상된 파일에서 끝나지 않도록 어느 쪽이든 파일의 노드 수보다 많이 따라가면 멈춘다.
+func countAudit(f File, h *Header, nodes int64) (auditState, error) {
+	st := auditState{ready: true}
+	walked := int64(0)
+	for off := h.HeadOffset; off != NullOffset && walked <= nodes; walked++ {
+		node, err := readNodeAt(f, off)
+		if err != nil {
+			return auditState{}, err
+		}
+		if node.Tomb == 0 {
+			st.live++
+		}
+		off = node.Next
+	}
+	if h.hasFreeList() {
+		walked = 0
+		for off := h.FreeHead; off != NullOffset && walked <= nodes; walked++ {
+			node, err := readNodeAt(f, off)
+			if err != nil {
+				return auditState{}, err
+			}
+			st.free++
+			off = node.Next
+		}
+	}
+	st.orphan = max(nodes-st.live-st.free, 0)
+	return st, nil
+}
@@ -0,0 +1,86 @@
+package linkedlist
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/tmdgusya/btree/iometrics"
+)
+
+// Demo 는 linked_list.db 를 새로 만들어 값을 넣고 / 찾고 / 지우면서 상태와 I/O 를 출력한다.
+// 튜토리얼 데모라서 실패하면 panic 한다.
+func Demo() {
+	var store LinkedListStore = &OffsetStore{}
+
+	N := 10000
+	// 교육용: 항상 새로 시작(O_TRUNC)
+	// CountingFile 로 감싸서 넘기면 저장소 코드는 그대로 두고 I/O 를 셀 수 있다.
+	raw, err := os.OpenFile("linked_list.db", os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		panic(err)
+	}
+	cf := iometrics.NewCountingFile(raw)
+	handle, err := store.OpenFile(cf)
+	if err != nil {
+		panic(err)
+	}
+	defer store.Close(handle)
+
+	for i := 0; i < N; i++ {
+		if err := store.AppendTail(handle, uint32(i)); err != nil {
+			panic(err)
+		}
+	}
+
+	offset, _ := store.Where(handle, 9999)
+	if offset != NullOffset {
+		fmt.Println("Found offset:", offset)
+	} else {
+		fmt.Println("Not found")
+	}
+
+	// 삭제 / 삽입을 반복해도 프리 리스트 덕분에 파일 크기가 늘지 않는다.
+	size, err := fileSize(handle.File)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("file size before churn:", size)
+
+	for i := 0; i < 1000; i++ {
+		if _, err := store.DeleteFirstByValue(handle, uint32(i)); err != nil {
+			panic(err)
+		}
+		if i%2 == 0 {
+			err = store.PrependHead(handle, uint32(N+i))
+		} else {
+			err = store.AppendTail(handle, uint32(N+i))
+		}
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	size, err = fileSize(handle.File)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("file size after churn :", size)
+
+	head, _, err := store.PeekHead(handle)
+	if err != nil {
+		panic(err)
+	}
+	tail, _, err := store.PeekTail(handle)
+	if err != nil {
+		panic(err)
+	}
+	length, err := store.Length(handle)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("head=%d tail=%d length=%d\n", head, tail, length)
+
+	m := cf.Metrics()
+	fmt.Printf("I/O: reads=%d writes=%d seeks=%d bytesRead=%d bytesWritten=%d\n",
+		m.Reads, m.Writes, m.Seeks, m.BytesRead, m.BytesWritten)
+}
@@ -0,0 +1,195 @@
+package linkedlist
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// ==================================
+// 디스크 포맷 디코더 퍼징
+// ==================================
+// 손상된 파일을 읽어도 패닉하거나 엉뚱한 값을 조용히 돌려주지 않고 에러가 나야 한다.
+// 시드는 testdata/golden 의 offset 골든 파일이다.
+//
+//	go test -fuzz FuzzReadNodeAt ./chapter02/linkedlist
+
+// memFile 은 바이트 슬라이스 위의 File. 퍼징 입력을 디스크를 거치지 않고 파일처럼 연다.
+type memFile struct {
+	data []byte
+	off  int64
+}
+
+func newMemFile(data []byte) *memFile { return &memFile{data: slices.Clone(data)} }
+
+func (m *memFile) Read(p []byte) (int, error) {
+	n, err := m.ReadAt(p, m.off)
+	m.off += int64(n)
+	return n, err
+}
+
+func (m *memFile) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("memfile: negative offset")
+	}
+	if off >= int64(len(m.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, m.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (m *memFile) Write(p []byte) (int, error) {
+	n, err := m.WriteAt(p, m.off)
+	m.off += int64(n)
+	return n, err
+}
+
+func (m *memFile) WriteAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("memfile: negative offset")
+	}
+	if end := off + int64(len(p)); end > int64(len(m.data)) {
+		m.data = append(m.data, make([]byte, end-int64(len(m.data)))...)
+	}
+	return copy(m.data[off:], p), nil
+}
+
+func (m *memFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += m.off
+	case io.SeekEnd:
+		offset += int64(len(m.data))
+	}
+	if offset < 0 {
+		return 0, errors.New("memfile: negative offset")
+	}
+	m.off = offset
+	return offset, nil
+}
+
+func (m *memFile) Truncate(size int64) error {
+	if size < int64(len(m.data)) {
+		m.data = m.data[:size]
+	} else {
+		m.data = append(m.data, make([]byte, size-int64(len(m.data)))...)
+	}
+	return nil
+}
+
+func (m *memFile) Close() error { return nil }
+func (m *memFile) Sync() error  { return nil }
+
+// goldenFiles 는 testdata/golden 의 offset 골든 파일 내용
+func goldenFiles(f *testing.F) [][]byte {
+	f.Helper()
+	paths, err := filepath.Glob(filepath.Join("..", "..", "testdata", "golden", "offset-v*.db"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	if len(paths) == 0 {
+		f.Fatal("no offset golden files in testdata/golden")
+	}
+	files := make([][]byte, 0, len(paths))
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			f.Fatal(err)
+		}
+		files = append(files, data)
+	}
+	return files
+}
+
+func FuzzReadHeader(f *testing.F) {
+	for _, data := range goldenFiles(f) {
+		f.Add(data)
+		f.Add(data[:legacyHeaderOnDiskSize])
+		f.Add(data[:headerOnDiskSize-1])
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var h Header
+		if err := readHeader(newMemFile(data), &h); err != nil {
+			return
+		}
+		if h.Magic != Magic {
+			t.Fatalf("accepted magic %q", h.Magic[:])
+		}
+		if int64(len(data)) < h.dataStart() {
+			t.Fatalf("accepted a %d byte file as a version %d header of %d bytes", len(data), h.Version, h.dataStart())
+		}
+		if !h.hasFreeList() && h.FreeHead != NullOffset {
+			t.Fatalf("version %d header has free head %d", h.Version, h.FreeHead)
+		}
+
+		// 읽은 헤더를 다시 써서 읽으면 같은 값이 나와야 한다.
+		out := newMemFile(nil)
+		if err := writeHeader(out, &h); err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(out.data, data[:h.dataStart()]) {
+			t.Fatalf("re-encoded header differs from the input: %x, want %x", out.data, data[:h.dataStart()])
+		}
+		var got Header
+		if err := readHeader(out, &got); err != nil {
+			t.Fatalf("re-reading %+v: %v", h, err)
+		}
+		if got != h {
+			t.Fatalf("round trip = %+v, want %+v", got, h)
+		}
+	})
+}
+
+// FuzzReadNodeAt 은 임의의 오프셋에서 노드를 읽는다. 파일 밖이면 에러여야 하고,
+// 읽혔으면 그 자리의 바이트를 그대로 디코딩한 값이어야 한다 (패딩은 무시한다).
+func FuzzReadNodeAt(f *testing.F) {
+	for _, data := range goldenFiles(f) {
+		var h Header
+		if err := readHeader(newMemFile(data), &h); err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data, h.HeadOffset)
+		f.Add(data, h.TailOffset)
+		f.Add(data, h.FreeHead)
+		f.Add(data, h.dataStart()+1)
+		f.Add(data, int64(len(data))-nodeOnDiskSize+1)
+		f.Add(data, int64(len(data)))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte, off int64) {
+		node, err := readNodeAt(newMemFile(data), off)
+		inside := off >= 0 && off <= int64(len(data))-nodeOnDiskSize
+		if !inside {
+			if err == nil {
+				t.Fatalf("offset %d of a %d byte file: read %+v, want an error", off, len(data), node)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("offset %d of a %d byte file: %v", off, len(data), err)
+		}
+
+		raw := data[off : off+nodeOnDiskSize]
+		want := Node{Value: Endian.Uint32(raw), Next: int64(Endian.Uint64(raw[4:])), Tomb: raw[12]}
+		if *node != want {
+			t.Fatalf("offset %d: read %+v, want %+v", off, *node, want)
+		}
+
+		// 다시 쓰면 패딩만 0 으로 바뀐다.
+		out := newMemFile(nil)
+		if err := writeNodeAt(out, 0, node); err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(out.data[:13], raw[:13]) || !slices.Equal(out.data[13:], make([]byte, nodePadBytes)) {
+			t.Fatalf("offset %d: re-encoded %x from %x", off, out.data, raw)
+		}
+	})
+}
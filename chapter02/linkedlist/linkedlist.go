@@ -0,0 +1,1309 @@
+// Package linkedlist 는 chapter02 의 offset 기반 디스크 연결 리스트. 노드마다 다음 노드의 파일 오프셋을 들고 있다.
+package linkedlist
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/tmdgusya/btree/codec"
+	"github.com/tmdgusya/btree/dberr"
+)
+
+// 다른 파일을 잘못 열었을 때 조기 실패를 위한 용도
+var Magic = [4]byte{'L', 'L', 'S', 'T'}
+var Endian = codec.Endian
+var ErrInvalidMagic = dberr.New(dberr.ErrInvalidMagic, "Invalid file: magic mismatch")
+var ErrUnsupportedVersion = dberr.New(dberr.ErrInvalidMagic, "unsupported header version")
+
+const DefaultPageSize uint16 = 4096
+const NullOffset int64 = -1
+
+// 헤더 버전
+// - 1: 프리 리스트 없는 최초 포맷 (32 바이트 헤더). OpenFile 이 열 때 version 3 으로 바꾼다 (upgradeLegacy).
+// - 2: paged_linked_list 가 같은 Magic 으로 쓰는 번호라서 건너뛴다
+// - 3: FreeHead 를 추가한 포맷 (40 바이트 헤더). 새 파일은 항상 이 버전으로 만든다.
+// - 4: paged_linked_list 의 shadow 헤더 포맷이라 역시 건너뛴다
+const legacyVersion uint16 = 1
+const currentVersion uint16 = 3
+
+// Node 의 on-disk 고정 길이(예: 16바이트) 로 맞추기 위한 패딩 크기
+const nodePadBytes = 3
+
+// LinkedListStore 인터페이스와 공통 핸들 정의
+type LinkedListStore interface {
+	Open(path string, truncate bool) (*Handle, error)
+	OpenFile(f File) (*Handle, error)
+	AppendTail(h *Handle, value uint32) error
+	PrependHead(h *Handle, value uint32) error
+	DeleteFirstByValue(h *Handle, value uint32) (bool, error)
+	TraverseValues(h *Handle) ([]uint32, error)
+	Where(h *Handle, target uint32) (int64, error)
+	PeekHead(h *Handle) (uint32, bool, error)
+	PeekTail(h *Handle) (uint32, bool, error)
+	Length(h *Handle) (int64, error)
+	Backup(h *Handle, dst io.Writer) error
+	Dump(h *Handle, w io.Writer) error
+	Load(h *Handle, r io.Reader) error
+	Check(h *Handle) (*CheckReport, error)
+	Inspect(h *Handle, w io.Writer) error
+	InspectHeader(h *Handle, w io.Writer) error
+	InspectPage(h *Handle, pageID uint32, w io.Writer) error
+	Stats(h *Handle) (*Stats, error)
+	Close(h *Handle) error
+}
+
+type HeaderRecord interface {
+	headerVersion() uint16
+}
+
+// File 은 저장소가 쓰는 파일 연산. File 과 iometrics.CountingFile 이 그대로 만족하므로
+// OpenFile 에 감싼 파일을 넘기면 코드를 고치지 않고 I/O 를 세거나 지연 / 실패를 끼워 넣을 수 있다.
+type File interface {
+	io.ReadWriteSeeker
+	io.ReaderAt
+	io.WriterAt
+	io.Closer
+	Sync() error
+	Truncate(size int64) error
+}
+
+// File 에는 Stat 이 없으므로 끝으로 Seek 해서 크기를 구한다. 스트림 위치가 파일 끝으로 옮겨진다.
+func fileSize(f File) (int64, error) {
+	return f.Seek(0, io.SeekEnd)
+}
+
+// Handle 은 열린 리스트 파일 하나. 연산은 Seek 로 파일 오프셋을 옮기므로 한 고루틴에서 차례로 부르는 것을 전제로 한다.
+// 예외는 Backup 으로, 다른 고루틴에서 불러도 된다. 파일을 바꾸는 연산과 Backup 은 mu 를 잡으므로
+// 복사하는 동안 변경이 끼어들지 못하고, 사본에는 연산 사이의 상태가 담긴다.
+type Handle struct {
+	File   File
+	Header HeaderRecord
+	mu     sync.Mutex // 쓰기 잠금. 파일을 바꾸는 연산과 Backup 이 잡는다.
+	tail   tailCache
+	audit  auditState
+}
+
+// tailCache 는 마지막으로 기록한 tail 노드의 복사본
+// AppendTail 이 기존 tail 의 Next 를 고칠 때 디스크에서 다시 읽지 않고 이 값을 쓴다.
+// tail 노드를 바꿀 수 있는 연산(DeleteFirstByValue, Load)은 valid 를 false 로 돌려놓는다.
+type tailCache struct {
+	valid bool
+	off   int64
+	node  Node
+}
+
+// 캐시가 현재 Header 의 tail 을 가리킬 때만 그 노드를 돌려준다.
+func (h *Handle) cachedTail(off int64) (*Node, bool) {
+	if !h.tail.valid || h.tail.off != off {
+		return nil, false
+	}
+	node := h.tail.node
+	return &node, true
+}
+
+// Audit 를 켜면 변경 연산마다 헤더의 Size 와 프리 리스트를 따로 센 값과 맞춰 본다 (audit.go).
+type OffsetStore struct {
+	Audit bool
+}
+
+// 구조체
+// 파일 헤
+// Magic: 포맷 식별자
+// Version: 버전
+// PageSize: 추후에 페이지네이션으로 업그레이드 할때 이용
+// HeadOffset: 첫 노드의 파일 오프셋(없으면 -1)
+// TailOffset: 마지막 노드의 파일 오프셋(없으면 -1)
+// Size: 통계 / 검증 용도
+// FreeHead: 삭제된 노드로 이어진 프리 리스트의 첫 오프셋(없으면 -1). 삽입할 때 파일을 늘리기 전에 먼저 꺼내 쓴다.
+type Header struct {
+	Magic      [4]byte
+	Version    uint16
+	PageSize   uint16
+	HeadOffset int64
+	TailOffset int64
+	Size       int64
+	FreeHead   int64
+}
+
+func (h *Header) headerVersion() uint16 {
+	return h.Version
+}
+
+// LinkedList 노드
+type Node struct {
+	Value uint32             // - Value: 실제 값(32비트 정수; 예제 단순화를 위해 uint32 이용)
+	Next  int64              // - Next: 다음 노드의 파일 오프셋 (없으면 -1)
+	Tomb  uint8              // - Tomb: 논리 삭제 마크 (0 == 유효, 1 == 삭제됨). 물리 삭제는 하지 않음
+	_pad  [nodePadBytes]byte // - _pad: 16 바이트 정렬을 위해 3바이트 패딩 (읽기 쉬운 고정 길이 유지)
+}
+
+func ensureOffsetHeader(h *Handle) (*Header, error) {
+	header, ok := h.Header.(*Header)
+	if !ok {
+		return nil, fmt.Errorf("linked list handle does not contain an offset header")
+	}
+	return header, nil
+}
+
+// 헤더의 고정 크기: Magic(4) + Version(2) + PageSize(2) + HeadOffset(8) + TailOffset(8) + Size(8) + FreeHead(8)
+// version 1 파일에는 FreeHead 가 없다.
+const headerOnDiskSize = 4 + 2 + 2 + 8 + 8 + 8 + 8
+const legacyHeaderOnDiskSize = headerOnDiskSize - 8
+
+// 첫 노드가 시작하는 오프셋
+func (h *Header) dataStart() int64 {
+	if h.Version == legacyVersion {
+		return legacyHeaderOnDiskSize
+	}
+	return headerOnDiskSize
+}
+
+func (h *Header) hasFreeList() bool {
+	return h.Version != legacyVersion
+}
+
+func writeHeader(f File, hdr *Header) error {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	buf := make([]byte, hdr.dataStart())
+	w := codec.NewWriter(buf)
+	w.Bytes(hdr.Magic[:])
+	w.Uint16(hdr.Version)
+	w.Uint16(hdr.PageSize)
+	w.Int64(hdr.HeadOffset)
+	w.Int64(hdr.TailOffset)
+	w.Int64(hdr.Size)
+	if hdr.hasFreeList() {
+		w.Int64(hdr.FreeHead)
+	}
+
+	_, err := f.Write(buf)
+	return err
+}
+
+// 파일 열기/초기화
+func (s *OffsetStore) Open(path string, truncate bool) (*Handle, error) {
+	flags := os.O_RDWR | os.O_CREATE
+	if truncate {
+		flags |= os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, flags, 0666)
+	if err != nil {
+		return nil, err
+	}
+	return s.OpenFile(f)
+}
+
+// OpenFile 은 이미 열린 파일 위에 핸들을 만든다. 비어 있으면 새 헤더를 쓴다.
+// 실패하면 f 를 닫는다. 성공하면 f 는 Close 에서 닫힌다.
+func (s *OffsetStore) OpenFile(f File) (*Handle, error) {
+	size, err := fileSize(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if size == 0 {
+		hdr := &Header{
+			Magic:      Magic,
+			Version:    currentVersion,
+			PageSize:   DefaultPageSize,
+			HeadOffset: NullOffset,
+			TailOffset: NullOffset,
+			Size:       0,
+			FreeHead:   NullOffset,
+		}
+		if err := writeHeader(f, hdr); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+
+	hrd := &Header{}
+
+	if err := readHeader(f, hrd); err != nil {
+		f.Close()
+		return nil, err
+	}
+	switch hrd.Version {
+	case currentVersion:
+	case legacyVersion:
+		if err := upgradeLegacy(f, hrd); err != nil {
+			f.Close()
+			return nil, err
+		}
+	default:
+		f.Close()
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, hrd.Version)
+	}
+
+	return &Handle{
+		File:   f,
+		Header: hrd,
+	}, nil
+}
+
+func readHeader(f File, h *Header) error {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	// version 1 파일은 FreeHead 가 없으므로 버전을 보기 전에는 legacy 크기만 읽는다.
+	buf := make([]byte, headerOnDiskSize)
+
+	if _, err := io.ReadFull(f, buf[:legacyHeaderOnDiskSize]); err != nil {
+		return err
+	}
+
+	r := codec.NewReader(buf)
+	r.Bytes(h.Magic[:])
+
+	// Magic 검증
+	if h.Magic != Magic {
+		return ErrInvalidMagic
+	}
+
+	h.Version = r.Uint16()
+	h.PageSize = r.Uint16()
+	h.HeadOffset = r.Int64()
+	h.TailOffset = r.Int64()
+	h.Size = r.Int64()
+
+	h.FreeHead = NullOffset
+	if h.hasFreeList() {
+		if _, err := io.ReadFull(f, buf[legacyHeaderOnDiskSize:]); err != nil {
+			return err
+		}
+		h.FreeHead = r.Int64()
+	}
+
+	return nil
+}
+
+// upgradeLegacy 는 version 1 파일을 현재 포맷으로 바꾼다. OpenFile 이 열 때 한 번 부른다.
+// 헤더가 FreeHead 만큼 길어지므로 노드를 모두 그만큼 뒤로 옮기고 Next / Head / Tail 오프셋도 같이 민다.
+// version 1 의 삭제는 tombstone 만 남기고 자리를 돌려받지 못했으므로, 옮기면서 tombstone 을 프리 리스트로 잇는다.
+// 뒤쪽 노드부터 옮겨야 아직 읽지 않은 노드를 덮어쓰지 않는다. 제자리 변환이라 노드를 옮기는 도중에 죽으면 파일이 깨진다.
+// 노드를 다 옮기고 Sync 한 뒤에야 새 헤더를 쓰므로, 헤더가 version 3 이면 변환은 끝난 것이다.
+func upgradeLegacy(f File, h *Header) error {
+	size, err := fileSize(f)
+	if err != nil {
+		return err
+	}
+	const shift = headerOnDiskSize - legacyHeaderOnDiskSize
+	moved := func(off int64) int64 {
+		if off == NullOffset {
+			return NullOffset
+		}
+		return off + shift
+	}
+
+	nodes := (size - legacyHeaderOnDiskSize) / nodeOnDiskSize
+	freeHead := NullOffset
+	for i := nodes - 1; i >= 0; i-- {
+		off := legacyHeaderOnDiskSize + i*nodeOnDiskSize
+		node, err := readNodeAt(f, off)
+		if err != nil {
+			return err
+		}
+		if node.Tomb == 1 {
+			node.Next = freeHead
+			freeHead = moved(off)
+		} else {
+			node.Next = moved(node.Next)
+		}
+		if err := writeNodeAt(f, moved(off), node); err != nil {
+			return err
+		}
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+
+	h.Version = currentVersion
+	h.HeadOffset = moved(h.HeadOffset)
+	h.TailOffset = moved(h.TailOffset)
+	h.FreeHead = freeHead
+	if err := writeHeader(f, h); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// Backup 은 열려있는 파일의 일관된 사본을 dst 로 복사한다.
+// 복사가 끝날 때까지 핸들의 쓰기 잠금을 잡으므로, 다른 고루틴의 변경 연산은 기다리고 연산 사이의 상태가 복사된다.
+// ReadAt 기반으로 읽기 때문에 핸들의 파일 오프셋은 바뀌지 않는다.
+func (s *OffsetStore) Backup(handle *Handle, dst io.Writer) error {
+	handle.mu.Lock()
+	defer handle.mu.Unlock()
+	h, err := ensureOffsetHeader(handle)
+	if err != nil {
+		return err
+	}
+	f := handle.File
+
+	if err := writeHeader(f, h); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+
+	size, err := fileSize(f)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(dst, io.NewSectionReader(f, 0, size))
+	return err
+}
+
+func (s *OffsetStore) Close(h *Handle) error {
+	return h.File.Close()
+}
+
+// 노드 읽기 / 쓰기 (고정 16 바이트)
+
+const nodeOnDiskSize = 4 + 8 + 1 + nodePadBytes
+
+func writeNodeAt(f File, off int64, n *Node) error {
+	if _, err := f.Seek(off, io.SeekStart); err != nil {
+		return err
+	}
+
+	var buf [nodeOnDiskSize]byte
+	w := codec.NewWriter(buf[:])
+	w.Uint32(n.Value)
+	w.Int64(n.Next)
+	w.Uint8(n.Tomb)
+	w.Zero(nodePadBytes) // 패딩은 0 으로 기록한다
+
+	if _, err := f.Write(buf[:]); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func readNodeAt(f File, off int64) (*Node, error) {
+	if _, err := f.Seek(off, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	var buf [nodeOnDiskSize]byte
+	if _, err := io.ReadFull(f, buf[:]); err != nil {
+		return nil, err
+	}
+
+	r := codec.NewReader(buf[:])
+	n := &Node{
+		Value: r.Uint32(),
+		Next:  r.Int64(),
+		Tomb:  r.Uint8(),
+	}
+
+	return n, nil
+}
+
+// 새 노드를 쓸 오프셋을 정한다.
+// 프리 리스트에 삭제된 노드가 있으면 맨 앞 노드를 꺼내 재사용하고, 없으면 파일 끝에 붙인다.
+// 꺼낸 결과(h.FreeHead)는 호출한 쪽이 writeHeader 로 기록한다.
+func allocateNode(f File, h *Header) (int64, error) {
+	if h.FreeHead == NullOffset {
+		return f.Seek(0, io.SeekEnd)
+	}
+
+	off := h.FreeHead
+	free, err := readNodeAt(f, off)
+	if err != nil {
+		return 0, err
+	}
+	h.FreeHead = free.Next
+	return off, nil
+}
+
+// 리스트 연산
+func (s *OffsetStore) AppendTail(handle *Handle, value uint32) error {
+	handle.mu.Lock()
+	defer handle.mu.Unlock()
+	if !s.Audit {
+		return s.appendTail(handle, value)
+	}
+	a, err := s.beginAudit(handle)
+	if err != nil {
+		return err
+	}
+	return a.end("append", 1, s.appendTail(handle, value))
+}
+
+func (s *OffsetStore) appendTail(handle *Handle, value uint32) error {
+	h, err := ensureOffsetHeader(handle)
+	if err != nil {
+		return err
+	}
+	f := handle.File
+
+	newNode := &Node{
+		Value: value,
+		Next:  NullOffset,
+		Tomb:  0,
+	}
+
+	newOff, err := allocateNode(f, h)
+	if err != nil {
+		return err
+	}
+
+	if err := writeNodeAt(f, newOff, newNode); err != nil {
+		return err
+	}
+
+	if h.HeadOffset == NullOffset {
+		h.HeadOffset = newOff
+		h.TailOffset = newOff
+		h.Size++
+		handle.tail = tailCache{valid: true, off: newOff, node: *newNode}
+		return writeHeader(f, h)
+	}
+
+	// 기존 tail 노드의 Next 를 새 노드의 Next 로 설정
+	tailNode, ok := handle.cachedTail(h.TailOffset)
+	if !ok {
+		tailNode, err = readNodeAt(f, h.TailOffset)
+		if err != nil {
+			return err
+		}
+	}
+
+	tailNode.Next = newOff
+	if err := writeNodeAt(f, h.TailOffset, tailNode); err != nil {
+		return err
+	}
+
+	h.TailOffset = newOff
+	h.Size++
+	handle.tail = tailCache{valid: true, off: newOff, node: *newNode}
+
+	return writeHeader(f, h)
+}
+
+func (s *OffsetStore) PrependHead(handle *Handle, value uint32) error {
+	handle.mu.Lock()
+	defer handle.mu.Unlock()
+	if !s.Audit {
+		return s.prependHead(handle, value)
+	}
+	a, err := s.beginAudit(handle)
+	if err != nil {
+		return err
+	}
+	return a.end("prepend", 1, s.prependHead(handle, value))
+}
+
+func (s *OffsetStore) prependHead(handle *Handle, value uint32) error {
+	h, err := ensureOffsetHeader(handle)
+	if err != nil {
+		return err
+	}
+	f := handle.File
+
+	newOff, err := allocateNode(f, h)
+	if err != nil {
+		return err
+	}
+
+	// 새 노드가 기존 head 를 가리키므로 다른 노드는 고칠 필요가 없다.
+	newNode := &Node{
+		Value: value,
+		Next:  h.HeadOffset,
+		Tomb:  0,
+	}
+	if err := writeNodeAt(f, newOff, newNode); err != nil {
+		return err
+	}
+
+	h.HeadOffset = newOff
+	if h.TailOffset == NullOffset {
+		h.TailOffset = newOff
+		handle.tail = tailCache{valid: true, off: newOff, node: *newNode}
+	}
+	h.Size++
+
+	return writeHeader(f, h)
+}
+
+func (s *OffsetStore) DeleteFirstByValue(handle *Handle, value uint32) (bool, error) {
+	handle.mu.Lock()
+	defer handle.mu.Unlock()
+	if !s.Audit {
+		return s.deleteFirstByValue(handle, value)
+	}
+	a, err := s.beginAudit(handle)
+	if err != nil {
+		return false, err
+	}
+	removed, err := s.deleteFirstByValue(handle, value)
+	added := 0
+	if removed {
+		added = -1
+	}
+	return removed, a.end("delete", added, err)
+}
+
+func (s *OffsetStore) deleteFirstByValue(handle *Handle, value uint32) (bool, error) {
+	h, err := ensureOffsetHeader(handle)
+	if err != nil {
+		return false, err
+	}
+	f := handle.File
+
+	if h.HeadOffset == NullOffset {
+		return false, nil
+	}
+
+	var prevOff int64 = NullOffset
+	var off int64 = h.HeadOffset
+
+	for off != NullOffset {
+		node, err := readNodeAt(f, off)
+		if err != nil {
+			return false, err
+		}
+
+		if node.Value == value && node.Tomb == 0 {
+			// 지워지는 노드나 그 앞 노드가 tail 일 수 있고, 지워진 자리는 프리 리스트로 재사용되므로 캐시를 버린다.
+			handle.tail = tailCache{}
+
+			// 원래 Next 값을 저장
+			originalNext := node.Next
+
+			// 체인에서 끊어진 노드는 Next 를 프리 리스트 연결에 재활용한다.
+			node.Tomb = 1
+			if h.hasFreeList() {
+				node.Next = h.FreeHead
+				h.FreeHead = off
+			}
+			if err := writeNodeAt(f, off, node); err != nil {
+				return false, err
+			}
+
+			if prevOff == NullOffset {
+				// head 가 지워지는 경우
+				h.HeadOffset = originalNext
+				if h.HeadOffset == NullOffset {
+					h.TailOffset = NullOffset
+				}
+			} else {
+				prevNode, err := readNodeAt(f, prevOff)
+				if err != nil {
+					return false, err
+				}
+				prevNode.Next = originalNext
+				if err := writeNodeAt(f, prevOff, prevNode); err != nil {
+					return false, err
+				}
+
+				if off == h.TailOffset {
+					h.TailOffset = prevOff
+				}
+			}
+
+			if h.Size > 0 {
+				h.Size--
+			}
+
+			if err := writeHeader(f, h); err != nil {
+				return false, err
+			}
+
+			return true, nil
+		}
+
+		prevOff = off
+		off = node.Next
+	}
+
+	return false, nil
+}
+
+func (s *OffsetStore) TraverseValues(handle *Handle) ([]uint32, error) {
+	h, err := ensureOffsetHeader(handle)
+	if err != nil {
+		return nil, err
+	}
+	f := handle.File
+
+	out := make([]uint32, 0, h.Size)
+	off := h.HeadOffset
+
+	for off != NullOffset {
+		node, err := readNodeAt(f, off)
+		if err != nil {
+			return nil, err
+		}
+		if node.Tomb == 0 {
+			out = append(out, node.Value)
+		}
+		off = node.Next
+	}
+	return out, nil
+}
+
+func (s *OffsetStore) Where(handle *Handle, target uint32) (int64, error) {
+	h, err := ensureOffsetHeader(handle)
+	if err != nil {
+		return 0, err
+	}
+	f := handle.File
+
+	off := h.HeadOffset
+
+	for off != NullOffset {
+		node, err := readNodeAt(f, off)
+		if err != nil {
+			return 0, err
+		}
+		if node.Tomb == 0 && node.Value == target {
+			return off, nil
+		}
+		off = node.Next
+	}
+	return NullOffset, nil
+}
+
+// PeekHead 는 첫 번째 살아 있는 값. 리스트가 비었으면 ok 가 false 다.
+// 삭제는 노드를 체인에서 끊으므로 보통 head 를 한 번 읽으면 끝나지만,
+// Load 로 들어온 tombstone 이 체인에 남아 있을 수 있어서 살아 있는 노드가 나올 때까지 넘어간다.
+func (s *OffsetStore) PeekHead(handle *Handle) (value uint32, ok bool, err error) {
+	h, err := ensureOffsetHeader(handle)
+	if err != nil {
+		return 0, false, err
+	}
+	for off := h.HeadOffset; off != NullOffset; {
+		node, err := readNodeAt(handle.File, off)
+		if err != nil {
+			return 0, false, err
+		}
+		if node.Tomb == 0 {
+			return node.Value, true, nil
+		}
+		off = node.Next
+	}
+	return 0, false, nil
+}
+
+// PeekTail 은 마지막 살아 있는 값. TailOffset 의 노드 하나만 읽고,
+// 그 노드가 tombstone 일 때만 (Load 로 들어온 파일) 처음부터 따라가서 마지막 살아 있는 값을 찾는다.
+func (s *OffsetStore) PeekTail(handle *Handle) (value uint32, ok bool, err error) {
+	h, err := ensureOffsetHeader(handle)
+	if err != nil {
+		return 0, false, err
+	}
+	if h.TailOffset == NullOffset {
+		return 0, false, nil
+	}
+	node, cached := handle.cachedTail(h.TailOffset)
+	if !cached {
+		if node, err = readNodeAt(handle.File, h.TailOffset); err != nil {
+			return 0, false, err
+		}
+	}
+	if node.Tomb == 0 {
+		return node.Value, true, nil
+	}
+
+	for off := h.HeadOffset; off != NullOffset; {
+		node, err := readNodeAt(handle.File, off)
+		if err != nil {
+			return 0, false, err
+		}
+		if node.Tomb == 0 {
+			value, ok = node.Value, true
+		}
+		off = node.Next
+	}
+	return value, ok, nil
+}
+
+// Length 는 살아 있는 값의 개수. 헤더의 Size 를 그대로 돌려주므로 순회하지 않는다.
+func (s *OffsetStore) Length(handle *Handle) (int64, error) {
+	h, err := ensureOffsetHeader(handle)
+	if err != nil {
+		return 0, err
+	}
+	return h.Size, nil
+}
+
+// JSON 내보내기 / 가져오기
+// Next 가 파일 오프셋이므로 노드마다 오프셋을 함께 적어 두고, Load 할 때 같은 자리에 다시 쓴다.
+// tombstone 노드도 그대로 포함한다.
+type dumpFile struct {
+	Magic      string     `json:"magic"`
+	Version    uint16     `json:"version"`
+	PageSize   uint16     `json:"pageSize"`
+	HeadOffset int64      `json:"headOffset"`
+	TailOffset int64      `json:"tailOffset"`
+	Size       int64      `json:"size"`
+	FreeHead   int64      `json:"freeHead"`
+	Nodes      []dumpNode `json:"nodes"`
+}
+
+type dumpNode struct {
+	Offset int64  `json:"offset"`
+	Value  uint32 `json:"value"`
+	Next   int64  `json:"next"`
+	Tomb   uint8  `json:"tomb"`
+}
+
+// Dump 는 헤더와 파일에 기록된 모든 노드(물리 순서)를 JSON 으로 w 에 쓴다.
+func (s *OffsetStore) Dump(handle *Handle, w io.Writer) error {
+	h, err := ensureOffsetHeader(handle)
+	if err != nil {
+		return err
+	}
+	f := handle.File
+
+	size, err := fileSize(f)
+	if err != nil {
+		return err
+	}
+
+	out := dumpFile{
+		Magic:      string(h.Magic[:]),
+		Version:    h.Version,
+		PageSize:   h.PageSize,
+		HeadOffset: h.HeadOffset,
+		TailOffset: h.TailOffset,
+		Size:       h.Size,
+		FreeHead:   h.FreeHead,
+		Nodes:      make([]dumpNode, 0),
+	}
+
+	for off := h.dataStart(); off+nodeOnDiskSize <= size; off += nodeOnDiskSize {
+		node, err := readNodeAt(f, off)
+		if err != nil {
+			return err
+		}
+		out.Nodes = append(out.Nodes, dumpNode{
+			Offset: off,
+			Value:  node.Value,
+			Next:   node.Next,
+			Tomb:   node.Tomb,
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// Load 는 Dump 로 만든 JSON 으로 handle 의 파일을 통째로 다시 만든다.
+func (s *OffsetStore) Load(handle *Handle, r io.Reader) error {
+	handle.mu.Lock()
+	defer handle.mu.Unlock()
+	// freeHead 가 없는 예전 덤프는 프리 리스트가 빈 것으로 본다.
+	in := dumpFile{FreeHead: NullOffset}
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return err
+	}
+	if in.Magic != string(Magic[:]) {
+		return ErrInvalidMagic
+	}
+	if in.Version != legacyVersion && in.Version != currentVersion {
+		return fmt.Errorf("unsupported version %d", in.Version)
+	}
+
+	f := handle.File
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+
+	h := &Header{
+		Magic:      Magic,
+		Version:    in.Version,
+		PageSize:   in.PageSize,
+		HeadOffset: in.HeadOffset,
+		TailOffset: in.TailOffset,
+		Size:       in.Size,
+		FreeHead:   in.FreeHead,
+	}
+	if err := writeHeader(f, h); err != nil {
+		return err
+	}
+
+	for _, n := range in.Nodes {
+		if n.Offset < h.dataStart() || (n.Offset-h.dataStart())%nodeOnDiskSize != 0 {
+			return fmt.Errorf("node offset %d is not aligned to the node size", n.Offset)
+		}
+		node := &Node{Value: n.Value, Next: n.Next, Tomb: n.Tomb}
+		if err := writeNodeAt(f, n.Offset, node); err != nil {
+			return err
+		}
+	}
+
+	handle.Header = h
+	handle.tail = tailCache{}
+	handle.audit = auditState{}
+	return nil
+}
+
+// 무결성 검사 (fsck)
+// Problem 하나는 발견된 이상 한 건. Offset 이 NullOffset 이면 파일 헤더에 대한 문제다.
+type Problem struct {
+	Offset  int64  `json:"offset"`
+	Message string `json:"message"`
+}
+
+type CheckReport struct {
+	Nodes      int       `json:"nodes"`
+	Live       int       `json:"live"`
+	Tombstones int       `json:"tombstones"`
+	Reachable  int       `json:"reachable"`
+	Free       int       `json:"free"`
+	Problems   []Problem `json:"problems"`
+}
+
+func (r *CheckReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+func (r *CheckReport) addf(off int64, format string, args ...any) {
+	r.Problems = append(r.Problems, Problem{Offset: off, Message: fmt.Sprintf(format, args...)})
+}
+
+// Check 는 디스크의 헤더와 노드를 다시 읽어서 다음을 검증한다.
+// - Magic / Version 과 파일 크기가 노드 크기에 맞는지
+// - head 부터 따라간 Next 오프셋이 노드 경계에 맞는지, 순환이 없는지, 끝이 Tail 인지
+// - tombstone 이 체인에 남아 있지 않은지, 살아있는 노드가 모두 도달 가능한지
+// - Header.Size 와 도달 가능한 노드 수가 같은지
+// - 프리 리스트가 tombstone 노드만 이어져 있는지, 모든 tombstone 이 프리 리스트에 있는지 (version 3)
+// 파일을 읽을 수 없는 I/O 에러만 error 로 돌려주고, 포맷 이상은 모두 리포트에 담는다.
+func (s *OffsetStore) Check(handle *Handle) (*CheckReport, error) {
+	f := handle.File
+	report := &CheckReport{Problems: make([]Problem, 0)}
+
+	h := &Header{}
+	if err := readHeader(f, h); err != nil {
+		if errors.Is(err, ErrInvalidMagic) {
+			report.addf(NullOffset, "magic mismatch: %q", h.Magic[:])
+			return report, nil
+		}
+		return nil, err
+	}
+	if h.Version != legacyVersion && h.Version != currentVersion {
+		// 같은 Magic 을 쓰는 다른 포맷(offset/paged) 이므로 더 해석하지 않는다.
+		report.addf(NullOffset, "unsupported version %d", h.Version)
+		return report, nil
+	}
+
+	size, err := fileSize(f)
+	if err != nil {
+		return nil, err
+	}
+	start := h.dataStart()
+	end := size
+	if (end-start)%nodeOnDiskSize != 0 {
+		report.addf(NullOffset, "file size %d leaves a partial node at the end", end)
+		end -= (end - start) % nodeOnDiskSize
+	}
+	if (h.HeadOffset == NullOffset) != (h.TailOffset == NullOffset) {
+		report.addf(NullOffset, "head %d and tail %d disagree on emptiness", h.HeadOffset, h.TailOffset)
+	}
+
+	validOffset := func(off int64) bool {
+		return off >= start && off+nodeOnDiskSize <= end && (off-start)%nodeOnDiskSize == 0
+	}
+
+	// 1) 물리 순서로 노드 상태 수집
+	tombs := make(map[int64]uint8)
+	for off := start; off < end; off += nodeOnDiskSize {
+		node, err := readNodeAt(f, off)
+		if err != nil {
+			return nil, err
+		}
+		report.Nodes++
+		switch node.Tomb {
+		case 0:
+			report.Live++
+		case 1:
+			report.Tombstones++
+		default:
+			report.addf(off, "invalid tomb marker %d", node.Tomb)
+		}
+		tombs[off] = node.Tomb
+	}
+
+	// 2) head 부터 체인 따라가기
+	visited := make(map[int64]bool)
+	last := NullOffset
+	for off := h.HeadOffset; off != NullOffset; {
+		if !validOffset(off) {
+			report.addf(last, "next offset %d is not a node boundary", off)
+			break
+		}
+		if visited[off] {
+			report.addf(last, "cycle detected: offset %d visited twice", off)
+			break
+		}
+		visited[off] = true
+
+		if tombs[off] != 0 {
+			report.addf(off, "tombstoned node is still linked in the chain")
+		} else {
+			report.Reachable++
+		}
+
+		node, err := readNodeAt(f, off)
+		if err != nil {
+			return nil, err
+		}
+		last = off
+		off = node.Next
+	}
+
+	if last != h.TailOffset {
+		report.addf(NullOffset, "chain ends at %d but tail is %d", last, h.TailOffset)
+	}
+	if int64(report.Reachable) != h.Size {
+		report.addf(NullOffset, "header size %d, reachable live nodes %d", h.Size, report.Reachable)
+	}
+
+	// 3) 체인에 없는 살아있는 노드 = 잃어버린 노드
+	for off := start; off < end; off += nodeOnDiskSize {
+		if tombs[off] == 0 && !visited[off] {
+			report.addf(off, "live node is not reachable from head")
+		}
+	}
+
+	if !h.hasFreeList() {
+		return report, nil
+	}
+
+	// 4) 프리 리스트 따라가기
+	freed := make(map[int64]bool)
+	last = NullOffset
+	for off := h.FreeHead; off != NullOffset; {
+		if !validOffset(off) {
+			report.addf(last, "free list offset %d is not a node boundary", off)
+			break
+		}
+		if freed[off] {
+			report.addf(last, "cycle detected in free list: offset %d visited twice", off)
+			break
+		}
+		freed[off] = true
+		report.Free++
+
+		if tombs[off] == 0 {
+			report.addf(off, "live node is on the free list")
+		}
+
+		node, err := readNodeAt(f, off)
+		if err != nil {
+			return nil, err
+		}
+		last = off
+		off = node.Next
+	}
+
+	// 프리 리스트에 없는 tombstone 은 다시 쓰이지 않는 공간이다.
+	for off := start; off < end; off += nodeOnDiskSize {
+		if tombs[off] == 1 && !freed[off] {
+			report.addf(off, "tombstoned node is not on the free list")
+		}
+	}
+
+	return report, nil
+}
+
+// Inspect 는 파일을 사람이 읽을 수 있게 w 에 출력한다.
+// 이 포맷에는 페이지가 없으므로 Header.PageSize 단위로 묶어서 노드를 물리 순서대로 보여준다.
+// 노드마다 Value/Next/Tomb 와 16바이트 raw hex 를 한 줄에 같이 적는다.
+func (s *OffsetStore) Inspect(handle *Handle, w io.Writer) error {
+	h, err := s.inspectHeader(handle, w)
+	if err != nil {
+		return err
+	}
+	return inspectNodes(handle.File, h, h.dataStart(), -1, w)
+}
+
+// InspectHeader 는 Inspect 중 파일 헤더 부분만 출력한다. 디스크에서 다시 읽으므로 변경이 바로 보인다.
+func (s *OffsetStore) InspectHeader(handle *Handle, w io.Writer) error {
+	_, err := s.inspectHeader(handle, w)
+	return err
+}
+
+// InspectPage 는 Header.PageSize 로 나눈 pageID 번째 구간에 시작하는 노드만 출력한다.
+// 노드는 페이지 경계를 넘어 걸칠 수 있으므로 "시작 오프셋" 기준으로 고른다.
+func (s *OffsetStore) InspectPage(handle *Handle, pageID uint32, w io.Writer) error {
+	h := &Header{}
+	if err := readHeader(handle.File, h); err != nil {
+		return err
+	}
+	pageSize := inspectPageSize(h)
+	size, err := fileSize(handle.File)
+	if err != nil {
+		return err
+	}
+	from := max(int64(pageID)*pageSize, h.dataStart())
+	if from >= size {
+		return fmt.Errorf("page %d out of range: file is %d bytes", pageID, size)
+	}
+	// from 이 노드 경계에 오도록 데이터 영역 시작 기준으로 올림한다.
+	if rem := (from - h.dataStart()) % nodeOnDiskSize; rem != 0 {
+		from += nodeOnDiskSize - rem
+	}
+	return inspectNodes(handle.File, h, from, (int64(pageID)+1)*pageSize, w)
+}
+
+func (s *OffsetStore) inspectHeader(handle *Handle, w io.Writer) (*Header, error) {
+	f := handle.File
+
+	h := &Header{}
+	if err := readHeader(f, h); err != nil {
+		return nil, err
+	}
+
+	raw := make([]byte, h.dataStart())
+	if _, err := f.ReadAt(raw, 0); err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(w, "== header @0 (%d bytes)\n", h.dataStart())
+	fmt.Fprintf(w, "magic=%s version=%d pageSize=%d size=%d\n", h.Magic[:], h.Version, h.PageSize, h.Size)
+	fmt.Fprintf(w, "head=%d tail=%d free=%d\n", h.HeadOffset, h.TailOffset, h.FreeHead)
+	fmt.Fprint(w, hex.Dump(raw))
+	return h, nil
+}
+
+func inspectPageSize(h *Header) int64 {
+	if h.PageSize == 0 {
+		return int64(DefaultPageSize)
+	}
+	return int64(h.PageSize)
+}
+
+// [from, until) 에서 시작하는 노드를 출력한다. until < 0 이면 파일 끝까지.
+func inspectNodes(f File, h *Header, from, until int64, w io.Writer) error {
+	size, err := fileSize(f)
+	if err != nil {
+		return err
+	}
+	if until < 0 || until > size {
+		until = size
+	}
+	pageSize := inspectPageSize(h)
+
+	buf := make([]byte, nodeOnDiskSize)
+	for off := from; off < until && off+nodeOnDiskSize <= size; off += nodeOnDiskSize {
+		if off == from || off/pageSize != (off-nodeOnDiskSize)/pageSize {
+			fmt.Fprintf(w, "\n== page %d (bytes %d..%d)\n", off/pageSize, off/pageSize*pageSize, (off/pageSize+1)*pageSize)
+		}
+		if _, err := f.ReadAt(buf, off); err != nil {
+			return err
+		}
+		node, err := readNodeAt(f, off)
+		if err != nil {
+			return err
+		}
+		mark := ""
+		if node.Tomb != 0 {
+			mark = " TOMB"
+		}
+		fmt.Fprintf(w, "  @%-8d value=%-10d next=%-8d %s%s\n", off, node.Value, node.Next, hex.EncodeToString(buf), mark)
+	}
+
+	return nil
+}
+
+// Stats 는 파일의 공간 사용 현황
+// 이 포맷에는 페이지가 없으므로 Header.PageSize 로 나눈 가상 페이지 기준으로 센다.
+// - UsedSlots: 파일에 기록된 노드 수 (살아있는 노드 + tombstone)
+// - FreeSlots: 프리 리스트에 있어서 삽입할 때 재사용될 노드 수
+// - AvgPageFill: 살아있는 노드가 차지하는 바이트 / (PageCount * PageSize). tombstone 은 빈 공간으로 본다.
+// - GarbageRatio: tombstone / UsedSlots. tombstone 은 프리 리스트를 거쳐 삽입이 다시 쓰므로 따로 vacuum 하지 않는다.
+type Stats struct {
+	FileSize     int64   `json:"fileSize"`
+	PageSize     uint16  `json:"pageSize"`
+	PageCount    int     `json:"pageCount"`
+	UsedSlots    int     `json:"usedSlots"`
+	FreeSlots    int     `json:"freeSlots"`
+	LiveSlots    int     `json:"liveSlots"`
+	Tombstones   int     `json:"tombstones"`
+	AvgPageFill  float64 `json:"avgPageFill"`
+	GarbageRatio float64 `json:"garbageRatio"`
+}
+
+// Stats 는 노드를 물리 순서로 한 번 훑고 프리 리스트를 따라가서 공간 사용 현황을 모은다.
+func (s *OffsetStore) Stats(handle *Handle) (*Stats, error) {
+	h, err := ensureOffsetHeader(handle)
+	if err != nil {
+		return nil, err
+	}
+	f := handle.File
+
+	size, err := fileSize(f)
+	if err != nil {
+		return nil, err
+	}
+
+	pageSize := int64(h.PageSize)
+	if pageSize == 0 {
+		pageSize = int64(DefaultPageSize)
+	}
+
+	st := &Stats{
+		FileSize:  size,
+		PageSize:  uint16(pageSize),
+		PageCount: int((size + pageSize - 1) / pageSize),
+	}
+
+	for off := h.dataStart(); off+nodeOnDiskSize <= size; off += nodeOnDiskSize {
+		node, err := readNodeAt(f, off)
+		if err != nil {
+			return nil, err
+		}
+		st.UsedSlots++
+		if node.Tomb == 0 {
+			st.LiveSlots++
+		} else {
+			st.Tombstones++
+		}
+	}
+
+	// 프리 리스트 길이. 깨진 파일에서 무한 루프를 돌지 않도록 노드 수를 넘으면 멈춘다.
+	for off := h.FreeHead; off != NullOffset && st.FreeSlots < st.UsedSlots; st.FreeSlots++ {
+		node, err := readNodeAt(f, off)
+		if err != nil {
+			return nil, err
+		}
+		off = node.Next
+	}
+
+	if st.PageCount > 0 {
+		st.AvgPageFill = float64(st.LiveSlots*nodeOnDiskSize) / float64(int64(st.PageCount)*pageSize)
+	}
+	if st.UsedSlots > 0 {
+		st.GarbageRatio = float64(st.Tombstones) / float64(st.UsedSlots)
+	}
+
+	return st, nil
+}
+
+// RunCommand 는 `btree <command> -store offset <file>` 의 실제 동작. args 는 [command, file].
+// - export : 파일을 JSON 으로 stdout 에 출력
+// - import : stdin 의 JSON 으로 파일을 다시 만든다
+// - check  : 무결성 검사 결과를 출력, 문제가 있으면 에러
+// - dump   : 헤더 / 노드 / raw hex 를 출력
+// - stats  : 공간 사용 현황을 출력
+func RunCommand(store LinkedListStore, args []string) error {
+	return RunCommandContext(context.Background(), store, args)
+}
+
+// RunCommandContext 는 ctx 가 취소되면 export / import 를 중간에 멈추는 RunCommand.
+func RunCommandContext(ctx context.Context, store LinkedListStore, args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("usage: <command> <file>")
+	}
+
+	switch args[0] {
+	case "export":
+		handle, err := store.Open(args[1], false)
+		if err != nil {
+			return err
+		}
+		defer store.Close(handle)
+		return store.Dump(handle, ctxWriter{ctx, os.Stdout})
+	case "import":
+		handle, err := store.Open(args[1], true)
+		if err != nil {
+			return err
+		}
+		defer store.Close(handle)
+		return store.Load(handle, ctxReader{ctx, os.Stdin})
+	case "check":
+		handle, err := store.Open(args[1], false)
+		if err != nil {
+			return err
+		}
+		defer store.Close(handle)
+
+		report, err := store.Check(handle)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("nodes=%d live=%d tombstones=%d reachable=%d free=%d\n",
+			report.Nodes, report.Live, report.Tombstones, report.Reachable, report.Free)
+		for _, p := range report.Problems {
+			if p.Offset != NullOffset {
+				fmt.Printf("  @%d: %s\n", p.Offset, p.Message)
+			} else {
+				fmt.Printf("  header: %s\n", p.Message)
+			}
+		}
+		if !report.OK() {
+			return fmt.Errorf("%d problem(s) found", len(report.Problems))
+		}
+		return nil
+	case "dump":
+		handle, err := store.Open(args[1], false)
+		if err != nil {
+			return err
+		}
+		defer store.Close(handle)
+		return store.Inspect(handle, os.Stdout)
+	case "stats":
+		handle, err := store.Open(args[1], false)
+		if err != nil {
+			return err
+		}
+		defer store.Close(handle)
+
+		st, err := store.Stats(handle)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("file size=%d\n", st.FileSize)
+		fmt.Printf("pages=%d pageSize=%d\n", st.PageCount, st.PageSize)
+		fmt.Printf("slots used=%d free=%d live=%d tombstones=%d\n", st.UsedSlots, st.FreeSlots, st.LiveSlots, st.Tombstones)
+		fmt.Printf("avg page fill=%.1f%%\n", st.AvgPageFill*100)
+		fmt.Printf("garbage=%.1f%%\n", st.GarbageRatio*100)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// ctxWriter / ctxReader 는 쓰기 / 읽기 호출마다 ctx 를 보고, 취소됐으면 ctx.Err() 로 멈춘다.
+// Dump / Load 처럼 io.Writer / io.Reader 만 받는 긴 작업을 중간에 끊을 때 쓴다.
+type ctxWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+func (c ctxWriter) Write(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.w.Write(p)
+}
+
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c ctxReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
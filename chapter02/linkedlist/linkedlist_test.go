@@ -0,0 +1,278 @@
+package linkedlist
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/tmdgusya/btree/dberr"
+)
+
+func fileSizeOf(t *testing.T, path string) int64 {
+	t.Helper()
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fi.Size()
+}
+
+// checkList 는 파일이 fsck 를 통과하고 want 와 같은 값을 같은 순서로 갖고 있는지 본다.
+func checkList(t *testing.T, s *OffsetStore, handle *Handle, want []uint32) *CheckReport {
+	t.Helper()
+	report, err := s.Check(handle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() {
+		t.Fatalf("check found problems: %+v", report.Problems)
+	}
+	got, err := s.TraverseValues(handle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("values = %v, want %v", got, want)
+	}
+	return report
+}
+
+// 지운 노드는 프리 리스트로 돌아가서 다음 삽입이 다시 쓰므로, 살아 있는 노드 수가 그대로면
+// 지우고 넣기를 수천 번 섞어도 첫 바퀴 뒤로는 파일이 커지지 않는다.
+func TestChurnKeepsFileSize(t *testing.T) {
+	const live, cycles = 1000, 6
+	path := filepath.Join(t.TempDir(), "churn.db")
+	s := &OffsetStore{Audit: true}
+	handle, err := s.Open(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { s.Close(handle) }()
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	var values []uint32
+	var next uint32
+	for ; next < live; next++ {
+		if err := s.AppendTail(handle, next); err != nil {
+			t.Fatal(err)
+		}
+		values = append(values, next)
+	}
+
+	var stable int64
+	for cycle := 0; cycle < cycles; cycle++ {
+		for i := 0; i < live; i++ {
+			victim := values[rng.IntN(len(values))]
+			ok, err := s.DeleteFirstByValue(handle, victim)
+			if err != nil {
+				t.Fatalf("delete %d: %v", victim, err)
+			}
+			if !ok {
+				t.Fatalf("delete %d: not found", victim)
+			}
+			values = slices.DeleteFunc(values, func(v uint32) bool { return v == victim })
+
+			if rng.IntN(2) == 0 {
+				err = s.AppendTail(handle, next)
+				values = append(values, next)
+			} else {
+				err = s.PrependHead(handle, next)
+				values = slices.Insert(values, 0, next)
+			}
+			if err != nil {
+				t.Fatalf("insert %d: %v", next, err)
+			}
+			next++
+		}
+
+		size := fileSizeOf(t, path)
+		if cycle == 0 {
+			stable = size
+		} else if size > stable {
+			t.Fatalf("cycle %d: file grew from %d to %d bytes", cycle, stable, size)
+		}
+	}
+	checkList(t, s, handle, values)
+}
+
+// version 1 파일은 열 때 version 3 으로 바뀐다. 노드는 FreeHead 만큼 뒤로 밀리고,
+// 예전에 지워서 돌려받지 못한 tombstone 은 프리 리스트로 들어가 다음 삽입이 재사용한다.
+func TestOpenUpgradesLegacyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legacy.db")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 1 -> 2 -> 3 사이에 지워진 9 가 끼어 있다. version 1 의 삭제는 Next 를 그대로 둔다.
+	const n = nodeOnDiskSize
+	base := int64(legacyHeaderOnDiskSize)
+	nodes := []Node{
+		{Value: 1, Next: base + 2*n},
+		{Value: 9, Next: base + 2*n, Tomb: 1},
+		{Value: 2, Next: base + 3*n},
+		{Value: 3, Next: NullOffset},
+	}
+	for i := range nodes {
+		if err := writeNodeAt(f, base+int64(i)*n, &nodes[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	legacy := &Header{
+		Magic:      Magic,
+		Version:    legacyVersion,
+		PageSize:   DefaultPageSize,
+		HeadOffset: base,
+		TailOffset: base + 3*n,
+		Size:       3,
+	}
+	if err := writeHeader(f, legacy); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &OffsetStore{}
+	handle, err := s.Open(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := ensureOffsetHeader(handle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Version != currentVersion {
+		t.Fatalf("version = %d, want %d", h.Version, currentVersion)
+	}
+	if want := int64(headerOnDiskSize) + n; h.FreeHead != want {
+		t.Fatalf("free head = %d, want the old tombstone at %d", h.FreeHead, want)
+	}
+	if report := checkList(t, s, handle, []uint32{1, 2, 3}); report.Free != 1 {
+		t.Fatalf("free nodes = %d, want 1", report.Free)
+	}
+
+	upgraded := fileSizeOf(t, path)
+	if want := int64(headerOnDiskSize) + 4*n; upgraded != want {
+		t.Fatalf("upgraded file is %d bytes, want %d", upgraded, want)
+	}
+	if err := s.AppendTail(handle, 4); err != nil {
+		t.Fatal(err)
+	}
+	if size := fileSizeOf(t, path); size != upgraded {
+		t.Fatalf("append grew the file from %d to %d bytes instead of reusing the tombstone", upgraded, size)
+	}
+	if err := s.Close(handle); err != nil {
+		t.Fatal(err)
+	}
+
+	// 다시 열면 이미 version 3 이므로 그대로 읽힌다.
+	if handle, err = s.Open(path, false); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close(handle)
+	checkList(t, s, handle, []uint32{1, 2, 3, 4})
+}
+
+// version 2 는 paged_linked_list 가 같은 Magic 으로 쓰는 번호라 오프셋 리스트로 열면 안 된다.
+func TestOpenRejectsPagedVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "paged.db")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &Header{Magic: Magic, Version: 2, PageSize: DefaultPageSize, HeadOffset: NullOffset, TailOffset: NullOffset, FreeHead: NullOffset}
+	if err := writeHeader(f, h); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &OffsetStore{}
+	_, err = s.Open(path, false)
+	if !errors.Is(err, ErrUnsupportedVersion) || !errors.Is(err, dberr.ErrInvalidMagic) {
+		t.Fatalf("open version 2 file: err = %v, want ErrUnsupportedVersion", err)
+	}
+}
+
+// Backup 은 다른 고루틴이 값을 넣는 동안 불러도 연산 사이의 상태를 복사해야 한다.
+// 사본은 그대로 열려서 fsck 를 통과하고, 그때까지 넣은 값의 앞부분을 순서대로 갖는다.
+// 쓰기가 끝난 뒤의 사본은 원본과 같은 값을 갖는다.
+func TestBackupWhileAppending(t *testing.T) {
+	const total = 3000
+	dir := t.TempDir()
+	s := &OffsetStore{}
+	handle, err := s.Open(filepath.Join(dir, "source.db"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { s.Close(handle) }()
+
+	done := make(chan error, 1)
+	go func() {
+		for v := uint32(0); v < total; v++ {
+			if err := s.AppendTail(handle, v); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+
+	// openBackup 은 사본을 파일로 쓰고 열어서 fsck 를 통과하는지 본 뒤 값을 돌려준다.
+	openBackup := func(name string) []uint32 {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		f, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Backup(handle, f); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+		copied, err := s.Open(path, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer s.Close(copied)
+		report, err := s.Check(copied)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !report.OK() {
+			t.Fatalf("%s: check found problems: %+v", name, report.Problems)
+		}
+		values, err := s.TraverseValues(copied)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return values
+	}
+
+	for i := 0; i < 5; i++ {
+		values := openBackup(fmt.Sprintf("backup-%d.db", i))
+		for j, v := range values {
+			if v != uint32(j) {
+				t.Fatalf("backup %d: value %d is %d, want a prefix of the appended values", i, j, v)
+			}
+		}
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := s.TraverseValues(handle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := openBackup("final.db"); !slices.Equal(got, want) {
+		t.Fatalf("final backup has %d values, source has %d", len(got), len(want))
+	}
+}
@@ -0,0 +1,114 @@
+package pagedlist
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/tmdgusya/btree/iometrics"
+)
+
+// ==================================
+// 관리 엔드포인트 (`btree serve -store paged` 의 /api/admin/*)
+// ==================================
+// 운영 중인 데모 서버에서 정리 작업을 손으로 돌리고, 전후의 공간 사용과 그 작업이 쓴 I/O 를 돌려준다.
+// - POST /api/admin/compact     모든 페이지의 tombstone 을 정리하고 빈 영역에 구멍을 뚫는다 (compact 명령과 같다)
+// - POST /api/admin/checkpoint  헤더를 쓰고 fsync 한다
+// - POST /api/admin/flush       헤더를 쓴다 (fsync 없음)
+// ServeOptions.AdminToken 이 비어 있으면 모두 403 이고, 있으면 "Authorization: Bearer <토큰>" 이 맞아야 한다.
+// 작업하는 동안은 다른 요청과 같은 잠금을 잡으므로 값 추가 / 삭제는 기다린다.
+
+// ServeOptions 는 Serve 의 추가 설정
+type ServeOptions struct {
+	// AdminToken 은 /api/admin/* 가 받는 Bearer 토큰. 비어 있으면 관리 엔드포인트를 열지 않는다.
+	AdminToken string
+}
+
+// AdminResult 는 관리 작업 하나의 결과. Before / After 는 작업 전후의 Stats 이고 IO 는 작업 자체의 I/O 만 담는다.
+// Reclaimed / Punched 는 compact 에서만 채운다.
+type AdminResult struct {
+	Op        string              `json:"op"`
+	Before    *Stats              `json:"before"`
+	After     *Stats              `json:"after"`
+	IO        iometrics.IOMetrics `json:"io"`
+	Elapsed   time.Duration       `json:"elapsed"`
+	Reclaimed int                 `json:"reclaimed,omitempty"`
+	Punched   int64               `json:"punched,omitempty"`
+}
+
+// adminOps 는 /api/admin/ 뒤에 오는 이름과 작업. 작업은 s.mu 를 잡은 채로 불린다.
+func (s *listServer) adminOps() map[string]func(r *http.Request, res *AdminResult) error {
+	return map[string]func(r *http.Request, res *AdminResult) error{
+		"compact": func(r *http.Request, res *AdminResult) (err error) {
+			res.Reclaimed, res.Punched, err = compactAll(r.Context(), s.store, s.handle, punchHoleSupported)
+			return err
+		},
+		"checkpoint": func(*http.Request, *AdminResult) error {
+			return s.store.Checkpoint(s.handle)
+		},
+		"flush": func(*http.Request, *AdminResult) error {
+			return s.store.Flush(s.handle)
+		},
+	}
+}
+
+// handleAdmin 은 /api/admin/{compact,checkpoint,flush} 를 처리한다.
+func (s *listServer) handleAdmin(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/api/admin/")
+	op, ok := s.adminOps()[name]
+	if !ok {
+		writeError(w, http.StatusNotFound, "unknown admin operation")
+		return
+	}
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+	if !s.authorized(w, r) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := AdminResult{Op: name}
+	var err error
+	if res.Before, err = s.store.Stats(s.handle); err != nil {
+		writeErr(w, err)
+		return
+	}
+	// 작업의 I/O 만 따로 보려고 구간 지표를 잰다. 누적 지표에도 "admin-<이름>" 구간으로 남는다.
+	before := s.cf.Metrics()
+	start := time.Now()
+	end, done := s.cf.Section("admin-"+name), s.heat.begin()
+	err = op(r, &res)
+	done()
+	end()
+	res.Elapsed = time.Since(start)
+	res.IO = s.cf.Metrics().Diff(before)
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	if res.After, err = s.store.Stats(s.handle); err != nil {
+		writeErr(w, err)
+		return
+	}
+	s.ops[name]++
+	respondJSON(w, http.StatusOK, res)
+}
+
+// authorized 는 요청의 Bearer 토큰을 확인하고, 맞지 않으면 응답까지 쓰고 false 를 돌려준다.
+func (s *listServer) authorized(w http.ResponseWriter, r *http.Request) bool {
+	if s.adminToken == "" {
+		writeError(w, http.StatusForbidden, "admin endpoints are disabled (set admin-token)")
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="btree admin"`)
+		writeError(w, http.StatusUnauthorized, "missing or invalid admin token")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,99 @@
+package pagedlist
+
+import (
+	"fmt"
+
+	"github.com/tmdgusya/btree/dberr"
+)
+
+// ==================================
+// 크기 감사 (audit)
+// ==================================
+// PagedStore.Audit 를 켜면 변경 연산(AppendTail, AppendValues, PrependHead, DeleteFirstByValue)이 끝날 때마다
+// 헤더의 Size 를 핸들이 따로 센 값과 맞춰 보고, 어긋나면 ErrSizeDrift 로 그 연산을 바로 실패시킨다.
+// 구멍 재사용이나 compaction 처럼 슬롯을 옮겨 다니는 연산을 새로 붙일 때 실수를 그 자리에서 잡으려는 것이라 기본은 꺼져 있다.
+// - 처음 바꿀 때 체인을 한 번 따라가서 live 를 센다. 그 뒤로는 연산의 결과(넣은 수, 지웠는지)만 보고 더하고 뺀다.
+// - 페이지 리스트에는 프리 리스트가 없고 tombstone 슬롯이 그 역할을 하므로, 페이지 헤더의 Used 합계가
+//   live 보다 작아지지 않는지도 본다. 삭제 뒤의 자동 vacuum 도 같은 연산 안에서 돌기 때문에 함께 검사된다.
+
+var ErrSizeDrift = dberr.New(dberr.ErrCorruptPage, "size drift")
+
+// auditState 는 핸들마다 따로 센 값. ready 가 false 면 다음 변경 때 체인을 따라가서 다시 센다 (Load 뒤 등).
+type auditState struct {
+	ready bool
+	live  uint64
+}
+
+// beginAudit 은 변경 연산 전에 부른다. 처음이면 live 를 세고, 그 값이 이미 헤더와 다르면 연산을 하지 않고 실패한다.
+func (s *PagedStore) beginAudit(handle *Handle) error {
+	if handle.audit.ready {
+		return nil
+	}
+	h, err := ensurePagedHeader(handle)
+	if err != nil {
+		return err
+	}
+	live, err := countLive(handle.File, h)
+	if err != nil {
+		return err
+	}
+	handle.audit = auditState{ready: true, live: live}
+	return s.verifyAudit(handle, "open")
+}
+
+// endAudit 은 변경 연산이 err 로 끝난 뒤에 부른다. added 는 넣은 값 수, 지웠으면 -1.
+// 실패한 연산은 어디까지 썼는지 모르므로 다음에 다시 센다.
+func (s *PagedStore) endAudit(handle *Handle, op string, added int, err error) error {
+	if err != nil {
+		handle.audit.ready = false
+		return err
+	}
+	handle.audit.live = uint64(int64(handle.audit.live) + int64(added))
+	if err := s.verifyAudit(handle, op); err != nil {
+		handle.audit.ready = false
+		return err
+	}
+	return nil
+}
+
+func (s *PagedStore) verifyAudit(handle *Handle, op string) error {
+	h, err := ensurePagedHeader(handle)
+	if err != nil {
+		return err
+	}
+	live := handle.audit.live
+	if h.Size != live {
+		return fmt.Errorf("%w after %s: header size %d, counted %d", ErrSizeDrift, op, h.Size, live)
+	}
+	used, err := countUsedSlots(handle.File, h)
+	if err != nil {
+		return err
+	}
+	if uint64(used) < live {
+		return fmt.Errorf("%w after %s: pages use %d slots, fewer than %d live values", ErrSizeDrift, op, used, live)
+	}
+	return nil
+}
+
+// countLive 는 체인을 따라가서 살아 있는 노드 수를 센다.
+// 손상된 파일에서 끝나지 않도록 페이지에 들어갈 수 있는 슬롯 수보다 많이 따라가면 멈춘다.
+func countLive(f File, h *Header) (uint64, error) {
+	var pb PageBuffer
+	defer pb.release()
+
+	limit := uint64(h.PageCount) * uint64(slotsPerPage(h.PageSize))
+	var live, walked uint64
+	page, slot := h.HeadPage, h.HeadSlot
+	for page != NullPage && slot != NullSlot && walked <= limit {
+		node, err := readSlotWithBuffer(f, &pb, h, page, slot)
+		if err != nil {
+			return 0, err
+		}
+		if node.Tomb == 0 {
+			live++
+		}
+		page, slot = node.NextPage, node.NextSlot
+		walked++
+	}
+	return live, nil
+}
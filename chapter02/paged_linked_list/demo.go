@@ -0,0 +1,143 @@
+package pagedlist
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/tmdgusya/btree/iometrics"
+)
+
+// Demo 는 paged_list.llst 를 새로 만들어 값을 넣고 / 찾고 / 지우면서 헤더와 I/O 를 출력한다.
+// 튜토리얼 데모라서 실패하면 panic 한다.
+func Demo() {
+	var store LinkedListStore = &PagedStore{}
+
+	// 교육용: 항상 새로 시작하도록 O_TRUNC
+	// CountingFile 로 감싸서 넘기면 저장소 코드는 그대로 두고 I/O 를 셀 수 있다.
+	raw, err := os.OpenFile("paged_list.llst", os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		panic(err)
+	}
+	cf := iometrics.NewCountingFile(raw)
+	handle, err := store.OpenFile(cf)
+	if err != nil {
+		panic(err)
+	}
+	defer store.Close(handle)
+
+	if err := store.AppendTail(handle, 0); err != nil {
+		panic(err)
+	}
+	if err := store.AppendTail(handle, 1); err != nil {
+		panic(err)
+	}
+	if err := store.AppendTail(handle, 2); err != nil {
+		panic(err)
+	}
+
+	if err := store.AppendTail(handle, 3); err != nil {
+		panic(err)
+	}
+	if err := store.AppendTail(handle, 4); err != nil {
+		panic(err)
+	}
+	if err := store.AppendTail(handle, 5); err != nil {
+		panic(err)
+	}
+
+	// 리스트 전체를 순회해 값 출력
+	vals, err := store.TraverseValues(handle)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("paged list before delete:", vals)
+
+	removed, err := store.DeleteFirstByValue(handle, 3)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("deleted 3? ->", removed)
+
+	vals, err = store.TraverseValues(handle)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("paged list after delete :", vals)
+
+	// 양 끝 값과 길이는 순회 없이 head / tail 슬롯과 헤더만 본다.
+	head, _, err := store.PeekHead(handle)
+	if err != nil {
+		panic(err)
+	}
+	tail, _, err := store.PeekTail(handle)
+	if err != nil {
+		panic(err)
+	}
+	length, err := store.Length(handle)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("head=%d tail=%d length=%d\n", head, tail, length)
+
+	// 헤더를 다시 읽어와 상태 확인 (파일 재오픈 시나리오 흉내)
+	if _, err := handle.File.Seek(0, io.SeekStart); err != nil {
+		panic(err)
+	}
+	hdr, err := ensurePagedHeader(handle)
+	if err != nil {
+		panic(err)
+	}
+	if err := readHeader(handle.File, hdr); err != nil {
+		panic(err)
+	}
+	fmt.Printf("Header{PageCount=%d, Size=%d, Head=(%d,%d), Tail=(%d,%d)}\n",
+		hdr.PageCount, hdr.Size, hdr.HeadPage, hdr.HeadSlot, hdr.TailPage, hdr.TailSlot)
+
+	// Where 함수 테스트
+	loc, err := store.Where(handle, 4)
+	if err != nil {
+		panic(err)
+	}
+	if loc != nil {
+		fmt.Printf("Found value 4 at Page=%d, Slot=%d\n", loc.Page, loc.Slot)
+	} else {
+		fmt.Println("Value 4 not found")
+	}
+
+	// 존재하지 않는 값 검색
+	loc, err = store.Where(handle, 9999)
+	if err != nil {
+		panic(err)
+	}
+	if loc != nil {
+		fmt.Printf("Found value 9999 at Page=%d, Slot=%d\n", loc.Page, loc.Slot)
+	} else {
+		fmt.Println("Value 9999 not found")
+	}
+
+	loc, err = store.Where(handle, 2)
+	if err != nil {
+		panic(err)
+	}
+	if loc != nil {
+		fmt.Printf("Found value 2 at Page=%d, Slot=%d\n", loc.Page, loc.Slot)
+	} else {
+		fmt.Println("Value 2 not found")
+	}
+
+	// 열린 상태 그대로 스냅샷 복사
+	backup, err := os.Create("paged_list.llst.bak")
+	if err != nil {
+		panic(err)
+	}
+	defer backup.Close()
+	if err := store.Backup(handle, backup); err != nil {
+		panic(err)
+	}
+	fmt.Println("backup written to paged_list.llst.bak")
+
+	m := cf.Metrics()
+	fmt.Printf("I/O: reads=%d writes=%d seeks=%d bytesRead=%d bytesWritten=%d\n",
+		m.Reads, m.Writes, m.Seeks, m.BytesRead, m.BytesWritten)
+}
@@ -0,0 +1,225 @@
+package pagedlist
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// ==================================
+// 디스크 포맷 디코더 퍼징
+// ==================================
+// 손상된 파일을 읽어도 패닉하거나 엉뚱한 값을 조용히 돌려주지 않고 에러가 나야 한다.
+// 시드는 testdata/golden 의 paged 골든 파일과, ErrInvalidSlot 검사마다 그 검사가 없으면 깨지는 회귀 입력이다.
+//
+//	go test -fuzz FuzzReadSlot ./chapter02/paged_linked_list
+
+// memFile 은 바이트 슬라이스 위의 File. 퍼징 입력을 디스크를 거치지 않고 파일처럼 연다.
+type memFile struct {
+	data []byte
+	off  int64
+}
+
+func newMemFile(data []byte) *memFile { return &memFile{data: slices.Clone(data)} }
+
+func (m *memFile) Read(p []byte) (int, error) {
+	n, err := m.ReadAt(p, m.off)
+	m.off += int64(n)
+	return n, err
+}
+
+func (m *memFile) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("memfile: negative offset")
+	}
+	if off >= int64(len(m.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, m.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (m *memFile) Write(p []byte) (int, error) {
+	n, err := m.WriteAt(p, m.off)
+	m.off += int64(n)
+	return n, err
+}
+
+func (m *memFile) WriteAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("memfile: negative offset")
+	}
+	if end := off + int64(len(p)); end > int64(len(m.data)) {
+		m.data = append(m.data, make([]byte, end-int64(len(m.data)))...)
+	}
+	return copy(m.data[off:], p), nil
+}
+
+func (m *memFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += m.off
+	case io.SeekEnd:
+		offset += int64(len(m.data))
+	}
+	if offset < 0 {
+		return 0, errors.New("memfile: negative offset")
+	}
+	m.off = offset
+	return offset, nil
+}
+
+func (m *memFile) Truncate(size int64) error {
+	if size < int64(len(m.data)) {
+		m.data = m.data[:size]
+	} else {
+		m.data = append(m.data, make([]byte, size-int64(len(m.data)))...)
+	}
+	return nil
+}
+
+func (m *memFile) Close() error { return nil }
+func (m *memFile) Sync() error  { return nil }
+
+// goldenFiles 는 testdata/golden 의 paged 골든 파일 내용
+func goldenFiles(f *testing.F) [][]byte {
+	f.Helper()
+	paths, err := filepath.Glob(filepath.Join("..", "..", "testdata", "golden", "paged-v*.db"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	if len(paths) == 0 {
+		f.Fatal("no paged golden files in testdata/golden")
+	}
+	files := make([][]byte, 0, len(paths))
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			f.Fatal(err)
+		}
+		files = append(files, data)
+	}
+	return files
+}
+
+func FuzzReadHeader(f *testing.F) {
+	for _, data := range goldenFiles(f) {
+		f.Add(data)
+		f.Add(data[:HEADER_SIZE])
+		f.Add(data[:HEADER_SLOT_SIZE+1])
+		// version 4 의 두 슬롯이 모두 찢어진 파일
+		torn := slices.Clone(data)
+		torn[HEADER_SLOT_SIZE-1] ^= 0xff
+		torn[SHADOW_HEADER_SIZE-1] ^= 0xff
+		f.Add(torn)
+	}
+	// 같은 Magic 을 쓰는 OffsetStore 의 version 3 헤더
+	f.Add([]byte("LLST\x00\x03\x10\x00\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\x00\x00\x00\x00\x00\x00\x00\x00"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var h Header
+		if err := readHeader(newMemFile(data), &h); err != nil {
+			return
+		}
+		if h.Magic != Magic || (h.Version != legacyVersion && h.Version != currentVersion) {
+			t.Fatalf("accepted a header that is not ours: %+v", h)
+		}
+
+		// 읽은 헤더를 다시 써서 읽으면 같은 값이 나와야 한다. version 4 는 쓸 때 Seq 가 하나 오른다.
+		out := newMemFile(make([]byte, h.headerSize()))
+		written := h
+		if err := writeHeader(out, &written); err != nil {
+			t.Fatal(err)
+		}
+		var got Header
+		if err := readHeader(out, &got); err != nil {
+			t.Fatalf("re-reading %+v: %v", written, err)
+		}
+		if got != written {
+			t.Fatalf("round trip = %+v, want %+v", got, written)
+		}
+	})
+}
+
+// FuzzReadSlot 은 헤더가 읽히는 파일에서 pageID / slotID 슬롯을 두 경로(readSlot, readSlotWithBuffer)로 읽고,
+// 같은 페이지를 페이지 단위 디코더(appendPageValues, PageIterator)로도 읽는다.
+// 슬롯 번호가 페이지 밖이거나 페이지의 Used 가 용량보다 크면 모두 ErrInvalidSlot 이어야 한다.
+func FuzzReadSlot(f *testing.F) {
+	for _, data := range goldenFiles(f) {
+		var h Header
+		if err := readHeader(newMemFile(data), &h); err != nil {
+			f.Fatal(err)
+		}
+		capacity := slotsPerPage(h.PageSize)
+		f.Add(data, uint32(0), uint16(0))
+		f.Add(data, h.TailPage, h.TailSlot)
+		f.Add(data, h.PageCount, uint16(0))
+		// readSlot / readSlotWithBuffer: 페이지 끝을 넘는 슬롯 번호
+		f.Add(data, uint32(0), uint16(capacity))
+		f.Add(data, h.PageCount-1, uint16(0xffff))
+		// appendPageValues / PageIterator: 용량보다 큰 Used
+		overfull := slices.Clone(data)
+		putPageHeader(overfull[pageOffset(&h, 0):], PageHeader{Used: uint16(capacity + 1)})
+		f.Add(overfull, uint32(0), uint16(0))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte, pageID uint32, slotID uint16) {
+		file := newMemFile(data)
+		var h Header
+		if err := readHeader(file, &h); err != nil || validatePageSize(h.PageSize) != nil {
+			return
+		}
+		capacity := slotsPerPage(h.PageSize)
+
+		node, err := readSlot(file, &h, pageID, slotID)
+		var pb PageBuffer
+		defer pb.release()
+		buffered, berr := readSlotWithBuffer(file, &pb, &h, pageID, slotID)
+		if int(slotID) >= capacity {
+			if !errors.Is(err, ErrInvalidSlot) || !errors.Is(berr, ErrInvalidSlot) {
+				t.Fatalf("slot %d of %d: readSlot err = %v, readSlotWithBuffer err = %v, want ErrInvalidSlot", slotID, capacity, err, berr)
+			}
+		} else if err == nil && berr == nil && node != buffered {
+			t.Fatalf("readSlot = %+v, readSlotWithBuffer = %+v", node, buffered)
+		}
+
+		// 페이지가 통째로 있으면 Used 만 보고 결과를 정할 수 있다.
+		off := pageOffset(&h, pageID)
+		if off+int64(h.PageSize) <= int64(len(data)) {
+			used := int(parsePageHeader(data[off:]).Used)
+			_, err := appendPageValues(nil, file, make([]byte, h.PageSize), &h, pageID)
+			if overfull := used > capacity; overfull != errors.Is(err, ErrInvalidSlot) || (!overfull && err != nil) {
+				t.Fatalf("page %d used %d of %d: appendPageValues err = %v", pageID, used, capacity, err)
+			}
+		}
+
+		// 반복자는 읽을 수 없거나 Used 가 넘치는 첫 페이지에서 멈춘다.
+		var wantInvalid bool
+		for p := uint32(0); p < h.PageCount; p++ {
+			off := pageOffset(&h, p)
+			if off+int64(h.PageSize) > int64(len(data)) {
+				break
+			}
+			if int(parsePageHeader(data[off:]).Used) > capacity {
+				wantInvalid = true
+				break
+			}
+		}
+		it := (&PagedStore{}).PagesIterator(&Handle{File: file, Header: &h})
+		defer it.Close()
+		for it.Next() {
+			if int(it.Used()) > capacity {
+				t.Fatalf("iterator returned page %d with used %d of %d", it.Page(), it.Used(), capacity)
+			}
+		}
+		if errors.Is(it.Err(), ErrInvalidSlot) != wantInvalid {
+			t.Fatalf("iterator err = %v, want ErrInvalidSlot: %v", it.Err(), wantInvalid)
+		}
+	})
+}
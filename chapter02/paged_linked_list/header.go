@@ -0,0 +1,197 @@
+package pagedlist
+
+import (
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"github.com/tmdgusya/btree/codec"
+	"github.com/tmdgusya/btree/dberr"
+)
+
+// ==================================
+// 파일 헤더와 shadow 헤더 슬롯
+// ==================================
+// 연산마다 헤더를 고쳐 쓰는데, version 2 처럼 오프셋 0 의 헤더 하나를 덮어쓰면 쓰는 도중에 죽었을 때
+// 옛 값과 새 값이 섞인 헤더가 남고 그 파일은 더 열 수 없다. version 4 는 헤더 슬롯 두 개를 번갈아 쓴다.
+//
+//	[slot 0 (48)] [slot 1 (48)] [page 0] [page 1] ...
+//	slot: 헤더 필드(32) + Seq(8) + CRC32(4) + 여백(4)
+//
+// - 쓸 때마다 Seq 를 하나 올리고 Seq%2 번 슬롯에 쓴다. 직전 헤더가 든 슬롯은 건드리지 않으므로,
+//   쓰다 죽어도 한쪽은 온전하다.
+// - 열 때는 Magic / Version / CRC 가 맞는 슬롯 중 Seq 가 큰 쪽을 쓴다. 찢어진 슬롯은 다음 쓰기가 덮어쓴다.
+// - 새 파일은 두 슬롯을 모두 써서 만든다. version 2 파일은 예전처럼 헤더 하나를 제자리에 쓴다.
+
+// 헤더 버전
+// - 2: 오프셋 0 의 헤더 하나 (HEADER_SIZE)
+// - 3: linkedlist 의 OffsetStore 가 같은 Magic 으로 쓰는 번호라서 건너뛴다
+// - 4: shadow 헤더 슬롯 두 개. 새 파일은 항상 이 버전으로 만든다.
+const legacyVersion uint16 = 2
+const currentVersion uint16 = 4
+
+// 헤더 슬롯 하나의 크기와 슬롯 두 개가 차지하는 헤더 영역의 크기
+const HEADER_SLOT_SIZE = 48 // HEADER_SIZE + 8 + 4 + 4
+const SHADOW_HEADER_SIZE = 2 * HEADER_SLOT_SIZE
+
+// 두 슬롯이 모두 깨져서 쓸 헤더가 없을 때
+var ErrCorruptHeader = dberr.New(dberr.ErrCorruptPage, "corrupt header: no valid header slot")
+
+// Magic 은 맞지만 version 2 / 4 가 아닐 때. 같은 Magic 을 쓰는 OffsetStore 파일(version 1 / 3)을 열었을 가능성이 크다.
+var ErrUnsupportedVersion = dberr.New(dberr.ErrInvalidMagic, "unsupported header version")
+
+// 첫 페이지가 시작하는 오프셋
+func (h *Header) headerSize() int64 {
+	if h.Version == currentVersion {
+		return SHADOW_HEADER_SIZE
+	}
+	return HEADER_SIZE
+}
+
+// encodeHeader 는 Seq / CRC 를 뺀 헤더 필드(HEADER_SIZE 바이트)를 쓴다.
+func encodeHeader(w *codec.Writer, h *Header) {
+	w.Bytes(h.Magic[:])
+	w.Uint16(h.Version)
+	w.Uint16(h.PageSize)
+	w.Uint32(h.PageCount)
+	w.Uint32(h.HeadPage)
+	w.Uint16(h.HeadSlot)
+	w.Uint32(h.TailPage)
+	w.Uint16(h.TailSlot)
+	w.Uint64(h.Size)
+}
+
+func decodeHeader(r *codec.Reader, h *Header) {
+	r.Bytes(h.Magic[:])
+	h.Version = r.Uint16()
+	h.PageSize = r.Uint16()
+	h.PageCount = r.Uint32()
+	h.HeadPage = r.Uint32()
+	h.HeadSlot = r.Uint16()
+	h.TailPage = r.Uint32()
+	h.TailSlot = r.Uint16()
+	h.Size = r.Uint64()
+}
+
+// headerSlot 은 version 4 헤더 슬롯 하나를 읽은 결과. ok 는 Magic / Version / CRC 가 모두 맞을 때만 true 다.
+type headerSlot struct {
+	header Header
+	ok     bool
+}
+
+func decodeSlot(buf []byte) headerSlot {
+	var s headerSlot
+	r := codec.NewReader(buf)
+	decodeHeader(&r, &s.header)
+	s.header.Seq = r.Uint64()
+	// CRC 는 헤더 필드와 Seq 를 덮는다.
+	sum := crc32.ChecksumIEEE(buf[:r.Len()])
+	s.ok = s.header.Magic == Magic && s.header.Version == currentVersion && sum == r.Uint32()
+	return s
+}
+
+// newest 는 올바른 슬롯 중 Seq 가 큰 쪽의 번호. 둘 다 깨졌으면 -1 이다.
+func newest(slots [2]headerSlot) int {
+	best := -1
+	for i, s := range slots {
+		if s.ok && (best < 0 || s.header.Seq > slots[best].header.Seq) {
+			best = i
+		}
+	}
+	return best
+}
+
+// writeHeader 는 h 를 파일에 쓴다. version 4 면 Seq 를 올려서 직전과 다른 슬롯에 쓴다.
+func writeHeader(f File, h *Header) error {
+	if h.Version != currentVersion {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		buf := make([]byte, HEADER_SIZE)
+		w := codec.NewWriter(buf)
+		encodeHeader(&w, h)
+		_, err := f.Write(buf)
+		return err
+	}
+
+	h.Seq++
+	buf := make([]byte, HEADER_SLOT_SIZE) // 여백은 0
+	w := codec.NewWriter(buf)
+	encodeHeader(&w, h)
+	w.Uint64(h.Seq)
+	w.Uint32(crc32.ChecksumIEEE(buf[:w.Len()]))
+
+	_, err := f.Seek(int64(h.Seq%2)*HEADER_SLOT_SIZE, io.SeekStart)
+	if err == nil {
+		_, err = f.Write(buf)
+	}
+	if err != nil {
+		// 쓰지 못한 슬롯은 다음 쓰기가 다시 고르도록 Seq 를 되돌린다.
+		// 그냥 두면 다음 쓰기가 지금 온전한 직전 헤더의 슬롯을 덮어쓴다.
+		h.Seq--
+	}
+	return err
+}
+
+// createHeader 는 새 파일의 헤더를 쓴다. version 4 면 두 슬롯을 모두 채운다.
+func createHeader(f File, h *Header) error {
+	if err := writeHeader(f, h); err != nil {
+		return err
+	}
+	if h.Version != currentVersion {
+		return nil
+	}
+	return writeHeader(f, h)
+}
+
+// readHeader 는 파일의 헤더를 읽는다. version 4 면 올바른 슬롯 중 가장 최근 것을 고른다.
+// Magic 이 맞지 않으면 ErrInvalidMagic, 두 슬롯이 모두 깨졌으면 ErrCorruptHeader 이고, 어느 쪽이든 h.Magic 에는 오프셋 0 의 4 바이트가 남는다.
+// Magic 은 맞는데 version 2 / 4 가 아니면 ErrUnsupportedVersion 이고 h.Version 에 읽은 번호가 남는다.
+func readHeader(f File, h *Header) error {
+	buf := make([]byte, SHADOW_HEADER_SIZE)
+	n, err := f.ReadAt(buf, 0)
+	if n < HEADER_SIZE {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	// version 2 로 읽은 모양. 버전을 정하기 전에는 Magic 만 믿는다.
+	var legacy Header
+	r := codec.NewReader(buf[:HEADER_SIZE])
+	decodeHeader(&r, &legacy)
+	h.Magic = legacy.Magic
+
+	// 슬롯 0 이 찢어졌으면 Magic / Version 부터 틀릴 수 있으므로 Version 을 믿기 전에 슬롯부터 본다.
+	// CRC 가 맞는 슬롯이 있으면 version 4 다.
+	var slots [2]headerSlot
+	if n == SHADOW_HEADER_SIZE {
+		slots = readSlots(buf)
+		if best := newest(slots); best >= 0 {
+			*h = slots[best].header
+			return nil
+		}
+	}
+
+	// 슬롯 1 의 Magic / Version 이 온전하면 두 슬롯이 모두 깨진 version 4 파일이다.
+	// version 2 파일에서 그 자리는 페이지 0 의 슬롯이라 우연히 맞을 일은 없다고 본다.
+	switch {
+	case slots[1].header.Magic == Magic && slots[1].header.Version == currentVersion:
+		return ErrCorruptHeader
+	case h.Magic == Magic && legacy.Version == legacyVersion:
+		*h = legacy
+		return nil
+	case h.Magic == Magic && legacy.Version != currentVersion:
+		// 다른 포맷의 필드를 이 포맷으로 읽으면 엉뚱한 PageCount / Head 가 나오므로 더 해석하지 않는다.
+		h.Version = legacy.Version
+		return fmt.Errorf("%w: %d", ErrUnsupportedVersion, legacy.Version)
+	case h.Magic != Magic && slots[1].header.Magic != Magic:
+		return ErrInvalidMagic
+	default:
+		return ErrCorruptHeader
+	}
+}
+
+func readSlots(buf []byte) [2]headerSlot {
+	return [2]headerSlot{decodeSlot(buf[:HEADER_SLOT_SIZE]), decodeSlot(buf[HEADER_SLOT_SIZE:SHADOW_HEADER_SIZE])}
+}
@@ -0,0 +1,193 @@
+package pagedlist
+
+import (
+	"cmp"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/tmdgusya/btree/iometrics"
+)
+
+// ==================================
+// 페이지 접근 히트맵 (`GET /api/heatmap?last=N`)
+// ==================================
+// 서버는 파일의 CountingFile 에 Observe 를 걸어 I/O 트레이스를 받는다. 읽기 / 쓰기가 건드린 바이트 범위를 페이지로 바꿔
+// API 연산(append / delete / values / 관리 작업) 하나마다 페이지별 읽기 / 쓰기 수를 모으고, 최근 heatmapWindow 개를 둔다.
+// GET /api/heatmap?last=N 은 그중 마지막 N 개 연산을 페이지마다 더해서 돌려준다.
+// 헤더 영역은 Page -1 로 센다. append 는 헤더와 꼬리 페이지만, delete / values 는 체인 전체를 건드리므로
+// 헤더 / 꼬리는 늘 뜨겁고 나머지 페이지는 순회하는 연산이 있을 때만 뜨겁다.
+// - 페이지 버퍼에서 찾아 파일을 읽지 않은 접근은 트레이스에 없으므로 세지 않는다.
+// - append / delete 응답에 붙는 값 목록을 읽는 I/O 는 연산에 넣지 않는다. 화면이 부르는 것이지 연산이 아니다.
+// - Seek / Sync 는 페이지를 건드리지 않으므로 뺀다.
+
+// 기억하는 최근 연산 수
+const heatmapWindow = 1024
+
+// 헤더 영역을 나타내는 페이지 번호
+const headerPage = -1
+
+// pageCount 는 연산 하나가 페이지 하나를 읽고 쓴 횟수
+type pageCount struct {
+	reads, writes int
+}
+
+// pageAccessLog 는 최근 연산의 페이지별 접근 수를 담는 고리 버퍼
+type pageAccessLog struct {
+	headerSize int64 // 파일을 여는 동안 바뀌지 않는다
+	pageSize   int64
+
+	mu      sync.Mutex
+	current map[int64]*pageCount // begin 과 end 사이에 모으는 중인 연산. nil 이면 세지 않는다.
+	ops     []map[int64]pageCount
+	next    int // 다음에 덮어쓸 자리 (가득 찬 뒤에만 쓴다)
+}
+
+func newPageAccessLog(h *Header) *pageAccessLog {
+	return &pageAccessLog{headerSize: h.headerSize(), pageSize: int64(h.PageSize)}
+}
+
+// begin 은 연산 하나의 접근을 모으기 시작하고, 끝낼 때 부를 함수를 돌려준다. 연산은 서버의 mu 로 직렬화되어 있다.
+func (l *pageAccessLog) begin() (end func()) {
+	l.mu.Lock()
+	l.current = make(map[int64]*pageCount)
+	l.mu.Unlock()
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		op := make(map[int64]pageCount, len(l.current))
+		for page, c := range l.current {
+			op[page] = *c
+		}
+		l.current = nil
+		if len(l.ops) < heatmapWindow {
+			l.ops = append(l.ops, op)
+			return
+		}
+		l.ops[l.next] = op
+		l.next = (l.next + 1) % heatmapWindow
+	}
+}
+
+// record 는 CountingFile.Observe 에 거는 함수. [Offset, Offset+Len) 이 걸친 페이지마다 한 번씩 센다.
+func (l *pageAccessLog) record(a iometrics.Access) {
+	if (a.Op != iometrics.OpRead && a.Op != iometrics.OpWrite) || a.Len <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.current == nil {
+		return
+	}
+	for page := l.page(a.Offset); page <= l.page(a.Offset+int64(a.Len)-1); page++ {
+		c := l.current[page]
+		if c == nil {
+			c = &pageCount{}
+			l.current[page] = c
+		}
+		if a.Op == iometrics.OpRead {
+			c.reads++
+		} else {
+			c.writes++
+		}
+	}
+}
+
+// page 는 파일 오프셋이 든 페이지. 헤더 영역이면 headerPage.
+func (l *pageAccessLog) page(off int64) int64 {
+	if off < l.headerSize {
+		return headerPage
+	}
+	return (off - l.headerSize) / l.pageSize
+}
+
+// PageHeat 는 페이지 하나의 접근 수. Kind 는 header / head / tail / data 이다 (head / tail 은 지금 헤더 기준).
+type PageHeat struct {
+	Page   int64  `json:"page"`
+	Kind   string `json:"kind"`
+	Reads  int    `json:"reads"`
+	Writes int    `json:"writes"`
+	Count  int    `json:"count"`
+}
+
+// PageHeatmap 은 최근 Operations 개 연산의 페이지별 접근 수. Pages 는 페이지 번호 순이고 (헤더가 맨 앞),
+// Max 는 Count 중 가장 큰 값이다. 한 번도 건드리지 않은 페이지는 빠진다.
+type PageHeatmap struct {
+	Operations int        `json:"operations"`
+	Window     int        `json:"window"`
+	Max        int        `json:"max"`
+	Pages      []PageHeat `json:"pages"`
+}
+
+// heatmap 은 마지막 last 개 (0 이하이거나 기록보다 많으면 전부) 연산으로 히트맵을 만든다.
+func (l *pageAccessLog) heatmap(last int, h *Header) PageHeatmap {
+	l.mu.Lock()
+	// 오래된 것부터 차례로: 가득 찼으면 next 부터 한 바퀴
+	ordered := append(slices.Clone(l.ops[l.next:]), l.ops[:l.next]...)
+	l.mu.Unlock()
+
+	if last > 0 && last < len(ordered) {
+		ordered = ordered[len(ordered)-last:]
+	}
+	sums := make(map[int64]pageCount)
+	for _, op := range ordered {
+		for page, c := range op {
+			s := sums[page]
+			s.reads += c.reads
+			s.writes += c.writes
+			sums[page] = s
+		}
+	}
+	hm := PageHeatmap{Operations: len(ordered), Window: heatmapWindow, Pages: make([]PageHeat, 0, len(sums))}
+	for page, c := range sums {
+		p := PageHeat{Page: page, Kind: pageKind(page, h), Reads: c.reads, Writes: c.writes, Count: c.reads + c.writes}
+		hm.Pages = append(hm.Pages, p)
+		hm.Max = max(hm.Max, p.Count)
+	}
+	slices.SortFunc(hm.Pages, func(a, b PageHeat) int { return cmp.Compare(a.Page, b.Page) })
+	return hm
+}
+
+func pageKind(page int64, h *Header) string {
+	switch {
+	case page == headerPage:
+		return "header"
+	case h == nil || h.PageCount == 0:
+		return "data"
+	case page == int64(h.HeadPage):
+		return "head"
+	case page == int64(h.TailPage):
+		return "tail"
+	}
+	return "data"
+}
+
+// handleHeatmap 은 GET /api/heatmap?last=N 으로 최근 N 개 연산의 페이지별 접근 수를 돌려준다 (기본은 기억하는 전부).
+func (s *listServer) handleHeatmap(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	last := 0
+	if v := r.URL.Query().Get("last"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "last must be a positive integer")
+			return
+		}
+		last = n
+	}
+	s.mu.Lock()
+	h, err := ensurePagedHeader(s.handle)
+	var snapshot Header
+	if err == nil {
+		snapshot = *h
+	}
+	s.mu.Unlock()
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, s.heat.heatmap(last, &snapshot))
+}
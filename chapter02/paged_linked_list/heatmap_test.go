@@ -0,0 +1,96 @@
+package pagedlist
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tmdgusya/btree/iometrics"
+)
+
+// 히트맵은 I/O 트레이스에서 나온다. append 는 헤더와 꼬리 페이지에만 쓰고, 순회는 모든 페이지를 읽는다.
+// (append 가 빈 슬롯을 찾느라 앞 페이지를 읽을 수는 있다.)
+func TestPageHeatmap(t *testing.T) {
+	raw, err := os.Create(filepath.Join(t.TempDir(), "heat.llst"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cf := iometrics.NewCountingFile(raw)
+	store := &PagedStore{}
+	handle, err := store.OpenFile(cf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close(handle)
+	values := make([]uint32, 1000)
+	for i := range values {
+		values[i] = uint32(i)
+	}
+	if err := store.AppendValues(handle, values); err != nil {
+		t.Fatal(err)
+	}
+	h, _ := ensurePagedHeader(handle)
+	if h.PageCount < 3 {
+		t.Fatalf("setup: %d pages, want at least 3", h.PageCount)
+	}
+
+	heat := newPageAccessLog(h)
+	defer cf.Observe(heat.record)()
+
+	// begin / end 밖의 I/O 는 세지 않는다.
+	if _, err := store.TraverseValues(handle); err != nil {
+		t.Fatal(err)
+	}
+	if hm := heat.heatmap(0, h); hm.Operations != 0 || len(hm.Pages) != 0 {
+		t.Fatalf("I/O outside an operation was counted: %+v", hm)
+	}
+
+	for i := 0; i < 5; i++ {
+		done := heat.begin()
+		if err := store.AppendTail(handle, uint32(2000+i)); err != nil {
+			t.Fatal(err)
+		}
+		done()
+	}
+	hm := heat.heatmap(0, h)
+	if hm.Operations != 5 {
+		t.Fatalf("operations = %d, want 5", hm.Operations)
+	}
+	written := map[string]bool{}
+	for _, p := range hm.Pages {
+		if p.Writes == 0 {
+			continue
+		}
+		if p.Kind != "header" && p.Kind != "tail" {
+			t.Fatalf("append wrote %s page %d: %+v", p.Kind, p.Page, hm.Pages)
+		}
+		written[p.Kind] = true
+	}
+	if !written["header"] || !written["tail"] {
+		t.Fatalf("append did not write both the header and the tail page: %+v", hm.Pages)
+	}
+
+	done := heat.begin()
+	if _, err := store.TraverseValues(handle); err != nil {
+		t.Fatal(err)
+	}
+	done()
+	last := heat.heatmap(1, h)
+	if last.Operations != 1 {
+		t.Fatalf("last=1 covers %d operations", last.Operations)
+	}
+	read := map[int64]bool{}
+	for _, p := range last.Pages {
+		if p.Reads > 0 {
+			read[p.Page] = true
+		}
+	}
+	for page := int64(0); page < int64(h.PageCount); page++ {
+		if !read[page] {
+			t.Fatalf("traversal did not read page %d: %+v", page, last.Pages)
+		}
+	}
+	if all := heat.heatmap(0, h); all.Operations != 6 || all.Max < last.Max {
+		t.Fatalf("whole window: %d operations, max %d", all.Operations, all.Max)
+	}
+}
@@ -0,0 +1,92 @@
+package pagedlist
+
+import "fmt"
+
+// ==================================
+// 페이지 단위 순회
+// ==================================
+// PagesIterator 는 페이지를 0 번부터 한 장씩 통째로 읽어서, 그 페이지의 살아 있는 슬롯을 한 번에 돌려준다.
+// 체인 순서가 아니라 파일 순서이고, 슬롯마다 따로 읽지 않으므로 페이지 하나에 읽기 한 번이다.
+// 통계, compaction, 히트맵처럼 페이지별로 모아서 보는 도구가 쓴다.
+//
+//	it := store.PagesIterator(handle)
+//	defer it.Close()
+//	for it.Next() {
+//		fmt.Println(it.Page(), it.Used(), len(it.Nodes()))
+//	}
+//	if err := it.Err(); err != nil { ... }
+
+// LiveNode 는 페이지 안의 살아 있는 슬롯 하나
+type LiveNode struct {
+	Slot uint16
+	Node
+}
+
+// PageIterator 는 PagesIterator 가 돌려주는 순회 상태. 고루틴 사이에 나눠 쓰지 않는다.
+type PageIterator struct {
+	f     File
+	h     *Header
+	pb    PageBuffer
+	next  uint32
+	page  uint32
+	used  uint16
+	nodes []LiveNode
+	err   error
+}
+
+// PagesIterator 는 handle 의 페이지를 처음부터 도는 반복자. 다 쓰면 Close 로 페이지 버퍼를 돌려준다.
+// 도는 동안 리스트를 바꾸면 안 된다. 페이지 수는 만들 때의 헤더 기준이다.
+func (s *PagedStore) PagesIterator(handle *Handle) *PageIterator {
+	h, err := ensurePagedHeader(handle)
+	if err != nil {
+		return &PageIterator{err: err}
+	}
+	snapshot := *h
+	return &PageIterator{f: handle.File, h: &snapshot}
+}
+
+// Next 는 다음 페이지로 넘어간다. 페이지가 없거나 에러가 났으면 false 다.
+func (it *PageIterator) Next() bool {
+	if it.err != nil || it.h == nil || it.next >= it.h.PageCount {
+		return false
+	}
+	pageID := it.next
+	if err := it.pb.loadPage(it.f, it.h, pageID); err != nil {
+		it.err = err
+		return false
+	}
+	used := parsePageHeader(it.pb.data).Used
+	if int(used) > slotsPerPage(it.h.PageSize) {
+		it.err = fmt.Errorf("page %d used %d: %w", pageID, used, ErrInvalidSlot)
+		return false
+	}
+
+	it.nodes = it.nodes[:0]
+	for slot := uint16(0); slot < used; slot++ {
+		off := PAGE_HEADER_SIZE + SLOT_SIZE*int(slot)
+		if node := parseSlot(it.pb.data[off : off+SLOT_SIZE]); node.Tomb == 0 {
+			it.nodes = append(it.nodes, LiveNode{Slot: slot, Node: node})
+		}
+	}
+	it.page, it.used = pageID, used
+	it.next++
+	return true
+}
+
+// Page 는 지금 페이지의 번호
+func (it *PageIterator) Page() uint32 { return it.page }
+
+// Used 는 지금 페이지에서 쓴 슬롯 수 (살아 있는 슬롯 + tombstone)
+func (it *PageIterator) Used() uint16 { return it.used }
+
+// Nodes 는 지금 페이지의 살아 있는 슬롯을 슬롯 순서대로. 다음 Next 가 덮어쓰므로 남겨 두려면 복사한다.
+func (it *PageIterator) Nodes() []LiveNode { return it.nodes }
+
+// Err 는 순회를 멈추게 한 에러. 끝까지 돌았으면 nil 이다.
+func (it *PageIterator) Err() error { return it.err }
+
+// Close 는 페이지 버퍼를 돌려준다. 두 번 불러도 된다.
+func (it *PageIterator) Close() {
+	it.pb.release()
+	it.h = nil
+}
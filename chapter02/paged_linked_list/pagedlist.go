@@ -0,0 +1,2030 @@
+// Package pagedlist 는 chapter02 의 페이지 기반 디스크 연결 리스트. 노드를 (page, slot) 으로 가리키고 페이지 단위로 읽는다.
+package pagedlist
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/tmdgusya/btree/bufpool"
+	"github.com/tmdgusya/btree/codec"
+	"github.com/tmdgusya/btree/dberr"
+	"github.com/tmdgusya/btree/iometrics"
+)
+
+var Magic = [4]byte{'L', 'L', 'S', 'T'}
+var Endian = codec.Endian
+var ErrInvalidMagic = dberr.New(dberr.ErrInvalidMagic, "Invalid file: magic mismatch")
+var ErrInvalidPageSize = errors.New("invalid page size")
+
+// 손상된 파일의 NextSlot 등이 페이지 밖을 가리키면 옆 페이지를 잘못 읽거나 패닉하는 대신 이 에러를 돌려준다.
+var ErrInvalidSlot = dberr.New(dberr.ErrCorruptPage, "slot index out of page range")
+
+// 기본 페이지 크기. 실제 크기는 파일 헤더의 PageSize 에 기록되고 열 때 검증한다.
+const PAGE_SIZE = 4096
+
+// 허용하는 페이지 크기 범위 (2의 거듭제곱)
+// PageSize 필드가 uint16 이므로 최대 32 KB
+const MIN_PAGE_SIZE = 512
+const MAX_PAGE_SIZE = 32768
+
+// 페이지 헤더 크기 (byte). 여기서는 "Used" 값 하나만 둔다.
+const PAGE_HEADER_SIZE = 2
+
+// 슬롯(노드) 하나가 디스크에 차지하는 크기(byte).
+// - Value: uint32 (4 바이트)
+// - NextPage: uint32 (4 바이트)
+// - NextSlot: uint16 (2 바이트)
+// - Tomb: uint8 (1 바이트)
+// - padding: uint8 (1 바이트)
+const SLOT_SIZE = 12 // 4 + 4 + 2 + 1 + 1
+
+// 한 페이지안에 들어갈 수 있는 Slot 개수
+// 페이지 전체에서 페이지 헤더를 제외한 공간을 슬롯 크기로 나눔
+func slotsPerPage(pageSize uint16) int {
+	return (int(pageSize) - PAGE_HEADER_SIZE) / SLOT_SIZE
+}
+
+// 헤더의 고정 크기(바이트 단위)
+// Magic(4 바이트) + Version(2 바이트) + PageSize(2 바이트) + PageCount(4 바이트)
+// HeadPage(4 바이트) + HeadSlot(2 바이트) + TailPage(4 바이트) + TailSlot(2 바이트) + Size(8 바이트)
+// version 4 파일은 이 뒤에 Seq 와 CRC 를 붙인 슬롯 두 개를 둔다 (header.go).
+const HEADER_SIZE = 32 // 4 + 2 + 2 + 4 + 4 + 2 + 4 + 2 + 8
+
+// 없음(NULL) 을 표기하기 위한 상수 값들
+// uint32 의 모든 비트를 1로 세팅한 값 = 0xFFFFFFFF
+// uint16 의 모든 비트를 1로 세팅한 값 = 0xFFFF
+const NullPage uint32 = ^uint32(0)
+const NullSlot uint16 = ^uint16(0)
+
+// LinkedListStore 인터페이스와 공통 핸들 정의
+type LinkedListStore interface {
+	Open(path string, truncate bool) (*Handle, error)
+	OpenFile(f File) (*Handle, error)
+	AppendTail(h *Handle, value uint32) error
+	PrependHead(h *Handle, value uint32) error
+	DeleteFirstByValue(h *Handle, value uint32) (bool, error)
+	TraverseValues(h *Handle) ([]uint32, error)
+	TraverseValuesPhysical(h *Handle) ([]uint32, error)
+	Where(h *Handle, target uint32) (*Location, error)
+	PeekHead(h *Handle) (uint32, bool, error)
+	PeekTail(h *Handle) (uint32, bool, error)
+	Length(h *Handle) (uint64, error)
+	Backup(h *Handle, dst io.Writer) error
+	Dump(h *Handle, w io.Writer) error
+	Load(h *Handle, r io.Reader) error
+	Check(h *Handle) (*CheckReport, error)
+	Inspect(h *Handle, w io.Writer) error
+	InspectHeader(h *Handle, w io.Writer) error
+	InspectPage(h *Handle, pageID uint32, w io.Writer) error
+	Stats(h *Handle) (*Stats, error)
+	Close(h *Handle) error
+}
+
+type HeaderRecord interface {
+	headerVersion() uint16
+}
+
+// File 은 저장소가 쓰는 파일 연산. *os.File 과 iometrics.CountingFile 이 그대로 만족하므로
+// OpenFile 에 감싼 파일을 넘기면 코드를 고치지 않고 I/O 를 세거나 지연 / 실패를 끼워 넣을 수 있다.
+type File interface {
+	io.ReadWriteSeeker
+	io.ReaderAt
+	io.WriterAt
+	io.Closer
+	Sync() error
+	Truncate(size int64) error
+}
+
+// File 에는 Stat 이 없으므로 끝으로 Seek 해서 크기를 구한다. 스트림 위치가 파일 끝으로 옮겨진다.
+func fileSize(f File) (int64, error) {
+	return f.Seek(0, io.SeekEnd)
+}
+
+// 감싼 파일(CountingFile 등)을 Unwrap 으로 벗겨서 *os.File 을 찾는다.
+// 구멍 뚫기 / 블록 수 조회처럼 OS 에 직접 묻는 기능에만 쓴다.
+func osFile(f any) (*os.File, bool) {
+	for {
+		switch v := f.(type) {
+		case *os.File:
+			return v, true
+		case interface{ Unwrap() iometrics.File }:
+			f = v.Unwrap()
+		default:
+			return nil, false
+		}
+	}
+}
+
+// Handle 은 열린 리스트 파일 하나. 연산은 Seek 로 파일 오프셋을 옮기므로 한 고루틴에서 차례로 부르는 것을 전제로 한다.
+// 예외는 Backup 으로, 다른 고루틴에서 불러도 된다. 파일을 바꾸는 연산과 Backup 은 mu 를 잡으므로
+// 복사하는 동안 변경이 끼어들지 못하고, 사본에는 연산 사이의 상태가 담긴다.
+type Handle struct {
+	File   File
+	Header HeaderRecord
+	mu     sync.Mutex // 쓰기 잠금. 파일을 바꾸는 연산과 Backup 이 잡는다.
+	tail   tailCache
+	vacuum vacuumState
+	audit  auditState
+	free   uint32 // 빈 슬롯이나 tombstone 이 있을 수 있는 가장 낮은 페이지 (freePage 참고)
+}
+
+// tailCache 는 마지막으로 기록한 tail 노드의 복사본
+// AppendTail 이 기존 tail 의 Next 를 고칠 때 디스크에서 다시 읽지 않고 이 값을 쓴다.
+// tail 노드를 바꿀 수 있는 연산(DeleteFirstByValue, Load)은 valid 를 false 로 돌려놓는다.
+type tailCache struct {
+	valid bool
+	page  uint32
+	slot  uint16
+	node  Node
+}
+
+// 캐시가 현재 Header 의 tail 을 가리킬 때만 그 노드를 돌려준다.
+func (h *Handle) cachedTail(page uint32, slot uint16) (Node, bool) {
+	if !h.tail.valid || h.tail.page != page || h.tail.slot != slot {
+		return Node{}, false
+	}
+	return h.tail.node, true
+}
+
+// PageSize 는 새 파일을 만들 때 쓸 페이지 크기 (0 이면 PAGE_SIZE).
+// 기존 파일을 열 때는 헤더에 기록된 값을 쓰고, PageSize 가 지정되어 있다면 서로 같은지 확인한다.
+// AutoVacuum 이 0 보다 크면 삭제 뒤 새 쓰레기 비율이 그 이상일 때 모든 페이지를 정리한다 (vacuum.go).
+// 판단 결과는 OnVacuum 으로 받고, nil 이면 slog 로 찍는다 (정리했으면 Info, 아니면 Debug).
+// Audit 을 켜면 변경 연산마다 헤더의 Size 를 따로 센 값과 맞춰 본다 (audit.go).
+type PagedStore struct {
+	PageSize   uint16
+	AutoVacuum float64
+	OnVacuum   func(VacuumDecision)
+	Audit      bool
+}
+
+func validatePageSize(pageSize uint16) error {
+	if pageSize < MIN_PAGE_SIZE || pageSize > MAX_PAGE_SIZE || pageSize&(pageSize-1) != 0 {
+		return fmt.Errorf("%w: %d (must be a power of two between %d and %d)", ErrInvalidPageSize, pageSize, MIN_PAGE_SIZE, MAX_PAGE_SIZE)
+	}
+	return nil
+}
+
+func (s *PagedStore) pageSize() uint16 {
+	if s.PageSize == 0 {
+		return PAGE_SIZE
+	}
+	return s.PageSize
+}
+
+type Header struct {
+	Magic     [4]byte
+	Version   uint16
+	PageSize  uint16
+	PageCount uint32
+	HeadPage  uint32
+	HeadSlot  uint16
+	TailPage  uint32
+	TailSlot  uint16
+	Size      uint64
+	Seq       uint64 // version 4: 마지막으로 쓴 헤더 슬롯의 순번
+}
+
+func (h *Header) headerVersion() uint16 {
+	return h.Version
+}
+
+// Used - 이 페이지에서 사용중인 슬롯 개수
+type PageHeader struct {
+	Used uint16
+}
+
+type Node struct {
+	Value    uint32
+	NextPage uint32
+	NextSlot uint16
+	Tomb     uint8
+	_pad     uint8
+}
+
+type PageBuffer struct {
+	pageID uint32  // 현재 버퍼가 담고 있는 페이지 ID
+	data   []byte  // len == Header.PageSize
+	valid  bool    // 아직 안 채워졌는지 여부
+	buf    *[]byte // data 를 빌려 온 bufpool 버퍼
+}
+
+// release 는 빌린 페이지 버퍼를 bufpool 에 돌려준다. 순회가 끝나면 defer 로 부른다.
+func (pb *PageBuffer) release() {
+	if pb.buf != nil {
+		bufpool.Put(pb.buf)
+	}
+	*pb = PageBuffer{}
+}
+
+// Location represents a position in the paged linked list
+type Location struct {
+	Page uint32
+	Slot uint16
+}
+
+func ensurePagedHeader(h *Handle) (*Header, error) {
+	header, ok := h.Header.(*Header)
+	if !ok {
+		return nil, fmt.Errorf("paged list handle does not contain a paged header")
+	}
+	return header, nil
+}
+
+func (s *PagedStore) Open(path string, truncate bool) (*Handle, error) {
+	if err := validatePageSize(s.pageSize()); err != nil {
+		return nil, err
+	}
+
+	flags := os.O_RDWR | os.O_CREATE
+	if truncate {
+		flags |= os.O_TRUNC
+	}
+
+	f, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return s.OpenFile(f)
+}
+
+// OpenFile 은 이미 열린 파일 위에 핸들을 만든다. 비어 있으면 새 헤더를 쓴다.
+// 실패하면 f 를 닫는다. 성공하면 f 는 Close 에서 닫힌다.
+func (s *PagedStore) OpenFile(f File) (*Handle, error) {
+	if err := validatePageSize(s.pageSize()); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	size, err := fileSize(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if size == 0 {
+		h := &Header{
+			Magic:     Magic,
+			Version:   currentVersion,
+			PageSize:  s.pageSize(),
+			PageCount: 0,
+			HeadPage:  NullPage,
+			HeadSlot:  NullSlot,
+			TailPage:  NullPage,
+			TailSlot:  NullSlot,
+			Size:      0,
+		}
+
+		if err := createHeader(f, h); err != nil {
+			f.Close()
+			return nil, err
+		}
+
+		return &Handle{File: f, Header: h}, nil
+	}
+
+	header := &Header{}
+	if err := readHeader(f, header); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := validatePageSize(header.PageSize); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if s.PageSize != 0 && s.PageSize != header.PageSize {
+		f.Close()
+		return nil, fmt.Errorf("%w: file uses %d, store configured for %d", ErrInvalidPageSize, header.PageSize, s.PageSize)
+	}
+
+	return &Handle{File: f, Header: header}, nil
+}
+
+// Flush 는 메모리의 헤더를 파일에 쓴다. fsync 는 하지 않는다.
+// 연산마다 헤더를 쓰고 돌아오므로 보통은 같은 바이트를 다시 쓰는 셈이고, 운영체제 캐시까지만 보장한다.
+func (s *PagedStore) Flush(handle *Handle) error {
+	handle.mu.Lock()
+	defer handle.mu.Unlock()
+	return s.flush(handle)
+}
+
+func (s *PagedStore) flush(handle *Handle) error {
+	h, err := ensurePagedHeader(handle)
+	if err != nil {
+		return err
+	}
+	return writeHeader(handle.File, h)
+}
+
+// Checkpoint 는 Flush 한 뒤 fsync 한다. 돌아오면 그때까지의 연산이 모두 디스크에 있다.
+func (s *PagedStore) Checkpoint(handle *Handle) error {
+	handle.mu.Lock()
+	defer handle.mu.Unlock()
+	return s.checkpoint(handle)
+}
+
+func (s *PagedStore) checkpoint(handle *Handle) error {
+	if err := s.flush(handle); err != nil {
+		return err
+	}
+	return handle.File.Sync()
+}
+
+// Backup 은 열려있는 파일의 일관된 사본을 dst 로 복사한다.
+// - 복사가 끝날 때까지 핸들의 쓰기 잠금을 잡는다. 다른 고루틴의 변경 연산은 그동안 기다리므로 연산 사이의 상태가 복사된다.
+// - 먼저 Checkpoint 한 뒤, 헤더 + PageCount 만큼의 페이지만 복사한다.
+// - ReadAt 기반(SectionReader) 으로 읽으므로 핸들의 파일 오프셋을 건드리지 않는다.
+// dst 가 느리면 그만큼 쓰기가 멈추므로, 네트워크로 보낼 때는 로컬 파일에 먼저 받는 편이 낫다.
+func (s *PagedStore) Backup(handle *Handle, dst io.Writer) error {
+	handle.mu.Lock()
+	defer handle.mu.Unlock()
+	h, err := ensurePagedHeader(handle)
+	if err != nil {
+		return err
+	}
+	if err := s.checkpoint(handle); err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, io.NewSectionReader(handle.File, 0, pageOffset(h, h.PageCount)))
+	return err
+}
+
+func (s *PagedStore) Close(h *Handle) error {
+	return h.File.Close()
+}
+
+// 페이지 슬롯 유틸리티
+// - 헤더 영역(headerSize) 이후에 페이지들이 연속적으로 저장된다고 가정
+// - pageID=0 이면 header 바로 뒤에 오는 첫 페이지
+func pageOffset(h *Header, pageID uint32) int64 {
+	return h.headerSize() + int64(pageID)*int64(h.PageSize)
+}
+
+// 새로운 빈 페이지를 파일에 생성
+// - PageHeader(Used = 0) 으로 기록하고 나머지는 0 으로 채움
+func initEmptyPage(f File, h *Header, pageID uint32) error {
+	offset := pageOffset(h, pageID)
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	// 페이지 전체를 0 으로 채운다.
+	bp := bufpool.Get(int(h.PageSize))
+	defer bufpool.Put(bp)
+	buf := *bp
+	clear(buf)
+
+	_, err := f.Write(buf)
+	return err
+}
+
+func readPageHeader(f File, h *Header, pageID uint32) (PageHeader, error) {
+	offset := pageOffset(h, pageID)
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return PageHeader{}, err
+	}
+
+	var buf [PAGE_HEADER_SIZE]byte
+	if _, err := io.ReadFull(f, buf[:]); err != nil {
+		return PageHeader{}, err
+	}
+
+	return parsePageHeader(buf[:]), nil
+}
+
+func writePageHeader(f File, h *Header, pageID uint32, ph PageHeader) error {
+	offset := pageOffset(h, pageID)
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	var buf [PAGE_HEADER_SIZE]byte
+	putPageHeader(buf[:], ph)
+
+	_, err := f.Write(buf[:])
+	return err
+}
+
+// putPageHeader 는 ph 를 페이지 맨 앞 PAGE_HEADER_SIZE 바이트에 인코딩한다.
+func putPageHeader(buf []byte, ph PageHeader) {
+	w := codec.NewWriter(buf)
+	w.Uint16(ph.Used)
+}
+
+// parsePageHeader 는 페이지 맨 앞 PAGE_HEADER_SIZE 바이트를 읽는다.
+func parsePageHeader(buf []byte) PageHeader {
+	r := codec.NewReader(buf)
+	return PageHeader{Used: r.Uint16()}
+}
+
+// 특정 페이지/슬롯 위치에 Node 쓰기
+// - 페이지 내 레이아웃: [PageHeader(2바이트)] [Slot 0] [Slot 1]
+// - 특정 슬롯의 오프셋 = pageOffset + PAGE_HEADER_SIZE + SLOT_SIZE * slotID
+func writeSlot(f File, h *Header, pageID uint32, slotID uint16, node Node) error {
+	offset := pageOffset(h, pageID) + PAGE_HEADER_SIZE + SLOT_SIZE*int64(slotID)
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	var buf [SLOT_SIZE]byte
+	putSlot(buf[:], node)
+
+	_, err := f.Write(buf[:])
+	return err
+}
+
+// putSlot 은 node 를 SLOT_SIZE 바이트 buf 에 인코딩한다.
+func putSlot(buf []byte, node Node) {
+	w := codec.NewWriter(buf)
+	w.Uint32(node.Value)
+	w.Uint32(node.NextPage)
+	w.Uint16(node.NextSlot)
+	w.Uint8(node.Tomb)
+	w.Uint8(node._pad) // 의미없는 패딩값 (0 유지)
+}
+
+// parseSlot 은 SLOT_SIZE 바이트 buf 를 Node 로 읽는다.
+func parseSlot(buf []byte) Node {
+	r := codec.NewReader(buf)
+	return Node{
+		Value:    r.Uint32(),
+		NextPage: r.Uint32(),
+		NextSlot: r.Uint16(),
+		Tomb:     r.Uint8(),
+		_pad:     r.Uint8(),
+	}
+}
+
+func readSlot(f File, h *Header, pageID uint32, slotID uint16) (Node, error) {
+	if int(slotID) >= slotsPerPage(h.PageSize) {
+		return Node{}, fmt.Errorf("page %d slot %d: %w", pageID, slotID, ErrInvalidSlot)
+	}
+	offset := pageOffset(h, pageID) + PAGE_HEADER_SIZE + SLOT_SIZE*int64(slotID)
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return Node{}, err
+	}
+
+	var buf [SLOT_SIZE]byte
+	if _, err := io.ReadFull(f, buf[:]); err != nil {
+		return Node{}, err
+	}
+
+	return parseSlot(buf[:]), nil
+}
+
+// 새 슬롯을 할당하는 함수
+// - Handle.free 부터 페이지를 차례로 보면서 여유 슬롯이나 tombstone 구멍이 있는 첫 페이지를 사용.
+// - 꽉 찬 페이지는 compactPage 로 정리해서 끝쪽 tombstone 을 회수하고 중간 구멍을 재사용한다.
+// - 모든 페이지가 꽉 찼으면 새 페이지를 생성하고 그 페이지의 0번 슬롯을 사용, Header 의 PageCount를 증가시킴
+// 앞쪽 페이지에서 지운 슬롯도 파일을 늘리기 전에 다시 쓰므로, 지우고 넣기를 반복해도 파일이 계속 커지지 않는다.
+func allocateSlot(handle *Handle, h *Header) (pageID uint32, slotIndex uint16, err error) {
+	f := handle.File
+	capacity := slotsPerPage(h.PageSize)
+
+	for pageID = handle.free; pageID < h.PageCount; pageID++ {
+		ph, rerr := readPageHeader(f, h, pageID)
+		if rerr != nil {
+			return 0, 0, rerr
+		}
+		if int(ph.Used) >= capacity {
+			// 슬롯이 꽉 찼더라도 삭제된 슬롯(tombstone) 이 남아있다면
+			// 새 페이지를 만들기 전에 페이지를 정리해서 그 자리를 재사용한다.
+			compacted, holes, cerr := compactPage(f, h, pageID)
+			if cerr != nil {
+				return 0, 0, cerr
+			}
+			if len(holes) > 0 {
+				handle.free = pageID
+				return pageID, holes[0], nil
+			}
+			ph = compacted
+		}
+		if int(ph.Used) < capacity {
+			handle.free = pageID
+			return useSlot(f, h, pageID, ph)
+		}
+	}
+
+	pageID = h.PageCount // 새 페이지 번호
+	if err = initEmptyPage(f, h, pageID); err != nil {
+		return
+	}
+	h.PageCount++
+	handle.free = pageID
+	return useSlot(f, h, pageID, PageHeader{})
+}
+
+// useSlot 은 페이지의 Used 다음 슬롯을 쓴 것으로 표시하고 그 슬롯 번호를 돌려준다.
+func useSlot(f File, h *Header, pageID uint32, ph PageHeader) (uint32, uint16, error) {
+	slotIndex := ph.Used
+	ph.Used++
+	if err := writePageHeader(f, h, pageID, ph); err != nil {
+		return 0, 0, err
+	}
+	return pageID, slotIndex, nil
+}
+
+// freePage 는 pageID 페이지에 빈 자리가 생겼을 때 부른다. allocateSlot 이 그 페이지부터 다시 훑는다.
+// Handle.free 는 파일에 남기지 않는 힌트라서 핸들을 새로 열면 0 에서 시작하고,
+// 첫 할당이 앞쪽의 꽉 찬 페이지를 한 번 훑으면서 다시 맞춰진다.
+func (h *Handle) freePage(pageID uint32) {
+	h.free = min(h.free, pageID)
+}
+
+// 페이지 내 조각 모음 (defragmentation)
+// - 슬롯 ID 는 다른 노드의 Next / Header 의 Head, Tail 이 직접 가리키므로 살아있는 슬롯은 옮기지 않는다.
+// - 페이지 끝쪽에 몰려 있는 tombstone 은 Used 를 줄여서 바로 회수한다.
+// - 중간에 끼어 있는 tombstone 은 리스트에서 이미 끊어진 슬롯이므로 그대로 재사용할 수 있다.
+// 반환값: 정리된 페이지 헤더, 재사용 가능한 중간 슬롯 목록
+func compactPage(f File, h *Header, pageID uint32) (PageHeader, []uint16, error) {
+	var pb PageBuffer
+	defer pb.release()
+	if err := pb.loadPage(f, h, pageID); err != nil {
+		return PageHeader{}, nil, err
+	}
+
+	ph := parsePageHeader(pb.data)
+
+	// 1) 끝쪽 tombstone 잘라내기
+	used := ph.Used
+	for used > 0 {
+		node, err := readSlotWithBuffer(f, &pb, h, pageID, used-1)
+		if err != nil {
+			return PageHeader{}, nil, err
+		}
+		if node.Tomb == 0 {
+			break
+		}
+		used--
+	}
+
+	// 2) 남은 구간에서 중간 tombstone 수집
+	holes := make([]uint16, 0)
+	for slotID := uint16(0); slotID < used; slotID++ {
+		node, err := readSlotWithBuffer(f, &pb, h, pageID, slotID)
+		if err != nil {
+			return PageHeader{}, nil, err
+		}
+		if node.Tomb == 1 {
+			holes = append(holes, slotID)
+		}
+	}
+
+	if used != ph.Used {
+		ph.Used = used
+		if err := writePageHeader(f, h, pageID, ph); err != nil {
+			return PageHeader{}, nil, err
+		}
+	}
+
+	return ph, holes, nil
+}
+
+// Compact 는 pageID 페이지에서 삭제된 슬롯이 남긴 구멍을 정리하고,
+// 슬롯 ID 를 바꾸지 않고 재사용할 수 있는 tombstone 자리 수 (잘라낸 끝쪽 + 남은 중간 구멍)를 돌려준다.
+// allocateSlot 은 Handle.free 부터 모든 페이지의 빈 자리를 쓰므로 이 수만큼은 파일을 늘리지 않고 다시 넣을 수 있다.
+// 앞선 Compact 뒤에 아직 채워지지 않은 구멍은 다시 센다. 삽입이 꽉 찬 페이지를 만나면 allocateSlot 이 자동으로 호출한다.
+func (s *PagedStore) Compact(handle *Handle, pageID uint32) (int, error) {
+	handle.mu.Lock()
+	defer handle.mu.Unlock()
+	return s.compact(handle, pageID)
+}
+
+func (s *PagedStore) compact(handle *Handle, pageID uint32) (int, error) {
+	h, err := ensurePagedHeader(handle)
+	if err != nil {
+		return 0, err
+	}
+	if pageID >= h.PageCount {
+		return 0, fmt.Errorf("page %d out of range (page count %d)", pageID, h.PageCount)
+	}
+
+	before, err := readPageHeader(handle.File, h, pageID)
+	if err != nil {
+		return 0, err
+	}
+
+	after, holes, err := compactPage(handle.File, h, pageID)
+	if err != nil {
+		return 0, err
+	}
+
+	reclaimed := int(before.Used-after.Used) + len(holes)
+	if reclaimed > 0 {
+		handle.freePage(pageID)
+	}
+	return reclaimed, nil
+}
+
+// PunchHoles 는 페이지마다 Used 이후의 빈 영역을 모아서 fallocate 로 디스크 블록을 반납하고,
+// 구멍을 뚫은 바이트 수를 돌려준다. Compact 로 tombstone 을 잘라낸 뒤 호출하면 효과가 크다.
+// - Used 이후 영역은 다시 할당될 때 덮어쓰이므로 0 으로 읽혀도 상관없다.
+// - 완전히 빈 페이지(Used == 0)는 페이지 헤더까지 반납한다. 0 으로 읽히면 Used == 0 과 같다.
+// - 페이지는 헤더 영역만큼 밀려 있어서 파일시스템 블록과 정렬되지 않는다. 페이지 하나씩 뚫으면
+// 블록 전체를 덮는 구간이 없어 아무것도 반납되지 않으므로, 이어지는 빈 영역을 합쳐서 한 번에 뚫는다.
+func (s *PagedStore) PunchHoles(handle *Handle) (int64, error) {
+	handle.mu.Lock()
+	defer handle.mu.Unlock()
+	return s.punchHoles(handle)
+}
+
+func (s *PagedStore) punchHoles(handle *Handle) (int64, error) {
+	h, err := ensurePagedHeader(handle)
+	if err != nil {
+		return 0, err
+	}
+	if !punchHoleSupported {
+		return 0, fmt.Errorf("hole punching is not supported on this platform")
+	}
+
+	var punched int64
+	var runStart, runEnd int64 = -1, -1
+	flush := func() error {
+		if runStart < 0 || runEnd <= runStart {
+			return nil
+		}
+		if err := punchHole(handle.File, runStart, runEnd-runStart); err != nil {
+			return err
+		}
+		punched += runEnd - runStart
+		return nil
+	}
+
+	for pageID := uint32(0); pageID < h.PageCount; pageID++ {
+		ph, err := readPageHeader(handle.File, h, pageID)
+		if err != nil {
+			return punched, err
+		}
+
+		start := pageOffset(h, pageID)
+		if ph.Used > 0 {
+			// 앞 페이지에서 이어지던 빈 영역은 여기서 끊긴다.
+			if err := flush(); err != nil {
+				return punched, err
+			}
+			runStart = -1
+			start += PAGE_HEADER_SIZE + int64(ph.Used)*SLOT_SIZE
+		}
+		end := pageOffset(h, pageID+1)
+
+		if runStart < 0 {
+			runStart = start
+		}
+		runEnd = end
+	}
+	if err := flush(); err != nil {
+		return punched, err
+	}
+	return punched, nil
+}
+
+// Logical - 파일의 논리 크기 (stat 의 size)
+// Physical - 실제로 할당된 디스크 블록 크기. 구멍을 뚫으면 Logical 보다 작아진다.
+type SpaceUsage struct {
+	Logical  int64
+	Physical int64
+}
+
+// *os.File 까지 벗길 수 없는 파일은 블록 할당 정보를 모르므로 Physical 을 Logical 과 같게 둔다.
+func (s *PagedStore) SpaceUsage(handle *Handle) (SpaceUsage, error) {
+	if of, ok := osFile(handle.File); ok {
+		info, err := of.Stat()
+		if err != nil {
+			return SpaceUsage{}, err
+		}
+		return SpaceUsage{Logical: info.Size(), Physical: physicalSize(info)}, nil
+	}
+	size, err := fileSize(handle.File)
+	if err != nil {
+		return SpaceUsage{}, err
+	}
+	return SpaceUsage{Logical: size, Physical: size}, nil
+}
+
+// Stats 는 파일의 공간 사용 현황
+// - UsedSlots: 페이지 헤더의 Used 합계 (살아있는 슬롯 + tombstone)
+// - FreeSlots: 아직 한 번도 쓰지 않은 슬롯 (페이지 용량 - Used)
+// - AvgPageFill: 페이지마다 살아있는 슬롯 / 페이지 용량 의 평균. tombstone 은 빈 공간으로 본다.
+// - GarbageRatio: tombstone / UsedSlots. 자동 vacuum 이 보는 쓰레기의 양
+// - AutoVacuums: 이 핸들에서 자동 vacuum 이 돈 횟수
+type Stats struct {
+	FileSize     int64   `json:"fileSize"`
+	PhysicalSize int64   `json:"physicalSize"`
+	PageSize     uint16  `json:"pageSize"`
+	PageCount    int     `json:"pageCount"`
+	UsedSlots    int     `json:"usedSlots"`
+	FreeSlots    int     `json:"freeSlots"`
+	LiveSlots    int     `json:"liveSlots"`
+	Tombstones   int     `json:"tombstones"`
+	AvgPageFill  float64 `json:"avgPageFill"`
+	GarbageRatio float64 `json:"garbageRatio"`
+	AutoVacuums  int     `json:"autoVacuums"`
+}
+
+// Stats 는 모든 페이지를 한 번씩 읽어서 공간 사용 현황을 모은다.
+func (s *PagedStore) Stats(handle *Handle) (*Stats, error) {
+	h, err := ensurePagedHeader(handle)
+	if err != nil {
+		return nil, err
+	}
+	f := handle.File
+
+	usage, err := s.SpaceUsage(handle)
+	if err != nil {
+		return nil, err
+	}
+
+	st := &Stats{
+		FileSize:     usage.Logical,
+		PhysicalSize: usage.Physical,
+		PageSize:     h.PageSize,
+		PageCount:    int(h.PageCount),
+	}
+	capacity := slotsPerPage(h.PageSize)
+
+	var pb PageBuffer
+	defer pb.release()
+	var fillSum float64
+	for pageID := uint32(0); pageID < h.PageCount; pageID++ {
+		if err := pb.loadPage(f, h, pageID); err != nil {
+			return nil, err
+		}
+		used := int(parsePageHeader(pb.data).Used)
+		if used > capacity {
+			used = capacity
+		}
+
+		live := 0
+		for slotID := 0; slotID < used; slotID++ {
+			node, err := readSlotWithBuffer(f, &pb, h, pageID, uint16(slotID))
+			if err != nil {
+				return nil, err
+			}
+			if node.Tomb == 0 {
+				live++
+			}
+		}
+
+		st.UsedSlots += used
+		st.FreeSlots += capacity - used
+		st.LiveSlots += live
+		st.Tombstones += used - live
+		fillSum += float64(live) / float64(capacity)
+	}
+	if h.PageCount > 0 {
+		st.AvgPageFill = fillSum / float64(h.PageCount)
+	}
+	if st.UsedSlots > 0 {
+		st.GarbageRatio = float64(st.Tombstones) / float64(st.UsedSlots)
+	}
+	st.AutoVacuums = handle.vacuum.runs
+
+	return st, nil
+}
+
+func (s *PagedStore) AppendTail(handle *Handle, value uint32) error {
+	handle.mu.Lock()
+	defer handle.mu.Unlock()
+	if !s.Audit {
+		return s.appendTail(handle, value)
+	}
+	if err := s.beginAudit(handle); err != nil {
+		return err
+	}
+	return s.endAudit(handle, "append", 1, s.appendTail(handle, value))
+}
+
+func (s *PagedStore) appendTail(handle *Handle, value uint32) error {
+	h, err := ensurePagedHeader(handle)
+	if err != nil {
+		return err
+	}
+	f := handle.File
+
+	pageID, slotIndex, err := allocateSlot(handle, h)
+	if err != nil {
+		return err
+	}
+
+	slotOffset := pageOffset(h, pageID) + PAGE_HEADER_SIZE + SLOT_SIZE*int64(slotIndex)
+	if _, err := f.Seek(slotOffset, io.SeekStart); err != nil {
+		return err
+	}
+
+	newNode := &Node{
+		Value:    value,
+		NextPage: NullPage,
+		NextSlot: NullSlot,
+		Tomb:     0,
+		_pad:     0,
+	}
+
+	if err := writeSlot(f, h, pageID, slotIndex, *newNode); err != nil {
+		return err
+	}
+
+	if h.HeadPage == NullPage {
+		h.HeadPage = pageID
+		h.HeadSlot = slotIndex
+		h.TailPage = pageID
+		h.TailSlot = slotIndex
+		h.Size++
+		handle.tail = tailCache{valid: true, page: pageID, slot: slotIndex, node: *newNode}
+		return writeHeader(f, h)
+	}
+
+	tailNode, ok := handle.cachedTail(h.TailPage, h.TailSlot)
+	if !ok {
+		tailNode, err = readSlot(f, h, h.TailPage, h.TailSlot)
+		if err != nil {
+			return err
+		}
+	}
+
+	tailNode.NextPage = pageID
+	tailNode.NextSlot = slotIndex
+	if err := writeSlot(f, h, h.TailPage, h.TailSlot, tailNode); err != nil {
+		return err
+	}
+
+	h.TailPage = pageID
+	h.TailSlot = slotIndex
+	h.Size++
+	handle.tail = tailCache{valid: true, page: pageID, slot: slotIndex, node: *newNode}
+	return writeHeader(f, h)
+}
+
+// AppendValues 는 values 를 순서대로 꼬리에 붙인다. 결과는 AppendTail 을 여러 번 부른 것과 바이트까지 같다.
+// 슬롯을 하나씩 쓰지 않고, 마지막 페이지의 빈 슬롯과 뒤에 새로 붙는 페이지들을 메모리에서 한 번에 만든 뒤
+// 그 구간을 WriteAt 한 번으로 쓴다. 따로 쓰는 것은 구간 밖에 있는 기존 tail 노드와 파일 헤더뿐이다.
+// 마지막 페이지가 꽉 차 있거나 그 앞 페이지에 빈 자리가 있을 수 있으면 (Handle.free 가 마지막 페이지보다 앞)
+// allocateSlot 이 그 자리를 재사용하므로 그 동안은 AppendTail 로 하나씩 넣는다.
+func (s *PagedStore) AppendValues(handle *Handle, values []uint32) error {
+	handle.mu.Lock()
+	defer handle.mu.Unlock()
+	if !s.Audit {
+		return s.appendValues(handle, values)
+	}
+	if err := s.beginAudit(handle); err != nil {
+		return err
+	}
+	return s.endAudit(handle, "append", len(values), s.appendValues(handle, values))
+}
+
+func (s *PagedStore) appendValues(handle *Handle, values []uint32) error {
+	h, err := ensurePagedHeader(handle)
+	if err != nil {
+		return err
+	}
+	f := handle.File
+	perPage := slotsPerPage(h.PageSize)
+
+	for len(values) > 0 {
+		if h.PageCount == 0 {
+			n, err := appendRun(handle, h, 0, nil, values)
+			if err != nil {
+				return err
+			}
+			values = values[n:]
+			continue
+		}
+
+		last := h.PageCount - 1
+		ph, err := readPageHeader(f, h, last)
+		if err != nil {
+			return err
+		}
+		if int(ph.Used) >= perPage || handle.free < last {
+			if err := s.appendTail(handle, values[0]); err != nil {
+				return err
+			}
+			values = values[1:]
+			continue
+		}
+
+		page := make([]byte, h.PageSize)
+		if _, err := f.ReadAt(page, pageOffset(h, last)); err != nil {
+			return err
+		}
+		n, err := appendRun(handle, h, last, page, values)
+		if err != nil {
+			return err
+		}
+		values = values[n:]
+	}
+	return nil
+}
+
+// appendRun 은 first 페이지의 빈 슬롯부터 values 를 채우고, 넘치면 새 페이지를 이어 붙여서 한 번에 쓴다.
+// existing 은 first 페이지의 현재 내용 (새 페이지면 nil). 넣은 값 수를 돌려준다.
+// first 페이지에 tombstone 이 있으면 그 페이지가 찼을 때 AppendTail 이 구멍부터 채우므로 그 페이지까지만 넣는다.
+func appendRun(handle *Handle, h *Header, first uint32, existing []byte, values []uint32) (int, error) {
+	f := handle.File
+	perPage := slotsPerPage(h.PageSize)
+	used := 0
+	if existing != nil {
+		used = int(parsePageHeader(existing).Used)
+		for slot := 0; slot < used; slot++ {
+			off := PAGE_HEADER_SIZE + SLOT_SIZE*slot
+			if parseSlot(existing[off:off+SLOT_SIZE]).Tomb != 0 {
+				values = values[:min(len(values), perPage-used)]
+				break
+			}
+		}
+	}
+
+	pages := 1 + (used+len(values)-1)/perPage
+	image := make([]byte, pages*int(h.PageSize))
+	copy(image, existing)
+
+	locate := func(i int) (uint32, uint16) {
+		n := used + i
+		return first + uint32(n/perPage), uint16(n % perPage)
+	}
+	slotAt := func(page uint32, slot uint16) []byte {
+		off := int(page-first)*int(h.PageSize) + PAGE_HEADER_SIZE + SLOT_SIZE*int(slot)
+		return image[off : off+SLOT_SIZE]
+	}
+	for i, v := range values {
+		node := Node{Value: v, NextPage: NullPage, NextSlot: NullSlot}
+		if i+1 < len(values) {
+			node.NextPage, node.NextSlot = locate(i + 1)
+		}
+		page, slot := locate(i)
+		putSlot(slotAt(page, slot), node)
+		// 이 페이지의 Used 는 지금까지 채운 슬롯 수
+		putPageHeader(image[int(page-first)*int(h.PageSize):], PageHeader{Used: slot + 1})
+	}
+
+	// 기존 tail 의 Next 를 첫 새 노드로. tail 이 first 페이지에 있으면 이미지 안에서 고친다.
+	headPage, headSlot := locate(0)
+	if h.TailPage != NullPage {
+		if h.TailPage == first {
+			buf := slotAt(h.TailPage, h.TailSlot)
+			tailNode := parseSlot(buf)
+			tailNode.NextPage, tailNode.NextSlot = headPage, headSlot
+			putSlot(buf, tailNode)
+		} else {
+			tailNode, ok := handle.cachedTail(h.TailPage, h.TailSlot)
+			if !ok {
+				var err error
+				if tailNode, err = readSlot(f, h, h.TailPage, h.TailSlot); err != nil {
+					return 0, err
+				}
+			}
+			tailNode.NextPage, tailNode.NextSlot = headPage, headSlot
+			if err := writeSlot(f, h, h.TailPage, h.TailSlot, tailNode); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if _, err := f.WriteAt(image, pageOffset(h, first)); err != nil {
+		return 0, err
+	}
+
+	tailPage, tailSlot := locate(len(values) - 1)
+	if h.HeadPage == NullPage {
+		h.HeadPage, h.HeadSlot = headPage, headSlot
+	}
+	h.TailPage, h.TailSlot = tailPage, tailSlot
+	h.PageCount = first + uint32(pages)
+	h.Size += uint64(len(values))
+	handle.tail = tailCache{valid: true, page: tailPage, slot: tailSlot, node: Node{Value: values[len(values)-1], NextPage: NullPage, NextSlot: NullSlot}}
+	return len(values), writeHeader(f, h)
+}
+
+func (s *PagedStore) PrependHead(handle *Handle, value uint32) error {
+	handle.mu.Lock()
+	defer handle.mu.Unlock()
+	if !s.Audit {
+		return s.prependHead(handle, value)
+	}
+	if err := s.beginAudit(handle); err != nil {
+		return err
+	}
+	return s.endAudit(handle, "prepend", 1, s.prependHead(handle, value))
+}
+
+func (s *PagedStore) prependHead(handle *Handle, value uint32) error {
+	h, err := ensurePagedHeader(handle)
+	if err != nil {
+		return err
+	}
+	f := handle.File
+
+	pageID, slotIndex, err := allocateSlot(handle, h)
+	if err != nil {
+		return err
+	}
+
+	newNode := &Node{
+		Value:    value,
+		NextPage: h.HeadPage,
+		NextSlot: h.HeadSlot,
+		Tomb:     0,
+		_pad:     0,
+	}
+
+	if err := writeSlot(f, h, pageID, slotIndex, *newNode); err != nil {
+		return err
+	}
+
+	if h.HeadPage == NullPage {
+		h.TailPage = pageID
+		h.TailSlot = slotIndex
+		handle.tail = tailCache{valid: true, page: pageID, slot: slotIndex, node: *newNode}
+	}
+	h.HeadPage = pageID
+	h.HeadSlot = slotIndex
+	h.Size++
+	return writeHeader(f, h)
+}
+
+func (s *PagedStore) TraverseValues(handle *Handle) ([]uint32, error) {
+	h, err := ensurePagedHeader(handle)
+	if err != nil {
+		return nil, err
+	}
+	f := handle.File
+
+	values := make([]uint32, 0, h.Size)
+
+	page := h.HeadPage
+	slot := h.HeadSlot
+
+	var pb PageBuffer
+	defer pb.release()
+
+	from := fromHeader
+	for page != NullPage && slot != NullSlot {
+		node, err := followLink(f, &pb, h, from, page, slot)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, node.Value)
+		from = Location{Page: page, Slot: slot}
+		page = node.NextPage
+		slot = node.NextSlot
+	}
+
+	return values, nil
+}
+
+func (s *PagedStore) Where(handle *Handle, target uint32) (*Location, error) {
+	h, err := ensurePagedHeader(handle)
+	if err != nil {
+		return nil, err
+	}
+	f := handle.File
+
+	page := h.HeadPage
+	slot := h.HeadSlot
+
+	var pb PageBuffer
+	defer pb.release()
+
+	from := fromHeader
+	for page != NullPage && slot != NullSlot {
+		node, err := followLink(f, &pb, h, from, page, slot)
+		if err != nil {
+			return nil, err
+		}
+
+		if node.Value == target {
+			return &Location{Page: page, Slot: slot}, nil
+		}
+		from = Location{Page: page, Slot: slot}
+		page = node.NextPage
+		slot = node.NextSlot
+	}
+
+	return nil, nil
+}
+
+// PeekHead 는 첫 번째 값. 리스트가 비었으면 ok 가 false 다.
+// 삭제는 노드를 체인에서 끊으므로 head 슬롯 하나만 읽는다. head 가 tombstone 이면 *BrokenLinkError 다.
+func (s *PagedStore) PeekHead(handle *Handle) (value uint32, ok bool, err error) {
+	h, err := ensurePagedHeader(handle)
+	if err != nil {
+		return 0, false, err
+	}
+	if h.HeadPage == NullPage || h.HeadSlot == NullSlot {
+		return 0, false, nil
+	}
+
+	var pb PageBuffer
+	defer pb.release()
+	node, err := followLink(handle.File, &pb, h, fromHeader, h.HeadPage, h.HeadSlot)
+	if err != nil {
+		return 0, false, err
+	}
+	return node.Value, true, nil
+}
+
+// PeekTail 은 마지막 값. tail 슬롯 하나만 읽는다 (tail 캐시가 맞으면 읽지도 않는다).
+// tail 이 갈 수 없는 곳이거나 tombstone 이면 *BrokenLinkError 다.
+func (s *PagedStore) PeekTail(handle *Handle) (value uint32, ok bool, err error) {
+	h, err := ensurePagedHeader(handle)
+	if err != nil {
+		return 0, false, err
+	}
+	if h.TailPage == NullPage || h.TailSlot == NullSlot {
+		return 0, false, nil
+	}
+	if node, cached := handle.cachedTail(h.TailPage, h.TailSlot); cached {
+		return node.Value, true, nil
+	}
+
+	var pb PageBuffer
+	defer pb.release()
+	node, err := followLink(handle.File, &pb, h, fromHeader, h.TailPage, h.TailSlot)
+	if err != nil {
+		return 0, false, err
+	}
+	return node.Value, true, nil
+}
+
+// Length 는 살아 있는 값의 개수. 헤더의 Size 를 그대로 돌려주므로 순회하지 않는다.
+func (s *PagedStore) Length(handle *Handle) (uint64, error) {
+	h, err := ensurePagedHeader(handle)
+	if err != nil {
+		return 0, err
+	}
+	return h.Size, nil
+}
+
+func (s *PagedStore) TraverseValuesPhysical(handle *Handle) ([]uint32, error) {
+	h, err := ensurePagedHeader(handle)
+	if err != nil {
+		return nil, err
+	}
+	f := handle.File
+
+	values := make([]uint32, 0, h.Size)
+
+	for pageID := uint32(0); pageID < h.PageCount; pageID++ {
+		ph, err := readPageHeader(f, h, pageID)
+		if err != nil {
+			return nil, err
+		}
+
+		for slotID := uint16(0); slotID < ph.Used; slotID++ {
+			node, err := readSlot(f, h, pageID, slotID)
+			if err != nil {
+				return nil, err
+			}
+			if node.Tomb == 0 {
+				values = append(values, node.Value)
+			}
+		}
+	}
+
+	return values, nil
+}
+
+// 병렬 물리 순회에서 일꾼 하나가 한 번에 맡는 페이지 수. 채널 / 할당 비용을 페이지 여러 장에 나눠 낸다.
+const parallelChunkPages = 32
+
+// TraverseValuesPhysicalParallel 은 TraverseValuesPhysical 과 같은 결과를 workers 개의 고루틴으로 만든다.
+// 페이지마다 ReadAt 한 번으로 통째로 읽어서 살아 있는 값을 뽑고, 결과는 페이지 순서대로 모은다.
+// - 페이지를 parallelChunkPages 장씩 묶어 일꾼에게 나눠 준다.
+// - 읽기 순서와 상관없이 결과 순서를 지키도록 묶음마다 결과 채널을 만들어 order 채널에 차례로 넣는다.
+// - order 채널의 용량만큼만 앞서 읽으므로 메모리에 올라가는 묶음 결과는 많아야 2*workers 개다.
+// ReadAt 만 쓰므로 핸들의 파일 오프셋을 건드리지 않는다. workers 가 1 이하이면 고루틴 없이 읽는다.
+func (s *PagedStore) TraverseValuesPhysicalParallel(handle *Handle, workers int) ([]uint32, error) {
+	h, err := ensurePagedHeader(handle)
+	if err != nil {
+		return nil, err
+	}
+	f := handle.File
+	values := make([]uint32, 0, h.Size)
+
+	if workers <= 1 {
+		bp := bufpool.Get(int(h.PageSize))
+		defer bufpool.Put(bp)
+		for pageID := uint32(0); pageID < h.PageCount; pageID++ {
+			if values, err = appendPageValues(values, f, *bp, h, pageID); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
+
+	type chunkResult struct {
+		values []uint32
+		err    error
+	}
+	type chunk struct {
+		first, end uint32
+		result     chan chunkResult
+	}
+	jobs := make(chan chunk)
+	order := make(chan chan chunkResult, 2*workers)
+	done := make(chan struct{})
+	defer close(done)
+
+	// 묶음을 일꾼에게 나눠 주면서 같은 순서로 결과 채널을 order 에 넣는다.
+	go func() {
+		defer close(jobs)
+		defer close(order)
+		for first := uint32(0); first < h.PageCount; first += parallelChunkPages {
+			c := chunk{first: first, end: min(first+parallelChunkPages, h.PageCount), result: make(chan chunkResult, 1)}
+			select {
+			case order <- c.result:
+			case <-done:
+				return
+			}
+			select {
+			case jobs <- c:
+			case <-done:
+				return
+			}
+		}
+	}()
+	for range workers {
+		go func() {
+			bp := bufpool.Get(int(h.PageSize))
+			defer bufpool.Put(bp)
+			for c := range jobs {
+				r := chunkResult{values: make([]uint32, 0, int(c.end-c.first)*slotsPerPage(h.PageSize))}
+				for pageID := c.first; pageID < c.end && r.err == nil; pageID++ {
+					r.values, r.err = appendPageValues(r.values, f, *bp, h, pageID)
+				}
+				c.result <- r
+			}
+		}()
+	}
+
+	for ch := range order {
+		r := <-ch
+		if r.err != nil {
+			return nil, r.err
+		}
+		values = append(values, r.values...)
+	}
+	return values, nil
+}
+
+// appendPageValues 는 pageID 페이지를 buf 로 읽어서 tombstone 이 아닌 값을 dst 뒤에 붙인다.
+func appendPageValues(dst []uint32, f File, buf []byte, h *Header, pageID uint32) ([]uint32, error) {
+	if _, err := f.ReadAt(buf, pageOffset(h, pageID)); err != nil {
+		return dst, err
+	}
+	used := int(parsePageHeader(buf).Used)
+	if used > slotsPerPage(h.PageSize) {
+		return dst, fmt.Errorf("page %d used %d: %w", pageID, used, ErrInvalidSlot)
+	}
+	for slot := 0; slot < used; slot++ {
+		off := PAGE_HEADER_SIZE + SLOT_SIZE*slot
+		if node := parseSlot(buf[off : off+SLOT_SIZE]); node.Tomb == 0 {
+			dst = append(dst, node.Value)
+		}
+	}
+	return dst, nil
+}
+
+func (pb *PageBuffer) loadPage(f File, h *Header, pageID uint32) error {
+	offset := pageOffset(h, pageID)
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	if len(pb.data) != int(h.PageSize) {
+		pb.release()
+		pb.buf = bufpool.Get(int(h.PageSize))
+		pb.data = *pb.buf
+	}
+
+	if _, err := io.ReadFull(f, pb.data); err != nil {
+		return err
+	}
+
+	pb.pageID = pageID
+	pb.valid = true
+	return nil
+}
+
+// 페이지 버퍼 적중률을 받아 주는 파일 (iometrics.CountingFile)
+type cacheCounter interface {
+	CacheHit()
+	CacheMiss()
+}
+
+func readSlotWithBuffer(f File, pb *PageBuffer, h *Header, pageID uint32, slotID uint16) (Node, error) {
+	if int(slotID) >= slotsPerPage(h.PageSize) {
+		return Node{}, fmt.Errorf("page %d slot %d: %w", pageID, slotID, ErrInvalidSlot)
+	}
+
+	// 1) 버퍼에 원하는 페이지가 없으면 페이지 전체를 한 번 읽어온다.
+	//    f 가 CountingFile 이면 버퍼에서 바로 꺼낸 것은 hit, 새로 읽은 것은 miss 로 센다.
+	counter, counting := f.(cacheCounter)
+	if !pb.valid || pb.pageID != pageID {
+		if counting {
+			counter.CacheMiss()
+		}
+		if err := pb.loadPage(f, h, pageID); err != nil {
+			return Node{}, err
+		}
+	} else if counting {
+		counter.CacheHit()
+	}
+
+	// 2) 페이지 내에서 이 슬롯이 시작하는 오프셋 계산
+	//    [PageHeader(2바이트)] [Slot0] [Slot1] ...
+	start := PAGE_HEADER_SIZE + int64(SLOT_SIZE)*int64(slotID)
+
+	// 3) buf[start : start+SLOT_SIZE] 부분만 잘라서 파싱
+	return parseSlot(pb.data[start : start+SLOT_SIZE]), nil
+}
+
+func (s *PagedStore) DeleteFirstByValue(handle *Handle, value uint32) (bool, error) {
+	handle.mu.Lock()
+	defer handle.mu.Unlock()
+	if !s.Audit {
+		return s.deleteFirstByValue(handle, value)
+	}
+	if err := s.beginAudit(handle); err != nil {
+		return false, err
+	}
+	removed, err := s.deleteFirstByValue(handle, value)
+	added := 0
+	if removed {
+		added = -1
+	}
+	return removed, s.endAudit(handle, "delete", added, err)
+}
+
+func (s *PagedStore) deleteFirstByValue(handle *Handle, value uint32) (bool, error) {
+	h, err := ensurePagedHeader(handle)
+	if err != nil {
+		return false, err
+	}
+	f := handle.File
+
+	if h.HeadPage == NullPage || h.HeadSlot == NullSlot {
+		return false, nil
+	}
+
+	prevPage := NullPage
+	prevSlot := NullSlot
+	page := h.HeadPage
+	slot := h.HeadSlot
+
+	var pb PageBuffer
+	defer pb.release()
+
+	for page != NullPage && slot != NullSlot {
+		node, err := followLink(f, &pb, h, Location{Page: prevPage, Slot: prevSlot}, page, slot)
+		if err != nil {
+			return false, err
+		}
+
+		if node.Value == value {
+			// 지워지는 노드나 그 앞 노드가 tail 일 수 있으므로 캐시를 버린다.
+			handle.tail = tailCache{}
+
+			node.Tomb = 1
+			handle.freePage(page)
+			if err := writeSlot(f, h, page, slot, node); err != nil {
+				return false, err
+			}
+
+			if prevPage == NullPage {
+				h.HeadPage = node.NextPage
+				h.HeadSlot = node.NextSlot
+				if h.HeadPage == NullPage || h.HeadSlot == NullSlot {
+					h.TailPage = NullPage
+					h.TailSlot = NullSlot
+				}
+			} else {
+				prevNode, err := readSlot(f, h, prevPage, prevSlot)
+				if err != nil {
+					return false, err
+				}
+				prevNode.NextPage = node.NextPage
+				prevNode.NextSlot = node.NextSlot
+				if err := writeSlot(f, h, prevPage, prevSlot, prevNode); err != nil {
+					return false, err
+				}
+
+				if page == h.TailPage && slot == h.TailSlot {
+					h.TailPage = prevPage
+					h.TailSlot = prevSlot
+				}
+			}
+
+			if h.Size > 0 {
+				h.Size--
+			}
+			if err := writeHeader(f, h); err != nil {
+				return false, err
+			}
+			if s.AutoVacuum > 0 {
+				if err := s.maybeVacuum(handle, h); err != nil {
+					return true, err
+				}
+			}
+			return true, nil
+		}
+
+		prevPage = page
+		prevSlot = slot
+		page = node.NextPage
+		slot = node.NextSlot
+	}
+
+	return false, nil
+}
+
+// JSON 내보내기 / 가져오기
+// - 파일을 사람이 읽고 diff 할 수 있도록 헤더와 "물리" 페이지/슬롯을 그대로 옮겨 적는다.
+// - tombstone 슬롯도 포함하므로 Load 하면 같은 Page/Slot 위치에 같은 노드가 복원된다.
+type dumpFile struct {
+	Magic     string     `json:"magic"`
+	Version   uint16     `json:"version"`
+	PageSize  uint16     `json:"pageSize"`
+	PageCount uint32     `json:"pageCount"`
+	HeadPage  uint32     `json:"headPage"`
+	HeadSlot  uint16     `json:"headSlot"`
+	TailPage  uint32     `json:"tailPage"`
+	TailSlot  uint16     `json:"tailSlot"`
+	Size      uint64     `json:"size"`
+	Pages     []dumpPage `json:"pages"`
+}
+
+type dumpPage struct {
+	ID    uint32     `json:"id"`
+	Used  uint16     `json:"used"`
+	Slots []dumpSlot `json:"slots"`
+}
+
+type dumpSlot struct {
+	Value    uint32 `json:"value"`
+	NextPage uint32 `json:"nextPage"`
+	NextSlot uint16 `json:"nextSlot"`
+	Tomb     uint8  `json:"tomb"`
+}
+
+// Dump 는 파일 내용을 JSON 으로 w 에 쓴다.
+func (s *PagedStore) Dump(handle *Handle, w io.Writer) error {
+	h, err := ensurePagedHeader(handle)
+	if err != nil {
+		return err
+	}
+	f := handle.File
+
+	out := dumpFile{
+		Magic:     string(h.Magic[:]),
+		Version:   h.Version,
+		PageSize:  h.PageSize,
+		PageCount: h.PageCount,
+		HeadPage:  h.HeadPage,
+		HeadSlot:  h.HeadSlot,
+		TailPage:  h.TailPage,
+		TailSlot:  h.TailSlot,
+		Size:      h.Size,
+		Pages:     make([]dumpPage, 0, h.PageCount),
+	}
+
+	var pb PageBuffer
+	defer pb.release()
+	for pageID := uint32(0); pageID < h.PageCount; pageID++ {
+		ph, err := readPageHeader(f, h, pageID)
+		if err != nil {
+			return err
+		}
+
+		page := dumpPage{ID: pageID, Used: ph.Used, Slots: make([]dumpSlot, 0, ph.Used)}
+		for slotID := uint16(0); slotID < ph.Used; slotID++ {
+			node, err := readSlotWithBuffer(f, &pb, h, pageID, slotID)
+			if err != nil {
+				return err
+			}
+			page.Slots = append(page.Slots, dumpSlot{
+				Value:    node.Value,
+				NextPage: node.NextPage,
+				NextSlot: node.NextSlot,
+				Tomb:     node.Tomb,
+			})
+		}
+		out.Pages = append(out.Pages, page)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// Load 는 Dump 로 만든 JSON 을 읽어 handle 의 파일을 통째로 다시 만든다.
+// 현재 포맷의 writeHeader / writeSlot 으로 기록하므로 다른 버전에서 만든 덤프도 옮겨올 수 있다.
+func (s *PagedStore) Load(handle *Handle, r io.Reader) error {
+	handle.mu.Lock()
+	defer handle.mu.Unlock()
+	var in dumpFile
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return err
+	}
+	if in.Magic != string(Magic[:]) {
+		return ErrInvalidMagic
+	}
+	if err := validatePageSize(in.PageSize); err != nil {
+		return err
+	}
+	if int(in.PageCount) != len(in.Pages) {
+		return fmt.Errorf("page count mismatch: header %d, pages %d", in.PageCount, len(in.Pages))
+	}
+
+	f := handle.File
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+
+	h := &Header{
+		Magic:     Magic,
+		Version:   currentVersion,
+		PageSize:  in.PageSize,
+		PageCount: in.PageCount,
+		HeadPage:  in.HeadPage,
+		HeadSlot:  in.HeadSlot,
+		TailPage:  in.TailPage,
+		TailSlot:  in.TailSlot,
+		Size:      in.Size,
+	}
+	if err := createHeader(f, h); err != nil {
+		return err
+	}
+
+	for i, page := range in.Pages {
+		if page.ID != uint32(i) {
+			return fmt.Errorf("pages out of order: expected %d, got %d", i, page.ID)
+		}
+		if int(page.Used) != len(page.Slots) || int(page.Used) > slotsPerPage(h.PageSize) {
+			return fmt.Errorf("page %d: invalid used count %d", page.ID, page.Used)
+		}
+		if err := initEmptyPage(f, h, page.ID); err != nil {
+			return err
+		}
+		if err := writePageHeader(f, h, page.ID, PageHeader{Used: page.Used}); err != nil {
+			return err
+		}
+		for slotID, slot := range page.Slots {
+			node := Node{
+				Value:    slot.Value,
+				NextPage: slot.NextPage,
+				NextSlot: slot.NextSlot,
+				Tomb:     slot.Tomb,
+			}
+			if err := writeSlot(f, h, page.ID, uint16(slotID), node); err != nil {
+				return err
+			}
+		}
+	}
+
+	handle.Header = h
+	handle.tail = tailCache{}
+	handle.audit = auditState{}
+	handle.free = 0
+	return nil
+}
+
+// 무결성 검사 (fsck)
+// Problem 하나는 발견된 이상 한 건. Location 이 nil 이면 파일 헤더에 대한 문제다.
+type Problem struct {
+	Location *Location `json:"location,omitempty"`
+	Message  string    `json:"message"`
+}
+
+type CheckReport struct {
+	Pages      int       `json:"pages"`
+	Slots      int       `json:"slots"`
+	Live       int       `json:"live"`
+	Tombstones int       `json:"tombstones"`
+	Reachable  int       `json:"reachable"`
+	Problems   []Problem `json:"problems"`
+}
+
+func (r *CheckReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+func (r *CheckReport) addf(loc *Location, format string, args ...any) {
+	r.Problems = append(r.Problems, Problem{Location: loc, Message: fmt.Sprintf(format, args...)})
+}
+
+// Check 는 디스크에 기록된 내용을 다시 읽어서 다음을 검증한다.
+// - Magic / Version / PageSize / PageCount 와 실제 파일 크기
+// - 페이지 헤더의 Used 범위
+// - head 부터 따라간 Next 포인터가 모두 유효한 범위인지, 순환이 없는지, 끝이 Tail 인지
+// - tombstone 이 체인에 남아 있지 않은지, 살아있는 슬롯이 모두 체인에서 도달 가능한지
+// - Header.Size 와 도달 가능한 노드 수가 같은지
+// 파일을 읽을 수 없는 I/O 에러만 error 로 돌려주고, 포맷 이상은 모두 리포트에 담는다.
+func (s *PagedStore) Check(handle *Handle) (*CheckReport, error) {
+	f := handle.File
+	report := &CheckReport{Problems: make([]Problem, 0)}
+
+	h := &Header{}
+	if err := readHeader(f, h); err != nil {
+		if errors.Is(err, ErrInvalidMagic) {
+			report.addf(nil, "magic mismatch: %q", h.Magic[:])
+			return report, nil
+		}
+		if errors.Is(err, ErrCorruptHeader) {
+			report.addf(nil, "both header slots are damaged")
+			return report, nil
+		}
+		if errors.Is(err, ErrUnsupportedVersion) {
+			// 같은 Magic 을 쓰는 다른 포맷(offset/paged) 이므로 더 해석하지 않는다.
+			report.addf(nil, "unsupported version %d", h.Version)
+			return report, nil
+		}
+		return nil, err
+	}
+
+	if err := validatePageSize(h.PageSize); err != nil {
+		report.addf(nil, "%v", err)
+		return report, nil
+	}
+
+	size, err := fileSize(f)
+	if err != nil {
+		return nil, err
+	}
+	if size < pageOffset(h, h.PageCount) {
+		report.addf(nil, "file is %d bytes but %d pages need %d bytes", size, h.PageCount, pageOffset(h, h.PageCount))
+		return report, nil
+	}
+	if (h.HeadPage == NullPage) != (h.TailPage == NullPage) {
+		report.addf(nil, "head (%d,%d) and tail (%d,%d) disagree on emptiness", h.HeadPage, h.HeadSlot, h.TailPage, h.TailSlot)
+	}
+
+	// 1) 페이지 헤더 + 슬롯 상태 수집
+	used := make([]uint16, h.PageCount)
+	tombs := make(map[Location]uint8)
+	var pb PageBuffer
+	defer pb.release()
+	for pageID := uint32(0); pageID < h.PageCount; pageID++ {
+		ph, err := readPageHeader(f, h, pageID)
+		if err != nil {
+			return nil, err
+		}
+		if int(ph.Used) > slotsPerPage(h.PageSize) {
+			report.addf(&Location{Page: pageID, Slot: NullSlot}, "used %d exceeds %d slots per page", ph.Used, slotsPerPage(h.PageSize))
+			ph.Used = uint16(slotsPerPage(h.PageSize))
+		}
+		used[pageID] = ph.Used
+		report.Pages++
+
+		for slotID := uint16(0); slotID < ph.Used; slotID++ {
+			node, err := readSlotWithBuffer(f, &pb, h, pageID, slotID)
+			if err != nil {
+				return nil, err
+			}
+			report.Slots++
+			loc := Location{Page: pageID, Slot: slotID}
+			switch node.Tomb {
+			case 0:
+				report.Live++
+			case 1:
+				report.Tombstones++
+			default:
+				report.addf(&loc, "invalid tomb marker %d", node.Tomb)
+			}
+			tombs[loc] = node.Tomb
+		}
+	}
+
+	// 2) head 부터 체인 따라가기
+	visited := make(map[Location]bool)
+	page, slot := h.HeadPage, h.HeadSlot
+	last := Location{Page: NullPage, Slot: NullSlot}
+	for page != NullPage && slot != NullSlot {
+		loc := Location{Page: page, Slot: slot}
+		if page >= h.PageCount || slot >= used[page] {
+			report.addf(&last, "next pointer (%d,%d) is out of range", page, slot)
+			break
+		}
+		if visited[loc] {
+			report.addf(&last, "cycle detected: (%d,%d) visited twice", page, slot)
+			break
+		}
+		visited[loc] = true
+
+		if tombs[loc] != 0 {
+			report.addf(&loc, "tombstoned slot is still linked in the chain")
+		} else {
+			report.Reachable++
+		}
+
+		node, err := readSlotWithBuffer(f, &pb, h, page, slot)
+		if err != nil {
+			return nil, err
+		}
+		last = loc
+		page, slot = node.NextPage, node.NextSlot
+	}
+
+	if last.Page != h.TailPage || last.Slot != h.TailSlot {
+		report.addf(nil, "chain ends at (%d,%d) but tail is (%d,%d)", last.Page, last.Slot, h.TailPage, h.TailSlot)
+	}
+	if uint64(report.Reachable) != h.Size {
+		report.addf(nil, "header size %d, reachable live nodes %d", h.Size, report.Reachable)
+	}
+
+	// 3) 체인에 없는 살아있는 슬롯 = 잃어버린 노드
+	for pageID := uint32(0); pageID < h.PageCount; pageID++ {
+		for slotID := uint16(0); slotID < used[pageID]; slotID++ {
+			loc := Location{Page: pageID, Slot: slotID}
+			if tombs[loc] == 0 && !visited[loc] {
+				report.addf(&loc, "live slot is not reachable from head")
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// Inspect 는 파일을 페이지 단위로 풀어서 사람이 읽을 수 있게 w 에 출력한다.
+// - 파일 헤더: 디코딩된 필드 + raw hex
+// - 페이지마다: 페이지 헤더, 슬롯별 Value/Next/Tomb, 사용중인 영역의 raw hex
+// 페이지의 나머지(Used 이후) 는 항상 0 이므로 hex 에서 생략한다.
+func (s *PagedStore) Inspect(handle *Handle, w io.Writer) error {
+	h, err := s.inspectHeader(handle, w)
+	if err != nil {
+		return err
+	}
+
+	var pb PageBuffer
+	defer pb.release()
+	for pageID := uint32(0); pageID < h.PageCount; pageID++ {
+		fmt.Fprintln(w)
+		if err := inspectPage(handle.File, &pb, h, pageID, w); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// InspectHeader 는 Inspect 중 파일 헤더 부분만 출력한다. 디스크에서 다시 읽으므로 변경이 바로 보인다.
+func (s *PagedStore) InspectHeader(handle *Handle, w io.Writer) error {
+	_, err := s.inspectHeader(handle, w)
+	return err
+}
+
+// InspectPage 는 Inspect 중 pageID 한 장만 출력한다.
+func (s *PagedStore) InspectPage(handle *Handle, pageID uint32, w io.Writer) error {
+	h := &Header{}
+	if err := readHeader(handle.File, h); err != nil {
+		return err
+	}
+	if pageID >= h.PageCount {
+		return fmt.Errorf("page %d out of range: file has %d page(s)", pageID, h.PageCount)
+	}
+	var pb PageBuffer
+	defer pb.release()
+	return inspectPage(handle.File, &pb, h, pageID, w)
+}
+
+func (s *PagedStore) inspectHeader(handle *Handle, w io.Writer) (*Header, error) {
+	f := handle.File
+
+	h := &Header{}
+	if err := readHeader(f, h); err != nil {
+		return nil, err
+	}
+
+	raw := make([]byte, h.headerSize())
+	if _, err := f.ReadAt(raw, 0); err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(w, "== header @0 (%d bytes)\n", len(raw))
+	fmt.Fprintf(w, "magic=%s version=%d pageSize=%d pageCount=%d size=%d\n",
+		h.Magic[:], h.Version, h.PageSize, h.PageCount, h.Size)
+	fmt.Fprintf(w, "head=%s tail=%s\n", formatLocation(h.HeadPage, h.HeadSlot), formatLocation(h.TailPage, h.TailSlot))
+	if h.Version == currentVersion {
+		// 어느 슬롯을 골랐는지, 찢어진 슬롯이 있는지
+		slots := readSlots(raw)
+		best := newest(slots)
+		for i, slot := range slots {
+			state := "ok"
+			switch {
+			case !slot.ok:
+				state = "BAD"
+			case i == best:
+				state = "ok, current"
+			}
+			fmt.Fprintf(w, "slot %d @%d seq=%d (%s)\n", i, i*HEADER_SLOT_SIZE, slot.header.Seq, state)
+		}
+	}
+	fmt.Fprint(w, hex.Dump(raw))
+	return h, nil
+}
+
+func inspectPage(f File, pb *PageBuffer, h *Header, pageID uint32, w io.Writer) error {
+	if err := pb.loadPage(f, h, pageID); err != nil {
+		return err
+	}
+	used := parsePageHeader(pb.data).Used
+	if int(used) > slotsPerPage(h.PageSize) {
+		return fmt.Errorf("page %d: used %d exceeds %d slots per page", pageID, used, slotsPerPage(h.PageSize))
+	}
+
+	fmt.Fprintf(w, "== page %d @%d used=%d/%d\n", pageID, pageOffset(h, pageID), used, slotsPerPage(h.PageSize))
+	for slotID := uint16(0); slotID < used; slotID++ {
+		node, err := readSlotWithBuffer(f, pb, h, pageID, slotID)
+		if err != nil {
+			return err
+		}
+		mark := ""
+		if node.Tomb != 0 {
+			mark = " TOMB"
+		}
+		fmt.Fprintf(w, "  slot %4d value=%-10d next=%s%s\n", slotID, node.Value, formatLocation(node.NextPage, node.NextSlot), mark)
+	}
+	fmt.Fprint(w, hex.Dump(pb.data[:PAGE_HEADER_SIZE+int(used)*SLOT_SIZE]))
+	return nil
+}
+
+func formatLocation(page uint32, slot uint16) string {
+	if page == NullPage || slot == NullSlot {
+		return "(null)"
+	}
+	return fmt.Sprintf("(%d,%d)", page, slot)
+}
+
+// RunCommand 는 `btree <command> -store paged <file>` 의 실제 동작. args 는 [command, file].
+// - export  : 파일을 JSON 으로 stdout 에 출력
+// - import  : stdin 의 JSON 으로 파일을 다시 만든다
+// - check   : 무결성 검사 결과를 출력, 문제가 있으면 에러
+// - dump    : 헤더 / 페이지 / 슬롯 / raw hex 를 출력
+// - stats   : 공간 사용 현황을 출력
+// - compact : 모든 페이지를 정리하고 빈 영역에 구멍을 뚫은 뒤 논리 / 물리 크기를 출력
+// - repair  : 끊어진 next 포인터를 고치고 tail / Size 를 맞춘 뒤 한 일을 출력 (repair.go)
+func RunCommand(store LinkedListStore, args []string) error {
+	return RunCommandContext(context.Background(), store, args)
+}
+
+// RunCommandContext 는 ctx 가 취소되면 export / import / compact 를 중간에 멈추는 RunCommand.
+func RunCommandContext(ctx context.Context, store LinkedListStore, args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("usage: <command> <file>")
+	}
+
+	switch args[0] {
+	case "export":
+		handle, err := store.Open(args[1], false)
+		if err != nil {
+			return err
+		}
+		defer store.Close(handle)
+		return store.Dump(handle, ctxWriter{ctx, os.Stdout})
+	case "import":
+		handle, err := store.Open(args[1], true)
+		if err != nil {
+			return err
+		}
+		defer store.Close(handle)
+		return store.Load(handle, ctxReader{ctx, os.Stdin})
+	case "check":
+		handle, err := store.Open(args[1], false)
+		if err != nil {
+			return err
+		}
+		defer store.Close(handle)
+
+		report, err := store.Check(handle)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("pages=%d slots=%d live=%d tombstones=%d reachable=%d\n",
+			report.Pages, report.Slots, report.Live, report.Tombstones, report.Reachable)
+		for _, p := range report.Problems {
+			if p.Location != nil {
+				fmt.Printf("  (%d,%d): %s\n", p.Location.Page, p.Location.Slot, p.Message)
+			} else {
+				fmt.Printf("  header: %s\n", p.Message)
+			}
+		}
+		if !report.OK() {
+			return fmt.Errorf("%d problem(s) found", len(report.Problems))
+		}
+		return nil
+	case "dump":
+		handle, err := store.Open(args[1], false)
+		if err != nil {
+			return err
+		}
+		defer store.Close(handle)
+		return store.Inspect(handle, os.Stdout)
+	case "stats":
+		handle, err := store.Open(args[1], false)
+		if err != nil {
+			return err
+		}
+		defer store.Close(handle)
+
+		st, err := store.Stats(handle)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("file size=%d physical=%d\n", st.FileSize, st.PhysicalSize)
+		fmt.Printf("pages=%d pageSize=%d\n", st.PageCount, st.PageSize)
+		fmt.Printf("slots used=%d free=%d live=%d tombstones=%d\n", st.UsedSlots, st.FreeSlots, st.LiveSlots, st.Tombstones)
+		fmt.Printf("avg page fill=%.1f%%\n", st.AvgPageFill*100)
+		fmt.Printf("garbage=%.1f%%\n", st.GarbageRatio*100)
+		return nil
+	case "compact":
+		paged, ok := store.(*PagedStore)
+		if !ok {
+			return fmt.Errorf("compact is only supported by the paged store")
+		}
+
+		handle, err := paged.Open(args[1], false)
+		if err != nil {
+			return err
+		}
+		defer paged.Close(handle)
+
+		before, err := paged.SpaceUsage(handle)
+		if err != nil {
+			return err
+		}
+		reclaimed, punched, err := compactAll(ctx, paged, handle, true)
+		if err != nil {
+			return err
+		}
+		after, err := paged.SpaceUsage(handle)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("reclaimed slots=%d punched bytes=%d\n", reclaimed, punched)
+		fmt.Printf("before: logical=%d physical=%d\n", before.Logical, before.Physical)
+		fmt.Printf("after : logical=%d physical=%d\n", after.Logical, after.Physical)
+		return nil
+	case "repair":
+		paged, ok := store.(*PagedStore)
+		if !ok {
+			return fmt.Errorf("repair is only supported by the paged store")
+		}
+
+		handle, err := paged.Open(args[1], false)
+		if err != nil {
+			return err
+		}
+		defer paged.Close(handle)
+
+		report, err := paged.Repair(handle)
+		if err != nil {
+			return err
+		}
+		for _, b := range report.Breaks {
+			fmt.Printf("  %v\n", &b)
+		}
+		if !report.Changed() {
+			fmt.Println("chain is intact, nothing to repair")
+			return nil
+		}
+		fmt.Printf("orphaned live slots=%d\n", report.Orphaned)
+		fmt.Printf("tail %s -> %s, size %d -> %d\n",
+			formatLocation(report.OldTail.Page, report.OldTail.Slot), formatLocation(report.NewTail.Page, report.NewTail.Slot),
+			report.OldSize, report.NewSize)
+		return paged.Checkpoint(handle)
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// ctxWriter / ctxReader 는 쓰기 / 읽기 호출마다 ctx 를 보고, 취소됐으면 ctx.Err() 로 멈춘다.
+// Dump / Load 처럼 io.Writer / io.Reader 만 받는 긴 작업을 중간에 끊을 때 쓴다.
+type ctxWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+func (c ctxWriter) Write(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.w.Write(p)
+}
+
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c ctxReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
@@ -0,0 +1,401 @@
+package pagedlist
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// churn 은 가장 오래된 값 n 개를 지우고 새 값 n 개를 꼬리에 붙인다. next 는 다음에 넣을 값.
+func churn(t *testing.T, s *PagedStore, handle *Handle, oldest, next *uint32, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		ok, err := s.DeleteFirstByValue(handle, *oldest)
+		if err != nil {
+			t.Fatalf("delete %d: %v", *oldest, err)
+		}
+		if !ok {
+			t.Fatalf("delete %d: not found", *oldest)
+		}
+		*oldest++
+	}
+	for i := 0; i < n; i++ {
+		if err := s.AppendTail(handle, *next); err != nil {
+			t.Fatalf("append %d: %v", *next, err)
+		}
+		*next++
+	}
+}
+
+func fileSizeOf(t *testing.T, path string) int64 {
+	t.Helper()
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fi.Size()
+}
+
+// checkList 는 파일이 fsck 를 통과하고 값이 oldest..next-1 순서로 남아 있는지 본다.
+func checkList(t *testing.T, s *PagedStore, handle *Handle, oldest, next uint32) {
+	t.Helper()
+	report, err := s.Check(handle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Problems) > 0 {
+		t.Fatalf("check found problems: %+v", report.Problems)
+	}
+	got, err := s.TraverseValues(handle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := make([]uint32, 0, next-oldest)
+	for v := oldest; v < next; v++ {
+		want = append(want, v)
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("values = %d items starting %v, want %d items starting %v", len(got), got[:min(len(got), 3)], len(want), want[:min(len(want), 3)])
+	}
+}
+
+// 앞쪽 페이지에서 지운 슬롯도 다시 써야 하므로, 지우고 넣기를 반복해도 첫 바퀴 뒤로는 파일이 커지지 않는다.
+// 중간에 다시 열어도 (Handle.free 가 0 에서 다시 시작해도) 마찬가지다.
+func TestChurnReusesFreedSlots(t *testing.T) {
+	const live, batch, cycles = 2000, 500, 8
+	path := filepath.Join(t.TempDir(), "churn.db")
+	s := &PagedStore{PageSize: MIN_PAGE_SIZE}
+	handle, err := s.Open(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { s.Close(handle) }()
+
+	var oldest, next uint32
+	for ; next < live; next++ {
+		if err := s.AppendTail(handle, next); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	churn(t, s, handle, &oldest, &next, batch)
+	stable := fileSizeOf(t, path)
+	for cycle := 1; cycle < cycles; cycle++ {
+		if cycle == cycles/2 {
+			if err := s.Close(handle); err != nil {
+				t.Fatal(err)
+			}
+			if handle, err = s.Open(path, false); err != nil {
+				t.Fatal(err)
+			}
+		}
+		churn(t, s, handle, &oldest, &next, batch)
+		if size := fileSizeOf(t, path); size > stable {
+			t.Fatalf("cycle %d: file grew from %d to %d bytes", cycle, stable, size)
+		}
+	}
+	checkList(t, s, handle, oldest, next)
+}
+
+// 자동 vacuum 을 켜도 첫 바퀴 뒤로는 파일이 커지지 않아야 하고,
+// vacuum 이 돌려준 Reclaimed 는 정리할 때 있던 tombstone 을 모두 다시 쓸 수 있는 자리로 센다.
+func TestAutoVacuumKeepsFileSizeStable(t *testing.T) {
+	const live, batch, cycles = 2000, 500, 8
+	path := filepath.Join(t.TempDir(), "vacuum.db")
+	var runs []VacuumDecision
+	s := &PagedStore{
+		PageSize:   MIN_PAGE_SIZE,
+		AutoVacuum: 0.1,
+		OnVacuum: func(d VacuumDecision) {
+			if d.Vacuumed {
+				runs = append(runs, d)
+			}
+		},
+	}
+	handle, err := s.Open(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { s.Close(handle) }()
+
+	var oldest, next uint32
+	for ; next < live; next++ {
+		if err := s.AppendTail(handle, next); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	churn(t, s, handle, &oldest, &next, batch)
+	stable := fileSizeOf(t, path)
+	for cycle := 1; cycle < cycles; cycle++ {
+		churn(t, s, handle, &oldest, &next, batch)
+		if size := fileSizeOf(t, path); size > stable {
+			t.Fatalf("cycle %d: file grew from %d to %d bytes", cycle, stable, size)
+		}
+	}
+	checkList(t, s, handle, oldest, next)
+
+	if len(runs) == 0 {
+		t.Fatal("auto-vacuum never ran")
+	}
+	if first := runs[0]; first.Reclaimed != first.Tombstones {
+		t.Fatalf("first vacuum reclaimed %d slots, want all %d tombstones", first.Reclaimed, first.Tombstones)
+	}
+}
+
+// Backup 은 다른 고루틴이 값을 넣는 동안 불러도 연산 사이의 상태를 복사해야 한다.
+// 사본은 그대로 열려서 fsck 를 통과하고, 그때까지 넣은 값의 앞부분을 순서대로 갖는다.
+// 쓰기가 끝난 뒤의 사본은 원본과 같은 값을 갖는다.
+func TestBackupWhileAppending(t *testing.T) {
+	const total = 3000
+	dir := t.TempDir()
+	s := &PagedStore{PageSize: MIN_PAGE_SIZE}
+	handle, err := s.Open(filepath.Join(dir, "source.db"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { s.Close(handle) }()
+
+	done := make(chan error, 1)
+	go func() {
+		for v := uint32(0); v < total; v++ {
+			if err := s.AppendTail(handle, v); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+
+	// openBackup 은 사본을 파일로 쓰고 열어서 fsck 를 통과하는지 본 뒤 값을 돌려준다.
+	openBackup := func(name string) []uint32 {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		f, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Backup(handle, f); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+		copied, err := s.Open(path, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer s.Close(copied)
+		report, err := s.Check(copied)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(report.Problems) > 0 {
+			t.Fatalf("%s: check found problems: %+v", name, report.Problems)
+		}
+		values, err := s.TraverseValues(copied)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return values
+	}
+
+	for i := 0; i < 5; i++ {
+		values := openBackup(fmt.Sprintf("backup-%d.db", i))
+		for j, v := range values {
+			if v != uint32(j) {
+				t.Fatalf("backup %d: value %d is %d, want a prefix of the appended values", i, j, v)
+			}
+		}
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := s.TraverseValues(handle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := openBackup("final.db"); !slices.Equal(got, want) {
+		t.Fatalf("final backup has %d values, source has %d", len(got), len(want))
+	}
+}
+
+// ==================================
+// shadow 헤더 슬롯
+// ==================================
+
+// newMemStore 는 메모리 파일 위에 값 n 개를 붙인 리스트를 만든다.
+func newMemStore(t *testing.T, n int) (*PagedStore, *Handle, *memFile) {
+	t.Helper()
+	s := &PagedStore{PageSize: MIN_PAGE_SIZE}
+	f := newMemFile(nil)
+	handle, err := s.OpenFile(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for v := uint32(0); v < uint32(n); v++ {
+		if err := s.AppendTail(handle, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s, handle, f
+}
+
+// 최근 슬롯이 찢어지면 다시 열 때 직전 슬롯의 헤더를 쓴다. Seq 는 하나 낮다.
+func TestReopenOnOlderHeaderSlot(t *testing.T) {
+	s, handle, f := newMemStore(t, 100)
+	newer, err := ensurePagedHeader(handle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slots := readSlots(f.data[:SHADOW_HEADER_SIZE])
+	best := newest(slots)
+	if best < 0 || slots[best].header != *newer {
+		t.Fatalf("newest slot %d does not hold the in-memory header %+v", best, *newer)
+	}
+	older := slots[1-best].header
+
+	data := slices.Clone(f.data)
+	data[best*HEADER_SLOT_SIZE+8] ^= 0xff // PageCount 의 한 바이트
+	reopened, err := s.OpenFile(newMemFile(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ensurePagedHeader(reopened)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != older {
+		t.Fatalf("reopened header = %+v, want the older slot %+v", *got, older)
+	}
+	if got.Seq != newer.Seq-1 {
+		t.Fatalf("reopened Seq = %d, want %d", got.Seq, newer.Seq-1)
+	}
+
+	// 다음 쓰기는 찢어진 슬롯을 덮어쓰고 온전한 직전 슬롯은 그대로 둔다.
+	if err := s.AppendTail(reopened, 1000); err != nil {
+		t.Fatal(err)
+	}
+	after := readSlots(reopened.File.(*memFile).data[:SHADOW_HEADER_SIZE])
+	if !after[best].ok || after[best].header.Seq != older.Seq+1 {
+		t.Fatalf("torn slot after the next write = %+v, want Seq %d", after[best], older.Seq+1)
+	}
+	if after[1-best].header != older {
+		t.Fatalf("intact slot was overwritten: %+v", after[1-best].header)
+	}
+}
+
+// 두 슬롯이 모두 찢어진 version 4 파일은 열지 않는다.
+func TestReopenBothHeaderSlotsCorrupt(t *testing.T) {
+	s, _, f := newMemStore(t, 10)
+	data := slices.Clone(f.data)
+	data[8] ^= 0xff
+	data[HEADER_SLOT_SIZE+8] ^= 0xff
+	if _, err := s.OpenFile(newMemFile(data)); !errors.Is(err, ErrCorruptHeader) {
+		t.Fatalf("open = %v, want ErrCorruptHeader", err)
+	}
+}
+
+// version 2 파일은 오프셋 0 의 헤더 하나로 열리고, 쓰고 다시 열어도 version 2 로 남는다.
+func TestLegacyVersion2File(t *testing.T) {
+	golden, err := os.ReadFile(filepath.Join("..", "..", "testdata", "golden", "paged-v2.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &PagedStore{}
+	f := newMemFile(golden)
+	handle, err := s.OpenFile(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := ensurePagedHeader(handle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Version != legacyVersion || h.headerSize() != HEADER_SIZE || h.Seq != 0 {
+		t.Fatalf("header = %+v (header size %d), want a version 2 header", *h, h.headerSize())
+	}
+	values, err := s.TraverseValues(handle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uint64(len(values)) != h.Size {
+		t.Fatalf("traversed %d values, header Size is %d", len(values), h.Size)
+	}
+
+	if err := s.AppendTail(handle, 4242); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := s.OpenFile(newMemFile(f.data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ensurePagedHeader(reopened)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != legacyVersion || got.Seq != 0 {
+		t.Fatalf("reopened header = %+v, want version 2", *got)
+	}
+	after, err := s.TraverseValues(reopened)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := append(values, 4242); !slices.Equal(after, want) {
+		t.Fatalf("values after append = %d items ending %v, want %d items ending 4242", len(after), after[max(len(after)-3, 0):], len(want))
+	}
+}
+
+// tornWriteFile 은 fail 이 켜져 있으면 앞 절반만 쓰고 에러를 내는 파일. 쓰다가 죽은 헤더 쓰기를 흉내 낸다.
+type tornWriteFile struct {
+	*memFile
+	fail bool
+}
+
+func (f *tornWriteFile) Write(p []byte) (int, error) {
+	if f.fail {
+		n, _ := f.memFile.Write(p[:len(p)/2])
+		return n, errors.New("torn write")
+	}
+	return f.memFile.Write(p)
+}
+
+// 헤더 쓰기가 실패하면 Seq 가 되돌아가서, 다음 쓰기도 같은 (찢어진) 슬롯을 고르고 온전한 직전 슬롯은 남는다.
+func TestFailedHeaderWriteRollsBackSeq(t *testing.T) {
+	f := &tornWriteFile{memFile: newMemFile(nil)}
+	h := &Header{Magic: Magic, Version: currentVersion, PageSize: MIN_PAGE_SIZE, HeadPage: NullPage, HeadSlot: NullSlot, TailPage: NullPage, TailSlot: NullSlot}
+	if err := createHeader(f, h); err != nil {
+		t.Fatal(err)
+	}
+	intact := *h
+	intactSlot := int(h.Seq % 2)
+
+	f.fail = true
+	h.Size = 1
+	if err := writeHeader(f, h); err == nil {
+		t.Fatal("torn write did not fail")
+	}
+	if h.Seq != intact.Seq {
+		t.Fatalf("Seq after a failed write = %d, want %d", h.Seq, intact.Seq)
+	}
+	var got Header
+	if err := readHeader(f, &got); err != nil || got != intact {
+		t.Fatalf("after a torn write read %+v, %v, want %+v", got, err, intact)
+	}
+
+	f.fail = false
+	if err := writeHeader(f, h); err != nil {
+		t.Fatal(err)
+	}
+	slots := readSlots(f.data[:SHADOW_HEADER_SIZE])
+	if slots[intactSlot].header != intact {
+		t.Fatalf("retry overwrote the intact slot: %+v", slots[intactSlot].header)
+	}
+	if !slots[1-intactSlot].ok || slots[1-intactSlot].header != *h {
+		t.Fatalf("retried slot = %+v, want %+v", slots[1-intactSlot], *h)
+	}
+}
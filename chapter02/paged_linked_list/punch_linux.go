@@ -0,0 +1,36 @@
+//go:build linux
+
+package pagedlist
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// fallocate(2) 플래그. syscall 패키지에는 상수가 없어서 직접 정의한다.
+const (
+	fallocKeepSize  = 0x01 // FALLOC_FL_KEEP_SIZE
+	fallocPunchHole = 0x02 // FALLOC_FL_PUNCH_HOLE
+)
+
+const punchHoleSupported = true
+
+// [off, off+length) 구간의 디스크 블록을 반납한다. 논리 크기는 그대로이고 읽으면 0 이 나온다.
+// 블록 경계에 걸치지 않는 부분은 파일시스템이 0 으로 채우기만 한다.
+// CountingFile 등으로 감싸 있으면 *os.File 까지 벗겨서 부른다.
+func punchHole(f File, off, length int64) error {
+	of, ok := osFile(f)
+	if !ok {
+		return errors.New("hole punching needs an *os.File")
+	}
+	return syscall.Fallocate(int(of.Fd()), fallocPunchHole|fallocKeepSize, off, length)
+}
+
+// 실제로 디스크 블록이 할당된 크기 (st_blocks 는 512 바이트 단위)
+func physicalSize(info os.FileInfo) int64 {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return st.Blocks * 512
+	}
+	return info.Size()
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+
+package pagedlist
+
+import (
+	"errors"
+	"os"
+)
+
+const punchHoleSupported = false
+
+func punchHole(f File, off, length int64) error {
+	return errors.New("hole punching is not supported on this platform")
+}
+
+// 블록 할당 정보를 알 수 없으면 논리 크기를 그대로 쓴다.
+func physicalSize(info os.FileInfo) int64 {
+	return info.Size()
+}
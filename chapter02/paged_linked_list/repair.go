@@ -0,0 +1,214 @@
+package pagedlist
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tmdgusya/btree/dberr"
+)
+
+// ==================================
+// 끊어진 next 포인터 (read repair)
+// ==================================
+// 체인을 따라가는 연산(TraverseValues, Where, PeekHead, PeekTail, DeleteFirstByValue)은 다음 노드로 넘어가기 전에
+// 포인터가 갈 수 있는 곳인지 본다. 파일 밖의 페이지, 페이지의 Used 밖 슬롯, tombstone 을 가리키면
+// 엉뚱한 슬롯을 값으로 읽는 대신 *BrokenLinkError 로 멈춘다. 어느 노드의 포인터가 어디를 가리켰는지 담겨 있다.
+// Repair 는 체인을 다시 따라가면서 끊어진 곳을 고친다.
+// - 범위 밖 포인터와 순환: 그 앞 노드에서 체인을 잘라 끝으로 만든다. 그 뒤의 노드는 잃는다.
+// - tombstone: 삭제가 tombstone 을 쓰고 앞 노드를 고치기 전에 죽은 흔적이므로, 앞 노드를 tombstone 의 next 로 이어서
+//   그 삭제를 마저 끝낸다.
+// - 그런 다음 tail 을 마지막 노드로, Size 를 도달 가능한 노드 수로 맞추고,
+//   체인에서 떨어져 나간 살아 있는 슬롯은 tombstone 으로 만들어 다음 할당 / compact 가 재사용하게 한다.
+
+var ErrBrokenLink = dberr.New(dberr.ErrCorruptPage, "broken next pointer")
+
+// BrokenLinkError 는 끊어진 포인터 하나. From 이 nil 이면 헤더의 head / tail 이다.
+// errors.Is(err, ErrBrokenLink) 로 분류하고 errors.As 로 위치를 꺼낸다.
+type BrokenLinkError struct {
+	From   *Location `json:"from,omitempty"`
+	To     Location  `json:"to"`
+	Reason string    `json:"reason"`
+}
+
+func (e *BrokenLinkError) Error() string {
+	from := "header"
+	if e.From != nil {
+		from = formatLocation(e.From.Page, e.From.Slot)
+	}
+	return fmt.Sprintf("%v: %s -> %s: %s", ErrBrokenLink, from, formatLocation(e.To.Page, e.To.Slot), e.Reason)
+}
+
+func (e *BrokenLinkError) Unwrap() error { return ErrBrokenLink }
+
+// 헤더에서 출발하는 포인터의 from
+var fromHeader = Location{Page: NullPage, Slot: NullSlot}
+
+// Repair 가 잘라내지 않고 건너뛰는 경우라서 이유를 따로 둔다.
+const reasonTombstone = "target is a tombstone"
+
+func brokenLink(from Location, page uint32, slot uint16, reason string) *BrokenLinkError {
+	e := &BrokenLinkError{To: Location{Page: page, Slot: slot}, Reason: reason}
+	if from != fromHeader {
+		e.From = &from
+	}
+	return e
+}
+
+// followLink 는 from 의 next 포인터 (from 이 fromHeader 면 헤더의 head) 가 가리키는 (page, slot) 의 노드를 읽는다.
+// 갈 수 없는 곳이면 *BrokenLinkError 이고, 파일을 읽지 못하면 그 I/O 에러다.
+func followLink(f File, pb *PageBuffer, h *Header, from Location, page uint32, slot uint16) (Node, error) {
+	if page >= h.PageCount {
+		return Node{}, brokenLink(from, page, slot, fmt.Sprintf("page out of range (page count %d)", h.PageCount))
+	}
+	if int(slot) >= slotsPerPage(h.PageSize) {
+		return Node{}, brokenLink(from, page, slot, fmt.Sprintf("slot out of range (%d slots per page)", slotsPerPage(h.PageSize)))
+	}
+	node, err := readSlotWithBuffer(f, pb, h, page, slot)
+	if err != nil {
+		return Node{}, err
+	}
+	if used := parsePageHeader(pb.data).Used; slot >= used {
+		return Node{}, brokenLink(from, page, slot, fmt.Sprintf("slot past the page's used count %d", used))
+	}
+	if node.Tomb != 0 {
+		return Node{}, brokenLink(from, page, slot, reasonTombstone)
+	}
+	return node, nil
+}
+
+// RepairReport 는 Repair 가 한 일. 고칠 것이 없었으면 Breaks 가 비어 있고 나머지는 전후가 같다.
+type RepairReport struct {
+	Breaks   []BrokenLinkError `json:"breaks"`   // 만난 순서대로. tombstone 은 건너뛰고 계속 따라간다.
+	Orphaned int               `json:"orphaned"` // 체인에서 떨어져 tombstone 으로 만든 살아 있는 슬롯 수
+	OldTail  Location          `json:"oldTail"`
+	NewTail  Location          `json:"newTail"`
+	OldSize  uint64            `json:"oldSize"`
+	NewSize  uint64            `json:"newSize"`
+}
+
+// Changed 는 Repair 가 파일을 고쳤는지
+func (r *RepairReport) Changed() bool {
+	return len(r.Breaks) > 0 || r.Orphaned > 0 || r.OldTail != r.NewTail || r.OldSize != r.NewSize
+}
+
+// Repair 는 head 부터 체인을 따라가며 끊어진 포인터를 고치고 tail / Size 를 맞춘다 (이 파일 맨 위 설명).
+// 페이지 헤더의 Used 나 슬롯 자체가 깨진 경우는 고치지 않는다. Check 로 먼저 보고, 고친 뒤에도 Check 로 확인한다.
+func (s *PagedStore) Repair(handle *Handle) (*RepairReport, error) {
+	handle.mu.Lock()
+	defer handle.mu.Unlock()
+	h, err := ensurePagedHeader(handle)
+	if err != nil {
+		return nil, err
+	}
+	f := handle.File
+	report := &RepairReport{
+		Breaks:  make([]BrokenLinkError, 0),
+		OldTail: Location{Page: h.TailPage, Slot: h.TailSlot},
+		OldSize: h.Size,
+	}
+	// 고치는 동안 tail 노드와 Size 가 바뀐다.
+	handle.tail = tailCache{}
+	handle.audit = auditState{}
+	handle.free = 0
+
+	var pb PageBuffer
+	defer pb.release()
+
+	// relink 는 from 의 next (헤더면 head) 를 (page, slot) 으로 바꾼다.
+	relink := func(from Location, page uint32, slot uint16) error {
+		if from == fromHeader {
+			h.HeadPage, h.HeadSlot = page, slot
+			return nil
+		}
+		node, err := readSlot(f, h, from.Page, from.Slot)
+		if err != nil {
+			return err
+		}
+		node.NextPage, node.NextSlot = page, slot
+		pb.valid = false // 버퍼에 든 페이지를 고쳐 썼을 수 있다.
+		return writeSlot(f, h, from.Page, from.Slot, node)
+	}
+
+	visited := make(map[Location]bool)
+	last := fromHeader
+	var live uint64
+	page, slot := h.HeadPage, h.HeadSlot
+	for page != NullPage && slot != NullSlot {
+		if loc := (Location{Page: page, Slot: slot}); visited[loc] {
+			report.Breaks = append(report.Breaks, *brokenLink(last, page, slot, "cycle"))
+			if err := relink(last, NullPage, NullSlot); err != nil {
+				return report, err
+			}
+			break
+		}
+		node, err := followLink(f, &pb, h, last, page, slot)
+		if err == nil {
+			visited[Location{Page: page, Slot: slot}] = true
+			live++
+			last = Location{Page: page, Slot: slot}
+			page, slot = node.NextPage, node.NextSlot
+			continue
+		}
+		var broken *BrokenLinkError
+		if !errors.As(err, &broken) {
+			return report, err
+		}
+		report.Breaks = append(report.Breaks, *broken)
+
+		// tombstone 이면 그 next 로 건너뛰고 계속 따라간다. 범위 밖이면 여기서 자른다.
+		if broken.Reason == reasonTombstone {
+			tomb, err := readSlot(f, h, page, slot)
+			if err != nil {
+				return report, err
+			}
+			visited[Location{Page: page, Slot: slot}] = true
+			if err := relink(last, tomb.NextPage, tomb.NextSlot); err != nil {
+				return report, err
+			}
+			page, slot = tomb.NextPage, tomb.NextSlot
+			continue
+		}
+		if err := relink(last, NullPage, NullSlot); err != nil {
+			return report, err
+		}
+		break
+	}
+
+	if last == fromHeader {
+		h.HeadPage, h.HeadSlot = NullPage, NullSlot
+	}
+	h.TailPage, h.TailSlot = last.Page, last.Slot
+	h.Size = live
+
+	// 체인에서 떨어진 살아 있는 슬롯을 tombstone 으로
+	for pageID := uint32(0); pageID < h.PageCount; pageID++ {
+		ph, err := readPageHeader(f, h, pageID)
+		if err != nil {
+			return report, err
+		}
+		for slotID := uint16(0); slotID < ph.Used && int(slotID) < slotsPerPage(h.PageSize); slotID++ {
+			loc := Location{Page: pageID, Slot: slotID}
+			if visited[loc] {
+				continue
+			}
+			node, err := readSlot(f, h, pageID, slotID)
+			if err != nil {
+				return report, err
+			}
+			if node.Tomb == 0 {
+				node.Tomb = 1
+				if err := writeSlot(f, h, pageID, slotID, node); err != nil {
+					return report, err
+				}
+				report.Orphaned++
+			}
+		}
+	}
+
+	report.NewTail = Location{Page: h.TailPage, Slot: h.TailSlot}
+	report.NewSize = h.Size
+	if !report.Changed() {
+		return report, nil
+	}
+	return report, writeHeader(f, h)
+}
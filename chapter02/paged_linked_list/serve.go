@@ -0,0 +1,379 @@
+package pagedlist
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/tmdgusya/btree/dberr"
+	"github.com/tmdgusya/btree/iometrics"
+)
+
+// ==================================
+// Serve: 파일 위의 리스트를 HTTP 로 띄우기 (`btree serve -store paged <file>`)
+// ==================================
+// 값을 넣을 때마다 파일 I/O 와 페이지 버퍼 적중률이 어떻게 쌓이는지 브라우저에서 바로 보려는 데모.
+// - GET  /             값 입력 폼 + I/O 그래프 (1 초마다 /api/metrics 를 폴링)
+// - GET  /api/values   리스트 값 전체
+// - POST /api/append   {"value": n} 을 꼬리에 추가
+// - POST /api/delete   {"value": n} 인 첫 노드 삭제
+// - GET  /api/metrics  누적 IOMetrics (DELETE 로 0 부터 다시 잰다)
+// - GET  /metrics      같은 값 + 요청 수 / 파일 크기를 Prometheus 형식으로
+// - POST /api/admin/*  compact / checkpoint / flush. Bearer 토큰이 있어야 한다 (admin.go)
+// - GET  /api/heatmap  최근 연산들의 페이지별 읽기 / 쓰기 수 (heatmap.go)
+// 요청마다 CountingFile 구간을 열어서 append / delete / values 별 I/O 도 따로 볼 수 있다.
+
+type listServer struct {
+	mu     sync.Mutex
+	store  *PagedStore
+	handle *Handle
+	cf     *iometrics.CountingFile
+	ops    map[string]int64 // 성공한 요청 수 (append / delete / values / 관리 작업)
+	heat   *pageAccessLog   // 연산마다 건드린 페이지 (heatmap.go)
+
+	adminToken string
+}
+
+// 서버 타임아웃. 느린 클라이언트가 연결과 파일 잠금을 붙잡고 있지 않도록 한다.
+// handlerTimeout 을 넘긴 요청은 503 을 받는다. writeTimeout 은 그 503 을 쓸 수 있도록 더 길다.
+const (
+	serveReadTimeout     = 10 * time.Second
+	serveWriteTimeout    = 30 * time.Second
+	serveIdleTimeout     = 60 * time.Second
+	serveHandlerTimeout  = 20 * time.Second
+	serveMaxRequestBytes = 1 << 20
+	serveShutdownTimeout = 5 * time.Second
+)
+
+// Serve 는 path 의 리스트 파일을 열어 addr 에서 데모 서버를 띄운다. 서버가 멈출 때까지 돌아오지 않는다.
+// 관리 엔드포인트는 닫혀 있다. 열려면 ServeContext 에 AdminToken 을 준다.
+func Serve(addr, path string) error {
+	return ServeContext(context.Background(), addr, path, ServeOptions{})
+}
+
+// ServeContext 는 ctx 가 취소되면 처리 중인 요청을 기다린 뒤 파일을 닫고 nil 을 돌려주는 Serve.
+func ServeContext(ctx context.Context, addr, path string, opts ServeOptions) error {
+	// 데모 서버는 기존 파일을 이어서 쓴다 (O_TRUNC 없음).
+	raw, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	cf := iometrics.NewCountingFile(raw)
+	store := &PagedStore{}
+	handle, err := store.OpenFile(cf)
+	if err != nil {
+		return err
+	}
+	defer store.Close(handle)
+	h, err := ensurePagedHeader(handle)
+	if err != nil {
+		return err
+	}
+	heat := newPageAccessLog(h)
+	defer cf.Observe(heat.record)()
+
+	s := &listServer{store: store, handle: handle, cf: cf, ops: make(map[string]int64), heat: heat, adminToken: opts.AdminToken}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.handleIndex)
+	mux.HandleFunc("/api/values", s.handleValues)
+	mux.HandleFunc("/api/append", s.handleAppend)
+	mux.HandleFunc("/api/delete", s.handleDelete)
+	mux.HandleFunc("/api/admin/", s.handleAdmin)
+	mux.HandleFunc("/api/heatmap", s.handleHeatmap)
+	mux.Handle("/api/metrics", iometrics.Handler(cf))
+	mux.Handle("/metrics", iometrics.PrometheusHandler("btree", cf, s.samples))
+
+	srv := &http.Server{
+		Addr:         addr,
+		Handler:      http.TimeoutHandler(http.MaxBytesHandler(mux, serveMaxRequestBytes), serveHandlerTimeout, "request timed out\n"),
+		ReadTimeout:  serveReadTimeout,
+		WriteTimeout: serveWriteTimeout,
+		IdleTimeout:  serveIdleTimeout,
+		ErrorLog:     slog.NewLogLogger(slog.Default().Handler(), slog.LevelError),
+	}
+	slog.Info("paged list server listening", "file", path, "addr", addr)
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serveShutdownTimeout)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
+
+func (s *listServer) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, serveHTML)
+}
+
+func (s *listServer) handleValues(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	done := s.heat.begin()
+	vals, err := s.values()
+	done()
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	s.ops["values"]++
+	respondJSON(w, http.StatusOK, map[string]any{"values": vals})
+}
+
+func (s *listServer) handleAppend(w http.ResponseWriter, r *http.Request) {
+	value, ok := decodeValue(w, r)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	end, done := s.cf.Section("append"), s.heat.begin()
+	err := s.store.AppendTail(s.handle, value)
+	done()
+	end()
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	s.ops["append"]++
+	vals, err := s.values()
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"values": vals})
+}
+
+func (s *listServer) handleDelete(w http.ResponseWriter, r *http.Request) {
+	value, ok := decodeValue(w, r)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	end, done := s.cf.Section("delete"), s.heat.begin()
+	removed, err := s.store.DeleteFirstByValue(s.handle, value)
+	done()
+	end()
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	s.ops["delete"]++
+	vals, err := s.values()
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"removed": removed, "values": vals})
+}
+
+// samples 는 /metrics 에 IOMetrics 와 같이 나가는 서버 / 파일 상태 값
+func (s *listServer) samples() []iometrics.Sample {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []iometrics.Sample
+	for _, op := range []string{"append", "delete", "values", "compact", "checkpoint", "flush"} {
+		out = append(out, iometrics.Sample{
+			Name: "btree_ops_total", Help: "Successful API operations.", Type: "counter",
+			Labels: map[string]string{"op": op}, Value: float64(s.ops[op]),
+		})
+	}
+	// fileSize 는 Seek 을 해서 I/O 카운터를 건드리므로 stat 으로 묻는 SpaceUsage 를 쓴다.
+	if usage, err := s.store.SpaceUsage(s.handle); err == nil {
+		out = append(out,
+			iometrics.Sample{Name: "btree_file_size_bytes", Help: "Logical size of the data file.", Type: "gauge", Value: float64(usage.Logical)},
+			iometrics.Sample{Name: "btree_file_physical_bytes", Help: "Disk blocks actually allocated to the data file.", Type: "gauge", Value: float64(usage.Physical)},
+		)
+	}
+	if h, err := ensurePagedHeader(s.handle); err == nil {
+		out = append(out,
+			iometrics.Sample{Name: "btree_list_length", Help: "Live values in the list (Header.Size).", Type: "gauge", Value: float64(h.Size)},
+			iometrics.Sample{Name: "btree_pages", Help: "Allocated pages (Header.PageCount).", Type: "gauge", Value: float64(h.PageCount)},
+		)
+	}
+	return out
+}
+
+// s.mu 를 잡은 채로 부른다.
+func (s *listServer) values() ([]uint32, error) {
+	end := s.cf.Section("values")
+	defer end()
+	vals, err := s.store.TraverseValues(s.handle)
+	if vals == nil {
+		vals = []uint32{}
+	}
+	return vals, err
+}
+
+func decodeValue(w http.ResponseWriter, r *http.Request) (uint32, bool) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return 0, false
+	}
+	var payload struct {
+		Value uint32 `json:"value"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return 0, false
+	}
+	return payload.Value, true
+}
+
+func methodNotAllowed(w http.ResponseWriter, method string) {
+	w.Header().Set("Allow", method)
+	writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+}
+
+func respondJSON(w http.ResponseWriter, status int, payload any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if payload != nil {
+		_ = json.NewEncoder(w).Encode(payload)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	respondJSON(w, status, map[string]string{"error": message})
+}
+
+// writeErr 는 저장소 에러를 종류(dberr)에 맞는 상태 코드로 돌려준다. 종류가 있으면 "kind" 도 같이 보낸다.
+func writeErr(w http.ResponseWriter, err error) {
+	body := map[string]string{"error": err.Error()}
+	if kind := dberr.Kind(err); kind != nil {
+		body["kind"] = kind.Error()
+	}
+	respondJSON(w, dberr.HTTPStatus(err), body)
+}
+
+const serveHTML = `<!DOCTYPE html>
+<html lang="ko">
+<head>
+<meta charset="UTF-8" />
+<title>Paged linked list I/O</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+#values { font-family: monospace; word-break: break-all; }
+svg { border: 1px solid #ddd; background: #fafafa; }
+.legend span { margin-right: 1em; }
+#heatmap { display: flex; flex-wrap: wrap; gap: 2px; max-width: 600px; }
+#heatmap div { width: 28px; height: 28px; font-size: 10px; line-height: 28px; text-align: center; border: 1px solid #ddd; }
+</style>
+</head>
+<body>
+<h1>Paged linked list I/O</h1>
+<form id="form">
+<input id="value" type="number" min="0" required />
+<button type="submit" data-op="append">append</button>
+<button type="submit" data-op="delete">delete</button>
+<button type="button" id="reset">reset metrics</button>
+</form>
+<p id="values"></p>
+
+<h2>누적 I/O</h2>
+<table id="metrics"></table>
+<div class="legend"><span style="color:#4a7fb5">reads</span><span style="color:#c0392b">writes</span><span style="color:#27ae60">buffer hits</span></div>
+<svg id="chart" width="600" height="200"></svg>
+
+<h2>페이지 히트맵 (최근 연산)</h2>
+<p id="heatinfo"></p>
+<div id="heatmap"></div>
+
+<script>
+const history = [];
+const maxPoints = 120;
+let op = "append";
+
+document.querySelectorAll("button[data-op]").forEach(b => b.addEventListener("click", () => { op = b.dataset.op; }));
+
+document.getElementById("form").addEventListener("submit", async e => {
+  e.preventDefault();
+  const value = Number(document.getElementById("value").value);
+  const res = await fetch("/api/" + op, { method: "POST", body: JSON.stringify({ value }) });
+  showValues(await res.json());
+  poll();
+});
+
+document.getElementById("reset").addEventListener("click", async () => {
+  await fetch("/api/metrics", { method: "DELETE" });
+  history.length = 0;
+  poll();
+});
+
+function showValues(data) {
+  document.getElementById("values").textContent = data.error ? data.error : "[" + data.values.join(", ") + "]";
+}
+
+function row(name, m) {
+  return "<tr><td>" + name + "</td><td>" + m.reads + "</td><td>" + m.writes + "</td><td>" + m.seeks + "</td><td>" +
+    m.bytesRead + "</td><td>" + m.bytesWritten + "</td><td>" + m.cacheHits + "</td><td>" + m.cacheMisses + "</td><td>" +
+    (m.hitRatio * 100).toFixed(1) + "%</td></tr>";
+}
+
+function draw() {
+  const svg = document.getElementById("chart");
+  const w = svg.width.baseVal.value, h = svg.height.baseVal.value;
+  const peak = Math.max(1, ...history.flatMap(p => [p.reads, p.writes, p.cacheHits]));
+  const line = (key, color) => {
+    const pts = history.map((p, i) => (i / (maxPoints - 1) * w).toFixed(1) + "," + (h - p[key] / peak * (h - 10)).toFixed(1));
+    return '<polyline fill="none" stroke="' + color + '" stroke-width="2" points="' + pts.join(" ") + '" />';
+  };
+  svg.innerHTML = line("reads", "#4a7fb5") + line("writes", "#c0392b") + line("cacheHits", "#27ae60") +
+    '<text x="4" y="12" font-size="11">' + peak + '</text>';
+}
+
+async function poll() {
+  const data = await (await fetch("/api/metrics")).json();
+  let html = "<tr><th></th><th>reads</th><th>writes</th><th>seeks</th><th>bytes read</th><th>bytes written</th><th>hits</th><th>misses</th><th>hit ratio</th></tr>";
+  html += row("total", data.total);
+  data.sections.forEach(s => { html += row(s.name, s.io); });
+  document.getElementById("metrics").innerHTML = html;
+  history.push(data.total);
+  if (history.length > maxPoints) history.shift();
+  draw();
+  drawHeatmap(await (await fetch("/api/heatmap")).json());
+}
+
+// 페이지 하나가 칸 하나. 많이 읽고 쓴 페이지일수록 진하다. H 는 헤더, 머리 / 꼬리 페이지는 테두리를 굵게.
+function drawHeatmap(hm) {
+  document.getElementById("heatinfo").textContent = hm.operations + " operations, max " + hm.max + " accesses per page";
+  document.getElementById("heatmap").innerHTML = hm.pages.map(p => {
+    const a = hm.max ? p.count / hm.max : 0;
+    const border = p.kind === "head" || p.kind === "tail" ? "border:2px solid #333;" : "";
+    const label = p.kind === "header" ? "H" : p.page;
+    return '<div style="' + border + 'background:rgba(192,57,43,' + a.toFixed(2) + ')" title="' + p.kind + " page " + p.page +
+      ": " + p.reads + " reads, " + p.writes + ' writes">' + label + "</div>";
+  }).join("");
+}
+
+fetch("/api/values").then(r => r.json()).then(showValues);
+poll();
+setInterval(poll, 1000);
+</script>
+</body>
+</html>
+`
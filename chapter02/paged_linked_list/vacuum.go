@@ -0,0 +1,124 @@
+package pagedlist
+
+import (
+	"context"
+	"log/slog"
+)
+
+// ==================================
+// 쓰레기 통계와 자동 vacuum
+// ==================================
+// 지운 노드는 tombstone 으로 슬롯에 남는다. 쓰레기 비율은 tombstone / 쓴 슬롯(Used 합계).
+// PagedStore.AutoVacuum 을 주면 DeleteFirstByValue 가 끝날 때마다 페이지 헤더만 훑어서 비율을 다시 재고,
+// 마지막 vacuum 뒤에 새로 생긴 tombstone 의 비율이 AutoVacuum 이상이면 compact 명령과 같은 정리를 돌린다.
+// - 모든 페이지를 Compact: 끝쪽 tombstone 은 Used 를 줄여 회수하고, 중간 tombstone 은 재사용할 구멍으로 남는다.
+// - 구멍 뚫기가 되는 환경이면 PunchHoles 로 Used 뒤의 빈 영역을 디스크에 돌려준다.
+// 중간 tombstone 은 정리해도 tombstone 으로 남으므로, 전체 비율이 아니라 "마지막 vacuum 뒤에 늘어난 만큼"으로
+// 판단해야 같은 쓰레기를 보고 지울 때마다 vacuum 을 다시 돌지 않는다.
+
+// VacuumDecision 은 자동 vacuum 을 판단한 결과. OnVacuum 이나 로그로 알린다.
+// Ratio 는 마지막 vacuum 뒤에 생긴 tombstone / UsedSlots, Vacuumed 가 false 면 Reclaimed / Punched 는 0 이다.
+type VacuumDecision struct {
+	UsedSlots  int     `json:"usedSlots"`
+	Tombstones int     `json:"tombstones"`
+	Ratio      float64 `json:"ratio"`
+	Threshold  float64 `json:"threshold"`
+	Vacuumed   bool    `json:"vacuumed"`
+	Reclaimed  int     `json:"reclaimed"`
+	Punched    int64   `json:"punched"`
+}
+
+// vacuumState 는 핸들마다 기억하는 자동 vacuum 상태
+type vacuumState struct {
+	base int // 마지막 vacuum 뒤에 남은 tombstone 수
+	runs int // 이 핸들에서 자동 vacuum 을 돈 횟수
+}
+
+// countUsedSlots 는 페이지 헤더만 읽어서 Used 합계를 구한다. tombstone 수는 이 값 - Header.Size 다.
+func countUsedSlots(f File, h *Header) (int, error) {
+	capacity := slotsPerPage(h.PageSize)
+	used := 0
+	for pageID := uint32(0); pageID < h.PageCount; pageID++ {
+		ph, err := readPageHeader(f, h, pageID)
+		if err != nil {
+			return 0, err
+		}
+		used += min(int(ph.Used), capacity)
+	}
+	return used, nil
+}
+
+// maybeVacuum 은 삭제 뒤에 불러서 쓰레기가 AutoVacuum 을 넘었으면 정리한다.
+func (s *PagedStore) maybeVacuum(handle *Handle, h *Header) error {
+	used, err := countUsedSlots(handle.File, h)
+	if err != nil {
+		return err
+	}
+	tombs := max(used-int(h.Size), 0)
+	// 삽입이 구멍을 다시 쓰면 tombstone 이 기준보다 줄어들 수 있다.
+	handle.vacuum.base = min(handle.vacuum.base, tombs)
+
+	d := VacuumDecision{UsedSlots: used, Tombstones: tombs, Threshold: s.AutoVacuum}
+	if used > 0 {
+		d.Ratio = float64(tombs-handle.vacuum.base) / float64(used)
+	}
+	if d.Ratio >= s.AutoVacuum && tombs > handle.vacuum.base {
+		d.Vacuumed = true
+		if d.Reclaimed, d.Punched, err = compactPages(context.Background(), s, handle, punchHoleSupported); err != nil {
+			return err
+		}
+		if used, err = countUsedSlots(handle.File, h); err != nil {
+			return err
+		}
+		handle.vacuum.base = max(used-int(h.Size), 0)
+		handle.vacuum.runs++
+	}
+	s.reportVacuum(d)
+	return nil
+}
+
+func (s *PagedStore) reportVacuum(d VacuumDecision) {
+	if s.OnVacuum != nil {
+		s.OnVacuum(d)
+		return
+	}
+	level := slog.LevelDebug
+	if d.Vacuumed {
+		level = slog.LevelInfo
+	}
+	slog.Log(context.Background(), level, "paged list auto-vacuum",
+		"vacuumed", d.Vacuumed, "ratio", d.Ratio, "threshold", d.Threshold,
+		"usedSlots", d.UsedSlots, "tombstones", d.Tombstones, "reclaimed", d.Reclaimed, "punched", d.Punched)
+}
+
+// compactAll 은 모든 페이지를 Compact 하고, punch 면 PunchHoles 까지 한다. 페이지 사이에서 ctx 를 본다.
+// *os.File 까지 벗길 수 없는 파일은 구멍을 뚫을 수 없으므로 건너뛴다.
+func compactAll(ctx context.Context, s *PagedStore, handle *Handle, punch bool) (reclaimed int, punched int64, err error) {
+	handle.mu.Lock()
+	defer handle.mu.Unlock()
+	return compactPages(ctx, s, handle, punch)
+}
+
+// compactPages 는 쓰기 잠금을 이미 잡은 쪽 (삭제 뒤의 자동 vacuum) 이 부르는 compactAll.
+func compactPages(ctx context.Context, s *PagedStore, handle *Handle, punch bool) (reclaimed int, punched int64, err error) {
+	h, err := ensurePagedHeader(handle)
+	if err != nil {
+		return 0, 0, err
+	}
+	for pageID := uint32(0); pageID < h.PageCount; pageID++ {
+		// 페이지 하나의 정리는 끝까지 하고, 페이지 사이에서 멈춘다.
+		if err := ctx.Err(); err != nil {
+			return reclaimed, 0, err
+		}
+		n, err := s.compact(handle, pageID)
+		if err != nil {
+			return reclaimed, 0, err
+		}
+		reclaimed += n
+	}
+	if _, ok := osFile(handle.File); !punch || !ok {
+		return reclaimed, 0, nil
+	}
+	punched, err = s.punchHoles(handle)
+	return reclaimed, punched, err
+}
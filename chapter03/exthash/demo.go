@@ -0,0 +1,65 @@
+package exthash
+
+import (
+	"fmt"
+	"os"
+)
+
+// Demo 는 ext_hash.db 를 새로 만들어 키를 넣으면서 버킷이 나뉘고 디렉터리가 커지는 모습과 I/O 를 출력한다.
+// 튜토리얼 데모라서 실패하면 panic 한다.
+func Demo() {
+	const path = "ext_hash.db"
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		panic(err)
+	}
+	// 작은 페이지(버킷당 31 개)로 split 이 자주 일어나게 한다.
+	idx, err := Open(path, 512)
+	if err != nil {
+		panic(err)
+	}
+
+	for i := uint64(0); i < 2000; i++ {
+		if err := idx.Put(i, i*i); err != nil {
+			panic(err)
+		}
+		if i+1 == 10 || i+1 == 100 || i+1 == 1000 || i+1 == 2000 {
+			s := idx.Stats()
+			fmt.Printf("%4d keys: global depth %d, %d buckets, %d splits, %d directory doublings\n",
+				s.Keys, s.GlobalDepth, s.Buckets, s.Splits, s.Doublings)
+		}
+	}
+
+	before := idx.Stats().IO
+	v, ok, err := idx.Get(42)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("get 42 -> %d found=%v (%d page reads)\n", v, ok, idx.Stats().IO.Reads-before.Reads)
+
+	if _, err := idx.Delete(42); err != nil {
+		panic(err)
+	}
+	_, ok, err = idx.Get(42)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("after delete: get 42 found=%v, %d keys\n", ok, idx.Len())
+
+	s := idx.Stats()
+	fmt.Printf("\nI/O: reads=%d writes=%d (%d B written)\n", s.IO.Reads, s.IO.Writes, s.IO.BytesWritten)
+	if err := idx.Close(); err != nil {
+		panic(err)
+	}
+
+	// 다시 열어서 디렉터리와 키 수가 복원되는지 본다.
+	idx, err = Open(path, 0)
+	if err != nil {
+		panic(err)
+	}
+	defer idx.Close()
+	v, ok, err = idx.Get(1999)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("reopened: %d keys, global depth %d, get 1999 -> %d found=%v\n", idx.Len(), idx.Stats().GlobalDepth, v, ok)
+}
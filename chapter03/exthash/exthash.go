@@ -0,0 +1,394 @@
+// Package exthash 는 Pager 위에 올린 디스크 기반 extendible hashing 인덱스다.
+// B-Tree 와 같은 점 조회(point lookup)를 해시로 풀어서, 범위 질의를 포기하는 대신 조회 한 번에 버킷 페이지 하나만 읽는다.
+//
+//   - 디렉터리: 길이 2^globalDepth 인 버킷 페이지 번호 배열. 키 해시의 아래 globalDepth 비트가 인덱스다.
+//   - 버킷: 페이지 하나. localDepth 비트까지 같은 해시를 가진 키들이 들어간다.
+//   - 버킷이 넘치면 localDepth 를 1 올려 둘로 나눈다. localDepth 가 globalDepth 와 같으면 먼저 디렉터리를 두 배로 늘린다.
+//
+// 삭제는 버킷에서 빼기만 하고 버킷을 합치거나 디렉터리를 줄이지는 않는다.
+//
+// 페이지 배치 (Pager 의 0번은 슈퍼블록)
+//
+//	1       : 메타 페이지   magic(4) | version(2) | globalDepth(2) | nextPage(4) | count(8) | dirPages(4) | dirPageID(4) * dirPages
+//	2 ~     : 디렉터리 페이지 (버킷 페이지 번호 uint32 배열) 와 버킷 페이지가 만들어진 순서대로
+//	버킷    : localDepth(2) | n(2) | { key(8) | value(8) } * n
+package exthash
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tmdgusya/btree/chapter01/page"
+	"github.com/tmdgusya/btree/codec"
+	"github.com/tmdgusya/btree/dberr"
+	"github.com/tmdgusya/btree/iometrics"
+)
+
+var Endian = codec.Endian
+
+var Magic = [4]byte{'E', 'X', 'T', 'H'}
+
+var (
+	ErrInvalidIndex = dberr.New(dberr.ErrCorruptPage, "exthash: invalid index file")
+	// 해시가 maxDepth 비트까지 모두 같은 키가 버킷 하나를 넘치게 채우면 더 나눌 수 없다.
+	ErrBucketFull = errors.New("exthash: bucket cannot be split further")
+)
+
+const (
+	version = 1
+
+	metaPage = 1
+
+	metaHeaderSize   = 4 + 2 + 2 + 4 + 8 + 4
+	bucketHeaderSize = 2 + 2
+	entrySize        = 8 + 8
+
+	// 디렉터리 길이는 최대 2^maxDepth
+	maxDepth = 24
+)
+
+// Index 는 uint64 키 -> uint64 값 해시 인덱스. 디렉터리는 메모리에 올려 두고 바뀔 때마다 페이지에 다시 쓴다.
+// 고루틴 여러 개에서 같이 쓰면 안 된다.
+type Index struct {
+	pager       *page.Pager
+	globalDepth uint
+	dir         []uint32 // 디렉터리 (버킷 페이지 번호)
+	dirPages    []uint32 // 디렉터리를 담은 페이지 번호
+	nextPage    uint32
+	count       uint64
+
+	splits    int64
+	doublings int64
+}
+
+// Open 은 path 의 인덱스를 연다. 새 파일이면 빈 버킷 하나로 시작한다.
+// pageSize 는 새 파일을 만들 때만 쓰인다 (0 이면 Pager 기본값).
+func Open(path string, pageSize int) (*Index, error) {
+	pager, err := page.OpenPager(path, page.PagerOptions{PageSize: pageSize})
+	if err != nil {
+		return nil, err
+	}
+	idx := &Index{pager: pager}
+
+	meta, err := pager.ReadPage(metaPage)
+	switch {
+	case errors.Is(err, page.ErrPageOutOfRange):
+		err = idx.create()
+	case err == nil:
+		err = idx.load(meta.Data)
+	}
+	if err != nil {
+		pager.Close()
+		return nil, err
+	}
+	return idx, nil
+}
+
+func (idx *Index) create() error {
+	// Pager 는 페이지를 순서대로만 할당하므로 메타 페이지 자리부터 잡는다. 내용은 writeDirectory 가 마지막에 쓴다.
+	if err := idx.pager.WritePage(&page.Page{Id: metaPage}); err != nil {
+		return err
+	}
+	idx.nextPage = metaPage + 1
+	bucket := idx.allocPage()
+	idx.dir = []uint32{bucket}
+	if err := idx.writeBucket(bucket, &bucketPage{}); err != nil {
+		return err
+	}
+	return idx.writeDirectory()
+}
+
+func (idx *Index) load(meta []byte) error {
+	if len(meta) < metaHeaderSize {
+		return fmt.Errorf("%w: %w", ErrInvalidIndex, dberr.ErrInvalidMagic)
+	}
+	r := codec.NewReader(meta)
+	var magic [4]byte
+	r.Bytes(magic[:])
+	if magic != Magic {
+		return fmt.Errorf("%w: %w", ErrInvalidIndex, dberr.ErrInvalidMagic)
+	}
+	if v := r.Uint16(); v != version {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidIndex, v)
+	}
+	idx.globalDepth = uint(r.Uint16())
+	idx.nextPage = r.Uint32()
+	idx.count = r.Uint64()
+	n := int(r.Uint32())
+	if idx.globalDepth > maxDepth || metaHeaderSize+4*n > len(meta) {
+		return ErrInvalidIndex
+	}
+	for i := 0; i < n; i++ {
+		idx.dirPages = append(idx.dirPages, r.Uint32())
+	}
+
+	size := 1 << idx.globalDepth
+	perPage := idx.pager.PageSize() / 4
+	idx.dir = make([]uint32, 0, size)
+	for _, id := range idx.dirPages {
+		pg, err := idx.pager.ReadPage(int64(id))
+		if err != nil {
+			return err
+		}
+		r := codec.NewReader(pg.Data)
+		for i := 0; i < perPage && len(idx.dir) < size; i++ {
+			idx.dir = append(idx.dir, r.Uint32())
+		}
+	}
+	if len(idx.dir) != size {
+		return fmt.Errorf("%w: directory has %d entries, want %d", ErrInvalidIndex, len(idx.dir), size)
+	}
+	return nil
+}
+
+func (idx *Index) allocPage() uint32 {
+	id := idx.nextPage
+	idx.nextPage++
+	return id
+}
+
+// writeDirectory 는 디렉터리와 메타 페이지를 다시 쓴다. 디렉터리가 커져서 페이지가 더 필요하면 새로 할당한다.
+func (idx *Index) writeDirectory() error {
+	perPage := idx.pager.PageSize() / 4
+	need := (len(idx.dir) + perPage - 1) / perPage
+	if metaHeaderSize+4*need > idx.pager.PageSize() {
+		return fmt.Errorf("exthash: directory of %d entries does not fit in the meta page", len(idx.dir))
+	}
+	for len(idx.dirPages) < need {
+		idx.dirPages = append(idx.dirPages, idx.allocPage())
+	}
+	for i, id := range idx.dirPages {
+		data := make([]byte, idx.pager.PageSize())
+		w := codec.NewWriter(data)
+		for j := 0; j < perPage && i*perPage+j < len(idx.dir); j++ {
+			w.Uint32(idx.dir[i*perPage+j])
+		}
+		if err := idx.pager.WritePage(&page.Page{Id: int(id), Data: data}); err != nil {
+			return err
+		}
+	}
+	return idx.writeMeta()
+}
+
+func (idx *Index) writeMeta() error {
+	data := make([]byte, idx.pager.PageSize())
+	w := codec.NewWriter(data)
+	w.Bytes(Magic[:])
+	w.Uint16(version)
+	w.Uint16(uint16(idx.globalDepth))
+	w.Uint32(idx.nextPage)
+	w.Uint64(idx.count)
+	w.Uint32(uint32(len(idx.dirPages)))
+	for _, id := range idx.dirPages {
+		w.Uint32(id)
+	}
+	return idx.pager.WritePage(&page.Page{Id: metaPage, Data: data})
+}
+
+// ==================================
+// 버킷 페이지
+// ==================================
+
+type bucketEntry struct {
+	key, value uint64
+}
+
+type bucketPage struct {
+	localDepth uint
+	entries    []bucketEntry
+}
+
+func (idx *Index) bucketCapacity() int {
+	return (idx.pager.PageSize() - bucketHeaderSize) / entrySize
+}
+
+func (idx *Index) readBucket(id uint32) (*bucketPage, error) {
+	pg, err := idx.pager.ReadPage(int64(id))
+	if err != nil {
+		return nil, err
+	}
+	r := codec.NewReader(pg.Data)
+	localDepth := uint(r.Uint16())
+	n := int(r.Uint16())
+	if n > idx.bucketCapacity() {
+		return nil, &dberr.PageError{Page: int64(id), Err: fmt.Errorf("%w: bucket has %d entries", ErrInvalidIndex, n)}
+	}
+	b := &bucketPage{localDepth: localDepth, entries: make([]bucketEntry, n)}
+	for i := range b.entries {
+		b.entries[i] = bucketEntry{key: r.Uint64(), value: r.Uint64()}
+	}
+	return b, nil
+}
+
+func (idx *Index) writeBucket(id uint32, b *bucketPage) error {
+	data := make([]byte, idx.pager.PageSize())
+	w := codec.NewWriter(data)
+	w.Uint16(uint16(b.localDepth))
+	w.Uint16(uint16(len(b.entries)))
+	for _, e := range b.entries {
+		w.Uint64(e.key)
+		w.Uint64(e.value)
+	}
+	return idx.pager.WritePage(&page.Page{Id: int(id), Data: data})
+}
+
+func (b *bucketPage) find(key uint64) int {
+	for i, e := range b.entries {
+		if e.key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// ==================================
+// 연산
+// ==================================
+
+// hash 는 splitmix64 의 마무리 단계. 순차 키도 아래 비트가 고르게 섞인다.
+func hash(key uint64) uint64 {
+	key ^= key >> 30
+	key *= 0xbf58476d1ce4e5b9
+	key ^= key >> 27
+	key *= 0x94d049bb133111eb
+	key ^= key >> 31
+	return key
+}
+
+func (idx *Index) slot(key uint64) int {
+	return int(hash(key) & (1<<idx.globalDepth - 1))
+}
+
+// Get 은 key 의 값을 돌려준다. 버킷 페이지 하나를 읽는다.
+func (idx *Index) Get(key uint64) (uint64, bool, error) {
+	b, err := idx.readBucket(idx.dir[idx.slot(key)])
+	if err != nil {
+		return 0, false, err
+	}
+	if i := b.find(key); i >= 0 {
+		return b.entries[i].value, true, nil
+	}
+	return 0, false, nil
+}
+
+// Put 은 key 의 값을 value 로 바꾼다. 버킷이 가득 차면 나눈 뒤 다시 시도한다.
+func (idx *Index) Put(key, value uint64) error {
+	for {
+		id := idx.dir[idx.slot(key)]
+		b, err := idx.readBucket(id)
+		if err != nil {
+			return err
+		}
+		if i := b.find(key); i >= 0 {
+			b.entries[i].value = value
+			return idx.writeBucket(id, b)
+		}
+		if len(b.entries) < idx.bucketCapacity() {
+			b.entries = append(b.entries, bucketEntry{key, value})
+			if err := idx.writeBucket(id, b); err != nil {
+				return err
+			}
+			idx.count++
+			return nil
+		}
+		if err := idx.split(id, b); err != nil {
+			return err
+		}
+	}
+}
+
+// split 은 버킷 id 를 localDepth 번째 비트로 둘로 나눈다.
+func (idx *Index) split(id uint32, b *bucketPage) error {
+	if b.localDepth >= maxDepth {
+		return ErrBucketFull
+	}
+	if b.localDepth == idx.globalDepth {
+		// 디렉터리를 두 배로: 새 절반은 기존 절반과 같은 버킷을 가리킨다.
+		idx.dir = append(idx.dir, idx.dir...)
+		idx.globalDepth++
+		idx.doublings++
+	}
+
+	bit := uint64(1) << b.localDepth
+	low := &bucketPage{localDepth: b.localDepth + 1}
+	high := &bucketPage{localDepth: b.localDepth + 1}
+	for _, e := range b.entries {
+		if hash(e.key)&bit == 0 {
+			low.entries = append(low.entries, e)
+		} else {
+			high.entries = append(high.entries, e)
+		}
+	}
+
+	newID := idx.allocPage()
+	for i, target := range idx.dir {
+		if target == id && uint64(i)&bit != 0 {
+			idx.dir[i] = newID
+		}
+	}
+	if err := idx.writeBucket(newID, high); err != nil {
+		return err
+	}
+	if err := idx.writeBucket(id, low); err != nil {
+		return err
+	}
+	idx.splits++
+	return idx.writeDirectory()
+}
+
+// Delete 는 key 를 지운다. 있었으면 true.
+func (idx *Index) Delete(key uint64) (bool, error) {
+	id := idx.dir[idx.slot(key)]
+	b, err := idx.readBucket(id)
+	if err != nil {
+		return false, err
+	}
+	i := b.find(key)
+	if i < 0 {
+		return false, nil
+	}
+	b.entries = append(b.entries[:i], b.entries[i+1:]...)
+	if err := idx.writeBucket(id, b); err != nil {
+		return false, err
+	}
+	idx.count--
+	return true, nil
+}
+
+// Len 은 들어 있는 키 수
+func (idx *Index) Len() uint64 { return idx.count }
+
+// Stats 는 인덱스 모양과 Pager 의 I/O
+type Stats struct {
+	Keys        uint64
+	GlobalDepth uint
+	Buckets     int
+	Splits      int64
+	Doublings   int64
+	IO          iometrics.IOMetrics
+}
+
+func (idx *Index) Stats() Stats {
+	buckets := make(map[uint32]bool)
+	for _, id := range idx.dir {
+		buckets[id] = true
+	}
+	return Stats{
+		Keys:        idx.count,
+		GlobalDepth: idx.globalDepth,
+		Buckets:     len(buckets),
+		Splits:      idx.splits,
+		Doublings:   idx.doublings,
+		IO:          idx.pager.Metrics(),
+	}
+}
+
+// Close 는 키 수를 메타 페이지에 기록하고 파일을 닫는다.
+// 키 수는 연산마다 쓰지 않으므로 Close 없이 끝나면 다음에 열 때 Len 이 맞지 않을 수 있다.
+func (idx *Index) Close() error {
+	err := idx.writeMeta()
+	if cerr := idx.pager.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
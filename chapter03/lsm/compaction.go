@@ -0,0 +1,155 @@
+package lsm
+
+import (
+	"bytes"
+	"slices"
+)
+
+// ==================================
+// Leveled compaction
+// ==================================
+// compact 는 한도를 넘은 레벨이 없을 때까지 한 번에 하나씩 컴팩션한다.
+// - L0: 테이블 수가 L0Trigger 이상이면 L0 전부 + 겹치는 L1 테이블을 L1 으로
+// - Ln (n >= 1): 크기가 levelLimit(n) 을 넘으면 compact pointer 다음 테이블 하나 + 겹치는 Ln+1 테이블을 Ln+1 로
+
+func (db *DB) compact() error {
+	for {
+		level := db.pickLevel()
+		if level < 0 {
+			return nil
+		}
+		if err := db.compactLevel(level); err != nil {
+			return err
+		}
+	}
+}
+
+// pickLevel 은 컴팩션이 필요한 가장 위 레벨. 없으면 -1.
+func (db *DB) pickLevel() int {
+	if len(db.levels[0]) >= db.opts.L0Trigger {
+		return 0
+	}
+	// 마지막 레벨은 내려갈 곳이 없으므로 보지 않는다.
+	for level := 1; level < len(db.levels)-1; level++ {
+		if levelBytes(db.levels[level]) > db.levelLimit(level) {
+			return level
+		}
+	}
+	return -1
+}
+
+func (db *DB) levelLimit(level int) int64 {
+	limit := db.opts.LevelBase
+	for i := 1; i < level; i++ {
+		limit *= int64(db.opts.LevelMultiplier)
+	}
+	return limit
+}
+
+func levelBytes(tables []*table) int64 {
+	var n int64
+	for _, t := range tables {
+		n += t.size
+	}
+	return n
+}
+
+func (db *DB) compactLevel(level int) error {
+	if level == 0 {
+		return db.compactTables(0, slices.Clone(db.levels[0]))
+	}
+	return db.compactTables(level, []*table{db.pickTable(level)})
+}
+
+// compactTables 는 level 의 inputs 를 겹치는 아래 레벨 테이블과 합쳐 아래 레벨에 쓴다.
+// level 이 마지막 레벨이면 (Vacuum) 내려갈 곳이 없으므로 inputs 만 그 자리에서 다시 쓴다.
+func (db *DB) compactTables(level int, inputs []*table) error {
+	target := min(level+1, len(db.levels)-1)
+	lo, hi := keyRange(inputs)
+	var lower []*table
+	if target != level {
+		for _, t := range db.levels[target] {
+			if t.overlaps(lo, hi) {
+				lower = append(lower, t)
+			}
+		}
+	}
+
+	// 새것이 앞에 오도록: 위 레벨 (L0 는 이미 최신부터) 다음에 아래 레벨
+	var sources []iterator
+	for _, t := range append(slices.Clone(inputs), lower...) {
+		end := t.cf.Section("compaction")
+		sources = append(sources, &sectionIter{iterator: t.iter(nil), end: end})
+	}
+
+	// 더 아래에 이 범위의 데이터가 없으면 tombstone 이 가릴 것이 없으므로 버린다.
+	drop := db.isBottom(target, lo, hi)
+	out, err := db.writeTables("compaction", newMergeIter(sources), drop, db.opts.TableSize)
+	for _, s := range sources {
+		s.(*sectionIter).end()
+	}
+	if err != nil {
+		return err
+	}
+
+	// 설치: 입력을 빼고 결과를 아래 레벨에 넣은 뒤 MANIFEST 를 바꾸고, 그다음에 입력 파일을 지운다.
+	db.levels[level] = slices.DeleteFunc(db.levels[level], func(t *table) bool { return slices.Contains(inputs, t) })
+	db.levels[target] = slices.DeleteFunc(db.levels[target], func(t *table) bool { return slices.Contains(lower, t) })
+	db.levels[target] = append(db.levels[target], out...)
+	sortTables(db.levels[target])
+	if level > 0 && target != level {
+		db.compactPointer[level] = hi
+	}
+	if err := db.saveManifest(); err != nil {
+		return err
+	}
+	for _, t := range append(inputs, lower...) {
+		db.dropTable(t)
+	}
+	db.compacts++
+	return nil
+}
+
+// pickTable 은 compact pointer 뒤의 첫 테이블. 레벨 끝까지 갔으면 처음으로 돌아간다.
+func (db *DB) pickTable(level int) *table {
+	tables := db.levels[level]
+	if p := db.compactPointer[level]; p != nil {
+		for _, t := range tables {
+			if bytes.Compare(t.first, p) > 0 {
+				return t
+			}
+		}
+	}
+	return tables[0]
+}
+
+// isBottom 은 level 아래 레벨들에 [lo, hi] 와 겹치는 테이블이 없는지
+func (db *DB) isBottom(level int, lo, hi []byte) bool {
+	for l := level + 1; l < len(db.levels); l++ {
+		for _, t := range db.levels[l] {
+			if t.overlaps(lo, hi) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func keyRange(tables []*table) (lo, hi []byte) {
+	for _, t := range tables {
+		if lo == nil || bytes.Compare(t.first, lo) < 0 {
+			lo = t.first
+		}
+		if hi == nil || bytes.Compare(t.last, hi) > 0 {
+			hi = t.last
+		}
+	}
+	return lo, hi
+}
+
+// sectionIter 는 테이블을 읽는 동안 그 파일의 "compaction" 구간을 열어 둔다.
+// 병합 중에는 여러 테이블을 번갈아 읽으므로 테이블마다 구간을 따로 연다.
+type sectionIter struct {
+	iterator
+	end func()
+}
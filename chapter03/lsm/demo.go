@@ -0,0 +1,97 @@
+package lsm
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// Demo 는 lsm_demo 디렉터리를 새로 만들어 키를 넣고 / 지우고 / 찾으면서 레벨 모양과 증폭 비율을 출력한다.
+// 작은 memtable 로 flush 와 컴팩션이 여러 번 일어나게 한다. 튜토리얼 데모라서 실패하면 panic 한다.
+func Demo() {
+	const dir = "lsm_demo"
+	if err := os.RemoveAll(dir); err != nil {
+		panic(err)
+	}
+	opts := Options{MemtableSize: 16 << 10, TableSize: 32 << 10, LevelBase: 128 << 10}
+	db, err := Open(dir, opts)
+	if err != nil {
+		panic(err)
+	}
+
+	const n = 20000
+	for i := 0; i < n; i++ {
+		// 순서를 섞어서 넣어야 L0 테이블끼리 범위가 겹친다.
+		k := (i * 7919) % n
+		if err := db.Put(demoKey(k), []byte(fmt.Sprintf("value-%d", k))); err != nil {
+			panic(err)
+		}
+	}
+	for k := 0; k < n; k += 10 {
+		if err := db.Delete(demoKey(k)); err != nil {
+			panic(err)
+		}
+	}
+	if err := db.Flush(); err != nil {
+		panic(err)
+	}
+
+	for _, k := range []int{3, 10, 19999} {
+		v, ok, err := db.Get(demoKey(k))
+		if err != nil {
+			panic(err)
+		}
+		fmt.Printf("get %s -> %q found=%v\n", demoKey(k), v, ok)
+	}
+
+	count := 0
+	if err := db.Scan(demoKey(100), demoKey(199), func(key, value []byte) bool {
+		count++
+		return true
+	}); err != nil {
+		panic(err)
+	}
+	fmt.Printf("scan [%s, %s] -> %d keys (every 10th deleted)\n", demoKey(100), demoKey(199), count)
+
+	count = 0
+	if err := db.ScanPrefix([]byte("key-0001"), func(key, value []byte) bool {
+		count++
+		return true
+	}); err != nil {
+		panic(err)
+	}
+	fmt.Printf("scan prefix %q -> %d keys\n", "key-0001", count)
+
+	// 만료 시각이 있는 값: 시간이 지나면 읽히지 않고, Vacuum 이 공간을 돌려받는다.
+	expiresAt := time.Now().Add(100 * time.Millisecond)
+	for i := 0; i < 2000; i++ {
+		if err := db.PutWithExpiry([]byte(fmt.Sprintf("session-%04d", i)), make([]byte, 64), expiresAt); err != nil {
+			panic(err)
+		}
+	}
+	if err := db.Flush(); err != nil {
+		panic(err)
+	}
+	before := db.Stats().DiskBytes()
+	time.Sleep(time.Until(expiresAt))
+	_, ok, err := db.Get([]byte("session-0042"))
+	if err != nil {
+		panic(err)
+	}
+	if err := db.Vacuum(); err != nil {
+		panic(err)
+	}
+	fmt.Printf("expired: get session-0042 found=%v, vacuum %d -> %d disk bytes\n", ok, before, db.Stats().DiskBytes())
+
+	fmt.Println()
+	db.Stats().Print(os.Stdout)
+
+	if err := db.Close(); err != nil {
+		panic(err)
+	}
+}
+
+// 바이트 순서와 숫자 순서가 같도록 0 을 채운다.
+func demoKey(k int) []byte {
+	return []byte(fmt.Sprintf("key-%08d", k))
+}
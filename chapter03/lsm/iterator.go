@@ -0,0 +1,19 @@
+package lsm
+
+import (
+	"bytes"
+
+	"github.com/tmdgusya/btree/merge"
+)
+
+// iterator 는 키 오름차순으로 entry 를 돌려준다.
+type iterator = merge.Iterator[entry]
+
+// newMergeIter 는 여러 iterator 를 키 순서로 합친다.
+// 같은 키가 여러 곳에 있으면 우선순위가 가장 높은 (sources 에서 앞에 있는) 것만 남긴다.
+// sources 는 새것부터 넣는다: memtable, L0 (최신 테이블부터), L1, L2, ...
+func newMergeIter(sources []iterator) iterator {
+	return merge.New(compareEntries, sources...)
+}
+
+func compareEntries(a, b entry) int { return bytes.Compare(a.key, b.key) }
@@ -0,0 +1,485 @@
+// Package lsm 은 B-Tree 와 나란히 비교하려고 만든 작은 LSM-Tree (Log-Structured Merge Tree) 엔진이다.
+//
+//   - 쓰기는 메모리의 memtable 에 모았다가 MemtableSize 를 넘으면 SSTable 하나로 L0 에 내린다 (flush).
+//   - L0 테이블은 서로 키 범위가 겹친다. L0Trigger 개가 쌓이면 L1 과 합쳐 겹치지 않는 테이블들로 다시 쓴다.
+//   - L1 이상은 레벨마다 크기 한도(LevelBase * LevelMultiplier^(n-1))가 있고, 넘으면 테이블 하나를 골라
+//     아래 레벨의 겹치는 테이블과 합친다 (leveled compaction).
+//   - 읽기는 memtable -> L0 (최신부터) -> L1 -> ... 순서로 찾고, 처음 찾은 값(또는 tombstone)이 답이다.
+//
+// SSTable 마다 Bloom filter 를 두어서 없는 키를 찾을 때는 블록을 읽지 않는다.
+// 모든 SSTable 은 iometrics.CountingFile 로 열어서 flush / compaction / get 별 I/O 를 리스트 비교 도구와
+// 같은 측정값으로 모은다. Stats 의 WriteAmplification / ReadAmplification 으로 두 인덱스 계열을 비교한다.
+//
+// PutWithExpiry 로 쓴 값은 만료 시각이 지나면 읽을 때 없는 것으로 보인다. 공간은 그 값을 담은 테이블이
+// 컴팩션되거나 Vacuum 이 다시 쓸 때 돌려받는다. SweepInterval 을 주면 Vacuum 을 주기적으로 돌린다.
+//
+// WAL 은 다루지 않는다. Close 없이 프로세스가 죽으면 memtable 에 있던 쓰기는 사라진다.
+package lsm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/tmdgusya/btree/bloom"
+	"github.com/tmdgusya/btree/iometrics"
+	"github.com/tmdgusya/btree/sstable"
+)
+
+var ErrClosed = errors.New("lsm: db is closed")
+var ErrEmptyKey = errors.New("lsm: empty key")
+
+// Options 는 엔진의 크기 설정. 0 인 필드는 DefaultOptions 의 값을 쓴다.
+// - MemtableSize: memtable 의 키 + 값 바이트가 이만큼 되면 flush
+// - TableSize: 컴팩션이 쓰는 SSTable 하나의 목표 크기
+// - L0Trigger: L0 테이블이 이만큼 쌓이면 L1 으로 컴팩션
+// - LevelBase / LevelMultiplier: L1 의 크기 한도와 레벨마다 늘어나는 배수
+// - Levels: 레벨 수 (L0 포함). 마지막 레벨은 한도 없이 쌓인다.
+// - BloomBitsPerKey: SSTable 마다 두는 Bloom filter 의 키당 비트 수. 음수면 필터를 두지 않는다.
+// - SweepInterval: 0 보다 크면 이 간격마다 백그라운드에서 Vacuum 을 돌린다. 0 이면 끈다 (기본값).
+type Options struct {
+	MemtableSize    int
+	TableSize       int64
+	L0Trigger       int
+	LevelBase       int64
+	LevelMultiplier int
+	Levels          int
+	BloomBitsPerKey int
+	SweepInterval   time.Duration
+}
+
+func DefaultOptions() Options {
+	return Options{
+		MemtableSize:    1 << 20,
+		TableSize:       2 << 20,
+		L0Trigger:       4,
+		LevelBase:       10 << 20,
+		LevelMultiplier: 10,
+		Levels:          7,
+		BloomBitsPerKey: bloom.DefaultBitsPerKey,
+	}
+}
+
+func (o Options) withDefaults() Options {
+	def := DefaultOptions()
+	if o.MemtableSize <= 0 {
+		o.MemtableSize = def.MemtableSize
+	}
+	if o.TableSize <= 0 {
+		o.TableSize = def.TableSize
+	}
+	if o.L0Trigger <= 0 {
+		o.L0Trigger = def.L0Trigger
+	}
+	if o.LevelBase <= 0 {
+		o.LevelBase = def.LevelBase
+	}
+	if o.LevelMultiplier <= 1 {
+		o.LevelMultiplier = def.LevelMultiplier
+	}
+	if o.Levels < 2 {
+		o.Levels = def.Levels
+	}
+	if o.BloomBitsPerKey == 0 {
+		o.BloomBitsPerKey = def.BloomBitsPerKey
+	}
+	return o
+}
+
+// DB 는 디렉터리 하나에 들어 있는 LSM 트리. 모든 메서드는 mu 하나로 직렬화한다.
+// flush 와 컴팩션도 백그라운드가 아니라 쓰기를 부른 고루틴에서 바로 돈다 (측정이 결정적이도록).
+// SweepInterval 로 켠 Vacuum 만 예외로 별도 고루틴에서 돈다.
+type DB struct {
+	mu     sync.Mutex
+	dir    string
+	opts   Options
+	mem    *memtable
+	levels [][]*table // levels[0] 은 최신 테이블부터
+	next   uint64     // 다음 SSTable 번호
+	closed bool
+
+	// 레벨마다 다음에 컴팩션할 키 (LevelDB 의 compact pointer). 레벨 전체를 돌아가며 고르게 내린다.
+	compactPointer [][]byte
+
+	userBytes int64
+	gets      int64
+	flushes   int64
+	compacts  int64
+	vacuums   int64
+	expired   int64 // 테이블을 다시 쓰면서 tombstone 으로 바꾸거나 버린 만료된 값 수
+	// 지워진 테이블과 다 쓴 writer 의 구간별 I/O 와 필터 결과. 살아 있는 테이블 것과 더해서 Stats 를 만든다.
+	retired      map[string]iometrics.IOMetrics
+	retiredBloom sstable.FilterStats
+
+	now       func() time.Time
+	stopSweep chan struct{} // SweepInterval 이 0 이면 nil
+	sweepDone chan struct{}
+	sweepErr  error // 백그라운드 Vacuum 의 첫 에러. Close 가 돌려준다.
+}
+
+// Open 은 dir 의 DB 를 연다. 없으면 디렉터리를 만든다.
+// MANIFEST 에 없는 .sst 파일은 컴팩션 중에 죽고 남은 것이므로 지운다.
+func Open(dir string, opts Options) (*DB, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	db := &DB{
+		dir:     dir,
+		opts:    opts.withDefaults(),
+		mem:     &memtable{},
+		retired: make(map[string]iometrics.IOMetrics),
+		now:     time.Now,
+	}
+	db.levels = make([][]*table, db.opts.Levels)
+	db.compactPointer = make([][]byte, db.opts.Levels)
+
+	m, err := readManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	db.next = m.Next
+	live := make(map[string]bool)
+	for level, nums := range m.Levels {
+		if level >= db.opts.Levels {
+			db.closeTables()
+			return nil, fmt.Errorf("lsm: manifest has %d levels, options allow %d", len(m.Levels), db.opts.Levels)
+		}
+		for _, num := range nums {
+			t, err := openTable(db.tablePath(num), num)
+			if err != nil {
+				db.closeTables()
+				return nil, err
+			}
+			t.minExpiry = m.Expiry[num]
+			db.levels[level] = append(db.levels[level], t)
+			live[filepath.Base(t.path)] = true
+		}
+	}
+
+	stray, _ := filepath.Glob(filepath.Join(dir, "*.sst"))
+	for _, p := range stray {
+		if !live[filepath.Base(p)] {
+			os.Remove(p)
+		}
+	}
+
+	if db.opts.SweepInterval > 0 {
+		db.stopSweep = make(chan struct{})
+		db.sweepDone = make(chan struct{})
+		go db.sweep()
+	}
+	return db, nil
+}
+
+func (db *DB) tablePath(num uint64) string {
+	return filepath.Join(db.dir, fmt.Sprintf("%06d.sst", num))
+}
+
+// Put 은 key 의 값을 value 로 바꾼다.
+func (db *DB) Put(key, value []byte) error {
+	return db.write(key, value, false, 0)
+}
+
+// PutWithExpiry 는 expiresAt 이 지나면 없어지는 값을 쓴다. expiresAt 이 zero 값이면 Put 과 같다.
+func (db *DB) PutWithExpiry(key, value []byte, expiresAt time.Time) error {
+	var expires int64
+	if !expiresAt.IsZero() {
+		expires = expiresAt.UnixNano()
+	}
+	return db.write(key, value, false, expires)
+}
+
+// Delete 는 key 를 지운다. 아래 레벨의 값을 가리는 tombstone 을 쓴다.
+func (db *DB) Delete(key []byte) error {
+	return db.write(key, nil, true, 0)
+}
+
+func (db *DB) write(key, value []byte, tomb bool, expires int64) error {
+	if len(key) == 0 {
+		return ErrEmptyKey
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.closed {
+		return ErrClosed
+	}
+
+	db.mem.put(key, value, tomb, expires)
+	db.userBytes += int64(len(key) + len(value))
+	if db.mem.size < db.opts.MemtableSize {
+		return nil
+	}
+	if err := db.flush(); err != nil {
+		return err
+	}
+	return db.compact()
+}
+
+// Get 은 key 의 값을 돌려준다. 없거나 지워졌으면 ok 가 false.
+func (db *DB) Get(key []byte) (value []byte, ok bool, err error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.closed {
+		return nil, false, ErrClosed
+	}
+	db.gets++
+	now := db.now().UnixNano()
+
+	if e, found := db.mem.get(key); found {
+		return liveValue(e, now)
+	}
+	for level, tables := range db.levels {
+		for _, t := range tables {
+			// L1 이상은 키 범위가 겹치지 않으므로 범위가 맞는 테이블 하나만 읽게 된다.
+			if level > 0 && !t.overlaps(key, key) {
+				continue
+			}
+			end := t.cf.Section("get")
+			e, found, err := t.get(key)
+			end()
+			if err != nil {
+				return nil, false, err
+			}
+			if found {
+				return liveValue(e, now)
+			}
+		}
+	}
+	return nil, false, nil
+}
+
+func liveValue(e entry, now int64) ([]byte, bool, error) {
+	if e.tomb || e.expired(now) {
+		return nil, false, nil
+	}
+	return bytes.Clone(e.value), true, nil
+}
+
+// Scan 은 lo <= key <= hi 인 키를 오름차순으로 fn 에 넘긴다. hi 가 nil 이면 끝까지. fn 이 false 면 멈춘다.
+func (db *DB) Scan(lo, hi []byte, fn func(key, value []byte) bool) error {
+	return db.scan(lo, hi, func(key []byte) bool { return hi == nil || bytes.Compare(key, hi) <= 0 }, fn)
+}
+
+// ScanPrefix 는 prefix 로 시작하는 키를 오름차순으로 fn 에 넘긴다. fn 이 false 면 멈춘다.
+// prefix 로 seek 한 뒤 prefix 가 맞지 않는 첫 키에서 멈추므로 "user:" 같은 이름 공간 하나만 읽는다.
+func (db *DB) ScanPrefix(prefix []byte, fn func(key, value []byte) bool) error {
+	return db.scan(prefix, prefixEnd(prefix), func(key []byte) bool { return bytes.HasPrefix(key, prefix) }, fn)
+}
+
+// prefixEnd 는 prefix 로 시작하는 모든 키보다 큰 가장 작은 키. prefix 가 0xff 로만 되어 있으면 nil (끝까지).
+func prefixEnd(prefix []byte) []byte {
+	end := bytes.Clone(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
+
+// scan 은 lo 부터 돌면서 within 이 false 인 첫 키에서 멈춘다.
+// hi 는 테이블을 고르는 데만 쓴다: 첫 키가 hi 보다 큰 테이블은 열지 않는다.
+func (db *DB) scan(lo, hi []byte, within func(key []byte) bool, fn func(key, value []byte) bool) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.closed {
+		return ErrClosed
+	}
+
+	sources := []iterator{db.mem.iter(lo)}
+	for _, tables := range db.levels {
+		for _, t := range tables {
+			if t.overlaps(lo, hi) {
+				sources = append(sources, t.iter(lo))
+			}
+		}
+	}
+	now := db.now().UnixNano()
+	it := newMergeIter(sources)
+	for it.Next() {
+		e := it.Entry()
+		if !within(e.key) {
+			break
+		}
+		if e.tomb || e.expired(now) {
+			continue
+		}
+		if !fn(e.key, e.value) {
+			break
+		}
+	}
+	return it.Err()
+}
+
+// Flush 는 memtable 을 지금 L0 로 내리고 필요한 컴팩션을 돈다.
+func (db *DB) Flush() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.closed {
+		return ErrClosed
+	}
+	if err := db.flush(); err != nil {
+		return err
+	}
+	return db.compact()
+}
+
+func (db *DB) flush() error {
+	if len(db.mem.entries) == 0 {
+		return nil
+	}
+	t, err := db.writeTable("flush", newMergeIter([]iterator{db.mem.iter(nil)}), false)
+	if err != nil {
+		return err
+	}
+	db.levels[0] = append([]*table{t}, db.levels[0]...)
+	if err := db.saveManifest(); err != nil {
+		return err
+	}
+	db.mem = &memtable{}
+	db.flushes++
+	return nil
+}
+
+// writeTable 은 it 전체를 SSTable 하나로 쓰고 연다. dropTombstones 면 tombstone 은 버린다.
+func (db *DB) writeTable(section string, it iterator, dropTombstones bool) (*table, error) {
+	tables, err := db.writeTables(section, it, dropTombstones, 0)
+	if err != nil || len(tables) == 0 {
+		return nil, err
+	}
+	return tables[0], nil
+}
+
+// writeTables 는 it 를 테이블들로 나눠 쓴다. split 이 0 보다 크면 데이터가 split 바이트를 넘을 때마다 새 테이블을 시작한다.
+func (db *DB) writeTables(section string, it iterator, dropTombstones bool, split int64) ([]*table, error) {
+	var out []*table
+	var w *tableWriter
+	var num uint64
+	var minExpiry int64
+	now := db.now().UnixNano()
+
+	fail := func(err error) ([]*table, error) {
+		if w != nil {
+			w.abort()
+		}
+		for _, t := range out {
+			t.close()
+			os.Remove(t.path)
+		}
+		return nil, err
+	}
+	finish := func() error {
+		m, err := w.finish()
+		db.retire(section, m)
+		w = nil
+		if err != nil {
+			return err
+		}
+		t, err := openTable(db.tablePath(num), num)
+		if err != nil {
+			return err
+		}
+		t.minExpiry, minExpiry = minExpiry, 0
+		out = append(out, t)
+		return nil
+	}
+
+	for it.Next() {
+		e := it.Entry()
+		if e.expired(now) {
+			// 아래 레벨의 예전 값이 다시 보이지 않도록 바로 버리지 않고 tombstone 으로 바꾼다.
+			e = entry{key: e.key, tomb: true}
+			db.expired++
+		}
+		if dropTombstones && e.tomb {
+			continue
+		}
+		if e.expires != 0 && (minExpiry == 0 || e.expires < minExpiry) {
+			minExpiry = e.expires
+		}
+		if w == nil {
+			num = db.next
+			db.next++
+			var err error
+			if w, err = createTable(db.tablePath(num), db.opts.BloomBitsPerKey); err != nil {
+				return fail(err)
+			}
+		}
+		if err := w.add(e); err != nil {
+			return fail(err)
+		}
+		if split > 0 && w.size() >= split {
+			if err := finish(); err != nil {
+				return fail(err)
+			}
+		}
+	}
+	if err := it.Err(); err != nil {
+		return fail(err)
+	}
+	if w != nil {
+		if err := finish(); err != nil {
+			return fail(err)
+		}
+	}
+	return out, nil
+}
+
+func (db *DB) retire(section string, m iometrics.IOMetrics) {
+	db.retired[section] = db.retired[section].Add(m)
+}
+
+// dropTable 은 컴팩션 입력으로 쓰인 테이블을 닫고 지운다. 측정값은 retired 로 옮긴다.
+func (db *DB) dropTable(t *table) {
+	for _, s := range t.cf.Sections() {
+		db.retire(s.Name, s.IO)
+	}
+	db.retiredBloom = db.retiredBloom.Add(t.r.FilterStats())
+	t.close()
+	os.Remove(t.path)
+}
+
+// Close 는 memtable 을 내리고 파일을 닫는다. 백그라운드 Vacuum 이 실패했으면 그 에러도 여기서 돌려준다.
+func (db *DB) Close() error {
+	db.mu.Lock()
+	if db.closed {
+		db.mu.Unlock()
+		return nil
+	}
+	err := db.flush()
+	if err == nil {
+		err = db.compact()
+	}
+	db.closeTables()
+	db.closed = true
+	if err == nil {
+		err = db.sweepErr
+	}
+	db.mu.Unlock()
+
+	// sweep 은 closed 를 보고 멈추므로 잠금을 푼 뒤에 기다린다.
+	if db.stopSweep != nil {
+		close(db.stopSweep)
+		<-db.sweepDone
+	}
+	return err
+}
+
+func (db *DB) closeTables() {
+	for _, tables := range db.levels {
+		for _, t := range tables {
+			t.close()
+		}
+	}
+}
+
+// sortTables 는 L1 이상의 테이블을 첫 키 순서로 정렬한다.
+func sortTables(tables []*table) {
+	slices.SortFunc(tables, func(a, b *table) int { return bytes.Compare(a.first, b.first) })
+}
@@ -0,0 +1,72 @@
+package lsm
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// MANIFEST 는 어떤 SSTable 이 어느 레벨에 있는지 기록한다.
+// 임시 파일에 쓰고 fsync 한 뒤 rename 으로 바꾸므로, 도중에 죽어도 예전 것과 새것 중 하나가 남는다.
+const manifestName = "MANIFEST"
+
+// Expiry 는 만료되는 값이 있는 테이블의 가장 빠른 만료 시각. Vacuum 이 테이블을 열어 보지 않고 고른다.
+type manifest struct {
+	Next   uint64           `json:"next"`
+	Levels [][]uint64       `json:"levels"`
+	Expiry map[uint64]int64 `json:"expiry,omitempty"`
+}
+
+func readManifest(dir string) (manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, manifestName))
+	if errors.Is(err, os.ErrNotExist) {
+		return manifest{Next: 1}, nil
+	}
+	if err != nil {
+		return manifest{}, err
+	}
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return manifest{}, err
+	}
+	return m, nil
+}
+
+func (db *DB) saveManifest() error {
+	m := manifest{Next: db.next, Levels: make([][]uint64, len(db.levels))}
+	for level, tables := range db.levels {
+		m.Levels[level] = []uint64{}
+		for _, t := range tables {
+			m.Levels[level] = append(m.Levels[level], t.num)
+			if t.minExpiry != 0 {
+				if m.Expiry == nil {
+					m.Expiry = make(map[uint64]int64)
+				}
+				m.Expiry[t.num] = t.minExpiry
+			}
+		}
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	tmp := filepath.Join(db.dir, manifestName+".tmp")
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(db.dir, manifestName))
+}
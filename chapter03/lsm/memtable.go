@@ -0,0 +1,65 @@
+package lsm
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/tmdgusya/btree/merge"
+)
+
+// entry 는 키 하나의 최신 상태. Delete 는 값 대신 tombstone 을 남긴다.
+// 아래 레벨에 남아 있는 예전 값을 가려야 하므로 바로 지울 수 없고, 가장 아래 레벨로 컴팩션될 때 사라진다.
+// expires 는 값이 만료되는 시각 (unix 나노초, 0 이면 만료 없음). 만료된 값은 읽을 때 없는 것으로 보고,
+// 테이블을 다시 쓸 때 tombstone 으로 바뀐다.
+type entry struct {
+	key     []byte
+	value   []byte
+	tomb    bool
+	expires int64
+}
+
+func (e entry) expired(now int64) bool {
+	return e.expires != 0 && e.expires <= now
+}
+
+// memtable 은 아직 디스크에 내려가지 않은 쓰기를 키 순서로 들고 있다.
+// 튜토리얼이라 스킵 리스트 대신 정렬된 슬라이스에 이진 탐색으로 끼워 넣는다.
+// 끼워 넣기가 O(n) 이지만 memtable 은 MemtableSize 를 넘으면 바로 내려가므로 작다.
+type memtable struct {
+	entries []entry
+	size    int // 키 + 값 바이트 합. flush 시점을 정한다.
+}
+
+func (m *memtable) search(key []byte) int {
+	return sort.Search(len(m.entries), func(i int) bool {
+		return bytes.Compare(m.entries[i].key, key) >= 0
+	})
+}
+
+// put 은 key 의 값을 바꾼다. 호출한 쪽의 슬라이스를 재사용해도 되도록 복사해서 보관한다.
+func (m *memtable) put(key, value []byte, tomb bool, expires int64) {
+	e := entry{key: bytes.Clone(key), value: bytes.Clone(value), tomb: tomb, expires: expires}
+	i := m.search(key)
+	if i < len(m.entries) && bytes.Equal(m.entries[i].key, key) {
+		m.size += len(value) - len(m.entries[i].value)
+		m.entries[i] = e
+		return
+	}
+	m.entries = append(m.entries, entry{})
+	copy(m.entries[i+1:], m.entries[i:])
+	m.entries[i] = e
+	m.size += len(key) + len(value)
+}
+
+func (m *memtable) get(key []byte) (entry, bool) {
+	i := m.search(key)
+	if i < len(m.entries) && bytes.Equal(m.entries[i].key, key) {
+		return m.entries[i], true
+	}
+	return entry{}, false
+}
+
+// iter 는 lo 이상인 키부터 차례로 돈다.
+func (m *memtable) iter(lo []byte) iterator {
+	return merge.Slice(m.entries[m.search(lo):])
+}
@@ -0,0 +1,545 @@
+package lsm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ==================================
+// RESP 서버 (`btree resp <dir>`)
+// ==================================
+// Redis 프로토콜(RESP2)의 일부로 DB 를 띄운다. redis-cli 를 그대로 붙여서 엔진을 만져 볼 수 있다.
+// - PING [message]
+// - GET key                           없으면 null bulk
+// - SET key value [EX seconds | PX ms] EX / PX 는 PutWithExpiry 로 쓴다
+// - DEL key [key ...]                 지운 키 수
+// - SCAN cursor [MATCH pattern] [COUNT n]
+// - COMMAND                           redis-cli 가 시작할 때 묻는다. 빈 배열
+// - QUIT
+// 요청은 bulk string 배열과 한 줄짜리 inline 명령("GET k") 둘 다 받는다. 파이프라인으로 보낸 명령은
+// 읽어 둔 요청을 다 처리한 뒤에 응답을 한꺼번에 flush 한다.
+//
+// SCAN 의 cursor 는 다음에 볼 첫 키를 hex 로 적은 것이다 ("0" 은 처음과 끝). 페이지마다 그 키로 seek 하므로
+// 한 페이지의 비용은 COUNT 에 비례하고, 연결을 다시 맺어도 이어 갈 수 있다. 순회 내내 있던 키는 정확히 한 번 돌려주고,
+// 순회 도중에 생기거나 지워진 키는 나올 수도 안 나올 수도 있다 (Redis 의 SCAN 과 같은 보장).
+// redis-cli --scan 은 cursor 를 숫자로 읽으므로 이 cursor 로는 쓸 수 없다. 대화형 SCAN 은 그대로 된다.
+//
+// DEL 은 키마다 Get 으로 있는지 본 뒤 Delete 한다. 두 호출 사이에 다른 연결이 같은 키를 쓰면 개수가 어긋날 수 있다.
+
+// 요청 크기 한도. 한도를 넘는 길이를 적은 요청은 메모리를 잡기 전에 protocol error 로 끊는다.
+const (
+	respMaxArgs       = 1 << 16
+	respMaxBulkLen    = 64 << 20
+	respMaxInlineLen  = 64 << 10
+	respDefaultCount  = 10
+	respIdleTimeout   = 5 * time.Minute
+	respShutdownGrace = 5 * time.Second
+)
+
+var errRESPQuit = errors.New("resp: client quit")
+
+// respProtocolError 는 요청을 해석할 수 없는 경우. 응답을 보낸 뒤 연결을 끊는다.
+type respProtocolError struct{ msg string }
+
+func (e *respProtocolError) Error() string { return "Protocol error: " + e.msg }
+
+// ServeRESP 는 addr 에서 RESP 연결을 받아 db 에 명령을 실행한다.
+// ctx 가 취소되면 리스너와 열린 연결을 닫고, 처리 중인 명령이 끝나기를 기다린 뒤 nil 을 돌려준다. db 는 닫지 않는다.
+func ServeRESP(ctx context.Context, addr string, db *DB) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	slog.Info("lsm RESP server listening", "dir", db.dir, "addr", l.Addr().String())
+	return ServeRESPListener(ctx, l, db)
+}
+
+// ServeRESPListener 는 이미 연 l 로 받는 ServeRESP. 테스트처럼 ":0" 으로 연 포트를 먼저 알아야 할 때 쓴다.
+func ServeRESPListener(ctx context.Context, l net.Listener, db *DB) error {
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		conns = make(map[net.Conn]struct{})
+	)
+	stop := context.AfterFunc(ctx, func() {
+		l.Close()
+		mu.Lock()
+		for c := range conns {
+			// 읽기만 끊는다. 처리 중인 명령은 응답을 쓰고 다음 읽기에서 멈춘다.
+			c.SetReadDeadline(time.Now())
+		}
+		mu.Unlock()
+	})
+	defer stop()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() == nil {
+				l.Close()
+				return err
+			}
+			break
+		}
+		mu.Lock()
+		conns[conn] = struct{}{}
+		mu.Unlock()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serveRESPConn(ctx, conn, db)
+			mu.Lock()
+			delete(conns, conn)
+			mu.Unlock()
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() { wg.Wait(); close(done) }()
+	select {
+	case <-done:
+	case <-time.After(respShutdownGrace):
+		mu.Lock()
+		for c := range conns {
+			c.Close()
+		}
+		mu.Unlock()
+		<-done
+	}
+	return nil
+}
+
+func serveRESPConn(ctx context.Context, conn net.Conn, db *DB) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	for {
+		// 마감을 늘린 뒤에 ctx 를 본다. ServeRESPListener 가 ctx 취소 뒤에 건 "지금" 마감을 여기서 덮어써도 놓치지 않는다.
+		conn.SetReadDeadline(time.Now().Add(respIdleTimeout))
+		if ctx.Err() != nil {
+			return
+		}
+		args, err := readRESPCommand(r)
+		if err != nil {
+			var perr *respProtocolError
+			if errors.As(err, &perr) {
+				writeRESPError(w, "ERR "+perr.Error())
+				w.Flush()
+			} else if err != io.EOF && ctx.Err() == nil {
+				slog.Debug("resp connection closed", "remote", conn.RemoteAddr().String(), "err", err)
+			}
+			return
+		}
+		if len(args) == 0 {
+			continue // 빈 inline 줄
+		}
+		err = execRESP(w, db, args)
+		// 파이프라인으로 보낸 명령이 더 읽혀 있으면 응답을 모았다가 한꺼번에 보낸다.
+		if errors.Is(err, errRESPQuit) || r.Buffered() == 0 {
+			if ferr := w.Flush(); ferr != nil {
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// ==================================
+// 요청 읽기
+// ==================================
+
+// readRESPCommand 는 명령 하나를 읽는다. "*<n>\r\n" 으로 시작하면 bulk string 배열, 아니면 공백으로 나눈 inline 명령.
+func readRESPCommand(r *bufio.Reader) ([][]byte, error) {
+	line, err := readRESPLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 || line[0] != '*' {
+		return bytes.Fields(line), nil
+	}
+	n, err := parseRESPLen(line[1:], respMaxArgs, "multibulk length")
+	if err != nil {
+		return nil, err
+	}
+	args := make([][]byte, 0, max(n, 0))
+	for i := 0; i < n; i++ {
+		line, err := readRESPLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 || line[0] != '$' {
+			return nil, &respProtocolError{fmt.Sprintf("expected '$', got '%s'", respFirstByte(line))}
+		}
+		size, err := parseRESPLen(line[1:], respMaxBulkLen, "bulk length")
+		if err != nil {
+			return nil, err
+		}
+		if size < 0 {
+			return nil, &respProtocolError{"invalid bulk length"}
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		if buf[size] != '\r' || buf[size+1] != '\n' {
+			return nil, &respProtocolError{"bulk string not terminated by CRLF"}
+		}
+		args = append(args, buf[:size])
+	}
+	return args, nil
+}
+
+// readRESPLine 은 CRLF(또는 LF)로 끝나는 줄을 끝 문자 없이 돌려준다.
+func readRESPLine(r *bufio.Reader) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := r.ReadSlice('\n')
+		line = append(line, chunk...)
+		if len(line) > respMaxInlineLen {
+			return nil, &respProtocolError{"too big inline request"}
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			if err == io.EOF && len(line) > 0 {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		line = bytes.TrimSuffix(line[:len(line)-1], []byte{'\r'})
+		return line, nil
+	}
+}
+
+// parseRESPLen 은 "*" / "$" 뒤의 길이. -1 (null) 은 그대로 돌려주고, limit 를 넘으면 protocol error.
+func parseRESPLen(b []byte, limit int, what string) (int, error) {
+	n, err := strconv.Atoi(string(b))
+	if err != nil || n < -1 || n > limit {
+		return 0, &respProtocolError{"invalid " + what}
+	}
+	return n, nil
+}
+
+func respFirstByte(line []byte) string {
+	if len(line) == 0 {
+		return ""
+	}
+	return string(line[:1])
+}
+
+// ==================================
+// 명령 실행
+// ==================================
+
+// execRESP 는 명령 하나를 실행하고 응답을 w 에 쓴다. 연결을 끊어야 하면 에러를 돌려준다.
+// 명령 인자가 틀리거나 DB 가 실패한 것은 RESP 에러 응답으로 보내고 연결은 유지한다.
+func execRESP(w *bufio.Writer, db *DB, args [][]byte) error {
+	cmd := string(args[0])
+	name := strings.ToUpper(cmd)
+	args = args[1:]
+	switch name {
+	case "PING":
+		switch len(args) {
+		case 0:
+			writeRESPSimple(w, "PONG")
+		case 1:
+			writeRESPBulk(w, args[0])
+		default:
+			writeRESPArity(w, name)
+		}
+	case "GET":
+		if len(args) != 1 {
+			writeRESPArity(w, name)
+			break
+		}
+		value, ok, err := db.Get(args[0])
+		switch {
+		case err != nil:
+			writeRESPDBError(w, err)
+		case !ok:
+			writeRESPNull(w)
+		default:
+			writeRESPBulk(w, value)
+		}
+	case "SET":
+		execRESPSet(w, db, args)
+	case "DEL":
+		if len(args) == 0 {
+			writeRESPArity(w, name)
+			break
+		}
+		n := 0
+		for _, key := range args {
+			_, ok, err := db.Get(key)
+			if err == nil && ok {
+				err = db.Delete(key)
+				n++
+			}
+			if err != nil {
+				writeRESPDBError(w, err)
+				return nil
+			}
+		}
+		writeRESPInt(w, int64(n))
+	case "SCAN":
+		execRESPScan(w, db, args)
+	case "COMMAND":
+		w.WriteString("*0\r\n")
+	case "QUIT":
+		writeRESPSimple(w, "OK")
+		return errRESPQuit
+	default:
+		if len(cmd) > 64 {
+			cmd = cmd[:64] + "..."
+		}
+		writeRESPError(w, fmt.Sprintf("ERR unknown command '%s'", cmd))
+	}
+	return nil
+}
+
+// SET key value [EX seconds | PX milliseconds]
+func execRESPSet(w *bufio.Writer, db *DB, args [][]byte) {
+	if len(args) < 2 {
+		writeRESPArity(w, "SET")
+		return
+	}
+	key, value := args[0], args[1]
+	var ttl time.Duration
+	for opts := args[2:]; len(opts) > 0; opts = opts[2:] {
+		unit := time.Duration(0)
+		switch strings.ToUpper(string(opts[0])) {
+		case "EX":
+			unit = time.Second
+		case "PX":
+			unit = time.Millisecond
+		}
+		if unit == 0 || len(opts) < 2 || ttl != 0 {
+			writeRESPError(w, "ERR syntax error")
+			return
+		}
+		n, err := strconv.ParseInt(string(opts[1]), 10, 64)
+		if err != nil || n <= 0 || n > int64(1<<62/unit) {
+			writeRESPError(w, "ERR invalid expire time in 'set' command")
+			return
+		}
+		ttl = time.Duration(n) * unit
+	}
+
+	var err error
+	if ttl > 0 {
+		err = db.PutWithExpiry(key, value, time.Now().Add(ttl))
+	} else {
+		err = db.Put(key, value)
+	}
+	if err != nil {
+		writeRESPDBError(w, err)
+		return
+	}
+	writeRESPSimple(w, "OK")
+}
+
+// SCAN cursor [MATCH pattern] [COUNT n]
+// COUNT 는 돌려줄 키 수가 아니라 이번에 볼 키 수다 (Redis 와 같다). MATCH 에 걸러지면 빈 배열이 올 수도 있다.
+func execRESPScan(w *bufio.Writer, db *DB, args [][]byte) {
+	if len(args) == 0 || len(args)%2 != 1 {
+		writeRESPArity(w, "SCAN")
+		return
+	}
+	start, err := parseRESPCursor(args[0])
+	if err != nil {
+		writeRESPError(w, "ERR invalid cursor")
+		return
+	}
+	var pattern []byte
+	count := respDefaultCount
+	for opts := args[1:]; len(opts) > 0; opts = opts[2:] {
+		switch strings.ToUpper(string(opts[0])) {
+		case "MATCH":
+			pattern = opts[1]
+		case "COUNT":
+			n, err := strconv.Atoi(string(opts[1]))
+			if err != nil || n < 1 {
+				writeRESPError(w, "ERR value is not an integer or out of range")
+				return
+			}
+			count = n
+		default:
+			writeRESPError(w, "ERR syntax error")
+			return
+		}
+	}
+
+	var keys [][]byte
+	var next []byte // 이번에 보지 않은 첫 키. 끝까지 봤으면 nil
+	seen := 0
+	err = db.Scan(start, nil, func(key, _ []byte) bool {
+		if seen == count {
+			next = bytes.Clone(key)
+			return false
+		}
+		seen++
+		if pattern == nil || globMatch(pattern, key) {
+			keys = append(keys, bytes.Clone(key))
+		}
+		return true
+	})
+	if err != nil {
+		writeRESPDBError(w, err)
+		return
+	}
+
+	w.WriteString("*2\r\n")
+	writeRESPBulk(w, formatRESPCursor(next))
+	fmt.Fprintf(w, "*%d\r\n", len(keys))
+	for _, k := range keys {
+		writeRESPBulk(w, k)
+	}
+}
+
+// SCAN cursor 는 다음 페이지가 시작할 키의 hex 이고, "0" 은 처음 (요청) 또는 끝 (응답) 이다.
+// 키는 비어 있을 수 없으므로 hex 가 "0" 과 겹치지 않는다.
+func parseRESPCursor(b []byte) ([]byte, error) {
+	if string(b) == "0" {
+		return nil, nil
+	}
+	key, err := hex.DecodeString(string(b))
+	if err == nil && len(key) == 0 {
+		err = errors.New("empty cursor")
+	}
+	return key, err
+}
+
+func formatRESPCursor(key []byte) []byte {
+	if key == nil {
+		return []byte("0")
+	}
+	return hex.AppendEncode(nil, key)
+}
+
+// globMatch 는 Redis 의 MATCH 패턴: * (아무 바이트열), ? (바이트 하나), [abc] / [a-z] / [^a] (바이트 집합), \x (x 그대로).
+// path.Match 와 달리 '/' 를 특별히 다루지 않는다.
+func globMatch(pattern, s []byte) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 0 && pattern[0] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if globMatch(pattern, s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+			pattern, s = pattern[1:], s[1:]
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+			ok, rest := matchClass(pattern[1:], s[0])
+			if !ok {
+				return false
+			}
+			pattern, s = rest, s[1:]
+		default:
+			if pattern[0] == '\\' && len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			if len(s) == 0 || s[0] != pattern[0] {
+				return false
+			}
+			pattern, s = pattern[1:], s[1:]
+		}
+	}
+	return len(s) == 0
+}
+
+// matchClass 는 '[' 다음부터 ']' 까지의 집합에 c 가 드는지와 ']' 뒤의 패턴을 돌려준다.
+// 닫는 ']' 가 없으면 패턴 끝까지를 집합으로 본다 (Redis 와 같다).
+func matchClass(pattern []byte, c byte) (bool, []byte) {
+	negate := len(pattern) > 0 && pattern[0] == '^'
+	if negate {
+		pattern = pattern[1:]
+	}
+	match := false
+	for len(pattern) > 0 && pattern[0] != ']' {
+		lo := pattern[0]
+		if lo == '\\' && len(pattern) > 1 {
+			pattern = pattern[1:]
+			lo = pattern[0]
+		}
+		pattern = pattern[1:]
+		hi := lo
+		if len(pattern) > 1 && pattern[0] == '-' && pattern[1] != ']' {
+			hi = pattern[1]
+			pattern = pattern[2:]
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+		}
+		if lo <= c && c <= hi {
+			match = true
+		}
+	}
+	if len(pattern) > 0 {
+		pattern = pattern[1:] // ']'
+	}
+	return match != negate, pattern
+}
+
+// ==================================
+// 응답 쓰기
+// ==================================
+// bufio.Writer 의 에러는 다음 Flush 가 돌려주므로 여기서는 보지 않는다.
+
+func writeRESPSimple(w *bufio.Writer, s string) {
+	w.WriteString("+" + s + "\r\n")
+}
+
+// writeRESPError 는 msg 의 줄바꿈을 공백으로 바꾼다. 에러 응답은 한 줄이어야 한다.
+func writeRESPError(w *bufio.Writer, msg string) {
+	w.WriteString("-" + strings.NewReplacer("\r", " ", "\n", " ").Replace(msg) + "\r\n")
+}
+
+func writeRESPArity(w *bufio.Writer, name string) {
+	writeRESPError(w, fmt.Sprintf("ERR wrong number of arguments for '%s' command", strings.ToLower(name)))
+}
+
+func writeRESPDBError(w *bufio.Writer, err error) {
+	writeRESPError(w, "ERR "+err.Error())
+}
+
+func writeRESPInt(w *bufio.Writer, n int64) {
+	fmt.Fprintf(w, ":%d\r\n", n)
+}
+
+func writeRESPBulk(w *bufio.Writer, b []byte) {
+	fmt.Fprintf(w, "$%d\r\n", len(b))
+	w.Write(b)
+	w.WriteString("\r\n")
+}
+
+func writeRESPNull(w *bufio.Writer) {
+	w.WriteString("$-1\r\n")
+}
@@ -0,0 +1,249 @@
+package lsm
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startRESP 는 임시 디렉터리의 DB 를 RESP 로 띄우고 연결 하나를 돌려준다.
+func startRESP(t *testing.T) (*DB, net.Conn, *bufio.Reader) {
+	t.Helper()
+	db, err := Open(t.TempDir(), Options{MemtableSize: 4 << 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- ServeRESPListener(ctx, l, db) }()
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		cancel()
+		if err := <-served; err != nil {
+			t.Errorf("serve: %v", err)
+		}
+		db.Close()
+	})
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	return db, conn, bufio.NewReader(conn)
+}
+
+// respCommand 는 args 를 bulk string 배열로 인코딩한다 (redis-cli 가 보내는 모양).
+func respCommand(args ...string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(a), a)
+	}
+	return b.String()
+}
+
+// readReply 는 응답 하나를 읽어 한 줄 문자열로 펼친다. 배열은 "[a b]", null bulk 는 "(nil)".
+func readReply(t *testing.T, r *bufio.Reader) string {
+	t.Helper()
+	line, err := r.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	switch line[0] {
+	case '+', '-', ':':
+		return line
+	case '$':
+		var n int
+		fmt.Sscan(line[1:], &n)
+		if n < 0 {
+			return "(nil)"
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			t.Fatal(err)
+		}
+		return string(buf[:n])
+	case '*':
+		var n int
+		fmt.Sscan(line[1:], &n)
+		items := make([]string, n)
+		for i := range items {
+			items[i] = readReply(t, r)
+		}
+		return "[" + strings.Join(items, " ") + "]"
+	}
+	t.Fatalf("unexpected reply %q", line)
+	return ""
+}
+
+func TestRESPCommands(t *testing.T) {
+	_, conn, r := startRESP(t)
+	steps := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"PING"}, "+PONG"},
+		{[]string{"ping", "hello"}, "hello"},
+		{[]string{"GET", "k"}, "(nil)"},
+		{[]string{"SET", "k", "v1"}, "+OK"},
+		{[]string{"GET", "k"}, "v1"},
+		{[]string{"SET", "k", "v\r\n2"}, "+OK"},
+		{[]string{"GET", "k"}, "v\r\n2"},
+		{[]string{"SET", "other", "x"}, "+OK"},
+		{[]string{"DEL", "k", "missing", "other"}, ":2"},
+		{[]string{"GET", "k"}, "(nil)"},
+		{[]string{"DEL", "k"}, ":0"},
+		{[]string{"SET", "k", "v", "EX", "0"}, "-ERR invalid expire time in 'set' command"},
+		{[]string{"SET", "k", "v", "NX"}, "-ERR syntax error"},
+		{[]string{"SET", "k"}, "-ERR wrong number of arguments for 'set' command"},
+		{[]string{"SET", "", "v"}, "-ERR " + ErrEmptyKey.Error()},
+		{[]string{"FLUSHALL"}, "-ERR unknown command 'FLUSHALL'"},
+		{[]string{"COMMAND", "DOCS"}, "[]"},
+	}
+	for _, s := range steps {
+		if _, err := conn.Write([]byte(respCommand(s.args...))); err != nil {
+			t.Fatal(err)
+		}
+		if got := readReply(t, r); got != s.want {
+			t.Fatalf("%q = %q, want %q", s.args, got, s.want)
+		}
+	}
+}
+
+// 만료 시각이 지난 값은 GET 에서 보이지 않는다.
+func TestRESPSetExpiry(t *testing.T) {
+	_, conn, r := startRESP(t)
+	conn.Write([]byte(respCommand("SET", "k", "v", "PX", "50")))
+	if got := readReply(t, r); got != "+OK" {
+		t.Fatalf("SET PX = %q", got)
+	}
+	time.Sleep(100 * time.Millisecond)
+	conn.Write([]byte(respCommand("GET", "k")))
+	if got := readReply(t, r); got != "(nil)" {
+		t.Fatalf("GET after expiry = %q", got)
+	}
+}
+
+// 파이프라인으로 한꺼번에 보낸 명령과 inline 명령도 순서대로 응답한다.
+func TestRESPPipelineAndInline(t *testing.T) {
+	_, conn, r := startRESP(t)
+	req := respCommand("SET", "a", "1") + respCommand("SET", "b", "2") + "GET a\r\n" + "\r\n" + respCommand("GET", "b")
+	if _, err := conn.Write([]byte(req)); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"+OK", "+OK", "1", "2"} {
+		if got := readReply(t, r); got != want {
+			t.Fatalf("reply = %q, want %q", got, want)
+		}
+	}
+}
+
+// SCAN 을 cursor 0 부터 다시 0 이 나올 때까지 돌리면 MATCH 에 맞는 키를 한 번씩 받는다.
+// 페이지 사이에 cursor 앞쪽의 키를 지우고 넣어도 순회 내내 있던 키는 빠지거나 겹치지 않는다.
+func TestRESPScan(t *testing.T) {
+	db, conn, r := startRESP(t)
+	want := map[string]bool{}
+	for i := 0; i < 250; i++ {
+		key := fmt.Sprintf("user:%03d", i)
+		if i%5 == 0 {
+			key = fmt.Sprintf("order:%03d", i)
+		} else {
+			want[key] = true
+		}
+		if err := db.Put([]byte(key), []byte("v")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := map[string]bool{}
+	cursor := "0"
+	for rounds := 0; ; rounds++ {
+		if rounds > 100 {
+			t.Fatal("scan did not finish")
+		}
+		conn.Write([]byte(respCommand("SCAN", cursor, "MATCH", "user:*", "COUNT", "17")))
+		// [cursor [key ...]]
+		fields := strings.Fields(strings.NewReplacer("[", "", "]", "").Replace(readReply(t, r)))
+		cursor = fields[0]
+		for _, k := range fields[1:] {
+			if got[k] {
+				t.Fatalf("scan returned %s twice", k)
+			}
+			got[k] = true
+		}
+		if cursor == "0" {
+			break
+		}
+		// 모든 user 키보다 앞에 오는 키를 하나 지우고 둘 넣는다.
+		db.Delete([]byte(fmt.Sprintf("order:%03d", rounds*5)))
+		db.Put([]byte(fmt.Sprintf("a:%03d", rounds)), []byte("v"))
+		db.Put([]byte(fmt.Sprintf("b:%03d", rounds)), []byte("v"))
+	}
+	if len(got) != len(want) {
+		t.Fatalf("scan returned %d keys, want %d", len(got), len(want))
+	}
+	for k := range want {
+		if !got[k] {
+			t.Fatalf("scan missed %s", k)
+		}
+	}
+
+	for _, bad := range []string{"xyz", "-1", ""} {
+		conn.Write([]byte(respCommand("SCAN", bad)))
+		if got := readReply(t, r); got != "-ERR invalid cursor" {
+			t.Fatalf("SCAN %q = %q", bad, got)
+		}
+	}
+}
+
+// 해석할 수 없는 요청은 에러를 보내고 연결을 끊는다.
+func TestRESPProtocolError(t *testing.T) {
+	_, conn, r := startRESP(t)
+	conn.Write([]byte("*1\r\n:5\r\n"))
+	if got := readReply(t, r); got != "-ERR Protocol error: expected '$', got ':'" {
+		t.Fatalf("reply = %q", got)
+	}
+	if _, err := r.ReadByte(); err == nil {
+		t.Fatal("connection still open after a protocol error")
+	}
+}
+
+func TestGlobMatch(t *testing.T) {
+	cases := []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"*", "", true},
+		{"*", "a/b", true},
+		{"user:*", "user:1", true},
+		{"user:*", "order:1", false},
+		{"h?llo", "hello", true},
+		{"h?llo", "hllo", false},
+		{"h*llo", "heeeello", true},
+		{"h[ae]llo", "hallo", true},
+		{"h[ae]llo", "hillo", false},
+		{"h[^e]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-b]llo", "hbllo", true},
+		{"h[a-b]llo", "hcllo", false},
+		{`h\*llo`, "h*llo", true},
+		{`h\*llo`, "hello", false},
+		{"a*b*c", "aXXbYYc", true},
+		{"a*b*c", "aXXbYY", false},
+	}
+	for _, c := range cases {
+		if got := globMatch([]byte(c.pattern), []byte(c.s)); got != c.want {
+			t.Errorf("globMatch(%q, %q) = %v, want %v", c.pattern, c.s, got, c.want)
+		}
+	}
+}
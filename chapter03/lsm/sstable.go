@@ -0,0 +1,136 @@
+package lsm
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/tmdgusya/btree/iometrics"
+	"github.com/tmdgusya/btree/sstable"
+)
+
+// ==================================
+// SSTable 파일
+// ==================================
+// 파일 형식은 sstable 패키지가 정한다 (data block + restart point + block index + footer).
+// 여기서는 파일을 CountingFile 로 감싸서 열고, sstable.Entry 와 이 패키지의 entry 를 오간다.
+
+// tableWriter 는 키 오름차순으로 add 된 entry 를 파일 하나로 쓴다.
+type tableWriter struct {
+	f  *os.File
+	cf *iometrics.CountingFile
+	w  *sstable.Writer
+}
+
+// bloomBitsPerKey 가 0 이하이면 필터 없이 쓴다.
+func createTable(path string, bloomBitsPerKey int) (*tableWriter, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, err
+	}
+	cf := iometrics.NewCountingFile(f)
+	return &tableWriter{f: f, cf: cf, w: sstable.NewWriter(cf, sstable.WriterOptions{BloomBitsPerKey: max(bloomBitsPerKey, 0)})}, nil
+}
+
+func (w *tableWriter) add(e entry) error {
+	return w.w.AddEntry(sstable.Entry{Key: e.key, Value: e.value, Deleted: e.tomb, ExpiresAt: e.expires})
+}
+
+// size 는 지금까지 쓴 크기. 컴팩션이 테이블을 나눌 때 본다.
+func (w *tableWriter) size() int64 { return w.w.Size() }
+
+// finish 는 index 와 footer 를 쓰고 fsync 한 뒤 닫는다. 돌려준 I/O 는 이 파일을 쓰는 데 든 전부다.
+func (w *tableWriter) finish() (iometrics.IOMetrics, error) {
+	if err := w.w.Close(); err != nil {
+		w.f.Close()
+		return w.cf.Metrics(), err
+	}
+	if err := w.cf.Sync(); err != nil {
+		w.f.Close()
+		return w.cf.Metrics(), err
+	}
+	return w.cf.Metrics(), w.f.Close()
+}
+
+// abort 는 쓰다 만 파일을 지운다.
+func (w *tableWriter) abort() {
+	w.f.Close()
+	os.Remove(w.f.Name())
+}
+
+// table 은 읽기용으로 열린 SSTable. block index 는 sstable.Reader 가 메모리에 들고 있다.
+type table struct {
+	num   uint64
+	path  string
+	f     *os.File
+	cf    *iometrics.CountingFile
+	r     *sstable.Reader
+	size  int64
+	first []byte
+	last  []byte
+
+	minExpiry int64 // 가장 빠른 만료 시각 (MANIFEST 에 기록). 0 이면 만료되는 값이 없다.
+}
+
+func openTable(path string, num uint64) (*table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	cf := iometrics.NewCountingFile(f)
+	end := cf.Section("open")
+	r, err := sstable.NewReader(cf, fi.Size())
+	end()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &table{num: num, path: path, f: f, cf: cf, r: r, size: fi.Size(), first: r.First(), last: r.Last()}, nil
+}
+
+// overlaps 는 [lo, hi] 와 키 범위가 겹치는지. hi 가 nil 이면 위쪽은 열려 있다.
+func (t *table) overlaps(lo, hi []byte) bool {
+	if t.r.Len() == 0 {
+		return false
+	}
+	if hi != nil && bytes.Compare(t.first, hi) > 0 {
+		return false
+	}
+	return bytes.Compare(t.last, lo) >= 0
+}
+
+func (t *table) get(key []byte) (entry, bool, error) {
+	if !t.overlaps(key, key) {
+		return entry{}, false, nil
+	}
+	e, ok, err := t.r.Get(key)
+	if err != nil || !ok {
+		return entry{}, false, err
+	}
+	return fromTable(e), true, nil
+}
+
+// iter 는 lo 이상인 키부터 블록 단위로 읽어 가며 돈다.
+func (t *table) iter(lo []byte) iterator {
+	return &tableIter{it: t.r.Iter(lo)}
+}
+
+func (t *table) close() error { return t.f.Close() }
+
+func fromTable(e sstable.Entry) entry {
+	return entry{key: e.Key, value: e.Value, tomb: e.Deleted, expires: e.ExpiresAt}
+}
+
+type tableIter struct {
+	it *sstable.Iterator
+}
+
+func (it *tableIter) Next() bool   { return it.it.Next() }
+func (it *tableIter) Entry() entry { return fromTable(it.it.Entry()) }
+func (it *tableIter) Err() error   { return it.it.Err() }
@@ -0,0 +1,125 @@
+package lsm
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/tmdgusya/btree/iometrics"
+	"github.com/tmdgusya/btree/sstable"
+)
+
+// LevelStats 는 레벨 하나의 테이블 수와 크기
+type LevelStats struct {
+	Tables int
+	Bytes  int64
+	Limit  int64 // 크기 한도. L0 와 마지막 레벨은 0 (L0 는 테이블 수로, 마지막 레벨은 한도 없음)
+
+	Entries int64 // 테이블에 든 entry 수 (tombstone 포함)
+	Blocks  int   // data block 수. Entries / Blocks 가 블록 하나의 평균 채움
+}
+
+// Stats 는 지금까지의 측정값. I/O 는 SSTable 파일에서만 센다 (MANIFEST 는 빠진다).
+type Stats struct {
+	UserBytes  int64 // Put / Delete 로 받은 키 + 값 바이트
+	Gets       int64
+	Flushes    int64
+	Compacts   int64
+	Vacuums    int64
+	Expired    int64 // 테이블을 다시 쓰면서 tombstone 으로 바꾸거나 버린 만료된 값 수
+	Levels     []LevelStats
+	Flush      iometrics.IOMetrics
+	Compaction iometrics.IOMetrics
+	Get        iometrics.IOMetrics
+	Open       iometrics.IOMetrics // 테이블을 열 때 footer / filter / index 를 읽은 양
+	Bloom      sstable.FilterStats
+}
+
+// WriteAmplification 은 사용자가 쓴 바이트 대비 디스크에 쓴 바이트 (flush + compaction).
+func (s Stats) WriteAmplification() float64 {
+	if s.UserBytes == 0 {
+		return 0
+	}
+	return float64(s.Flush.BytesWritten+s.Compaction.BytesWritten) / float64(s.UserBytes)
+}
+
+// ReadAmplification 은 Get 한 번당 파일 읽기 횟수. memtable 에서 찾은 Get 도 분모에 들어간다.
+func (s Stats) ReadAmplification() float64 {
+	if s.Gets == 0 {
+		return 0
+	}
+	return float64(s.Get.Reads) / float64(s.Gets)
+}
+
+// DiskBytes 는 살아 있는 SSTable 크기의 합
+func (s Stats) DiskBytes() int64 {
+	var n int64
+	for _, l := range s.Levels {
+		n += l.Bytes
+	}
+	return n
+}
+
+func (db *DB) Stats() Stats {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	sections := make(map[string]iometrics.IOMetrics, len(db.retired))
+	for name, m := range db.retired {
+		sections[name] = m
+	}
+	s := Stats{
+		UserBytes: db.userBytes,
+		Gets:      db.gets,
+		Flushes:   db.flushes,
+		Compacts:  db.compacts,
+		Vacuums:   db.vacuums,
+		Expired:   db.expired,
+		Bloom:     db.retiredBloom,
+	}
+	for level, tables := range db.levels {
+		ls := LevelStats{Tables: len(tables), Bytes: levelBytes(tables)}
+		if level > 0 && level < len(db.levels)-1 {
+			ls.Limit = db.levelLimit(level)
+		}
+		for _, t := range tables {
+			ls.Entries += t.r.Len()
+			ls.Blocks += t.r.Blocks()
+			for _, sec := range t.cf.Sections() {
+				sections[sec.Name] = sections[sec.Name].Add(sec.IO)
+			}
+			s.Bloom = s.Bloom.Add(t.r.FilterStats())
+		}
+		s.Levels = append(s.Levels, ls)
+	}
+	s.Flush = sections["flush"]
+	s.Compaction = sections["compaction"]
+	s.Get = sections["get"]
+	s.Open = sections["open"]
+	return s
+}
+
+// Print 는 레벨 모양과 증폭 비율을 사람이 읽을 수 있게 쓴다.
+func (s Stats) Print(w io.Writer) {
+	fmt.Fprintf(w, "user bytes=%d disk bytes=%d flushes=%d compactions=%d gets=%d\n",
+		s.UserBytes, s.DiskBytes(), s.Flushes, s.Compacts, s.Gets)
+	for i, l := range s.Levels {
+		if l.Tables == 0 {
+			continue
+		}
+		if l.Limit > 0 {
+			fmt.Fprintf(w, "  L%d: %d tables, %d bytes (limit %d)\n", i, l.Tables, l.Bytes, l.Limit)
+		} else {
+			fmt.Fprintf(w, "  L%d: %d tables, %d bytes\n", i, l.Tables, l.Bytes)
+		}
+	}
+	fmt.Fprintf(w, "write amplification=%.2f (flush %d B + compaction %d B written)\n",
+		s.WriteAmplification(), s.Flush.BytesWritten, s.Compaction.BytesWritten)
+	fmt.Fprintf(w, "read amplification=%.2f file reads per get (%d B read)\n",
+		s.ReadAmplification(), s.Get.BytesRead)
+	fmt.Fprintf(w, "bloom: %d checks, %d block reads skipped, false positive rate %.2f%%\n",
+		s.Bloom.Checks, s.Bloom.Negatives, s.Bloom.FalsePositiveRate()*100)
+	fmt.Fprintf(w, "compaction read %d B in %d calls\n", s.Compaction.BytesRead, s.Compaction.Reads)
+	if s.Vacuums > 0 || s.Expired > 0 {
+		fmt.Fprintf(w, "ttl: %d expired values reclaimed, %d vacuums\n", s.Expired, s.Vacuums)
+	}
+}
@@ -0,0 +1,83 @@
+package lsm
+
+import (
+	"slices"
+	"time"
+)
+
+// ==================================
+// 만료된 값 회수 (Vacuum)
+// ==================================
+// 만료된 값은 읽을 때 걸러지지만, 그 값을 담은 테이블이 컴팩션되기 전까지는 디스크에 남는다.
+// Vacuum 은 MANIFEST 의 테이블별 가장 빠른 만료 시각을 보고, 지금 만료된 값이 든 테이블만 골라 다시 쓴다.
+// - L0: 테이블끼리 키가 겹쳐서 하나만 내리면 순서가 깨지므로 L0 전부를 L1 으로
+// - L1 ~ 마지막 앞: 그 테이블 + 겹치는 아래 레벨 테이블을 아래 레벨로 (보통 컴팩션과 같다)
+// - 마지막 레벨: 그 테이블을 그 자리에서 다시 쓴다
+// 아래에 같은 키가 더 있으면 만료된 값은 tombstone 으로 남고, 가장 아래까지 내려가야 완전히 사라진다.
+
+// Vacuum 은 memtable 을 내리고 지금 만료된 값이 든 테이블을 모두 다시 쓴다.
+func (db *DB) Vacuum() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.closed {
+		return ErrClosed
+	}
+	return db.vacuum()
+}
+
+func (db *DB) vacuum() error {
+	if err := db.flush(); err != nil {
+		return err
+	}
+	// 다시 쓴 테이블의 minExpiry 는 now 보다 뒤이므로 같은 테이블을 두 번 고르지 않는다.
+	now := db.now().UnixNano()
+	for {
+		level, t := db.pickExpired(now)
+		if t == nil {
+			break
+		}
+		inputs := []*table{t}
+		if level == 0 {
+			inputs = slices.Clone(db.levels[0])
+		}
+		if err := db.compactTables(level, inputs); err != nil {
+			return err
+		}
+	}
+	db.vacuums++
+	// 테이블을 아래로 내리면서 레벨 한도를 넘었을 수 있다.
+	return db.compact()
+}
+
+// pickExpired 는 now 까지 만료된 값이 든 가장 위 레벨의 테이블. 없으면 nil.
+func (db *DB) pickExpired(now int64) (int, *table) {
+	for level, tables := range db.levels {
+		for _, t := range tables {
+			if t.minExpiry != 0 && t.minExpiry <= now {
+				return level, t
+			}
+		}
+	}
+	return -1, nil
+}
+
+// sweep 은 SweepInterval 마다 Vacuum 을 돈다. Close 가 stopSweep 을 닫으면 끝난다.
+func (db *DB) sweep() {
+	defer close(db.sweepDone)
+	ticker := time.NewTicker(db.opts.SweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-db.stopSweep:
+			return
+		case <-ticker.C:
+		}
+		db.mu.Lock()
+		if !db.closed {
+			if err := db.vacuum(); err != nil && db.sweepErr == nil {
+				db.sweepErr = err
+			}
+		}
+		db.mu.Unlock()
+	}
+}
@@ -0,0 +1,92 @@
+package logstore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Demo 는 log_demo 디렉터리에 작은 세그먼트 크기로 레코드를 덧붙여 세그먼트가 바뀌는 모습을 보이고, 끝이 잘린 쓰기를 흉내 낸 뒤
+// 다시 열어서 잘린 꼬리를 잘라 내고 남은 레코드를 재생한다. 마지막으로 checkpoint 를 옮겨 오래된 세그먼트가 지워지는 모습을 출력한다.
+// 튜토리얼 데모라서 실패하면 panic 한다.
+func Demo() {
+	const dir = "log_demo"
+	if err := os.RemoveAll(dir); err != nil {
+		panic(err)
+	}
+	// 헤더 12 바이트 + 레코드 20 바이트 남짓이므로 세그먼트 하나에 레코드 3 개씩 들어간다.
+	opts := Options{SegmentSize: 80}
+	log, err := Open(dir, opts)
+	if err != nil {
+		panic(err)
+	}
+
+	var positions []Position
+	for i := 1; i <= 10; i++ {
+		pos, err := log.Append(fmt.Appendf(nil, "set k%d = v%d", i, i))
+		if err != nil {
+			panic(err)
+		}
+		positions = append(positions, pos)
+		fmt.Printf("append record %2d at %s\n", i, pos)
+	}
+	if err := log.Sync(); err != nil {
+		panic(err)
+	}
+	segments := log.Segments()
+	if err := log.Close(); err != nil {
+		panic(err)
+	}
+	fmt.Printf("segments: %v\n", segments)
+
+	// 마지막 레코드를 쓰다가 죽은 것처럼, 길이만 적히고 데이터가 모자란 레코드를 붙인다.
+	last := filepath.Join(dir, segmentName(segments[len(segments)-1]))
+	f, err := os.OpenFile(last, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		panic(err)
+	}
+	if _, err := f.Write([]byte{0, 0, 0, 100, 1, 2, 3, 4, 's', 'e', 't'}); err != nil {
+		panic(err)
+	}
+	f.Close()
+
+	log, err = Open(dir, opts)
+	if err != nil {
+		panic(err)
+	}
+	defer log.Close()
+	fmt.Printf("\nreopened: truncated %d bytes of a torn record\n", log.Truncated())
+
+	n := 0
+	err = log.ReadAll(func(pos Position, data []byte) error {
+		n++
+		fmt.Printf("  replay %s %q\n", pos, data)
+		return nil
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("replayed %d records\n", n)
+
+	// 레코드 1~7 이 더 필요 없다면 (예: 그 변경이 모두 데이터 파일에 들어갔다면) 8 번 자리를 checkpoint 로 삼는다.
+	// 8 번이 든 세그먼트보다 앞의 세그먼트는 바로 지워지고, 재생은 8 번부터 한다.
+	if err := log.Checkpoint(positions[7]); err != nil {
+		panic(err)
+	}
+	manifest, err := os.ReadFile(filepath.Join(dir, manifestName))
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("\ncheckpoint at %s: segments %v\n  %s: %s\n", log.LastCheckpoint(), log.Segments(), manifestName, manifest)
+	err = log.ReadAll(func(pos Position, data []byte) error {
+		fmt.Printf("  replay %s %q\n", pos, data)
+		return nil
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	m := log.Metrics()
+	fmt.Printf("I/O: reads=%d writes=%d syncs=%d bytesWritten=%d\n", m.Reads, m.Writes, m.Syncs, m.BytesWritten)
+}
@@ -0,0 +1,509 @@
+// Package logstore 는 chapter04 의 append-only 레코드 로그다. 로그 구조 저장소(log-structured storage)의 가장 작은 형태로,
+// 한 번 쓴 바이트는 고치지 않고 항상 끝에만 덧붙인다.
+//
+//   - 레코드: Length(4) + CRC32(4) + 데이터. CRC 는 데이터를 덮는다.
+//   - 세그먼트: 레코드를 담는 파일 하나 (dir/000001.log ...). 앞에 Magic(4) + 세그먼트 번호(8) 헤더가 있다.
+//     지금 세그먼트가 SegmentSize 를 넘으려 하면 (또는 Rotate 를 부르면) 새 세그먼트로 넘어간다.
+//     이전 세그먼트는 더 이상 바뀌지 않으므로 필요 없어지면 파일째 지운다.
+//   - checkpoint: 로그를 쓰는 쪽이 "이 자리 앞의 레코드는 더 필요 없다"고 알려 주는 자리 (Checkpoint).
+//     checkpoint 가 든 세그먼트와 그 뒤의 세그먼트만 남기고, 앞의 세그먼트는 바로 지운다.
+//   - MANIFEST: 살아 있는 세그먼트 목록과 checkpoint. 세그먼트를 만들거나 지우기 전에 고쳐 쓰고,
+//     열 때 MANIFEST 에 없는 세그먼트 파일(만들다 죽었거나 지우다 죽은 것)은 지운다.
+//   - 재생: ReadAll 은 checkpoint 부터 세그먼트 번호 순서대로 레코드를 돌려준다.
+//
+// 덧붙이던 도중에 죽으면 마지막 레코드가 잘린 채 남는다. 다시 열 때 마지막 세그먼트를 끝까지 읽어서
+// 길이나 CRC 가 맞지 않는 첫 레코드부터 잘라 낸다. 마지막이 아닌 세그먼트가 중간에 깨져 있으면 ErrCorrupt 다.
+//
+// Pager 의 BufferPool 은 이 로그를 WAL 로 쓴다.
+package logstore
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/tmdgusya/btree/bufpool"
+	"github.com/tmdgusya/btree/codec"
+	"github.com/tmdgusya/btree/dberr"
+	"github.com/tmdgusya/btree/iometrics"
+)
+
+// 세그먼트 파일 이름: 6 자리 번호 + ".log"
+const segmentSuffix = ".log"
+
+var segmentMagic = [4]byte{'L', 'O', 'G', '1'}
+
+// 세그먼트 헤더: Magic(4) + 세그먼트 번호(8)
+const segmentHeaderSize = 4 + 8
+
+// 레코드 헤더: Length(4) + CRC32(4)
+const recordHeaderSize = 4 + 4
+
+// 레코드 하나의 최대 크기. 잘린 길이 필드를 믿고 거대한 버퍼를 잡지 않도록 막는다.
+const MaxRecordSize = 64 << 20
+
+// SegmentSize 를 주지 않았을 때 세그먼트 하나의 크기 한도
+const DefaultSegmentSize = 16 << 20
+
+var (
+	ErrCorrupt  = dberr.New(dberr.ErrCorruptPage, "logstore: corrupt segment")
+	ErrReadOnly = dberr.New(dberr.ErrReadOnly, "logstore: opened read-only")
+	ErrTooLarge = errors.New("logstore: record too large")
+)
+
+// ReadOnly 면 디렉터리와 파일을 만들거나 고치지 않는다. 잘린 꼬리도 그대로 두고 읽을 때만 건너뛴다.
+// SegmentSize 는 세그먼트 하나의 크기 한도 (0 이면 DefaultSegmentSize). 레코드 하나가 이보다 크면 그 세그먼트만 넘친다.
+type Options struct {
+	ReadOnly    bool
+	SegmentSize int64
+}
+
+// Position 은 레코드가 놓인 자리 (세그먼트 번호, 세그먼트 안의 바이트 오프셋)
+type Position struct {
+	Segment uint64 `json:"segment"`
+	Offset  int64  `json:"offset"`
+}
+
+// Less 는 p 가 o 보다 로그 앞쪽인지
+func (p Position) Less(o Position) bool {
+	if p.Segment != o.Segment {
+		return p.Segment < o.Segment
+	}
+	return p.Offset < o.Offset
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%06d:%d", p.Segment, p.Offset)
+}
+
+// Log 는 dir 아래 세그먼트 파일들로 이루어진 append-only 로그
+type Log struct {
+	dir         string
+	readOnly    bool
+	segmentSize int64
+	segments    []uint64 // 오름차순. 쓰기 모드에서는 마지막이 덧붙이는 중인 세그먼트다.
+	checkpoint  Position // 이 자리 앞의 레코드는 재생하지 않는다
+
+	active *iometrics.CountingFile // 쓰기 모드에서만
+	size   int64                   // active 의 끝 = 다음 레코드 자리
+
+	closed    iometrics.IOMetrics // 닫은 세그먼트와 ReadAll 이 연 파일의 I/O
+	truncated int64               // 열 때 잘라 낸 꼬리 바이트 수
+}
+
+func segmentName(num uint64) string {
+	return fmt.Sprintf("%06d%s", num, segmentSuffix)
+}
+
+func (l *Log) segmentPath(num uint64) string {
+	return filepath.Join(l.dir, segmentName(num))
+}
+
+// Open 은 dir 의 로그를 연다. 쓰기 모드면 dir 이 없을 때 만들고, 세그먼트가 없으면 1 번을 만든다.
+// 쓰기 모드에서는 MANIFEST 에 없는 세그먼트 파일을 지운다.
+func Open(dir string, opts Options) (*Log, error) {
+	if opts.SegmentSize == 0 {
+		opts.SegmentSize = DefaultSegmentSize
+	}
+	if opts.SegmentSize <= segmentHeaderSize {
+		return nil, fmt.Errorf("logstore: segment size %d is too small", opts.SegmentSize)
+	}
+	l := &Log{dir: dir, readOnly: opts.ReadOnly, segmentSize: opts.SegmentSize}
+	if !l.readOnly {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+	onDisk, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+	m, found, err := readManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		l.segments, l.checkpoint = m.Segments, m.Checkpoint
+	} else {
+		l.segments = onDisk // MANIFEST 가 없으면 (아직 한 번도 쓰지 않은 로그) 디렉터리를 믿는다
+	}
+	if l.readOnly {
+		return l, nil
+	}
+
+	for _, num := range onDisk {
+		if !slices.Contains(l.segments, num) {
+			if err := os.Remove(l.segmentPath(num)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(l.segments) == 0 {
+		if err := l.createSegment(1); err != nil {
+			return nil, err
+		}
+		return l, nil
+	}
+	if err := l.openActive(); err != nil {
+		return nil, err
+	}
+	if !found {
+		if err := l.saveManifest(); err != nil {
+			l.active.Close()
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// listSegments 는 dir 에 있는 세그먼트 번호들. dir 이 없으면 빈 목록.
+func listSegments(dir string) ([]uint64, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var out []uint64
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), segmentSuffix)
+		if !ok || e.IsDir() {
+			continue
+		}
+		num, err := strconv.ParseUint(name, 10, 64)
+		if err != nil {
+			continue
+		}
+		out = append(out, num)
+	}
+	slices.Sort(out)
+	return out, nil
+}
+
+// createSegment 는 헤더만 있는 새 세그먼트를 만들고 MANIFEST 에 올린 뒤 덧붙일 세그먼트로 삼는다.
+// 파일을 먼저 만들고 MANIFEST 를 고치므로, 그 사이에 죽으면 다음 Open 이 MANIFEST 에 없는 파일을 지운다.
+func (l *Log) createSegment(num uint64) error {
+	raw, err := os.OpenFile(l.segmentPath(num), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	f := iometrics.NewCountingFile(raw)
+	hdr := make([]byte, segmentHeaderSize)
+	w := codec.NewWriter(hdr)
+	w.Bytes(segmentMagic[:])
+	w.Uint64(num)
+	if _, err := f.WriteAt(hdr, 0); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	l.segments = append(l.segments, num)
+	if err := l.saveManifest(); err != nil {
+		l.segments = l.segments[:len(l.segments)-1]
+		f.Close()
+		return err
+	}
+	l.active, l.size = f, segmentHeaderSize
+	return nil
+}
+
+// openActive 는 마지막 세그먼트를 덧붙이기용으로 열고, 끝이 잘린 레코드가 있으면 잘라 낸다.
+func (l *Log) openActive() error {
+	num := l.segments[len(l.segments)-1]
+	raw, err := os.OpenFile(l.segmentPath(num), os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	f := iometrics.NewCountingFile(raw)
+	end, err := scanSegment(f, num, nil)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if end < size {
+		if err := f.Truncate(end); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Sync(); err != nil {
+			f.Close()
+			return err
+		}
+		l.truncated = size - end
+	}
+	l.active, l.size = f, end
+	return nil
+}
+
+// scanSegment 는 세그먼트 헤더를 확인하고 온전한 레코드를 차례로 fn 에 넘긴다 (fn 이 nil 이면 읽기만 한다).
+// 길이나 CRC 가 맞지 않는 첫 레코드 앞에서 멈추고 그 자리(마지막 온전한 레코드의 끝)를 돌려준다.
+func scanSegment(f io.ReaderAt, num uint64, fn func(pos Position, data []byte) error) (int64, error) {
+	hdr := make([]byte, segmentHeaderSize)
+	if _, err := f.ReadAt(hdr, 0); err != nil {
+		return 0, fmt.Errorf("%w: %s: %w", ErrCorrupt, segmentName(num), err)
+	}
+	hr := codec.NewReader(hdr)
+	var magic [4]byte
+	hr.Bytes(magic[:])
+	if magic != segmentMagic {
+		return 0, fmt.Errorf("%w: %s: %w", ErrCorrupt, segmentName(num), dberr.ErrInvalidMagic)
+	}
+	if got := hr.Uint64(); got != num {
+		return 0, fmt.Errorf("%w: %s: header says segment %d", ErrCorrupt, segmentName(num), got)
+	}
+
+	r := bufio.NewReader(io.NewSectionReader(f, segmentHeaderSize, 1<<62))
+	off := int64(segmentHeaderSize)
+	rh := make([]byte, recordHeaderSize)
+	var data []byte
+	for {
+		if _, err := io.ReadFull(r, rh); err != nil {
+			return off, nil // 끝 또는 잘린 헤더
+		}
+		rr := codec.NewReader(rh)
+		n, sum := rr.Uint32(), rr.Uint32()
+		if n > MaxRecordSize {
+			return off, nil
+		}
+		if cap(data) < int(n) {
+			data = make([]byte, n)
+		}
+		data = data[:n]
+		if _, err := io.ReadFull(r, data); err != nil {
+			return off, nil
+		}
+		if crc32.ChecksumIEEE(data) != sum {
+			return off, nil
+		}
+		if fn != nil {
+			if err := fn(Position{Segment: num, Offset: off}, data); err != nil {
+				return off, err
+			}
+		}
+		off += recordHeaderSize + int64(n)
+	}
+}
+
+// Append 는 data 를 레코드 하나로 덧붙이고 그 자리를 돌려준다. 디스크에 닿게 하려면 Sync 를 부른다.
+// 지금 세그먼트에 레코드가 있고 이 레코드로 SegmentSize 를 넘게 되면 먼저 새 세그먼트로 넘어간다.
+func (l *Log) Append(data []byte) (Position, error) {
+	if l.readOnly {
+		return Position{}, ErrReadOnly
+	}
+	if len(data) > MaxRecordSize {
+		return Position{}, fmt.Errorf("%w: %d bytes (max %d)", ErrTooLarge, len(data), MaxRecordSize)
+	}
+	if l.size > segmentHeaderSize && l.size+recordHeaderSize+int64(len(data)) > l.segmentSize {
+		if _, err := l.Rotate(); err != nil {
+			return Position{}, err
+		}
+	}
+	rp := bufpool.Get(recordHeaderSize + len(data))
+	defer bufpool.Put(rp)
+	rec := *rp
+	w := codec.NewWriter(rec)
+	w.Uint32(uint32(len(data)))
+	w.Uint32(crc32.ChecksumIEEE(data))
+	w.Bytes(data)
+
+	pos := Position{Segment: l.segments[len(l.segments)-1], Offset: l.size}
+	if _, err := l.active.WriteAt(rec, l.size); err != nil {
+		return Position{}, err
+	}
+	l.size += int64(len(rec))
+	return pos, nil
+}
+
+// Sync 는 덧붙이는 중인 세그먼트를 fsync 한다. 닫은 세그먼트는 Rotate 때 이미 fsync 했다.
+func (l *Log) Sync() error {
+	if l.readOnly {
+		return nil
+	}
+	return l.active.Sync()
+}
+
+// End 는 다음 레코드가 놓일 자리
+func (l *Log) End() Position {
+	if l.readOnly || len(l.segments) == 0 {
+		return Position{}
+	}
+	return Position{Segment: l.segments[len(l.segments)-1], Offset: l.size}
+}
+
+// Rotate 는 지금 세그먼트를 fsync 하고 닫은 뒤 다음 번호의 새 세그먼트를 시작한다. 새 세그먼트 번호를 돌려준다.
+func (l *Log) Rotate() (uint64, error) {
+	if l.readOnly {
+		return 0, ErrReadOnly
+	}
+	if err := l.active.Sync(); err != nil {
+		return 0, err
+	}
+	l.closed = l.closed.Add(l.active.Metrics())
+	if err := l.active.Close(); err != nil {
+		return 0, err
+	}
+	num := l.segments[len(l.segments)-1] + 1
+	if err := l.createSegment(num); err != nil {
+		return 0, err
+	}
+	return num, nil
+}
+
+// Checkpoint 는 pos 앞의 레코드가 더 필요 없다고 기록한다 (예: WAL 이면 그 앞의 변경이 모두 데이터 파일에 들어갔을 때).
+// 이후 ReadAll 은 pos 부터 돌려주고, pos 가 든 세그먼트보다 앞의 세그먼트는 MANIFEST 를 고친 뒤 지운다.
+// checkpoint 는 뒤로만 간다. 지금 checkpoint 보다 앞이거나 로그 끝을 넘는 pos 는 에러다.
+func (l *Log) Checkpoint(pos Position) error {
+	if l.readOnly {
+		return ErrReadOnly
+	}
+	if pos.Less(l.checkpoint) || l.End().Less(pos) || !slices.Contains(l.segments, pos.Segment) {
+		return fmt.Errorf("logstore: checkpoint %s is outside [%s, %s]", pos, l.checkpoint, l.End())
+	}
+	i := slices.Index(l.segments, pos.Segment)
+	obsolete := slices.Clone(l.segments[:i])
+	l.segments = slices.Delete(l.segments, 0, i)
+	l.checkpoint = pos
+	if err := l.saveManifest(); err != nil {
+		return err
+	}
+	for _, num := range obsolete {
+		if err := os.Remove(l.segmentPath(num)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// LastCheckpoint 는 마지막으로 기록한 checkpoint. 한 번도 없으면 0 번 세그먼트의 0 (로그 맨 앞).
+func (l *Log) LastCheckpoint() Position {
+	return l.checkpoint
+}
+
+// Segments 는 지금 있는 세그먼트 번호들 (오름차순)
+func (l *Log) Segments() []uint64 {
+	return slices.Clone(l.segments)
+}
+
+// Truncated 는 열 때 마지막 세그먼트에서 잘라 낸 꼬리 바이트 수
+func (l *Log) Truncated() int64 {
+	return l.truncated
+}
+
+// ReadAll 은 checkpoint 부터의 레코드를 세그먼트 번호, 오프셋 순서로 fn 에 넘긴다. fn 이 에러를 돌려주면 거기서 멈춘다.
+// data 는 다음 레코드를 읽을 때 덮이므로 fn 밖으로 내보내려면 복사한다.
+// 마지막 세그먼트의 잘린 꼬리는 건너뛰지만, 그 앞 세그먼트가 중간에 끊겨 있으면 ErrCorrupt 다.
+func (l *Log) ReadAll(fn func(pos Position, data []byte) error) error {
+	for i, num := range l.segments {
+		raw, err := os.Open(l.segmentPath(num))
+		if err != nil {
+			return err
+		}
+		f := iometrics.NewCountingFile(raw)
+		end, err := scanSegment(f, num, func(pos Position, data []byte) error {
+			if pos.Less(l.checkpoint) {
+				return nil
+			}
+			return fn(pos, data)
+		})
+		if err == nil && i < len(l.segments)-1 {
+			var size int64
+			if size, err = f.Seek(0, io.SeekEnd); err == nil && end < size {
+				err = fmt.Errorf("%w: %s: bad record at offset %d", ErrCorrupt, segmentName(num), end)
+			}
+		}
+		l.closed = l.closed.Add(f.Metrics())
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Metrics 는 이 로그가 연 모든 세그먼트 파일의 I/O 합
+func (l *Log) Metrics() iometrics.IOMetrics {
+	if l.active == nil {
+		return l.closed
+	}
+	return l.closed.Add(l.active.Metrics())
+}
+
+// Close 는 덧붙이는 중인 세그먼트를 닫는다. fsync 는 하지 않으므로 필요하면 먼저 Sync 를 부른다.
+func (l *Log) Close() error {
+	if l.active == nil {
+		return nil
+	}
+	l.closed = l.closed.Add(l.active.Metrics())
+	err := l.active.Close()
+	l.active = nil
+	return err
+}
+
+// ==================================
+// MANIFEST
+// ==================================
+// 살아 있는 세그먼트 목록과 checkpoint 를 JSON 으로 적는다.
+// 임시 파일에 쓰고 fsync 한 뒤 rename 으로 바꾸므로, 도중에 죽어도 예전 것과 새것 중 하나가 남는다.
+const manifestName = "MANIFEST"
+
+type manifest struct {
+	Segments   []uint64 `json:"segments"`
+	Checkpoint Position `json:"checkpoint"`
+}
+
+func readManifest(dir string) (manifest, bool, error) {
+	data, err := os.ReadFile(filepath.Join(dir, manifestName))
+	if errors.Is(err, os.ErrNotExist) {
+		return manifest{}, false, nil
+	}
+	if err != nil {
+		return manifest{}, false, err
+	}
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return manifest{}, false, fmt.Errorf("%w: %s: %w", ErrCorrupt, manifestName, err)
+	}
+	return m, true, nil
+}
+
+func (l *Log) saveManifest() error {
+	data, err := json.Marshal(manifest{Segments: l.segments, Checkpoint: l.checkpoint})
+	if err != nil {
+		return err
+	}
+
+	tmp := filepath.Join(l.dir, manifestName+".tmp")
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(l.dir, manifestName))
+}
@@ -0,0 +1,242 @@
+package logstore
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type record struct {
+	pos  Position
+	data []byte
+}
+
+func testRecord(i int) []byte {
+	return bytes.Repeat([]byte{byte(i)}, i%37)
+}
+
+// appendRecords 는 testRecord(from..to-1) 을 덧붙이고, group 개마다 (그리고 끝에) Sync 한다.
+func appendRecords(t *testing.T, l *Log, from, to, group int) []record {
+	t.Helper()
+	var out []record
+	for i := from; i < to; i++ {
+		data := testRecord(i)
+		pos, err := l.Append(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, record{pos, data})
+		if (i-from+1)%group == 0 || i == to-1 {
+			if err := l.Sync(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return out
+}
+
+func readAll(t *testing.T, l *Log) []record {
+	t.Helper()
+	var out []record
+	err := l.ReadAll(func(pos Position, data []byte) error {
+		out = append(out, record{pos, bytes.Clone(data)})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func checkRecords(t *testing.T, got, want []record) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("replayed %d records, want %d", len(got), len(want))
+	}
+	for i := range got {
+		if got[i].pos != want[i].pos || !bytes.Equal(got[i].data, want[i].data) {
+			t.Fatalf("record %d = %s %x, want %s %x", i, got[i].pos, got[i].data, want[i].pos, want[i].data)
+		}
+	}
+}
+
+func openLog(t *testing.T, dir string, opts Options) *Log {
+	t.Helper()
+	l, err := Open(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+// 덧붙인 레코드는 다시 열어서 재생하면 같은 자리, 같은 내용으로 나오고, 이어서 덧붙일 수 있다.
+func TestAppendReplay(t *testing.T) {
+	dir := t.TempDir()
+	l := openLog(t, dir, Options{})
+	want := appendRecords(t, l, 0, 200, 1)
+	end := l.End()
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	l = openLog(t, dir, Options{})
+	if l.End() != end || l.Truncated() != 0 {
+		t.Fatalf("reopened at %s with %d bytes truncated, want %s and 0", l.End(), l.Truncated(), end)
+	}
+	checkRecords(t, readAll(t, l), want)
+
+	want = append(want, appendRecords(t, l, 200, 250, 1)...)
+	checkRecords(t, readAll(t, l), want)
+
+	ro := openLog(t, dir, Options{ReadOnly: true})
+	checkRecords(t, readAll(t, ro), want)
+	if _, err := ro.Append([]byte("x")); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("read-only Append = %v, want ErrReadOnly", err)
+	}
+}
+
+// 마지막 레코드가 잘렸거나 CRC 가 맞지 않으면 다시 열 때 그 레코드부터 잘라 내고, 다음 레코드는 그 자리에 쓴다.
+func TestTornTailIsDropped(t *testing.T) {
+	cases := []struct {
+		name string
+		tear func(seg []byte, last Position) []byte
+	}{
+		{"cut in the record header", func(seg []byte, last Position) []byte { return seg[:last.Offset+3] }},
+		{"cut in the data", func(seg []byte, last Position) []byte { return seg[:len(seg)-1] }},
+		{"bad crc", func(seg []byte, last Position) []byte { seg[len(seg)-1] ^= 0xff; return seg }},
+		{"huge length", func(seg []byte, last Position) []byte { seg[last.Offset] = 0xff; return seg }},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			l := openLog(t, dir, Options{})
+			want := appendRecords(t, l, 1, 51, 10) // 마지막 레코드(50 번)는 빈 레코드가 아니다
+			l.Close()
+
+			path := filepath.Join(dir, segmentName(1))
+			seg, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			last := want[len(want)-1].pos
+			torn := c.tear(seg, last)
+			if err := os.WriteFile(path, torn, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			l = openLog(t, dir, Options{})
+			want = want[:len(want)-1]
+			if l.End() != last || l.Truncated() != int64(len(torn))-last.Offset {
+				t.Fatalf("reopened at %s with %d bytes truncated, want %s and %d", l.End(), l.Truncated(), last, int64(len(torn))-last.Offset)
+			}
+			checkRecords(t, readAll(t, l), want)
+
+			want = append(want, appendRecords(t, l, 100, 101, 1)...)
+			if want[len(want)-1].pos != last {
+				t.Fatalf("next record at %s, want the cut point %s", want[len(want)-1].pos, last)
+			}
+			checkRecords(t, readAll(t, l), want)
+		})
+	}
+}
+
+// 마지막이 아닌 세그먼트의 레코드가 깨져 있으면 잘라 내지 않고 ErrCorrupt 다.
+func TestCorruptOlderSegment(t *testing.T) {
+	dir := t.TempDir()
+	l := openLog(t, dir, Options{})
+	appendRecords(t, l, 1, 20, 5)
+	if _, err := l.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	appendRecords(t, l, 20, 30, 5)
+	l.Close()
+
+	path := filepath.Join(dir, segmentName(1))
+	seg, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seg[len(seg)-1] ^= 0xff
+	if err := os.WriteFile(path, seg, 0644); err != nil {
+		t.Fatal(err)
+	}
+	l = openLog(t, dir, Options{})
+	if err := l.ReadAll(func(Position, []byte) error { return nil }); !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("ReadAll = %v, want ErrCorrupt", err)
+	}
+}
+
+// 레코드 여러 개를 쓰고 Sync 를 한 번 하는 묶음 쓰기에서도, 세그먼트를 넘나들며 덧붙인 순서대로 재생된다.
+// 마지막 묶음을 Sync 하기 전에 끊기면 앞 묶음까지와 끊긴 묶음의 온전한 앞부분만 남는다.
+func TestGroupedSyncOrder(t *testing.T) {
+	dir := t.TempDir()
+	l := openLog(t, dir, Options{SegmentSize: 512})
+	want := appendRecords(t, l, 0, 300, 16)
+	if len(l.Segments()) < 5 {
+		t.Fatalf("only %d segments, the test wants rotations inside groups", len(l.Segments()))
+	}
+	for i := 1; i < len(want); i++ {
+		if !want[i-1].pos.Less(want[i].pos) {
+			t.Fatalf("record %d at %s is not after record %d at %s", i, want[i].pos, i-1, want[i-1].pos)
+		}
+	}
+
+	// Sync 하지 않은 묶음을 쓰다가 끊긴 것처럼, 마지막 레코드의 중간에서 자른다.
+	unsynced := make([]record, 0, 5)
+	for i := 300; i < 305; i++ {
+		pos, err := l.Append(testRecord(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		unsynced = append(unsynced, record{pos, testRecord(i)})
+	}
+	l.Close()
+	last := unsynced[len(unsynced)-1].pos
+	if err := os.Truncate(filepath.Join(dir, segmentName(last.Segment)), last.Offset+recordHeaderSize+1); err != nil {
+		t.Fatal(err)
+	}
+
+	l = openLog(t, dir, Options{SegmentSize: 512})
+	want = append(want, unsynced[:len(unsynced)-1]...)
+	checkRecords(t, readAll(t, l), want)
+}
+
+// checkpoint 앞의 레코드는 재생하지 않고, checkpoint 가 든 세그먼트보다 앞의 세그먼트는 지운다.
+func TestCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	l := openLog(t, dir, Options{SegmentSize: 512})
+	all := appendRecords(t, l, 0, 100, 10)
+	cp := all[60]
+	if err := l.Checkpoint(cp.pos); err != nil {
+		t.Fatal(err)
+	}
+	if l.Segments()[0] != cp.pos.Segment {
+		t.Fatalf("segments %v, want the first to be %d", l.Segments(), cp.pos.Segment)
+	}
+	if _, err := os.Stat(filepath.Join(dir, segmentName(1))); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("segment 1 still exists: %v", err)
+	}
+	if err := l.Checkpoint(all[10].pos); err == nil {
+		t.Fatal("checkpoint moved backwards")
+	}
+	l.Close()
+
+	l = openLog(t, dir, Options{SegmentSize: 512})
+	if l.LastCheckpoint() != cp.pos {
+		t.Fatalf("checkpoint after reopen = %s, want %s", l.LastCheckpoint(), cp.pos)
+	}
+	checkRecords(t, readAll(t, l), all[60:])
+}
+
+func TestAppendTooLarge(t *testing.T) {
+	l := openLog(t, t.TempDir(), Options{})
+	if _, err := l.Append(make([]byte, MaxRecordSize+1)); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("Append = %v, want ErrTooLarge", err)
+	}
+	if _, err := Open(t.TempDir(), Options{SegmentSize: segmentHeaderSize}); err == nil {
+		t.Fatalf("segment size %d accepted", segmentHeaderSize)
+	}
+}
@@ -0,0 +1,95 @@
+package btree
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/tmdgusya/btree/dberr"
+)
+
+// ==================================
+// 불변식 검사
+// ==================================
+// Check 는 트리 전체를 한 번 돌면서 B-Tree 가 지켜야 할 모양을 확인한다.
+// - 노드 안의 키는 오름차순이다 (같은 키가 여럿 들어갈 수 있으므로 같은 값은 허용).
+// - 루트가 아닌 노드는 t-1 ~ 2t-1 개, 루트는 1 ~ 2t-1 개의 키를 가진다.
+// - 값의 수는 키 수와 같다. 내부 노드의 자식 수는 키 수 + 1 이고, 모든 잎은 같은 깊이에 있다.
+// - children[i] 의 키는 keys[i-1] 이상 keys[i] 이하다. 분할로 같은 값이 양쪽에 갈 수 있어서 양 끝을 포함한다.
+// 동시 실행 도구(stress)가 배치마다 부르므로 첫 위반에서 바로 멈추고 어느 노드인지 알려 준다.
+
+// ErrInvariant 는 Check 가 찾은 위반. 메모리 트리가 깨진 것이므로 dberr.ErrCorruptPage 로 분류한다.
+var ErrInvariant = dberr.New(dberr.ErrCorruptPage, "btree: invariant violated")
+
+// Check 는 트리가 B-Tree 불변식을 지키는지 확인하고 키 수를 돌려준다. 빈 트리는 0, nil.
+func (b *BTree) Check() (int, error) {
+	if b.root == nil {
+		return 0, nil
+	}
+	if len(b.root.keys) == 0 {
+		return 0, fmt.Errorf("%w: root: no keys", ErrInvariant)
+	}
+	c := checker{t: b.t, leafDepth: -1}
+	if err := c.node(b.root, "root", 0, math.MinInt, math.MaxInt); err != nil {
+		return 0, err
+	}
+	return c.keys, nil
+}
+
+type checker struct {
+	t         int
+	leafDepth int
+	keys      int
+}
+
+func (c *checker) node(x *BTreeNode, path string, depth, lo, hi int) error {
+	if len(x.keys) > 2*c.t-1 {
+		return fmt.Errorf("%w: %s: %d keys, more than 2t-1 = %d", ErrInvariant, path, len(x.keys), 2*c.t-1)
+	}
+	if depth > 0 && len(x.keys) < c.t-1 {
+		return fmt.Errorf("%w: %s: %d keys, fewer than t-1 = %d", ErrInvariant, path, len(x.keys), c.t-1)
+	}
+	if len(x.values) != len(x.keys) {
+		return fmt.Errorf("%w: %s: %d keys but %d values", ErrInvariant, path, len(x.keys), len(x.values))
+	}
+	for i, k := range x.keys {
+		if i > 0 && k < x.keys[i-1] {
+			return fmt.Errorf("%w: %s: keys %v are not sorted", ErrInvariant, path, x.keys)
+		}
+		if k < lo || k > hi {
+			return fmt.Errorf("%w: %s: key %d outside its parent's range [%d, %d]", ErrInvariant, path, k, lo, hi)
+		}
+	}
+	c.keys += len(x.keys)
+
+	if x.isLeaf {
+		if len(x.children) != 0 {
+			return fmt.Errorf("%w: %s: leaf has %d children", ErrInvariant, path, len(x.children))
+		}
+		if c.leafDepth < 0 {
+			c.leafDepth = depth
+		} else if depth != c.leafDepth {
+			return fmt.Errorf("%w: %s: leaf at depth %d, others at %d", ErrInvariant, path, depth, c.leafDepth)
+		}
+		return nil
+	}
+
+	if len(x.children) != len(x.keys)+1 {
+		return fmt.Errorf("%w: %s: %d keys but %d children", ErrInvariant, path, len(x.keys), len(x.children))
+	}
+	for i, child := range x.children {
+		if child == nil {
+			return fmt.Errorf("%w: %s: child %d is nil", ErrInvariant, path, i)
+		}
+		clo, chi := lo, hi
+		if i > 0 {
+			clo = x.keys[i-1]
+		}
+		if i < len(x.keys) {
+			chi = x.keys[i]
+		}
+		if err := c.node(child, fmt.Sprintf("%s-%d", path, i), depth+1, clo, chi); err != nil {
+			return err
+		}
+	}
+	return nil
+}
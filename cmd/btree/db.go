@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tmdgusya/btree/chapter01/page"
+	"github.com/tmdgusya/btree/dbdir"
+)
+
+// ==================================
+// db: 데이터베이스 디렉터리
+// ==================================
+// dbdir 패키지의 디렉터리(MANIFEST, LOCK, data.pages, wal/)를 만들거나 들여다본다.
+// - init: 없으면 만든다 (페이지 크기는 config 의 page-size, 캐시는 cache-size). 이미 있으면 열었다 닫기만 한다.
+// - info: 읽기 전용으로 열어서 MANIFEST 의 내용, 페이지 수와 파일마다의 크기를 출력한다.
+
+func runDB(fs *flag.FlagSet, args []string) error {
+	if err := parseArgs(fs, args, 2); err != nil {
+		return err
+	}
+	dir := cfg.Path(fs.Arg(1))
+	opts := dbdir.Options{
+		Pager: page.PagerOptions{PageSize: cfg.PageSize},
+		Pool:  page.BufferPoolOptions{Capacity: cfg.CacheSize},
+	}
+	switch fs.Arg(0) {
+	case "init":
+	case "info":
+		// 기존 파일은 기록된 페이지 크기를 따르도록 0 으로 연다.
+		opts.Pager = page.PagerOptions{ReadOnly: true}
+	default:
+		return fmt.Errorf("db: unknown subcommand %q (want init or info)", fs.Arg(0))
+	}
+
+	db, err := dbdir.Open(dir, opts)
+	if err != nil {
+		return err
+	}
+	if fs.Arg(0) == "info" {
+		m := db.Manifest()
+		fmt.Printf("%s: %s format %d, page size %d, %d pages, created %s\n",
+			db.Dir(), m.Store, m.Format, m.PageSize, db.Pages().PageCount(), m.Created.Format(time.RFC3339))
+		if c := m.LastCheckpoint; c != nil {
+			fmt.Printf("last checkpoint %s: lsn %d, %d pages\n", c.Time.Format(time.RFC3339), c.LSN, c.Pages)
+		}
+		for _, name := range []string{dbdir.ManifestFile, dbdir.LockFile, dbdir.DataFile, dbdir.WALDir} {
+			size, err := diskSize(db.Path(name))
+			if err != nil {
+				db.Close()
+				return err
+			}
+			fmt.Printf("  %-10s %d bytes\n", name, size)
+		}
+	}
+	return db.Close()
+}
+
+// diskSize 는 파일의 크기, 디렉터리면 그 안의 파일 크기의 합
+func diskSize(path string) (int64, error) {
+	var total int64
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		total += info.Size()
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	return total, err
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"time"
+
+	"github.com/tmdgusya/btree"
+	"github.com/tmdgusya/btree/chapter02/linkedlist"
+	"github.com/tmdgusya/btree/config"
+	"github.com/tmdgusya/btree/importer"
+)
+
+// ==================================
+// load: CSV / NDJSON 데이터셋 넣기
+// ==================================
+// 리스트와 메모리 트리는 아직 키만 저장하므로 value 열은 읽기만 하고 버린다.
+
+// 리스트 저장소용 Sink. 배치가 끝날 때마다 sync 를 부른다 (-durability none 이면 아무것도 안 하는 함수).
+// appendValues 가 있으면 (paged) Put 은 값을 모으기만 하고 Commit 에서 배치를 한 번에 쓴다.
+type listSink struct {
+	appendTail   func(v uint32) error
+	appendValues func(vs []uint32) error
+	sync         func() error
+	pending      []uint32
+}
+
+func (s *listSink) Put(rec importer.Record) error {
+	if rec.Key < 0 || rec.Key > math.MaxUint32 {
+		return fmt.Errorf("key %d does not fit in uint32", rec.Key)
+	}
+	if s.appendValues != nil {
+		s.pending = append(s.pending, uint32(rec.Key))
+		return nil
+	}
+	return s.appendTail(uint32(rec.Key))
+}
+
+func (s *listSink) Commit() error {
+	if len(s.pending) > 0 {
+		if err := s.appendValues(s.pending); err != nil {
+			return err
+		}
+		s.pending = s.pending[:0]
+	}
+	return s.sync()
+}
+
+// 메모리 트리용 Sink. 커밋할 것이 없다.
+type treeSink struct{ tree *btree.BTree }
+
+func (s treeSink) Put(rec importer.Record) error {
+	s.tree.Insert(int(rec.Key), rec.Value)
+	return nil
+}
+
+func (s treeSink) Commit() error { return nil }
+
+// importFlags 는 load 와 serve -load 가 같이 쓰는 플래그
+func importFlags(fs *flag.FlagSet) (format *string, batch *int) {
+	format = fs.String("format", "", "input format: csv or ndjson (default: from the file extension)")
+	batch = fs.Int("batch", importer.DefaultBatchSize, "records per commit (fsync for file stores unless -durability none; sync forces 1)")
+	return format, batch
+}
+
+// runImport 는 path("-" 이면 stdin)를 읽어 sink 에 넣고 진행 상황을 stderr 에 찍는다.
+func runImport(path, format string, batch int, sink importer.Sink) (importer.Progress, error) {
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return importer.Progress{}, err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	var fmtv importer.Format
+	switch {
+	case format != "":
+		var err error
+		if fmtv, err = importer.ParseFormat(format); err != nil {
+			return importer.Progress{}, err
+		}
+	default:
+		var ok bool
+		if fmtv, ok = importer.FormatFromPath(path); !ok {
+			return importer.Progress{}, fmt.Errorf("cannot tell the format of %q; pass -format csv or -format ndjson", path)
+		}
+	}
+
+	r, err := importer.NewReader(fmtv, in)
+	if err != nil {
+		return importer.Progress{}, err
+	}
+
+	// 배치마다 찍으면 너무 많으므로 1 초에 한 번만 찍는다.
+	var last time.Time
+	return importer.RunContext(cmdCtx, r, sink, importer.Options{
+		BatchSize: batch,
+		OnCommit: func(p importer.Progress) {
+			if time.Since(last) < time.Second {
+				return
+			}
+			last = time.Now()
+			slog.Info("load progress", "records", p.Records, "batches", p.Batches, "recordsPerSec", int64(p.Rate()))
+		},
+	})
+}
+
+func runLoad(fs *flag.FlagSet, args []string) error {
+	store, pageSize := storeFlags(fs, storePaged)
+	format, batch := importFlags(fs)
+	if err := parseArgs(fs, args, 2); err != nil {
+		return err
+	}
+	data, path := fs.Arg(0), cfg.Path(fs.Arg(1))
+
+	var sink listSink
+	var closeStore func() error
+	switch *store {
+	case storeOffset:
+		s := &linkedlist.OffsetStore{Audit: cfg.Audit}
+		h, err := s.Open(path, false)
+		if err != nil {
+			return err
+		}
+		sink = listSink{appendTail: func(v uint32) error { return s.AppendTail(h, v) }, sync: h.File.Sync}
+		closeStore = func() error { return s.Close(h) }
+	case storePaged:
+		s, err := pagedStore(*pageSize, path)
+		if err != nil {
+			return err
+		}
+		h, err := s.Open(path, false)
+		if err != nil {
+			return err
+		}
+		sink = listSink{appendValues: func(vs []uint32) error { return s.AppendValues(h, vs) }, sync: h.File.Sync}
+		closeStore = func() error { return s.Close(h) }
+	default:
+		return fmt.Errorf("load: unsupported store %q (want offset or paged; use serve -load for the tree)", *store)
+	}
+
+	switch cfg.Durability {
+	case config.DurabilityNone:
+		sink.sync = func() error { return nil }
+	case config.DurabilitySync:
+		// 레코드마다 커밋하면 레코드마다 Sync 한다.
+		*batch = 1
+	}
+
+	p, err := runImport(data, *format, *batch, &sink)
+	if cerr := closeStore(); err == nil {
+		err = cerr
+	}
+	fmt.Printf("loaded %d records into %s in %d batches (%s, %.0f records/s)\n",
+		p.Records, path, p.Batches, p.Elapsed.Round(time.Millisecond), p.Rate())
+	return err
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/tmdgusya/btree/chapter03/lsm"
+	"github.com/tmdgusya/btree/workload"
+)
+
+// ==================================
+// lsm: 워크로드를 넣고 증폭 비율 보기
+// ==================================
+// workload 패키지의 키 스트림을 LSM 트리에 넣고 (Insert 는 Put, Lookup 은 Get) I/O 측정값을 출력한다.
+
+func runLSM(fs *flag.FlagSet, args []string) error {
+	n := fs.Int("n", 100000, "number of operations")
+	dist := fs.String("dist", "uniform", "key distribution: sequential, uniform or zipfian")
+	keySpace := fs.Int("keys", 100000, "size of the key space")
+	lookups := fs.Float64("lookups", 0.5, "fraction of operations that are lookups")
+	valueSize := fs.Int("value-size", 100, "bytes per value")
+	seed := fs.Int64("seed", 1, "workload seed (0 = pick one at random; the seed used is printed)")
+	memtable := fs.Int("memtable", 0, "memtable size in bytes (0 = default)")
+	bloomBits := fs.Int("bloom-bits", 0, "bloom filter bits per key (0 = default, -1 = no filter)")
+	ttl := fs.Duration("ttl", 0, "expire inserted values after this long (0 = never)")
+	if err := parseArgs(fs, args, 1); err != nil {
+		return err
+	}
+	d, err := workload.ParseDistribution(*dist)
+	if err != nil {
+		return err
+	}
+	if *seed == 0 {
+		*seed = workload.RandomSeed()
+	}
+	gen, err := workload.New(workload.Config{
+		Distribution: d,
+		KeySpace:     *keySpace,
+		Seed:         *seed,
+		LookupRatio:  *lookups,
+	})
+	if err != nil {
+		return err
+	}
+
+	db, err := lsm.Open(cfg.Path(fs.Arg(0)), lsm.Options{MemtableSize: *memtable, BloomBitsPerKey: *bloomBits})
+	if err != nil {
+		return err
+	}
+	value := make([]byte, *valueSize)
+	hits := 0
+	for i := 0; i < *n; i++ {
+		op := gen.Next()
+		key := binary.BigEndian.AppendUint32(nil, op.Key)
+		switch op.Kind {
+		case workload.Insert:
+			if *ttl > 0 {
+				err = db.PutWithExpiry(key, value, time.Now().Add(*ttl))
+			} else {
+				err = db.Put(key, value)
+			}
+		case workload.Lookup:
+			var ok bool
+			_, ok, err = db.Get(key)
+			if ok {
+				hits++
+			}
+		}
+		if err != nil {
+			db.Close()
+			return err
+		}
+	}
+
+	fmt.Printf("%d ops (%s, seed %d, %.0f%% lookups, %d hits)\n", *n, d, *seed, *lookups*100, hits)
+	db.Stats().Print(os.Stdout)
+	return db.Close()
+}
+
+// ==================================
+// resp: LSM 트리를 Redis 프로토콜로 띄우기
+// ==================================
+// redis-cli -p 6379 로 붙어서 GET / SET / DEL / SCAN 을 보낸다. Ctrl-C 로 멈추면 memtable 을 내리고 닫는다.
+
+func runRESP(fs *flag.FlagSet, args []string) error {
+	addr := fs.String("addr", "localhost:6379", "listen address")
+	memtable := fs.Int("memtable", 0, "memtable size in bytes (0 = default)")
+	sweep := fs.Duration("sweep", 0, "vacuum expired values this often (0 = only during compaction)")
+	if err := parseArgs(fs, args, 1); err != nil {
+		return err
+	}
+	db, err := lsm.Open(cfg.Path(fs.Arg(0)), lsm.Options{MemtableSize: *memtable, SweepInterval: *sweep})
+	if err != nil {
+		return err
+	}
+	if err := lsm.ServeRESP(cmdCtx, *addr, db); err != nil {
+		db.Close()
+		return err
+	}
+	return db.Close()
+}
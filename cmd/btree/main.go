@@ -0,0 +1,473 @@
+// btree 는 튜토리얼의 모든 실행 기능을 모은 바이너리다.
+// 챕터마다 따로 있던 main 대신 `btree [config flags] <command> [flags] [args]` 하나로 서버, 벤치마크, 파일 도구를 부른다.
+// 주소, 데이터 디렉터리, 페이지 크기 같은 공통 값은 config 패키지가 플래그 / 환경 변수 / JSON 파일에서 읽는다.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+
+	"github.com/tmdgusya/btree"
+	"github.com/tmdgusya/btree/benchsuite"
+	"github.com/tmdgusya/btree/chapter01/file"
+	"github.com/tmdgusya/btree/chapter01/page"
+	"github.com/tmdgusya/btree/chapter02/compare"
+	"github.com/tmdgusya/btree/chapter02/linkedlist"
+	pagedlist "github.com/tmdgusya/btree/chapter02/paged_linked_list"
+	"github.com/tmdgusya/btree/chapter03/exthash"
+	"github.com/tmdgusya/btree/chapter03/lsm"
+	"github.com/tmdgusya/btree/chapter04/logstore"
+	"github.com/tmdgusya/btree/config"
+)
+
+type command struct {
+	name    string
+	args    string // usage 줄에서 명령 이름 뒤에 붙는 플래그 / 인자 설명
+	summary string
+	run     func(fs *flag.FlagSet, args []string) error
+}
+
+var commands []command
+
+// cfg 는 명령 이름 앞의 설정 플래그, 환경 변수, -config 파일을 합친 값. run 이 명령을 부르기 전에 채운다.
+var cfg = config.Default()
+
+// cmdCtx 는 Ctrl-C (SIGINT) / SIGTERM 을 받으면 취소된다. 긴 명령(load, export, import, compact, serve, resp)이
+// 이것을 보고 하던 일을 깔끔하게 멈춘다. run 이 명령을 부르기 전에 채운다.
+var cmdCtx = context.Background()
+
+func init() {
+	// init 에서 채우는 이유: help 가 commands 를 참조하므로 변수 초기화 순환을 피한다.
+	commands = []command{
+		{"serve", "[flags] [file]", "run the B-tree web UI, or the paged list demo server with -store paged", runServe},
+		{"bench", "[flags]", "run the micro benchmarks of every package; -baseline fails on regressions", runBench},
+		{"compare", "<demo|bench|timeline> [mode flags]", "compare offset and paged list I/O", runCompare},
+		{"demo", "<file|page|offset|paged|lsm|exthash|log>", "run a chapter's tutorial demo in the data directory", runDemo},
+		{"dump", "[flags] <file>", "print header, pages and raw hex of a list file", fileCommand("dump")},
+		{"check", "[flags] <file>", "verify a list file; exits 1 if problems are found", fileCommand("check")},
+		{"stats", "[flags] <file>", "print space usage of a list file", fileCommand("stats")},
+		{"export", "[flags] <file>", "write a list file as JSON to stdout", fileCommand("export")},
+		{"import", "[flags] <file>", "rebuild a list file from JSON on stdin", fileCommand("import")},
+		{"compact", "[flags] <file>", "compact every page and punch holes (paged store only)", runCompact},
+		{"repair", "[flags] <file>", "cut broken next pointers and fix the tail and size (paged store only)", runRepair},
+		{"repl", "[flags] <file>", "append, delete and inspect a list file interactively", runRepl},
+		{"load", "[flags] <data.csv|data.ndjson|-> <file>", "stream keys from CSV or NDJSON into a list file in fsync'd batches", runLoad},
+		{"sql", "[flags]", "run INSERT / SELECT / DELETE statements against an in-memory B-tree index", runSQL},
+		{"script", "[flags] <script|-> [file]", "run a script of create / insert / delete / expect-* lines against the tree or a list file", runScript},
+		{"convert", "[flags] <src> <dst>", "copy the values of one list file into a new file of another layout", runConvert},
+		{"db", "<init|info> <dir>", "create or describe a database directory (data file, WAL, manifest and lock file)", runDB},
+		{"lsm", "[flags] <dir>", "run a workload against an LSM tree and report write / read amplification", runLSM},
+		{"resp", "[flags] <dir>", "serve an LSM tree over a subset of the Redis protocol (GET / SET / DEL / SCAN)", runRESP},
+		{"sort", "[flags]", "sort a workload larger than memory with an external merge sort and report its I/O", runSort},
+		{"stress", "[flags]", "hammer the tree or the LSM store from many goroutines and check it after every batch", runStress},
+		{"help", "[command]", "show help for a command", runHelp},
+	}
+}
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		fmt.Fprintln(os.Stderr, "btree:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	global := flag.NewFlagSet("btree", flag.ContinueOnError)
+	global.Usage = func() { usage(global.Output()) }
+	loader := config.Register(global)
+	// 설정 플래그는 명령 이름 앞에 온다. Parse 는 첫 번째 비플래그 인자(명령 이름)에서 멈춘다.
+	if err := global.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = global.Args()
+	if len(args) == 0 {
+		usage(os.Stderr)
+		return errors.New("no command given")
+	}
+
+	var err error
+	if cfg, err = loader.Load(os.Getenv); err != nil {
+		return err
+	}
+	// 서버의 http.Server 에러와 log 패키지로 찍히는 것도 이 핸들러를 거치므로 -log-level / -log-format 이 함께 적용된다.
+	slog.SetDefault(cfg.Logger(os.Stderr))
+
+	cmd, ok := lookup(args[0])
+	if !ok {
+		usage(os.Stderr)
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	cmdCtx = ctx
+	return cmd.run(newFlagSet(cmd), args[1:])
+}
+
+func lookup(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: btree [config flags] <command> [flags] [args]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "config flags (precedence: defaults < -config file < BTREE_* env < flags):")
+	global := flag.NewFlagSet("btree", flag.ContinueOnError)
+	global.SetOutput(w)
+	config.Register(global)
+	global.PrintDefaults()
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `run "btree help <command>" for the flags of a command`)
+}
+
+// 모든 명령이 같은 모양의 도움말을 내도록 FlagSet 을 여기서 만든다.
+func newFlagSet(cmd command) *flag.FlagSet {
+	fs := flag.NewFlagSet("btree "+cmd.name, flag.ContinueOnError)
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "usage: btree %s %s\n\n%s\n", cmd.name, cmd.args, cmd.summary)
+		hasFlags := false
+		fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintln(out, "\nflags:")
+			fs.PrintDefaults()
+		}
+	}
+	return fs
+}
+
+func runHelp(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		usage(os.Stdout)
+		return nil
+	}
+	cmd, ok := lookup(fs.Arg(0))
+	if !ok {
+		return fmt.Errorf("unknown command %q", fs.Arg(0))
+	}
+	// 각 명령은 자기 플래그를 run 안에서 등록하므로 -h 로 불러서 도움말을 찍게 한다.
+	sub := newFlagSet(cmd)
+	sub.SetOutput(os.Stdout)
+	err := cmd.run(sub, []string{"-h"})
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	return err
+}
+
+// ==================================
+// 공통 플래그
+// ==================================
+
+const (
+	storeTree   = "tree"
+	storeOffset = "offset"
+	storePaged  = "paged"
+)
+
+// -store 와 -page-size 는 파일을 다루는 모든 명령에서 같은 이름, 같은 기본값을 쓴다.
+func storeFlags(fs *flag.FlagSet, def string) (store *string, pageSize *uint) {
+	store = fs.String("store", def, "list layout: offset or paged")
+	pageSize = fs.Uint("page-size", 0, "page size used when a paged file is created (0 = config page-size); existing files keep their own")
+	return store, pageSize
+}
+
+// pagedStore 는 path 의 페이지 저장소를 만든다. pageSize 가 0 이면 새 파일(없거나 비어 있음)에만
+// 설정의 페이지 크기를 쓰고, 기존 파일은 헤더에 적힌 크기를 그대로 받아들인다. 자동 vacuum 은 설정의 auto-vacuum 을 따른다.
+func pagedStore(pageSize uint, path string) (*pagedlist.PagedStore, error) {
+	if pageSize == 0 {
+		if fi, err := os.Stat(path); err != nil || fi.Size() == 0 {
+			pageSize = uint(cfg.PageSize)
+		}
+	}
+	if pageSize > pagedlist.MAX_PAGE_SIZE {
+		return nil, fmt.Errorf("page size %d is larger than %d", pageSize, pagedlist.MAX_PAGE_SIZE)
+	}
+	return &pagedlist.PagedStore{PageSize: uint16(pageSize), AutoVacuum: cfg.AutoVacuum, Audit: cfg.Audit}, nil
+}
+
+func parseArgs(fs *flag.FlagSet, args []string, n int) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != n {
+		fs.Usage()
+		return fmt.Errorf("expected %d argument(s), got %d", n, fs.NArg())
+	}
+	return nil
+}
+
+// ==================================
+// 명령
+// ==================================
+
+func runServe(fs *flag.FlagSet, args []string) error {
+	addr := fs.String("addr", cfg.Addr, "listen address (default: config addr)")
+	store := fs.String("store", storeTree, "what to serve: tree (in-memory B-tree UI) or paged (list file with live I/O metrics)")
+	load := fs.String("load", "", "CSV / NDJSON dataset to insert into the tree before serving (-store tree)")
+	degree := fs.Int("t", 3, "minimum degree of the preloaded tree")
+	trace := fs.Bool("trace", false, "log a span for every tree operation at debug level (-store tree)")
+	format, batch := importFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	switch *store {
+	case storeTree:
+		if fs.NArg() != 0 {
+			return errors.New("serve: the in-memory tree takes no file argument")
+		}
+		if *load != "" {
+			tree, err := btree.New(*degree)
+			if err != nil {
+				return err
+			}
+			// 한꺼번에 적재하므로 노드를 arena 에서 덩어리로 잘라 쓴다.
+			tree.SetArena(true)
+			p, err := runImport(*load, *format, *batch, treeSink{tree: tree})
+			if err != nil {
+				return err
+			}
+			slog.Info("preloaded keys", "records", p.Records, "file", *load, "degree", *degree)
+			btree.SetTree(tree)
+		}
+		if *trace {
+			btree.SetTracer(btree.LogTracer{})
+		}
+		return btree.ListenAndServeContext(cmdCtx, *addr)
+	case storePaged:
+		if fs.NArg() != 1 {
+			fs.Usage()
+			return errors.New("serve: -store paged needs a file argument")
+		}
+		return pagedlist.ServeContext(cmdCtx, *addr, cfg.Path(fs.Arg(0)), pagedlist.ServeOptions{AdminToken: cfg.AdminToken})
+	default:
+		return fmt.Errorf("serve: unsupported store %q (want tree or paged)", *store)
+	}
+}
+
+func runBench(fs *flag.FlagSet, args []string) error {
+	opts := benchsuite.Register(fs)
+	if err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	return benchsuite.Main(os.Stdout, opts, benchsuite.All())
+}
+
+func runCompare(fs *flag.FlagSet, args []string) error {
+	modes := map[string]func([]string) error{
+		"demo":     compare.Demo,
+		"bench":    compare.Bench,
+		"timeline": compare.Timeline,
+	}
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" {
+		fs.Usage()
+		fmt.Fprintln(fs.Output(), "\nrun \"btree compare <mode> -h\" for the flags of each mode")
+		if len(args) == 0 {
+			return errors.New("compare: missing mode")
+		}
+		return flag.ErrHelp
+	}
+	mode, ok := modes[args[0]]
+	if !ok {
+		names := make([]string, 0, len(modes))
+		for name := range modes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("compare: unknown mode %q (want one of %v)", args[0], names)
+	}
+	return mode(args[1:])
+}
+
+func runDemo(fs *flag.FlagSet, args []string) error {
+	if err := parseArgs(fs, args, 1); err != nil {
+		return err
+	}
+	// 데모는 파일 이름이 고정되어 있으므로 데이터 디렉터리로 옮겨 가서 실행한다.
+	if err := os.Chdir(cfg.DataDir); err != nil {
+		return err
+	}
+	switch fs.Arg(0) {
+	case "file":
+		file.Demo()
+	case "page":
+		page.Demo()
+	case storeOffset:
+		linkedlist.Demo()
+	case storePaged:
+		pagedlist.Demo()
+	case "lsm":
+		lsm.Demo()
+	case "exthash":
+		exthash.Demo()
+	case "log":
+		logstore.Demo()
+	default:
+		return fmt.Errorf("demo: unknown demo %q", fs.Arg(0))
+	}
+	return nil
+}
+
+// dump / check / stats / export / import 는 두 리스트가 같은 이름으로 구현해 두었으므로 -store 로 골라 넘긴다.
+func fileCommand(name string) func(fs *flag.FlagSet, args []string) error {
+	return func(fs *flag.FlagSet, args []string) error {
+		store, pageSize := storeFlags(fs, storePaged)
+		if err := parseArgs(fs, args, 1); err != nil {
+			return err
+		}
+		path := cfg.Path(fs.Arg(0))
+		switch *store {
+		case storeOffset:
+			return linkedlist.RunCommandContext(cmdCtx, &linkedlist.OffsetStore{}, []string{name, path})
+		case storePaged:
+			ps, err := pagedStore(*pageSize, path)
+			if err != nil {
+				return err
+			}
+			return pagedlist.RunCommandContext(cmdCtx, ps, []string{name, path})
+		default:
+			return fmt.Errorf("%s: unsupported store %q (want offset or paged)", name, *store)
+		}
+	}
+}
+
+func runCompact(fs *flag.FlagSet, args []string) error {
+	store := fs.String("store", storePaged, "list layout: only paged supports compaction")
+	if err := parseArgs(fs, args, 1); err != nil {
+		return err
+	}
+	if *store != storePaged {
+		return fmt.Errorf("compact: only the paged store can be compacted")
+	}
+	return pagedlist.RunCommandContext(cmdCtx, &pagedlist.PagedStore{}, []string{"compact", cfg.Path(fs.Arg(0))})
+}
+
+func runRepair(fs *flag.FlagSet, args []string) error {
+	store := fs.String("store", storePaged, "list layout: only paged can be repaired")
+	if err := parseArgs(fs, args, 1); err != nil {
+		return err
+	}
+	if *store != storePaged {
+		return fmt.Errorf("repair: only the paged store can be repaired")
+	}
+	return pagedlist.RunCommandContext(cmdCtx, &pagedlist.PagedStore{}, []string{"repair", cfg.Path(fs.Arg(0))})
+}
+
+func runConvert(fs *flag.FlagSet, args []string) error {
+	from := fs.String("from", storeOffset, "layout of <src>: offset or paged")
+	to := fs.String("to", storePaged, "layout of <dst>: offset or paged")
+	pageSize := fs.Uint("page-size", 0, "page size of <dst> when -to paged (0 = config page-size)")
+	if err := parseArgs(fs, args, 2); err != nil {
+		return err
+	}
+	src, dst := cfg.Path(fs.Arg(0)), cfg.Path(fs.Arg(1))
+	if src == dst {
+		return errors.New("convert: <src> and <dst> must be different files")
+	}
+
+	values, err := readValues(*from, src)
+	if err != nil {
+		return err
+	}
+	if err := writeValues(*to, dst, *pageSize, values); err != nil {
+		return err
+	}
+	fmt.Printf("converted %d values: %s (%s) -> %s (%s)\n", len(values), src, *from, dst, *to)
+	return nil
+}
+
+// 논리 순서(head -> tail)대로 값을 꺼낸다. tombstone 은 빠진다.
+func readValues(layout, path string) ([]uint32, error) {
+	switch layout {
+	case storeOffset:
+		s := &linkedlist.OffsetStore{}
+		h, err := s.Open(path, false)
+		if err != nil {
+			return nil, err
+		}
+		defer s.Close(h)
+		return s.TraverseValues(h)
+	case storePaged:
+		s := &pagedlist.PagedStore{}
+		h, err := s.Open(path, false)
+		if err != nil {
+			return nil, err
+		}
+		defer s.Close(h)
+		return s.TraverseValues(h)
+	default:
+		return nil, fmt.Errorf("convert: unsupported store %q (want offset or paged)", layout)
+	}
+}
+
+// dst 는 새로 만든다 (O_TRUNC). 값은 같은 순서로 꼬리에 붙인다.
+func writeValues(layout, path string, pageSize uint, values []uint32) (err error) {
+	switch layout {
+	case storeOffset:
+		s := &linkedlist.OffsetStore{Audit: cfg.Audit}
+		h, err := s.Open(path, true)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if cerr := s.Close(h); err == nil {
+				err = cerr
+			}
+		}()
+		for _, v := range values {
+			if err := s.AppendTail(h, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	case storePaged:
+		// dst 는 새로 만들므로 기존 파일의 페이지 크기를 따를 이유가 없다.
+		if pageSize == 0 {
+			pageSize = uint(cfg.PageSize)
+		}
+		s, err := pagedStore(pageSize, path)
+		if err != nil {
+			return err
+		}
+		h, err := s.Open(path, true)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if cerr := s.Close(h); err == nil {
+				err = cerr
+			}
+		}()
+		return s.AppendValues(h, values)
+	default:
+		return fmt.Errorf("convert: unsupported store %q (want offset or paged)", layout)
+	}
+}
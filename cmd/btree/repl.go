@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/tmdgusya/btree/chapter02/linkedlist"
+	"github.com/tmdgusya/btree/dberr"
+)
+
+// ==================================
+// repl: 파일 포맷을 한 줄씩 건드려 보기
+// ==================================
+// 값을 넣고 지울 때마다 헤더를 다시 읽어서 보여 주므로 head / tail / size 가 어떻게 바뀌는지 바로 보인다.
+
+const replHelp = `commands:
+  append <n>    add n at the tail
+  prepend <n>   add n at the head
+  delete <n>    delete the first node holding n
+  find <n>      print where n lives in the file
+  scan          print every value from head to tail
+  head          print the first live value
+  tail          print the last live value
+  len           print the number of live values
+  page <id>     print one page (slots and raw hex)
+  header        print the file header
+  help          show this help
+  quit          leave the repl`
+
+// 두 리스트는 Handle / Location 타입이 달라서 REPL 이 쓰는 연산만 함수로 묶어 둔다.
+type replTarget struct {
+	appendTail  func(v uint32) error
+	prependHead func(v uint32) error
+	deleteValue func(v uint32) (bool, error)
+	find        func(v uint32) (string, error)
+	scan        func() ([]uint32, error)
+	peekHead    func() (uint32, bool, error)
+	peekTail    func() (uint32, bool, error)
+	length      func() (uint64, error)
+	header      func(w io.Writer) error
+	page        func(id uint32, w io.Writer) error
+	close       func() error
+}
+
+func openOffsetTarget(path string) (*replTarget, error) {
+	s := &linkedlist.OffsetStore{Audit: cfg.Audit}
+	h, err := s.Open(path, false)
+	if err != nil {
+		return nil, err
+	}
+	return &replTarget{
+		appendTail:  func(v uint32) error { return s.AppendTail(h, v) },
+		prependHead: func(v uint32) error { return s.PrependHead(h, v) },
+		deleteValue: func(v uint32) (bool, error) { return s.DeleteFirstByValue(h, v) },
+		find: func(v uint32) (string, error) {
+			off, err := s.Where(h, v)
+			if err != nil || off == linkedlist.NullOffset {
+				return "", err
+			}
+			return fmt.Sprintf("offset %d", off), nil
+		},
+		scan:     func() ([]uint32, error) { return s.TraverseValues(h) },
+		peekHead: func() (uint32, bool, error) { return s.PeekHead(h) },
+		peekTail: func() (uint32, bool, error) { return s.PeekTail(h) },
+		length: func() (uint64, error) {
+			n, err := s.Length(h)
+			return uint64(max(n, 0)), err
+		},
+		header: func(w io.Writer) error { return s.InspectHeader(h, w) },
+		page:   func(id uint32, w io.Writer) error { return s.InspectPage(h, id, w) },
+		close:  func() error { return s.Close(h) },
+	}, nil
+}
+
+func openPagedTarget(path string, pageSize uint) (*replTarget, error) {
+	s, err := pagedStore(pageSize, path)
+	if err != nil {
+		return nil, err
+	}
+	h, err := s.Open(path, false)
+	if err != nil {
+		return nil, err
+	}
+	return &replTarget{
+		appendTail:  func(v uint32) error { return s.AppendTail(h, v) },
+		prependHead: func(v uint32) error { return s.PrependHead(h, v) },
+		deleteValue: func(v uint32) (bool, error) { return s.DeleteFirstByValue(h, v) },
+		find: func(v uint32) (string, error) {
+			loc, err := s.Where(h, v)
+			if err != nil || loc == nil {
+				return "", err
+			}
+			return fmt.Sprintf("page %d slot %d", loc.Page, loc.Slot), nil
+		},
+		scan:     func() ([]uint32, error) { return s.TraverseValues(h) },
+		peekHead: func() (uint32, bool, error) { return s.PeekHead(h) },
+		peekTail: func() (uint32, bool, error) { return s.PeekTail(h) },
+		length:   func() (uint64, error) { return s.Length(h) },
+		header:   func(w io.Writer) error { return s.InspectHeader(h, w) },
+		page:     func(id uint32, w io.Writer) error { return s.InspectPage(h, id, w) },
+		close:    func() error { return s.Close(h) },
+	}, nil
+}
+
+func runRepl(fs *flag.FlagSet, args []string) error {
+	store, pageSize := storeFlags(fs, storePaged)
+	if err := parseArgs(fs, args, 1); err != nil {
+		return err
+	}
+
+	path := cfg.Path(fs.Arg(0))
+	var t *replTarget
+	var err error
+	switch *store {
+	case storeOffset:
+		t, err = openOffsetTarget(path)
+	case storePaged:
+		t, err = openPagedTarget(path, *pageSize)
+	default:
+		return fmt.Errorf("repl: unsupported store %q (want offset or paged)", *store)
+	}
+	if err != nil {
+		return err
+	}
+	defer t.close()
+
+	fmt.Printf("%s (%s): type \"help\" for commands\n", path, *store)
+	return repl(t, os.Stdin, os.Stdout)
+}
+
+// repl 은 in 에서 한 줄씩 읽어 실행한다. 명령이 실패해도 세션은 계속되고, quit 이나 EOF 에서 끝난다.
+func repl(t *replTarget, in io.Reader, out io.Writer) error {
+	sc := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(out, "> ")
+		if !sc.Scan() {
+			fmt.Fprintln(out)
+			return sc.Err()
+		}
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "quit" || fields[0] == "exit" {
+			return nil
+		}
+		if err := replCommand(t, fields, out); err != nil {
+			fmt.Fprintln(out, "error:", err)
+		}
+	}
+}
+
+func replCommand(t *replTarget, fields []string, out io.Writer) error {
+	cmd, args := fields[0], fields[1:]
+
+	switch cmd {
+	case "help":
+		fmt.Fprintln(out, replHelp)
+		return nil
+	case "scan":
+		vals, err := t.scan()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "%v (%d values)\n", vals, len(vals))
+		return nil
+	case "head", "tail":
+		peek := t.peekHead
+		if cmd == "tail" {
+			peek = t.peekTail
+		}
+		v, ok, err := peek()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("%s: list is empty", cmd)
+		}
+		fmt.Fprintf(out, "%s = %d\n", cmd, v)
+		return nil
+	case "len":
+		n, err := t.length()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "%d values\n", n)
+		return nil
+	case "header":
+		return t.header(out)
+	case "append", "prepend", "delete", "find", "page":
+	default:
+		return fmt.Errorf("unknown command %q (try \"help\")", cmd)
+	}
+
+	// 나머지 명령은 숫자 인자 하나를 받는다.
+	if len(args) != 1 {
+		return fmt.Errorf("%s takes exactly one number (try \"help\")", cmd)
+	}
+	n, err := strconv.ParseUint(args[0], 10, 32)
+	if err != nil {
+		return fmt.Errorf("%s: %q is not a uint32", cmd, args[0])
+	}
+	v := uint32(n)
+
+	switch cmd {
+	case "append":
+		err = t.appendTail(v)
+	case "prepend":
+		err = t.prependHead(v)
+	case "delete":
+		var removed bool
+		removed, err = t.deleteValue(v)
+		if err == nil && !removed {
+			return fmt.Errorf("%d: %w", v, dberr.ErrKeyNotFound)
+		}
+	case "find":
+		where, err := t.find(v)
+		if err != nil {
+			return err
+		}
+		if where == "" {
+			return fmt.Errorf("%d: %w", v, dberr.ErrKeyNotFound)
+		}
+		fmt.Fprintf(out, "%d is at %s\n", v, where)
+		return nil
+	case "page":
+		return t.page(v, out)
+	}
+	if err != nil {
+		return err
+	}
+
+	// 값이 바뀌었으면 헤더를 다시 읽어 보여 준다.
+	return t.header(out)
+}
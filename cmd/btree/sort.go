@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/tmdgusya/btree/extsort"
+	"github.com/tmdgusya/btree/workload"
+)
+
+// ==================================
+// sort: 외부 병합 정렬 I/O 보기
+// ==================================
+// workload 패키지의 키 스트림을 extsort 로 정렬하고, run / pass 수와 scratch 파일 I/O 를 출력한다.
+// scratch 파일은 데이터 디렉터리에 만들고 끝나면 지운다.
+
+func runSort(fs *flag.FlagSet, args []string) error {
+	n := fs.Int("n", 1000000, "number of records")
+	dist := fs.String("dist", "uniform", "key distribution: sequential, uniform or zipfian")
+	keySpace := fs.Int("keys", 1000000, "size of the key space")
+	seed := fs.Int64("seed", 1, "workload seed (0 = pick one at random; the seed used is printed)")
+	memory := fs.Int("memory", 65536, "records sorted in memory per run")
+	fanIn := fs.Int("fan-in", 16, "runs merged at once")
+	if err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	d, err := workload.ParseDistribution(*dist)
+	if err != nil {
+		return err
+	}
+	if *seed == 0 {
+		*seed = workload.RandomSeed()
+	}
+	gen, err := workload.New(workload.Config{Distribution: d, KeySpace: *keySpace, Seed: *seed})
+	if err != nil {
+		return err
+	}
+
+	s := extsort.New(extsort.Options{MemoryRecords: *memory, FanIn: *fanIn, PageSize: cfg.PageSize, Dir: cfg.DataDir})
+	defer s.Close()
+	for i, k := range gen.Keys(*n) {
+		if err := s.Add(extsort.Record{Key: uint64(k), Value: uint64(i)}); err != nil {
+			return err
+		}
+	}
+
+	// 정렬 결과가 정말 오름차순인지 확인하면서 센다.
+	var out int
+	var prev uint64
+	err = s.Sort(func(r extsort.Record) error {
+		if out > 0 && r.Key < prev {
+			return fmt.Errorf("record %d: key %d after %d", out, r.Key, prev)
+		}
+		prev = r.Key
+		out++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	st := s.Stats()
+	fmt.Printf("%d records sorted (%s keys, seed %d), %d in memory per run, fan-in %d\n", out, d, *seed, *memory, *fanIn)
+	fmt.Printf("runs=%d merge passes=%d (+ final merge)\n", st.Runs, st.Passes)
+	fmt.Printf("I/O: reads=%d (%d B) writes=%d (%d B)\n", st.IO.Reads, st.IO.BytesRead, st.IO.Writes, st.IO.BytesWritten)
+	return nil
+}
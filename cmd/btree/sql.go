@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/tmdgusya/btree"
+	"github.com/tmdgusya/btree/query"
+)
+
+// ==================================
+// sql: 메모리 B-Tree 위의 작은 질의 계층
+// ==================================
+// 한 줄에 문장 하나씩 읽어서 결과와 실행 계획을 출력한다. 실패한 문장은 에러만 찍고 넘어간다.
+// "trace insert <k>" / "trace delete <k>" / "trace search <k>" 는 SQL 대신 트리 연산 하나를 추적해서 방문한 노드와 비교, 구조 변경을 보여 준다.
+
+func runSQL(fs *flag.FlagSet, args []string) error {
+	degree := fs.Int("t", 3, "minimum degree of the tree")
+	table := fs.String("table", "t", "name of the single table")
+	load := fs.String("load", "", "CSV / NDJSON dataset to insert before reading statements")
+	linear := fs.Bool("linear", false, "scan keys inside a node one by one instead of binary search (compare with trace)")
+	format, batch := importFlags(fs)
+	if err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+
+	tree, err := btree.New(*degree)
+	if err != nil {
+		return err
+	}
+	tree.SetLinearSearch(*linear)
+	if *load != "" {
+		p, err := runImport(*load, *format, *batch, treeSink{tree: tree})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("loaded %d keys from %s\n", p.Records, *load)
+	}
+
+	e := &query.Engine{Table: *table, Index: tree}
+	fmt.Printf("table %s(k int), t=%d: INSERT / SELECT / DELETE, one statement per line\n", *table, *degree)
+	return sqlLoop(e, os.Stdin, os.Stdout)
+}
+
+func sqlLoop(e *query.Engine, in io.Reader, out io.Writer) error {
+	sc := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(out, "sql> ")
+		if !sc.Scan() {
+			fmt.Fprintln(out)
+			return sc.Err()
+		}
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		if line == "quit" || line == "exit" {
+			return nil
+		}
+		if strings.HasPrefix(line, "trace ") {
+			traceLine(e, line, out)
+			continue
+		}
+
+		res, err := e.Exec(line)
+		if err != nil {
+			fmt.Fprintln(out, "error:", err)
+			continue
+		}
+		if res.Rows != nil {
+			fmt.Fprintf(out, "%v (%d rows)\n", res.Rows, len(res.Rows))
+		} else {
+			fmt.Fprintf(out, "%d row(s) affected\n", res.Affected)
+		}
+		fmt.Fprintf(out, "plan: %s\n", res.Plan)
+	}
+}
+
+func traceLine(e *query.Engine, line string, out io.Writer) {
+	tree, ok := e.Index.(*btree.BTree)
+	if !ok {
+		fmt.Fprintln(out, "error: index does not support tracing")
+		return
+	}
+	var op string
+	var k int
+	if _, err := fmt.Sscanf(line, "trace %s %d", &op, &k); err != nil {
+		fmt.Fprintln(out, "error: usage: trace insert|delete|search <k>")
+		return
+	}
+	switch op {
+	case "insert":
+		fmt.Fprint(out, tree.InsertTrace(k, nil))
+	case "delete":
+		fmt.Fprint(out, tree.DeleteTrace(k))
+	case "search":
+		fmt.Fprint(out, tree.SearchTrace(k))
+	default:
+		fmt.Fprintln(out, "error: usage: trace insert|delete|search <k>")
+	}
+}
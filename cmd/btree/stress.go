@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/tmdgusya/btree/stress"
+	"github.com/tmdgusya/btree/workload"
+)
+
+// ==================================
+// stress: 동시 실행 검사
+// ==================================
+// stress 패키지로 트리나 LSM 저장소를 여러 goroutine 에서 두드리고 배치마다 검사한다.
+// race detector 를 함께 쓰려면 `go run -race ./cmd/btree stress` 처럼 -race 로 빌드한다.
+// lsm / pager 대상은 -dir 를 주지 않으면 임시 디렉터리에서 돌고 끝나면 지운다.
+// pager 대상은 page.PagerOptions.WriteHook 으로 페이지 쓰기 중간마다 멈춰서 읽어 본다 (stress/pager.go).
+
+func runStress(fs *flag.FlagSet, args []string) error {
+	def := stress.DefaultOptions()
+	target := fs.String("target", def.Target, "what to hammer: tree (in-memory B-tree), lsm, or pager (reads between the steps of each page write)")
+	workers := fs.Int("workers", def.Workers, "concurrent goroutines")
+	batches := fs.Int("batches", def.Batches, "batches; every store check runs between two batches")
+	ops := fs.Int("ops", def.Ops, "operations per worker per batch")
+	keySpace := fs.Int("keys", def.KeySpace, "size of the key space")
+	lookups := fs.Float64("lookups", def.Lookups, "fraction of operations that are reads")
+	deletes := fs.Float64("deletes", def.Deletes, "fraction of writes that are deletes (lsm)")
+	dist := fs.String("dist", "uniform", "key distribution: sequential, uniform or zipfian")
+	seed := fs.Int64("seed", def.Seed, "workload seed (0 = pick one at random; the report shows the seed used)")
+	degree := fs.Int("t", def.Degree, "minimum degree of the tree (tree)")
+	dir := fs.String("dir", "", "working directory (lsm, pager; default: a temporary directory)")
+	if err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	d, err := workload.ParseDistribution(*dist)
+	if err != nil {
+		return err
+	}
+	opts := stress.Options{
+		Target:       *target,
+		Workers:      *workers,
+		Batches:      *batches,
+		Ops:          *ops,
+		KeySpace:     *keySpace,
+		Lookups:      *lookups,
+		Deletes:      *deletes,
+		Distribution: d,
+		Seed:         *seed,
+		Degree:       *degree,
+	}
+	if *dir != "" {
+		opts.Dir = cfg.Path(*dir)
+	}
+
+	rep, err := stress.Run(cmdCtx, opts)
+	rep.Print(os.Stdout)
+	return err
+}
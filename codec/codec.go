@@ -0,0 +1,134 @@
+// Package codec 는 고정 길이 레코드(파일 헤더, 페이지 헤더, 노드, 슬롯)를 big-endian 필드로 읽고 쓴다.
+//
+// 필드를 buf[4:6], buf[6:8] 처럼 오프셋으로 적으면 필드 하나를 넣거나 크기를 바꿀 때 뒤의 오프셋을 모두 고쳐야 하고,
+// 하나를 빠뜨려 두 필드가 겹쳐도 컴파일은 된다. Writer / Reader 는 필드를 적힌 순서대로 이어서 쓰고 읽으므로
+// 오프셋은 커서가 센다. 쓰는 쪽과 읽는 쪽이 같은 순서로 필드를 부르기만 하면 레이아웃이 맞는다.
+//
+//	w := codec.NewWriter(buf)
+//	w.Bytes(h.Magic[:])
+//	w.Uint16(h.Version)
+//	w.Int64(h.HeadOffset)
+//
+//	r := codec.NewReader(buf)
+//	r.Bytes(h.Magic[:])
+//	h.Version = r.Uint16()
+//	h.HeadOffset = r.Int64()
+//
+// buf 보다 길게 쓰거나 읽으면 panic 한다. 레코드 크기 상수가 필드 합과 어긋난 것이므로 조용히 잘리는 것보다 낫다.
+// 슬롯 읽기처럼 자주 불리는 곳에서도 쓰도록 reflection / struct tag 없이 필드마다 메서드를 부르고, 값으로 만들어 할당이 없다.
+package codec
+
+import "encoding/binary"
+
+var Endian = binary.BigEndian
+
+// ==================================
+// Writer
+// ==================================
+
+// Writer 는 buf 앞에서부터 필드를 차례로 쓰는 커서
+type Writer struct {
+	buf []byte
+	off int
+}
+
+// buf 의 cap 을 len 으로 잘라 둔다. 그렇지 않으면 len 을 넘어도 cap 안쪽이면 슬라이싱이 panic 하지 않는다.
+func NewWriter(buf []byte) Writer {
+	return Writer{buf: buf[:len(buf):len(buf)]}
+}
+
+func (w *Writer) Uint8(v uint8) {
+	w.buf[w.off] = v
+	w.off++
+}
+
+func (w *Writer) Uint16(v uint16) {
+	Endian.PutUint16(w.buf[w.off:w.off+2], v)
+	w.off += 2
+}
+
+func (w *Writer) Uint32(v uint32) {
+	Endian.PutUint32(w.buf[w.off:w.off+4], v)
+	w.off += 4
+}
+
+func (w *Writer) Uint64(v uint64) {
+	Endian.PutUint64(w.buf[w.off:w.off+8], v)
+	w.off += 8
+}
+
+// Int64 는 파일 오프셋처럼 음수(NullOffset = -1)가 있는 값을 2 의 보수 8 바이트로 쓴다.
+func (w *Writer) Int64(v int64) {
+	w.Uint64(uint64(v))
+}
+
+// Bytes 는 b 를 그대로 쓴다. Magic 같은 고정 길이 배열에 쓴다.
+func (w *Writer) Bytes(b []byte) {
+	w.off += copy(w.buf[w.off:w.off+len(b)], b)
+}
+
+// Zero 는 n 바이트를 0 으로 채운다 (여백, 예약 필드).
+func (w *Writer) Zero(n int) {
+	clear(w.buf[w.off : w.off+n])
+	w.off += n
+}
+
+// Len 은 지금까지 쓴 바이트 수
+func (w *Writer) Len() int { return w.off }
+
+// ==================================
+// Reader
+// ==================================
+
+// Reader 는 buf 앞에서부터 필드를 차례로 읽는 커서
+type Reader struct {
+	buf []byte
+	off int
+}
+
+// cap 을 자르는 이유는 NewWriter 와 같다.
+func NewReader(buf []byte) Reader {
+	return Reader{buf: buf[:len(buf):len(buf)]}
+}
+
+func (r *Reader) Uint8() uint8 {
+	v := r.buf[r.off]
+	r.off++
+	return v
+}
+
+func (r *Reader) Uint16() uint16 {
+	v := Endian.Uint16(r.buf[r.off : r.off+2])
+	r.off += 2
+	return v
+}
+
+func (r *Reader) Uint32() uint32 {
+	v := Endian.Uint32(r.buf[r.off : r.off+4])
+	r.off += 4
+	return v
+}
+
+func (r *Reader) Uint64() uint64 {
+	v := Endian.Uint64(r.buf[r.off : r.off+8])
+	r.off += 8
+	return v
+}
+
+func (r *Reader) Int64() int64 {
+	return int64(r.Uint64())
+}
+
+// Bytes 는 len(dst) 바이트를 dst 에 복사한다.
+func (r *Reader) Bytes(dst []byte) {
+	r.off += copy(dst, r.buf[r.off:r.off+len(dst)])
+}
+
+// Skip 은 n 바이트를 건너뛴다 (여백, 예약 필드).
+func (r *Reader) Skip(n int) {
+	_ = r.buf[r.off : r.off+n]
+	r.off += n
+}
+
+// Len 은 지금까지 읽은 바이트 수
+func (r *Reader) Len() int { return r.off }
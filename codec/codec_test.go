@@ -0,0 +1,100 @@
+package codec
+
+import (
+	"bytes"
+	"testing"
+)
+
+// 쓴 순서대로 읽으면 같은 값이 나오고, 쓰는 쪽과 읽는 쪽의 커서가 같은 자리에서 끝난다.
+func TestRoundTrip(t *testing.T) {
+	buf := make([]byte, 1+2+4+8+8+4+3)
+	w := NewWriter(buf)
+	w.Uint8(0xab)
+	w.Uint16(0xbeef)
+	w.Uint32(0xdeadbeef)
+	w.Uint64(1<<63 | 42)
+	w.Int64(-1)
+	w.Bytes([]byte("MAGI"))
+	w.Zero(3)
+	if w.Len() != len(buf) {
+		t.Fatalf("writer at %d, want %d", w.Len(), len(buf))
+	}
+
+	r := NewReader(buf)
+	if v := r.Uint8(); v != 0xab {
+		t.Fatalf("Uint8 = %#x", v)
+	}
+	if v := r.Uint16(); v != 0xbeef {
+		t.Fatalf("Uint16 = %#x", v)
+	}
+	if v := r.Uint32(); v != 0xdeadbeef {
+		t.Fatalf("Uint32 = %#x", v)
+	}
+	if v := r.Uint64(); v != 1<<63|42 {
+		t.Fatalf("Uint64 = %#x", v)
+	}
+	if v := r.Int64(); v != -1 {
+		t.Fatalf("Int64 = %d", v)
+	}
+	var magic [4]byte
+	r.Bytes(magic[:])
+	if string(magic[:]) != "MAGI" {
+		t.Fatalf("Bytes = %q", magic)
+	}
+	r.Skip(3)
+	if r.Len() != len(buf) {
+		t.Fatalf("reader at %d, want %d", r.Len(), len(buf))
+	}
+}
+
+// 필드는 big-endian 으로 빈틈없이 이어진다. 파일 형식이 이 레이아웃에 기대므로 바이트까지 확인한다.
+func TestLayout(t *testing.T) {
+	buf := bytes.Repeat([]byte{0xff}, 1+2+4+8+2)
+	w := NewWriter(buf)
+	w.Uint8(1)
+	w.Uint16(0x0203)
+	w.Uint32(0x04050607)
+	w.Int64(0x08090a0b0c0d0e0f)
+	w.Zero(2)
+
+	want := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 0, 0}
+	if !bytes.Equal(buf, want) {
+		t.Fatalf("buf = % x, want % x", buf, want)
+	}
+}
+
+// buf 보다 길게 쓰거나 읽으면 잘리지 않고 panic 한다.
+func TestShortBuffer(t *testing.T) {
+	cases := []struct {
+		name string
+		f    func(buf []byte)
+	}{
+		{"Writer.Uint8", func(buf []byte) { w := NewWriter(buf[:0]); w.Uint8(1) }},
+		{"Writer.Uint16", func(buf []byte) { w := NewWriter(buf[:1]); w.Uint16(1) }},
+		{"Writer.Uint32", func(buf []byte) { w := NewWriter(buf[:3]); w.Uint32(1) }},
+		{"Writer.Uint64", func(buf []byte) { w := NewWriter(buf[:7]); w.Uint64(1) }},
+		{"Writer.Int64", func(buf []byte) { w := NewWriter(buf[:7]); w.Int64(-1) }},
+		{"Writer.Bytes", func(buf []byte) { w := NewWriter(buf[:3]); w.Bytes([]byte("MAGI")) }},
+		{"Writer.Zero", func(buf []byte) { w := NewWriter(buf[:3]); w.Zero(4) }},
+		{"Writer.after fields", func(buf []byte) { w := NewWriter(buf[:8]); w.Uint32(1); w.Uint64(2) }},
+		{"Reader.Uint8", func(buf []byte) { r := NewReader(buf[:0]); r.Uint8() }},
+		{"Reader.Uint16", func(buf []byte) { r := NewReader(buf[:1]); r.Uint16() }},
+		{"Reader.Uint32", func(buf []byte) { r := NewReader(buf[:3]); r.Uint32() }},
+		{"Reader.Uint64", func(buf []byte) { r := NewReader(buf[:7]); r.Uint64() }},
+		{"Reader.Int64", func(buf []byte) { r := NewReader(buf[:7]); r.Int64() }},
+		{"Reader.Bytes", func(buf []byte) { r := NewReader(buf[:3]); r.Bytes(make([]byte, 4)) }},
+		{"Reader.Skip", func(buf []byte) { r := NewReader(buf[:3]); r.Skip(4) }},
+		{"Reader.after fields", func(buf []byte) { r := NewReader(buf[:8]); r.Uint32(); r.Uint64() }},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("overrun did not panic")
+				}
+			}()
+			// 뒤에 여유 용량이 있어도 len 을 넘으면 panic 해야 한다.
+			c.f(make([]byte, 16))
+		})
+	}
+}
@@ -0,0 +1,290 @@
+// Package config 는 btree 바이너리가 쓰는 설정을 한곳에서 읽는다.
+// 우선순위는 기본값 < JSON 파일 < 환경 변수 < 플래그 이고, 같은 키 이름을 모든 경로에서 쓴다.
+//
+//	키           플래그        환경 변수           JSON
+//	addr         -addr         BTREE_ADDR         "addr"
+//	data-dir     -data-dir     BTREE_DATA_DIR     "dataDir"
+//	page-size    -page-size    BTREE_PAGE_SIZE    "pageSize"
+//	cache-size   -cache-size   BTREE_CACHE_SIZE   "cacheSize"
+//	durability   -durability   BTREE_DURABILITY   "durability"
+//	log-level    -log-level    BTREE_LOG_LEVEL    "logLevel"
+//	log-format   -log-format   BTREE_LOG_FORMAT   "logFormat"
+//	auto-vacuum  -auto-vacuum  BTREE_AUTO_VACUUM  "autoVacuum"
+//	audit        -audit        BTREE_AUDIT        "audit"
+//	admin-token  -admin-token  BTREE_ADMIN_TOKEN  "adminToken"
+//
+// JSON 파일 경로는 -config 또는 BTREE_CONFIG 로 준다.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Durability 는 쓰기를 언제 fsync 할지 정한다.
+type Durability string
+
+const (
+	// DurabilityNone: Sync 를 부르지 않는다. 운영체제가 알아서 내려 쓴다.
+	DurabilityNone Durability = "none"
+	// DurabilityBatch: 배치(벌크 로드의 커밋 단위)가 끝날 때마다 Sync 한다.
+	DurabilityBatch Durability = "batch"
+	// DurabilitySync: 변경 하나마다 Sync 한다.
+	DurabilitySync Durability = "sync"
+)
+
+type Config struct {
+	Addr    string `json:"addr"`
+	DataDir string `json:"dataDir"`
+	// PageSize 는 새로 만드는 페이지 파일의 페이지 크기. 기존 파일은 헤더에 적힌 값을 따른다.
+	PageSize int `json:"pageSize"`
+	// CacheSize 는 버퍼 풀이 들고 있을 페이지 수. 지금의 저장소는 페이지 버퍼가 한 장뿐이라 아직 쓰지 않는다.
+	CacheSize  int        `json:"cacheSize"`
+	Durability Durability `json:"durability"`
+	LogLevel   string     `json:"logLevel"`
+	// LogFormat 은 stderr 로그의 모양. text 는 key=value 한 줄, json 은 한 줄에 JSON 객체 하나.
+	LogFormat string `json:"logFormat"`
+	// AutoVacuum 은 페이지 리스트에서 삭제 뒤 새 쓰레기(tombstone) 비율이 이 값 이상이면 파일을 정리한다. 0 이면 끈다.
+	AutoVacuum float64 `json:"autoVacuum"`
+	// Audit 을 켜면 리스트 파일을 바꿀 때마다 헤더의 Size 를 따로 센 값과 맞춰 보고, 어긋나면 바로 실패한다.
+	Audit bool `json:"audit"`
+	// AdminToken 은 serve 의 /api/admin/* 가 받는 Bearer 토큰. 비어 있으면 관리 엔드포인트는 닫혀 있다.
+	// 명령줄은 ps 로 보이므로 환경 변수나 설정 파일로 주는 편이 낫다.
+	AdminToken string `json:"adminToken"`
+}
+
+// Default 는 아무 설정도 없을 때의 값
+func Default() Config {
+	return Config{
+		Addr:       ":8080",
+		DataDir:    ".",
+		PageSize:   4096,
+		CacheSize:  64,
+		Durability: DurabilityBatch,
+		LogLevel:   "info",
+		LogFormat:  "text",
+	}
+}
+
+// EnvPrefix 는 환경 변수 이름 앞에 붙는다. 키의 '-' 는 '_' 로 바꾸고 대문자로 쓴다.
+const EnvPrefix = "BTREE_"
+
+// EnvConfigFile 은 JSON 설정 파일 경로를 담는 환경 변수
+const EnvConfigFile = EnvPrefix + "CONFIG"
+
+var keys = []struct {
+	name, usage string
+}{
+	{"addr", "listen address for servers"},
+	{"data-dir", "directory that relative data file paths are resolved against"},
+	{"page-size", "page size for newly created page files"},
+	{"cache-size", "buffer pool size in pages"},
+	{"durability", "when to fsync: none, batch or sync"},
+	{"log-level", "minimum log level: debug, info, warn or error"},
+	{"log-format", "log output format on stderr: text or json"},
+	{"auto-vacuum", "compact a paged list when a delete leaves this fraction of new garbage slots (0 = off)"},
+	{"audit", "cross-check a list file's size after every change and fail on drift (true or false)"},
+	{"admin-token", "bearer token required by the serve admin endpoints (empty = admin endpoints disabled)"},
+}
+
+// EnvName 은 key 에 해당하는 환경 변수 이름
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
+
+// Get 은 key 의 현재 값을 문자열로 돌려준다.
+func (c Config) Get(key string) (string, error) {
+	switch key {
+	case "addr":
+		return c.Addr, nil
+	case "data-dir":
+		return c.DataDir, nil
+	case "page-size":
+		return strconv.Itoa(c.PageSize), nil
+	case "cache-size":
+		return strconv.Itoa(c.CacheSize), nil
+	case "durability":
+		return string(c.Durability), nil
+	case "log-level":
+		return c.LogLevel, nil
+	case "log-format":
+		return c.LogFormat, nil
+	case "auto-vacuum":
+		return strconv.FormatFloat(c.AutoVacuum, 'g', -1, 64), nil
+	case "audit":
+		return strconv.FormatBool(c.Audit), nil
+	case "admin-token":
+		return c.AdminToken, nil
+	default:
+		return "", fmt.Errorf("unknown config key %q", key)
+	}
+}
+
+// Set 은 key 를 value 로 바꾼다. 플래그와 환경 변수가 같은 경로를 쓴다.
+func (c *Config) Set(key, value string) error {
+	var err error
+	switch key {
+	case "addr":
+		c.Addr = value
+	case "data-dir":
+		c.DataDir = value
+	case "page-size":
+		c.PageSize, err = strconv.Atoi(value)
+	case "cache-size":
+		c.CacheSize, err = strconv.Atoi(value)
+	case "durability":
+		c.Durability = Durability(value)
+	case "log-level":
+		c.LogLevel = value
+	case "log-format":
+		c.LogFormat = value
+	case "auto-vacuum":
+		c.AutoVacuum, err = strconv.ParseFloat(value, 64)
+	case "audit":
+		c.Audit, err = strconv.ParseBool(value)
+	case "admin-token":
+		c.AdminToken = value
+	default:
+		return fmt.Errorf("unknown config key %q", key)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	return nil
+}
+
+// Validate 는 값의 범위를 검사한다.
+func (c Config) Validate() error {
+	if c.PageSize < 512 || c.PageSize > 32768 || c.PageSize&(c.PageSize-1) != 0 {
+		return fmt.Errorf("page-size %d must be a power of two between 512 and 32768", c.PageSize)
+	}
+	if c.CacheSize < 1 {
+		return fmt.Errorf("cache-size %d must be at least 1", c.CacheSize)
+	}
+	switch c.Durability {
+	case DurabilityNone, DurabilityBatch, DurabilitySync:
+	default:
+		return fmt.Errorf("durability %q must be none, batch or sync", c.Durability)
+	}
+	if !(c.AutoVacuum >= 0 && c.AutoVacuum <= 1) {
+		return fmt.Errorf("auto-vacuum %g must be between 0 and 1", c.AutoVacuum)
+	}
+	if _, err := c.SlogLevel(); err != nil {
+		return err
+	}
+	if c.LogFormat != "text" && c.LogFormat != "json" {
+		return fmt.Errorf("log-format %q must be text or json", c.LogFormat)
+	}
+	if c.DataDir == "" {
+		return errors.New("data-dir must not be empty")
+	}
+	return nil
+}
+
+// SlogLevel 은 LogLevel 을 slog.Level 로 바꾼다.
+func (c Config) SlogLevel() (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		return 0, fmt.Errorf("log-level %q must be debug, info, warn or error", c.LogLevel)
+	}
+	return l, nil
+}
+
+// Logger 는 LogLevel 과 LogFormat 에 맞춰 w 에 쓰는 slog 로거. Validate 를 통과한 값이라고 본다.
+func (c Config) Logger(w io.Writer) *slog.Logger {
+	level, _ := c.SlogLevel()
+	opts := &slog.HandlerOptions{Level: level}
+	if c.LogFormat == "json" {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+// Path 는 상대 경로를 DataDir 기준으로 바꾼다. 절대 경로와 "-"(stdin/stdout) 는 그대로 둔다.
+func (c Config) Path(name string) string {
+	if name == "-" || filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(c.DataDir, name)
+}
+
+// Loader 는 FlagSet 에 설정 플래그를 등록해 두었다가 Parse 뒤에 Load 로 최종 값을 만든다.
+type Loader struct {
+	fs   *flag.FlagSet
+	file *string
+}
+
+// Register 는 fs 에 -config 와 키마다 플래그 하나를 등록한다.
+func Register(fs *flag.FlagSet) *Loader {
+	def := Default()
+	l := &Loader{fs: fs}
+	l.file = fs.String("config", "", "JSON config file (also "+EnvConfigFile+")")
+	for _, k := range keys {
+		v, _ := def.Get(k.name)
+		fs.String(k.name, v, k.usage+" (also "+EnvName(k.name)+")")
+	}
+	return l
+}
+
+// Load 는 fs.Parse 뒤에 부른다. getenv 는 보통 os.Getenv.
+func (l *Loader) Load(getenv func(string) string) (Config, error) {
+	cfg := Default()
+
+	path := *l.file
+	if path == "" {
+		path = getenv(EnvConfigFile)
+	}
+	if path != "" {
+		if err := loadFile(path, &cfg); err != nil {
+			return Config{}, err
+		}
+	}
+
+	for _, k := range keys {
+		if v := getenv(EnvName(k.name)); v != "" {
+			if err := cfg.Set(k.name, v); err != nil {
+				return Config{}, fmt.Errorf("%s: %w", EnvName(k.name), err)
+			}
+		}
+	}
+
+	// 명시적으로 준 플래그만 덮어쓴다. 기본값을 그대로 둔 플래그가 파일 / 환경 값을 지우면 안 된다.
+	var err error
+	l.fs.Visit(func(f *flag.Flag) {
+		if err != nil || f.Name == "config" {
+			return
+		}
+		if _, gerr := cfg.Get(f.Name); gerr != nil {
+			return // 설정 키가 아닌 플래그
+		}
+		err = cfg.Set(f.Name, f.Value.String())
+	})
+	if err != nil {
+		return Config{}, err
+	}
+
+	return cfg, cfg.Validate()
+}
+
+func loadFile(path string, cfg *Config) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(cfg); err != nil {
+		return fmt.Errorf("config %s: %w", path, err)
+	}
+	return nil
+}
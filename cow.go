@@ -0,0 +1,72 @@
+package btree
+
+// ==================================
+// Copy-on-write 삽입과 삭제
+// ==================================
+// 읽기가 대부분인 웹 데모에서 검색과 상태 조회가 삽입을 기다리지 않도록, 트리를 고치는 대신 새 트리를 만든다.
+// - 루트에서 k 가 들어갈 잎까지 지나는 노드만 복사해서 고치고, 나머지 서브트리는 이전 트리와 함께 쓴다.
+//   분할로 생기는 노드는 원래 새로 만들므로 복사할 게 없다. 한 번에 새로 만드는 노드는 높이 + 분할 수 정도다.
+// - 이전 트리는 한 바이트도 바뀌지 않으므로 그것을 읽던 goroutine 은 잠금 없이 끝까지 읽는다.
+// - 삭제는 내려가는 자식에 더해 borrow 로 키를 내주는 형제와 merge 로 합쳐지는 왼쪽 노드도 복사한다.
+// - 함께 쓰는 노드를 고치면 두 트리가 같이 깨진다. 다른 goroutine 에 넘긴 트리에는 Insert / Delete 대신 InsertCopy / DeleteCopy 만 쓴다.
+
+// InsertCopy 는 b 를 그대로 두고 k 와 그 값을 넣은 새 트리를 돌려준다. 이미 있는 키면 값만 바꾼 새 트리와 true 다.
+// 새 트리는 arena 를 쓰지 않는다. 버린 이전 노드가 arena 덩어리를 통째로 붙잡고 있지 않게 하려는 것이다.
+func (b *BTree) InsertCopy(k int, value []byte) (*BTree, bool) {
+	return b.insertCopy(k, value, nil)
+}
+
+// InsertCopyTrace 는 InsertTrace 의 copy-on-write 판. 기록은 새 트리 기준이다.
+func (b *BTree) InsertCopyTrace(k int, value []byte) (*BTree, *Trace) {
+	tr := &Trace{Op: "insert", Key: k}
+	next, replaced := b.insertCopy(k, value, tr)
+	tr.Found = replaced
+	return next, tr
+}
+
+func (b *BTree) insertCopy(k int, value []byte, tr *Trace) (*BTree, bool) {
+	next := *b
+	next.arena = nil
+	if next.root != nil {
+		next.root = next.root.clone(b.t)
+	}
+	replaced := next.insert(k, value, tr, true)
+	return &next, replaced
+}
+
+// DeleteCopy 는 b 를 그대로 두고 k 하나를 지운 새 트리를 돌려준다. 없는 키면 b 자신과 false 다.
+func (b *BTree) DeleteCopy(k int) (*BTree, bool) {
+	return b.deleteCopy(k, nil)
+}
+
+// DeleteCopyTrace 는 DeleteTrace 의 copy-on-write 판. 없는 키면 b 자신을 돌려준다.
+func (b *BTree) DeleteCopyTrace(k int) (*BTree, *Trace) {
+	tr := &Trace{Op: "delete", Key: k}
+	next, found := b.deleteCopy(k, tr)
+	tr.Found = found
+	return next, tr
+}
+
+func (b *BTree) deleteCopy(k int, tr *Trace) (*BTree, bool) {
+	// 없는 키에 루트만 복사한 새 트리를 만들지 않도록 복사하기 전에 찾는다.
+	if !b.lookupForDelete(k, tr) {
+		return b, false
+	}
+	next := *b
+	next.arena = nil
+	next.root = next.root.clone(b.t)
+	next.remove(k, tr, true)
+	return &next, true
+}
+
+// clone 은 x 의 키, 값, 자식 포인터를 새 배열에 옮긴 노드. 자식 노드와 값의 바이트는 함께 쓴다.
+// 배열을 꽉 찬 크기로 새로 잡으므로 복사본에 append 해도 x 의 배열(arena 덩어리일 수도 있다)을 덮지 않는다.
+func (x *BTreeNode) clone(t int) *BTreeNode {
+	y := newNode(t, x.isLeaf)
+	y.keys = append(y.keys, x.keys...)
+	y.values = append(y.values, x.values...)
+	if !x.isLeaf {
+		y.children = append(y.children, x.children...)
+	}
+	return y
+}
@@ -0,0 +1,185 @@
+// Package dbdir 는 데이터베이스 하나를 디렉터리 하나로 다룬다.
+// 데이터 파일, WAL, MANIFEST, LOCK 을 낱개 파일로 흩어 두지 않고 한 디렉터리에 모아서 Open 하나로 함께 열고 닫는다.
+//
+//	<dir>/
+//	  MANIFEST     저장소 종류, 포맷 버전, 페이지 크기, 만든 시각, 마지막 checkpoint (JSON, manifest.go)
+//	  LOCK         열려 있는 동안 잠가 두는 빈 파일. 다른 프로세스가 같은 디렉터리를 동시에 쓰지 못하게 한다.
+//	  data.pages   page.Pager 의 페이지 파일
+//	  wal/         page.BufferPool 의 WAL (logstore 세그먼트)
+//
+// - 없는 디렉터리나 빈 디렉터리를 열면 새로 만든다. MANIFEST 를 가장 나중에 쓰므로, 만들다 죽은 디렉터리는 다시 열 때 이어서 만든다.
+// - MANIFEST 가 없는데 다른 파일이 있는 디렉터리는 데이터베이스가 아니므로 열지 않는다.
+// - 쓰기로 열면 LOCK 을 배타적으로, 읽기 전용이면 공유로 잡는다. 잡지 못하면 기다리지 않고 ErrLocked 다.
+package dbdir
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tmdgusya/btree/chapter01/page"
+	"github.com/tmdgusya/btree/dberr"
+)
+
+// 디렉터리 안의 이름
+const (
+	ManifestFile = "MANIFEST"
+	LockFile     = "LOCK"
+	DataFile     = "data.pages"
+	WALDir       = "wal"
+)
+
+var (
+	ErrNotDatabase = dberr.New(dberr.ErrInvalidMagic, "dbdir: not a database directory")
+	ErrLocked      = errors.New("dbdir: database is locked by another process")
+)
+
+// Options 는 데이터 파일과 버퍼 풀을 여는 설정. Pager.ReadOnly 면 디렉터리 전체를 읽기 전용으로 연다.
+// Pool.WALDir 은 무시하고 항상 <dir>/wal 을 쓴다.
+type Options struct {
+	Pager page.PagerOptions
+	Pool  page.BufferPoolOptions
+}
+
+// DB 는 열려 있는 데이터베이스 디렉터리
+type DB struct {
+	dir      string
+	readOnly bool
+	lock     *os.File
+	pool     *page.BufferPool
+	manifest Manifest
+}
+
+// Open 은 dir 의 데이터베이스를 연다. 쓰기로 열 때 dir 이 없거나 비어 있으면 새로 만든다.
+// 있던 디렉터리면 MANIFEST 를 검사하고, 데이터 파일의 페이지 크기가 MANIFEST 와 같은지 본다.
+func Open(dir string, opts Options) (*DB, error) {
+	readOnly := opts.Pager.ReadOnly
+	if !readOnly {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+	lock, err := acquire(filepath.Join(dir, LockFile), readOnly)
+	if err != nil {
+		return nil, err
+	}
+	db := &DB{dir: dir, readOnly: readOnly, lock: lock}
+	if err := db.open(opts); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+func (db *DB) open(opts Options) error {
+	m, ok, err := readManifest(db.dir)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if err := db.checkFresh(); err != nil {
+			return err
+		}
+	}
+
+	opts.Pool.WALDir = db.Path(WALDir)
+	if db.pool, err = page.OpenBufferPool(db.Path(DataFile), opts.Pager, opts.Pool); err != nil {
+		return err
+	}
+
+	if ok {
+		if got := db.pool.FilePageSize(); got != m.PageSize {
+			return fmt.Errorf("%w: page size %d, data file has %d", ErrManifest, m.PageSize, got)
+		}
+		db.manifest = m
+		return nil
+	}
+	// 데이터 파일과 WAL 을 다 만든 뒤에 MANIFEST 를 쓴다.
+	db.manifest = Manifest{Store: StoreType, Format: formatVersion, PageSize: db.pool.FilePageSize(), Created: time.Now().UTC()}
+	return writeManifest(db.dir, db.manifest)
+}
+
+// checkFresh 는 MANIFEST 가 없는 디렉터리가 새로 만들어도 되는 곳인지 본다.
+// LOCK 말고는 우리가 만드는 파일(만들다 죽은 흔적)만 있어야 한다. 읽기 전용이면 만들 수 없다.
+func (db *DB) checkFresh() error {
+	if db.readOnly {
+		return fmt.Errorf("%w: %s has no %s", ErrNotDatabase, db.dir, ManifestFile)
+	}
+	entries, err := os.ReadDir(db.dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		switch e.Name() {
+		case LockFile, DataFile, WALDir, manifestTemp:
+		default:
+			return fmt.Errorf("%w: %s has %s but no %s", ErrNotDatabase, db.dir, e.Name(), ManifestFile)
+		}
+	}
+	return nil
+}
+
+// Dir 은 데이터베이스 디렉터리
+func (db *DB) Dir() string {
+	return db.dir
+}
+
+// Path 는 디렉터리 안의 name 의 경로 (ManifestFile, DataFile ...)
+func (db *DB) Path(name string) string {
+	return filepath.Join(db.dir, name)
+}
+
+// Pages 는 데이터 파일 위의 버퍼 풀. 닫지 말고 DB.Close 로 닫는다.
+func (db *DB) Pages() *page.BufferPool {
+	return db.pool
+}
+
+// Manifest 는 연 (또는 마지막으로 고쳐 쓴) MANIFEST
+func (db *DB) Manifest() Manifest {
+	return db.manifest
+}
+
+// Checkpoint 는 버퍼 풀을 Checkpoint 하고 (dirty 페이지를 쓰고 fsync 한 뒤 WAL 을 비운다) MANIFEST 에 기록한다.
+func (db *DB) Checkpoint() error {
+	if db.readOnly {
+		return fmt.Errorf("%w: %s", page.ErrReadOnly, db.dir)
+	}
+	lsn, pages := db.pool.LSN(), db.pool.PageCount()
+	if err := db.pool.Checkpoint(); err != nil {
+		return err
+	}
+	return db.recordCheckpoint(lsn, pages)
+}
+
+func (db *DB) recordCheckpoint(lsn, pages int64) error {
+	m := db.manifest
+	m.LastCheckpoint = &Checkpoint{Time: time.Now().UTC(), LSN: lsn, Pages: pages}
+	if err := writeManifest(db.dir, m); err != nil {
+		return err
+	}
+	db.manifest = m
+	return nil
+}
+
+// Close 는 버퍼 풀을 닫고 (닫으면서 Checkpoint 한다) MANIFEST 에 그 checkpoint 를 적은 뒤 잠금을 푼다.
+// LOCK 파일은 지우지 않는다.
+func (db *DB) Close() error {
+	var errs []error
+	if db.pool != nil {
+		lsn, pages := db.pool.LSN(), db.pool.PageCount()
+		err := db.pool.Close()
+		// 새로 만들다 실패한 디렉터리는 MANIFEST 가 없으므로 적지 않는다.
+		if err == nil && !db.readOnly && db.manifest.Store != "" {
+			err = db.recordCheckpoint(lsn, pages)
+		}
+		errs = append(errs, err)
+		db.pool = nil
+	}
+	if db.lock != nil {
+		errs = append(errs, release(db.lock))
+		db.lock = nil
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,31 @@
+//go:build !unix
+
+package dbdir
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// flock 이 없는 플랫폼에서는 LOCK 파일을 열어 두기만 하고 잠그지 않는다. 동시에 여는 것을 막지 못한다.
+func acquire(path string, readOnly bool) (*os.File, error) {
+	flags := os.O_RDWR | os.O_CREATE
+	if readOnly {
+		flags = os.O_RDONLY
+	}
+	f, err := os.OpenFile(path, flags, 0644)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %w", ErrNotDatabase, err)
+	}
+	return f, err
+}
+
+func release(f *os.File) error {
+	return f.Close()
+}
+
+// 디렉터리를 fsync 할 방법이 없으므로 rename 의 지속성은 파일시스템에 맡긴다.
+func syncDir(dir string) error {
+	return nil
+}
@@ -0,0 +1,50 @@
+//go:build unix
+
+package dbdir
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// acquire 는 path 를 열고 flock 을 건다. 쓰기면 배타적, 읽기 전용이면 공유 잠금이다.
+// flock 은 파일 설명자에 걸리므로 같은 프로세스에서 두 번 열어도 서로 막는다. 프로세스가 죽으면 커널이 풀어 준다.
+func acquire(path string, readOnly bool) (*os.File, error) {
+	flags, how := os.O_RDWR|os.O_CREATE, syscall.LOCK_EX
+	if readOnly {
+		flags, how = os.O_RDONLY, syscall.LOCK_SH
+	}
+	f, err := os.OpenFile(path, flags, 0644)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %w", ErrNotDatabase, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), how|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, fmt.Errorf("%w: %s", ErrLocked, path)
+		}
+		return nil, err
+	}
+	return f, nil
+}
+
+func release(f *os.File) error {
+	// 닫으면 잠금도 풀리지만, 닫기 전에 풀어서 순서를 분명히 한다.
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+	return errors.Join(err, f.Close())
+}
+
+// syncDir 은 디렉터리 항목(rename 결과)을 디스크에 내린다.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
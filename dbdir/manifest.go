@@ -0,0 +1,107 @@
+package dbdir
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ==================================
+// MANIFEST
+// ==================================
+// 디렉터리의 메타데이터. 포맷을 바꿀 때 옛 디렉터리를 알아보고 거절하거나 옮길 수 있도록 열 때마다 검사한다.
+//
+//	{"store": "pages", "format": 1, "pageSize": 4096, "created": "...",
+//	 "lastCheckpoint": {"time": "...", "lsn": 42, "pages": 7}}
+//
+// - 고칠 때는 MANIFEST.tmp 에 쓰고 fsync 한 뒤 rename 하고 디렉터리를 fsync 한다.
+//   rename 은 원자적이므로 어느 순간에 죽어도 MANIFEST 는 예전 것이나 새것 중 하나이고, 남은 .tmp 는 다음에 덮어쓴다.
+// - lastCheckpoint 는 DB.Checkpoint / Close 가 데이터 파일을 fsync 하고 WAL 을 비운 뒤에 적는다.
+
+// StoreType 은 이 패키지가 만드는 디렉터리의 저장소 종류
+const StoreType = "pages"
+
+// 지금 쓰는 디렉터리 포맷 버전
+const formatVersion = 1
+
+const manifestTemp = ManifestFile + ".tmp"
+
+// Manifest 는 MANIFEST 의 내용
+type Manifest struct {
+	Store          string      `json:"store"`
+	Format         int         `json:"format"`
+	PageSize       int         `json:"pageSize"`
+	Created        time.Time   `json:"created"`
+	LastCheckpoint *Checkpoint `json:"lastCheckpoint,omitempty"`
+}
+
+// Checkpoint 는 마지막 checkpoint 의 시각과, 그때까지 나눠 준 LSN 과 페이지 수
+type Checkpoint struct {
+	Time  time.Time `json:"time"`
+	LSN   int64     `json:"lsn"`
+	Pages int64     `json:"pages"`
+}
+
+// ErrManifest 는 MANIFEST 가 이 패키지가 여는 디렉터리와 맞지 않을 때
+var ErrManifest = fmt.Errorf("%w: bad %s", ErrNotDatabase, ManifestFile)
+
+// readManifest 는 dir 의 MANIFEST 를 읽는다. 없으면 ok 가 false 다.
+func readManifest(dir string) (m Manifest, ok bool, err error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return Manifest{}, false, nil
+	}
+	if err != nil {
+		return Manifest{}, false, err
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return Manifest{}, false, fmt.Errorf("%w: %w", ErrManifest, err)
+	}
+	return m, true, m.validate()
+}
+
+// validate 는 이 버전의 코드가 열 수 있는 MANIFEST 인지 본다. 페이지 크기는 데이터 파일을 연 뒤에 따로 맞춰 본다.
+func (m Manifest) validate() error {
+	switch {
+	case m.Store != StoreType:
+		return fmt.Errorf("%w: store %q, want %q", ErrManifest, m.Store, StoreType)
+	case m.Format != formatVersion:
+		return fmt.Errorf("%w: format %d, this build reads %d", ErrManifest, m.Format, formatVersion)
+	case m.PageSize <= 0:
+		return fmt.Errorf("%w: page size %d", ErrManifest, m.PageSize)
+	case m.Created.IsZero():
+		return fmt.Errorf("%w: no creation time", ErrManifest)
+	}
+	return nil
+}
+
+// writeManifest 는 m 을 dir 의 MANIFEST 로 원자적으로 바꿔 넣는다.
+func writeManifest(dir string, m Manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(dir, manifestTemp)
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, ManifestFile)); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
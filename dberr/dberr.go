@@ -0,0 +1,72 @@
+// Package dberr 는 트리, 저장소, HTTP 계층이 함께 쓰는 에러 종류다.
+// 패키지마다 자기 에러(pager.ErrChecksum, pagedlist.ErrInvalidMagic ...)는 그대로 두고 이 종류로 분류해 둔다.
+// 그래서 호출하는 쪽은 어느 패키지에서 온 에러인지 몰라도 errors.Is(err, dberr.ErrCorruptPage) 로 나눌 수 있고,
+// 패키지 고유의 에러로 더 좁혀서 볼 수도 있다.
+package dberr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	// ErrInvalidMagic 은 파일 맨 앞의 식별자가 기대한 포맷이 아닐 때. 다른 종류의 파일을 연 경우다.
+	ErrInvalidMagic = errors.New("invalid magic")
+	// ErrCorruptPage 는 포맷은 맞지만 페이지 (또는 블록) 내용이 깨졌을 때. 체크섬 불일치, 범위 밖 슬롯 등.
+	ErrCorruptPage = errors.New("corrupt page")
+	// ErrKeyNotFound 는 찾거나 지우려는 키가 없을 때
+	ErrKeyNotFound = errors.New("key not found")
+	// ErrTreeNotInitialized 는 트리를 만들기 전에 트리 연산을 했을 때
+	ErrTreeNotInitialized = errors.New("tree not initialized")
+	// ErrReadOnly 는 읽기 전용으로 연 저장소에 쓰려고 할 때
+	ErrReadOnly = errors.New("read-only")
+)
+
+var kinds = []error{ErrInvalidMagic, ErrCorruptPage, ErrKeyNotFound, ErrTreeNotInitialized, ErrReadOnly}
+
+// New 는 kind 로 분류되는 패키지 고유의 sentinel 을 만든다. 메시지는 text 그대로이고,
+// 돌려받은 에러 e 에 대해 errors.Is(e, kind) 가 참이다.
+func New(kind error, text string) error {
+	return &kindError{kind: kind, text: text}
+}
+
+type kindError struct {
+	kind error
+	text string
+}
+
+func (e *kindError) Error() string { return e.text }
+func (e *kindError) Unwrap() error { return e.kind }
+
+// PageError 는 어느 페이지에서 난 에러인지 붙여 둔다. errors.As 로 꺼내면 페이지 번호를 알 수 있다.
+type PageError struct {
+	Page int64
+	Err  error
+}
+
+func (e *PageError) Error() string { return fmt.Sprintf("%v: page %d", e.Err, e.Page) }
+func (e *PageError) Unwrap() error { return e.Err }
+
+// Kind 는 err 가 속한 종류. 어느 종류에도 속하지 않으면 nil.
+func Kind(err error) error {
+	for _, k := range kinds {
+		if errors.Is(err, k) {
+			return k
+		}
+	}
+	return nil
+}
+
+// HTTPStatus 는 err 의 종류에 맞는 HTTP 상태 코드. 종류가 없으면 500.
+func HTTPStatus(err error) int {
+	switch Kind(err) {
+	case ErrKeyNotFound:
+		return http.StatusNotFound
+	case ErrTreeNotInitialized:
+		return http.StatusConflict
+	case ErrReadOnly:
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,241 @@
+package btree
+
+import "slices"
+
+// ==================================
+// 삭제
+// ==================================
+// 삽입이 꽉 찬 자식을 내려가기 전에 미리 나누듯, 삭제는 키가 t-1 개뿐인 자식을 내려가기 전에 미리 채운다 (CLRS).
+// 그래서 잎에서 키 하나를 빼도 t-1 개 밑으로 내려가지 않고, 다시 올라오며 고칠 일이 없다.
+// - 잎에서 찾으면 그냥 뺀다.
+// - 내부 노드에서 찾으면 왼쪽 자식에 키가 t 개 이상일 때 predecessor 를, 오른쪽 자식이 그렇다면 successor 를
+//   그 서브트리에서 빼 와서 자리를 채운다. 둘 다 t-1 개면 두 자식과 그 키를 한 노드로 합치고 합친 노드로 내려간다.
+// - 내려갈 자식이 t-1 개면 형제 중 t 개 이상인 쪽에서 부모를 거쳐 키 하나를 빌려 오고 (borrow),
+//   둘 다 t-1 개면 형제와 합친다 (merge).
+// - 합치다가 루트의 키가 다 빠지면 그 자식이 새 루트가 되어 높이가 하나 준다 (shrink).
+// 없는 키는 먼저 검색으로 확인하고 트리를 건드리지 않는다. 같은 키가 여럿이면 그중 하나만 지운다.
+
+// Delete 는 k 하나를 지운다. 없으면 트리를 그대로 두고 false 다.
+func (b *BTree) Delete(k int) bool {
+	return b.delete(k, nil, false)
+}
+
+// DeleteTrace 는 Delete 를 하면서 방문한 노드, 비교, borrow / merge 를 기록해서 돌려준다. Found 는 지웠는지다.
+// 없는 키면 검색과 같은 기록이 남고 경로의 끝이 DirMiss 다.
+func (b *BTree) DeleteTrace(k int) *Trace {
+	tr := &Trace{Op: "delete", Key: k}
+	tr.Found = b.delete(k, tr, false)
+	return tr
+}
+
+func (b *BTree) delete(k int, tr *Trace, cow bool) bool {
+	if !b.lookupForDelete(k, tr) {
+		return false
+	}
+	b.remove(k, tr, cow)
+	return true
+}
+
+// lookupForDelete 는 k 가 있는지 본다. 없는 키 때문에 borrow / merge 로 트리 모양만 바뀌지 않도록 지우기 전에 먼저 찾는다.
+// 없으면 이 검색의 기록이 곧 실패한 삭제의 기록이고, 있으면 기록을 비우고 실제 삭제를 기록한다.
+func (b *BTree) lookupForDelete(k int, tr *Trace) bool {
+	if b.root == nil {
+		return false
+	}
+	if x, _ := searchWithTrace(b.root, "root", 0, k, b.linear, tr); x == nil {
+		return false
+	}
+	if tr != nil {
+		tr.Path, tr.Events = tr.Path[:0], tr.Events[:0]
+	}
+	return true
+}
+
+// remove 는 있는 것을 확인한 k 를 지우고, 루트의 키가 다 빠졌으면 높이를 줄인다.
+func (b *BTree) remove(k int, tr *Trace, cow bool) {
+	b.root.delete(k, b.t, b.linear, cow, tr, "root", 0)
+
+	if len(b.root.keys) == 0 {
+		if b.root.isLeaf {
+			b.root = nil
+		} else {
+			b.root = b.root.children[0]
+			tr.add(TraceEvent{Kind: TraceShrink, Node: "root", Keys: append([]int(nil), b.root.keys...)})
+		}
+	}
+}
+
+// delete 는 x 의 서브트리에서 k 하나를 지운다. k 는 서브트리에 있어야 하고,
+// x 는 루트이거나 키가 t 개 이상이어야 한다. cow 면 x 는 이미 복사본이다.
+func (x *BTreeNode) delete(k int, t int, linear, cow bool, tr *Trace, path string, index int) {
+	tr.visit(path, x)
+	i := x.lowerBound(k, linear, tr, path)
+
+	if i < len(x.keys) && x.keys[i] == k {
+		if x.isLeaf {
+			x.keys = slices.Delete(x.keys, i, i+1)
+			x.values = slices.Delete(x.values, i, i+1)
+			tr.add(TraceEvent{Kind: TraceDelete, Node: path, Index: i, Key: k})
+			tr.step(index, i, DirDelete)
+			return
+		}
+		tr.step(index, i, DirFound)
+		x.deleteInternal(i, t, linear, cow, tr, path)
+		return
+	}
+
+	// keys[i-1] < k < keys[i] 이므로 k 는 children[i] 에만 있을 수 있다.
+	i = x.prepareChild(i, t, cow, tr, path)
+	tr.step(index, i, DirDown)
+	x.children[i].delete(k, t, linear, cow, tr, tr.child(path, i), i)
+}
+
+// deleteInternal 은 내부 노드 x 의 keys[i] 를 지운다.
+func (x *BTreeNode) deleteInternal(i int, t int, linear, cow bool, tr *Trace, path string) {
+	k := x.keys[i]
+	switch {
+	case len(x.children[i].keys) >= t:
+		if cow {
+			x.children[i] = x.children[i].clone(t)
+		}
+		pred, value := x.children[i].deleteMax(t, cow, tr, tr.child(path, i), i)
+		x.keys[i], x.values[i] = pred, value
+		tr.add(TraceEvent{Kind: TraceReplace, Node: path, Index: i, Key: pred, Result: -1})
+	case len(x.children[i+1].keys) >= t:
+		if cow {
+			x.children[i+1] = x.children[i+1].clone(t)
+		}
+		succ, value := x.children[i+1].deleteMin(t, cow, tr, tr.child(path, i+1), i+1)
+		x.keys[i], x.values[i] = succ, value
+		tr.add(TraceEvent{Kind: TraceReplace, Node: path, Index: i, Key: succ, Result: 1})
+	default:
+		if cow {
+			x.children[i] = x.children[i].clone(t)
+		}
+		x.merge(i, tr, path)
+		x.children[i].delete(k, t, linear, cow, tr, tr.child(path, i), i)
+	}
+}
+
+// deleteMax 는 x 의 서브트리에서 가장 큰 키를 값과 함께 빼서 돌려준다. 오른쪽 끝으로만 내려가므로 비교가 없다.
+func (x *BTreeNode) deleteMax(t int, cow bool, tr *Trace, path string, index int) (int, []byte) {
+	tr.visit(path, x)
+	if x.isLeaf {
+		last := len(x.keys) - 1
+		k, value := x.keys[last], x.values[last]
+		x.keys = x.keys[:last]
+		x.values[last] = nil
+		x.values = x.values[:last]
+		tr.add(TraceEvent{Kind: TraceDelete, Node: path, Index: last, Key: k})
+		tr.step(index, last, DirDelete)
+		return k, value
+	}
+	i := x.prepareChild(len(x.keys), t, cow, tr, path)
+	tr.step(index, i, DirDown)
+	return x.children[i].deleteMax(t, cow, tr, tr.child(path, i), i)
+}
+
+// deleteMin 은 x 의 서브트리에서 가장 작은 키를 값과 함께 빼서 돌려준다.
+func (x *BTreeNode) deleteMin(t int, cow bool, tr *Trace, path string, index int) (int, []byte) {
+	tr.visit(path, x)
+	if x.isLeaf {
+		k, value := x.keys[0], x.values[0]
+		x.keys = slices.Delete(x.keys, 0, 1)
+		x.values = slices.Delete(x.values, 0, 1)
+		tr.add(TraceEvent{Kind: TraceDelete, Node: path, Index: 0, Key: k})
+		tr.step(index, 0, DirDelete)
+		return k, value
+	}
+	x.prepareChild(0, t, cow, tr, path)
+	tr.step(index, 0, DirDown)
+	return x.children[0].deleteMin(t, cow, tr, tr.child(path, 0), 0)
+}
+
+// prepareChild 는 children[i] 로 내려가기 전에 키가 t 개 이상이 되게 채우고, 내려갈 자식 번호를 돌려준다.
+// 왼쪽 형제와 합치면 i-1 이 된다. cow 면 돌려준 자식과 고친 형제는 복사본이다.
+func (x *BTreeNode) prepareChild(i int, t int, cow bool, tr *Trace, path string) int {
+	if cow {
+		x.children[i] = x.children[i].clone(t)
+	}
+	if len(x.children[i].keys) >= t {
+		return i
+	}
+
+	hasLeft, hasRight := i > 0, i < len(x.keys)
+	switch {
+	case hasLeft && len(x.children[i-1].keys) >= t:
+		if cow {
+			x.children[i-1] = x.children[i-1].clone(t)
+		}
+		x.borrowFromLeft(i, tr, path)
+	case hasRight && len(x.children[i+1].keys) >= t:
+		if cow {
+			x.children[i+1] = x.children[i+1].clone(t)
+		}
+		x.borrowFromRight(i, tr, path)
+	case hasRight:
+		x.merge(i, tr, path)
+	default:
+		if cow {
+			x.children[i-1] = x.children[i-1].clone(t)
+		}
+		x.merge(i-1, tr, path)
+		i--
+	}
+	return i
+}
+
+// borrowFromLeft 는 keys[i-1] 을 children[i] 의 맨 앞으로 내리고 왼쪽 형제의 마지막 키를 그 자리로 올린다.
+// 왼쪽 형제의 마지막 자식은 children[i] 의 첫 자식이 된다.
+func (x *BTreeNode) borrowFromLeft(i int, tr *Trace, path string) {
+	c, left := x.children[i], x.children[i-1]
+	last := len(left.keys) - 1
+
+	c.keys = slices.Insert(c.keys, 0, x.keys[i-1])
+	c.values = slices.Insert(c.values, 0, x.values[i-1])
+	x.keys[i-1], x.values[i-1] = left.keys[last], left.values[last]
+	left.keys = left.keys[:last]
+	left.values[last] = nil
+	left.values = left.values[:last]
+	if !c.isLeaf {
+		c.children = slices.Insert(c.children, 0, left.children[last+1])
+		left.children[last+1] = nil
+		left.children = left.children[:last+1]
+	}
+	tr.add(TraceEvent{Kind: TraceBorrow, Node: path, Index: i, Key: x.keys[i-1], Result: -1})
+}
+
+// borrowFromRight 는 keys[i] 를 children[i] 의 맨 뒤로 내리고 오른쪽 형제의 첫 키를 그 자리로 올린다.
+func (x *BTreeNode) borrowFromRight(i int, tr *Trace, path string) {
+	c, right := x.children[i], x.children[i+1]
+
+	c.keys = append(c.keys, x.keys[i])
+	c.values = append(c.values, x.values[i])
+	x.keys[i], x.values[i] = right.keys[0], right.values[0]
+	right.keys = slices.Delete(right.keys, 0, 1)
+	right.values = slices.Delete(right.values, 0, 1)
+	if !c.isLeaf {
+		c.children = append(c.children, right.children[0])
+		right.children = slices.Delete(right.children, 0, 1)
+	}
+	tr.add(TraceEvent{Kind: TraceBorrow, Node: path, Index: i, Key: x.keys[i], Result: 1})
+}
+
+// merge 는 children[i], keys[i], children[i+1] 을 children[i] 하나로 합친다. 합친 노드의 키는 2t-1 개다.
+// children[i+1] 은 고치지 않고 x 에서 떼어 내기만 하므로 cow 여도 복사할 필요가 없다.
+func (x *BTreeNode) merge(i int, tr *Trace, path string) {
+	y, z := x.children[i], x.children[i+1]
+	sep := x.keys[i]
+
+	y.keys = append(y.keys, sep)
+	y.keys = append(y.keys, z.keys...)
+	y.values = append(y.values, x.values[i])
+	y.values = append(y.values, z.values...)
+	if !y.isLeaf {
+		y.children = append(y.children, z.children...)
+	}
+	x.keys = slices.Delete(x.keys, i, i+1)
+	x.values = slices.Delete(x.values, i, i+1)
+	x.children = slices.Delete(x.children, i+1, i+2)
+	tr.add(TraceEvent{Kind: TraceMerge, Node: path, Index: i, Key: sep})
+}
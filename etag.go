@@ -0,0 +1,120 @@
+package btree
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"encoding/json"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// ==================================
+// 상태 응답의 ETag 와 gzip
+// ==================================
+// 화면을 주기적으로 새로 고치는 클라이언트는 트리가 그대로여도 같은 (수 MB 일 수도 있는) 상태를 계속 받아 간다.
+// - 내보낸 트리는 바뀌지 않으므로 (트리, depth, values) 가 같으면 본문도 같다. 마지막으로 만든 본문 하나를 인코딩한 채로 들고 있는다.
+// - ETag 는 본문의 FNV-64a 해시. If-None-Match 가 맞으면 본문 없이 304 를 돌려준다.
+// - Accept-Encoding 에 gzip 이 있고 본문이 gzipMinSize 이상이면 미리 압축해 둔 본문을 보낸다.
+//   압축 여부만 다른 같은 표현이므로 ETag 는 약한(W/) 것 하나를 함께 쓴다.
+
+// 이보다 작은 본문은 압축해도 헤더 값 만큼도 줄지 않는다.
+const gzipMinSize = 1 << 10
+
+// encodedState 는 tree 를 view 대로 그린 /api/state 본문
+type encodedState struct {
+	tree    *BTree
+	view    stateView
+	etag    string
+	body    []byte
+	gzipped []byte // 본문이 gzipMinSize 보다 작으면 nil
+}
+
+var lastState atomic.Pointer[encodedState]
+
+// encodeState 는 tree 의 상태 본문을 돌려준다. 바로 전에 같은 트리, 같은 view 로 만든 것이 있으면 그대로 쓴다.
+func encodeState(tree *BTree, view stateView) (*encodedState, error) {
+	if last := lastState.Load(); last != nil && last.tree == tree && last.view == view {
+		return last, nil
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(snapshotState(tree, view)); err != nil {
+		return nil, err
+	}
+	st := &encodedState{tree: tree, view: view, body: buf.Bytes()}
+	h := fnv.New64a()
+	h.Write(st.body)
+	st.etag = `W/"` + hex.EncodeToString(h.Sum(nil)) + `"`
+
+	if len(st.body) >= gzipMinSize {
+		var zbuf bytes.Buffer
+		zw := gzip.NewWriter(&zbuf)
+		if _, err := zw.Write(st.body); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		st.gzipped = zbuf.Bytes()
+	}
+	lastState.Store(st)
+	return st, nil
+}
+
+// write 는 r 의 If-None-Match / Accept-Encoding 에 맞춰 304, gzip 본문, 그냥 본문 중 하나로 응답한다.
+func (st *encodedState) write(w http.ResponseWriter, r *http.Request) {
+	h := w.Header()
+	h.Set("ETag", st.etag)
+	h.Set("Cache-Control", "no-cache")
+	h.Add("Vary", "Accept-Encoding")
+	if etagMatches(r.Header.Get("If-None-Match"), st.etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	h.Set("Content-Type", "application/json; charset=utf-8")
+	body := st.body
+	if st.gzipped != nil && acceptsGzip(r.Header.Get("Accept-Encoding")) {
+		h.Set("Content-Encoding", "gzip")
+		body = st.gzipped
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
+
+// etagMatches 는 If-None-Match 값 (쉼표로 나눈 목록이나 *) 에 etag 가 있는지 약한 비교로 본다.
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	want := strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptsGzip 은 Accept-Encoding 에 gzip 이 있고 q=0 으로 막혀 있지 않은지 본다.
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(name), "gzip") {
+			continue
+		}
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if q, err := strconv.ParseFloat(value, 64); key == "q" && err == nil && q == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
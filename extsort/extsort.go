@@ -0,0 +1,392 @@
+// Package extsort 는 메모리에 다 들어가지 않는 레코드를 정렬하는 외부 병합 정렬(external merge sort)이다.
+//
+//   - run 생성: 레코드를 MemoryRecords 개씩 모아 메모리에서 정렬한 뒤 Pager 파일에 run 으로 쓴다.
+//   - 병합: run 을 FanIn 개씩 k-way 병합한다. run 이 FanIn 개보다 많으면 병합 결과를 다시 run 으로 쓰고 (pass) 반복한다.
+//   - 마지막 pass 는 파일에 쓰지 않고 바로 호출자에게 넘긴다.
+//
+// 정렬된 스트림은 B-Tree 를 아래에서부터 채우는 bulk load 의 입력이 된다.
+// 같은 키는 모두 남기고 Add 한 순서를 지킨다 (stable).
+//
+// pass 마다 새 scratch 파일 하나에 run 들을 이어 쓰고, pass 가 끝나면 이전 파일을 지운다.
+//
+//	run 페이지 : n(2) | { key(8) | value(8) } * n
+package extsort
+
+import (
+	"cmp"
+	"container/heap"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/tmdgusya/btree/chapter01/page"
+	"github.com/tmdgusya/btree/codec"
+	"github.com/tmdgusya/btree/iometrics"
+)
+
+var Endian = codec.Endian
+
+var ErrClosed = errors.New("extsort: sorter already sorted or closed")
+
+const (
+	pageHeaderSize = 2
+	recordSize     = 8 + 8
+
+	defaultMemoryRecords = 64 * 1024
+	defaultFanIn         = 16
+)
+
+// Record 는 정렬할 레코드. Key 로만 정렬한다.
+type Record struct {
+	Key   uint64
+	Value uint64
+}
+
+// MemoryRecords 는 run 하나에 담을 레코드 수 (= 메모리에 한 번에 올리는 양). 0 이면 64K.
+// FanIn 은 한 번에 병합하는 run 수. 병합 중에는 run 마다 페이지 하나씩 버퍼를 든다. 0 이면 16.
+// PageSize 는 scratch 파일의 페이지 크기 (0 이면 Pager 기본값). Dir 은 scratch 파일을 둘 곳 ("" 이면 os.TempDir).
+type Options struct {
+	MemoryRecords int
+	FanIn         int
+	PageSize      int
+	Dir           string
+}
+
+// Stats 는 정렬 한 번의 통계. IO 는 모든 scratch 파일의 I/O 를 더한 값이다.
+// Passes 는 run 을 다시 쓴 병합 pass 수 (마지막 병합은 세지 않는다). 메모리 안에서 끝나면 Runs 와 함께 0 이다.
+type Stats struct {
+	Records int64
+	Runs    int
+	Passes  int
+	IO      iometrics.IOMetrics
+}
+
+// run 은 scratch 파일 안에서 이어진 페이지 구간
+type run struct {
+	first int64
+	pages int64
+}
+
+// Sorter 는 Add 로 레코드를 받고 Sort 로 정렬된 순서대로 돌려준다. 한 번만 쓸 수 있다.
+type Sorter struct {
+	opts  Options
+	buf   []Record
+	stats Stats
+
+	// 지금 pass 의 scratch 파일
+	pager    *page.Pager
+	path     string
+	nextPage int64
+	runs     []run
+	pass     int
+
+	retired iometrics.IOMetrics // 닫은 scratch 파일들의 I/O
+	done    bool
+}
+
+func New(opts Options) *Sorter {
+	if opts.MemoryRecords <= 0 {
+		opts.MemoryRecords = defaultMemoryRecords
+	}
+	if opts.FanIn < 2 {
+		opts.FanIn = defaultFanIn
+	}
+	if opts.Dir == "" {
+		opts.Dir = os.TempDir()
+	}
+	return &Sorter{opts: opts}
+}
+
+// Add 는 레코드 하나를 넣는다. 버퍼가 차면 정렬해서 run 으로 내려쓴다.
+func (s *Sorter) Add(r Record) error {
+	if s.done {
+		return ErrClosed
+	}
+	s.buf = append(s.buf, r)
+	s.stats.Records++
+	if len(s.buf) >= s.opts.MemoryRecords {
+		return s.spill()
+	}
+	return nil
+}
+
+// spill 은 버퍼를 정렬해서 지금 scratch 파일 끝에 run 하나로 쓴다.
+func (s *Sorter) spill() error {
+	if len(s.buf) == 0 {
+		return nil
+	}
+	if s.pager == nil {
+		if err := s.openScratch(); err != nil {
+			return err
+		}
+	}
+	sortRecords(s.buf)
+	w := s.newRunWriter()
+	for _, r := range s.buf {
+		if err := w.add(r); err != nil {
+			return err
+		}
+	}
+	if err := w.finish(); err != nil {
+		return err
+	}
+	s.buf = s.buf[:0]
+	s.stats.Runs++
+	return nil
+}
+
+// sortRecords 는 키 순으로 정렬한다. 같은 키는 들어온 순서를 지킨다.
+func sortRecords(rs []Record) {
+	slices.SortStableFunc(rs, func(a, b Record) int { return cmp.Compare(a.Key, b.Key) })
+}
+
+func (s *Sorter) openScratch() error {
+	path := filepath.Join(s.opts.Dir, fmt.Sprintf("extsort-%d-%d.tmp", os.Getpid(), s.pass))
+	os.Remove(path) // 지난 실행이 남긴 파일이면 새로 만든다
+	pager, err := page.OpenPager(path, page.PagerOptions{PageSize: s.opts.PageSize})
+	if err != nil {
+		return err
+	}
+	s.pager, s.path, s.nextPage, s.runs = pager, path, 1, nil
+	return nil
+}
+
+// closeScratch 는 scratch 파일을 닫고 지운다. I/O 는 retired 로 옮긴다.
+func (s *Sorter) closeScratch(pager *page.Pager, path string) error {
+	s.retired = s.retired.Add(pager.Metrics())
+	err := pager.Close()
+	if rmErr := os.Remove(path); err == nil {
+		err = rmErr
+	}
+	return err
+}
+
+// Sort 는 남은 레코드까지 정렬해서 키 오름차순으로 emit 을 부른다. emit 이 에러를 돌려주면 거기서 멈춘다.
+// 끝나면 scratch 파일을 모두 지운다. Sort 뒤에는 Add 할 수 없다.
+func (s *Sorter) Sort(emit func(Record) error) error {
+	if s.done {
+		return ErrClosed
+	}
+	s.done = true
+	defer s.Close()
+
+	// run 을 하나도 쓰지 않았다면 디스크를 건드리지 않고 메모리에서 끝낸다.
+	if s.pager == nil {
+		sortRecords(s.buf)
+		for _, r := range s.buf {
+			if err := emit(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := s.spill(); err != nil {
+		return err
+	}
+
+	// run 이 FanIn 개 이하가 될 때까지 FanIn 개씩 병합해서 다음 파일에 다시 쓴다.
+	for len(s.runs) > s.opts.FanIn {
+		if err := s.mergePass(); err != nil {
+			return err
+		}
+	}
+	return mergeRuns(s.pager, s.runs, emit)
+}
+
+// mergePass 는 지금 파일의 run 들을 FanIn 개씩 병합해서 새 파일에 쓰고, 지금 파일은 지운다.
+func (s *Sorter) mergePass() error {
+	src, srcPath, runs := s.pager, s.path, s.runs
+	s.pass++
+	if err := s.openScratch(); err != nil {
+		return err
+	}
+	for i := 0; i < len(runs); i += s.opts.FanIn {
+		w := s.newRunWriter()
+		err := mergeRuns(src, runs[i:min(i+s.opts.FanIn, len(runs))], w.add)
+		if err == nil {
+			err = w.finish()
+		}
+		if err != nil {
+			s.closeScratch(src, srcPath)
+			return err
+		}
+	}
+	s.stats.Passes++
+	return s.closeScratch(src, srcPath)
+}
+
+// Stats 는 지금까지의 통계. Sort 가 끝난 뒤에 부르면 정렬 전체의 I/O 가 나온다.
+func (s *Sorter) Stats() Stats {
+	st := s.stats
+	st.IO = s.retired
+	if s.pager != nil {
+		st.IO = st.IO.Add(s.pager.Metrics())
+	}
+	return st
+}
+
+// Close 는 남은 scratch 파일을 지운다. Sort 가 부르므로 Sort 를 하지 않고 버릴 때만 부르면 된다.
+func (s *Sorter) Close() error {
+	s.done = true
+	s.buf = nil
+	if s.pager == nil {
+		return nil
+	}
+	pager := s.pager
+	s.pager = nil
+	return s.closeScratch(pager, s.path)
+}
+
+// ==================================
+// run 쓰기 / 읽기
+// ==================================
+
+func recordsPerPage(pageSize int) int {
+	return (pageSize - pageHeaderSize) / recordSize
+}
+
+// runWriter 는 레코드를 페이지 단위로 모아 scratch 파일 끝에 이어 쓴다.
+type runWriter struct {
+	s     *Sorter
+	first int64
+	data  []byte
+	n     int
+}
+
+func (s *Sorter) newRunWriter() *runWriter {
+	return &runWriter{s: s, first: s.nextPage, data: make([]byte, s.pager.PageSize())}
+}
+
+func (w *runWriter) add(r Record) error {
+	off := pageHeaderSize + w.n*recordSize
+	putRecord(w.data[off:off+recordSize], r)
+	w.n++
+	if w.n == recordsPerPage(len(w.data)) {
+		return w.flush()
+	}
+	return nil
+}
+
+func (w *runWriter) flush() error {
+	if w.n == 0 {
+		return nil
+	}
+	ph := codec.NewWriter(w.data[:pageHeaderSize])
+	ph.Uint16(uint16(w.n))
+	if err := w.s.pager.WritePage(&page.Page{Id: int(w.s.nextPage), Data: w.data}); err != nil {
+		return err
+	}
+	w.s.nextPage++
+	w.n = 0
+	return nil
+}
+
+// finish 는 마지막 페이지를 쓰고 run 을 목록에 올린다.
+func (w *runWriter) finish() error {
+	if err := w.flush(); err != nil {
+		return err
+	}
+	w.s.runs = append(w.s.runs, run{first: w.first, pages: w.s.nextPage - w.first})
+	return nil
+}
+
+// runReader 는 run 을 한 페이지씩 읽는다.
+type runReader struct {
+	pager *page.Pager
+	run   run
+	next  int64 // 다음에 읽을 페이지 (run 안에서의 순번)
+	data  []byte
+	i, n  int
+	cur   Record
+}
+
+// advance 는 다음 레코드로 간다. run 이 끝나면 false.
+func (r *runReader) advance() (bool, error) {
+	for r.i == r.n {
+		if r.next == r.run.pages {
+			return false, nil
+		}
+		pg, err := r.pager.ReadPage(r.run.first + r.next)
+		if err != nil {
+			return false, err
+		}
+		r.next++
+		ph := codec.NewReader(pg.Data[:pageHeaderSize])
+		r.data, r.i, r.n = pg.Data, 0, int(ph.Uint16())
+		if r.n > recordsPerPage(len(r.data)) {
+			return false, fmt.Errorf("extsort: corrupt run page %d", pg.Id)
+		}
+	}
+	off := pageHeaderSize + r.i*recordSize
+	r.cur = parseRecord(r.data[off : off+recordSize])
+	r.i++
+	return true, nil
+}
+
+func putRecord(buf []byte, r Record) {
+	w := codec.NewWriter(buf)
+	w.Uint64(r.Key)
+	w.Uint64(r.Value)
+}
+
+func parseRecord(buf []byte) Record {
+	r := codec.NewReader(buf)
+	return Record{Key: r.Uint64(), Value: r.Uint64()}
+}
+
+// ==================================
+// k-way 병합
+// ==================================
+// run 마다 맨 앞 레코드를 min-heap 에 올려 두고, 가장 작은 것을 꺼낼 때마다 그 run 에서 하나를 채운다.
+// 키가 같으면 앞쪽 run (먼저 Add 된 쪽) 이 먼저 나온다.
+
+type mergeHeap []*runReader
+
+func (h mergeHeap) Len() int { return len(h) }
+func (h mergeHeap) Less(i, j int) bool {
+	if h[i].cur.Key != h[j].cur.Key {
+		return h[i].cur.Key < h[j].cur.Key
+	}
+	return h[i].run.first < h[j].run.first
+}
+func (h mergeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *mergeHeap) Push(x any)   { *h = append(*h, x.(*runReader)) }
+func (h *mergeHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+func mergeRuns(pager *page.Pager, runs []run, emit func(Record) error) error {
+	h := make(mergeHeap, 0, len(runs))
+	for _, rn := range runs {
+		r := &runReader{pager: pager, run: rn}
+		ok, err := r.advance()
+		if err != nil {
+			return err
+		}
+		if ok {
+			h = append(h, r)
+		}
+	}
+	heap.Init(&h)
+	for h.Len() > 0 {
+		r := h[0]
+		if err := emit(r.cur); err != nil {
+			return err
+		}
+		ok, err := r.advance()
+		if err != nil {
+			return err
+		}
+		if ok {
+			heap.Fix(&h, 0)
+		} else {
+			heap.Pop(&h)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+package btree
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/tmdgusya/btree/chapter02/linkedlist"
+	pagedlist "github.com/tmdgusya/btree/chapter02/paged_linked_list"
+)
+
+// ==================================
+// 골든 파일: 디스크 포맷 회귀 검사
+// ==================================
+// 정해진 연산으로 각 레이아웃의 파일을 만들어 testdata/golden 의 골든 파일과 바이트 단위로 비교한다.
+// - 골든 파일 이름은 <layout>-v<헤더 버전>.db. 포맷을 바꾸면 헤더 버전을 올리고 -update 로 새 파일을 추가한다.
+//
+//	go test -run TestGolden -update .
+//
+// - 예전 버전의 골든 파일은 지우지 않는다. 사용자 디스크에 남아 있는 파일이므로 지금 코드로 그대로 읽혀야 한다.
+// - 지금 코드가 아직 쓸 수 있는 예전 포맷(offset version 1)은 그 포맷으로도 만들어서 비교한다.
+
+var update = flag.Bool("update", false, "rewrite testdata/golden with the files the current code writes")
+
+const goldenDir = "testdata/golden"
+
+// 페이지 두 장에 걸치도록 작은 페이지 크기를 쓴다.
+const goldenPageSize = 512
+
+type goldenOp struct {
+	kind  byte // 'a' append, 'p' prepend, 'd' delete
+	value uint32
+}
+
+// goldenOps 는 골든 파일을 만드는 연산 순서. 프리 리스트 재사용과 head / tail 삭제가 모두 들어가게 골랐다.
+func goldenOps() []goldenOp {
+	var ops []goldenOp
+	for v := uint32(1); v <= 40; v++ {
+		ops = append(ops, goldenOp{'a', v})
+	}
+	return append(ops,
+		goldenOp{'p', 100}, goldenOp{'p', 101},
+		goldenOp{'d', 5}, goldenOp{'d', 17}, goldenOp{'d', 40}, goldenOp{'d', 101},
+		goldenOp{'a', 200},
+	)
+}
+
+// 같은 연산을 슬라이스에 적용한 결과. 골든 파일을 읽은 값과 비교한다.
+func goldenValues() []uint32 {
+	var values []uint32
+	for _, op := range goldenOps() {
+		switch op.kind {
+		case 'a':
+			values = append(values, op.value)
+		case 'p':
+			values = append([]uint32{op.value}, values...)
+		case 'd':
+			if i := slices.Index(values, op.value); i >= 0 {
+				values = slices.Delete(values, i, i+1)
+			}
+		}
+	}
+	return values
+}
+
+// 두 리스트의 연산을 같은 모양으로 묶는다.
+type goldenList struct {
+	appendTail  func(v uint32) error
+	prependHead func(v uint32) error
+	deleteValue func(v uint32) (bool, error)
+	values      func() ([]uint32, error)
+	close       func() error
+}
+
+func openOffset(t *testing.T, path string, truncate bool) goldenList {
+	t.Helper()
+	s := &linkedlist.OffsetStore{}
+	h, err := s.Open(path, truncate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return goldenList{
+		appendTail:  func(v uint32) error { return s.AppendTail(h, v) },
+		prependHead: func(v uint32) error { return s.PrependHead(h, v) },
+		deleteValue: func(v uint32) (bool, error) { return s.DeleteFirstByValue(h, v) },
+		values:      func() ([]uint32, error) { return s.TraverseValues(h) },
+		close:       func() error { return s.Close(h) },
+	}
+}
+
+// openLegacyOffset 은 빈 version 1 파일을 연다. 열 때는 version 3 으로 바뀌므로
+// 빈 version 1 덤프를 Load 해서 핸들을 version 1 로 둔다. 그 뒤의 연산은 version 1 코드와 같은 바이트를 쓴다.
+func openLegacyOffset(t *testing.T, path string) goldenList {
+	t.Helper()
+	s := &linkedlist.OffsetStore{}
+	h, err := s.Open(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	empty := `{"magic":"LLST","version":1,"pageSize":4096,"headOffset":-1,"tailOffset":-1,"size":0}`
+	if err := s.Load(h, strings.NewReader(empty)); err != nil {
+		t.Fatal(err)
+	}
+	return goldenList{
+		appendTail:  func(v uint32) error { return s.AppendTail(h, v) },
+		prependHead: func(v uint32) error { return s.PrependHead(h, v) },
+		deleteValue: func(v uint32) (bool, error) { return s.DeleteFirstByValue(h, v) },
+		values:      func() ([]uint32, error) { return s.TraverseValues(h) },
+		close:       func() error { return s.Close(h) },
+	}
+}
+
+func openPaged(t *testing.T, path string, truncate bool) goldenList {
+	t.Helper()
+	s := &pagedlist.PagedStore{}
+	if truncate {
+		s.PageSize = goldenPageSize
+	}
+	h, err := s.Open(path, truncate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return goldenList{
+		appendTail:  func(v uint32) error { return s.AppendTail(h, v) },
+		prependHead: func(v uint32) error { return s.PrependHead(h, v) },
+		deleteValue: func(v uint32) (bool, error) { return s.DeleteFirstByValue(h, v) },
+		values:      func() ([]uint32, error) { return s.TraverseValues(h) },
+		close:       func() error { return s.Close(h) },
+	}
+}
+
+// createGolden 은 빈 리스트에 goldenOps 를 적용해서 path 에 파일을 만든다.
+func createGolden(t *testing.T, l goldenList) {
+	t.Helper()
+	for _, op := range goldenOps() {
+		var err error
+		switch op.kind {
+		case 'a':
+			err = l.appendTail(op.value)
+		case 'p':
+			err = l.prependHead(op.value)
+		case 'd':
+			var ok bool
+			if ok, err = l.deleteValue(op.value); err == nil && !ok {
+				err = fmt.Errorf("value %d not found", op.value)
+			}
+		}
+		if err != nil {
+			t.Fatalf("%c %d: %v", op.kind, op.value, err)
+		}
+	}
+	if err := l.close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGolden(t *testing.T) {
+	writers := []struct {
+		name string // 골든 파일 이름
+		open func(t *testing.T, path string) goldenList
+	}{
+		{"offset-v3.db", func(t *testing.T, path string) goldenList { return openOffset(t, path, true) }},
+		{"offset-v1.db", openLegacyOffset},
+		{"paged-v4.db", func(t *testing.T, path string) goldenList { return openPaged(t, path, true) }},
+	}
+	for _, w := range writers {
+		t.Run("write/"+w.name, func(t *testing.T) {
+			built := filepath.Join(t.TempDir(), w.name)
+			createGolden(t, w.open(t, built))
+			got, err := os.ReadFile(built)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// 두 포맷 모두 Magic(4) 다음 2 바이트가 헤더 버전이다.
+			layout, _, _ := strings.Cut(w.name, "-")
+			if name := fmt.Sprintf("%s-v%d.db", layout, linkedlist.Endian.Uint16(got[4:6])); name != w.name {
+				t.Fatalf("wrote %s; bump the golden file name with the header version", name)
+			}
+
+			path := filepath.Join(goldenDir, w.name)
+			if *update {
+				if err := os.MkdirAll(goldenDir, 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+				t.Logf("wrote %s (%d bytes)", path, len(got))
+				return
+			}
+			golden, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%v; run with -update if the version bump is intentional", err)
+			}
+			if !bytes.Equal(golden, got) {
+				t.Fatalf("format drift at byte %d (golden %d bytes, now %d bytes); bump the header version for intentional changes",
+					firstDiff(golden, got), len(golden), len(got))
+			}
+		})
+	}
+
+	// 예전 버전을 포함한 모든 골든 파일이 지금 코드로 같은 값을 돌려주는지 본다.
+	// 열 때 헤더를 고쳐 쓰거나 (offset version 1 은 version 3 으로 바뀐다) 할 수 있으므로 복사본으로 연다.
+	readers := map[string]func(t *testing.T, path string) goldenList{
+		"offset": func(t *testing.T, path string) goldenList { return openOffset(t, path, false) },
+		"paged":  func(t *testing.T, path string) goldenList { return openPaged(t, path, false) },
+	}
+	files, err := filepath.Glob(filepath.Join(goldenDir, "*-v*.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range files {
+		name := filepath.Base(p)
+		t.Run("read/"+name, func(t *testing.T) {
+			layout, _, _ := strings.Cut(name, "-")
+			open, ok := readers[layout]
+			if !ok {
+				t.Fatalf("unknown layout %q", layout)
+			}
+			data, err := os.ReadFile(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cp := filepath.Join(t.TempDir(), name)
+			if err := os.WriteFile(cp, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			l := open(t, cp)
+			defer l.close()
+			values, err := l.values()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := goldenValues(); !slices.Equal(values, want) {
+				t.Fatalf("read %v, want %v", values, want)
+			}
+		})
+	}
+}
+
+func firstDiff(a, b []byte) int {
+	n := min(len(a), len(b))
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
@@ -0,0 +1,121 @@
+package btree
+
+import (
+	"cmp"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tmdgusya/btree/dberr"
+)
+
+// ==================================
+// 노드 접근 히트맵
+// ==================================
+// 서버는 삽입 / 검색의 트레이스(Path)를 최근 heatmapWindow 개까지 들고 있다가,
+// GET /api/heatmap?last=N 에 그중 마지막 N 개 연산이 노드마다 몇 번 들렀는지 세어 돌려준다.
+// 메모리 트리라서 파일 I/O 가 없으므로 트레이스 대신 노드 방문을 센다. 실제 페이지 I/O 트레이스로 만든 페이지 히트맵은
+// paged 리스트 서버의 GET /api/heatmap 이다 (chapter02/paged_linked_list/heatmap.go).
+// 모든 연산이 지나는 루트는 늘 뜨겁고, 잎은 키가 몰린 곳만 뜨겁다.
+// - 노드 이름은 연산 당시의 트리 모양 기준이다. 그 뒤의 분할로 이름이 가리키는 노드가 바뀌었을 수 있다.
+// - 트리를 새로 만들면 이름이 전부 무의미해지므로 기록도 비운다.
+
+// 기억하는 최근 연산 수
+const heatmapWindow = 1024
+
+// accessLog 는 최근 연산의 방문 경로를 담는 고리 버퍼
+type accessLog struct {
+	mu    sync.Mutex
+	paths [][]string // 연산마다 방문한 노드 이름
+	next  int        // 다음에 덮어쓸 자리 (가득 찬 뒤에만 쓴다)
+}
+
+var accesses accessLog
+
+// record 는 연산 하나의 경로를 더한다. 가득 차 있으면 가장 오래된 것을 덮어쓴다.
+func (l *accessLog) record(p Path) {
+	labels := p.Labels()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.paths) < heatmapWindow {
+		l.paths = append(l.paths, labels)
+		return
+	}
+	l.paths[l.next] = labels
+	l.next = (l.next + 1) % heatmapWindow
+}
+
+func (l *accessLog) reset() {
+	l.mu.Lock()
+	l.paths, l.next = nil, 0
+	l.mu.Unlock()
+}
+
+// NodeHeat 는 노드 하나에 들른 횟수
+type NodeHeat struct {
+	Path  string `json:"path"`
+	Depth int    `json:"depth"`
+	Count int    `json:"count"`
+}
+
+// Heatmap 은 최근 Operations 개 연산의 노드별 방문 수. Nodes 는 많이 들른 순이고, Max 는 그중 가장 큰 값이다.
+type Heatmap struct {
+	Operations int        `json:"operations"`
+	Window     int        `json:"window"`
+	Max        int        `json:"max"`
+	Nodes      []NodeHeat `json:"nodes"`
+}
+
+// heatmap 은 마지막 last 개 (0 이하이거나 기록보다 많으면 전부) 연산으로 히트맵을 만든다.
+func (l *accessLog) heatmap(last int) Heatmap {
+	l.mu.Lock()
+	// 오래된 것부터 차례로: 가득 찼으면 next 부터 한 바퀴
+	ordered := append(slices.Clone(l.paths[l.next:]), l.paths[:l.next]...)
+	l.mu.Unlock()
+
+	if last > 0 && last < len(ordered) {
+		ordered = ordered[len(ordered)-last:]
+	}
+	counts := make(map[string]int)
+	for _, labels := range ordered {
+		for _, label := range labels {
+			counts[label]++
+		}
+	}
+	h := Heatmap{Operations: len(ordered), Window: heatmapWindow, Nodes: make([]NodeHeat, 0, len(counts))}
+	for label, n := range counts {
+		h.Nodes = append(h.Nodes, NodeHeat{Path: label, Depth: strings.Count(label, "-"), Count: n})
+		h.Max = max(h.Max, n)
+	}
+	slices.SortFunc(h.Nodes, func(a, b NodeHeat) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Path, b.Path)
+	})
+	return h
+}
+
+// handleHeatmap 은 GET /api/heatmap?last=N 으로 최근 N 개 연산의 노드 방문 수를 돌려준다 (기본은 기억하는 전부).
+func handleHeatmap(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	last := 0
+	if s := r.URL.Query().Get("last"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "last 는 1 이상의 정수여야 합니다.")
+			return
+		}
+		last = n
+	}
+	if currentTree.Load() == nil {
+		writeErr(w, dberr.ErrTreeNotInitialized)
+		return
+	}
+	respondJSON(w, http.StatusOK, accesses.heatmap(last))
+}
@@ -0,0 +1,86 @@
+package btree
+
+import "math"
+
+// ==================================
+// Equi-depth 히스토그램
+// ==================================
+// 트리를 키 순서로 한 번 돌면서 키를 개수가 거의 같은 구간(bucket)들로 나눈다.
+// 구간 폭이 아니라 개수를 맞추므로 키가 몰린 곳은 구간이 좁고 듬성한 곳은 넓다.
+// 옵티마이저가 "lo <= k <= hi 인 행이 몇 개쯤인가" (selectivity) 를 추정할 때 쓰는 통계와 같은 모양이다.
+
+// HistogramBucket 은 Lo <= k <= Hi 인 키 Count 개 (서로 다른 값은 Distinct 개)
+type HistogramBucket struct {
+	Lo       int `json:"lo"`
+	Hi       int `json:"hi"`
+	Count    int `json:"count"`
+	Distinct int `json:"distinct"`
+}
+
+type Histogram struct {
+	Total   int               `json:"total"`
+	Buckets []HistogramBucket `json:"buckets"`
+}
+
+// Histogram 은 저장된 키를 최대 buckets 개의 equi-depth 구간으로 나눈다.
+// 같은 키는 한 구간에 모으므로 구간 크기가 조금씩 다를 수 있고, 키가 buckets 개보다 적으면 구간도 그만큼 적다.
+func (b *BTree) Histogram(buckets int) Histogram {
+	var h Histogram
+	if buckets < 1 {
+		return h
+	}
+	b.Range(math.MinInt, math.MaxInt, func(int) bool {
+		h.Total++
+		return true
+	})
+	if h.Total == 0 {
+		return h
+	}
+
+	// i 번째 구간은 (i+1)*Total/buckets 번째 키까지 담는다.
+	var cur *HistogramBucket
+	seen := 0
+	b.Range(math.MinInt, math.MaxInt, func(k int) bool {
+		switch {
+		case cur != nil && k == cur.Hi:
+			cur.Count++
+		case cur == nil || seen >= (len(h.Buckets))*h.Total/buckets:
+			h.Buckets = append(h.Buckets, HistogramBucket{Lo: k, Hi: k, Count: 1, Distinct: 1})
+			cur = &h.Buckets[len(h.Buckets)-1]
+		default:
+			cur.Hi = k
+			cur.Count++
+			cur.Distinct++
+		}
+		seen++
+		return true
+	})
+	return h
+}
+
+// EstimateRange 는 lo <= k <= hi 인 키 수를 히스토그램만 보고 추정한다.
+// 구간 안에서는 서로 다른 값이 Lo ~ Hi 에 고르게 퍼져 있다고 보고 겹치는 비율만큼 센다.
+func (h Histogram) EstimateRange(lo, hi int) float64 {
+	var n float64
+	for _, bk := range h.Buckets {
+		if hi < bk.Lo || lo > bk.Hi {
+			continue
+		}
+		if lo <= bk.Lo && bk.Hi <= hi {
+			n += float64(bk.Count)
+			continue
+		}
+		from, to := max(lo, bk.Lo), min(hi, bk.Hi)
+		width := float64(bk.Hi) - float64(bk.Lo) + 1
+		n += float64(bk.Count) * (float64(to) - float64(from) + 1) / width
+	}
+	return n
+}
+
+// Selectivity 는 EstimateRange 를 전체 키 수로 나눈 비율 (0 ~ 1)
+func (h Histogram) Selectivity(lo, hi int) float64 {
+	if h.Total == 0 {
+		return 0
+	}
+	return h.EstimateRange(lo, hi) / float64(h.Total)
+}
@@ -0,0 +1,284 @@
+// Package importer 는 CSV / NDJSON 으로 된 데이터셋을 저장소나 메모리 트리에 흘려 넣는다.
+// 입력을 한 번에 메모리에 올리지 않고 한 줄씩 읽으며, BatchSize 개마다 Sink.Commit 을 불러
+// (파일 저장소라면 fsync) 중간에 멈춰도 마지막 커밋까지는 남도록 한다.
+package importer
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Record 는 입력 한 줄. 값 열이 없으면 Value 는 nil 이다.
+type Record struct {
+	Key   int64
+	Value []byte
+}
+
+// Format 은 입력 형식
+type Format int
+
+const (
+	// CSV: 한 줄에 `key` 또는 `key,value`. 첫 줄의 key 가 숫자가 아니면 헤더로 보고 건너뛴다.
+	CSV Format = iota
+	// NDJSON: 한 줄에 JSON 하나. 숫자(`5`) 또는 객체(`{"key": 5, "value": "..."}`).
+	NDJSON
+)
+
+var formatNames = map[Format]string{
+	CSV:    "csv",
+	NDJSON: "ndjson",
+}
+
+func (f Format) String() string {
+	if name, ok := formatNames[f]; ok {
+		return name
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// ParseFormat 은 플래그 값("csv", "ndjson")을 Format 으로 바꾼다. "jsonl" 도 NDJSON 으로 받는다.
+func ParseFormat(s string) (Format, error) {
+	if s == "jsonl" {
+		return NDJSON, nil
+	}
+	for f, name := range formatNames {
+		if name == s {
+			return f, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown import format %q", s)
+}
+
+// FormatFromPath 는 확장자(.csv, .ndjson, .jsonl)로 형식을 짐작한다.
+func FormatFromPath(path string) (Format, bool) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return CSV, true
+	case ".ndjson", ".jsonl":
+		return NDJSON, true
+	}
+	return 0, false
+}
+
+// Reader 는 입력을 Record 단위로 꺼낸다. 끝이면 io.EOF.
+type Reader interface {
+	Next() (Record, error)
+}
+
+// NewReader 는 format 에 맞는 Reader 를 만든다.
+func NewReader(format Format, r io.Reader) (Reader, error) {
+	switch format {
+	case CSV:
+		return NewCSVReader(r), nil
+	case NDJSON:
+		return NewNDJSONReader(r), nil
+	default:
+		return nil, fmt.Errorf("unknown import format %v", format)
+	}
+}
+
+// CSVReader 는 `key[,value]` 줄을 읽는다. 나머지 열은 무시한다.
+type CSVReader struct {
+	r     *csv.Reader
+	first bool
+}
+
+func NewCSVReader(r io.Reader) *CSVReader {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+	cr.TrimLeadingSpace = true
+	return &CSVReader{r: cr, first: true}
+}
+
+func (c *CSVReader) Next() (Record, error) {
+	for {
+		fields, err := c.r.Read()
+		if err != nil {
+			return Record{}, err
+		}
+		first := c.first
+		c.first = false
+		if len(fields) == 0 || len(fields) == 1 && fields[0] == "" {
+			continue
+		}
+
+		key, err := strconv.ParseInt(strings.TrimSpace(fields[0]), 10, 64)
+		if err != nil {
+			if first {
+				continue // 헤더 줄
+			}
+			line, _ := c.r.FieldPos(0)
+			return Record{}, fmt.Errorf("line %d: invalid key %q", line, fields[0])
+		}
+		rec := Record{Key: key}
+		if len(fields) > 1 {
+			rec.Value = []byte(fields[1])
+		}
+		return rec, nil
+	}
+}
+
+// NDJSONReader 는 한 줄에 JSON 값 하나를 읽는다.
+type NDJSONReader struct {
+	sc   *bufio.Scanner
+	line int
+}
+
+func NewNDJSONReader(r io.Reader) *NDJSONReader {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	return &NDJSONReader{sc: sc}
+}
+
+func (n *NDJSONReader) Next() (Record, error) {
+	for n.sc.Scan() {
+		n.line++
+		line := strings.TrimSpace(n.sc.Text())
+		if line == "" {
+			continue
+		}
+
+		if line[0] != '{' {
+			key, err := strconv.ParseInt(line, 10, 64)
+			if err != nil {
+				return Record{}, fmt.Errorf("line %d: invalid key %q", n.line, line)
+			}
+			return Record{Key: key}, nil
+		}
+
+		var obj struct {
+			Key   *int64          `json:"key"`
+			Value json.RawMessage `json:"value"`
+		}
+		if err := json.Unmarshal([]byte(line), &obj); err != nil {
+			return Record{}, fmt.Errorf("line %d: %w", n.line, err)
+		}
+		if obj.Key == nil {
+			return Record{}, fmt.Errorf("line %d: missing \"key\"", n.line)
+		}
+		rec := Record{Key: *obj.Key}
+		if len(obj.Value) > 0 {
+			// 문자열이면 따옴표를 벗기고, 그 밖의 JSON 은 원문 그대로 값으로 쓴다.
+			var s string
+			if err := json.Unmarshal(obj.Value, &s); err == nil {
+				rec.Value = []byte(s)
+			} else {
+				rec.Value = []byte(obj.Value)
+			}
+		}
+		return rec, nil
+	}
+	if err := n.sc.Err(); err != nil {
+		return Record{}, err
+	}
+	return Record{}, io.EOF
+}
+
+// Sink 는 레코드를 받는 쪽. 저장소 / 트리마다 얇은 어댑터를 만들어 넘긴다.
+type Sink interface {
+	Put(rec Record) error
+	// Commit 은 배치가 끝날 때마다 불린다. 파일 저장소는 여기서 Sync 한다.
+	Commit() error
+}
+
+// Progress 는 지금까지 커밋된 양
+type Progress struct {
+	Records int64
+	Batches int64
+	Elapsed time.Duration
+}
+
+// Rate 는 초당 레코드 수
+func (p Progress) Rate() float64 {
+	if p.Elapsed <= 0 {
+		return 0
+	}
+	return float64(p.Records) / p.Elapsed.Seconds()
+}
+
+// DefaultBatchSize 는 Options.BatchSize 가 0 일 때 쓰는 값
+const DefaultBatchSize = 1000
+
+type Options struct {
+	// BatchSize 개마다 Commit 한다. 0 이면 DefaultBatchSize.
+	BatchSize int
+	// OnCommit 은 Commit 이 끝날 때마다 (마지막 부분 배치 포함) 불린다. nil 이면 부르지 않는다.
+	OnCommit func(Progress)
+}
+
+// ErrInvalidBatchSize 는 BatchSize 가 음수일 때
+var ErrInvalidBatchSize = errors.New("batch size must be positive")
+
+// Run 은 r 이 끝날 때까지 읽어서 sink 에 넣는다. 돌려주는 Progress 는 마지막으로 커밋된 양이다.
+// 도중에 실패하면 그 배치는 커밋하지 않고 돌아온다.
+func Run(r Reader, sink Sink, opts Options) (Progress, error) {
+	return RunContext(context.Background(), r, sink, opts)
+}
+
+// RunContext 는 ctx 가 취소되면 레코드 사이에서 멈추는 Run. 취소되면 ctx.Err() 를 돌려주고,
+// 진행 중이던 배치는 커밋하지 않으므로 마지막 커밋까지만 남는다.
+func RunContext(ctx context.Context, r Reader, sink Sink, opts Options) (Progress, error) {
+	batch := opts.BatchSize
+	if batch == 0 {
+		batch = DefaultBatchSize
+	}
+	if batch < 0 {
+		return Progress{}, ErrInvalidBatchSize
+	}
+
+	start := time.Now()
+	var done Progress
+	pending := 0
+
+	commit := func() error {
+		if err := sink.Commit(); err != nil {
+			return err
+		}
+		done.Records += int64(pending)
+		done.Batches++
+		done.Elapsed = time.Since(start)
+		pending = 0
+		if opts.OnCommit != nil {
+			opts.OnCommit(done)
+		}
+		return nil
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return done, err
+		}
+		rec, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return done, err
+		}
+		if err := sink.Put(rec); err != nil {
+			return done, fmt.Errorf("record %d (key %d): %w", done.Records+int64(pending)+1, rec.Key, err)
+		}
+		pending++
+		if pending == batch {
+			if err := commit(); err != nil {
+				return done, err
+			}
+		}
+	}
+	if pending > 0 {
+		if err := commit(); err != nil {
+			return done, err
+		}
+	}
+	return done, nil
+}
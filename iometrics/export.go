@@ -0,0 +1,140 @@
+package iometrics
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// CSV 는 한 줄에 한 측정값이 들어가도록 납작하게 만든다.
+// 크기 분포는 열이 너무 많아지므로 JSON 에만 넣는다.
+var csvHeader = []string{
+	"reads", "writes", "seeks", "syncs", "bytes_read", "bytes_written",
+	"read_p50_ns", "read_p95_ns", "read_p99_ns",
+	"write_p50_ns", "write_p95_ns", "write_p99_ns",
+	"seek_p50_ns", "seek_p95_ns", "seek_p99_ns",
+	"cache_hits", "cache_misses",
+	"seek_distance",
+}
+
+// CSVHeader 는 CSVRecord 의 각 열 이름
+func CSVHeader() []string {
+	return append([]string(nil), csvHeader...)
+}
+
+// CSVRecord 는 CSVHeader 순서대로 값을 문자열로 돌려준다. 다른 열과 이어 붙여서 쓰기 좋게 한 줄만 만든다.
+func (m IOMetrics) CSVRecord() []string {
+	record := []string{
+		strconv.FormatInt(m.Reads, 10),
+		strconv.FormatInt(m.Writes, 10),
+		strconv.FormatInt(m.Seeks, 10),
+		strconv.FormatInt(m.Syncs, 10),
+		strconv.FormatInt(m.BytesRead, 10),
+		strconv.FormatInt(m.BytesWritten, 10),
+	}
+	for _, h := range []LatencyHistogram{m.ReadLatency, m.WriteLatency, m.SeekLatency} {
+		for _, q := range []float64{0.50, 0.95, 0.99} {
+			record = append(record, strconv.FormatInt(int64(h.Quantile(q)), 10))
+		}
+	}
+	record = append(record,
+		strconv.FormatInt(m.CacheHits, 10),
+		strconv.FormatInt(m.CacheMisses, 10),
+		strconv.FormatInt(m.SeekDistance, 10),
+	)
+	return record
+}
+
+// WriteCSV 는 헤더 한 줄과 값 한 줄을 쓴다.
+func (m IOMetrics) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	if err := cw.Write(m.CSVRecord()); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+type latencyJSON struct {
+	Count int64 `json:"count"`
+	P50   int64 `json:"p50Ns"`
+	P95   int64 `json:"p95Ns"`
+	P99   int64 `json:"p99Ns"`
+}
+
+func (h LatencyHistogram) summary() latencyJSON {
+	return latencyJSON{
+		Count: h.Count,
+		P50:   int64(h.Quantile(0.50)),
+		P95:   int64(h.Quantile(0.95)),
+		P99:   int64(h.Quantile(0.99)),
+	}
+}
+
+// 크기 분포는 비어있지 않은 버킷만 "하한" 바이트 -> 횟수 로 적는다.
+func (h SizeHistogram) buckets() map[string]int64 {
+	out := make(map[string]int64)
+	for i, count := range h {
+		if count == 0 {
+			continue
+		}
+		lower := int64(0)
+		if i > 0 {
+			lower = 1 << (i - 1)
+		}
+		out[strconv.FormatInt(lower, 10)] = count
+	}
+	return out
+}
+
+type ioMetricsJSON struct {
+	Reads        int64            `json:"reads"`
+	Writes       int64            `json:"writes"`
+	Seeks        int64            `json:"seeks"`
+	Syncs        int64            `json:"syncs"`
+	BytesRead    int64            `json:"bytesRead"`
+	BytesWritten int64            `json:"bytesWritten"`
+	ReadSizes    map[string]int64 `json:"readSizes"`
+	WriteSizes   map[string]int64 `json:"writeSizes"`
+	ReadLatency  latencyJSON      `json:"readLatency"`
+	WriteLatency latencyJSON      `json:"writeLatency"`
+	SeekLatency  latencyJSON      `json:"seekLatency"`
+	CacheHits    int64            `json:"cacheHits"`
+	CacheMisses  int64            `json:"cacheMisses"`
+	SeekDistance int64            `json:"seekDistance"`
+	AvgSeekDist  float64          `json:"avgSeekDistance"`
+	LogicalReads int64            `json:"logicalReads"`
+	HitRatio     float64          `json:"hitRatio"`
+}
+
+func (m IOMetrics) MarshalJSON() ([]byte, error) {
+	return json.Marshal(ioMetricsJSON{
+		Reads:        m.Reads,
+		Writes:       m.Writes,
+		Seeks:        m.Seeks,
+		Syncs:        m.Syncs,
+		BytesRead:    m.BytesRead,
+		BytesWritten: m.BytesWritten,
+		ReadSizes:    m.ReadSizes.buckets(),
+		WriteSizes:   m.WriteSizes.buckets(),
+		ReadLatency:  m.ReadLatency.summary(),
+		WriteLatency: m.WriteLatency.summary(),
+		SeekLatency:  m.SeekLatency.summary(),
+		CacheHits:    m.CacheHits,
+		CacheMisses:  m.CacheMisses,
+		SeekDistance: m.SeekDistance,
+		AvgSeekDist:  m.AvgSeekDistance(),
+		LogicalReads: m.LogicalReads(),
+		HitRatio:     m.HitRatio(),
+	})
+}
+
+func (m IOMetrics) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
@@ -0,0 +1,183 @@
+package iometrics
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// 크기 분포 버킷 수. 마지막 버킷은 그보다 큰 크기를 모두 담는다.
+const sizeBuckets = 22
+
+// SizeHistogram 은 호출 한 번당 크기를 2의 거듭제곱 구간으로 센다.
+// - 0번 버킷: 0 바이트
+// - i번 버킷: [2^(i-1), 2^i) 바이트 (예: 12 바이트 슬롯은 4번, 4096 바이트 페이지는 13번)
+type SizeHistogram [sizeBuckets]int64
+
+func (h *SizeHistogram) add(n int) {
+	b := bits.Len(uint(n))
+	if b >= sizeBuckets {
+		b = sizeBuckets - 1
+	}
+	atomic.AddInt64(&h[b], 1)
+}
+
+func (h *SizeHistogram) snapshot() SizeHistogram {
+	var out SizeHistogram
+	for i := range h {
+		out[i] = atomic.LoadInt64(&h[i])
+	}
+	return out
+}
+
+func (h *SizeHistogram) reset() {
+	for i := range h {
+		atomic.StoreInt64(&h[i], 0)
+	}
+}
+
+func (h SizeHistogram) diff(prev SizeHistogram) SizeHistogram {
+	for i := range h {
+		h[i] -= prev[i]
+	}
+	return h
+}
+
+func (h SizeHistogram) sum(o SizeHistogram) SizeHistogram {
+	for i := range h {
+		h[i] += o[i]
+	}
+	return h
+}
+
+// 비어있지 않은 버킷만 "하한-상한:횟수" 로 나열한다. 예: "8B-16B:3000 4KB-8KB:9"
+func (h SizeHistogram) String() string {
+	var sb strings.Builder
+	for i, count := range h {
+		if count == 0 {
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteByte(' ')
+		}
+		switch {
+		case i == 0:
+			fmt.Fprintf(&sb, "0B:%d", count)
+		case i == sizeBuckets-1:
+			fmt.Fprintf(&sb, "%s+:%d", formatBytes(1<<(i-1)), count)
+		default:
+			fmt.Fprintf(&sb, "%s-%s:%d", formatBytes(1<<(i-1)), formatBytes(1<<i), count)
+		}
+	}
+	if sb.Len() == 0 {
+		return "-"
+	}
+	return sb.String()
+}
+
+// LatencyHistogram 은 호출 한 번의 소요 시간을 로그 구간으로 센다.
+// 2의 거듭제곱 구간마다 latencySubBuckets 개로 다시 나눠서, 분위수 오차가 값의 1/16 이내가 되게 한다.
+// 원본 값을 모두 들고 있지 않아도 되므로 IOMetrics 처럼 값으로 복사하고 Diff 할 수 있다.
+type LatencyHistogram struct {
+	Count  int64
+	counts [latencyBuckets]int64
+}
+
+const latencySubBuckets = 16
+const latencySubBits = 4 // log2(latencySubBuckets)
+
+// 2^40ns (약 18분) 까지. 그보다 길면 마지막 버킷에 넣는다.
+const latencyBuckets = (40 - latencySubBits + 1) * latencySubBuckets
+
+func latencyBucket(ns int64) int {
+	if ns < latencySubBuckets {
+		if ns < 0 {
+			return 0
+		}
+		return int(ns)
+	}
+	shift := bits.Len64(uint64(ns)) - latencySubBits - 1
+	b := (shift+1)*latencySubBuckets + int(ns>>shift) - latencySubBuckets
+	if b >= latencyBuckets {
+		return latencyBuckets - 1
+	}
+	return b
+}
+
+// 버킷 b 에 들어가는 값의 범위 [lower, upper)
+func latencyBucketRange(b int) (lower, upper int64) {
+	if b < latencySubBuckets {
+		return int64(b), int64(b) + 1
+	}
+	shift := b/latencySubBuckets - 1
+	mantissa := int64(b%latencySubBuckets + latencySubBuckets)
+	return mantissa << shift, (mantissa + 1) << shift
+}
+
+func (h *LatencyHistogram) add(d time.Duration) {
+	atomic.AddInt64(&h.counts[latencyBucket(int64(d))], 1)
+	atomic.AddInt64(&h.Count, 1)
+}
+
+// Count 를 버킷보다 먼저 읽으므로, 기록 중에 찍어도 Count 가 버킷 합보다 크지 않다.
+func (h *LatencyHistogram) snapshot() LatencyHistogram {
+	var out LatencyHistogram
+	out.Count = atomic.LoadInt64(&h.Count)
+	for i := range h.counts {
+		out.counts[i] = atomic.LoadInt64(&h.counts[i])
+	}
+	return out
+}
+
+func (h *LatencyHistogram) reset() {
+	atomic.StoreInt64(&h.Count, 0)
+	for i := range h.counts {
+		atomic.StoreInt64(&h.counts[i], 0)
+	}
+}
+
+func (h LatencyHistogram) diff(prev LatencyHistogram) LatencyHistogram {
+	h.Count -= prev.Count
+	for i := range h.counts {
+		h.counts[i] -= prev.counts[i]
+	}
+	return h
+}
+
+func (h LatencyHistogram) sum(o LatencyHistogram) LatencyHistogram {
+	h.Count += o.Count
+	for i := range h.counts {
+		h.counts[i] += o.counts[i]
+	}
+	return h
+}
+
+// Quantile 은 q (0~1) 분위수를 돌려준다. 해당 버킷의 가운데 값이라서 근사치다.
+func (h LatencyHistogram) Quantile(q float64) time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	rank := int64(q*float64(h.Count-1)) + 1
+	var seen int64
+	for b, count := range h.counts {
+		seen += count
+		if seen >= rank {
+			lower, upper := latencyBucketRange(b)
+			return time.Duration((lower + upper) / 2)
+		}
+	}
+	return 0
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20 && n%(1<<20) == 0:
+		return fmt.Sprintf("%dMB", n>>20)
+	case n >= 1<<10 && n%(1<<10) == 0:
+		return fmt.Sprintf("%dKB", n>>10)
+	default:
+		return fmt.Sprintf("%dB", n)
+	}
+}
@@ -0,0 +1,45 @@
+package iometrics
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// 오래 떠 있는 데모 서버가 누적 I/O 를 웹 UI 로 보내기 위한 JSON 엔드포인트.
+// 브라우저는 이걸 주기적으로 폴링해서 값이 들어갈 때마다 그래프를 갱신한다.
+
+type sectionJSON struct {
+	Name string    `json:"name"`
+	IO   IOMetrics `json:"io"`
+}
+
+type metricsResponse struct {
+	Total    IOMetrics     `json:"total"`
+	Sections []sectionJSON `json:"sections"`
+}
+
+// Handler 는 cf 의 누적 값을 돌려주는 http.Handler.
+//
+//	GET    {"total": {...}, "sections": [{"name": ..., "io": {...}}]}
+//	DELETE Reset 한 뒤 같은 모양(전부 0)으로 돌려준다
+func Handler(cf *CountingFile) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodDelete:
+			cf.Reset()
+		default:
+			w.Header().Set("Allow", "GET, DELETE")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		resp := metricsResponse{Total: cf.Metrics(), Sections: []sectionJSON{}}
+		for _, s := range cf.Sections() {
+			resp.Sections = append(resp.Sections, sectionJSON{Name: s.Name, IO: s.IO})
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+}
@@ -0,0 +1,407 @@
+// Package iometrics 는 파일 I/O 를 세는 래퍼(CountingFile)와 측정값(IOMetrics)을 제공한다.
+// 호출 횟수, 옮긴 바이트 수, 호출 한 번당 크기 / 시간 분포를 모으고, 이름 붙인 구간별로 나눠 볼 수 있다.
+// 여러 챕터(리스트 비교 도구, Pager)가 같은 방식으로 I/O 를 비교할 수 있도록 별도 패키지로 둔다.
+package iometrics
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 호출 횟수와 함께 실제로 옮긴 바이트 수, 호출 한 번당 크기 분포를 센다.
+// 같은 Reads 라도 12 바이트 슬롯을 읽는 것과 4KB 페이지를 읽는 것은 비용이 다르기 때문이다.
+//
+// 카운터는 atomic 으로 올리므로 여러 고루틴이 같은 IOMetrics 에 기록해도 된다.
+// 기록 중인 값을 읽을 때는 필드를 직접 복사하지 말고 Snapshot 을 쓴다.
+type IOMetrics struct {
+	Reads  int64
+	Writes int64
+	Seeks  int64
+	Syncs  int64
+
+	BytesRead    int64
+	BytesWritten int64
+	ReadSizes    SizeHistogram
+	WriteSizes   SizeHistogram
+
+	// 호출 한 번에 걸린 시간 분포 (p50 / p95 / p99 용)
+	ReadLatency  LatencyHistogram
+	WriteLatency LatencyHistogram
+	SeekLatency  LatencyHistogram
+
+	// 읽기 / 쓰기가 직전 읽기 / 쓰기가 끝난 자리에서 얼마나 떨어진 곳에서 시작했는지(바이트)의 합.
+	// 디스크 헤드(또는 readahead)가 따라가야 하는 거리로, 순차 접근이면 0 에 가깝다.
+	SeekDistance int64
+
+	// 메모리 버퍼(PageBuffer 등)가 요청을 받은 횟수. 파일을 건드리지 않으므로 Reads 와 따로 센다.
+	// - CacheHits: 버퍼에 있던 페이지에서 바로 돌려준 횟수
+	// - CacheMisses: 버퍼에 없어서 파일에서 읽어온 횟수 (그 읽기는 Reads 에도 들어간다)
+	CacheHits   int64
+	CacheMisses int64
+}
+
+// LogicalReads 는 버퍼에 들어온 요청 수 (= CacheHits + CacheMisses).
+// 데이터베이스 통계의 logical read / physical read 처럼 Reads 와 나란히 놓고 본다.
+func (m IOMetrics) LogicalReads() int64 {
+	return m.CacheHits + m.CacheMisses
+}
+
+// AvgSeekDistance 는 읽기 / 쓰기 한 번당 평균 이동 거리 (바이트)
+func (m IOMetrics) AvgSeekDistance() float64 {
+	if m.Reads+m.Writes == 0 {
+		return 0
+	}
+	return float64(m.SeekDistance) / float64(m.Reads+m.Writes)
+}
+
+// HitRatio 는 버퍼 요청 중 메모리에서 처리한 비율. 요청이 없으면 0.
+func (m IOMetrics) HitRatio() float64 {
+	if m.LogicalReads() == 0 {
+		return 0
+	}
+	return float64(m.CacheHits) / float64(m.LogicalReads())
+}
+
+// File 은 CountingFile 이 감싸는 대상. *os.File 이 그대로 만족한다.
+// 스트림 방식(Read/Write/Seek)과 위치 지정 방식(ReadAt/WriteAt)을 모두 지원해서
+// 리스트 저장소와 Pager 를 같은 래퍼로 계측할 수 있다.
+type File interface {
+	io.ReadWriteSeeker
+	io.ReaderAt
+	io.WriterAt
+	io.Closer
+	Sync() error
+}
+
+// Snapshot 은 지금까지의 값을 복사해서 돌려준다. 기록이 계속되는 중에도 안전하게 부를 수 있다.
+// 두 Snapshot 의 Diff 로 한 단계(phase)의 I/O 만 떼어 볼 수 있다.
+func (m *IOMetrics) Snapshot() IOMetrics {
+	return IOMetrics{
+		Reads:  atomic.LoadInt64(&m.Reads),
+		Writes: atomic.LoadInt64(&m.Writes),
+		Seeks:  atomic.LoadInt64(&m.Seeks),
+		Syncs:  atomic.LoadInt64(&m.Syncs),
+
+		BytesRead:    atomic.LoadInt64(&m.BytesRead),
+		BytesWritten: atomic.LoadInt64(&m.BytesWritten),
+		ReadSizes:    m.ReadSizes.snapshot(),
+		WriteSizes:   m.WriteSizes.snapshot(),
+
+		ReadLatency:  m.ReadLatency.snapshot(),
+		WriteLatency: m.WriteLatency.snapshot(),
+		SeekLatency:  m.SeekLatency.snapshot(),
+
+		SeekDistance: atomic.LoadInt64(&m.SeekDistance),
+
+		CacheHits:   atomic.LoadInt64(&m.CacheHits),
+		CacheMisses: atomic.LoadInt64(&m.CacheMisses),
+	}
+}
+
+// Reset 은 모든 카운터와 분포를 0 으로 되돌린다.
+// 필드마다 따로 0 으로 만들기 때문에, 동시에 기록 중이던 호출 일부는 Reset 전후 어느 쪽에 들어갈지 정해져 있지 않다.
+func (m *IOMetrics) Reset() {
+	atomic.StoreInt64(&m.Reads, 0)
+	atomic.StoreInt64(&m.Writes, 0)
+	atomic.StoreInt64(&m.Seeks, 0)
+	atomic.StoreInt64(&m.Syncs, 0)
+
+	atomic.StoreInt64(&m.BytesRead, 0)
+	atomic.StoreInt64(&m.BytesWritten, 0)
+	m.ReadSizes.reset()
+	m.WriteSizes.reset()
+
+	m.ReadLatency.reset()
+	m.WriteLatency.reset()
+	m.SeekLatency.reset()
+
+	atomic.StoreInt64(&m.SeekDistance, 0)
+
+	atomic.StoreInt64(&m.CacheHits, 0)
+	atomic.StoreInt64(&m.CacheMisses, 0)
+}
+
+func (m *IOMetrics) addRead(n int, d time.Duration) {
+	m.ReadLatency.add(d)
+	atomic.AddInt64(&m.Reads, 1)
+	atomic.AddInt64(&m.BytesRead, int64(n))
+	m.ReadSizes.add(n)
+}
+
+func (m *IOMetrics) addWrite(n int, d time.Duration) {
+	m.WriteLatency.add(d)
+	atomic.AddInt64(&m.Writes, 1)
+	atomic.AddInt64(&m.BytesWritten, int64(n))
+	m.WriteSizes.add(n)
+}
+
+func (m *IOMetrics) addSeek(d time.Duration) {
+	m.SeekLatency.add(d)
+	atomic.AddInt64(&m.Seeks, 1)
+}
+
+func (m *IOMetrics) addSync() {
+	atomic.AddInt64(&m.Syncs, 1)
+}
+
+func (m *IOMetrics) addDistance(dist int64) {
+	atomic.AddInt64(&m.SeekDistance, dist)
+}
+
+func (m *IOMetrics) addCache(hit bool) {
+	if hit {
+		atomic.AddInt64(&m.CacheHits, 1)
+	} else {
+		atomic.AddInt64(&m.CacheMisses, 1)
+	}
+}
+
+// CountingFile 은 파일 전체의 I/O 를 io 에 모으고, Section 으로 열어둔 구간이 있으면 그 구간에도 같이 센다.
+// 카운터는 atomic, 구간 목록은 mu 로 보호하므로 여러 고루틴에서 같이 써도 된다.
+type CountingFile struct {
+	f  File
+	io IOMetrics
+
+	mu           sync.Mutex
+	sections     map[string]*IOMetrics
+	sectionOrder []string // 처음 열린 순서 (출력용)
+	open         []string // 열려 있는 구간 스택. I/O 는 가장 안쪽 구간에만 센다.
+
+	pos      int64 // Read / Write 가 시작할 오프셋. Seek 결과와 옮긴 바이트 수로 따라간다.
+	lastEnd  int64 // 직전 읽기 / 쓰기가 끝난 오프셋 (SeekDistance 용)
+	accessed bool  // 읽기 / 쓰기가 한 번이라도 있었는지. 첫 호출은 거리 0 으로 센다.
+
+	observers []observer // 호출마다 등록 순서대로 불린다 (Observe 참고)
+	nextObs   int
+}
+
+func NewCountingFile(f File) *CountingFile {
+	return &CountingFile{f: f, sections: make(map[string]*IOMetrics)}
+}
+
+// Section 은 이름 붙인 구간을 열고, 닫는 함수를 돌려준다.
+// 구간이 열려 있는 동안의 I/O 는 파일 전체 합계와 함께 가장 안쪽 구간에도 집계된다.
+// 같은 이름으로 여러 번 열면 누적된다.
+//
+//	defer cf.Section("append")()
+func (cf *CountingFile) Section(name string) (end func()) {
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
+	if _, ok := cf.sections[name]; !ok {
+		cf.sections[name] = &IOMetrics{}
+		cf.sectionOrder = append(cf.sectionOrder, name)
+	}
+	cf.open = append(cf.open, name)
+	depth := len(cf.open)
+	return func() {
+		cf.mu.Lock()
+		cf.open = cf.open[:depth-1]
+		cf.mu.Unlock()
+	}
+}
+
+// Sections 는 지금까지 구간별로 모인 I/O 를 처음 열린 순서대로 돌려준다.
+func (cf *CountingFile) Sections() []NamedMetrics {
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
+	out := make([]NamedMetrics, 0, len(cf.sectionOrder))
+	for _, name := range cf.sectionOrder {
+		out = append(out, NamedMetrics{Name: name, IO: cf.sections[name].Snapshot()})
+	}
+	return out
+}
+
+type NamedMetrics struct {
+	Name string
+	IO   IOMetrics
+}
+
+// I/O 한 번을 파일 전체와 현재 구간에 기록한다.
+func (cf *CountingFile) record(fn func(m *IOMetrics)) {
+	fn(&cf.io)
+	cf.mu.Lock()
+	var sec *IOMetrics
+	if len(cf.open) > 0 {
+		sec = cf.sections[cf.open[len(cf.open)-1]]
+	}
+	cf.mu.Unlock()
+	if sec != nil {
+		fn(sec)
+	}
+}
+
+func (cf *CountingFile) Read(p []byte) (int, error) {
+	start := time.Now()
+	n, err := cf.f.Read(p)
+	d := time.Since(start)
+	off := cf.advance(n)
+	dist := cf.distance(off, n)
+	cf.record(func(m *IOMetrics) { m.addRead(n, d); m.addDistance(dist) })
+	cf.observe(OpRead, off, n, start, d)
+	return n, err
+}
+
+func (cf *CountingFile) Write(p []byte) (int, error) {
+	start := time.Now()
+	n, err := cf.f.Write(p)
+	d := time.Since(start)
+	off := cf.advance(n)
+	dist := cf.distance(off, n)
+	cf.record(func(m *IOMetrics) { m.addWrite(n, d); m.addDistance(dist) })
+	cf.observe(OpWrite, off, n, start, d)
+	return n, err
+}
+
+// ReadAt / WriteAt 은 오프셋을 직접 받으므로 Seek 없이 Reads / Writes 로만 센다.
+func (cf *CountingFile) ReadAt(p []byte, off int64) (int, error) {
+	start := time.Now()
+	n, err := cf.f.ReadAt(p, off)
+	d := time.Since(start)
+	dist := cf.distance(off, n)
+	cf.record(func(m *IOMetrics) { m.addRead(n, d); m.addDistance(dist) })
+	cf.observe(OpRead, off, n, start, d)
+	return n, err
+}
+
+func (cf *CountingFile) WriteAt(p []byte, off int64) (int, error) {
+	start := time.Now()
+	n, err := cf.f.WriteAt(p, off)
+	d := time.Since(start)
+	dist := cf.distance(off, n)
+	cf.record(func(m *IOMetrics) { m.addWrite(n, d); m.addDistance(dist) })
+	cf.observe(OpWrite, off, n, start, d)
+	return n, err
+}
+
+func (cf *CountingFile) Seek(offset int64, whence int) (int64, error) {
+	start := time.Now()
+	pos, err := cf.f.Seek(offset, whence)
+	d := time.Since(start)
+	cf.record(func(m *IOMetrics) { m.addSeek(d) })
+	if err == nil {
+		cf.mu.Lock()
+		cf.pos = pos
+		cf.mu.Unlock()
+	}
+	cf.observe(OpSeek, pos, 0, start, d)
+	return pos, err
+}
+
+func (cf *CountingFile) Sync() error {
+	start := time.Now()
+	err := cf.f.Sync()
+	d := time.Since(start)
+	cf.record(func(m *IOMetrics) { m.addSync() })
+	cf.observe(OpSync, 0, 0, start, d)
+	return err
+}
+
+// [off, off+n) 에 접근할 때 직전 접근의 끝에서 떨어진 거리를 돌려주고 끝 위치를 갱신한다.
+func (cf *CountingFile) distance(off int64, n int) int64 {
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
+	var dist int64
+	if cf.accessed {
+		dist = off - cf.lastEnd
+		if dist < 0 {
+			dist = -dist
+		}
+	}
+	cf.accessed = true
+	cf.lastEnd = off + int64(n)
+	return dist
+}
+
+// CacheHit / CacheMiss 는 파일 위에 얹은 버퍼가 요청을 어떻게 처리했는지 기록한다.
+// 파일 I/O 와 같은 구간에 집계되므로, 구간별로 logical / physical read 를 비교할 수 있다.
+func (cf *CountingFile) CacheHit() {
+	cf.record(func(m *IOMetrics) { m.addCache(true) })
+}
+
+func (cf *CountingFile) CacheMiss() {
+	cf.record(func(m *IOMetrics) { m.addCache(false) })
+}
+
+// Truncate 는 감싼 파일이 지원하면 그대로 넘긴다. 데이터 I/O 가 아니므로 세지 않는다.
+func (cf *CountingFile) Truncate(size int64) error {
+	t, ok := cf.f.(interface{ Truncate(int64) error })
+	if !ok {
+		return errors.New("iometrics: underlying file does not support Truncate")
+	}
+	return t.Truncate(size)
+}
+
+// Unwrap 은 감싼 파일을 돌려준다. 구멍 뚫기처럼 *os.File 에만 있는 기능이 필요할 때 쓴다.
+func (cf *CountingFile) Unwrap() File {
+	return cf.f
+}
+
+func (cf *CountingFile) Close() error {
+	return cf.f.Close()
+}
+
+// Metrics 는 파일 전체 I/O 의 Snapshot
+func (cf *CountingFile) Metrics() IOMetrics {
+	return cf.io.Snapshot()
+}
+
+// Reset 은 파일 전체와 모든 구간의 값을 0 으로 되돌린다. 구간 이름과 순서는 유지한다.
+// 오래 떠 있는 프로세스에서 단계마다 새로 재고 싶을 때 쓴다.
+func (cf *CountingFile) Reset() {
+	cf.io.Reset()
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
+	for _, m := range cf.sections {
+		m.Reset()
+	}
+}
+
+func (m IOMetrics) Diff(prev IOMetrics) IOMetrics {
+	return IOMetrics{
+		Reads:  m.Reads - prev.Reads,
+		Writes: m.Writes - prev.Writes,
+		Seeks:  m.Seeks - prev.Seeks,
+		Syncs:  m.Syncs - prev.Syncs,
+
+		BytesRead:    m.BytesRead - prev.BytesRead,
+		BytesWritten: m.BytesWritten - prev.BytesWritten,
+		ReadSizes:    m.ReadSizes.diff(prev.ReadSizes),
+		WriteSizes:   m.WriteSizes.diff(prev.WriteSizes),
+
+		ReadLatency:  m.ReadLatency.diff(prev.ReadLatency),
+		WriteLatency: m.WriteLatency.diff(prev.WriteLatency),
+		SeekLatency:  m.SeekLatency.diff(prev.SeekLatency),
+
+		SeekDistance: m.SeekDistance - prev.SeekDistance,
+
+		CacheHits:   m.CacheHits - prev.CacheHits,
+		CacheMisses: m.CacheMisses - prev.CacheMisses,
+	}
+}
+
+// Add 는 두 측정값을 더한다. 파일 여러 개(LSM 의 SSTable 등)의 I/O 를 하나로 모을 때 쓴다.
+func (m IOMetrics) Add(o IOMetrics) IOMetrics {
+	return IOMetrics{
+		Reads:  m.Reads + o.Reads,
+		Writes: m.Writes + o.Writes,
+		Seeks:  m.Seeks + o.Seeks,
+		Syncs:  m.Syncs + o.Syncs,
+
+		BytesRead:    m.BytesRead + o.BytesRead,
+		BytesWritten: m.BytesWritten + o.BytesWritten,
+		ReadSizes:    m.ReadSizes.sum(o.ReadSizes),
+		WriteSizes:   m.WriteSizes.sum(o.WriteSizes),
+
+		ReadLatency:  m.ReadLatency.sum(o.ReadLatency),
+		WriteLatency: m.WriteLatency.sum(o.WriteLatency),
+		SeekLatency:  m.SeekLatency.sum(o.SeekLatency),
+
+		SeekDistance: m.SeekDistance + o.SeekDistance,
+
+		CacheHits:   m.CacheHits + o.CacheHits,
+		CacheMisses: m.CacheMisses + o.CacheMisses,
+	}
+}
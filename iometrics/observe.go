@@ -0,0 +1,100 @@
+package iometrics
+
+import "time"
+
+// Op 는 CountingFile 호출 종류
+type Op int
+
+const (
+	OpRead Op = iota
+	OpWrite
+	OpSeek
+	OpSync
+)
+
+func (op Op) String() string {
+	switch op {
+	case OpRead:
+		return "read"
+	case OpWrite:
+		return "write"
+	case OpSeek:
+		return "seek"
+	case OpSync:
+		return "sync"
+	}
+	return "unknown"
+}
+
+// Access 는 CountingFile 호출 한 번
+// - Offset: 읽기 / 쓰기가 시작한 파일 오프셋, Seek 은 옮겨간 위치. Sync 는 0.
+// - Len: 실제로 옮긴 바이트 수. Seek / Sync 는 0.
+// - Section: 호출 당시 가장 안쪽에 열려 있던 구간 이름 (없으면 "")
+type Access struct {
+	Op       Op
+	Offset   int64
+	Len      int
+	Section  string
+	Start    time.Time
+	Duration time.Duration
+}
+
+// Observe 는 호출마다 fn 을 부르도록 등록하고, 등록을 푸는 함수를 돌려준다.
+// 카운터만으로는 보이지 않는 "어디를" 건드렸는지(페이지 히트맵, 접근 순서)를 모을 때 쓴다.
+// 여러 개를 등록하면 등록한 순서대로 모두 불린다.
+// fn 은 I/O 를 한 고루틴에서 그대로 불리므로 오래 걸리는 일을 하면 측정 시간이 늘어난다.
+//
+//	defer cf.Observe(fn)()
+func (cf *CountingFile) Observe(fn func(Access)) (stop func()) {
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
+	id := cf.nextObs
+	cf.nextObs++
+	cf.observers = append(cf.observers, observer{id: id, fn: fn})
+	return func() {
+		cf.mu.Lock()
+		defer cf.mu.Unlock()
+		for i, o := range cf.observers {
+			if o.id == id {
+				cf.observers = append(cf.observers[:i:i], cf.observers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+type observer struct {
+	id int
+	fn func(Access)
+}
+
+// 스트림 방식 Read / Write 의 시작 오프셋을 돌려주고 n 만큼 앞으로 옮긴다.
+func (cf *CountingFile) advance(n int) (off int64) {
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
+	off = cf.pos
+	cf.pos += int64(n)
+	return off
+}
+
+func (cf *CountingFile) observe(op Op, off int64, n int, start time.Time, d time.Duration) {
+	cf.mu.Lock()
+	if len(cf.observers) == 0 {
+		cf.mu.Unlock()
+		return
+	}
+	fns := make([]func(Access), 0, len(cf.observers))
+	for _, o := range cf.observers {
+		fns = append(fns, o.fn)
+	}
+	var section string
+	if len(cf.open) > 0 {
+		section = cf.open[len(cf.open)-1]
+	}
+	cf.mu.Unlock()
+
+	a := Access{Op: op, Offset: off, Len: n, Section: section, Start: start, Duration: d}
+	for _, fn := range fns {
+		fn(a)
+	}
+}
@@ -0,0 +1,136 @@
+package iometrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 외부 의존성 없이 Prometheus text exposition format (0.0.4) 을 직접 쓴다.
+// 지연 분위수는 _sum 이 없어서 summary 대신 quantile 라벨이 붙은 gauge 로 내보낸다.
+
+// Sample 은 IOMetrics 밖의 값 하나 (파일 크기, 요청 수 등). 이름이 같은 Sample 은 한 묶음으로 출력한다.
+type Sample struct {
+	Name   string
+	Help   string
+	Type   string // "counter" 또는 "gauge"
+	Labels map[string]string
+	Value  float64
+}
+
+// Samples 는 m 을 prefix 가 붙은 Sample 들로 펼친다. labels 는 모든 Sample 에 붙는다 (nil 가능).
+func Samples(prefix string, m IOMetrics, labels map[string]string) []Sample {
+	with := func(extra ...string) map[string]string {
+		out := make(map[string]string, len(labels)+1)
+		for k, v := range labels {
+			out[k] = v
+		}
+		for i := 0; i+1 < len(extra); i += 2 {
+			out[extra[i]] = extra[i+1]
+		}
+		return out
+	}
+	counter := func(name, help string, v int64) Sample {
+		return Sample{Name: prefix + "_" + name, Help: help, Type: "counter", Labels: with(), Value: float64(v)}
+	}
+
+	out := []Sample{
+		counter("io_reads_total", "Read calls on the data file.", m.Reads),
+		counter("io_writes_total", "Write calls on the data file.", m.Writes),
+		counter("io_seeks_total", "Seek calls on the data file.", m.Seeks),
+		counter("io_syncs_total", "Sync calls on the data file.", m.Syncs),
+		counter("io_read_bytes_total", "Bytes read from the data file.", m.BytesRead),
+		counter("io_written_bytes_total", "Bytes written to the data file.", m.BytesWritten),
+		counter("io_seek_distance_bytes_total", "Bytes skipped between consecutive accesses.", m.SeekDistance),
+		counter("buffer_hits_total", "Page reads served by the page buffer.", m.CacheHits),
+		counter("buffer_misses_total", "Page reads that had to go to the file.", m.CacheMisses),
+		{Name: prefix + "_buffer_hit_ratio", Help: "Buffer hits / (hits + misses).", Type: "gauge", Labels: with(), Value: m.HitRatio()},
+	}
+	for _, l := range []struct {
+		op string
+		h  LatencyHistogram
+	}{{"read", m.ReadLatency}, {"write", m.WriteLatency}, {"seek", m.SeekLatency}} {
+		for _, q := range []float64{0.5, 0.95, 0.99} {
+			out = append(out, Sample{
+				Name:   prefix + "_io_latency_seconds",
+				Help:   "Approximate latency quantiles of file calls.",
+				Type:   "gauge",
+				Labels: with("op", l.op, "quantile", strconv.FormatFloat(q, 'g', -1, 64)),
+				Value:  l.h.Quantile(q).Seconds(),
+			})
+		}
+	}
+	return out
+}
+
+// WritePrometheus 는 samples 를 이름별로 묶어 (처음 나온 순서대로) HELP / TYPE 과 함께 쓴다.
+func WritePrometheus(w io.Writer, samples []Sample) error {
+	bw := bufio.NewWriter(w)
+	var order []string
+	groups := make(map[string][]Sample)
+	for _, s := range samples {
+		if _, ok := groups[s.Name]; !ok {
+			order = append(order, s.Name)
+		}
+		groups[s.Name] = append(groups[s.Name], s)
+	}
+
+	for _, name := range order {
+		group := groups[name]
+		fmt.Fprintf(bw, "# HELP %s %s\n", name, group[0].Help)
+		fmt.Fprintf(bw, "# TYPE %s %s\n", name, group[0].Type)
+		for _, s := range group {
+			fmt.Fprintf(bw, "%s%s %s\n", name, formatLabels(s.Labels), strconv.FormatFloat(s.Value, 'g', -1, 64))
+		}
+	}
+	return bw.Flush()
+}
+
+// 라벨은 이름순으로 적어서 출력이 매번 같게 한다.
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(k)
+		sb.WriteString(`="`)
+		sb.WriteString(labelEscaper.Replace(labels[k]))
+		sb.WriteByte('"')
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// PrometheusHandler 는 cf 의 누적 값(전체 + 구간별 section 라벨)과 extra() 를 /metrics 형식으로 돌려준다.
+// extra 는 요청마다 불리므로 파일 크기처럼 그때그때 바뀌는 값을 넣으면 된다 (nil 가능).
+func PrometheusHandler(prefix string, cf *CountingFile, extra func() []Sample) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		samples := Samples(prefix, cf.Metrics(), nil)
+		// 구간별 값은 이름을 나눠서 전체 값과 더해 세지 않게 한다.
+		for _, s := range cf.Sections() {
+			samples = append(samples, Samples(prefix+"_section", s.IO, map[string]string{"section": s.Name})...)
+		}
+		if extra != nil {
+			samples = append(samples, extra()...)
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = WritePrometheus(w, samples)
+	})
+}
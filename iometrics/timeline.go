@@ -0,0 +1,85 @@
+package iometrics
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+)
+
+// 타임라인 그림 크기 (칸). 호출이 더 많거나 파일이 더 크면 한 칸에 여러 호출 / 바이트를 합친다.
+const timelineCols = 800
+const timelineRows = 400
+
+// 칸 하나의 픽셀 크기
+const timelineCell = 1
+
+// RenderTimeline 은 트레이스를 접근 패턴 그림(SVG)으로 그린다.
+// - 가로축: 호출 순서 (왼쪽이 먼저), 세로축: 파일 오프셋 (위쪽이 파일 앞)
+// - 읽기는 파랑, 쓰기는 빨강, 같은 칸에 둘 다 있으면 보라. Sync 는 회색 세로줄.
+// 순차 접근은 비스듬한 선으로, 무작위 접근은 흩어진 점으로 보인다.
+func RenderTimeline(w io.Writer, entries []TraceEntry, title string) error {
+	var fileEnd int64 = 1
+	for _, e := range entries {
+		fileEnd = max(fileEnd, e.Offset+int64(e.Len))
+	}
+
+	cols := min(max(len(entries), 1), timelineCols)
+	const rows = timelineRows
+	// 칸마다 bit 0 = 읽기, bit 1 = 쓰기
+	grid := make([]uint8, cols*rows)
+	var syncs []int
+	lastSync := -1
+
+	for i, e := range entries {
+		x := i * cols / max(len(entries), 1)
+		switch e.Op {
+		case OpSync:
+			if x != lastSync {
+				syncs = append(syncs, x)
+				lastSync = x
+			}
+			continue
+		case OpRead, OpWrite:
+		default:
+			continue
+		}
+		bit := uint8(1)
+		if e.Op == OpWrite {
+			bit = 2
+		}
+		top := int(e.Offset * rows / fileEnd)
+		bottom := int((e.Offset + int64(max(e.Len, 1)) - 1) * rows / fileEnd)
+		for y := top; y <= bottom && y < rows; y++ {
+			grid[y*cols+x] |= bit
+		}
+	}
+
+	const margin = 50
+	width := cols*timelineCell + margin + 10
+	height := rows*timelineCell + margin
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="11">`+"\n", width, height)
+	fmt.Fprintf(bw, "<title>%s</title>\n", html.EscapeString(title))
+	fmt.Fprintf(bw, `<text x="%d" y="14">%s (%d calls, %d bytes)</text>`+"\n", margin, html.EscapeString(title), len(entries), fileEnd)
+	fmt.Fprintf(bw, `<g transform="translate(%d,20)">`+"\n", margin)
+	fmt.Fprintf(bw, `<rect width="%d" height="%d" fill="#fafafa" stroke="#ccc"/>`+"\n", cols*timelineCell, rows*timelineCell)
+	for _, x := range syncs {
+		fmt.Fprintf(bw, `<line x1="%d" y1="0" x2="%d" y2="%d" stroke="#bbb"/>`+"\n", x*timelineCell, x*timelineCell, rows*timelineCell)
+	}
+	colors := [4]string{"", "#2563eb", "#dc2626", "#7c3aed"}
+	for y := 0; y < rows; y++ {
+		for x := 0; x < cols; x++ {
+			if c := grid[y*cols+x]; c != 0 {
+				fmt.Fprintf(bw, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n",
+					x*timelineCell, y*timelineCell, timelineCell, timelineCell, colors[c])
+			}
+		}
+	}
+	fmt.Fprintln(bw, "</g>")
+	fmt.Fprintf(bw, `<text x="4" y="30">0</text><text x="4" y="%d">%d</text>`+"\n", rows*timelineCell+20, fileEnd)
+	fmt.Fprintf(bw, `<text x="%d" y="%d">call order →</text>`+"\n", margin, height-6)
+	fmt.Fprintln(bw, "</svg>")
+	return bw.Flush()
+}
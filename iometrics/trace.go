@@ -0,0 +1,138 @@
+package iometrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 트레이스 파일은 한 줄에 호출 하나, 탭으로 나눈 텍스트다. '#' 으로 시작하는 줄은 주석.
+//
+//	elapsed_ns  op  offset  len  duration_ns  section
+//
+// elapsed_ns 는 트레이스를 시작한 뒤 호출이 시작되기까지 걸린 시간, section 이 없으면 "-".
+const traceHeader = "# elapsed_ns\top\toffset\tlen\tduration_ns\tsection\n"
+
+// TraceEntry 는 트레이스 파일의 한 줄
+type TraceEntry struct {
+	Elapsed  time.Duration
+	Op       Op
+	Offset   int64
+	Len      int
+	Duration time.Duration
+	Section  string
+}
+
+// TraceWriter 는 Access 를 트레이스 형식으로 w 에 이어 쓴다. 여러 고루틴에서 불러도 된다.
+// 쓰다가 난 첫 에러는 기억해 뒀다가 Flush 에서 돌려준다.
+type TraceWriter struct {
+	mu    sync.Mutex
+	w     *bufio.Writer
+	start time.Time
+	err   error
+}
+
+func NewTraceWriter(w io.Writer) *TraceWriter {
+	tw := &TraceWriter{w: bufio.NewWriter(w), start: time.Now()}
+	_, tw.err = tw.w.WriteString(traceHeader)
+	return tw
+}
+
+// Record 는 Observe 에 그대로 넘길 수 있다.
+func (tw *TraceWriter) Record(a Access) {
+	section := a.Section
+	if section == "" {
+		section = "-"
+	}
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.err != nil {
+		return
+	}
+	_, tw.err = fmt.Fprintf(tw.w, "%d\t%s\t%d\t%d\t%d\t%s\n",
+		a.Start.Sub(tw.start).Nanoseconds(), a.Op, a.Offset, a.Len, a.Duration.Nanoseconds(), section)
+}
+
+func (tw *TraceWriter) Flush() error {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.err != nil {
+		return tw.err
+	}
+	return tw.w.Flush()
+}
+
+// Trace 는 이 파일의 모든 호출을 w 에 트레이스로 남기기 시작한다.
+// 돌려준 stop 을 부르면 기록을 멈추고 버퍼를 비운다.
+//
+//	stop := cf.Trace(traceFile)
+//	defer stop()
+func (cf *CountingFile) Trace(w io.Writer) (stop func() error) {
+	tw := NewTraceWriter(w)
+	unobserve := cf.Observe(tw.Record)
+	return func() error {
+		unobserve()
+		return tw.Flush()
+	}
+}
+
+// ParseOp 는 Op.String 의 반대
+func ParseOp(s string) (Op, error) {
+	for _, op := range []Op{OpRead, OpWrite, OpSeek, OpSync} {
+		if op.String() == s {
+			return op, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown op %q", s)
+}
+
+// ReadTrace 는 트레이스 파일 전체를 읽는다.
+func ReadTrace(r io.Reader) ([]TraceEntry, error) {
+	var entries []TraceEntry
+	sc := bufio.NewScanner(r)
+	line := 0
+	for sc.Scan() {
+		line++
+		text := sc.Text()
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Split(text, "\t")
+		if len(fields) != 6 {
+			return nil, fmt.Errorf("trace line %d: expected 6 fields, got %d", line, len(fields))
+		}
+
+		var e TraceEntry
+		var err error
+		var elapsed, duration int64
+		if elapsed, err = strconv.ParseInt(fields[0], 10, 64); err != nil {
+			return nil, fmt.Errorf("trace line %d: elapsed: %w", line, err)
+		}
+		if e.Op, err = ParseOp(fields[1]); err != nil {
+			return nil, fmt.Errorf("trace line %d: %w", line, err)
+		}
+		if e.Offset, err = strconv.ParseInt(fields[2], 10, 64); err != nil {
+			return nil, fmt.Errorf("trace line %d: offset: %w", line, err)
+		}
+		if e.Len, err = strconv.Atoi(fields[3]); err != nil {
+			return nil, fmt.Errorf("trace line %d: len: %w", line, err)
+		}
+		if duration, err = strconv.ParseInt(fields[4], 10, 64); err != nil {
+			return nil, fmt.Errorf("trace line %d: duration: %w", line, err)
+		}
+		e.Elapsed = time.Duration(elapsed)
+		e.Duration = time.Duration(duration)
+		if fields[5] != "-" {
+			e.Section = fields[5]
+		}
+		entries = append(entries, e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,187 @@
+// Package keyenc 는 여러 값을 묶은 튜플을 순서를 지키는 바이트 키로 바꾼다.
+// 인코딩한 키를 바이트 단위로 비교한 결과가 튜플을 앞 원소부터 비교한 결과와 같아서,
+// (user_id, created_at) 같은 여러 컬럼 인덱스를 바이트 키만 아는 트리 (lsm, sstable) 위에 만들 수 있다.
+//
+// 원소마다 타입 태그 1 바이트 뒤에 값이 온다.
+//
+//	bool   : 0x10 | 0x00 또는 0x01
+//	int64  : 0x20 | 부호 비트를 뒤집은 big-endian 8 바이트 (음수가 양수보다 앞에 오도록)
+//	uint64 : 0x21 | big-endian 8 바이트
+//	[]byte : 0x30 | 0x00 을 0x00 0xff 로 바꾼 바이트 | 0x00 0x01
+//	string : 0x31 | []byte 와 같다
+//
+// 바이트열 끝 표시(0x00 0x01)가 이스케이프된 0x00(0x00 0xff)보다 작아서 "a" < "a\x00" < "ab" 가 된다.
+// 튜플 (a, b) 의 키는 (a) 의 키 뒤에 b 를 이어 붙인 것이므로, 앞쪽 컬럼만 인코딩한 키를 ScanPrefix 에 그대로 쓸 수 있다.
+package keyenc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+var Endian = binary.BigEndian
+
+var (
+	ErrUnsupportedType = errors.New("keyenc: unsupported type")
+	ErrCorrupt         = errors.New("keyenc: corrupt key")
+)
+
+const (
+	tagBool   = 0x10
+	tagInt    = 0x20
+	tagUint   = 0x21
+	tagBytes  = 0x30
+	tagString = 0x31
+
+	escape     = 0x00
+	escapedNul = 0xff
+	terminator = 0x01
+)
+
+func AppendBool(dst []byte, v bool) []byte {
+	if v {
+		return append(dst, tagBool, 1)
+	}
+	return append(dst, tagBool, 0)
+}
+
+func AppendInt(dst []byte, v int64) []byte {
+	dst = append(dst, tagInt)
+	return Endian.AppendUint64(dst, uint64(v)^(1<<63))
+}
+
+func AppendUint(dst []byte, v uint64) []byte {
+	dst = append(dst, tagUint)
+	return Endian.AppendUint64(dst, v)
+}
+
+func AppendBytes(dst []byte, v []byte) []byte {
+	return appendEscaped(append(dst, tagBytes), v)
+}
+
+func AppendString(dst []byte, v string) []byte {
+	return appendEscaped(append(dst, tagString), []byte(v))
+}
+
+func appendEscaped(dst, v []byte) []byte {
+	for _, b := range v {
+		if b == escape {
+			dst = append(dst, escape, escapedNul)
+		} else {
+			dst = append(dst, b)
+		}
+	}
+	return append(dst, escape, terminator)
+}
+
+// Append 는 vals 를 차례로 인코딩해서 dst 뒤에 붙인다.
+// bool, int, int8~int64, uint, uint8~uint64, string, []byte 를 받는다. 부호 있는 정수는 int64 로, 없는 정수는 uint64 로 인코딩한다.
+func Append(dst []byte, vals ...any) ([]byte, error) {
+	for i, v := range vals {
+		switch v := v.(type) {
+		case bool:
+			dst = AppendBool(dst, v)
+		case int:
+			dst = AppendInt(dst, int64(v))
+		case int8:
+			dst = AppendInt(dst, int64(v))
+		case int16:
+			dst = AppendInt(dst, int64(v))
+		case int32:
+			dst = AppendInt(dst, int64(v))
+		case int64:
+			dst = AppendInt(dst, v)
+		case uint:
+			dst = AppendUint(dst, uint64(v))
+		case uint8:
+			dst = AppendUint(dst, uint64(v))
+		case uint16:
+			dst = AppendUint(dst, uint64(v))
+		case uint32:
+			dst = AppendUint(dst, uint64(v))
+		case uint64:
+			dst = AppendUint(dst, v)
+		case string:
+			dst = AppendString(dst, v)
+		case []byte:
+			dst = AppendBytes(dst, v)
+		default:
+			return nil, fmt.Errorf("%w: element %d is %T", ErrUnsupportedType, i, v)
+		}
+	}
+	return dst, nil
+}
+
+// Encode 는 vals 튜플의 키를 새로 만든다.
+func Encode(vals ...any) ([]byte, error) {
+	return Append(nil, vals...)
+}
+
+// Decode 는 키를 튜플로 되돌린다. 원소는 bool, int64, uint64, string, []byte 중 하나다.
+func Decode(key []byte) ([]any, error) {
+	var vals []any
+	for len(key) > 0 {
+		v, n, err := decodeOne(key)
+		if err != nil {
+			return nil, fmt.Errorf("%w (element %d)", err, len(vals))
+		}
+		vals = append(vals, v)
+		key = key[n:]
+	}
+	return vals, nil
+}
+
+// decodeOne 은 key 맨 앞 원소 하나와 그 길이를 돌려준다.
+func decodeOne(key []byte) (any, int, error) {
+	switch key[0] {
+	case tagBool:
+		if len(key) < 2 || key[1] > 1 {
+			return nil, 0, ErrCorrupt
+		}
+		return key[1] == 1, 2, nil
+	case tagInt:
+		if len(key) < 9 {
+			return nil, 0, ErrCorrupt
+		}
+		return int64(Endian.Uint64(key[1:9]) ^ (1 << 63)), 9, nil
+	case tagUint:
+		if len(key) < 9 {
+			return nil, 0, ErrCorrupt
+		}
+		return Endian.Uint64(key[1:9]), 9, nil
+	case tagBytes, tagString:
+		b, n, err := decodeEscaped(key[1:])
+		if err != nil {
+			return nil, 0, err
+		}
+		if key[0] == tagString {
+			return string(b), n + 1, nil
+		}
+		return b, n + 1, nil
+	}
+	return nil, 0, fmt.Errorf("%w: unknown tag 0x%02x", ErrCorrupt, key[0])
+}
+
+func decodeEscaped(src []byte) ([]byte, int, error) {
+	out := []byte{}
+	for i := 0; i < len(src); i++ {
+		if src[i] != escape {
+			out = append(out, src[i])
+			continue
+		}
+		if i+1 == len(src) {
+			break
+		}
+		switch src[i+1] {
+		case terminator:
+			return out, i + 2, nil
+		case escapedNul:
+			out = append(out, escape)
+			i++
+		default:
+			return nil, 0, ErrCorrupt
+		}
+	}
+	return nil, 0, fmt.Errorf("%w: unterminated string", ErrCorrupt)
+}
@@ -0,0 +1,207 @@
+package keyenc
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// typeRank 는 원소 타입의 순서. 타입이 다른 원소는 태그 순으로 비교된다.
+func typeRank(v any) int {
+	switch v.(type) {
+	case bool:
+		return tagBool
+	case int64:
+		return tagInt
+	case uint64:
+		return tagUint
+	case []byte:
+		return tagBytes
+	case string:
+		return tagString
+	}
+	panic("unexpected element type")
+}
+
+func compareElem(a, b any) int {
+	if c := cmp.Compare(typeRank(a), typeRank(b)); c != 0 {
+		return c
+	}
+	switch a := a.(type) {
+	case bool:
+		b := b.(bool)
+		if a == b {
+			return 0
+		}
+		if !a {
+			return -1
+		}
+		return 1
+	case int64:
+		return cmp.Compare(a, b.(int64))
+	case uint64:
+		return cmp.Compare(a, b.(uint64))
+	case []byte:
+		return bytes.Compare(a, b.([]byte))
+	case string:
+		return strings.Compare(a, b.(string))
+	}
+	panic("unexpected element type")
+}
+
+// compareTuples 는 튜플을 앞 원소부터 비교한다. 한쪽이 다른 쪽의 앞부분이면 짧은 쪽이 앞이다.
+func compareTuples(a, b []any) int {
+	for i := range min(len(a), len(b)) {
+		if c := compareElem(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+// 겹치는 값이 자주 나오도록 작은 후보에서 고른다. 부호가 바뀌는 자리의 정수와 0x00 이 든 바이트열을 일부러 넣는다.
+var (
+	intPool    = []int64{math.MinInt64, math.MinInt64 + 1, -256, -1, 0, 1, 255, 256, math.MaxInt64 - 1, math.MaxInt64}
+	uintPool   = []uint64{0, 1, 255, 256, 1 << 63, math.MaxUint64}
+	stringPool = []string{"", "\x00", "\x00\x00", "\x00\x01", "\x00\xff", "a", "a\x00", "a\x00\x00", "a\x00b", "a\x01", "ab", "b", "\xff", "\xff\x00"}
+)
+
+func randomElem(r *rand.Rand) any {
+	switch r.Intn(5) {
+	case 0:
+		return r.Intn(2) == 1
+	case 1:
+		if r.Intn(4) == 0 {
+			return r.Int63() - r.Int63()
+		}
+		return intPool[r.Intn(len(intPool))]
+	case 2:
+		return uintPool[r.Intn(len(uintPool))]
+	case 3:
+		return []byte(stringPool[r.Intn(len(stringPool))])
+	}
+	return stringPool[r.Intn(len(stringPool))]
+}
+
+func randomTuple(r *rand.Rand) []any {
+	t := make([]any, r.Intn(4))
+	for i := range t {
+		t[i] = randomElem(r)
+	}
+	return t
+}
+
+// 인코딩한 키의 바이트 비교가 튜플의 원소별 비교와 같다.
+func TestOrderMatchesTupleOrder(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 50000; i++ {
+		a, b := randomTuple(r), randomTuple(r)
+		if r.Intn(3) == 0 {
+			// 앞 원소가 같은 쌍을 자주 만든다.
+			b = append(append([]any{}, a[:r.Intn(len(a)+1)]...), b...)
+		}
+		ka, err := Encode(a...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		kb, err := Encode(b...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := bytes.Compare(ka, kb), compareTuples(a, b); got != want {
+			t.Fatalf("compare(%q, %q) = %d, tuples compare %d (keys % x / % x)", a, b, got, want, ka, kb)
+		}
+	}
+}
+
+// 손으로 고른 순서. 모두 오름차순이다.
+func TestOrderExamples(t *testing.T) {
+	ordered := [][]any{
+		{false},
+		{true},
+		{int64(math.MinInt64)},
+		{int64(-1)},
+		{int64(-1), "z"},
+		{int64(0)},
+		{int64(1)},
+		{int64(math.MaxInt64)},
+		{uint64(0)},
+		{uint64(math.MaxUint64)},
+		{"a"},
+		{"a", int64(-1)},
+		{"a\x00"},
+		{"a\x00\x00"},
+		{"a\x00b"},
+		{"ab"},
+	}
+	for i := 1; i < len(ordered); i++ {
+		prev, _ := Encode(ordered[i-1]...)
+		cur, _ := Encode(ordered[i]...)
+		if bytes.Compare(prev, cur) >= 0 {
+			t.Errorf("key of %q is not below key of %q", ordered[i-1], ordered[i])
+		}
+	}
+}
+
+func TestDecodeRoundTrip(t *testing.T) {
+	cases := []struct {
+		in   []any
+		want []any
+	}{
+		{nil, nil},
+		{[]any{true, false}, []any{true, false}},
+		{[]any{int64(math.MinInt64), int64(-1), int64(0), int64(math.MaxInt64)}, []any{int64(math.MinInt64), int64(-1), int64(0), int64(math.MaxInt64)}},
+		{[]any{int(-7), int8(-8), int16(16), int32(-32)}, []any{int64(-7), int64(-8), int64(16), int64(-32)}},
+		{[]any{uint(7), uint8(8), uint16(16), uint32(32), uint64(math.MaxUint64)}, []any{uint64(7), uint64(8), uint64(16), uint64(32), uint64(math.MaxUint64)}},
+		{[]any{"", "a\x00b", "\x00\x00", "\xff\x00\x01"}, []any{"", "a\x00b", "\x00\x00", "\xff\x00\x01"}},
+		{[]any{[]byte{}, []byte{0, 0xff, 0, 1}}, []any{[]byte{}, []byte{0, 0xff, 0, 1}}},
+		{[]any{int64(42), "user", []byte("x\x00"), true}, []any{int64(42), "user", []byte("x\x00"), true}},
+	}
+	for _, c := range cases {
+		key, err := Encode(c.in...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := Decode(key)
+		if err != nil {
+			t.Fatalf("Decode(Encode(%q)): %v", c.in, err)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Decode(Encode(%q)) = %#v, want %#v", c.in, got, c.want)
+		}
+	}
+}
+
+// 잘리거나 규칙에 맞지 않는 키는 ErrCorrupt 로 거절한다.
+func TestDecodeCorrupt(t *testing.T) {
+	cases := []struct {
+		name string
+		key  []byte
+	}{
+		{"bool without value", []byte{tagBool}},
+		{"bool value 2", []byte{tagBool, 2}},
+		{"short int", []byte{tagInt, 0x80, 0, 0}},
+		{"short uint", []byte{tagUint, 1}},
+		{"unknown tag", []byte{0x99, 1}},
+		{"unterminated string", []byte{tagString, 'a', 'b'}},
+		{"string ends in escape", []byte{tagString, 'a', escape}},
+		{"bad escape", []byte{tagBytes, 'a', escape, 0x02, escape, terminator}},
+		{"corrupt second element", append(AppendInt(nil, 1), tagInt, 0)},
+	}
+	for _, c := range cases {
+		if got, err := Decode(c.key); !errors.Is(err, ErrCorrupt) {
+			t.Errorf("%s: Decode(% x) = %#v, %v, want ErrCorrupt", c.name, c.key, got, err)
+		}
+	}
+}
+
+func TestEncodeUnsupportedType(t *testing.T) {
+	if _, err := Encode(int64(1), 3.14); !errors.Is(err, ErrUnsupportedType) {
+		t.Fatalf("Encode(float64) = %v, want ErrUnsupportedType", err)
+	}
+}
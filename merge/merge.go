@@ -0,0 +1,147 @@
+// Package merge 는 여러 정렬된 입력을 하나의 정렬된 스트림으로 합치는 k-way 병합 iterator 다.
+// 입력마다 맨 앞 항목을 min-heap 에 올려 두고, 가장 작은 것을 꺼낼 때마다 그 입력을 한 칸 옮긴다.
+//
+// 같은 키가 여러 입력에 있으면 sources 에서 앞에 있는 입력의 것 하나만 남긴다.
+// LSM 트리처럼 새 데이터부터 넣으면 최신 버전만 남고, 컴팩션 / bulk load / 두 파일 비교가 같은 방식으로 입력을 합칠 수 있다.
+//
+// 입력이 될 수 있는 것
+//   - *sstable.Iterator: 그대로 Iterator[sstable.Entry] 를 만족한다.
+//   - 메모리 슬라이스: Slice
+//   - 콜백으로 도는 구조 (btree.BTree.Range 등): Seq 로 iter.Seq 를 감싼다.
+package merge
+
+import (
+	"container/heap"
+	"iter"
+)
+
+// Iterator 는 오름차순으로 항목을 돌려주는 입력.
+// Next 가 false 면 끝났거나 에러가 난 것이고, 둘은 Err 로 구분한다. Entry 는 Next 가 true 를 돌려준 뒤에만 부른다.
+type Iterator[T any] interface {
+	Next() bool
+	Entry() T
+	Err() error
+}
+
+// Iter 는 여러 Iterator 를 cmp 순서로 합친 Iterator. 자신도 Iterator 라서 다시 다른 병합의 입력이 될 수 있다.
+type Iter[T any] struct {
+	h   iterHeap[T]
+	cur T
+	err error
+}
+
+type heapItem[T any] struct {
+	it   Iterator[T]
+	prio int // sources 에서의 순번. 작을수록 우선
+}
+
+type iterHeap[T any] struct {
+	items []heapItem[T]
+	cmp   func(a, b T) int
+}
+
+func (h *iterHeap[T]) Len() int { return len(h.items) }
+func (h *iterHeap[T]) Less(i, j int) bool {
+	if c := h.cmp(h.items[i].it.Entry(), h.items[j].it.Entry()); c != 0 {
+		return c < 0
+	}
+	return h.items[i].prio < h.items[j].prio
+}
+func (h *iterHeap[T]) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *iterHeap[T]) Push(x any)    { h.items = append(h.items, x.(heapItem[T])) }
+func (h *iterHeap[T]) Pop() any {
+	x := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return x
+}
+
+// New 는 sources 를 합친다. cmp 는 두 항목의 키를 비교해서 음수 / 0 / 양수를 돌려준다.
+// cmp 가 0 인 항목들은 sources 에서 가장 앞에 있는 입력의 것만 나온다.
+// 입력 하나 안에서 같은 키가 이어지면 그중 첫 번째만 나온다.
+func New[T any](cmp func(a, b T) int, sources ...Iterator[T]) *Iter[T] {
+	m := &Iter[T]{h: iterHeap[T]{cmp: cmp}}
+	for i, it := range sources {
+		m.push(heapItem[T]{it: it, prio: i})
+	}
+	return m
+}
+
+func (m *Iter[T]) Next() bool {
+	if m.err != nil || m.h.Len() == 0 {
+		return false
+	}
+	top := heap.Pop(&m.h).(heapItem[T])
+	m.cur = top.it.Entry()
+	m.push(top)
+
+	// 같은 키의 나머지 (우선순위가 낮은 버전) 는 건너뛴다.
+	for m.h.Len() > 0 && m.h.cmp(m.h.items[0].it.Entry(), m.cur) == 0 {
+		m.push(heap.Pop(&m.h).(heapItem[T]))
+	}
+	return m.err == nil
+}
+
+// push 는 item 을 한 칸 옮겨서 남은 것이 있으면 힙에 (다시) 넣는다.
+func (m *Iter[T]) push(item heapItem[T]) {
+	if item.it.Next() {
+		heap.Push(&m.h, item)
+		return
+	}
+	if err := item.it.Err(); err != nil && m.err == nil {
+		m.err = err
+	}
+}
+
+func (m *Iter[T]) Entry() T   { return m.cur }
+func (m *Iter[T]) Err() error { return m.err }
+
+// ==================================
+// 입력 어댑터
+// ==================================
+
+// Slice 는 이미 정렬된 슬라이스를 Iterator 로 돈다. 슬라이스를 복사하지 않는다.
+func Slice[T any](items []T) Iterator[T] {
+	return &sliceIter[T]{items: items, pos: -1}
+}
+
+type sliceIter[T any] struct {
+	items []T
+	pos   int
+}
+
+func (it *sliceIter[T]) Next() bool {
+	it.pos++
+	return it.pos < len(it.items)
+}
+
+func (it *sliceIter[T]) Entry() T   { return it.items[it.pos] }
+func (it *sliceIter[T]) Err() error { return nil }
+
+// Seq 는 정렬된 순서로 값을 내는 iter.Seq 를 Iterator 로 바꾼다.
+// 끝까지 돌면 저절로 정리되지만, 중간에 그만둘 때는 돌려준 stop 을 불러야 seq 가 멈춘다.
+//
+//	it, stop := merge.Seq(func(yield func(int) bool) { tree.Range(lo, hi, yield) })
+//	defer stop()
+func Seq[T any](seq iter.Seq[T]) (Iterator[T], func()) {
+	next, stop := iter.Pull(seq)
+	return &seqIter[T]{next: next, stop: stop}, stop
+}
+
+type seqIter[T any] struct {
+	next func() (T, bool)
+	stop func()
+	cur  T
+}
+
+func (it *seqIter[T]) Next() bool {
+	v, ok := it.next()
+	if !ok {
+		it.stop()
+		return false
+	}
+	it.cur = v
+	return true
+}
+
+func (it *seqIter[T]) Entry() T   { return it.cur }
+func (it *seqIter[T]) Err() error { return nil }
@@ -0,0 +1,128 @@
+package btree
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tmdgusya/btree/dberr"
+)
+
+// ==================================
+// 경로
+// ==================================
+// Path 는 연산 하나가 루트에서 잎 쪽으로 내려간 길이다. 단계마다 어느 노드에 있었고 그 노드에서 어디로 갔는지 적는다.
+// 검색, 삽입, 삭제 트레이스가 모두 같은 모양으로 길을 남기므로 웹 UI 의 강조 표시나 CLI 출력은 Path 하나만 알면 된다.
+// 노드 이름("root-1-0")이 필요하면 Labels 로 만든다. 이름은 VisualNode.Path 와 같다.
+
+// Direction 은 한 노드에서 연산이 한 일
+type Direction string
+
+const (
+	DirDown   Direction = "down"   // children[KeyIndex] 로 내려갔다
+	DirFound  Direction = "found"  // keys[KeyIndex] 가 찾던 키다
+	DirMiss   Direction = "miss"   // 잎(또는 더 내려갈 곳이 없는 노드)에서 못 찾았다. KeyIndex 는 있었다면 놓였을 자리
+	DirInsert Direction = "insert" // 잎의 keys[KeyIndex] 에 넣었다
+	DirDelete Direction = "delete" // 잎의 keys[KeyIndex] 를 뺐다 (내부 노드에서 찾은 키는 DirFound 뒤에 predecessor / successor 쪽으로 내려간다)
+)
+
+// PathStep 은 경로의 한 노드. NodeIndex 는 그 노드가 부모의 몇 번째 자식인지다 (루트는 0).
+// 앞 단계가 DirDown 이면 NodeIndex 는 앞 단계의 KeyIndex 와 같다.
+type PathStep struct {
+	NodeIndex int       `json:"nodeIndex"`
+	KeyIndex  int       `json:"keyIndex"`
+	Direction Direction `json:"direction"`
+}
+
+type Path []PathStep
+
+// Labels 는 단계마다의 노드 이름 ("root", "root-1", "root-1-0")
+func (p Path) Labels() []string {
+	labels := make([]string, len(p))
+	for i, s := range p {
+		if i == 0 {
+			labels[i] = "root"
+		} else {
+			labels[i] = fmt.Sprintf("%s-%d", labels[i-1], s.NodeIndex)
+		}
+	}
+	return labels
+}
+
+// String 은 "root down 1 > root-1 found 0" 처럼 한 줄로 쓴다.
+func (p Path) String() string {
+	var sb strings.Builder
+	for i, label := range p.Labels() {
+		if i > 0 {
+			sb.WriteString(" > ")
+		}
+		fmt.Fprintf(&sb, "%s %s %d", label, p[i].Direction, p[i].KeyIndex)
+	}
+	return sb.String()
+}
+
+var (
+	// ErrInvalidPath 는 노드 이름이 "root" 나 "root-1-0" 모양이 아닐 때
+	ErrInvalidPath = errors.New("btree: invalid node path")
+	// ErrNoNode 는 이름은 맞지만 지금 트리에 그 노드가 없을 때
+	ErrNoNode = dberr.New(dberr.ErrKeyNotFound, "btree: no node at path")
+)
+
+// ParseLabel 은 노드 이름("root-1-0")을 루트에서부터 내려갈 자식 번호([1 0])로 바꾼다. "root" 는 빈 슬라이스.
+func ParseLabel(label string) ([]int, error) {
+	parts := strings.Split(label, "-")
+	if parts[0] != "root" {
+		return nil, fmt.Errorf("%w: %q does not start with root", ErrInvalidPath, label)
+	}
+	indices := make([]int, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		i, err := strconv.Atoi(part)
+		if err != nil || i < 0 {
+			return nil, fmt.Errorf("%w: %q: bad child index %q", ErrInvalidPath, label, part)
+		}
+		indices = append(indices, i)
+	}
+	return indices, nil
+}
+
+// NodeInfo 는 노드 하나의 요약. 자식은 수만 알려 주므로 큰 트리도 노드 단위로 조금씩 펼쳐 볼 수 있다.
+type NodeInfo struct {
+	Path       string `json:"path"`
+	Keys       []int  `json:"keys"`
+	ChildCount int    `json:"childCount"`
+	IsLeaf     bool   `json:"isLeaf"`
+}
+
+// Node 는 label 이름의 노드를 찾아 요약을 돌려준다.
+func (b *BTree) Node(label string) (NodeInfo, error) {
+	x, err := b.nodeAt(label)
+	if err != nil {
+		return NodeInfo{}, err
+	}
+	return NodeInfo{
+		Path:       label,
+		Keys:       append([]int(nil), x.keys...),
+		ChildCount: len(x.children),
+		IsLeaf:     x.isLeaf,
+	}, nil
+}
+
+// nodeAt 은 label 이름의 노드. 이름이 틀리면 ErrInvalidPath, 그런 노드가 없으면 ErrNoNode.
+func (b *BTree) nodeAt(label string) (*BTreeNode, error) {
+	indices, err := ParseLabel(label)
+	if err != nil {
+		return nil, err
+	}
+	x := b.root
+	if x == nil {
+		return nil, fmt.Errorf("%w: %s (tree is empty)", ErrNoNode, label)
+	}
+	for depth, i := range indices {
+		if i >= len(x.children) {
+			return nil, fmt.Errorf("%w: %s (node at depth %d has %d children)", ErrNoNode, label, depth, len(x.children))
+		}
+		x = x.children[i]
+	}
+	return x, nil
+}
@@ -0,0 +1,368 @@
+// Package query 는 B-Tree 인덱스 위에 얹은 아주 작은 SQL 비슷한 질의 계층이다.
+// 테이블은 정수 키 열 k 하나뿐이고, 아래 네 문장만 안다.
+//
+//	INSERT INTO t VALUES (1), (2), (3)
+//	SELECT * FROM t [WHERE k = 5 | WHERE k BETWEEN 10 AND 20]
+//	DELETE FROM t WHERE k = 5 | WHERE k BETWEEN 10 AND 20
+//
+// 결과에 실행 계획(Plan)을 같이 돌려줘서 WHERE 가 인덱스 탐색 / 범위 스캔으로 바뀌는 모습을 보여 준다.
+package query
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/tmdgusya/btree/dberr"
+)
+
+// Index 는 질의가 쓰는 인덱스 연산. *btree.BTree 가 그대로 만족한다. 테이블에 키 열 하나뿐이라 값은 늘 nil 로 넣는다.
+// Insert 는 이미 있는 키면 true 를 돌려준다.
+type Index interface {
+	Insert(k int, value []byte) bool
+	Range(lo, hi int, fn func(k int) bool)
+}
+
+// Deleter 는 삭제를 지원하는 인덱스. 없으면 DELETE 는 ErrDeleteUnsupported 를 돌려준다.
+type Deleter interface {
+	Delete(k int) bool
+}
+
+var ErrDeleteUnsupported = errors.New("index does not support delete")
+
+// Kind 는 문장 종류
+type Kind int
+
+const (
+	Insert Kind = iota
+	Select
+	Delete
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Insert:
+		return "INSERT"
+	case Select:
+		return "SELECT"
+	case Delete:
+		return "DELETE"
+	default:
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+}
+
+// Statement 는 파싱된 문장 하나. WHERE 는 항상 닫힌 구간 [Lo, Hi] 로 정규화한다.
+type Statement struct {
+	Kind   Kind
+	Table  string
+	Values []int // INSERT
+	Where  bool  // WHERE 가 있었는지
+	Lo, Hi int
+}
+
+// Result 는 실행 결과
+type Result struct {
+	Rows     []int  // SELECT 결과 (오름차순)
+	Affected int    // INSERT / DELETE 로 바뀐 행 수
+	Plan     string // 인덱스를 어떻게 썼는지
+}
+
+// Engine 은 테이블 하나와 그 인덱스
+type Engine struct {
+	Table string
+	Index Index
+}
+
+// Exec 는 문장 하나를 파싱해서 실행한다. Index 가 nil 이면 dberr.ErrTreeNotInitialized.
+func (e *Engine) Exec(sql string) (*Result, error) {
+	st, err := Parse(sql)
+	if err != nil {
+		return nil, err
+	}
+	return e.Run(st)
+}
+
+// Run 은 파싱된 문장을 실행한다.
+func (e *Engine) Run(st *Statement) (*Result, error) {
+	if e.Index == nil {
+		return nil, dberr.ErrTreeNotInitialized
+	}
+	if !strings.EqualFold(st.Table, e.Table) {
+		return nil, fmt.Errorf("unknown table %q (only %q exists)", st.Table, e.Table)
+	}
+
+	switch st.Kind {
+	case Insert:
+		// 이미 있는 키는 행이 늘지 않으므로 Affected 에 넣지 않는다.
+		n := 0
+		for _, v := range st.Values {
+			if !e.Index.Insert(v, nil) {
+				n++
+			}
+		}
+		return &Result{Affected: n, Plan: fmt.Sprintf("index insert x%d", len(st.Values))}, nil
+	case Select:
+		rows := []int{}
+		e.Index.Range(st.Lo, st.Hi, func(k int) bool {
+			rows = append(rows, k)
+			return true
+		})
+		return &Result{Rows: rows, Plan: plan(st)}, nil
+	case Delete:
+		d, ok := e.Index.(Deleter)
+		if !ok {
+			return nil, ErrDeleteUnsupported
+		}
+		// 순회 중에 트리를 바꾸지 않도록 지울 키를 먼저 모은다.
+		var keys []int
+		e.Index.Range(st.Lo, st.Hi, func(k int) bool {
+			keys = append(keys, k)
+			return true
+		})
+		n := 0
+		for _, k := range keys {
+			if d.Delete(k) {
+				n++
+			}
+		}
+		return &Result{Affected: n, Plan: plan(st) + " + delete"}, nil
+	default:
+		return nil, fmt.Errorf("unknown statement kind %v", st.Kind)
+	}
+}
+
+func plan(st *Statement) string {
+	switch {
+	case !st.Where:
+		return "full index scan"
+	case st.Lo == st.Hi:
+		return fmt.Sprintf("index lookup k = %d", st.Lo)
+	default:
+		return fmt.Sprintf("index range scan k in [%d, %d]", st.Lo, st.Hi)
+	}
+}
+
+// ==================================
+// 파서
+// ==================================
+
+type parser struct {
+	toks []string
+	pos  int
+}
+
+// Parse 는 문장 하나를 읽는다. 키워드는 대소문자를 가리지 않고, 끝의 ';' 는 있어도 된다.
+func Parse(sql string) (*Statement, error) {
+	toks, err := tokenize(sql)
+	if err != nil {
+		return nil, err
+	}
+	if n := len(toks); n > 0 && toks[n-1] == ";" {
+		toks = toks[:n-1]
+	}
+	if len(toks) == 0 {
+		return nil, errors.New("empty statement")
+	}
+
+	p := &parser{toks: toks}
+	var st *Statement
+	switch strings.ToUpper(p.next()) {
+	case "INSERT":
+		st, err = p.insert()
+	case "SELECT":
+		st, err = p.selectStmt()
+	case "DELETE":
+		st, err = p.deleteStmt()
+	default:
+		return nil, fmt.Errorf("expected INSERT, SELECT or DELETE, got %q", toks[0])
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.toks) {
+		return nil, fmt.Errorf("unexpected %q after statement", p.toks[p.pos])
+	}
+	return st, nil
+}
+
+// INSERT INTO t VALUES (1), (2)
+func (p *parser) insert() (*Statement, error) {
+	if err := p.expect("INTO"); err != nil {
+		return nil, err
+	}
+	table, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect("VALUES"); err != nil {
+		return nil, err
+	}
+	st := &Statement{Kind: Insert, Table: table}
+	for {
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		v, err := p.number()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		st.Values = append(st.Values, v)
+		if p.peek() != "," {
+			return st, nil
+		}
+		p.next()
+	}
+}
+
+// SELECT * FROM t [WHERE ...]
+func (p *parser) selectStmt() (*Statement, error) {
+	if tok := p.next(); tok != "*" && !strings.EqualFold(tok, "k") {
+		return nil, fmt.Errorf("expected * or k after SELECT, got %q", tok)
+	}
+	if err := p.expect("FROM"); err != nil {
+		return nil, err
+	}
+	table, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	st := &Statement{Kind: Select, Table: table, Lo: math.MinInt, Hi: math.MaxInt}
+	return st, p.where(st, false)
+}
+
+// DELETE FROM t WHERE ...  (WHERE 없는 DELETE 는 실수일 가능성이 커서 받지 않는다)
+func (p *parser) deleteStmt() (*Statement, error) {
+	if err := p.expect("FROM"); err != nil {
+		return nil, err
+	}
+	table, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	st := &Statement{Kind: Delete, Table: table}
+	return st, p.where(st, true)
+}
+
+// WHERE k = n | WHERE k BETWEEN a AND b
+func (p *parser) where(st *Statement, required bool) error {
+	if p.peek() == "" {
+		if required {
+			return errors.New("DELETE needs a WHERE clause")
+		}
+		return nil
+	}
+	if err := p.expect("WHERE"); err != nil {
+		return err
+	}
+	if err := p.expect("K"); err != nil {
+		return err
+	}
+	st.Where = true
+
+	switch op := strings.ToUpper(p.next()); op {
+	case "=":
+		v, err := p.number()
+		if err != nil {
+			return err
+		}
+		st.Lo, st.Hi = v, v
+	case "BETWEEN":
+		lo, err := p.number()
+		if err != nil {
+			return err
+		}
+		if err := p.expect("AND"); err != nil {
+			return err
+		}
+		hi, err := p.number()
+		if err != nil {
+			return err
+		}
+		st.Lo, st.Hi = lo, hi
+	default:
+		return fmt.Errorf("expected = or BETWEEN after k, got %q", op)
+	}
+	return nil
+}
+
+func (p *parser) peek() string {
+	if p.pos >= len(p.toks) {
+		return ""
+	}
+	return p.toks[p.pos]
+}
+
+func (p *parser) next() string {
+	tok := p.peek()
+	if tok != "" {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) expect(want string) error {
+	tok := p.next()
+	if !strings.EqualFold(tok, want) {
+		if tok == "" {
+			return fmt.Errorf("expected %s, got end of statement", want)
+		}
+		return fmt.Errorf("expected %s, got %q", want, tok)
+	}
+	return nil
+}
+
+func (p *parser) ident() (string, error) {
+	tok := p.next()
+	if tok == "" || !unicode.IsLetter(rune(tok[0])) && tok[0] != '_' {
+		return "", fmt.Errorf("expected table name, got %q", tok)
+	}
+	return tok, nil
+}
+
+func (p *parser) number() (int, error) {
+	tok := p.next()
+	v, err := strconv.Atoi(tok)
+	if err != nil {
+		return 0, fmt.Errorf("expected integer, got %q", tok)
+	}
+	return v, nil
+}
+
+// 토큰: 식별자 / 키워드, 부호 있는 정수, 그리고 ( ) , = * ;
+func tokenize(s string) ([]string, error) {
+	var toks []string
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.IndexByte("(),=*;", c) >= 0:
+			toks = append(toks, string(c))
+			i++
+		case c == '-' || c >= '0' && c <= '9':
+			j := i + 1
+			for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+				j++
+			}
+			toks = append(toks, s[i:j])
+			i = j
+		case c == '_' || unicode.IsLetter(rune(c)):
+			j := i + 1
+			for j < len(s) && (s[j] == '_' || unicode.IsLetter(rune(s[j])) || s[j] >= '0' && s[j] <= '9') {
+				j++
+			}
+			toks = append(toks, s[i:j])
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected character %q at %d", c, i)
+		}
+	}
+	return toks, nil
+}
@@ -0,0 +1,169 @@
+package btree
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// ==================================
+// 요청 ID 와 span
+// ==================================
+// 호스팅된 데모에서 생긴 문제를 로그만 보고 따라갈 수 있도록 HTTP 요청마다 ID 를 붙인다.
+// - 클라이언트가 X-Request-ID 를 보내고 형식이 맞으면 그대로 쓰고, 아니면 16 진수 16 자리를 새로 만든다.
+// - 응답 헤더 X-Request-ID 로 돌려주고, 요청이 끝나면 같은 ID 로 한 줄 로그(메서드, 경로, 상태, 걸린 시간)를 남긴다.
+// - 트리 연산은 Tracer 가 있으면 span 으로 감싼다. 모듈에 외부 의존성을 두지 않으므로 OpenTelemetry 를 쓰려면
+//   trace.Tracer 를 이 Tracer 모양으로 감싼 어댑터를 SetTracer 로 넣는다. 기본은 span 을 만들지 않는다.
+
+// RequestIDHeader 는 요청 ID 를 주고받는 헤더
+const RequestIDHeader = "X-Request-ID"
+
+// 클라이언트가 보낸 ID 는 이 길이까지, 영문자 / 숫자 / '-' / '_' 로만 된 것을 받는다. 로그에 그대로 찍히기 때문이다.
+const maxRequestIDLen = 64
+
+type requestIDKey struct{}
+
+// RequestID 는 ctx 에 붙은 요청 ID. 서버를 거치지 않은 ctx 면 "".
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// statusRecorder 는 로그에 남길 상태 코드와 응답 크기를 기억한다.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *statusRecorder) Write(p []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	n, err := rec.ResponseWriter.Write(p)
+	rec.bytes += int64(n)
+	return n, err
+}
+
+// withRequestID 는 next 앞에서 요청 ID 를 정하고, 끝나면 요청 로그를 남긴다. 5xx 는 Error, 나머지는 Info.
+// TimeoutHandler 보다 바깥에 두어야 시간 초과로 끊긴 요청도 ID 와 함께 503 으로 남는다.
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+
+		rec := &statusRecorder{ResponseWriter: w}
+		start := time.Now()
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		level := slog.LevelInfo
+		if status >= 500 {
+			level = slog.LevelError
+		}
+		slog.LogAttrs(ctx, level, "http request",
+			slog.String("requestId", id),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", status),
+			slog.Int64("bytes", rec.bytes),
+			slog.Duration("elapsed", time.Since(start)))
+	})
+}
+
+// Tracer 는 트리 연산 하나를 감싸는 span 을 시작한다. ctx 에는 요청 ID 가 붙어 있다 (RequestID).
+type Tracer interface {
+	Start(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, Span)
+}
+
+// Span 은 Tracer 가 시작한 구간. 연산이 끝나면 결과 에러(성공이면 nil)와 함께 End 를 한 번 부른다.
+type Span interface {
+	End(err error)
+}
+
+var tracer atomic.Pointer[Tracer]
+
+// SetTracer 는 서버가 트리 연산에 쓸 Tracer 를 바꾼다. nil 이면 span 을 만들지 않는다.
+func SetTracer(t Tracer) {
+	if t == nil {
+		tracer.Store(nil)
+		return
+	}
+	tracer.Store(&t)
+}
+
+type noopSpan struct{}
+
+func (noopSpan) End(error) {}
+
+func startSpan(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, Span) {
+	t := tracer.Load()
+	if t == nil {
+		return ctx, noopSpan{}
+	}
+	return (*t).Start(ctx, name, attrs...)
+}
+
+// LogTracer 는 span 하나를 끝날 때 slog 한 줄(Debug)로 남기는 Tracer. 수집기 없이 -log-level debug 로 연산 시간을 볼 때 쓴다.
+type LogTracer struct{}
+
+func (LogTracer) Start(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, Span) {
+	return ctx, &logSpan{ctx: ctx, name: name, attrs: attrs, start: time.Now()}
+}
+
+type logSpan struct {
+	ctx   context.Context
+	name  string
+	attrs []slog.Attr
+	start time.Time
+}
+
+func (s *logSpan) End(err error) {
+	attrs := append([]slog.Attr{
+		slog.String("span", s.name),
+		slog.String("requestId", RequestID(s.ctx)),
+		slog.Duration("elapsed", time.Since(s.start)),
+	}, s.attrs...)
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	slog.LogAttrs(s.ctx, slog.LevelDebug, "span", attrs...)
+}
@@ -0,0 +1,268 @@
+// Package script 는 트리나 저장소에 돌릴 연산을 한 줄에 하나씩 적은 텍스트 스크립트를 읽고 실행한다.
+//
+//	# '#' 뒤는 주석
+//	create t=3
+//	insert 10 20 30
+//	delete 20
+//	expect-height 1
+//	expect-keys 10 30
+//	expect-found 10
+//	expect-missing 20
+//	expect-size 2
+//	expect-error delete 99
+//
+// 과제 채점이나 회귀 검사용이다. 틀린 줄이 있어도 멈추지 않고 끝까지 돌면서, 줄 번호와 함께 기대값과 무엇이 다른지 모은다.
+// - insert / delete 는 인자를 여러 개 받으면 차례로 한다. delete 는 없는 키면 실패한다.
+// - expect-keys 는 순서와 상관없이 (같은 키가 여럿이면 개수까지) 비교하고, 빠진 키와 남는 키를 알려 준다.
+// - expect-error 는 뒤의 문장이 실패해야 통과한다. 없는 키 지우기 같은 실패 경로를 검사할 때 쓴다.
+package script
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Target 은 스크립트를 돌릴 대상. 할 수 없는 연산은 ErrUnsupported 를 돌려준다.
+type Target interface {
+	// Create 는 최소 차수 t 인 빈 대상을 새로 만든다. 차수가 없는 대상은 t 를 무시하고 비우기만 한다.
+	Create(t int) error
+	Insert(k int) error
+	// Delete 는 k 하나를 지운다. 없으면 dberr.ErrKeyNotFound (로 분류되는 에러).
+	Delete(k int) error
+	Contains(k int) (bool, error)
+	// Keys 는 들어 있는 키 전부. 순서는 상관없다.
+	Keys() ([]int, error)
+	Height() (int, error)
+}
+
+var (
+	ErrUnsupported = errors.New("script: operation not supported by this target")
+	ErrSyntax      = errors.New("script: syntax error")
+	// ErrExpectation 은 expect-* 가 틀렸을 때. 메시지에 기대값과의 차이가 붙는다.
+	ErrExpectation = errors.New("expectation failed")
+)
+
+// Failure 는 실패한 줄 하나
+type Failure struct {
+	Line int
+	Text string
+	Err  error
+}
+
+func (f Failure) Error() string {
+	return fmt.Sprintf("line %d: %s: %v", f.Line, f.Text, f.Err)
+}
+
+// Result 는 스크립트 한 번의 결과. Ops 는 실행한 연산 문장 수, Checks 는 expect-* 문장 수다.
+type Result struct {
+	Statements int
+	Ops        int
+	Checks     int
+	Failures   []Failure
+}
+
+// Err 는 실패가 없으면 nil, 있으면 실패 수를 담은 에러
+func (r Result) Err() error {
+	if len(r.Failures) == 0 {
+		return nil
+	}
+	return fmt.Errorf("script: %d of %d statements failed", len(r.Failures), r.Statements)
+}
+
+// Run 은 in 의 스크립트를 t 에 대고 끝까지 돌린다. log 가 nil 이 아니면 문장마다 "ok" / "FAIL" 한 줄을 쓴다.
+// 돌려주는 에러는 스크립트를 읽지 못했을 때뿐이고, 문장의 실패는 Result.Failures 에 모인다.
+func Run(in io.Reader, t Target, log io.Writer) (Result, error) {
+	var res Result
+	sc := bufio.NewScanner(in)
+	for line := 1; sc.Scan(); line++ {
+		text, _, _ := strings.Cut(sc.Text(), "#")
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		res.Statements++
+
+		fields := strings.Fields(text)
+		if strings.HasPrefix(fields[0], "expect-") {
+			res.Checks++
+		} else {
+			res.Ops++
+		}
+		err := exec(t, fields)
+		if err != nil {
+			res.Failures = append(res.Failures, Failure{Line: line, Text: text, Err: err})
+		}
+		if log != nil {
+			if err != nil {
+				fmt.Fprintf(log, "FAIL %d: %s: %v\n", line, text, err)
+			} else {
+				fmt.Fprintf(log, "ok   %d: %s\n", line, text)
+			}
+		}
+	}
+	return res, sc.Err()
+}
+
+func exec(t Target, fields []string) error {
+	cmd, args := fields[0], fields[1:]
+	switch cmd {
+	case "create":
+		if len(args) != 1 {
+			return fmt.Errorf("%w: create takes t=<degree>", ErrSyntax)
+		}
+		v, ok := strings.CutPrefix(args[0], "t=")
+		if !ok {
+			return fmt.Errorf("%w: create takes t=<degree>, got %q", ErrSyntax, args[0])
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("%w: degree %q is not a number", ErrSyntax, v)
+		}
+		return t.Create(n)
+
+	case "insert", "delete":
+		keys, err := ints(cmd, args, 1)
+		if err != nil {
+			return err
+		}
+		op := t.Insert
+		if cmd == "delete" {
+			op = t.Delete
+		}
+		for _, k := range keys {
+			if err := op(k); err != nil {
+				return fmt.Errorf("%d: %w", k, err)
+			}
+		}
+		return nil
+
+	case "expect-error":
+		if len(args) == 0 {
+			return fmt.Errorf("%w: expect-error takes a statement", ErrSyntax)
+		}
+		if strings.HasPrefix(args[0], "expect-") {
+			return fmt.Errorf("%w: expect-error takes an operation, not %s", ErrSyntax, args[0])
+		}
+		// 문법이 틀렸거나 대상이 못 하는 연산은 기대한 실패가 아니다.
+		err := exec(t, args)
+		if errors.Is(err, ErrSyntax) || errors.Is(err, ErrUnsupported) {
+			return err
+		}
+		if err == nil {
+			return fmt.Errorf("%w: %s succeeded", ErrExpectation, strings.Join(args, " "))
+		}
+		return nil
+
+	case "expect-height", "expect-size":
+		want, err := ints(cmd, args, 1)
+		if err != nil {
+			return err
+		}
+		if len(want) != 1 {
+			return fmt.Errorf("%w: %s takes exactly one number", ErrSyntax, cmd)
+		}
+		var got int
+		if cmd == "expect-height" {
+			got, err = t.Height()
+		} else {
+			var keys []int
+			keys, err = t.Keys()
+			got = len(keys)
+		}
+		if err != nil {
+			return err
+		}
+		if got != want[0] {
+			return fmt.Errorf("%w: got %d, want %d", ErrExpectation, got, want[0])
+		}
+		return nil
+
+	case "expect-found", "expect-missing":
+		keys, err := ints(cmd, args, 1)
+		if err != nil {
+			return err
+		}
+		var wrong []int
+		for _, k := range keys {
+			found, err := t.Contains(k)
+			if err != nil {
+				return err
+			}
+			if found != (cmd == "expect-found") {
+				wrong = append(wrong, k)
+			}
+		}
+		switch {
+		case len(wrong) == 0:
+			return nil
+		case cmd == "expect-found":
+			return fmt.Errorf("%w: not found %v", ErrExpectation, wrong)
+		default:
+			return fmt.Errorf("%w: still present %v", ErrExpectation, wrong)
+		}
+
+	case "expect-keys":
+		want, err := ints(cmd, args, 0)
+		if err != nil {
+			return err
+		}
+		got, err := t.Keys()
+		if err != nil {
+			return err
+		}
+		return diffKeys(got, want)
+	}
+	return fmt.Errorf("%w: unknown statement %q", ErrSyntax, cmd)
+}
+
+// ints 는 args 를 정수로 바꾼다. least 개보다 적으면 문법 에러.
+func ints(cmd string, args []string, least int) ([]int, error) {
+	if len(args) < least {
+		return nil, fmt.Errorf("%w: %s needs at least %d number(s)", ErrSyntax, cmd, least)
+	}
+	out := make([]int, len(args))
+	for i, a := range args {
+		n, err := strconv.Atoi(a)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %q is not a number", ErrSyntax, cmd, a)
+		}
+		out[i] = n
+	}
+	return out, nil
+}
+
+// diffKeys 는 got 과 want 를 정렬해서 (중복 포함) 비교하고, 다르면 빠진 키와 남는 키를 담은 에러를 돌려준다.
+func diffKeys(got, want []int) error {
+	got, want = slices.Sorted(slices.Values(got)), slices.Sorted(slices.Values(want))
+	var missing, extra []int
+	i, j := 0, 0
+	for i < len(got) || j < len(want) {
+		switch {
+		case j == len(want) || (i < len(got) && got[i] < want[j]):
+			extra = append(extra, got[i])
+			i++
+		case i == len(got) || want[j] < got[i]:
+			missing = append(missing, want[j])
+			j++
+		default:
+			i++
+			j++
+		}
+	}
+	if len(missing) == 0 && len(extra) == 0 {
+		return nil
+	}
+	var parts []string
+	if len(missing) > 0 {
+		parts = append(parts, fmt.Sprintf("missing %v", missing))
+	}
+	if len(extra) > 0 {
+		parts = append(parts, fmt.Sprintf("unexpected %v", extra))
+	}
+	return fmt.Errorf("%w: %s (got %d keys, want %d)", ErrExpectation, strings.Join(parts, ", "), len(got), len(want))
+}
@@ -0,0 +1,97 @@
+package script
+
+import (
+	"math"
+
+	"github.com/tmdgusya/btree"
+	"github.com/tmdgusya/btree/dberr"
+)
+
+// TreeTarget 은 메모리 B-Tree 에 스크립트를 돌린다. create 전에는 모든 연산이 dberr.ErrTreeNotInitialized 다.
+// 트리에 Delete 가 있으면 쓰고, 없으면 delete 는 ErrUnsupported 다.
+type TreeTarget struct {
+	Tree *btree.BTree
+}
+
+// deleter 는 키 하나를 지우고 지웠는지 돌려주는 트리
+type deleter interface {
+	Delete(k int) bool
+}
+
+func (t *TreeTarget) Create(degree int) error {
+	tree, err := btree.New(degree)
+	if err != nil {
+		return err
+	}
+	t.Tree = tree
+	return nil
+}
+
+func (t *TreeTarget) tree() (*btree.BTree, error) {
+	if t.Tree == nil {
+		return nil, dberr.ErrTreeNotInitialized
+	}
+	return t.Tree, nil
+}
+
+func (t *TreeTarget) Insert(k int) error {
+	tree, err := t.tree()
+	if err != nil {
+		return err
+	}
+	tree.Insert(k, nil)
+	return nil
+}
+
+func (t *TreeTarget) Delete(k int) error {
+	tree, err := t.tree()
+	if err != nil {
+		return err
+	}
+	d, ok := any(tree).(deleter)
+	if !ok {
+		return ErrUnsupported
+	}
+	if !d.Delete(k) {
+		return dberr.ErrKeyNotFound
+	}
+	return nil
+}
+
+func (t *TreeTarget) Contains(k int) (bool, error) {
+	tree, err := t.tree()
+	if err != nil {
+		return false, err
+	}
+	found := false
+	tree.Range(k, k, func(int) bool {
+		found = true
+		return false
+	})
+	return found, nil
+}
+
+func (t *TreeTarget) Keys() ([]int, error) {
+	tree, err := t.tree()
+	if err != nil {
+		return nil, err
+	}
+	var keys []int
+	tree.Range(math.MinInt, math.MaxInt, func(k int) bool {
+		keys = append(keys, k)
+		return true
+	})
+	return keys, nil
+}
+
+// Height 는 트리 높이. 스크립트가 모양을 보는 김에 불변식도 확인해서, 깨졌으면 그 에러를 돌려준다.
+func (t *TreeTarget) Height() (int, error) {
+	tree, err := t.tree()
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tree.Check(); err != nil {
+		return 0, err
+	}
+	return tree.Height(), nil
+}
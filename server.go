@@ -0,0 +1,1212 @@
+package btree
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/tmdgusya/btree/dberr"
+	"github.com/tmdgusya/btree/workload"
+)
+
+// VisualNode 는 화면에 그릴 노드 하나. 깊이 제한으로 자식을 싣지 않은 노드는 Truncated 이고 Children 이 비어 있다.
+// 그런 노드의 자식은 ChildCount 로 수만 알려 주고, 펼칠 때 /api/subtree?path= 로 가져온다.
+// Values 는 ?values=N 을 준 요청에만, 값이 있는 노드에만 싣는다. Values[i] 는 Keys[i] 의 값을 앞 N 바이트까지 보여 주는 문자열이다 (formatValue).
+type VisualNode struct {
+	Path       string        `json:"path"`
+	Keys       []int         `json:"keys"`
+	Values     []string      `json:"values,omitempty"`
+	IsLeaf     bool          `json:"isLeaf"`
+	Children   []*VisualNode `json:"children"`
+	ChildCount int           `json:"childCount,omitempty"`
+	Truncated  bool          `json:"truncated,omitempty"`
+}
+
+// Depth 는 싣은 층 수 (0 이면 전부)
+type statePayload struct {
+	HasTree bool        `json:"hasTree"`
+	T       int         `json:"t"`
+	Depth   int         `json:"depth,omitempty"`
+	Tree    *VisualNode `json:"tree"`
+}
+
+// 서버가 보여 주는 트리. 읽기(상태, 검색, 히스토그램)는 currentTree 를 Load 해서 잠금 없이 읽는다.
+// 쓰기(생성, 삽입, 삭제)는 treeMu 로 서로만 줄을 서고, InsertCopy / DeleteCopy 로 만든 새 트리를 Store 해서 내보낸다 (cow.go).
+// 한 번 Store 한 트리는 아무도 고치지 않으므로, 삽입이 오래 걸려도 읽기는 그 직전 트리를 그대로 본다.
+var (
+	treeMu      sync.Mutex
+	currentTree atomic.Pointer[BTree]
+)
+
+// SetTree 는 서버가 보여 줄 트리를 바꾼다. 서버를 띄우기 전에 데이터셋을 미리 넣어 둘 때 쓴다.
+// 넘긴 뒤에는 tree 를 직접 고치지 않는다. 요청이 잠금 없이 읽고 있을 수 있다.
+func SetTree(tree *BTree) {
+	treeMu.Lock()
+	currentTree.Store(tree)
+	treeMu.Unlock()
+}
+
+// NewServeMux 는 교육용 웹 UI 와 /api/* 핸들러를 묶은 mux 를 만든다.
+// 트리는 패키지 전역 currentTree 하나를 모든 요청이 함께 쓴다. 읽기 요청은 잠금을 잡지 않는다.
+func NewServeMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", handleIndex)
+	mux.HandleFunc("/api/state", handleState)
+	mux.HandleFunc("/api/create", handleCreate)
+	mux.HandleFunc("/api/insert", handleInsert)
+	mux.HandleFunc("/api/delete", handleDelete)
+	mux.HandleFunc("/api/seed", handleSeed)
+	mux.HandleFunc("/api/search", handleSearch)
+	mux.HandleFunc("/api/histogram", handleHistogram)
+	mux.HandleFunc("/api/node", handleNode)
+	mux.HandleFunc("/api/subtree", handleSubtree)
+	mux.HandleFunc("/api/heatmap", handleHeatmap)
+	return mux
+}
+
+// 서버 타임아웃. 느린 클라이언트나 큰 요청이 연결과 트리 잠금을 붙잡고 있지 않도록 한다.
+// handlerTimeout 을 넘긴 요청은 503 을 받고 요청 context 가 취소된다.
+// writeTimeout 은 TimeoutHandler 가 503 을 쓸 수 있도록 handlerTimeout 보다 길어야 한다.
+const (
+	readTimeout     = 10 * time.Second
+	writeTimeout    = 30 * time.Second
+	idleTimeout     = 60 * time.Second
+	handlerTimeout  = 20 * time.Second
+	maxRequestBytes = 1 << 20
+	shutdownTimeout = 5 * time.Second
+)
+
+// ListenAndServe 는 addr 에서 교육용 서버를 띄운다. 서버가 멈출 때까지 돌아오지 않는다.
+func ListenAndServe(addr string) error {
+	return ListenAndServeContext(context.Background(), addr)
+}
+
+// ListenAndServeContext 는 ctx 가 취소되면 처리 중인 요청을 shutdownTimeout 동안 기다린 뒤 멈추는 ListenAndServe.
+// 그렇게 멈추면 nil 을 돌려준다. 모든 요청은 X-Request-ID 를 받고 요청 로그를 남긴다 (requestid.go).
+func ListenAndServeContext(ctx context.Context, addr string) error {
+	handler := withRequestID(http.TimeoutHandler(http.MaxBytesHandler(NewServeMux(), maxRequestBytes), handlerTimeout, "request timed out\n"))
+	srv := &http.Server{
+		Addr:         addr,
+		Handler:      handler,
+		ReadTimeout:  readTimeout,
+		WriteTimeout: writeTimeout,
+		IdleTimeout:  idleTimeout,
+		ErrorLog:     slog.NewLogLogger(slog.Default().Handler(), slog.LevelError),
+	}
+
+	slog.Info("B-Tree tutorial server listening", "addr", addr)
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
+
+func handleIndex(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, indexHTML)
+}
+
+// handleState 는 GET /api/state?depth=N&values=B 로 트리 모양을 돌려준다 (values 를 주면 값도 앞 B 바이트까지). ETag 와 gzip 을 지원한다 (etag.go).
+func handleState(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	view, ok := parseStateView(w, r)
+	if !ok {
+		return
+	}
+	_, span := startSpan(r.Context(), "btree.state", slog.Int("depth", view.depth))
+	state, err := encodeState(currentTree.Load(), view)
+	span.End(err)
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	state.write(w, r)
+}
+
+func handleCreate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+	view, ok := parseStateView(w, r)
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		T int `json:"t"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "JSON 데이터를 해석할 수 없습니다.")
+		return
+	}
+
+	if payload.T < 2 {
+		writeError(w, http.StatusBadRequest, "차수 t 는 2 이상이어야 합니다.")
+		return
+	}
+
+	_, span := startSpan(r.Context(), "btree.create", slog.Int("t", payload.T))
+	tree := &BTree{t: payload.T}
+	SetTree(tree)
+	accesses.reset()
+	state := snapshotState(tree, view)
+	span.End(nil)
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "새로운 B-Tree 인스턴스를 만들었습니다.",
+		"state":   state,
+	})
+}
+
+func handleInsert(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+	view, ok := parseStateView(w, r)
+	if !ok {
+		return
+	}
+
+	// value 는 키다. 키에 붙일 값은 data 로 받고, 없거나 빈 문자열이면 값 없이 넣는다.
+	var payload struct {
+		Value int    `json:"value"`
+		Data  string `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "JSON 데이터를 해석할 수 없습니다.")
+		return
+	}
+	var data []byte
+	if payload.Data != "" {
+		data = []byte(payload.Data)
+	}
+
+	_, span := startSpan(r.Context(), "btree.insert", slog.Int("key", payload.Value))
+	treeMu.Lock()
+	tree := currentTree.Load()
+	if tree == nil {
+		treeMu.Unlock()
+		span.End(dberr.ErrTreeNotInitialized)
+		writeErr(w, dberr.ErrTreeNotInitialized)
+		return
+	}
+
+	// 이미 있는 키면 트리 모양은 그대로 두고 값만 바뀐다 (trace.Found).
+	tree, trace := tree.InsertCopyTrace(payload.Value, data)
+	currentTree.Store(tree)
+	treeMu.Unlock()
+	accesses.record(trace.Path)
+	state := snapshotState(tree, view)
+	span.End(nil)
+
+	message := fmt.Sprintf("%d 값을 삽입했습니다.", payload.Value)
+	if trace.Found {
+		message = fmt.Sprintf("%d 값은 이미 있어서 그 키의 데이터를 바꿨습니다.", payload.Value)
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"message":  message,
+		"replaced": trace.Found,
+		"state":    state,
+		"trace":    trace,
+	})
+}
+
+// handleDelete 는 POST /api/delete {"value": k} 로 k 하나를 지운다. 같은 키가 여럿이면 하나만 지운다.
+// 없는 키는 트리를 그대로 두고 dberr.ErrKeyNotFound (404) 다.
+// 응답의 trace 에는 내려간 길과 borrow / merge / replace / shrink 가 차례로 담긴다.
+func handleDelete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+	view, ok := parseStateView(w, r)
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		Value int `json:"value"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "JSON 데이터를 해석할 수 없습니다.")
+		return
+	}
+
+	_, span := startSpan(r.Context(), "btree.delete", slog.Int("key", payload.Value))
+	treeMu.Lock()
+	tree := currentTree.Load()
+	if tree == nil {
+		treeMu.Unlock()
+		span.End(dberr.ErrTreeNotInitialized)
+		writeErr(w, dberr.ErrTreeNotInitialized)
+		return
+	}
+
+	tree, trace := tree.DeleteCopyTrace(payload.Value)
+	if !trace.Found {
+		treeMu.Unlock()
+		err := fmt.Errorf("delete %d: %w", payload.Value, dberr.ErrKeyNotFound)
+		span.End(err)
+		writeErr(w, err)
+		return
+	}
+	currentTree.Store(tree)
+	treeMu.Unlock()
+	accesses.record(trace.Path)
+	state := snapshotState(tree, view)
+	span.End(nil)
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"message": fmt.Sprintf("%d 값을 삭제했습니다.", payload.Value),
+		"state":   state,
+		"trace":   trace,
+	})
+}
+
+// 한 번의 /api/seed 로 넣을 수 있는 키 수와 기본 키 범위
+const (
+	maxSeedCount    = 10000
+	defaultSeedKeys = 100
+)
+
+// handleSeed 는 POST /api/seed {"count": N, "keys": K, "dist": "uniform", "seed": S} 로
+// workload 가 [0, K) 에서 뽑은 키 N 개를 지금 트리에 차례로 넣는다.
+// seed 가 없거나 0 이면 서버가 무작위로 골라서, 어느 쪽이든 응답의 seed 로 돌려준다.
+// 같은 차수의 빈 트리에 같은 요청(같은 seed)을 다시 보내면 같은 트리가 나온다.
+// 이미 있는 키는 트리에 더 늘지 않으므로 새로 늘어난 키 수는 응답의 added 로 알려 준다.
+func handleSeed(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+	view, ok := parseStateView(w, r)
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		Count int    `json:"count"`
+		Keys  int    `json:"keys"`
+		Dist  string `json:"dist"`
+		Seed  int64  `json:"seed"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "JSON 데이터를 해석할 수 없습니다.")
+		return
+	}
+	if payload.Count < 1 || payload.Count > maxSeedCount {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("count 는 1 이상 %d 이하여야 합니다.", maxSeedCount))
+		return
+	}
+	if payload.Keys == 0 {
+		payload.Keys = defaultSeedKeys
+	}
+	if payload.Dist == "" {
+		payload.Dist = workload.Uniform.String()
+	}
+	dist, err := workload.ParseDistribution(payload.Dist)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "dist 는 sequential, uniform, zipfian 중 하나여야 합니다.")
+		return
+	}
+	if payload.Seed == 0 {
+		payload.Seed = workload.RandomSeed()
+	}
+	gen, err := workload.New(workload.Config{Distribution: dist, KeySpace: payload.Keys, Seed: payload.Seed})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "keys 는 1 이상이어야 합니다.")
+		return
+	}
+
+	_, span := startSpan(r.Context(), "btree.seed", slog.Int("count", payload.Count), slog.Int64("seed", payload.Seed))
+	treeMu.Lock()
+	tree := currentTree.Load()
+	if tree == nil {
+		treeMu.Unlock()
+		span.End(dberr.ErrTreeNotInitialized)
+		writeErr(w, dberr.ErrTreeNotInitialized)
+		return
+	}
+	keys := gen.Keys(payload.Count)
+	added := 0
+	for _, k := range keys {
+		var trace *Trace
+		tree, trace = tree.InsertCopyTrace(int(k), nil)
+		accesses.record(trace.Path)
+		if !trace.Found {
+			added++
+		}
+	}
+	currentTree.Store(tree)
+	treeMu.Unlock()
+	state := snapshotState(tree, view)
+	span.End(nil)
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"message": fmt.Sprintf("%s 분포에서 뽑은 값 %d개를 삽입했습니다 (seed %d, 새 키 %d개).", dist, payload.Count, payload.Seed, added),
+		"added":   added,
+		"seed":    payload.Seed,
+		"dist":    dist.String(),
+		"keys":    keys,
+		"state":   state,
+	})
+}
+
+func handleSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+	view, ok := parseStateView(w, r)
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		Value int `json:"value"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "JSON 데이터를 해석할 수 없습니다.")
+		return
+	}
+
+	_, span := startSpan(r.Context(), "btree.search", slog.Int("key", payload.Value))
+	tree := currentTree.Load()
+	if tree == nil {
+		span.End(dberr.ErrTreeNotInitialized)
+		writeErr(w, dberr.ErrTreeNotInitialized)
+		return
+	}
+
+	trace, value := tree.searchValueTrace(payload.Value)
+	accesses.record(trace.Path)
+	state := snapshotState(tree, view)
+	span.End(nil)
+
+	body := map[string]interface{}{
+		"message": fmt.Sprintf("%d 값을 탐색했습니다.", payload.Value),
+		"found":   trace.Found,
+		"path":    trace.Path,
+		"state":   state,
+		"trace":   trace,
+	}
+	// 찾은 키에 값이 있으면 같이 보낸다. 큰 값은 searchValueLimit 바이트까지만 보여 준다.
+	if value != nil {
+		body["data"] = formatValue(value, searchValueLimit)
+	}
+	respondJSON(w, http.StatusOK, body)
+}
+
+// 검색 응답에 싣는 값의 최대 바이트 수
+const searchValueLimit = 1 << 10
+
+// handleHistogram 은 GET /api/histogram?buckets=N 으로 키 분포의 equi-depth 히스토그램을 돌려준다 (기본 10 구간).
+func handleHistogram(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	buckets := 10
+	if s := r.URL.Query().Get("buckets"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "buckets 는 1 이상의 정수여야 합니다.")
+			return
+		}
+		buckets = n
+	}
+
+	_, span := startSpan(r.Context(), "btree.histogram", slog.Int("buckets", buckets))
+	tree := currentTree.Load()
+	if tree == nil {
+		span.End(dberr.ErrTreeNotInitialized)
+		writeErr(w, dberr.ErrTreeNotInitialized)
+		return
+	}
+	hist := tree.Histogram(buckets)
+	span.End(nil)
+	respondJSON(w, http.StatusOK, hist)
+}
+
+// handleNode 는 GET /api/node?path=root-1-0 으로 노드 하나의 키, 자식 수, 잎 여부를 돌려준다 (기본 root).
+// 큰 트리에서 UI 가 전체 상태 대신 펼친 노드만 하나씩 가져올 때 쓴다.
+func handleNode(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	label := r.URL.Query().Get("path")
+	if label == "" {
+		label = "root"
+	}
+	if _, err := ParseLabel(label); err != nil {
+		writeError(w, http.StatusBadRequest, "path 는 root 나 root-1-0 같은 노드 이름이어야 합니다.")
+		return
+	}
+
+	_, span := startSpan(r.Context(), "btree.node", slog.String("path", label))
+	tree := currentTree.Load()
+	if tree == nil {
+		span.End(dberr.ErrTreeNotInitialized)
+		writeErr(w, dberr.ErrTreeNotInitialized)
+		return
+	}
+	node, err := tree.Node(label)
+	span.End(err)
+	if err != nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("%s 노드가 없습니다.", label))
+		return
+	}
+	respondJSON(w, http.StatusOK, node)
+}
+
+// handleSubtree 는 GET /api/subtree?path=root-1&depth=N 으로 그 노드 아래를 N 층까지 돌려준다 (depth 가 없으면 전부).
+// 깊이 제한으로 접혀 온 노드(Truncated)를 펼칠 때 쓴다. 노드 이름은 전체 트리 기준 그대로다.
+func handleSubtree(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	label := r.URL.Query().Get("path")
+	if label == "" {
+		label = "root"
+	}
+	if _, err := ParseLabel(label); err != nil {
+		writeError(w, http.StatusBadRequest, "path 는 root 나 root-1-0 같은 노드 이름이어야 합니다.")
+		return
+	}
+	view, ok := parseStateView(w, r)
+	if !ok {
+		return
+	}
+
+	_, span := startSpan(r.Context(), "btree.subtree", slog.String("path", label), slog.Int("depth", view.depth))
+	tree := currentTree.Load()
+	if tree == nil {
+		span.End(dberr.ErrTreeNotInitialized)
+		writeErr(w, dberr.ErrTreeNotInitialized)
+		return
+	}
+	node, err := tree.nodeAt(label)
+	if err != nil {
+		span.End(err)
+		writeError(w, http.StatusNotFound, fmt.Sprintf("%s 노드가 없습니다.", label))
+		return
+	}
+	subtree := buildVisualNode(node, label, view)
+	span.End(nil)
+	respondJSON(w, http.StatusOK, subtree)
+}
+
+// stateView 는 응답에 트리 모양을 어떻게 실을지. depth 층까지 (0 이면 전부) 싣고,
+// values 가 0 보다 크면 키마다 값도 앞 values 바이트까지 싣는다.
+type stateView struct {
+	depth  int
+	values int
+}
+
+// parseStateView 는 쿼리의 depth 와 values 를 읽는다. 없으면 0 (전부 / 값 없음) 이고, 잘못된 값이면 400 을 쓰고 false.
+// POST 요청(create / insert / delete / search)도 응답에 상태를 실으므로 같은 쿼리를 받는다.
+func parseStateView(w http.ResponseWriter, r *http.Request) (stateView, bool) {
+	var view stateView
+	for _, q := range []struct {
+		name string
+		dst  *int
+	}{{"depth", &view.depth}, {"values", &view.values}} {
+		s := r.URL.Query().Get(q.name)
+		if s == "" {
+			continue
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, q.name+" 는 0 이상의 정수여야 합니다.")
+			return stateView{}, false
+		}
+		*q.dst = n
+	}
+	return view, true
+}
+
+// snapshotState 는 tree (currentTree 에서 꺼낸 것, 없으면 nil) 의 화면용 모양을 view.depth 층까지 (0 이면 전부) 만든다.
+// 내보낸 트리는 바뀌지 않으므로 잠금이 필요 없다. 키가 수만 개인 트리는 전부 실으면 응답이 커지므로 UI 는 깊이를 준다.
+func snapshotState(tree *BTree, view stateView) statePayload {
+	if tree == nil {
+		return statePayload{HasTree: false}
+	}
+
+	var root *VisualNode
+	if tree.root != nil {
+		root = buildVisualNode(tree.root, "root", view)
+	}
+
+	return statePayload{
+		HasTree: true,
+		T:       tree.t,
+		Depth:   view.depth,
+		Tree:    root,
+	}
+}
+
+// buildVisualNode 는 node 부터 view.depth 층을 만든다. depth 가 1 이면 node 만 싣고 자식은 ChildCount 로만 남긴다.
+func buildVisualNode(node *BTreeNode, path string, view stateView) *VisualNode {
+	if node == nil {
+		return nil
+	}
+
+	snapshot := &VisualNode{
+		Path:       path,
+		Keys:       append([]int(nil), node.keys...),
+		IsLeaf:     node.isLeaf,
+		ChildCount: len(node.children),
+	}
+	// 값이 하나도 없는 노드(값 없이 넣은 키뿐)는 빈 문자열을 늘어놓지 않고 Values 를 뺀다.
+	if view.values > 0 && slices.ContainsFunc(node.values, func(v []byte) bool { return v != nil }) {
+		snapshot.Values = make([]string, len(node.values))
+		for i, v := range node.values {
+			snapshot.Values[i] = formatValue(v, view.values)
+		}
+	}
+
+	if len(node.children) > 0 {
+		if view.depth == 1 {
+			snapshot.Truncated = true
+			return snapshot
+		}
+		child := view
+		child.depth = max(view.depth-1, 0)
+		snapshot.Children = make([]*VisualNode, len(node.children))
+		for i, c := range node.children {
+			snapshot.Children[i] = buildVisualNode(c, fmt.Sprintf("%s-%d", path, i), child)
+		}
+	}
+
+	return snapshot
+}
+
+// formatValue 는 값을 화면에 보여 줄 문자열로 만든다. UTF-8 이면 그대로, 아니면 0x 로 시작하는 16 진수다.
+// limit 바이트보다 길면 거기서 자르고 "… (원래 크기 B)" 를 붙인다. 자른 자리가 글자 중간이면 그 글자는 뺀다.
+func formatValue(v []byte, limit int) string {
+	cut := v
+	if len(cut) > limit {
+		cut = cut[:limit]
+	}
+	var s string
+	if utf8.Valid(v) {
+		s = strings.ToValidUTF8(string(cut), "")
+	} else {
+		s = "0x" + hex.EncodeToString(cut)
+	}
+	if len(cut) < len(v) {
+		s += fmt.Sprintf("… (%d B)", len(v))
+	}
+	return s
+}
+
+func methodNotAllowed(w http.ResponseWriter, method string) {
+	w.Header().Set("Allow", method)
+	writeError(w, http.StatusMethodNotAllowed, "지원하지 않는 HTTP 메서드입니다.")
+}
+
+func respondJSON(w http.ResponseWriter, status int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if payload != nil {
+		_ = json.NewEncoder(w).Encode(payload)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	respondJSON(w, status, map[string]string{"error": message})
+}
+
+// 화면에 보여 줄 에러 문구. 없는 종류는 err.Error() 를 그대로 쓴다.
+var errorMessages = map[error]string{
+	dberr.ErrTreeNotInitialized: "먼저 B-Tree 를 생성하세요.",
+	dberr.ErrKeyNotFound:        "값을 찾을 수 없습니다.",
+}
+
+// writeErr 는 err 의 종류(dberr)에 맞는 상태 코드로 응답한다. 클라이언트가 나눌 수 있도록 종류도 "kind" 로 보낸다.
+func writeErr(w http.ResponseWriter, err error) {
+	kind := dberr.Kind(err)
+	message, ok := errorMessages[kind]
+	if !ok {
+		message = err.Error()
+	}
+	body := map[string]string{"error": message}
+	if kind != nil {
+		body["kind"] = kind.Error()
+	}
+	respondJSON(w, dberr.HTTPStatus(err), body)
+}
+
+const indexHTML = `<!DOCTYPE html>
+<html lang="ko">
+<head>
+<meta charset="UTF-8" />
+<meta name="viewport" content="width=device-width, initial-scale=1" />
+<title>B-Tree 시각화 튜토리얼</title>
+<style>
+:root {
+    font-family: 'Segoe UI', system-ui, -apple-system, BlinkMacSystemFont, sans-serif;
+    color: #111827;
+    background: #f9fafb;
+}
+body {
+    margin: 0;
+    background: #eef2ff;
+}
+main {
+    max-width: 1200px;
+    margin: 0 auto;
+    padding: 2rem 1.5rem 4rem;
+}
+h1 {
+    font-size: 2rem;
+    margin-bottom: 0.25rem;
+}
+p.lead {
+    margin-top: 0;
+    color: #4b5563;
+}
+.panel {
+    background: #fff;
+    border-radius: 16px;
+    padding: 1.5rem;
+    margin-top: 1.5rem;
+    box-shadow: 0 10px 30px rgba(15, 23, 42, 0.08);
+}
+.panel h2 {
+    margin-top: 0;
+}
+form {
+    display: flex;
+    flex-wrap: wrap;
+    gap: 0.75rem;
+    margin-bottom: 0.5rem;
+}
+input[type="number"] {
+    flex: 1;
+    min-width: 120px;
+    padding: 0.65rem 0.75rem;
+    border: 1px solid #c7d2fe;
+    border-radius: 10px;
+    font-size: 1rem;
+}
+button {
+    border: none;
+    border-radius: 10px;
+    padding: 0.65rem 1.5rem;
+    background: #4f46e5;
+    color: #fff;
+    font-weight: 600;
+    cursor: pointer;
+}
+button:disabled,
+input:disabled {
+    opacity: 0.6;
+    cursor: not-allowed;
+}
+.status {
+    font-size: 0.95rem;
+    color: #2563eb;
+    min-height: 1.25rem;
+}
+.tree-container {
+    margin-top: 1rem;
+    display: flex;
+    justify-content: center;
+    flex-wrap: wrap;
+}
+.node {
+    display: inline-flex;
+    flex-direction: column;
+    align-items: center;
+    border: 2px solid #4f46e5;
+    border-radius: 12px;
+    padding: 0.75rem;
+    margin: 0.75rem;
+    background: #fff;
+    min-width: 80px;
+    box-shadow: 0 6px 16px rgba(79, 70, 229, 0.1);
+}
+.node .keys {
+    display: flex;
+    gap: 0.4rem;
+}
+.node .keys span {
+    background: #e0e7ff;
+    border-radius: 8px;
+    padding: 0.35rem 0.75rem;
+    border: 1px solid #a5b4fc;
+}
+.children {
+    display: flex;
+    justify-content: center;
+    flex-wrap: wrap;
+    margin-top: 0.5rem;
+}
+.node button.expand {
+    margin-top: 0.5rem;
+    padding: 0.3rem 0.75rem;
+    font-size: 0.85rem;
+    font-weight: 500;
+    background: #e0e7ff;
+    color: #3730a3;
+}
+.placeholder {
+    text-align: center;
+    color: #6b7280;
+}
+.highlight {
+    border-color: #f97316 !important;
+    box-shadow: 0 0 0 3px rgba(249, 115, 22, 0.3);
+}
+ol#search-trace {
+    padding-left: 1.25rem;
+    color: #334155;
+}
+ol#search-trace li.result {
+    margin-top: 0.5rem;
+    font-weight: 600;
+}
+</style>
+</head>
+<body>
+<main>
+    <h1>B-Tree 삽입, 삭제 & 탐색 시각화</h1>
+    <p class="lead">차수(t)를 설정해 트리를 만든 뒤 값을 삽입, 삭제하거나 탐색해 보세요. 서버에서 실제 B-Tree 메서드가 실행되고, 그 결과를 아래에서 시각화합니다.</p>
+
+    <section class="panel">
+        <h2>1. B-Tree 생성</h2>
+        <form id="create-form">
+            <input id="degree-input" type="number" min="2" placeholder="차수 t (2 이상)" required />
+            <button type="submit">생성</button>
+        </form>
+        <p class="status" id="create-status"></p>
+    </section>
+
+    <section class="panel">
+        <h2>2. 삽입, 삭제 & 탐색</h2>
+        <form id="insert-form">
+            <input id="insert-input" type="number" placeholder="삽입할 키" required />
+            <input id="insert-data-input" type="text" placeholder="키에 붙일 값 (선택)" />
+            <button type="submit">삽입</button>
+        </form>
+        <form id="delete-form">
+            <input id="delete-input" type="number" placeholder="삭제할 값" required />
+            <button type="submit">삭제</button>
+        </form>
+        <form id="search-form">
+            <input id="search-input" type="number" placeholder="탐색할 값" required />
+            <button type="submit">탐색</button>
+        </form>
+        <form id="seed-form">
+            <input id="seed-count-input" type="number" min="1" max="10000" placeholder="무작위로 넣을 개수" required />
+            <input id="seed-input" type="number" placeholder="seed (비우면 무작위)" />
+            <button type="submit">무작위 삽입</button>
+        </form>
+        <p class="status" id="action-status"></p>
+    </section>
+
+    <section class="panel">
+        <h2>3. 현재 트리 상태</h2>
+        <p id="tree-state">아직 트리가 없습니다. 먼저 차수를 입력해 생성하세요.</p>
+        <form id="heatmap-form">
+            <input id="heatmap-input" type="number" min="1" placeholder="최근 연산 수 (비우면 전부)" />
+            <button type="submit">접근 히트맵</button>
+        </form>
+        <p class="status" id="heatmap-status"></p>
+        <div class="tree-container" id="tree-container">
+            <div class="placeholder">시각화 할 노드가 없습니다.</div>
+        </div>
+    </section>
+
+    <section class="panel">
+        <h2>4. 탐색 경로</h2>
+        <ol id="search-trace">
+            <li>아직 탐색 기록이 없습니다.</li>
+        </ol>
+    </section>
+</main>
+<script>
+const createForm = document.getElementById('create-form');
+const insertForm = document.getElementById('insert-form');
+const deleteForm = document.getElementById('delete-form');
+const searchForm = document.getElementById('search-form');
+const seedForm = document.getElementById('seed-form');
+const heatmapForm = document.getElementById('heatmap-form');
+const heatmapStatus = document.getElementById('heatmap-status');
+const createStatus = document.getElementById('create-status');
+const actionStatus = document.getElementById('action-status');
+const treeContainer = document.getElementById('tree-container');
+const treeState = document.getElementById('tree-state');
+const traceList = document.getElementById('search-trace');
+let currentTree = null;
+let highlightedPaths = [];
+// 한 번에 받아 올 트리 층 수. 더 깊은 노드는 접힌 채로 오고, 펼치기 버튼으로 /api/subtree 에서 가져온다.
+const STATE_DEPTH = 4;
+// 키에 마우스를 올리면 보여 줄 값의 최대 바이트 수
+const VALUE_PREVIEW = 64;
+const VIEW_QUERY = 'depth=' + STATE_DEPTH + '&values=' + VALUE_PREVIEW;
+toggleControls(false);
+
+async function request(url, options = {}) {
+    const response = await fetch(url, {
+        headers: { 'Content-Type': 'application/json' },
+        ...options,
+    });
+    const data = await response.json().catch(() => ({}));
+    if (!response.ok) {
+        throw data;
+    }
+    return data;
+}
+
+function applyState(state) {
+    const hasTree = state.hasTree;
+    currentTree = state.tree || null;
+    treeState.textContent = hasTree
+        ? '차수 t = ' + state.t + (currentTree ? ' / 노드 수: ' + countNodes(currentTree) : ' (아직 요소 없음)')
+        : '아직 트리가 없습니다. 먼저 차수를 입력해 생성하세요.';
+    renderTree(currentTree);
+    toggleControls(hasTree);
+}
+
+function toggleControls(enabled) {
+    ['insert-input', 'insert-data-input', 'delete-input', 'search-input', 'seed-count-input', 'seed-input', 'heatmap-input'].forEach(id => {
+        const el = document.getElementById(id);
+        el.disabled = !enabled;
+    });
+    insertForm.querySelector('button').disabled = !enabled;
+    deleteForm.querySelector('button').disabled = !enabled;
+    searchForm.querySelector('button').disabled = !enabled;
+    seedForm.querySelector('button').disabled = !enabled;
+    heatmapForm.querySelector('button').disabled = !enabled;
+}
+
+function countNodes(node) {
+    if (!node) return 0;
+    let total = 1;
+    if (node.children) {
+        node.children.forEach(child => {
+            total += countNodes(child);
+        });
+    }
+    return total;
+}
+
+function renderTree(node) {
+    treeContainer.innerHTML = '';
+    if (!node) {
+        treeContainer.innerHTML = '<div class="placeholder">시각화 할 노드가 없습니다.</div>';
+        return;
+    }
+    treeContainer.appendChild(buildNodeElement(node));
+}
+
+function buildNodeElement(node) {
+    const wrapper = document.createElement('div');
+    wrapper.className = 'node';
+    wrapper.dataset.path = node.path;
+
+    const keysRow = document.createElement('div');
+    keysRow.className = 'keys';
+    node.keys.forEach((key, i) => {
+        const span = document.createElement('span');
+        span.textContent = key;
+        if (node.values && node.values[i]) {
+            span.title = node.values[i];
+            span.style.textDecoration = 'underline dotted';
+        }
+        keysRow.appendChild(span);
+    });
+    if (!node.keys.length) {
+        const span = document.createElement('span');
+        span.textContent = '∅';
+        keysRow.appendChild(span);
+    }
+    wrapper.appendChild(keysRow);
+
+    if (node.truncated) {
+        const expand = document.createElement('button');
+        expand.className = 'expand';
+        expand.type = 'button';
+        expand.textContent = '+ 자식 ' + node.childCount + '개 펼치기';
+        expand.addEventListener('click', () => expandNode(node.path));
+        wrapper.appendChild(expand);
+    }
+
+    if (node.children && node.children.length) {
+        const childrenRow = document.createElement('div');
+        childrenRow.className = 'children';
+        node.children.forEach(child => {
+            childrenRow.appendChild(buildNodeElement(child));
+        });
+        wrapper.appendChild(childrenRow);
+    }
+
+    return wrapper;
+}
+
+// 서버의 Path (단계마다 nodeIndex / keyIndex / direction) 를 노드 이름("root-1-0")으로 바꾼다.
+function pathLabels(path) {
+    const labels = [];
+    (path || []).forEach((step, idx) => {
+        labels.push(idx === 0 ? 'root' : labels[idx - 1] + '-' + step.nodeIndex);
+    });
+    return labels;
+}
+
+// 접힌 노드 아래를 STATE_DEPTH 층 더 받아 와서 그 자리에 붙인다.
+async function expandNode(path) {
+    try {
+        const subtree = await request('/api/subtree?path=' + encodeURIComponent(path) + '&' + VIEW_QUERY);
+        const node = getNodeByPath(path);
+        if (!node) return;
+        Object.assign(node, subtree);
+        renderTree(currentTree);
+        highlightPath(highlightedPaths);
+    } catch (err) {
+        actionStatus.textContent = err.error || '노드를 펼치지 못했습니다.';
+    }
+}
+
+function highlightPath(paths) {
+    highlightedPaths.forEach(path => {
+        const el = treeContainer.querySelector('[data-path="' + path + '"]');
+        if (el) {
+            el.classList.remove('highlight');
+        }
+    });
+    highlightedPaths = paths || [];
+    highlightedPaths.forEach(path => {
+        const el = treeContainer.querySelector('[data-path="' + path + '"]');
+        if (el) {
+            el.classList.add('highlight');
+        }
+    });
+}
+
+function renderTrace(paths, found) {
+    traceList.innerHTML = '';
+    if (!paths || !paths.length) {
+        traceList.innerHTML = '<li>아직 탐색 기록이 없습니다.</li>';
+        return;
+    }
+
+    paths.forEach((path, idx) => {
+        const li = document.createElement('li');
+        const node = getNodeByPath(path);
+        const keys = node ? node.keys.join(', ') : path + ' (접힌 노드)';
+        li.textContent = '단계 ' + (idx + 1) + ': [' + keys + ']';
+        traceList.appendChild(li);
+    });
+
+    const result = document.createElement('li');
+    result.className = 'result';
+    result.textContent = found ? '✅ 값을 찾았습니다!' : '❌ 해당 값은 트리에 없습니다.';
+    traceList.appendChild(result);
+}
+
+// 삭제 트레이스에서 트리 모양을 바꾼 단계만 골라 적는다. 노드 이름은 그 단계 직전의 트리 기준이다.
+function renderDeleteTrace(trace) {
+    traceList.innerHTML = '';
+    const side = result => result < 0 ? '왼쪽' : '오른쪽';
+    (trace.events || []).forEach(e => {
+        let text;
+        switch (e.kind) {
+        case 'borrow':
+            text = e.node + ' 의 자식 ' + e.index + ' 이 ' + side(e.result) + ' 형제에게서 키를 빌림 (' + e.key + ' 이 부모로 올라감)';
+            break;
+        case 'merge':
+            text = e.node + ' 의 자식 ' + e.index + ', ' + (e.index + 1) + ' 을 ' + e.key + ' 를 가운데 두고 합침';
+            break;
+        case 'delete':
+            text = '잎 ' + e.node + ' 에서 ' + e.key + ' 를 뺌';
+            break;
+        case 'replace':
+            text = e.node + ' 의 keys[' + e.index + '] 를 ' + (e.result < 0 ? 'predecessor' : 'successor') + ' ' + e.key + ' 로 바꿈';
+            break;
+        case 'shrink':
+            text = '루트가 비어서 [' + e.keys.join(', ') + '] 가 새 루트가 됨 (높이 -1)';
+            break;
+        default:
+            return;
+        }
+        const li = document.createElement('li');
+        li.textContent = text;
+        traceList.appendChild(li);
+    });
+    const result = document.createElement('li');
+    result.className = 'result';
+    result.textContent = '✅ ' + trace.key + ' 값을 삭제했습니다.';
+    traceList.appendChild(result);
+}
+
+function getNodeByPath(path) {
+    if (!currentTree || !path) return null;
+    const segments = path.split('-').slice(1);
+    let node = currentTree;
+    for (const segment of segments) {
+        const idx = Number(segment);
+        if (!node.children || !node.children[idx]) {
+            return null;
+        }
+        node = node.children[idx];
+    }
+    return node;
+}
+
+createForm.addEventListener('submit', async (event) => {
+    event.preventDefault();
+    const t = Number(document.getElementById('degree-input').value);
+    try {
+        const data = await request('/api/create?' + VIEW_QUERY, {
+            method: 'POST',
+            body: JSON.stringify({ t })
+        });
+        createStatus.textContent = data.message;
+        applyState(data.state);
+        highlightPath([]);
+        renderTrace([], false);
+    } catch (err) {
+        createStatus.textContent = err.error || '생성에 실패했습니다.';
+    }
+});
+
+insertForm.addEventListener('submit', async (event) => {
+    event.preventDefault();
+    const value = Number(document.getElementById('insert-input').value);
+    const payload = document.getElementById('insert-data-input').value;
+    try {
+        const data = await request('/api/insert?' + VIEW_QUERY, {
+            method: 'POST',
+            body: JSON.stringify({ value, data: payload })
+        });
+        actionStatus.textContent = data.message;
+        applyState(data.state);
+        document.getElementById('insert-input').value = '';
+        document.getElementById('insert-data-input').value = '';
+        highlightPath([]);
+        renderTrace([], false);
+    } catch (err) {
+        actionStatus.textContent = err.error || '삽입에 실패했습니다.';
+    }
+});
+
+deleteForm.addEventListener('submit', async (event) => {
+    event.preventDefault();
+    const value = Number(document.getElementById('delete-input').value);
+    try {
+        const data = await request('/api/delete?' + VIEW_QUERY, {
+            method: 'POST',
+            body: JSON.stringify({ value })
+        });
+        actionStatus.textContent = data.message;
+        applyState(data.state);
+        document.getElementById('delete-input').value = '';
+        highlightPath([]);
+        renderDeleteTrace(data.trace);
+    } catch (err) {
+        actionStatus.textContent = err.error || '삭제에 실패했습니다.';
+    }
+});
+
+searchForm.addEventListener('submit', async (event) => {
+    event.preventDefault();
+    const value = Number(document.getElementById('search-input').value);
+    try {
+        const data = await request('/api/search?' + VIEW_QUERY, {
+            method: 'POST',
+            body: JSON.stringify({ value })
+        });
+        actionStatus.textContent = data.data === undefined ? data.message : data.message + ' 값: ' + data.data;
+        applyState(data.state);
+        const labels = pathLabels(data.path);
+        highlightPath(labels);
+        renderTrace(labels, data.found);
+    } catch (err) {
+        actionStatus.textContent = err.error || '탐색에 실패했습니다.';
+    }
+});
+
+// 서버가 쓴 seed 를 입력칸에 남겨 두어, 같은 차수의 빈 트리에서 다시 누르면 같은 트리를 만들 수 있게 한다.
+seedForm.addEventListener('submit', async (event) => {
+    event.preventDefault();
+    const count = Number(document.getElementById('seed-count-input').value);
+    const seed = Number(document.getElementById('seed-input').value) || 0;
+    try {
+        const data = await request('/api/seed?' + VIEW_QUERY, {
+            method: 'POST',
+            body: JSON.stringify({ count, seed })
+        });
+        actionStatus.textContent = data.message;
+        applyState(data.state);
+        document.getElementById('seed-input').value = data.seed;
+        highlightPath([]);
+        renderTrace([], false);
+    } catch (err) {
+        actionStatus.textContent = err.error || '무작위 삽입에 실패했습니다.';
+    }
+});
+
+// 노드의 키 줄을 방문 수에 비례한 진하기로 칠한다. 트리를 다시 그리면 지워진다.
+function renderHeatmap(heatmap) {
+    treeContainer.querySelectorAll('.node .keys').forEach(row => {
+        row.style.background = '';
+    });
+    heatmap.nodes.forEach(node => {
+        const el = treeContainer.querySelector('[data-path="' + node.path + '"] > .keys');
+        if (el) {
+            el.style.background = 'rgba(220, 38, 38, ' + (0.1 + 0.7 * node.count / heatmap.max).toFixed(2) + ')';
+        }
+    });
+}
+
+heatmapForm.addEventListener('submit', async (event) => {
+    event.preventDefault();
+    const last = document.getElementById('heatmap-input').value;
+    try {
+        const data = await request('/api/heatmap' + (last ? '?last=' + Number(last) : ''));
+        heatmapStatus.textContent = '최근 연산 ' + data.operations + '개 (최대 ' + data.window + '개 기억), 가장 많이 들른 노드 ' + data.max + '번';
+        renderHeatmap(data);
+    } catch (err) {
+        heatmapStatus.textContent = err.error || '히트맵을 가져오지 못했습니다.';
+    }
+});
+
+(async function init() {
+    try {
+        const state = await fetch('/api/state?' + VIEW_QUERY).then(res => res.json());
+        applyState(state);
+    } catch (err) {
+        console.error('초기 상태 로드 실패', err);
+    }
+})();
+</script>
+</body>
+</html>`
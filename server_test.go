@@ -0,0 +1,52 @@
+package btree
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// POST /api/delete 는 있는 키를 지우고 200, 없는 키는 트리를 바꾸지 않고 404 와 kind 를 돌려준다.
+func TestHandleDelete(t *testing.T) {
+	tree, err := New(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k := 0; k < 20; k++ {
+		tree.Insert(k, nil)
+	}
+	SetTree(tree)
+	t.Cleanup(func() { SetTree(nil) })
+	mux := NewServeMux()
+
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/delete", strings.NewReader(body)))
+		return rec
+	}
+
+	if rec := post(`{"value": 7}`); rec.Code != http.StatusOK {
+		t.Fatalf("delete 7: status %d, body %s", rec.Code, rec.Body)
+	}
+	after := currentTree.Load()
+	if _, ok := after.Search(7); ok {
+		t.Fatal("key 7 still in the served tree")
+	}
+	if _, ok := tree.Search(7); !ok {
+		t.Fatal("delete changed the tree passed to SetTree")
+	}
+
+	rec := post(`{"value": 7}`)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("delete missing key: status %d, body %s", rec.Code, rec.Body)
+	}
+	var body struct{ Kind string }
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Kind == "" {
+		t.Fatalf("delete missing key: body %s, err %v", rec.Body, err)
+	}
+	if currentTree.Load() != after {
+		t.Fatal("deleting a missing key replaced the served tree")
+	}
+}
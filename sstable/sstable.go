@@ -0,0 +1,648 @@
+// Package sstable 은 한 번 쓰면 바뀌지 않는 정렬된 키-값 파일(SSTable) 형식이다.
+// LSM 챕터의 flush / 컴팩션 결과와 외부 정렬의 run 파일이 같은 형식을 쓴다.
+//
+//	[data block]...[data block][filter block][index block][footer]
+//
+// data block (BlockSize 쯤에서 끊는다)
+//
+//	entry  : shared(uvarint) | unshared(uvarint) | valueLen(uvarint) | kind(1) | key[shared:] | value
+//	trailer: restart(4) * n | n(4)
+//
+// kind 는 0 = 값, 1 = tombstone, 2 = 만료 시각이 있는 값. 2 이면 value 앞 8 바이트가 만료 시각(unix ns)이고 valueLen 에 들어간다.
+//
+// 키는 앞 entry 와 겹치는 앞부분(shared)을 빼고 적는다 (prefix compression).
+// RestartInterval 개마다 shared 를 0 으로 두고 그 위치를 restart 배열에 적어서, 블록 안에서도 이진 탐색할 수 있게 한다.
+//
+// index block
+//
+//	count(uvarint) | { lastKeyLen(uvarint) | lastKey | offset(uvarint) | size(uvarint) } * count | firstKeyLen(uvarint) | firstKey
+//	| filterOffset(uvarint) | filterSize(uvarint)
+//
+// filter block (WriterOptions.BloomBitsPerKey 가 0 보다 클 때만, index 바로 앞)
+//
+//	bloom.Filter.Encode() 결과. 모든 키(tombstone 포함)가 들어 있어서 Get 이 없는 키의 블록을 읽지 않고 넘긴다.
+//	filterSize 가 0 이거나 index 가 firstKey 에서 끝나면 필터가 없는 테이블이다.
+//
+// footer (고정 24 바이트)
+//
+//	indexOffset(8) | indexSize(4) | entryCount(8) | magic(4)
+//
+// 읽는 쪽은 footer 와 index 만 메모리에 올리고, Get 한 번에 data block 하나만 읽는다.
+package sstable
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync/atomic"
+
+	"github.com/tmdgusya/btree/bloom"
+	"github.com/tmdgusya/btree/codec"
+	"github.com/tmdgusya/btree/dberr"
+)
+
+var Endian = binary.BigEndian
+
+var Magic = [4]byte{'S', 'S', 'T', 'B'}
+
+var ErrCorrupt = dberr.New(dberr.ErrCorruptPage, "sstable: corrupt table")
+var ErrOutOfOrder = errors.New("sstable: keys must be added in strictly increasing order")
+
+const (
+	DefaultBlockSize       = 4096
+	DefaultRestartInterval = 16
+
+	FooterSize = 8 + 4 + 8 + 4
+
+	kindPut         byte = 0
+	kindDelete      byte = 1
+	kindPutExpiring byte = 2
+)
+
+// Entry 는 키 하나. Deleted 는 LSM 의 tombstone 처럼 "이 키는 지워졌다" 를 남길 때 쓴다.
+// ExpiresAt 은 값이 만료되는 시각 (unix 나노초, 0 이면 만료 없음). 만료된 값을 걸러내는 것은 읽는 쪽의 몫이다.
+type Entry struct {
+	Key       []byte
+	Value     []byte
+	Deleted   bool
+	ExpiresAt int64
+}
+
+// ==================================
+// Writer
+// ==================================
+
+// WriterOptions 는 블록 모양. 0 이면 기본값을 쓴다.
+// BloomBitsPerKey 가 0 이면 필터를 쓰지 않는다 (bloom.DefaultBitsPerKey 면 false positive 약 1%).
+type WriterOptions struct {
+	BlockSize       int
+	RestartInterval int
+	BloomBitsPerKey int
+}
+
+type blockHandle struct {
+	lastKey []byte
+	offset  int64
+	size    int64
+}
+
+// Writer 는 키 오름차순으로 Add 된 entry 를 w 에 쓴다. 블록이 찰 때마다 블록 하나를 Write 한 번으로 내보낸다.
+type Writer struct {
+	w    io.Writer
+	opts WriterOptions
+
+	block    []byte
+	restarts []uint32
+	inBlock  int // 지금 블록의 entry 수
+
+	off      int64
+	count    int64
+	index    []blockHandle
+	first    []byte
+	last     []byte
+	hashes   []uint64 // 필터에 넣을 키 해시. 키 수를 알아야 필터 크기를 정하므로 Close 까지 모은다.
+	closed   bool
+	writeErr error
+}
+
+func NewWriter(w io.Writer, opts WriterOptions) *Writer {
+	if opts.BlockSize <= 0 {
+		opts.BlockSize = DefaultBlockSize
+	}
+	if opts.RestartInterval <= 0 {
+		opts.RestartInterval = DefaultRestartInterval
+	}
+	return &Writer{w: w, opts: opts}
+}
+
+// Add 는 key 의 값을 쓴다.
+func (w *Writer) Add(key, value []byte) error {
+	return w.add(Entry{Key: key, Value: value})
+}
+
+// Delete 는 key 의 tombstone 을 쓴다.
+func (w *Writer) Delete(key []byte) error {
+	return w.add(Entry{Key: key, Deleted: true})
+}
+
+// AddEntry 는 Entry 를 그대로 쓴다. 다른 테이블에서 읽은 것을 옮길 때 편하다.
+func (w *Writer) AddEntry(e Entry) error {
+	return w.add(e)
+}
+
+func (w *Writer) add(e Entry) error {
+	if w.writeErr != nil {
+		return w.writeErr
+	}
+	if w.closed {
+		return errors.New("sstable: writer is closed")
+	}
+	if w.count > 0 && bytes.Compare(e.Key, w.last) <= 0 {
+		return fmt.Errorf("%w: %q after %q", ErrOutOfOrder, e.Key, w.last)
+	}
+
+	shared := 0
+	if w.inBlock%w.opts.RestartInterval == 0 {
+		w.restarts = append(w.restarts, uint32(len(w.block)))
+	} else {
+		shared = sharedPrefix(w.last, e.Key)
+	}
+	kind, vlen := kindPut, len(e.Value)
+	switch {
+	case e.Deleted:
+		kind = kindDelete
+	case e.ExpiresAt != 0:
+		kind, vlen = kindPutExpiring, len(e.Value)+8
+	}
+	w.block = binary.AppendUvarint(w.block, uint64(shared))
+	w.block = binary.AppendUvarint(w.block, uint64(len(e.Key)-shared))
+	w.block = binary.AppendUvarint(w.block, uint64(vlen))
+	w.block = append(w.block, kind)
+	w.block = append(w.block, e.Key[shared:]...)
+	if kind == kindPutExpiring {
+		w.block = Endian.AppendUint64(w.block, uint64(e.ExpiresAt))
+	}
+	w.block = append(w.block, e.Value...)
+
+	if w.count == 0 {
+		w.first = bytes.Clone(e.Key)
+	}
+	if w.opts.BloomBitsPerKey > 0 {
+		w.hashes = append(w.hashes, bloom.Hash(e.Key))
+	}
+	w.last = append(w.last[:0], e.Key...)
+	w.inBlock++
+	w.count++
+
+	if len(w.block) >= w.opts.BlockSize {
+		return w.flushBlock()
+	}
+	return nil
+}
+
+func sharedPrefix(a, b []byte) int {
+	n := min(len(a), len(b))
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
+
+func (w *Writer) flushBlock() error {
+	if w.inBlock == 0 {
+		return nil
+	}
+	for _, r := range w.restarts {
+		w.block = Endian.AppendUint32(w.block, r)
+	}
+	w.block = Endian.AppendUint32(w.block, uint32(len(w.restarts)))
+
+	if err := w.write(w.block); err != nil {
+		return err
+	}
+	w.index = append(w.index, blockHandle{lastKey: bytes.Clone(w.last), offset: w.off - int64(len(w.block)), size: int64(len(w.block))})
+	w.block = w.block[:0]
+	w.restarts = w.restarts[:0]
+	w.inBlock = 0
+	return nil
+}
+
+func (w *Writer) write(p []byte) error {
+	n, err := w.w.Write(p)
+	w.off += int64(n)
+	if err != nil {
+		w.writeErr = err
+	}
+	return err
+}
+
+// Size 는 지금까지 내보낸 바이트 + 아직 블록에 모아 둔 바이트. 테이블을 나눌 시점을 정할 때 쓴다.
+func (w *Writer) Size() int64 { return w.off + int64(len(w.block)) }
+
+// Count 는 지금까지 Add / Delete 한 entry 수
+func (w *Writer) Count() int64 { return w.count }
+
+// Close 는 남은 블록, index, footer 를 쓴다. w 는 닫지 않는다 (Sync / Close 는 부른 쪽 몫).
+func (w *Writer) Close() error {
+	if w.closed {
+		return w.writeErr
+	}
+	w.closed = true
+	if err := w.flushBlock(); err != nil {
+		return err
+	}
+
+	var filterOffset, filterSize int64
+	if w.opts.BloomBitsPerKey > 0 {
+		f := bloom.New(len(w.hashes), w.opts.BloomBitsPerKey)
+		for _, h := range w.hashes {
+			f.AddHash(h)
+		}
+		data := f.Encode()
+		filterOffset, filterSize = w.off, int64(len(data))
+		if err := w.write(data); err != nil {
+			return err
+		}
+	}
+
+	indexOffset := w.off
+	var buf []byte
+	buf = binary.AppendUvarint(buf, uint64(len(w.index)))
+	for _, bh := range w.index {
+		buf = binary.AppendUvarint(buf, uint64(len(bh.lastKey)))
+		buf = append(buf, bh.lastKey...)
+		buf = binary.AppendUvarint(buf, uint64(bh.offset))
+		buf = binary.AppendUvarint(buf, uint64(bh.size))
+	}
+	buf = binary.AppendUvarint(buf, uint64(len(w.first)))
+	buf = append(buf, w.first...)
+	buf = binary.AppendUvarint(buf, uint64(filterOffset))
+	buf = binary.AppendUvarint(buf, uint64(filterSize))
+	indexSize := len(buf)
+
+	var footer [FooterSize]byte
+	fw := codec.NewWriter(footer[:])
+	fw.Int64(indexOffset)
+	fw.Uint32(uint32(indexSize))
+	fw.Int64(w.count)
+	fw.Bytes(Magic[:])
+	return w.write(append(buf, footer[:]...))
+}
+
+// ==================================
+// Reader
+// ==================================
+
+// Reader 는 r 의 [0, size) 에 있는 테이블을 읽는다. 여러 고루틴이 같이 써도 된다 (ReadAt 만 쓴다).
+type Reader struct {
+	r      io.ReaderAt
+	size   int64
+	count  int64
+	index  []blockHandle
+	first  []byte
+	filter *bloom.Filter // 필터가 없는 테이블이면 nil
+
+	checks, negatives, falsePositives atomic.Int64
+}
+
+// FilterStats 는 Get 이 필터를 쓴 결과.
+// - Checks: 필터에 물어본 횟수
+// - Negatives: 필터가 "없다" 고 해서 블록을 읽지 않은 횟수
+// - FalsePositives: 필터는 "있을 수도" 라고 했지만 블록을 읽어 보니 없던 횟수
+type FilterStats struct {
+	Checks         int64
+	Negatives      int64
+	FalsePositives int64
+}
+
+func (s FilterStats) Add(o FilterStats) FilterStats {
+	return FilterStats{
+		Checks:         s.Checks + o.Checks,
+		Negatives:      s.Negatives + o.Negatives,
+		FalsePositives: s.FalsePositives + o.FalsePositives,
+	}
+}
+
+// FalsePositiveRate 는 없는 키를 물어봤을 때 필터가 잘못 "있을 수도" 라고 한 비율. 없는 키를 물어본 적이 없으면 0.
+func (s FilterStats) FalsePositiveRate() float64 {
+	if s.Negatives+s.FalsePositives == 0 {
+		return 0
+	}
+	return float64(s.FalsePositives) / float64(s.Negatives+s.FalsePositives)
+}
+
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	if size < FooterSize {
+		return nil, ErrCorrupt
+	}
+	var footer [FooterSize]byte
+	if _, err := r.ReadAt(footer[:], size-FooterSize); err != nil {
+		return nil, err
+	}
+	fr := codec.NewReader(footer[:])
+	indexOffset := fr.Int64()
+	indexSize := int64(fr.Uint32())
+	count := fr.Int64()
+	var magic [4]byte
+	fr.Bytes(magic[:])
+	if magic != Magic {
+		return nil, fmt.Errorf("%w: %w", ErrCorrupt, dberr.ErrInvalidMagic)
+	}
+	if indexOffset < 0 || indexOffset+indexSize+FooterSize != size {
+		return nil, fmt.Errorf("%w: bad index location", ErrCorrupt)
+	}
+
+	buf := make([]byte, indexSize)
+	if _, err := r.ReadAt(buf, indexOffset); err != nil {
+		return nil, err
+	}
+	d := decoder{buf: buf}
+	n := d.uvarint()
+	if d.err != nil || n > uint64(len(buf)) {
+		return nil, ErrCorrupt
+	}
+	index := make([]blockHandle, 0, n)
+	for i := uint64(0); i < n; i++ {
+		bh := blockHandle{lastKey: d.bytes(), offset: int64(d.uvarint()), size: int64(d.uvarint())}
+		if d.err != nil || bh.offset < 0 || bh.size < 4 || bh.offset+bh.size > indexOffset {
+			return nil, ErrCorrupt
+		}
+		index = append(index, bh)
+	}
+	first := d.bytes()
+	if d.err != nil {
+		return nil, ErrCorrupt
+	}
+	rd := &Reader{r: r, size: size, count: count, index: index, first: first}
+
+	// 필터 위치는 index 끝에 붙어 있다. 필터가 생기기 전에 쓴 테이블은 firstKey 에서 끝난다.
+	if d.off < len(buf) {
+		filterOffset, filterSize := int64(d.uvarint()), int64(d.uvarint())
+		if d.err != nil || filterOffset < 0 || filterOffset+filterSize > indexOffset {
+			return nil, ErrCorrupt
+		}
+		if filterSize > 0 {
+			data := make([]byte, filterSize)
+			if _, err := r.ReadAt(data, filterOffset); err != nil {
+				return nil, err
+			}
+			f, err := bloom.Decode(data)
+			if err != nil {
+				return nil, err
+			}
+			rd.filter = f
+		}
+	}
+	return rd, nil
+}
+
+// FilterStats 는 지금까지 Get 이 필터를 쓴 결과. 필터가 없는 테이블이면 전부 0.
+func (r *Reader) FilterStats() FilterStats {
+	return FilterStats{
+		Checks:         r.checks.Load(),
+		Negatives:      r.negatives.Load(),
+		FalsePositives: r.falsePositives.Load(),
+	}
+}
+
+// Len 은 entry 수 (tombstone 포함)
+func (r *Reader) Len() int64 { return r.count }
+
+// Blocks 는 data block 수
+func (r *Reader) Blocks() int { return len(r.index) }
+
+// First / Last 는 가장 작은 / 큰 키. 비어 있는 테이블이면 nil.
+func (r *Reader) First() []byte {
+	if len(r.index) == 0 {
+		return nil
+	}
+	return r.first
+}
+func (r *Reader) Last() []byte {
+	if len(r.index) == 0 {
+		return nil
+	}
+	return r.index[len(r.index)-1].lastKey
+}
+
+// blockFor 는 key 가 들어 있을 수 있는 블록 (lastKey >= key 인 첫 블록). 없으면 len(index).
+func (r *Reader) blockFor(key []byte) int {
+	return sort.Search(len(r.index), func(i int) bool {
+		return bytes.Compare(r.index[i].lastKey, key) >= 0
+	})
+}
+
+// Get 은 key 의 entry 를 찾는다. data block 을 최대 하나 읽고, 필터가 "없다" 고 하면 하나도 읽지 않는다.
+func (r *Reader) Get(key []byte) (Entry, bool, error) {
+	if len(r.index) == 0 || bytes.Compare(key, r.first) < 0 {
+		return Entry{}, false, nil
+	}
+	i := r.blockFor(key)
+	if i == len(r.index) {
+		return Entry{}, false, nil
+	}
+	if r.filter != nil {
+		r.checks.Add(1)
+		if !r.filter.MayContain(key) {
+			r.negatives.Add(1)
+			return Entry{}, false, nil
+		}
+	}
+	b, err := r.readBlock(i)
+	if err != nil {
+		return Entry{}, false, err
+	}
+	b.seek(key)
+	for b.next() {
+		if c := bytes.Compare(b.cur.Key, key); c == 0 {
+			return b.cur, true, nil
+		} else if c > 0 {
+			break
+		}
+	}
+	if b.err == nil && r.filter != nil {
+		r.falsePositives.Add(1)
+	}
+	return Entry{}, false, b.err
+}
+
+func (r *Reader) readBlock(i int) (*block, error) {
+	bh := r.index[i]
+	buf := make([]byte, bh.size)
+	if _, err := r.r.ReadAt(buf, bh.offset); err != nil {
+		return nil, err
+	}
+	return newBlock(buf)
+}
+
+// Iterator 는 lo 이상인 키부터 오름차순으로 돈다. 블록 단위로 읽는다.
+type Iterator struct {
+	r   *Reader
+	blk int
+	b   *block
+	lo  []byte
+	err error
+}
+
+// Iter 는 lo 이상인 키부터 도는 Iterator. lo 가 nil 이면 처음부터.
+func (r *Reader) Iter(lo []byte) *Iterator {
+	return &Iterator{r: r, blk: r.blockFor(lo) - 1, lo: lo}
+}
+
+func (it *Iterator) Next() bool {
+	for {
+		if it.err != nil {
+			return false
+		}
+		if it.b != nil && it.b.next() {
+			return true
+		}
+		if it.b != nil && it.b.err != nil {
+			it.err = it.b.err
+			return false
+		}
+		it.blk++
+		if it.blk >= len(it.r.index) {
+			return false
+		}
+		if it.b, it.err = it.r.readBlock(it.blk); it.err != nil {
+			return false
+		}
+		if it.lo != nil {
+			it.b.seek(it.lo)
+			it.lo = nil // 첫 블록에서만 건너뛴다
+		}
+	}
+}
+
+// Entry 는 지금 entry. 다음 Next 뒤에도 내용이 바뀌지 않는다.
+func (it *Iterator) Entry() Entry { return it.b.cur }
+func (it *Iterator) Err() error   { return it.err }
+
+// ==================================
+// block 해석
+// ==================================
+
+type block struct {
+	data     []byte // trailer 를 뺀 entry 영역
+	restarts []uint32
+	pos      int
+	cur      Entry
+	err      error
+}
+
+func newBlock(buf []byte) (*block, error) {
+	if len(buf) < 4 {
+		return nil, ErrCorrupt
+	}
+	n := int(Endian.Uint32(buf[len(buf)-4:]))
+	end := len(buf) - 4 - 4*n
+	if n < 1 || end < 0 {
+		return nil, ErrCorrupt
+	}
+	restarts := make([]uint32, n)
+	for i := range restarts {
+		restarts[i] = Endian.Uint32(buf[end+4*i:])
+		if int(restarts[i]) >= end {
+			return nil, ErrCorrupt
+		}
+	}
+	return &block{data: buf[:end], restarts: restarts}, nil
+}
+
+// seek 은 key 이상인 첫 entry 바로 앞으로 옮긴다. restart 지점을 이진 탐색한 뒤 거기서부터 훑는다.
+func (b *block) seek(key []byte) {
+	// restart 지점의 키는 shared 가 0 이라 바로 읽을 수 있다.
+	i := sort.Search(len(b.restarts), func(i int) bool {
+		k, err := b.keyAt(int(b.restarts[i]))
+		return err != nil || bytes.Compare(k, key) > 0
+	})
+	if i > 0 {
+		i--
+	}
+	b.pos = int(b.restarts[i])
+	b.cur = Entry{}
+
+	// key 보다 작은 entry 는 건너뛰되, 마지막으로 건너뛴 entry 는 다음 키를 풀 때 필요하므로 cur 에 남긴다.
+	for {
+		save, prev := b.pos, b.cur
+		if !b.next() {
+			b.pos, b.cur = save, prev
+			return
+		}
+		if bytes.Compare(b.cur.Key, key) >= 0 {
+			b.pos, b.cur = save, prev
+			return
+		}
+	}
+}
+
+func (b *block) keyAt(pos int) ([]byte, error) {
+	d := decoder{buf: b.data[pos:]}
+	shared := d.uvarint()
+	unshared := d.uvarint()
+	d.uvarint()
+	d.skip(1)
+	key := d.take(int(unshared))
+	if d.err != nil || shared != 0 {
+		return nil, ErrCorrupt
+	}
+	return key, nil
+}
+
+func (b *block) next() bool {
+	if b.err != nil || b.pos >= len(b.data) {
+		return false
+	}
+	d := decoder{buf: b.data[b.pos:]}
+	shared := int(d.uvarint())
+	unshared := int(d.uvarint())
+	vlen := int(d.uvarint())
+	kindb := d.take(1)
+	suffix := d.take(unshared)
+	value := d.take(vlen)
+	if d.err != nil || shared > len(b.cur.Key) || kindb[0] > kindPutExpiring || kindb[0] == kindPutExpiring && vlen < 8 {
+		b.err = ErrCorrupt
+		return false
+	}
+
+	// 새 슬라이스를 만들어서 돌려준 Entry 가 다음 next 에 덮이지 않게 한다.
+	key := make([]byte, 0, shared+unshared)
+	key = append(key, b.cur.Key[:shared]...)
+	key = append(key, suffix...)
+	b.cur = Entry{Key: key, Value: value, Deleted: kindb[0] == kindDelete}
+	if kindb[0] == kindPutExpiring {
+		b.cur.ExpiresAt, b.cur.Value = int64(Endian.Uint64(value)), value[8:]
+	}
+	b.pos += d.off
+	return true
+}
+
+// decoder 는 uvarint / 길이 붙은 바이트를 차례로 읽는다. 한 번 실패하면 이후 값은 모두 0 / nil 이다.
+type decoder struct {
+	buf []byte
+	off int
+	err error
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.buf[d.off:])
+	if n <= 0 {
+		d.err = ErrCorrupt
+		return 0
+	}
+	d.off += n
+	return v
+}
+
+func (d *decoder) take(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(d.buf)-d.off {
+		d.err = ErrCorrupt
+		return nil
+	}
+	b := d.buf[d.off : d.off+n]
+	d.off += n
+	return b
+}
+
+func (d *decoder) skip(n int) { d.take(n) }
+
+func (d *decoder) bytes() []byte {
+	n := d.uvarint()
+	if n > uint64(len(d.buf)) {
+		d.err = ErrCorrupt
+		return nil
+	}
+	return bytes.Clone(d.take(int(n)))
+}
@@ -0,0 +1,243 @@
+package sstable
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/tmdgusya/btree/dberr"
+)
+
+func testKey(i int) []byte { return []byte(fmt.Sprintf("key%05d", i)) }
+
+// testEntries 는 짝수 키 n 개. 7 번째마다 tombstone, 5 번째마다 만료 시각이 있는 값이다.
+func testEntries(n int) []Entry {
+	entries := make([]Entry, n)
+	for i := range entries {
+		e := Entry{Key: testKey(2 * i), Value: []byte(fmt.Sprintf("value-%d", i))}
+		switch {
+		case i%7 == 3:
+			e.Value, e.Deleted = nil, true
+		case i%5 == 1:
+			e.ExpiresAt = int64(1_700_000_000_000_000_000 + i)
+		}
+		entries[i] = e
+	}
+	return entries
+}
+
+// buildTable 은 entries 를 작은 블록으로 쓰고 Reader 와 파일 내용을 돌려준다.
+func buildTable(t *testing.T, entries []Entry, opts WriterOptions) (*Reader, []byte) {
+	t.Helper()
+	var buf bytes.Buffer
+	w := NewWriter(&buf, opts)
+	for _, e := range entries {
+		if err := w.AddEntry(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if w.Size() != int64(buf.Len()) {
+		t.Fatalf("writer size %d, wrote %d bytes", w.Size(), buf.Len())
+	}
+	data := buf.Bytes()
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r, data
+}
+
+func sameEntry(a, b Entry) bool {
+	return bytes.Equal(a.Key, b.Key) && bytes.Equal(a.Value, b.Value) && a.Deleted == b.Deleted && a.ExpiresAt == b.ExpiresAt
+}
+
+func collect(t *testing.T, it *Iterator) []Entry {
+	t.Helper()
+	var got []Entry
+	for it.Next() {
+		got = append(got, it.Entry())
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+var testOptions = []struct {
+	name string
+	opts WriterOptions
+}{
+	{"small blocks", WriterOptions{BlockSize: 256, RestartInterval: 4}},
+	{"small blocks with filter", WriterOptions{BlockSize: 256, RestartInterval: 4, BloomBitsPerKey: 10}},
+	{"default", WriterOptions{}},
+}
+
+// 쓴 entry 를 처음부터 읽으면 tombstone / 만료 시각까지 그대로 나온다.
+func TestRoundTrip(t *testing.T) {
+	entries := testEntries(1000)
+	for _, o := range testOptions {
+		t.Run(o.name, func(t *testing.T) {
+			r, _ := buildTable(t, entries, o.opts)
+			if r.Len() != int64(len(entries)) {
+				t.Fatalf("Len = %d, want %d", r.Len(), len(entries))
+			}
+			if o.opts.BlockSize != 0 && r.Blocks() < 10 {
+				t.Fatalf("only %d blocks, the test wants many block boundaries", r.Blocks())
+			}
+			if !bytes.Equal(r.First(), entries[0].Key) || !bytes.Equal(r.Last(), entries[len(entries)-1].Key) {
+				t.Fatalf("First / Last = %q / %q", r.First(), r.Last())
+			}
+			got := collect(t, r.Iter(nil))
+			if len(got) != len(entries) {
+				t.Fatalf("iterated %d entries, want %d", len(got), len(entries))
+			}
+			for i := range got {
+				if !sameEntry(got[i], entries[i]) {
+					t.Fatalf("entry %d = %+v, want %+v", i, got[i], entries[i])
+				}
+			}
+		})
+	}
+}
+
+func TestGet(t *testing.T) {
+	entries := testEntries(1000)
+	for _, o := range testOptions {
+		t.Run(o.name, func(t *testing.T) {
+			r, _ := buildTable(t, entries, o.opts)
+			for _, want := range entries {
+				got, ok, err := r.Get(want.Key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !ok || !sameEntry(got, want) {
+					t.Fatalf("Get(%s) = %+v, %v, want %+v", want.Key, got, ok, want)
+				}
+			}
+			// 홀수 키, 첫 키보다 앞, 마지막 키보다 뒤
+			missing := [][]byte{[]byte("a"), []byte("key"), testKey(2*len(entries) + 1), []byte("zzz")}
+			for i := 1; i < 2*len(entries); i += 2 {
+				missing = append(missing, testKey(i))
+			}
+			for _, k := range missing {
+				if got, ok, err := r.Get(k); err != nil || ok {
+					t.Fatalf("Get(%s) = %+v, %v, %v, want not found", k, got, ok, err)
+				}
+			}
+			if stats := r.FilterStats(); o.opts.BloomBitsPerKey > 0 && stats.Negatives == 0 {
+				t.Fatalf("filter rejected no missing key: %+v", stats)
+			}
+		})
+	}
+}
+
+// Iter(lo) 는 lo 이상인 첫 키부터 끝까지 돈다. lo 가 블록의 마지막 키이거나 두 블록 사이여도 마찬가지다.
+func TestIterFrom(t *testing.T) {
+	entries := testEntries(1000)
+	r, _ := buildTable(t, entries, WriterOptions{BlockSize: 256, RestartInterval: 4})
+
+	var los [][]byte
+	for i := -1; i <= 2*len(entries); i++ {
+		los = append(los, testKey(i))
+	}
+	for _, bh := range r.index {
+		// 블록의 마지막 키, 그 바로 뒤 (다음 블록의 첫 키 앞), 그 바로 앞
+		los = append(los, bh.lastKey, append(bytes.Clone(bh.lastKey), 0), bh.lastKey[:len(bh.lastKey)-1])
+	}
+	los = append(los, []byte(""), []byte("zzz"))
+
+	for _, lo := range los {
+		start := sort.Search(len(entries), func(i int) bool { return bytes.Compare(entries[i].Key, lo) >= 0 })
+		got := collect(t, r.Iter(lo))
+		if len(got) != len(entries)-start {
+			t.Fatalf("Iter(%q) returned %d entries, want %d", lo, len(got), len(entries)-start)
+		}
+		if len(got) > 0 && !sameEntry(got[0], entries[start]) {
+			t.Fatalf("Iter(%q) starts at %q, want %q", lo, got[0].Key, entries[start].Key)
+		}
+	}
+}
+
+func TestEmptyTable(t *testing.T) {
+	r, _ := buildTable(t, nil, WriterOptions{})
+	if r.Len() != 0 || r.Blocks() != 0 || r.First() != nil || r.Last() != nil {
+		t.Fatalf("empty table: Len %d, Blocks %d, First %q, Last %q", r.Len(), r.Blocks(), r.First(), r.Last())
+	}
+	if _, ok, err := r.Get([]byte("k")); ok || err != nil {
+		t.Fatalf("Get on an empty table = %v, %v", ok, err)
+	}
+	if got := collect(t, r.Iter(nil)); len(got) != 0 {
+		t.Fatalf("empty table iterated %d entries", len(got))
+	}
+}
+
+func TestOutOfOrder(t *testing.T) {
+	w := NewWriter(&bytes.Buffer{}, WriterOptions{})
+	if err := w.Add([]byte("b"), nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"b", "a"} {
+		if err := w.Add([]byte(k), nil); !errors.Is(err, ErrOutOfOrder) {
+			t.Fatalf("Add(%q) after \"b\" = %v, want ErrOutOfOrder", k, err)
+		}
+	}
+}
+
+// 손상된 footer 는 NewReader 가, 손상된 data block 은 그 블록을 읽는 Get / Iter 가 ErrCorrupt 로 거절한다.
+func TestCorrupt(t *testing.T) {
+	entries := testEntries(200)
+	opts := WriterOptions{BlockSize: 256, RestartInterval: 4}
+	_, data := buildTable(t, entries, opts)
+
+	open := func(data []byte) error {
+		_, err := NewReader(bytes.NewReader(data), int64(len(data)))
+		return err
+	}
+	footer := len(data) - FooterSize
+	cases := []struct {
+		name   string
+		mutate func(b []byte) []byte
+	}{
+		{"bad magic", func(b []byte) []byte { b[len(b)-1] ^= 0xff; return b }},
+		{"index offset past the end", func(b []byte) []byte { b[footer] = 0x7f; return b }},
+		{"index size", func(b []byte) []byte { b[footer+11]++; return b }},
+		{"truncated", func(b []byte) []byte { return b[:len(b)-1] }},
+		{"shorter than a footer", func(b []byte) []byte { return b[:FooterSize-1] }},
+	}
+	for _, c := range cases {
+		err := open(c.mutate(bytes.Clone(data)))
+		if !errors.Is(err, ErrCorrupt) {
+			t.Errorf("%s: NewReader = %v, want ErrCorrupt", c.name, err)
+		}
+	}
+	if err := open(cases[0].mutate(bytes.Clone(data))); !errors.Is(err, dberr.ErrInvalidMagic) {
+		t.Errorf("bad magic: NewReader = %v, want ErrInvalidMagic", err)
+	}
+
+	// 첫 entry 의 kind 바이트 (shared / unshared / valueLen 이 모두 1 바이트 uvarint 다)
+	bad := bytes.Clone(data)
+	bad[3] = 9
+	r, err := NewReader(bytes.NewReader(bad), int64(len(bad)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := r.Get(entries[0].Key); !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("Get from a corrupt block = %v, want ErrCorrupt", err)
+	}
+	it := r.Iter(nil)
+	for it.Next() {
+	}
+	if !errors.Is(it.Err(), ErrCorrupt) {
+		t.Fatalf("Iter over a corrupt block = %v, want ErrCorrupt", it.Err())
+	}
+	// 다른 블록은 그대로 읽힌다.
+	last := entries[len(entries)-1]
+	if got, ok, err := r.Get(last.Key); err != nil || !ok || !sameEntry(got, last) {
+		t.Fatalf("Get(%s) from an intact block = %+v, %v, %v", last.Key, got, ok, err)
+	}
+}
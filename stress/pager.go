@@ -0,0 +1,259 @@
+package stress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/tmdgusya/btree/chapter01/page"
+	"github.com/tmdgusya/btree/codec"
+	"github.com/tmdgusya/btree/workload"
+)
+
+// ==================================
+// pager: 쓰는 도중의 읽기
+// ==================================
+// 쓰기 핸들(double-write Pager)과 읽기 핸들(같은 파일을 ReadOnly 로 연 Pager)을 따로 둔다.
+// - 쓰기: workload 의 키로 페이지를 고른다. 지금 페이지 수 다음 자리가 나오면 새 페이지를 할당한다.
+// 페이지마다 (페이지 ID, 버전) 을 페이지 끝까지 되풀이해서 채우므로, 섞인 이미지는 바로 드러난다.
+// - WriteHook 이 멈춘 자리마다 쓰고 있는 페이지와 다른 페이지 하나를 읽기 핸들로 읽는다.
+// 제자리 쓰기 전(started, scratch-done)에는 예전 버전, 뒤(page-done, finished)에는 새 버전이어야 한다.
+// 읽기 핸들을 연 뒤에 할당된 페이지는 스냅숏 밖이므로 ErrPageOutOfRange 여야 한다.
+// - 읽기: 쓰는 중이 아닐 때 아무 페이지나 읽어서 마지막으로 쓴 버전인지 본다.
+// - check: 읽기 핸들을 다시 열어 (새 스냅숏) 모든 페이지를 맞춰 본다.
+// Pager 는 잠금이 없으므로 worker 들은 mu 로 한 번에 하나씩 쓰고, hook 도 그 안에서 돈다.
+
+// 파일이 빨리 자라도록 페이지를 작게 잡는다.
+const stressPageSize = 512
+
+// 페이지 이미지의 한 칸: 페이지 ID(8) + 버전(8)
+const stampSize = 16
+
+type pagerTarget struct {
+	ops     int
+	path    string
+	dir     string
+	tempDir bool
+
+	mu       sync.Mutex
+	w        *page.Pager
+	r        *page.Pager
+	versions []uint64 // versions[id-1] 은 id 페이지를 끝까지 쓴 마지막 버전
+	next     uint64   // 지금 쓰고 있는 이미지의 버전
+
+	// 쓰는 동안에만 채운다. hook 이 덤으로 읽을 페이지를 고르고 읽은 수를 센다.
+	rng     *rand.Rand
+	c       *counters
+	hookErr error
+}
+
+func newPagerTarget(opts Options) (*pagerTarget, error) {
+	dir, temp, err := workDir(opts)
+	if err != nil {
+		return nil, err
+	}
+	t := &pagerTarget{ops: opts.Ops, path: filepath.Join(dir, "stress.pages"), dir: dir, tempDir: temp}
+	// 이전 실행이 남긴 파일이면 버전을 알 수 없으므로 새로 만든다.
+	if err := os.Remove(t.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		t.close()
+		return nil, err
+	}
+	t.w, err = page.OpenPager(t.path, page.PagerOptions{PageSize: stressPageSize, DoubleWrite: true, WriteHook: t.onWrite})
+	if err != nil {
+		t.close()
+		return nil, err
+	}
+	if err := t.reopenReader(); err != nil {
+		t.close()
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *pagerTarget) reopenReader() error {
+	if t.r != nil {
+		if err := t.r.Close(); err != nil {
+			return err
+		}
+		t.r = nil
+	}
+	r, err := page.OpenPager(t.path, page.PagerOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	t.r = r
+	return nil
+}
+
+// stamp 는 id 페이지의 version 이미지
+func stamp(id int64, version uint64) []byte {
+	buf := make([]byte, stressPageSize)
+	w := codec.NewWriter(buf)
+	for w.Len()+stampSize <= len(buf) {
+		w.Int64(id)
+		w.Uint64(version)
+	}
+	return buf
+}
+
+// unstamp 는 이미지의 버전을 돌려준다. 칸마다 같은 (id, 버전) 이 아니면 찢어진 이미지다.
+func unstamp(id int64, data []byte) (uint64, error) {
+	if len(data) < stampSize {
+		return 0, fmt.Errorf("page is %d bytes", len(data))
+	}
+	first := codec.NewReader(data[8:stampSize])
+	version := first.Uint64()
+	r := codec.NewReader(data)
+	for r.Len()+stampSize <= len(data) {
+		off := r.Len()
+		gotID, gotVersion := r.Int64(), r.Uint64()
+		if gotID != id || gotVersion != version {
+			return 0, fmt.Errorf("torn image: offset %d holds page %d version %d, offset 0 holds version %d", off, gotID, gotVersion, version)
+		}
+	}
+	return version, nil
+}
+
+func (t *pagerTarget) version(id int64) uint64 {
+	if id < 1 || id > int64(len(t.versions)) {
+		return 0
+	}
+	return t.versions[id-1]
+}
+
+// expect 는 읽기 핸들로 id 페이지를 읽어 want 버전인지 본다. at 은 에러 메시지에 붙일 시점.
+func (t *pagerTarget) expect(id int64, want uint64, at string) error {
+	pg, err := t.r.ReadPage(id)
+	if id > t.r.PageCount() {
+		if errors.Is(err, page.ErrPageOutOfRange) {
+			return nil
+		}
+		return fmt.Errorf("%w: page %d (%s) is outside the reader's %d pages but read returned %v", ErrMismatch, id, at, t.r.PageCount(), err)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: page %d (%s): %w", ErrMismatch, id, at, err)
+	}
+	got, err := unstamp(id, pg.Data)
+	if err != nil {
+		return fmt.Errorf("%w: page %d (%s): %w", ErrMismatch, id, at, err)
+	}
+	if got != want {
+		return fmt.Errorf("%w: page %d (%s): read version %d, want %d", ErrMismatch, id, at, got, want)
+	}
+	return nil
+}
+
+// onWrite 는 쓰기 핸들의 WriteHook. 에러는 hookErr 에 남기고 WritePage 가 돌아온 뒤에 돌려준다.
+func (t *pagerTarget) onWrite(id int64, step page.WriteStep) {
+	if t.hookErr != nil || t.rng == nil {
+		return
+	}
+	at := "mid-write " + step.String()
+	want := t.version(id)
+	if step >= page.WritePageDone {
+		want = t.next
+	}
+	if err := t.expect(id, want, at); err != nil {
+		t.hookErr = err
+		return
+	}
+	t.c.lookups.Add(1)
+	if n := len(t.versions); n > 0 {
+		other := int64(t.rng.Intn(n)) + 1
+		if other == id {
+			return
+		}
+		if err := t.expect(other, t.version(other), at); err != nil {
+			t.hookErr = err
+			return
+		}
+		t.c.lookups.Add(1)
+	}
+}
+
+func (t *pagerTarget) batch(ctx context.Context, worker int, gen *workload.Generator, rng *rand.Rand, c *counters) error {
+	for i := 0; i < t.ops; i++ {
+		if i%ctxCheckEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		op := gen.Next()
+		t.mu.Lock()
+		err := t.apply(op, rng, c)
+		t.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("worker %d: %w", worker, err)
+		}
+	}
+	return nil
+}
+
+func (t *pagerTarget) apply(op workload.Op, rng *rand.Rand, c *counters) error {
+	n := int64(len(t.versions))
+	id := int64(op.Key)%(n+1) + 1
+	if op.Kind == workload.Lookup {
+		if n == 0 {
+			return nil
+		}
+		id = min(id, n)
+		if err := t.expect(id, t.version(id), "at rest"); err != nil {
+			return err
+		}
+		c.lookups.Add(1)
+		return nil
+	}
+
+	t.next++
+	t.rng, t.c = rng, c
+	err := t.w.WritePage(&page.Page{Id: int(id), Data: stamp(id, t.next)})
+	t.rng, t.c = nil, nil
+	if err == nil {
+		err = t.hookErr
+	}
+	t.hookErr = nil
+	if err != nil {
+		return err
+	}
+	if id > n {
+		t.versions = append(t.versions, t.next)
+	} else {
+		t.versions[id-1] = t.next
+	}
+	c.inserts.Add(1)
+	return nil
+}
+
+// check 는 읽기 핸들을 다시 열고 모든 페이지가 마지막으로 쓴 버전인지 본다. 키 수 대신 페이지 수를 돌려준다.
+func (t *pagerTarget) check() (int, error) {
+	if err := t.reopenReader(); err != nil {
+		return 0, err
+	}
+	if got, want := t.r.PageCount(), int64(len(t.versions)); got != want {
+		return 0, fmt.Errorf("%w: reader sees %d pages, wrote %d", ErrMismatch, got, want)
+	}
+	for i, want := range t.versions {
+		if err := t.expect(int64(i+1), want, "check"); err != nil {
+			return 0, err
+		}
+	}
+	return len(t.versions), nil
+}
+
+func (t *pagerTarget) close() error {
+	var errs []error
+	if t.r != nil {
+		errs = append(errs, t.r.Close())
+	}
+	if t.w != nil {
+		errs = append(errs, t.w.Close())
+	}
+	if t.tempDir {
+		os.RemoveAll(t.dir)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,6 @@
+//go:build race
+
+package stress
+
+// raceEnabled 는 -race 로 빌드했는지. Report 에 적어서 race detector 없이 돈 결과와 나눈다.
+const raceEnabled = true
@@ -0,0 +1,497 @@
+// Package stress 는 메모리 B-Tree 나 LSM 저장소를 여러 goroutine 에서 동시에 두드리고,
+// 배치가 끝날 때마다 불변식과 내용을 검사하는 도구다. 동시성 기능을 믿기 전에 race detector 아래에서 돌린다:
+//
+//	go run -race ./cmd/btree stress -target tree -workers 8
+//
+// - tree: 웹 서버와 같은 방식(쓰기는 Lock 을 잡고 InsertCopy 로 새 트리를 내보내고, 읽기는 잠금 없이 내보낸 트리를 읽는다)으로 트리 하나를 함께 쓴다.
+// 배치마다 BTree.Check 로 모양을 보고, 모든 worker 가 넣은 키가 트리에 한 번씩만 있는지 본다. 같은 키를 다시 넣으면 값만 바뀐다.
+// - lsm: worker 마다 겹치지 않는 키를 맡아서 Put / Delete / Get / Scan 을 섞는다. 자기 키는 자기만 쓰므로
+// Get 은 바로 자기 모델과 맞아야 하고, 배치마다 모든 모델을 Get 과 전체 Scan 으로 다시 맞춰 본다.
+// - pager: double-write Pager 에 페이지를 쓰면서 WriteHook 이 멈춘 자리마다 따로 연 읽기 전용 Pager 로 같은 페이지를 읽는다.
+// 읽기 핸들은 배치 사이에 다시 열리므로 그 때의 페이지 수가 스냅숏이다. 쓰는 도중에도 예전 이미지나 새 이미지만 보여야 한다.
+package stress
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tmdgusya/btree"
+	"github.com/tmdgusya/btree/chapter03/lsm"
+	"github.com/tmdgusya/btree/workload"
+)
+
+const (
+	TargetTree  = "tree"
+	TargetLSM   = "lsm"
+	TargetPager = "pager"
+)
+
+// ErrMismatch 는 동시에 돌린 결과가 모델과 다를 때. 어느 키에서 어떻게 달랐는지 덧붙여서 돌려준다.
+var ErrMismatch = errors.New("stress: result does not match the model")
+
+// Options 는 한 번의 실행 설정. 0 인 값은 DefaultOptions 의 값을 쓴다.
+// - Ops: 배치 하나에서 worker 하나가 하는 연산 수
+// - Lookups: 연산 중 읽기(Get / 검색 / 범위)의 비율
+// - Deletes: lsm 에서 쓰기 중 Delete 의 비율 (tree 는 Delete 가 없어서 보지 않는다)
+// - Dir: lsm / pager 가 쓸 디렉터리. 비어 있으면 임시 디렉터리를 만들고 끝나면 지운다.
+// - Seed: 0 이면 기본값 대신 workload.RandomSeed 로 고른다. 어느 쪽이든 Report.Seed 로 알려 준다.
+type Options struct {
+	Target       string
+	Workers      int
+	Batches      int
+	Ops          int
+	KeySpace     int
+	Lookups      float64
+	Deletes      float64
+	Distribution workload.Distribution
+	Seed         int64
+	Degree       int
+	Dir          string
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Target:       TargetTree,
+		Workers:      4,
+		Batches:      10,
+		Ops:          2000,
+		KeySpace:     10000,
+		Lookups:      0.5,
+		Deletes:      0.2,
+		Distribution: workload.Uniform,
+		Seed:         1,
+		Degree:       3,
+	}
+}
+
+func (o Options) withDefaults() Options {
+	def := DefaultOptions()
+	if o.Target == "" {
+		o.Target = def.Target
+	}
+	if o.Workers <= 0 {
+		o.Workers = def.Workers
+	}
+	if o.Batches <= 0 {
+		o.Batches = def.Batches
+	}
+	if o.Ops <= 0 {
+		o.Ops = def.Ops
+	}
+	if o.KeySpace <= 0 {
+		o.KeySpace = def.KeySpace
+	}
+	if o.Degree == 0 {
+		o.Degree = def.Degree
+	}
+	if o.Seed == 0 {
+		o.Seed = workload.RandomSeed()
+	}
+	return o
+}
+
+// Report 는 실행 결과. Keys 는 마지막 배치 뒤에 저장소에 남은 키 수다.
+// 실패를 다시 보려면 같은 Options 에 Seed 를 넣고 돌린다 (worker 간 실행 순서까지 같지는 않다).
+type Report struct {
+	Target    string        `json:"target"`
+	Seed      int64         `json:"seed"`
+	Race      bool          `json:"race"`
+	Workers   int           `json:"workers"`
+	Batches   int           `json:"batches"`
+	Inserts   int64         `json:"inserts"`
+	Deletes   int64         `json:"deletes"`
+	Lookups   int64         `json:"lookups"`
+	Scans     int64         `json:"scans"`
+	Keys      int           `json:"keys"`
+	Elapsed   time.Duration `json:"elapsed"`
+	Checks    int           `json:"checks"`
+	OpsPerSec float64       `json:"opsPerSec"`
+}
+
+// Print 는 사람이 읽을 모양으로 w 에 쓴다.
+func (r Report) Print(w io.Writer) {
+	race := "off"
+	if r.Race {
+		race = "on"
+	}
+	fmt.Fprintf(w, "%s: %d workers x %d batches, race detector %s, seed %d\n", r.Target, r.Workers, r.Batches, race, r.Seed)
+	fmt.Fprintf(w, "ops: inserts=%d deletes=%d lookups=%d scans=%d (%.0f ops/s)\n", r.Inserts, r.Deletes, r.Lookups, r.Scans, r.OpsPerSec)
+	fmt.Fprintf(w, "checks passed=%d keys=%d elapsed=%s\n", r.Checks, r.Keys, r.Elapsed.Round(time.Millisecond))
+}
+
+// counters 는 worker 들이 함께 올리는 연산 수
+type counters struct {
+	inserts, deletes, lookups, scans atomic.Int64
+}
+
+// target 은 저장소 하나에 대한 stress. batch 는 worker 하나가 배치 하나를 돌고, check 는 모든 worker 가 멈춘 뒤에 불린다.
+type target interface {
+	batch(ctx context.Context, worker int, gen *workload.Generator, rng *rand.Rand, c *counters) error
+	check() (keys int, err error)
+	close() error
+}
+
+// Run 은 opts 대로 worker 들을 배치 단위로 돌린다. 검사가 실패하면 그 배치 번호와 함께 바로 돌아온다.
+func Run(ctx context.Context, opts Options) (Report, error) {
+	opts = opts.withDefaults()
+	var t target
+	var err error
+	switch opts.Target {
+	case TargetTree:
+		t, err = newTreeTarget(opts)
+	case TargetLSM:
+		t, err = newLSMTarget(opts)
+	case TargetPager:
+		t, err = newPagerTarget(opts)
+	default:
+		return Report{}, fmt.Errorf("stress: unknown target %q (want %s, %s or %s)", opts.Target, TargetTree, TargetLSM, TargetPager)
+	}
+	if err != nil {
+		return Report{}, err
+	}
+	defer t.close()
+
+	// worker 마다 씨앗을 달리해서 같은 Seed 면 worker 별 연산 순서가 같게 한다 (실행 순서는 스케줄러가 정한다).
+	gens := make([]*workload.Generator, opts.Workers)
+	rngs := make([]*rand.Rand, opts.Workers)
+	for w := range gens {
+		seed := opts.Seed + int64(w)*1000003
+		gens[w], err = workload.New(workload.Config{
+			Distribution: opts.Distribution,
+			KeySpace:     opts.KeySpace,
+			Seed:         seed,
+			LookupRatio:  opts.Lookups,
+		})
+		if err != nil {
+			return Report{}, err
+		}
+		rngs[w] = rand.New(rand.NewSource(seed + 2))
+	}
+
+	rep := Report{Target: opts.Target, Seed: opts.Seed, Race: raceEnabled, Workers: opts.Workers}
+	var c counters
+	start := time.Now()
+	for b := 0; b < opts.Batches; b++ {
+		if err := ctx.Err(); err != nil {
+			return rep, err
+		}
+		var wg sync.WaitGroup
+		errs := make([]error, opts.Workers)
+		for w := 0; w < opts.Workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[w] = t.batch(ctx, w, gens[w], rngs[w], &c)
+			}()
+		}
+		wg.Wait()
+		if err := errors.Join(errs...); err != nil {
+			return rep, fmt.Errorf("stress: batch %d: %w", b, err)
+		}
+
+		keys, err := t.check()
+		if err != nil {
+			return rep, fmt.Errorf("stress: check after batch %d: %w", b, err)
+		}
+		rep.Batches++
+		rep.Checks++
+		rep.Keys = keys
+	}
+	rep.Elapsed = time.Since(start)
+	rep.Inserts, rep.Deletes = c.inserts.Load(), c.deletes.Load()
+	rep.Lookups, rep.Scans = c.lookups.Load(), c.scans.Load()
+	if secs := rep.Elapsed.Seconds(); secs > 0 {
+		rep.OpsPerSec = float64(rep.Inserts+rep.Deletes+rep.Lookups+rep.Scans) / secs
+	}
+	return rep, nil
+}
+
+// 한 번에 이만큼 연산할 때마다 ctx 를 본다.
+const ctxCheckEvery = 256
+
+// 읽기 중 이 비율은 한 키를 찾는 대신 짧은 범위를 읽는다.
+const scanRatio = 0.1
+
+// 범위 읽기가 훑는 키 폭
+const scanWidth = 64
+
+// ==================================
+// tree: 메모리 B-Tree
+// ==================================
+
+type treeTarget struct {
+	ops int
+
+	mu   sync.Mutex // 쓰기끼리만 잡는다
+	tree atomic.Pointer[btree.BTree]
+
+	// want 는 배치 사이에만 고치는, 넣은 키의 집합. 배치 동안 worker 는 자기 몫을 local 에 모으고, check 가 합친다.
+	want  map[int]bool
+	local []map[int]bool
+}
+
+func newTreeTarget(opts Options) (*treeTarget, error) {
+	tree, err := btree.New(opts.Degree)
+	if err != nil {
+		return nil, err
+	}
+	local := make([]map[int]bool, opts.Workers)
+	for i := range local {
+		local[i] = make(map[int]bool)
+	}
+	t := &treeTarget{ops: opts.Ops, want: make(map[int]bool), local: local}
+	t.tree.Store(tree)
+	return t, nil
+}
+
+// batch 는 넣기와 읽기를 섞는다. 트리에는 Delete 가 없으므로 앞선 배치나 이 worker 가 넣은 키는 반드시 찾아져야 한다.
+// want 는 배치 동안 아무도 고치지 않으므로 잠금 없이 읽는다.
+func (t *treeTarget) batch(ctx context.Context, worker int, gen *workload.Generator, rng *rand.Rand, c *counters) error {
+	local := t.local[worker]
+	for i := 0; i < t.ops; i++ {
+		if i%ctxCheckEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		op := gen.Next()
+		k := int(op.Key)
+		switch {
+		case op.Kind == workload.Insert:
+			t.mu.Lock()
+			next, _ := t.tree.Load().InsertCopy(k, nil)
+			t.tree.Store(next)
+			t.mu.Unlock()
+			local[k] = true
+			c.inserts.Add(1)
+		case rng.Float64() < scanRatio:
+			prev := math.MinInt
+			sorted := true
+			t.tree.Load().Range(k, k+scanWidth, func(got int) bool {
+				sorted = sorted && got >= prev
+				prev = got
+				return true
+			})
+			if !sorted {
+				return fmt.Errorf("%w: worker %d: range [%d, %d] is not in order", ErrMismatch, worker, k, k+scanWidth)
+			}
+			c.scans.Add(1)
+		default:
+			found := false
+			t.tree.Load().Range(k, k, func(int) bool {
+				found = true
+				return false
+			})
+			if !found && (local[k] || t.want[k]) {
+				return fmt.Errorf("%w: worker %d: key %d was inserted but not found", ErrMismatch, worker, k)
+			}
+			c.lookups.Add(1)
+		}
+	}
+	return nil
+}
+
+// check 는 worker 들의 local 을 want 에 합치고, 트리 모양(BTree.Check)과 키마다 한 번씩 있는지를 맞춰 본다.
+func (t *treeTarget) check() (int, error) {
+	for _, local := range t.local {
+		for k := range local {
+			t.want[k] = true
+		}
+		clear(local)
+	}
+
+	tree := t.tree.Load()
+	keys, err := tree.Check()
+	if err != nil {
+		return 0, err
+	}
+	if keys != len(t.want) {
+		return 0, fmt.Errorf("%w: tree holds %d keys, workers inserted %d distinct keys", ErrMismatch, keys, len(t.want))
+	}
+	got := make(map[int]int, len(t.want))
+	tree.Range(math.MinInt, math.MaxInt, func(k int) bool {
+		got[k]++
+		return true
+	})
+	for k := range t.want {
+		if got[k] != 1 {
+			return 0, fmt.Errorf("%w: key %d appears %d times, want once", ErrMismatch, k, got[k])
+		}
+	}
+	return keys, nil
+}
+
+func (t *treeTarget) close() error { return nil }
+
+// ==================================
+// lsm: LSM 저장소
+// ==================================
+
+type lsmTarget struct {
+	ops     int
+	deletes float64
+	workers int
+	db      *lsm.DB
+	dir     string
+	tempDir bool
+
+	// models[w] 는 worker w 가 맡은 키 -> 마지막으로 쓴 값. worker 자신만 고친다.
+	models []map[uint64][]byte
+	seq    []uint64
+}
+
+// workDir 은 opts.Dir 을 돌려준다. 비어 있으면 임시 디렉터리를 만들고 temp 를 true 로 돌려준다.
+func workDir(opts Options) (dir string, temp bool, err error) {
+	if opts.Dir != "" {
+		return opts.Dir, false, nil
+	}
+	if dir, err = os.MkdirTemp("", "btree-stress-"); err != nil {
+		return "", false, err
+	}
+	return dir, true, nil
+}
+
+func newLSMTarget(opts Options) (*lsmTarget, error) {
+	dir, temp, err := workDir(opts)
+	if err != nil {
+		return nil, err
+	}
+	// memtable 을 작게 잡아서 flush 와 컴팩션이 배치 중에 여러 번 일어나게 한다.
+	db, err := lsm.Open(dir, lsm.Options{MemtableSize: 16 << 10})
+	if err != nil {
+		if temp {
+			os.RemoveAll(dir)
+		}
+		return nil, err
+	}
+	models := make([]map[uint64][]byte, opts.Workers)
+	for i := range models {
+		models[i] = make(map[uint64][]byte)
+	}
+	return &lsmTarget{
+		ops: opts.Ops, deletes: opts.Deletes, workers: opts.Workers,
+		db: db, dir: dir, tempDir: temp,
+		models: models, seq: make([]uint64, opts.Workers),
+	}, nil
+}
+
+// lsmKey 는 workload 의 키를 worker 몫의 키로 바꾼다. worker 끼리 키가 겹치지 않는다.
+func (t *lsmTarget) lsmKey(worker int, key uint32) uint64 {
+	return uint64(key)*uint64(t.workers) + uint64(worker)
+}
+
+func encodeKey(k uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, k)
+}
+
+func (t *lsmTarget) batch(ctx context.Context, worker int, gen *workload.Generator, rng *rand.Rand, c *counters) error {
+	model := t.models[worker]
+	for i := 0; i < t.ops; i++ {
+		if i%ctxCheckEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		op := gen.Next()
+		k := t.lsmKey(worker, op.Key)
+		key := encodeKey(k)
+		switch {
+		case op.Kind == workload.Insert && rng.Float64() < t.deletes:
+			if err := t.db.Delete(key); err != nil {
+				return err
+			}
+			delete(model, k)
+			c.deletes.Add(1)
+		case op.Kind == workload.Insert:
+			t.seq[worker]++
+			value := fmt.Appendf(nil, "w%d-%d", worker, t.seq[worker])
+			if err := t.db.Put(key, value); err != nil {
+				return err
+			}
+			model[k] = value
+			c.inserts.Add(1)
+		case rng.Float64() < scanRatio:
+			// 다른 worker 의 키도 섞여 나오므로 순서만 본다.
+			if err := scanInOrder(t.db, key, encodeKey(k+scanWidth), nil); err != nil {
+				return fmt.Errorf("worker %d: %w", worker, err)
+			}
+			c.scans.Add(1)
+		default:
+			got, ok, err := t.db.Get(key)
+			if err != nil {
+				return err
+			}
+			want, wantOK := model[k]
+			if ok != wantOK || !bytes.Equal(got, want) {
+				return fmt.Errorf("%w: worker %d: key %d = %q (found %v), want %q (found %v)", ErrMismatch, worker, k, got, ok, want, wantOK)
+			}
+			c.lookups.Add(1)
+		}
+	}
+	return nil
+}
+
+// check 는 모든 모델의 키를 Get 으로 다시 읽고, 전체 Scan 의 키 수가 모델 크기의 합과 같은지 본다.
+func (t *lsmTarget) check() (int, error) {
+	total := 0
+	for w, model := range t.models {
+		for k, want := range model {
+			got, ok, err := t.db.Get(encodeKey(k))
+			if err != nil {
+				return 0, err
+			}
+			if !ok || !bytes.Equal(got, want) {
+				return 0, fmt.Errorf("%w: worker %d: key %d = %q (found %v), want %q", ErrMismatch, w, k, got, ok, want)
+			}
+		}
+		total += len(model)
+	}
+
+	scanned := 0
+	if err := scanInOrder(t.db, nil, nil, func() { scanned++ }); err != nil {
+		return 0, err
+	}
+	if scanned != total {
+		return 0, fmt.Errorf("%w: scan found %d keys, workers hold %d", ErrMismatch, scanned, total)
+	}
+	return total, nil
+}
+
+// scanInOrder 는 lo ~ hi 를 Scan 하면서 키가 엄격하게 오름차순인지 본다. each 는 키마다 불린다 (nil 이면 부르지 않음).
+func scanInOrder(db *lsm.DB, lo, hi []byte, each func()) error {
+	var prev []byte
+	var order error
+	err := db.Scan(lo, hi, func(key, _ []byte) bool {
+		if prev != nil && bytes.Compare(prev, key) >= 0 {
+			order = fmt.Errorf("%w: scan returned %x after %x", ErrMismatch, key, prev)
+			return false
+		}
+		prev = append(prev[:0], key...)
+		if each != nil {
+			each()
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	return order
+}
+
+func (t *lsmTarget) close() error {
+	err := t.db.Close()
+	if t.tempDir {
+		os.RemoveAll(t.dir)
+	}
+	return err
+}
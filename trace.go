@@ -0,0 +1,151 @@
+package btree
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ==================================
+// 연산 추적
+// ==================================
+// InsertTrace / SearchTrace / DeleteTrace 는 연산 하나 동안 방문한 노드, 키 비교, 분할이나 합치기 같은 구조 변경을 차례로 기록한다.
+// 노드 이름은 웹 UI 와 같은 경로 이름("root", "root-0-2")이고, 그 순간의 트리 모양 기준이다.
+// 기록기는 연산마다 새로 만들고, 추적하지 않는 Insert 는 nil 기록기로 같은 코드를 지난다.
+
+type TraceKind string
+
+const (
+	TraceVisit   TraceKind = "visit"    // 노드에 들어왔다 (Keys 는 그때의 키)
+	TraceCompare TraceKind = "compare"  // Key 를 keys[Index] 와 비교했다. Result 는 -1 / 0 / 1
+	TraceSplit   TraceKind = "split"    // Node 의 children[Index] 를 Key 를 가운데로 나눴다
+	TraceInsert  TraceKind = "insert"   // 잎 Node 의 keys[Index] 에 Key 를 넣었다
+	TraceUpdate  TraceKind = "update"   // 이미 있던 Node 의 keys[Index] 의 값을 바꿨다
+	TraceNewRoot TraceKind = "new-root" // 루트가 새로 생겼다 (빈 트리의 첫 키, 또는 루트 분할로 높이 +1)
+	TraceDelete  TraceKind = "delete"   // 잎 Node 의 keys[Index] 에 있던 Key 를 뺐다
+	TraceReplace TraceKind = "replace"  // Node 의 keys[Index] 를 predecessor(Result -1) / successor(Result 1) Key 로 바꿨다
+	TraceBorrow  TraceKind = "borrow"   // Node 의 children[Index] 가 왼쪽(Result -1) / 오른쪽(Result 1) 형제에게서 키를 빌렸다. Key 는 부모로 올라간 키
+	TraceMerge   TraceKind = "merge"    // Node 의 children[Index] 와 children[Index+1] 을 Key 를 가운데 두고 합쳤다
+	TraceShrink  TraceKind = "shrink"   // 키가 다 빠진 루트를 버리고 그 자식이 루트가 됐다 (높이 -1). Keys 는 새 루트의 키
+)
+
+type TraceEvent struct {
+	Kind   TraceKind `json:"kind"`
+	Node   string    `json:"node"`
+	Keys   []int     `json:"keys,omitempty"`
+	Index  int       `json:"index"`
+	Key    int       `json:"key"`
+	Result int       `json:"result"`
+}
+
+// Trace 는 연산 하나의 기록. Found 는 search 면 찾았는지, delete 면 지웠는지, insert 면 이미 있던 키의 값만 바꿨는지다.
+// Path 는 내려간 길만 간추린 것이고 (path.go), Events 는 그 사이의 비교와 분할까지 담는다.
+type Trace struct {
+	Op     string       `json:"op"`
+	Key    int          `json:"key"`
+	Found  bool         `json:"found"`
+	Path   Path         `json:"path"`
+	Events []TraceEvent `json:"events"`
+}
+
+// Visited 는 방문한 노드 이름을 순서대로 돌려준다.
+func (tr *Trace) Visited() []string {
+	return tr.Path.Labels()
+}
+
+// Comparisons 는 키 비교 횟수
+func (tr *Trace) Comparisons() int {
+	n := 0
+	for _, e := range tr.Events {
+		if e.Kind == TraceCompare {
+			n++
+		}
+	}
+	return n
+}
+
+// String 은 CLI 에 찍을 한 줄에 이벤트 하나짜리 설명
+func (tr *Trace) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %d: %d nodes, %d comparisons", tr.Op, tr.Key, len(tr.Path), tr.Comparisons())
+	switch tr.Op {
+	case "search", "delete":
+		fmt.Fprintf(&sb, ", found=%v", tr.Found)
+	case "insert":
+		fmt.Fprintf(&sb, ", replaced=%v", tr.Found)
+	}
+	sb.WriteByte('\n')
+	if len(tr.Path) > 0 {
+		fmt.Fprintf(&sb, "  path     %s\n", tr.Path)
+	}
+	for _, e := range tr.Events {
+		switch e.Kind {
+		case TraceVisit:
+			fmt.Fprintf(&sb, "  visit    %s %v\n", e.Node, e.Keys)
+		case TraceCompare:
+			fmt.Fprintf(&sb, "  compare  %d with keys[%d]=%d -> %s\n", tr.Key, e.Index, e.Key, [...]string{"<", "=", ">"}[e.Result+1])
+		case TraceSplit:
+			fmt.Fprintf(&sb, "  split    %s child %d around %d\n", e.Node, e.Index, e.Key)
+		case TraceInsert:
+			fmt.Fprintf(&sb, "  insert   %d into %s at %d\n", e.Key, e.Node, e.Index)
+		case TraceUpdate:
+			fmt.Fprintf(&sb, "  update   %d in %s at %d\n", e.Key, e.Node, e.Index)
+		case TraceNewRoot:
+			fmt.Fprintf(&sb, "  new-root %v\n", e.Keys)
+		case TraceDelete:
+			fmt.Fprintf(&sb, "  delete   %d from %s at %d\n", e.Key, e.Node, e.Index)
+		case TraceReplace:
+			fmt.Fprintf(&sb, "  replace  %s keys[%d] with %s %d\n", e.Node, e.Index, [...]string{"predecessor", "", "successor"}[e.Result+1], e.Key)
+		case TraceBorrow:
+			fmt.Fprintf(&sb, "  borrow   %s child %d from its %s sibling, %d moves up\n", e.Node, e.Index, [...]string{"left", "", "right"}[e.Result+1], e.Key)
+		case TraceMerge:
+			fmt.Fprintf(&sb, "  merge    %s children %d and %d around %d\n", e.Node, e.Index, e.Index+1, e.Key)
+		case TraceShrink:
+			fmt.Fprintf(&sb, "  shrink   root is now %v\n", e.Keys)
+		}
+	}
+	return sb.String()
+}
+
+// 아래 메서드는 tr 가 nil 이면 아무것도 하지 않는다.
+
+func (tr *Trace) add(e TraceEvent) {
+	if tr != nil {
+		tr.Events = append(tr.Events, e)
+	}
+}
+
+// step 은 경로에 한 단계를 더한다. index 는 지금 노드가 부모의 몇 번째 자식인지.
+func (tr *Trace) step(index, keyIndex int, dir Direction) {
+	if tr != nil {
+		tr.Path = append(tr.Path, PathStep{NodeIndex: index, KeyIndex: keyIndex, Direction: dir})
+	}
+}
+
+func (tr *Trace) visit(node string, x *BTreeNode) {
+	if tr != nil {
+		tr.add(TraceEvent{Kind: TraceVisit, Node: node, Keys: append([]int(nil), x.keys...)})
+	}
+}
+
+// compare 는 k 와 keys[i] 의 비교를 기록한다.
+func (tr *Trace) compare(node string, x *BTreeNode, i, k int) {
+	if tr == nil {
+		return
+	}
+	result := 0
+	switch {
+	case k < x.keys[i]:
+		result = -1
+	case k > x.keys[i]:
+		result = 1
+	}
+	tr.add(TraceEvent{Kind: TraceCompare, Node: node, Index: i, Key: x.keys[i], Result: result})
+}
+
+// child 는 node 의 i 번째 자식 이름. 추적하지 않을 때는 문자열을 만들지 않는다.
+func (tr *Trace) child(node string, i int) string {
+	if tr == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s-%d", node, i)
+}
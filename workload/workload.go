@@ -0,0 +1,181 @@
+// Package workload 는 벤치마크에 쓸 키 / 연산 스트림을 만든다.
+// 순차(sequential), 균등 무작위(uniform), 지프(zipfian) 분포를 지원하고,
+// 같은 Config(특히 Seed)로 만들면 언제나 같은 순서를 돌려주므로
+// 여러 저장소 / 트리를 똑같은 접근 패턴으로 비교할 수 있다.
+package workload
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand"
+)
+
+// Distribution 은 키를 어떤 분포로 뽑을지 정한다.
+type Distribution int
+
+const (
+	// Sequential: 0, 1, 2, ... KeySpace-1 을 차례로 돌고 다시 0 부터
+	Sequential Distribution = iota
+	// Uniform: [0, KeySpace) 에서 균등하게
+	Uniform
+	// Zipfian: 작은 키일수록 자주 나온다. 키 k 의 빈도는 대략 1/(k+1)^ZipfS 에 비례한다.
+	Zipfian
+)
+
+var distributionNames = map[Distribution]string{
+	Sequential: "sequential",
+	Uniform:    "uniform",
+	Zipfian:    "zipfian",
+}
+
+func (d Distribution) String() string {
+	if name, ok := distributionNames[d]; ok {
+		return name
+	}
+	return fmt.Sprintf("Distribution(%d)", int(d))
+}
+
+// ParseDistribution 은 플래그 값("sequential", "uniform", "zipfian")을 Distribution 으로 바꾼다.
+func ParseDistribution(s string) (Distribution, error) {
+	for d, name := range distributionNames {
+		if name == s {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown distribution %q", s)
+}
+
+// OpKind 는 연산 스트림의 연산 종류
+type OpKind int
+
+const (
+	Insert OpKind = iota
+	Lookup
+)
+
+func (k OpKind) String() string {
+	switch k {
+	case Insert:
+		return "insert"
+	case Lookup:
+		return "lookup"
+	}
+	return fmt.Sprintf("OpKind(%d)", int(k))
+}
+
+type Op struct {
+	Kind OpKind
+	Key  uint32
+}
+
+// 지프 분포의 기본 기울기. math/rand 의 Zipf 는 s > 1 이어야 한다.
+const DefaultZipfS = 1.1
+
+// Config 는 스트림의 모양을 정한다.
+// - KeySpace: 키 범위 [0, KeySpace)
+// - Seed: 같은 Seed 면 같은 스트림 (Sequential 은 Seed 와 무관). 0 도 그냥 씨앗 하나다. 무작위로 고르려면 RandomSeed 를 쓴다.
+// - ZipfS: Zipfian 의 기울기. 0 이면 DefaultZipfS
+// - LookupRatio: Next 가 Lookup 을 낼 확률 (0~1). 나머지는 Insert
+type Config struct {
+	Distribution Distribution
+	KeySpace     int
+	Seed         int64
+	ZipfS        float64
+	LookupRatio  float64
+}
+
+var ErrEmptyKeySpace = errors.New("workload: key space must be positive")
+
+// RandomSeed 는 새 씨앗 하나를 무작위로 고른다. 고른 값을 출력 / 응답에 같이 실어 두면 같은 스트림을 다시 만들 수 있다.
+// 플래그나 요청에서 "0 이면 무작위" 로 받을 수 있도록 0 은 돌려주지 않고,
+// JSON 으로 주고받아도 (JavaScript 의 number) 값이 바뀌지 않도록 2^53 보다 작다.
+func RandomSeed() int64 {
+	var buf [8]byte
+	crand.Read(buf[:]) // Go 1.24 부터 실패하면 돌아오지 않고 프로그램을 멈춘다.
+	return int64(binary.BigEndian.Uint64(buf[:])%(1<<53-1)) + 1
+}
+
+// Generator 는 Config 대로 키 / 연산을 하나씩 만든다. 여러 고루틴에서 같이 쓰면 안 된다.
+type Generator struct {
+	cfg  Config
+	rng  *rand.Rand // 키 분포용
+	mix  *rand.Rand // 연산 종류용. 키 순서가 LookupRatio 에 따라 바뀌지 않도록 따로 둔다.
+	zipf *rand.Zipf
+	next int // Sequential 의 다음 키
+}
+
+func New(cfg Config) (*Generator, error) {
+	if cfg.KeySpace <= 0 {
+		return nil, ErrEmptyKeySpace
+	}
+	if cfg.LookupRatio < 0 || cfg.LookupRatio > 1 {
+		return nil, fmt.Errorf("workload: lookup ratio %v out of range [0, 1]", cfg.LookupRatio)
+	}
+	if cfg.ZipfS == 0 {
+		cfg.ZipfS = DefaultZipfS
+	}
+
+	g := &Generator{
+		cfg: cfg,
+		rng: rand.New(rand.NewSource(cfg.Seed)),
+		mix: rand.New(rand.NewSource(cfg.Seed + 1)),
+	}
+	switch cfg.Distribution {
+	case Sequential, Uniform:
+	case Zipfian:
+		if cfg.ZipfS <= 1 {
+			return nil, fmt.Errorf("workload: zipf exponent must be > 1, got %v", cfg.ZipfS)
+		}
+		g.zipf = rand.NewZipf(g.rng, cfg.ZipfS, 1, uint64(cfg.KeySpace-1))
+	default:
+		return nil, fmt.Errorf("workload: unknown distribution %v", cfg.Distribution)
+	}
+	return g, nil
+}
+
+// Seed 는 이 Generator 를 만든 씨앗. 같은 Config 에 이 값을 넣으면 같은 스트림이 나온다.
+func (g *Generator) Seed() int64 {
+	return g.cfg.Seed
+}
+
+// NextKey 는 분포에 따라 다음 키를 돌려준다.
+func (g *Generator) NextKey() uint32 {
+	switch g.cfg.Distribution {
+	case Uniform:
+		return uint32(g.rng.Intn(g.cfg.KeySpace))
+	case Zipfian:
+		return uint32(g.zipf.Uint64())
+	}
+	key := g.next
+	g.next = (g.next + 1) % g.cfg.KeySpace
+	return uint32(key)
+}
+
+// Next 는 LookupRatio 로 연산 종류를 고르고 NextKey 로 키를 붙인다.
+func (g *Generator) Next() Op {
+	kind := Insert
+	if g.mix.Float64() < g.cfg.LookupRatio {
+		kind = Lookup
+	}
+	return Op{Kind: kind, Key: g.NextKey()}
+}
+
+// Keys 는 NextKey 를 n 번 부른 결과
+func (g *Generator) Keys(n int) []uint32 {
+	keys := make([]uint32, n)
+	for i := range keys {
+		keys[i] = g.NextKey()
+	}
+	return keys
+}
+
+// Ops 는 Next 를 n 번 부른 결과
+func (g *Generator) Ops(n int) []Op {
+	ops := make([]Op, n)
+	for i := range ops {
+		ops[i] = g.Next()
+	}
+	return ops
+}